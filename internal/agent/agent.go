@@ -11,18 +11,18 @@ import (
 	"cmp"
 	"context"
 	_ "embed"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
-	"os"
-	"strconv"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"charm.land/fantasy"
 	"charm.land/fantasy/providers/anthropic"
-	"charm.land/fantasy/providers/bedrock"
+	"charm.land/fantasy/providers/azure"
 	"charm.land/fantasy/providers/google"
 	"charm.land/fantasy/providers/openai"
 	"charm.land/fantasy/providers/openrouter"
@@ -34,6 +34,7 @@ import (
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/stringext"
+	"github.com/charmbracelet/crush/internal/telemetry"
 )
 
 //go:embed templates/title.md
@@ -53,12 +54,34 @@ type SessionAgentCall struct {
 	TopK             *int64
 	FrequencyPenalty *float64
 	PresencePenalty  *float64
+	// Model replaces the large model for this call only.
+	Model *Model
+
+	// streamRetries counts how many times this call was sent again after
+	// the provider stream dropped. A retried call adds nothing to the
+	// conversation: it streams the next step of what's already there.
+	streamRetries int
+	// continuesPartial tells that the retried call follows a partial
+	// response kept in the conversation, which the model is asked to
+	// continue.
+	continuesPartial bool
 }
 
+// ContinuePrompt asks the model to pick up an interrupted response where it
+// stopped: in the request of a retry that follows a partial response, and
+// when the user asks for it.
+const ContinuePrompt = "Your previous response was cut off by a connection error. Continue exactly where you left off, without repeating what you already wrote."
+
+// maxStreamRetries is how many times a call is automatically sent again after
+// its stream drops before giving up and leaving it to the user.
+const maxStreamRetries = 1
+
 type SessionAgent interface {
 	Run(context.Context, SessionAgentCall) (*fantasy.AgentResult, error)
 	SetModels(large Model, small Model)
 	SetTools(tools []fantasy.AgentTool)
+	SetDisableAutoSummarize(disabled bool)
+	SetCompaction(compaction *config.Compaction)
 	Cancel(sessionID string)
 	CancelAll()
 	IsSessionBusy(sessionID string) bool
@@ -66,13 +89,17 @@ type SessionAgent interface {
 	QueuedPrompts(sessionID string) int
 	ClearQueue(sessionID string)
 	Summarize(context.Context, string, fantasy.ProviderOptions) error
+	GenerateObject(context.Context, string, *OutputSchema, fantasy.ProviderOptions) (json.RawMessage, error)
 	Model() Model
+	ContextPreview(ctx context.Context, sessionID string) (ContextPreview, error)
 }
 
 type Model struct {
 	Model      fantasy.LanguageModel
 	CatwalkCfg catwalk.Model
 	ModelCfg   config.SelectedModel
+	// Reasoning replaces the reasoning configuration of ModelCfg when set.
+	Reasoning ReasoningLevel
 }
 
 type sessionAgent struct {
@@ -84,6 +111,7 @@ type sessionAgent struct {
 	sessions             session.Service
 	messages             message.Service
 	disableAutoSummarize bool
+	compaction           *config.Compaction
 	isYolo               bool
 
 	messageQueue   *csync.Map[string, []SessionAgentCall]
@@ -96,6 +124,7 @@ type SessionAgentOptions struct {
 	SystemPromptPrefix   string
 	SystemPrompt         string
 	DisableAutoSummarize bool
+	Compaction           *config.Compaction
 	IsYolo               bool
 	Sessions             session.Service
 	Messages             message.Service
@@ -113,6 +142,7 @@ func NewSessionAgent(
 		sessions:             opts.Sessions,
 		messages:             opts.Messages,
 		disableAutoSummarize: opts.DisableAutoSummarize,
+		compaction:           opts.Compaction,
 		tools:                opts.Tools,
 		isYolo:               opts.IsYolo,
 		messageQueue:         csync.NewMap[string, []SessionAgentCall](),
@@ -139,13 +169,24 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		return nil, nil
 	}
 
+	largeModel := a.largeModel
+	if call.Model != nil {
+		largeModel = *call.Model
+	}
+
+	quirks := modelQuirks(largeModel)
+	cache := promptCache()
 	if len(a.tools) > 0 {
 		// Add Anthropic caching to the last tool.
-		a.tools[len(a.tools)-1].SetProviderOptions(a.getCacheControlOptions())
+		toolOptions := fantasy.ProviderOptions{}
+		if _, tools, _ := cache.CacheBreakpoints(); tools {
+			toolOptions = cacheControlOptions()
+		}
+		a.tools[len(a.tools)-1].SetProviderOptions(toolOptions)
 	}
 
 	agent := fantasy.NewAgent(
-		a.largeModel.Model,
+		largeModel.Model,
 		fantasy.WithSystemPrompt(a.systemPrompt),
 		fantasy.WithTools(a.tools...),
 	)
@@ -163,7 +204,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 
 	var wg sync.WaitGroup
 	// Generate title if first message.
-	if len(msgs) == 0 {
+	if len(msgs) == 0 && call.streamRetries == 0 {
 		wg.Go(func() {
 			sessionLock.Lock()
 			a.generateTitle(ctx, &currentSession, call.Prompt)
@@ -171,33 +212,60 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		})
 	}
 
-	// Add the user message to the session.
-	_, err = a.createUserMessage(ctx, call)
-	if err != nil {
-		return nil, err
+	// Add the user message to the session, unless it's already there as
+	// the call is retried.
+	if call.streamRetries == 0 {
+		_, err = a.createUserMessage(ctx, call)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	// Add the session to the context.
+	// Add the session to the context, with the one that started it when
+	// it's a sub-agent session.
+	ctx = context.WithValue(ctx, tools.RootSessionIDContextKey, cmp.Or(tools.GetRootSessionFromContext(ctx), call.SessionID))
 	ctx = context.WithValue(ctx, tools.SessionIDContextKey, call.SessionID)
 
-	genCtx, cancel := context.WithCancel(ctx)
+	telemetryModel := telemetry.Model{Provider: largeModel.ModelCfg.Provider, Model: largeModel.ModelCfg.Model}
+	runCtx, run := telemetry.StartRun(ctx, call.SessionID, telemetryModel)
+
+	genCtx, cancel := context.WithCancel(runCtx)
 	a.activeRequests.Set(call.SessionID, cancel)
 
 	defer cancel()
 	defer a.activeRequests.Del(call.SessionID)
 
 	history, files := a.preparePrompt(msgs, call.Attachments...)
+	// The prompt of a retried call is in the history already, and the one
+	// the agent adds after it is left out, or replaced with ContinuePrompt
+	// after a partial response: providers don't continue a trailing
+	// assistant message. It's only sent, not added to the conversation.
+	promptIndex := -1
+	if call.streamRetries > 0 {
+		promptIndex = len(history)
+		if a.systemPrompt != "" {
+			promptIndex++
+		}
+	}
 
 	startTime := time.Now()
 	a.eventPromptSent(call.SessionID)
 
 	var currentAssistant *message.Message
+	// stepStart and firstToken time the current step, for its usage.
+	var stepStart, firstToken time.Time
+	markFirstToken := func() {
+		if firstToken.IsZero() {
+			firstToken = time.Now()
+		}
+	}
+	var providerCall *telemetry.ProviderCall
 	var shouldSummarize bool
 	result, err := agent.Stream(genCtx, fantasy.AgentStreamCall{
 		Prompt:           call.Prompt,
 		Files:            files,
 		Messages:         history,
-		ProviderOptions:  call.ProviderOptions,
+		ProviderOptions:  withPromptCacheOptions(cache, call.ProviderOptions, call.SessionID),
 		MaxOutputTokens:  &call.MaxOutputTokens,
 		TopP:             call.TopP,
 		Temperature:      call.Temperature,
@@ -207,6 +275,14 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		// Before each step create a new assistant message.
 		PrepareStep: func(callContext context.Context, options fantasy.PrepareStepFunctionOptions) (_ context.Context, prepared fantasy.PrepareStepResult, err error) {
 			prepared.Messages = options.Messages
+			if promptIndex >= 0 && promptIndex < len(prepared.Messages) {
+				prepared.Messages = slices.Clone(prepared.Messages)
+				if call.continuesPartial {
+					prepared.Messages[promptIndex] = fantasy.NewUserMessage(ContinuePrompt)
+				} else {
+					prepared.Messages = slices.Delete(prepared.Messages, promptIndex, promptIndex+1)
+				}
+			}
 			// Reset all cached items.
 			for i := range prepared.Messages {
 				prepared.Messages[i].ProviderOptions = nil
@@ -222,41 +298,40 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				prepared.Messages = append(prepared.Messages, userMessage.ToAIMessage()...)
 			}
 
-			lastSystemRoleInx := 0
-			systemMessageUpdated := false
-			for i, msg := range prepared.Messages {
-				// Only add cache control to the last message.
-				if msg.Role == fantasy.MessageRoleSystem {
-					lastSystemRoleInx = i
-				} else if !systemMessageUpdated {
-					prepared.Messages[lastSystemRoleInx].ProviderOptions = a.getCacheControlOptions()
-					systemMessageUpdated = true
-				}
-				// Than add cache control to the last 2 messages.
-				if i > len(prepared.Messages)-3 {
-					prepared.Messages[i].ProviderOptions = a.getCacheControlOptions()
-				}
+			if a.compaction != nil {
+				prepared.Messages = synopsizeToolResults(prepared.Messages, a.compaction.SynopsisAfterTurns)
+			}
+			if cw := int64(largeModel.CatwalkCfg.ContextWindow); cw > 0 {
+				budget := int(cw - compactionThreshold(cw))
+				prepared.Messages = compactMessages(a.compaction.GetStrategy(), prepared.Messages, budget)
 			}
 
-			if promptPrefix := a.promptPrefix(); promptPrefix != "" {
+			markCacheBreakpoints(cache, prepared.Messages)
+
+			if promptPrefix := a.promptPrefix(largeModel); promptPrefix != "" {
 				prepared.Messages = append([]fantasy.Message{fantasy.NewSystemMessage(promptPrefix)}, prepared.Messages...)
 			}
+			prepared.Messages = applyMessageQuirks(quirks, prepared.Messages)
 
 			var assistantMsg message.Message
 			assistantMsg, err = a.messages.Create(callContext, call.SessionID, message.CreateMessageParams{
 				Role:     message.Assistant,
 				Parts:    []message.ContentPart{},
-				Model:    a.largeModel.ModelCfg.Model,
-				Provider: a.largeModel.ModelCfg.Provider,
+				Model:    largeModel.ModelCfg.Model,
+				Provider: largeModel.ModelCfg.Provider,
 			})
 			if err != nil {
 				return callContext, prepared, err
 			}
 			callContext = context.WithValue(callContext, tools.MessageIDContextKey, assistantMsg.ID)
 			currentAssistant = &assistantMsg
+			stepStart, firstToken = time.Now(), time.Time{}
+			providerCall = telemetry.StartProviderCall(genCtx, telemetryModel)
+			callContext = providerCall.Context(callContext)
 			return callContext, prepared, err
 		},
 		OnReasoningStart: func(id string, reasoning fantasy.ReasoningContent) error {
+			markFirstToken()
 			currentAssistant.AppendReasoningContent(reasoning.Text)
 			return a.messages.Update(genCtx, *currentAssistant)
 		},
@@ -285,6 +360,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			return a.messages.Update(genCtx, *currentAssistant)
 		},
 		OnTextDelta: func(id string, text string) error {
+			markFirstToken()
 			// Strip leading newline from initial text content. This is is
 			// particularly important in non-interactive mode where leading
 			// newlines are very visible.
@@ -296,6 +372,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			return a.messages.Update(genCtx, *currentAssistant)
 		},
 		OnToolInputStart: func(id string, toolName string) error {
+			markFirstToken()
 			toolCall := message.ToolCall{
 				ID:               id,
 				Name:             toolName,
@@ -366,7 +443,27 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				finishReason = message.FinishReasonToolUse
 			}
 			currentAssistant.AddFinish(finishReason, "", "")
-			a.updateSessionUsage(a.largeModel, &currentSession, stepResult.Usage, a.openrouterCost(stepResult.ProviderMetadata))
+			overrideCost := a.openrouterCost(stepResult.ProviderMetadata)
+			a.updateSessionUsage(largeModel, &currentSession, stepResult.Usage, overrideCost)
+			stepCost := usageCost(largeModel, stepResult.Usage)
+			if overrideCost != nil {
+				stepCost = *overrideCost
+			}
+			usage := message.Usage{
+				InputTokens:         stepResult.Usage.InputTokens,
+				OutputTokens:        stepResult.Usage.OutputTokens,
+				ReasoningTokens:     stepResult.Usage.ReasoningTokens,
+				CacheReadTokens:     stepResult.Usage.CacheReadTokens,
+				CacheCreationTokens: stepResult.Usage.CacheCreationTokens,
+				Cost:                stepCost,
+				DurationMS:          time.Since(stepStart).Milliseconds(),
+			}
+			if !firstToken.IsZero() {
+				usage.FirstTokenMS = firstToken.Sub(stepStart).Milliseconds()
+			}
+			currentAssistant.SetUsage(usage)
+			providerCall.End(stepResult.Usage, stepCost, nil)
+			providerCall = nil
 			sessionLock.Lock()
 			_, sessionErr := a.sessions.Save(genCtx, currentSession)
 			sessionLock.Unlock()
@@ -377,16 +474,10 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		},
 		StopWhen: []fantasy.StopCondition{
 			func(_ []fantasy.StepResult) bool {
-				cw := int64(a.largeModel.CatwalkCfg.ContextWindow)
+				cw := int64(largeModel.CatwalkCfg.ContextWindow)
 				tokens := currentSession.CompletionTokens + currentSession.PromptTokens
 				remaining := cw - tokens
-				var threshold int64
-				if cw > 200_000 {
-					threshold = 20_000
-				} else {
-					threshold = int64(float64(cw) * 0.2)
-				}
-				if (remaining <= threshold) && !a.disableAutoSummarize {
+				if remaining <= compactionThreshold(cw) && !a.disableAutoSummarize && summarizes(a.compaction.GetStrategy()) {
 					shouldSummarize = true
 					return true
 				}
@@ -396,6 +487,11 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	})
 
 	a.eventPromptResponded(call.SessionID, time.Since(startTime).Truncate(time.Second))
+	if providerCall != nil {
+		// The step failed before it finished.
+		providerCall.End(fantasy.Usage{}, 0, err)
+	}
+	run.End(err)
 
 	if err != nil {
 		isCancelErr := errors.Is(err, context.Canceled)
@@ -403,6 +499,11 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		if currentAssistant == nil {
 			return result, err
 		}
+		// Keep whatever was streamed before the connection dropped.
+		isInterrupted := isStreamInterrupted(err) && hasPartialContent(currentAssistant)
+		// A stream that stalled before anything came can be sent again to
+		// any provider, as the request is the same.
+		stalledEarly := isStreamStalled(err) && !hasPartialContent(currentAssistant)
 		// Ensure we finish thinking on error to close the reasoning state.
 		currentAssistant.FinishThinking()
 		toolCalls := currentAssistant.ToolCalls()
@@ -468,6 +569,10 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			currentAssistant.AddFinish(message.FinishReasonCanceled, "User canceled request", "")
 		} else if isPermissionErr {
 			currentAssistant.AddFinish(message.FinishReasonPermissionDenied, "User denied permission", "")
+		} else if isInterrupted {
+			currentAssistant.AddFinish(message.FinishReasonInterrupted, "Connection lost", err.Error())
+		} else if stalledEarly {
+			currentAssistant.AddFinish(message.FinishReasonInterrupted, "Provider stalled", err.Error())
 		} else if errors.As(err, &providerErr) {
 			currentAssistant.AddFinish(message.FinishReasonError, cmp.Or(stringext.Capitalize(providerErr.Title), defaultTitle), providerErr.Message)
 		} else if errors.As(err, &fantasyErr) {
@@ -481,6 +586,22 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		if updateErr != nil {
 			return nil, updateErr
 		}
+		if (isInterrupted && canResumeStream(largeModel) || stalledEarly) && call.streamRetries < maxStreamRetries {
+			slog.Warn("Provider stream interrupted, retrying", "session_id", call.SessionID, "error", err)
+			if stalledEarly {
+				// Nothing came of it, for the retry to take its place.
+				if deleteErr := a.messages.Delete(ctx, currentAssistant.ID); deleteErr != nil {
+					return nil, deleteErr
+				}
+			}
+			a.activeRequests.Del(call.SessionID)
+			cancel()
+			retry := call
+			retry.Attachments = nil
+			retry.streamRetries++
+			retry.continuesPartial = !stalledEarly
+			return a.Run(ctx, retry)
+		}
 		return nil, err
 	}
 	wg.Wait()
@@ -534,6 +655,17 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 		return nil
 	}
 
+	// The hybrid strategy keeps the latest turns out of the summary, to be
+	// sent along with it as they are.
+	var parts []message.ContentPart
+	if a.compaction.GetStrategy() == config.CompactionHybrid {
+		retain := a.compaction.GetRetainTokens(int64(a.largeModel.CatwalkCfg.ContextWindow))
+		if start := retainedStart(msgs, retain); start < len(msgs) {
+			parts = append(parts, message.Retained{MessageID: msgs[start].ID})
+			msgs = msgs[:start]
+		}
+	}
+
 	aiMsgs, _ := a.preparePrompt(msgs)
 
 	genCtx, cancel := context.WithCancel(ctx)
@@ -541,11 +673,13 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 	defer a.activeRequests.Del(sessionID)
 	defer cancel()
 
+	quirks := modelQuirks(a.largeModel)
 	agent := fantasy.NewAgent(a.largeModel.Model,
 		fantasy.WithSystemPrompt(string(summaryPrompt)),
 	)
 	summaryMessage, err := a.messages.Create(ctx, sessionID, message.CreateMessageParams{
 		Role:             message.Assistant,
+		Parts:            parts,
 		Model:            a.largeModel.Model.Model(),
 		Provider:         a.largeModel.Model.Provider(),
 		IsSummaryMessage: true,
@@ -563,6 +697,7 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 			if a.systemPromptPrefix != "" {
 				prepared.Messages = append([]fantasy.Message{fantasy.NewSystemMessage(a.systemPromptPrefix)}, prepared.Messages...)
 			}
+			prepared.Messages = applyMessageQuirks(quirks, prepared.Messages)
 			return callContext, prepared, nil
 		},
 		OnReasoningDelta: func(id string, text string) error {
@@ -585,15 +720,18 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 		},
 	})
 	if err != nil {
-		isCancelErr := errors.Is(err, context.Canceled)
-		if isCancelErr {
-			// User cancelled summarize we need to remove the summary message.
-			deleteErr := a.messages.Delete(ctx, summaryMessage.ID)
+		// Remove the partial summary, so that it's neither shown nor sent to
+		// the model with the session. A cancelled summary isn't an error.
+		deleteErr := a.messages.Delete(context.WithoutCancel(ctx), summaryMessage.ID)
+		if errors.Is(err, context.Canceled) || genCtx.Err() != nil {
 			return deleteErr
 		}
 		return err
 	}
 
+	if text := summaryMessage.Content().Text; quirks.StripThinkTags && stripThinkTags(text) != text {
+		summaryMessage.SetContent(strings.TrimSpace(stripThinkTags(text)))
+	}
 	summaryMessage.AddFinish(message.FinishReasonEndTurn, "", "")
 	err = a.messages.Update(genCtx, summaryMessage)
 	if err != nil {
@@ -623,20 +761,6 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 	return err
 }
 
-func (a *sessionAgent) getCacheControlOptions() fantasy.ProviderOptions {
-	if t, _ := strconv.ParseBool(os.Getenv("CRUSH_DISABLE_ANTHROPIC_CACHE")); t {
-		return fantasy.ProviderOptions{}
-	}
-	return fantasy.ProviderOptions{
-		anthropic.Name: &anthropic.ProviderCacheControlOptions{
-			CacheControl: anthropic.CacheControl{Type: "ephemeral"},
-		},
-		bedrock.Name: &anthropic.ProviderCacheControlOptions{
-			CacheControl: anthropic.CacheControl{Type: "ephemeral"},
-		},
-	}
-}
-
 func (a *sessionAgent) createUserMessage(ctx context.Context, call SessionAgentCall) (message.Message, error) {
 	var attachmentParts []message.ContentPart
 	for _, attachment := range call.Attachments {
@@ -665,6 +789,10 @@ func (a *sessionAgent) preparePrompt(msgs []message.Message, attachments ...mess
 		if m.Role == message.Assistant && len(m.ToolCalls()) == 0 && m.Content().Text == "" && m.ReasoningContent().String() == "" {
 			continue
 		}
+		// Comparisons are for the user to read, not the model.
+		if isComparison(m) {
+			continue
+		}
 		history = append(history, m.ToAIMessage()...)
 	}
 
@@ -695,8 +823,10 @@ func (a *sessionAgent) getSessionMessages(ctx context.Context, session session.S
 			}
 		}
 		if summaryMsgInex != -1 {
-			msgs = msgs[summaryMsgInex:]
-			msgs[0].Role = message.User
+			summary := msgs[summaryMsgInex]
+			summary.Role = message.User
+			retained := retainedMessages(msgs[:summaryMsgInex], summary.RetainedFrom())
+			msgs = append(append([]message.Message{summary}, retained...), msgs[summaryMsgInex+1:]...)
 		}
 	}
 	return msgs, nil
@@ -707,23 +837,32 @@ func (a *sessionAgent) generateTitle(ctx context.Context, session *session.Sessi
 		return
 	}
 
+	quirks := modelQuirks(a.smallModel)
 	var maxOutput int64 = 40
 	if a.smallModel.CatwalkCfg.CanReason {
 		maxOutput = a.smallModel.CatwalkCfg.DefaultMaxTokens
 	}
+	maxOutput = max(maxOutput, quirks.MinTitleTokens)
 
+	systemPrompt := string(titlePrompt)
+	userPrompt := fmt.Sprintf("Generate a concise title for the following content:\n\n%s", prompt)
+	if quirks.NoThink {
+		systemPrompt += "\n /no_think"
+		userPrompt += "\n <think>\n\n</think>"
+	}
 	agent := fantasy.NewAgent(a.smallModel.Model,
-		fantasy.WithSystemPrompt(string(titlePrompt)+"\n /no_think"),
+		fantasy.WithSystemPrompt(systemPrompt),
 		fantasy.WithMaxOutputTokens(maxOutput),
 	)
 
 	resp, err := agent.Stream(ctx, fantasy.AgentStreamCall{
-		Prompt: fmt.Sprintf("Generate a concise title for the following content:\n\n%s\n <think>\n\n</think>", prompt),
+		Prompt: userPrompt,
 		PrepareStep: func(callContext context.Context, options fantasy.PrepareStepFunctionOptions) (_ context.Context, prepared fantasy.PrepareStepResult, err error) {
 			prepared.Messages = options.Messages
 			if a.systemPromptPrefix != "" {
 				prepared.Messages = append([]fantasy.Message{fantasy.NewSystemMessage(a.systemPromptPrefix)}, prepared.Messages...)
 			}
+			prepared.Messages = applyMessageQuirks(quirks, prepared.Messages)
 			return callContext, prepared, nil
 		},
 	})
@@ -734,12 +873,10 @@ func (a *sessionAgent) generateTitle(ctx context.Context, session *session.Sessi
 
 	title := resp.Response.Content.Text()
 
-	title = strings.ReplaceAll(title, "\n", " ")
-
-	// Remove thinking tags if present.
-	if idx := strings.Index(title, "</think>"); idx > 0 {
-		title = title[idx+len("</think>"):]
+	if quirks.StripThinkTags {
+		title = stripThinkTags(title)
 	}
+	title = strings.ReplaceAll(title, "\n", " ")
 
 	title = strings.TrimSpace(title)
 	if title == "" {
@@ -783,15 +920,7 @@ func (a *sessionAgent) openrouterCost(metadata fantasy.ProviderMetadata) *float6
 }
 
 func (a *sessionAgent) updateSessionUsage(model Model, session *session.Session, usage fantasy.Usage, overrideCost *float64) {
-	modelConfig := model.CatwalkCfg
-	cost := modelConfig.CostPer1MInCached/1e6*float64(usage.CacheCreationTokens) +
-		modelConfig.CostPer1MOutCached/1e6*float64(usage.CacheReadTokens) +
-		modelConfig.CostPer1MIn/1e6*float64(usage.InputTokens) +
-		modelConfig.CostPer1MOut/1e6*float64(usage.OutputTokens)
-
-	if a.isClaudeCode() {
-		cost = 0
-	}
+	cost := usageCost(model, usage)
 
 	a.eventTokensUsed(session.ID, model, usage, cost)
 
@@ -803,6 +932,22 @@ func (a *sessionAgent) updateSessionUsage(model Model, session *session.Session,
 
 	session.CompletionTokens = usage.OutputTokens + usage.CacheReadTokens
 	session.PromptTokens = usage.InputTokens + usage.CacheCreationTokens
+
+	session.InputTokens += promptTokens(model, usage)
+	session.CacheReadTokens += usage.CacheReadTokens
+	session.CacheCreationTokens += usage.CacheCreationTokens
+}
+
+// usageCost estimates the cost of usage from the prices of the model.
+func usageCost(model Model, usage fantasy.Usage) float64 {
+	if isClaudeCode(model) {
+		return 0
+	}
+	modelConfig := model.CatwalkCfg
+	return modelConfig.CostPer1MInCached/1e6*float64(usage.CacheCreationTokens) +
+		modelConfig.CostPer1MOutCached/1e6*float64(usage.CacheReadTokens) +
+		modelConfig.CostPer1MIn/1e6*float64(usage.InputTokens) +
+		modelConfig.CostPer1MOut/1e6*float64(usage.OutputTokens)
 }
 
 func (a *sessionAgent) Cancel(sessionID string) {
@@ -883,19 +1028,49 @@ func (a *sessionAgent) SetTools(tools []fantasy.AgentTool) {
 	a.tools = tools
 }
 
+func (a *sessionAgent) SetDisableAutoSummarize(disabled bool) {
+	a.disableAutoSummarize = disabled
+}
+
+func (a *sessionAgent) SetCompaction(compaction *config.Compaction) {
+	a.compaction = compaction
+}
+
 func (a *sessionAgent) Model() Model {
 	return a.largeModel
 }
 
-func (a *sessionAgent) promptPrefix() string {
-	if a.isClaudeCode() {
+func (a *sessionAgent) promptPrefix(model Model) string {
+	if isClaudeCode(model) {
 		return "You are Claude Code, Anthropic's official CLI for Claude."
 	}
 	return a.systemPromptPrefix
 }
 
-func (a *sessionAgent) isClaudeCode() bool {
+// canResumeStream reports whether an interrupted response can be continued
+// automatically. The responses API keeps reasoning state in the encrypted
+// content we send back, so the model can reliably pick up mid-answer.
+func canResumeStream(model Model) bool {
+	cfg := config.Get()
+	pc, ok := cfg.Providers.Get(model.ModelCfg.Provider)
+	if !ok {
+		return false
+	}
+	switch pc.Type {
+	case openai.Name, azure.Name:
+		return openai.IsResponsesModel(model.CatwalkCfg.ID)
+	}
+	return false
+}
+
+func hasPartialContent(msg *message.Message) bool {
+	return msg.Content().Text != "" ||
+		msg.ReasoningContent().Thinking != "" ||
+		len(msg.ToolCalls()) > 0
+}
+
+func isClaudeCode(model Model) bool {
 	cfg := config.Get()
-	pc, ok := cfg.Providers.Get(a.largeModel.ModelCfg.Provider)
+	pc, ok := cfg.Providers.Get(model.ModelCfg.Provider)
 	return ok && pc.ID == string(catwalk.InferenceProviderAnthropic) && pc.OAuthToken != nil
 }
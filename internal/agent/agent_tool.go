@@ -29,7 +29,11 @@ func (c *coordinator) agentTool(ctx context.Context) (fantasy.AgentTool, error)
 	if !ok {
 		return nil, errors.New("task agent not configured")
 	}
-	prompt, err := taskPrompt(prompt.WithWorkingDir(c.cfg.WorkingDir()))
+	prompt, err := taskPrompt(
+		prompt.WithWorkingDir(c.cfg.WorkingDir()),
+		prompt.WithSystemPrompt(agentCfg.SystemPrompt),
+		prompt.WithSystemPromptAppend(agentCfg.SystemPromptAppend),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -71,7 +75,7 @@ func (c *coordinator) agentTool(ctx context.Context) (fantasy.AgentTool, error)
 			if !ok {
 				return fantasy.ToolResponse{}, errors.New("model provider not configured")
 			}
-			result, err := agent.Run(ctx, SessionAgentCall{
+			result, err := c.runSubAgent(ctx, call.ID, agent, SessionAgentCall{
 				SessionID:        session.ID,
 				Prompt:           params.Prompt,
 				MaxOutputTokens:  maxTokens,
@@ -81,7 +85,10 @@ func (c *coordinator) agentTool(ctx context.Context) (fantasy.AgentTool, error)
 				TopK:             model.ModelCfg.TopK,
 				FrequencyPenalty: model.ModelCfg.FrequencyPenalty,
 				PresencePenalty:  model.ModelCfg.PresencePenalty,
-			})
+			}, subAgentBudget{})
+			if errors.Is(err, ErrSubAgentCancelled) {
+				return fantasy.NewTextErrorResponse("The user cancelled this agent. Don't start it again unless asked to."), nil
+			}
 			if err != nil {
 				return fantasy.NewTextErrorResponse("error generating response"), nil
 			}
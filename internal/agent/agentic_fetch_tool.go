@@ -13,6 +13,7 @@ import (
 
 	"github.com/charmbracelet/crush/internal/agent/prompt"
 	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/httpext"
 	"github.com/charmbracelet/crush/internal/permission"
 )
 
@@ -56,15 +57,10 @@ var agenticFetchPromptTmpl []byte
 
 func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (fantasy.AgentTool, error) {
 	if client == nil {
-		client = &http.Client{
-			Timeout: 30 * time.Second,
-			Transport: &http.Transport{
-				MaxIdleConns:        100,
-				MaxIdleConnsPerHost: 10,
-				IdleConnTimeout:     90 * time.Second,
-			},
-		}
+		client = httpext.NewPooledClient(30 * time.Second)
 	}
+	opts := c.cfg.Tools.AgenticFetch
+	client = tools.RestrictToAllowedDomains(client, opts)
 
 	return fantasy.NewAgentTool(
 		tools.AgenticFetchToolName,
@@ -74,6 +70,9 @@ func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (
 			if err != nil {
 				return fantasy.NewTextErrorResponse(err.Error()), nil
 			}
+			if err := tools.CheckAllowedDomain(params.URL, opts); err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("Can't fetch %s: %s. See tools.agentic_fetch.allowed_domains in the configuration.", params.URL, err)), nil
+			}
 
 			p := c.permissions.Request(
 				permission.CreatePermissionRequest{
@@ -147,12 +146,12 @@ func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (
 				return fantasy.ToolResponse{}, errors.New("small model provider not configured")
 			}
 
-			webFetchTool := tools.NewWebFetchTool(tmpDir, client)
+			webFetchTool := tools.NewWebFetchTool(tmpDir, client, opts)
 			fetchTools := []fantasy.AgentTool{
 				webFetchTool,
 				tools.NewGlobTool(tmpDir),
 				tools.NewGrepTool(tmpDir),
-				tools.NewViewTool(c.lspClients, c.permissions, tmpDir),
+				tools.NewViewTool(c.lspClients, c.permissions, tmpDir, c.cfg.Tools.View),
 			}
 
 			agent := NewSessionAgent(SessionAgentOptions{
@@ -161,6 +160,7 @@ func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (
 				SystemPromptPrefix:   smallProviderCfg.SystemPromptPrefix,
 				SystemPrompt:         systemPrompt,
 				DisableAutoSummarize: c.cfg.Options.DisableAutoSummarize,
+				Compaction:           c.cfg.Options.Compaction,
 				IsYolo:               c.permissions.SkipRequests(),
 				Sessions:             c.sessions,
 				Messages:             c.messages,
@@ -181,7 +181,7 @@ func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (
 				maxTokens = small.ModelCfg.MaxTokens
 			}
 
-			result, err := agent.Run(ctx, SessionAgentCall{
+			result, runErr := c.runSubAgent(ctx, call.ID, agent, SessionAgentCall{
 				SessionID:        session.ID,
 				Prompt:           fullPrompt,
 				MaxOutputTokens:  maxTokens,
@@ -191,8 +191,13 @@ func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (
 				TopK:             small.ModelCfg.TopK,
 				FrequencyPenalty: small.ModelCfg.FrequencyPenalty,
 				PresencePenalty:  small.ModelCfg.PresencePenalty,
-			})
-			if err != nil {
+			}, subAgentBudget{MaxSteps: opts.MaxSteps, MaxTokens: opts.MaxTokens})
+			if errors.Is(runErr, ErrSubAgentCancelled) {
+				return fantasy.NewTextErrorResponse("The user cancelled this fetch. Don't start it again unless asked to."), nil
+			}
+			// The tokens used before going over budget still cost.
+			overBudget := errors.Is(runErr, ErrSubAgentOverBudget)
+			if runErr != nil && !overBudget {
 				return fantasy.NewTextErrorResponse("error generating response"), nil
 			}
 
@@ -211,6 +216,9 @@ func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (
 			if err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("error saving parent session: %s", err)
 			}
+			if overBudget {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("Stopped analyzing %s, the %s. Ask for less or answer with what you already know.", params.URL, runErr)), nil
+			}
 
 			return fantasy.NewTextResponse(result.Response.Content.Text()), nil
 		}), nil
@@ -0,0 +1,31 @@
+package agent
+
+import "github.com/charmbracelet/catwalk/pkg/catwalk"
+
+// AttachmentLimits are the sizes in bytes of the attachments a provider takes
+// with a prompt, zero when it isn't known.
+type AttachmentLimits struct {
+	// File is the size of each attachment.
+	File int64
+	// Total is the size of all the attachments together.
+	Total int64
+}
+
+const mb = 1 << 20
+
+// attachmentLimits are the documented limits of the provider APIs for files
+// sent inline with a prompt.
+var attachmentLimits = map[catwalk.Type]AttachmentLimits{
+	catwalk.TypeAnthropic: {File: 5 * mb, Total: 32 * mb},
+	catwalk.TypeBedrock:   {File: 3.75 * mb},
+	catwalk.TypeOpenAI:    {File: 20 * mb, Total: 50 * mb},
+	catwalk.TypeAzure:     {File: 20 * mb, Total: 50 * mb},
+	catwalk.TypeGoogle:    {Total: 20 * mb},
+	catwalk.TypeVertexAI:  {File: 7 * mb, Total: 20 * mb},
+}
+
+// ProviderAttachmentLimits returns the limits of the attachments sent to a
+// provider of type t.
+func ProviderAttachmentLimits(t catwalk.Type) AttachmentLimits {
+	return attachmentLimits[t]
+}
@@ -0,0 +1,105 @@
+package agent
+
+import (
+	"os"
+	"strconv"
+
+	"charm.land/fantasy"
+	"charm.land/fantasy/providers/anthropic"
+	"charm.land/fantasy/providers/bedrock"
+	"charm.land/fantasy/providers/google"
+	"charm.land/fantasy/providers/openai"
+	"github.com/charmbracelet/crush/internal/config"
+)
+
+// promptCache returns the prompt caching configuration.
+func promptCache() *config.PromptCache {
+	cache := config.Get().Options.PromptCache
+	if t, _ := strconv.ParseBool(os.Getenv("CRUSH_DISABLE_ANTHROPIC_CACHE")); t {
+		return &config.PromptCache{Disabled: true}
+	}
+	return cache
+}
+
+// cacheControlOptions marks a message or tool as a cache breakpoint for the
+// providers that need them.
+func cacheControlOptions() fantasy.ProviderOptions {
+	return fantasy.ProviderOptions{
+		anthropic.Name: &anthropic.ProviderCacheControlOptions{
+			CacheControl: anthropic.CacheControl{Type: "ephemeral"},
+		},
+		bedrock.Name: &anthropic.ProviderCacheControlOptions{
+			CacheControl: anthropic.CacheControl{Type: "ephemeral"},
+		},
+	}
+}
+
+// markCacheBreakpoints marks the last system message and the latest
+// messages as cache breakpoints, as configured.
+func markCacheBreakpoints(cache *config.PromptCache, messages []fantasy.Message) {
+	system, _, latest := cache.CacheBreakpoints()
+	lastSystemRoleInx := 0
+	systemMessageUpdated := false
+	for i, msg := range messages {
+		// Only add cache control to the last system message.
+		if msg.Role == fantasy.MessageRoleSystem {
+			lastSystemRoleInx = i
+		} else if !systemMessageUpdated {
+			if system {
+				messages[lastSystemRoleInx].ProviderOptions = cacheControlOptions()
+			}
+			systemMessageUpdated = true
+		}
+		if i >= len(messages)-latest {
+			messages[i].ProviderOptions = cacheControlOptions()
+		}
+	}
+}
+
+// withPromptCacheOptions adds the OpenAI prompt cache key and the Gemini
+// cached content to the call options, unless they're already set.
+func withPromptCacheOptions(cache *config.PromptCache, options fantasy.ProviderOptions, sessionID string) fantasy.ProviderOptions {
+	if cache == nil || cache.Disabled {
+		return options
+	}
+	result := make(fantasy.ProviderOptions, len(options))
+	for name, opts := range options {
+		switch opts := opts.(type) {
+		case *openai.ProviderOptions:
+			if cache.SessionKey && opts.PromptCacheKey == nil {
+				copied := *opts
+				copied.PromptCacheKey = &sessionID
+				result[name] = &copied
+				continue
+			}
+		case *openai.ResponsesProviderOptions:
+			if cache.SessionKey && opts.PromptCacheKey == nil {
+				copied := *opts
+				copied.PromptCacheKey = &sessionID
+				result[name] = &copied
+				continue
+			}
+		case *google.ProviderOptions:
+			if cache.CachedContent != "" && opts.CachedContent == "" {
+				copied := *opts
+				copied.CachedContent = cache.CachedContent
+				result[name] = &copied
+				continue
+			}
+		}
+		result[name] = opts
+	}
+	return result
+}
+
+// promptTokens returns all the input tokens of a call. Anthropic reports the
+// tokens read from or written to the cache apart from the input tokens,
+// while the other providers include them.
+func promptTokens(model Model, usage fantasy.Usage) int64 {
+	switch model.Model.Provider() {
+	case anthropic.Name, bedrock.Name:
+		return usage.InputTokens + usage.CacheCreationTokens + usage.CacheReadTokens
+	default:
+		return usage.InputTokens
+	}
+}
@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"testing"
+
+	"charm.land/fantasy"
+	"charm.land/fantasy/providers/google"
+	"charm.land/fantasy/providers/openai"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkCacheBreakpoints(t *testing.T) {
+	t.Parallel()
+
+	newMessages := func() []fantasy.Message {
+		return []fantasy.Message{
+			fantasy.NewSystemMessage("system"),
+			fantasy.NewUserMessage("one"),
+			fantasy.NewUserMessage("two"),
+			fantasy.NewUserMessage("three"),
+		}
+	}
+	marked := func(messages []fantasy.Message) []int {
+		var indexes []int
+		for i, msg := range messages {
+			if msg.ProviderOptions != nil {
+				indexes = append(indexes, i)
+			}
+		}
+		return indexes
+	}
+
+	for _, tt := range []struct {
+		name  string
+		cache *config.PromptCache
+		want  []int
+	}{
+		{"default", nil, []int{0, 2, 3}},
+		{"messages only", &config.PromptCache{Mark: []config.PromptCacheTarget{config.PromptCacheMessages}}, []int{0, 1, 2, 3}},
+		{"fewer breakpoints", &config.PromptCache{Breakpoints: 3}, []int{0, 3}},
+		{"disabled", &config.PromptCache{Disabled: true}, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			messages := newMessages()
+			markCacheBreakpoints(tt.cache, messages)
+			require.Equal(t, tt.want, marked(messages))
+		})
+	}
+}
+
+func TestWithPromptCacheOptions(t *testing.T) {
+	t.Parallel()
+
+	key := "custom"
+	options := fantasy.ProviderOptions{
+		openai.Name: &openai.ProviderOptions{},
+		google.Name: &google.ProviderOptions{},
+	}
+	cache := &config.PromptCache{SessionKey: true, CachedContent: "cachedContents/abc"}
+
+	got := withPromptCacheOptions(cache, options, "session-1")
+	require.Equal(t, "session-1", *got[openai.Name].(*openai.ProviderOptions).PromptCacheKey)
+	require.Equal(t, "cachedContents/abc", got[google.Name].(*google.ProviderOptions).CachedContent)
+	require.Nil(t, options[openai.Name].(*openai.ProviderOptions).PromptCacheKey, "the options given are left alone")
+
+	options[openai.Name] = &openai.ProviderOptions{PromptCacheKey: &key}
+	got = withPromptCacheOptions(cache, options, "session-1")
+	require.Equal(t, "custom", *got[openai.Name].(*openai.ProviderOptions).PromptCacheKey)
+
+	require.Equal(t, options, withPromptCacheOptions(nil, options, "session-1"))
+}
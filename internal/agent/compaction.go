@@ -0,0 +1,343 @@
+package agent
+
+import (
+	"cmp"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+)
+
+const (
+	// messageOverheadTokens is what a message takes besides its content:
+	// its role and the separators around it.
+	messageOverheadTokens = 4
+	// minPrunedToolResultTokens keeps small tool outputs, which take less
+	// than the turns they'd be left out of.
+	minPrunedToolResultTokens = 200
+
+	prunedToolResult = "[Output left out to save context; run the tool again if it's still needed]"
+
+	// A synopsis keeps the first and last lines of a tool output, cut to
+	// synopsisLineWidth characters.
+	synopsisHeadLines = 10
+	synopsisTailLines = 5
+	synopsisLineWidth = 200
+)
+
+// compactionThreshold is how many tokens of the context window of a model
+// are kept free for the response and the tools: 20% of it, up to 20k.
+func compactionThreshold(contextWindow int64) int64 {
+	if contextWindow > 200_000 {
+		return 20_000
+	}
+	return int64(float64(contextWindow) * 0.2)
+}
+
+// summarizes returns whether a strategy compacts the conversation into a
+// summary once it's too long, rather than leave parts of it out of the
+// requests.
+func summarizes(strategy config.CompactionStrategy) bool {
+	return strategy != config.CompactionSlidingWindow && strategy != config.CompactionPruneToolResults
+}
+
+// compactMessages leaves parts of msgs out, as strategy says, until their
+// estimated tokens fit budget. The summary strategies leave them as they
+// are.
+func compactMessages(strategy config.CompactionStrategy, msgs []fantasy.Message, budget int) []fantasy.Message {
+	switch strategy {
+	case config.CompactionSlidingWindow:
+		return slideWindow(msgs, budget)
+	case config.CompactionPruneToolResults:
+		return pruneToolResults(msgs, budget)
+	default:
+		return msgs
+	}
+}
+
+// slideWindow leaves the oldest turns out of msgs until they fit budget. A
+// turn starts with a user message, so that tool calls keep their results.
+// When the latest turn alone doesn't fit, the oldest steps of it are left
+// out too, an assistant message with the results of its tool calls at a
+// time. The system messages, the user message of the latest turn and its
+// latest step are always kept, even when they don't fit.
+func slideWindow(msgs []fantasy.Message, budget int) []fantasy.Message {
+	total := estimateMessagesTokens(msgs)
+	if total <= budget {
+		return msgs
+	}
+	last := 0
+	for i, m := range msgs {
+		if m.Role == fantasy.MessageRoleUser {
+			last = i
+		}
+	}
+
+	cut := 0
+	for cut < last && (total > budget || msgs[cut].Role != fantasy.MessageRoleUser) {
+		if msgs[cut].Role != fantasy.MessageRoleSystem {
+			total -= estimateMessageTokens(msgs[cut])
+		}
+		cut++
+	}
+
+	// The steps of the latest turn left out are msgs[from:to].
+	from, to := last+1, last+1
+	for total > budget && msgs[last].Role == fantasy.MessageRoleUser && to < len(msgs) {
+		next := slices.IndexFunc(msgs[to+1:], func(m fantasy.Message) bool {
+			return m.Role == fantasy.MessageRoleAssistant
+		})
+		if next == -1 {
+			break
+		}
+		next += to + 1
+		total -= estimateMessagesTokens(msgs[to:next])
+		to = next
+	}
+
+	if cut == 0 && from == to {
+		return msgs
+	}
+	kept := make([]fantasy.Message, 0, len(msgs)-cut-(to-from))
+	for _, m := range msgs[:cut] {
+		if m.Role == fantasy.MessageRoleSystem {
+			kept = append(kept, m)
+		}
+	}
+	kept = append(kept, msgs[cut:from]...)
+	return append(kept, msgs[to:]...)
+}
+
+// pruneToolResults replaces the large tool outputs of msgs with a note,
+// oldest first, until they fit budget, and then leaves the oldest turns out
+// if they still don't. The outputs of the latest step are kept.
+func pruneToolResults(msgs []fantasy.Message, budget int) []fantasy.Message {
+	total := estimateMessagesTokens(msgs)
+	if total <= budget {
+		return msgs
+	}
+	latest := len(msgs)
+	for latest > 0 && msgs[latest-1].Role == fantasy.MessageRoleTool {
+		latest--
+	}
+
+	pruned := slices.Clone(msgs)
+	noteTokens := estimateToolOutputTokens(fantasy.ToolResultOutputContentText{Text: prunedToolResult})
+	for i := range latest {
+		if pruned[i].Role != fantasy.MessageRoleTool {
+			continue
+		}
+		// The parts are shared with the history of the agent, which keeps
+		// them whole.
+		pruned[i].Content = slices.Clone(pruned[i].Content)
+		for j, part := range pruned[i].Content {
+			result, ok := part.(fantasy.ToolResultPart)
+			if !ok {
+				continue
+			}
+			tokens := estimateToolOutputTokens(result.Output)
+			if tokens < minPrunedToolResultTokens {
+				continue
+			}
+			result.Output = fantasy.ToolResultOutputContentText{Text: prunedToolResult}
+			pruned[i].Content[j] = result
+			total -= tokens - noteTokens
+			if total <= budget {
+				return pruned
+			}
+		}
+	}
+	return slideWindow(pruned, budget)
+}
+
+// synopsizeToolResults replaces the large text outputs of the tool calls
+// made more than turns turns ago with synopses of them. The rest of the
+// messages are kept as they are.
+func synopsizeToolResults(msgs []fantasy.Message, turns int) []fantasy.Message {
+	if turns <= 0 {
+		return msgs
+	}
+	// The turns to keep whole start at the turns-th user message from the
+	// end.
+	cutoff := len(msgs)
+	for i := len(msgs) - 1; i >= 0 && turns > 0; i-- {
+		if msgs[i].Role == fantasy.MessageRoleUser {
+			cutoff = i
+			turns--
+		}
+	}
+	if turns > 0 {
+		return msgs
+	}
+
+	var synopsized []fantasy.Message
+	calls := make(map[string]fantasy.ToolCallPart)
+	for i, m := range msgs[:cutoff] {
+		cloned := false
+		for j, part := range m.Content {
+			if call, ok := part.(fantasy.ToolCallPart); ok {
+				calls[call.ToolCallID] = call
+				continue
+			}
+			result, ok := part.(fantasy.ToolResultPart)
+			if !ok {
+				continue
+			}
+			output, ok := result.Output.(fantasy.ToolResultOutputContentText)
+			if !ok || EstimateTokens(output.Text) < minPrunedToolResultTokens {
+				continue
+			}
+			synopsis := toolResultSynopsis(calls[result.ToolCallID], output.Text)
+			if EstimateTokens(synopsis) >= EstimateTokens(output.Text) {
+				continue
+			}
+			// The messages and their parts are shared with the history of the
+			// agent, which keeps them whole.
+			if synopsized == nil {
+				synopsized = slices.Clone(msgs)
+			}
+			if !cloned {
+				synopsized[i].Content = slices.Clone(m.Content)
+				cloned = true
+			}
+			result.Output = fantasy.ToolResultOutputContentText{Text: synopsis}
+			synopsized[i].Content[j] = result
+		}
+	}
+	if synopsized == nil {
+		return msgs
+	}
+	return synopsized
+}
+
+// toolResultSynopsis shortens the output of call to its first and last
+// lines, under a note of what was left out.
+func toolResultSynopsis(call fantasy.ToolCallPart, output string) string {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	by := cmp.Or(call.ToolName, "a tool")
+	if call.Input != "" {
+		by += " " + truncateLine(call.Input)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Synopsis of the %d lines output by %s a few turns ago; run it again if all of it is needed]\n", len(lines), by)
+	if len(lines) <= synopsisHeadLines+synopsisTailLines {
+		for _, line := range lines {
+			b.WriteString(truncateLine(line) + "\n")
+		}
+		return b.String()
+	}
+	for _, line := range lines[:synopsisHeadLines] {
+		b.WriteString(truncateLine(line) + "\n")
+	}
+	fmt.Fprintf(&b, "[... %d lines ...]\n", len(lines)-synopsisHeadLines-synopsisTailLines)
+	for _, line := range lines[len(lines)-synopsisTailLines:] {
+		b.WriteString(truncateLine(line) + "\n")
+	}
+	return b.String()
+}
+
+func truncateLine(line string) string {
+	if len(line) <= synopsisLineWidth {
+		return line
+	}
+	// Cut at a rune boundary.
+	cut := synopsisLineWidth
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut--
+	}
+	return line[:cut] + "..."
+}
+
+// retainedStart returns where the latest turns of msgs that fit in retain
+// tokens start, for the hybrid strategy to keep them out of the summary.
+// The first message, which is the previous summary if there's one, is
+// always summarized. It returns len(msgs) when not even the latest turn
+// fits.
+func retainedStart(msgs []message.Message, retain int) int {
+	start := len(msgs)
+	tokens := 0
+	for i := len(msgs) - 1; i > 0; i-- {
+		tokens += estimateMessagesTokens(msgs[i].ToAIMessage())
+		if tokens > retain {
+			break
+		}
+		if msgs[i].Role == message.User && !msgs[i].IsSummaryMessage {
+			start = i
+		}
+	}
+	return start
+}
+
+// retainedMessages returns the messages a summary left out, from the one
+// with ID from on, without the summaries among them.
+func retainedMessages(msgs []message.Message, from string) []message.Message {
+	if from == "" {
+		return nil
+	}
+	start := slices.IndexFunc(msgs, func(m message.Message) bool { return m.ID == from })
+	if start == -1 {
+		return nil
+	}
+	var retained []message.Message
+	for _, m := range msgs[start:] {
+		if !m.IsSummaryMessage {
+			retained = append(retained, m)
+		}
+	}
+	return retained
+}
+
+func estimateMessagesTokens(msgs []fantasy.Message) int {
+	tokens := 0
+	for _, m := range msgs {
+		tokens += estimateMessageTokens(m)
+	}
+	return tokens
+}
+
+// estimateMessageTokens approximates the tokens of a message the way
+// EstimateTokens does those of a prompt.
+func estimateMessageTokens(msg fantasy.Message) int {
+	tokens := messageOverheadTokens
+	for _, part := range msg.Content {
+		switch part := part.(type) {
+		case fantasy.TextPart:
+			tokens += EstimateTokens(part.Text)
+		case fantasy.ReasoningPart:
+			tokens += EstimateTokens(part.Text)
+		case fantasy.FilePart:
+			tokens += estimateAttachmentTokens(message.Attachment{MimeType: part.MediaType, Content: part.Data})
+		case fantasy.ToolCallPart:
+			tokens += EstimateTokens(part.ToolName) + EstimateTokens(part.Input)
+		case fantasy.ToolResultPart:
+			tokens += estimateToolOutputTokens(part.Output)
+		}
+	}
+	return tokens
+}
+
+func estimateToolOutputTokens(output fantasy.ToolResultOutputContent) int {
+	switch output := output.(type) {
+	case fantasy.ToolResultOutputContentText:
+		return EstimateTokens(output.Text)
+	case fantasy.ToolResultOutputContentError:
+		if output.Error == nil {
+			return 0
+		}
+		return EstimateTokens(output.Error.Error())
+	case fantasy.ToolResultOutputContentMedia:
+		data, err := base64.StdEncoding.DecodeString(output.Data)
+		if err != nil {
+			return len(output.Data) / 4
+		}
+		return estimateAttachmentTokens(message.Attachment{MimeType: output.MediaType, Content: data})
+	default:
+		return 0
+	}
+}
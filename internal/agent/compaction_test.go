@@ -0,0 +1,242 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+// words makes a text of n tokens.
+func words(n int) string {
+	return strings.Repeat("word ", n)
+}
+
+func textMessage(role fantasy.MessageRole, tokens int) fantasy.Message {
+	return fantasy.Message{Role: role, Content: []fantasy.MessagePart{fantasy.TextPart{Text: words(tokens)}}}
+}
+
+func toolCallMessage(id string) fantasy.Message {
+	return fantasy.Message{Role: fantasy.MessageRoleAssistant, Content: []fantasy.MessagePart{
+		fantasy.ToolCallPart{ToolCallID: id, ToolName: "view", Input: "{}"},
+	}}
+}
+
+func toolResultMessage(id string, tokens int) fantasy.Message {
+	return fantasy.Message{Role: fantasy.MessageRoleTool, Content: []fantasy.MessagePart{
+		fantasy.ToolResultPart{ToolCallID: id, Output: fantasy.ToolResultOutputContentText{Text: words(tokens)}},
+	}}
+}
+
+func TestEstimateMessageTokens(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, messageOverheadTokens+10, estimateMessageTokens(textMessage(fantasy.MessageRoleUser, 10)))
+	// A token for every six letters of a word.
+	require.Equal(t, messageOverheadTokens+3, estimateMessageTokens(fantasy.Message{Content: []fantasy.MessagePart{
+		fantasy.ReasoningPart{Text: "thinking hard"},
+	}}))
+	// "view" and "{", "}".
+	require.Equal(t, messageOverheadTokens+3, estimateMessageTokens(toolCallMessage("1")))
+	require.Equal(t, messageOverheadTokens+50, estimateMessageTokens(toolResultMessage("1", 50)))
+	require.Equal(t, messageOverheadTokens+2, estimateMessageTokens(fantasy.Message{Content: []fantasy.MessagePart{
+		fantasy.ToolResultPart{Output: fantasy.ToolResultOutputContentError{Error: errors.New("not found")}},
+	}}))
+	require.Equal(t, messageOverheadTokens+maxImageTokens, estimateMessageTokens(fantasy.Message{Content: []fantasy.MessagePart{
+		fantasy.FilePart{MediaType: "image/png", Data: []byte("not an image")},
+	}}))
+
+	msgs := []fantasy.Message{textMessage(fantasy.MessageRoleUser, 10), textMessage(fantasy.MessageRoleAssistant, 20)}
+	require.Equal(t, 2*messageOverheadTokens+30, estimateMessagesTokens(msgs))
+}
+
+func TestCompactionThreshold(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, int64(20_000), compactionThreshold(100_000))
+	require.Equal(t, int64(40_000), compactionThreshold(200_000))
+	require.Equal(t, int64(20_000), compactionThreshold(1_000_000))
+}
+
+func TestSlideWindow(t *testing.T) {
+	t.Parallel()
+
+	// Every message takes 100 tokens.
+	const size = 100 - messageOverheadTokens
+	msgs := []fantasy.Message{
+		textMessage(fantasy.MessageRoleSystem, size),
+		textMessage(fantasy.MessageRoleUser, size),
+		toolCallMessage("1"),
+		toolResultMessage("1", size),
+		textMessage(fantasy.MessageRoleUser, size),
+		textMessage(fantasy.MessageRoleAssistant, size),
+		textMessage(fantasy.MessageRoleUser, size),
+	}
+
+	require.Equal(t, msgs, slideWindow(msgs, 10_000))
+
+	kept := slideWindow(msgs, 400)
+	require.Equal(t, []fantasy.Message{msgs[0], msgs[4], msgs[5], msgs[6]}, kept)
+
+	// The tool call and its result go together with the turn they're in.
+	kept = slideWindow(msgs, 350)
+	require.Equal(t, []fantasy.Message{msgs[0], msgs[6]}, kept)
+
+	kept = slideWindow(msgs, 10)
+	require.Equal(t, []fantasy.Message{msgs[0], msgs[6]}, kept, "the latest turn is kept even when it doesn't fit")
+}
+
+func TestSlideWindowLongTurn(t *testing.T) {
+	t.Parallel()
+
+	// A single agentic turn, with steps of about 1000 tokens.
+	msgs := []fantasy.Message{
+		textMessage(fantasy.MessageRoleSystem, 100),
+		textMessage(fantasy.MessageRoleUser, 100),
+		toolCallMessage("1"),
+		toolResultMessage("1", 1_000),
+		toolCallMessage("2"),
+		toolResultMessage("2", 1_000),
+		textMessage(fantasy.MessageRoleAssistant, 1_000),
+		toolCallMessage("3"),
+		toolResultMessage("3", 1_000),
+	}
+
+	kept := slideWindow(msgs, 2_500)
+	require.Equal(t, []fantasy.Message{msgs[0], msgs[1], msgs[6], msgs[7], msgs[8]}, kept, "the oldest steps of the turn are left out with their results")
+	require.LessOrEqual(t, estimateMessagesTokens(kept), 2_500)
+
+	kept = slideWindow(msgs, 10)
+	require.Equal(t, []fantasy.Message{msgs[0], msgs[1], msgs[7], msgs[8]}, kept, "the user message and the latest step are kept even when they don't fit")
+}
+
+func TestPruneToolResults(t *testing.T) {
+	t.Parallel()
+
+	msgs := []fantasy.Message{
+		textMessage(fantasy.MessageRoleSystem, 100),
+		textMessage(fantasy.MessageRoleUser, 100),
+		toolCallMessage("1"),
+		toolResultMessage("1", 5_000),
+		toolCallMessage("2"),
+		toolResultMessage("2", 50),
+		toolCallMessage("3"),
+		toolResultMessage("3", 5_000),
+		toolCallMessage("4"),
+		toolResultMessage("4", 5_000),
+	}
+	total := estimateMessagesTokens(msgs)
+	require.Equal(t, msgs, pruneToolResults(msgs, total))
+
+	pruned := pruneToolResults(msgs, total-1)
+	require.Len(t, pruned, len(msgs))
+	result := pruned[3].Content[0].(fantasy.ToolResultPart)
+	require.Equal(t, fantasy.ToolResultOutputContentText{Text: prunedToolResult}, result.Output, "the oldest large output goes first")
+	require.Equal(t, msgs[7], pruned[7])
+	require.Equal(t, words(5_000), msgs[3].Content[0].(fantasy.ToolResultPart).Output.(fantasy.ToolResultOutputContentText).Text, "the messages given are left as they are")
+
+	pruned = pruneToolResults(msgs, 6_000)
+	require.Len(t, pruned, len(msgs))
+	require.Equal(t, msgs[5], pruned[5], "small outputs are kept")
+	require.NotEqual(t, msgs[7], pruned[7])
+	require.Equal(t, msgs[9], pruned[9], "the latest step is kept")
+	require.LessOrEqual(t, estimateMessagesTokens(pruned), 6_000)
+
+	// Pruning isn't enough, and there's no older turn to leave out: the
+	// oldest steps of the turn go.
+	pruned = pruneToolResults(msgs, 1_000)
+	require.Equal(t, []fantasy.Message{msgs[0], msgs[1], msgs[8], msgs[9]}, pruned)
+}
+
+func TestRetainedStart(t *testing.T) {
+	t.Parallel()
+
+	text := func(id string, role message.MessageRole, tokens int) message.Message {
+		return message.Message{ID: id, Role: role, Parts: []message.ContentPart{message.TextContent{Text: words(tokens)}}}
+	}
+	msgs := []message.Message{
+		text("summary", message.User, 500),
+		text("1", message.User, 100),
+		text("2", message.Assistant, 100),
+		text("3", message.User, 100),
+		text("4", message.Assistant, 100),
+		text("5", message.User, 100),
+		text("6", message.Assistant, 100),
+	}
+	// Every message takes a bit more than 100 tokens, so that the latest two
+	// turns take a bit more than 400.
+	require.Equal(t, 5, retainedStart(msgs, 300))
+	require.Equal(t, 3, retainedStart(msgs, 450))
+	require.Equal(t, 1, retainedStart(msgs, 10_000), "the first message is always summarized")
+	require.Equal(t, len(msgs), retainedStart(msgs, 100), "not even the latest turn fits")
+}
+
+func TestRetainedMessages(t *testing.T) {
+	t.Parallel()
+
+	msgs := []message.Message{
+		{ID: "1"},
+		{ID: "2"},
+		{ID: "previous", IsSummaryMessage: true},
+		{ID: "3"},
+	}
+	require.Nil(t, retainedMessages(msgs, ""))
+	require.Nil(t, retainedMessages(msgs, "deleted"))
+	require.Equal(t, []message.Message{{ID: "2"}, {ID: "3"}}, retainedMessages(msgs, "2"))
+}
+
+func TestSynopsizeToolResults(t *testing.T) {
+	t.Parallel()
+
+	var lines []string
+	for i := range 100 {
+		lines = append(lines, fmt.Sprintf("line %d %s", i, words(10)))
+	}
+	dump := strings.Join(lines, "\n")
+	result := func(id, text string) fantasy.Message {
+		return fantasy.Message{Role: fantasy.MessageRoleTool, Content: []fantasy.MessagePart{
+			fantasy.ToolResultPart{ToolCallID: id, Output: fantasy.ToolResultOutputContentText{Text: text}},
+		}}
+	}
+	msgs := []fantasy.Message{
+		textMessage(fantasy.MessageRoleSystem, 10),
+		textMessage(fantasy.MessageRoleUser, 300),
+		toolCallMessage("1"),
+		result("1", dump),
+		toolCallMessage("2"),
+		result("2", "small"),
+		textMessage(fantasy.MessageRoleAssistant, 300),
+		textMessage(fantasy.MessageRoleUser, 10),
+		toolCallMessage("3"),
+		result("3", dump),
+	}
+
+	require.Equal(t, msgs, synopsizeToolResults(msgs, 0))
+	require.Equal(t, msgs, synopsizeToolResults(msgs, 2), "there are no turns older than 2")
+
+	synopsized := synopsizeToolResults(msgs, 1)
+	require.Len(t, synopsized, len(msgs))
+	for _, i := range []int{0, 1, 2, 4, 5, 6, 7, 8, 9} {
+		require.Equal(t, msgs[i], synopsized[i], "message %d", i)
+	}
+	output := synopsized[3].Content[0].(fantasy.ToolResultPart).Output.(fantasy.ToolResultOutputContentText).Text
+	require.Contains(t, output, "100 lines output by view {} a few")
+	require.Contains(t, output, "line 9 ")
+	require.NotContains(t, output, "line 10 ")
+	require.Contains(t, output, "[... 85 lines ...]")
+	require.Contains(t, output, "line 95 ")
+	require.Less(t, EstimateTokens(output), EstimateTokens(dump)/4)
+	require.Equal(t, dump, msgs[3].Content[0].(fantasy.ToolResultPart).Output.(fantasy.ToolResultOutputContentText).Text, "the messages given are left as they are")
+}
+
+func TestToolResultSynopsis(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("é", synopsisLineWidth)
+	synopsis := toolResultSynopsis(fantasy.ToolCallPart{}, "short\n"+long+"\n")
+	require.Equal(t, "[Synopsis of the 2 lines output by a tool a few turns ago; run it again if all of it is needed]\nshort\n"+strings.Repeat("é", synopsisLineWidth/2)+"...\n", synopsis)
+}
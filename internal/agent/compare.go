@@ -0,0 +1,214 @@
+package agent
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/google/uuid"
+)
+
+// CompareToolName names the tool call a comparison is recorded as in the
+// session it was asked in. There is no such tool for the model to call, and
+// comparisons are left out of the conversation sent to it.
+const CompareToolName = "compare"
+
+// CompareParams is the input of a comparison's tool call.
+type CompareParams struct {
+	Prompt string   `json:"prompt"`
+	Models []string `json:"models"`
+}
+
+// CompareMetadata holds the answers of the compared models, in the order
+// they were asked for.
+type CompareMetadata struct {
+	Results []CompareResult `json:"results"`
+}
+
+// CompareResult is how one of the compared models answered, in the child
+// session it ran in.
+type CompareResult struct {
+	Model        string  `json:"model"`
+	Name         string  `json:"name,omitempty"`
+	SessionID    string  `json:"session_id"`
+	Response     string  `json:"response,omitempty"`
+	Error        string  `json:"error,omitempty"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	Cost         float64 `json:"cost"`
+	DurationMS   int64   `json:"duration_ms"`
+}
+
+// compare sends prompt to each of the models in a child session of its
+// own, all at once, and records their answers side by side in the session.
+func (c *coordinator) compare(ctx context.Context, sessionID, prompt string, overrides promptOverrides, attachments []message.Attachment) (*fantasy.AgentResult, error) {
+	if c.IsSessionBusy(sessionID) {
+		return nil, ErrSessionBusy
+	}
+	if strings.TrimSpace(prompt) == "" {
+		return nil, ErrEmptyPrompt
+	}
+	current := c.sessionModel(sessionID)
+	models := make([]config.SelectedModel, 0, len(overrides.Compare))
+	params := CompareParams{Prompt: prompt}
+	for _, spec := range overrides.Compare {
+		selected, ok := c.findModel(current.ModelCfg.Provider, spec)
+		if !ok {
+			return nil, fmt.Errorf("model %q not found in the configured providers", spec)
+		}
+		models = append(models, selected)
+		params.Models = append(params.Models, selected.Provider+"/"+selected.Model)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	c.comparisons.Set(sessionID, cancel)
+	defer c.comparisons.Del(sessionID)
+
+	input, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	callID := uuid.NewString()
+	msg, err := c.messages.Create(ctx, sessionID, message.CreateMessageParams{
+		Role: message.Assistant,
+		Parts: []message.ContentPart{message.ToolCall{
+			ID:       callID,
+			Name:     CompareToolName,
+			Input:    string(input),
+			Finished: true,
+		}},
+		Model:    current.ModelCfg.Model,
+		Provider: current.ModelCfg.Provider,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create comparison message: %w", err)
+	}
+
+	results := make([]CompareResult, len(models))
+	var wg sync.WaitGroup
+	for i, selected := range models {
+		wg.Go(func() {
+			childID := c.sessions.CreateAgentToolSessionID(msg.ID, fmt.Sprintf("%s-%d", callID, i+1))
+			results[i] = c.compareOne(ctx, sessionID, childID, prompt, selected, overrides.Temperature, attachments)
+		})
+	}
+	wg.Wait()
+	canceled := ctx.Err() != nil
+
+	// The parent session's usage includes the comparison, as it does for
+	// sub-agents.
+	ctx = context.WithoutCancel(ctx)
+	if parent, err := c.sessions.Get(ctx, sessionID); err == nil {
+		for _, r := range results {
+			parent.Cost += r.Cost
+		}
+		if _, err := c.sessions.Save(ctx, parent); err != nil {
+			return nil, fmt.Errorf("failed to save session usage: %w", err)
+		}
+	}
+
+	if canceled {
+		msg.AddFinish(message.FinishReasonCanceled, "", "")
+		return nil, c.messages.Update(ctx, msg)
+	}
+	msg.AddFinish(message.FinishReasonToolUse, "", "")
+	if err := c.messages.Update(ctx, msg); err != nil {
+		return nil, err
+	}
+	metadata, err := json.Marshal(CompareMetadata{Results: results})
+	if err != nil {
+		return nil, err
+	}
+	_, err = c.messages.Create(ctx, sessionID, message.CreateMessageParams{
+		Role: message.Tool,
+		Parts: []message.ContentPart{message.ToolResult{
+			ToolCallID: callID,
+			Name:       CompareToolName,
+			Content:    compareSummary(results),
+			Metadata:   string(metadata),
+			IsError:    allFailed(results),
+		}},
+	})
+	return nil, err
+}
+
+func (c *coordinator) compareOne(ctx context.Context, parentID, childID, prompt string, selected config.SelectedModel, temperature *float64, attachments []message.Attachment) CompareResult {
+	start := time.Now()
+	result := CompareResult{
+		Model:     selected.Provider + "/" + selected.Model,
+		SessionID: childID,
+	}
+	if model := c.cfg.GetModel(selected.Provider, selected.Model); model != nil {
+		result.Name = model.Name
+	}
+	defer func() { result.DurationMS = time.Since(start).Milliseconds() }()
+
+	if _, err := c.sessions.CreateTaskSession(ctx, childID, parentID, "Compare: "+cmp.Or(result.Name, selected.Model)); err != nil {
+		result.Error = fmt.Sprintf("failed to create session: %v", err)
+		return result
+	}
+	res, err := c.run(ctx, childID, prompt, promptOverrides{Model: result.Model, Temperature: temperature}, attachments)
+	if err != nil {
+		result.Error = err.Error()
+	}
+	if res != nil {
+		result.Response = res.Response.Content.Text()
+	}
+	if child, err := c.sessions.Get(context.WithoutCancel(ctx), childID); err == nil {
+		result.InputTokens = child.PromptTokens
+		result.OutputTokens = child.CompletionTokens
+		result.Cost = child.Cost
+	}
+	return result
+}
+
+// compareSummary is the plain text of the answers, for where the metadata
+// isn't rendered.
+func compareSummary(results []CompareResult) string {
+	var sb strings.Builder
+	for i, r := range results {
+		if i > 0 {
+			sb.WriteString("\n\n")
+		}
+		fmt.Fprintf(&sb, "## %s\n\n", cmp.Or(r.Name, r.Model))
+		if r.Error != "" {
+			sb.WriteString("Error: " + r.Error)
+			continue
+		}
+		sb.WriteString(r.Response)
+	}
+	return sb.String()
+}
+
+// isComparison reports whether msg records a comparison, as either its
+// tool call or its result.
+func isComparison(msg message.Message) bool {
+	for _, tc := range msg.ToolCalls() {
+		if tc.Name == CompareToolName {
+			return true
+		}
+	}
+	for _, tr := range msg.ToolResults() {
+		if tr.Name == CompareToolName {
+			return true
+		}
+	}
+	return false
+}
+
+func allFailed(results []CompareResult) bool {
+	for _, r := range results {
+		if r.Error == "" {
+			return false
+		}
+	}
+	return true
+}
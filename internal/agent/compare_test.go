@@ -0,0 +1,37 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareLeftOutOfPrompt(t *testing.T) {
+	t.Parallel()
+
+	msg := func(role message.MessageRole, parts ...message.ContentPart) message.Message {
+		return message.Message{Role: role, Parts: parts}
+	}
+	msgs := []message.Message{
+		msg(message.User, message.TextContent{Text: "hi"}),
+		msg(message.Assistant, message.ToolCall{ID: "call-1", Name: CompareToolName, Finished: true}, message.Finish{Reason: message.FinishReasonToolUse}),
+		msg(message.Tool, message.ToolResult{ToolCallID: "call-1", Name: CompareToolName, Content: "## A\n\nhello"}),
+		msg(message.User, message.TextContent{Text: "again"}),
+	}
+	a := &sessionAgent{}
+	history, _ := a.preparePrompt(msgs)
+	require.Len(t, history, 2)
+}
+
+func TestCompareSummary(t *testing.T) {
+	t.Parallel()
+
+	results := []CompareResult{
+		{Model: "openai/gpt-4o", Name: "GPT-4o", Response: "hello"},
+		{Model: "anthropic/sonnet", Error: "rate limited"},
+	}
+	require.Equal(t, "## GPT-4o\n\nhello\n\n## anthropic/sonnet\n\nError: rate limited", compareSummary(results))
+	require.False(t, allFailed(results))
+	require.True(t, allFailed(results[1:]))
+}
@@ -0,0 +1,111 @@
+package agent
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/charmbracelet/crush/internal/httpext"
+	"github.com/charmbracelet/crush/internal/pubsub"
+)
+
+// RequestQueue is published when requests to a provider start or stop
+// waiting for one of its max_concurrent_requests to finish.
+type RequestQueue struct {
+	ProviderID string
+	// Waiting is how many requests are waiting.
+	Waiting int
+	// Limit is how many requests to the provider can run at once.
+	Limit int
+}
+
+var requestQueueBroker = pubsub.NewBroker[RequestQueue]()
+
+// SubscribeRequestQueue returns a channel for the events of requests waiting
+// for a provider.
+func SubscribeRequestQueue(ctx context.Context) <-chan pubsub.Event[RequestQueue] {
+	return requestQueueBroker.Subscribe(ctx)
+}
+
+// requestLimiter limits how many requests to a provider run at once, across
+// the sessions and sub-agents using it.
+type requestLimiter struct {
+	providerID string
+	slots      chan struct{}
+
+	mu      sync.Mutex
+	waiting int
+}
+
+func newRequestLimiter(providerID string, limit int) *requestLimiter {
+	return &requestLimiter{providerID: providerID, slots: make(chan struct{}, limit)}
+}
+
+// acquire waits for a request to be allowed to run, or for ctx to be done.
+func (l *requestLimiter) acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+	}
+	l.addWaiting(1)
+	defer l.addWaiting(-1)
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *requestLimiter) release() {
+	<-l.slots
+}
+
+func (l *requestLimiter) addWaiting(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.waiting += n
+	requestQueueBroker.Publish(pubsub.UpdatedEvent, RequestQueue{
+		ProviderID: l.providerID,
+		Waiting:    l.waiting,
+		Limit:      cap(l.slots),
+	})
+}
+
+// limitedTransport runs a request once its limiter allows it, and counts it
+// as running until its response body is closed, for streamed responses to
+// count until they end.
+type limitedTransport struct {
+	base    http.RoundTripper
+	limiter *requestLimiter
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.acquire(req.Context()); err != nil {
+		return nil, err
+	}
+	base := t.base
+	if base == nil {
+		base = httpext.DefaultTransport()
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		t.limiter.release()
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: sync.OnceFunc(t.limiter.release)}
+	return resp, nil
+}
+
+// releasingBody releases the slot of its request when it is closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
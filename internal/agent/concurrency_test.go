@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestLimitedTransport(t *testing.T) {
+	t.Parallel()
+
+	base := roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+	})
+	transport := &limitedTransport{base: base, limiter: newRequestLimiter("test", 1)}
+	newRequest := func(ctx context.Context) *http.Request {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com", nil)
+		require.NoError(t, err)
+		return req
+	}
+
+	first, err := transport.RoundTrip(newRequest(t.Context()))
+	require.NoError(t, err)
+
+	// The second request waits for the body of the first to be closed.
+	done := make(chan *http.Response)
+	go func() {
+		resp, err := transport.RoundTrip(newRequest(t.Context()))
+		if err != nil {
+			resp = nil
+		}
+		done <- resp
+	}()
+	select {
+	case <-done:
+		t.Fatal("second request ran with the first one")
+	case <-time.After(50 * time.Millisecond):
+	}
+	require.NoError(t, first.Body.Close())
+	require.NoError(t, first.Body.Close())
+	second := <-done
+	require.NotNil(t, second)
+
+	// A waiting request stops with its context.
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	_, err = transport.RoundTrip(newRequest(ctx))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	require.NoError(t, second.Body.Close())
+	third, err := transport.RoundTrip(newRequest(t.Context()))
+	require.NoError(t, err)
+	require.NoError(t, third.Body.Close())
+}
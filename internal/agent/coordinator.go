@@ -10,22 +10,35 @@ import (
 	"io"
 	"log/slog"
 	"maps"
+	"net/http"
 	"os"
+	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
 
 	"charm.land/fantasy"
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
 	"github.com/charmbracelet/crush/internal/agent/prompt"
 	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/editlock"
+	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/hooks"
+	"github.com/charmbracelet/crush/internal/httpext"
 	"github.com/charmbracelet/crush/internal/log"
 	"github.com/charmbracelet/crush/internal/lsp"
 	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/oauth"
+	"github.com/charmbracelet/crush/internal/oauth/copilot"
 	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/replay"
 	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/transcript"
+	"github.com/charmbracelet/crush/internal/trash"
 	"golang.org/x/sync/errgroup"
 
 	"charm.land/fantasy/providers/anthropic"
@@ -43,6 +56,15 @@ type Coordinator interface {
 	// INFO: (kujtim) this is not used yet we will use this when we have multiple agents
 	// SetMainAgent(string)
 	Run(ctx context.Context, sessionID, prompt string, attachments ...message.Attachment) (*fantasy.AgentResult, error)
+	// Retry drops the last turn of a session and runs its user message
+	// again, with model when it isn't empty.
+	Retry(ctx context.Context, sessionID, model string) (*fantasy.AgentResult, error)
+	// Resend drops the user message with messageID and everything after it
+	// from a session, and runs prompt instead with its attachments.
+	Resend(ctx context.Context, sessionID, messageID, prompt string) (*fantasy.AgentResult, error)
+	// RunWithSchema runs the prompt like Run, then asks for a final response
+	// that conforms to outputSchema and returns it.
+	RunWithSchema(ctx context.Context, sessionID, prompt string, outputSchema *OutputSchema, attachments ...message.Attachment) (json.RawMessage, error)
 	Cancel(sessionID string)
 	CancelAll()
 	IsSessionBusy(sessionID string) bool
@@ -52,6 +74,35 @@ type Coordinator interface {
 	Summarize(context.Context, string) error
 	Model() Model
 	UpdateModels(ctx context.Context) error
+	// CancelSubAgent cancels the sub-agent started by a tool call, leaving
+	// the run of its parent going.
+	CancelSubAgent(toolCallID string)
+	// ReasoningLevel returns the reasoning level of a session, and whether
+	// it was set with SetReasoningLevel rather than taken from the model
+	// configuration.
+	ReasoningLevel(sessionID string) (ReasoningLevel, bool)
+	// SetReasoningLevel sets the reasoning level of a session until Crush
+	// exits.
+	SetReasoningLevel(sessionID string, level ReasoningLevel)
+	// Scope returns the directory the file tools of a session are restricted
+	// to, which is the working directory when they aren't.
+	Scope(sessionID string) string
+	// SetScope restricts the file tools of a session to dir, a directory of
+	// the working directory, until Crush exits. With no session, it sets
+	// the scope of the sessions without one of their own.
+	SetScope(sessionID, dir string) error
+	// SkipToolCall cancels a running tool call, leaving the run going with an
+	// error result for it. It reports whether the call was running.
+	SkipToolCall(toolCallID string) bool
+	// ExtendToolCall starts the timeouts of a running tool call over. It
+	// reports whether the call was running with a timeout or a soft timeout.
+	ExtendToolCall(toolCallID string) bool
+	// Sample generates the completion an MCP server asked for while a tool
+	// call of the session ran, adding its cost to the session.
+	Sample(ctx context.Context, sessionID string, req mcp.SamplingRequest) (mcp.SamplingResult, error)
+	// ContextPreview returns what the next request of a session would send
+	// to the model, with the estimated tokens of each part.
+	ContextPreview(ctx context.Context, sessionID string) (ContextPreview, error)
 }
 
 type coordinator struct {
@@ -62,6 +113,52 @@ type coordinator struct {
 	history     history.Service
 	lspClients  *csync.Map[string, *lsp.Client]
 
+	hooks *hooks.Runner
+
+	// oauthRefreshLocks holds the lock of the token refreshes of each
+	// provider, so the models of a provider never refresh its token
+	// concurrently.
+	oauthRefreshLocks *csync.Map[string, *sync.Mutex]
+	// requestLimiters holds the limiter of each provider with
+	// max_concurrent_requests, so that every model of the provider shares
+	// it.
+	requestLimiters *csync.Map[string, *requestLimiter]
+	// providerTransports holds the transports of the connections to the
+	// providers.
+	providerTransports *csync.Map[providerTransportKey, *http.Transport]
+
+	// subAgentSlots limits how many sub-agents run at once; it is nil when
+	// there is no limit.
+	subAgentSlots chan struct{}
+	// subAgents holds the cancel functions of the running sub-agents by
+	// tool call ID.
+	subAgents *csync.Map[string, context.CancelFunc]
+
+	// runningTools holds the skip functions of the running tool calls by
+	// ID.
+	runningTools *csync.Map[string, context.CancelFunc]
+	// extendableTools holds the extend functions of the running tool calls
+	// with a timeout or a soft timeout by ID.
+	extendableTools *csync.Map[string, func() bool]
+	// toolCache holds the results of read-only tool calls for as long as
+	// the files they read don't change.
+	toolCache *toolCache
+	// editLocks holds the locks on the files sessions changed, shared with
+	// the other Crush instances in the working directory.
+	editLocks *editlock.Registry
+	// trash keeps the contents the file tools replace; it is nil when
+	// they aren't kept.
+	trash *trash.Store
+
+	// comparisons holds the cancel functions of the running comparisons by
+	// the ID of the session they were asked in.
+	comparisons *csync.Map[string, context.CancelFunc]
+
+	// reasoningLevels holds the reasoning levels set for sessions.
+	reasoningLevels *csync.Map[string, ReasoningLevel]
+	// scopes holds the scopes set for sessions.
+	scopes *csync.Map[string, string]
+
 	currentAgent SessionAgent
 	agents       map[string]SessionAgent
 
@@ -85,6 +182,30 @@ func NewCoordinator(
 		history:     history,
 		lspClients:  lspClients,
 		agents:      make(map[string]SessionAgent),
+		hooks:       hooks.New(cfg.Hooks, cfg.WorkingDir()),
+
+		oauthRefreshLocks:  csync.NewMap[string, *sync.Mutex](),
+		requestLimiters:    csync.NewMap[string, *requestLimiter](),
+		providerTransports: csync.NewMap[providerTransportKey, *http.Transport](),
+		subAgents:          csync.NewMap[string, context.CancelFunc](),
+		runningTools:       csync.NewMap[string, context.CancelFunc](),
+		extendableTools:    csync.NewMap[string, func() bool](),
+		toolCache:          newToolCache(cfg.WorkingDir(), lspClients),
+		editLocks:          editlock.ForDataDir(cfg.Options.DataDirectory),
+		comparisons:        csync.NewMap[string, context.CancelFunc](),
+		reasoningLevels:    csync.NewMap[string, ReasoningLevel](),
+		scopes:             csync.NewMap[string, string](),
+	}
+	if retention := cfg.Options.Trash.Retention(); retention > 0 {
+		c.trash = trash.ForDataDir(cfg.Options.DataDirectory, retention)
+		go func() {
+			if err := c.trash.Prune(); err != nil {
+				slog.Warn("Failed to prune the trash", "error", err)
+			}
+		}()
+	}
+	if cfg.Options.MaxSubAgents > 0 {
+		c.subAgentSlots = make(chan struct{}, cfg.Options.MaxSubAgents)
 	}
 
 	agentCfg, ok := cfg.Agents[config.AgentCoder]
@@ -93,7 +214,11 @@ func NewCoordinator(
 	}
 
 	// TODO: make this dynamic when we support multiple agents
-	prompt, err := coderPrompt(prompt.WithWorkingDir(c.cfg.WorkingDir()))
+	prompt, err := coderPrompt(
+		prompt.WithWorkingDir(c.cfg.WorkingDir()),
+		prompt.WithSystemPrompt(agentCfg.SystemPrompt),
+		prompt.WithSystemPromptAppend(agentCfg.SystemPromptAppend),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -113,7 +238,111 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 		return nil, err
 	}
 
-	model := c.currentAgent.Model()
+	overrides, prompt, err := parsePromptOverrides(prompt)
+	if err != nil {
+		return nil, err
+	}
+	if len(overrides.Compare) > 0 {
+		return c.compare(ctx, sessionID, prompt, overrides, attachments)
+	}
+	if _, ok := c.comparisons.Get(sessionID); ok {
+		return nil, ErrSessionBusy
+	}
+	return c.run(ctx, sessionID, prompt, overrides, attachments)
+}
+
+// Retry implements Coordinator.
+func (c *coordinator) Retry(ctx context.Context, sessionID, model string) (*fantasy.AgentResult, error) {
+	if err := c.readyWg.Wait(); err != nil {
+		return nil, err
+	}
+	if c.IsSessionBusy(sessionID) {
+		return nil, ErrSessionBusy
+	}
+	msgs, err := c.messages.List(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	last := -1
+	for i, msg := range msgs {
+		if msg.Role == message.User {
+			last = i
+		}
+	}
+	if last < 0 {
+		return nil, errors.New("there is no message to retry")
+	}
+	if model != "" {
+		if _, ok := c.findModel(c.currentAgent.Model().ModelCfg.Provider, model); !ok {
+			return nil, fmt.Errorf("model %q not found in the configured providers", model)
+		}
+	}
+	return c.resend(ctx, sessionID, msgs, last, msgs[last].Content().Text, promptOverrides{Model: model})
+}
+
+// Resend drops the user message with messageID and everything after it, and
+// sends prompt instead, with the attachments of the message.
+func (c *coordinator) Resend(ctx context.Context, sessionID, messageID, prompt string) (*fantasy.AgentResult, error) {
+	if err := c.readyWg.Wait(); err != nil {
+		return nil, err
+	}
+	if c.IsSessionBusy(sessionID) {
+		return nil, ErrSessionBusy
+	}
+	if strings.TrimSpace(prompt) == "" {
+		return nil, ErrEmptyPrompt
+	}
+	msgs, err := c.messages.List(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	index := slices.IndexFunc(msgs, func(msg message.Message) bool { return msg.ID == messageID })
+	if index < 0 || msgs[index].Role != message.User {
+		return nil, errors.New("there is no such message to resend")
+	}
+	return c.resend(ctx, sessionID, msgs, index, prompt, promptOverrides{})
+}
+
+// resend drops msgs from the user message at index on, and sends prompt
+// with the attachments of that message.
+func (c *coordinator) resend(ctx context.Context, sessionID string, msgs []message.Message, index int, prompt string, overrides promptOverrides) (*fantasy.AgentResult, error) {
+	for _, msg := range msgs[index+1:] {
+		if msg.IsSummaryMessage {
+			return nil, errors.New("the session was summarized since the message")
+		}
+	}
+
+	userMsg := msgs[index]
+	var attachments []message.Attachment
+	for _, part := range userMsg.BinaryContent() {
+		attachments = append(attachments, message.Attachment{
+			FilePath: part.Path,
+			FileName: filepath.Base(part.Path),
+			MimeType: part.MIMEType,
+			Content:  part.Data,
+		})
+	}
+	// Drop the turns, newest first, so that the user message is re-created
+	// by the run.
+	for _, msg := range slices.Backward(msgs[index:]) {
+		if err := c.messages.Delete(ctx, msg.ID); err != nil {
+			return nil, fmt.Errorf("failed to remove the previous response: %w", err)
+		}
+	}
+	return c.run(ctx, sessionID, prompt, overrides, attachments)
+}
+
+func (c *coordinator) run(ctx context.Context, sessionID, prompt string, overrides promptOverrides, attachments []message.Attachment) (*fantasy.AgentResult, error) {
+	model := c.sessionModel(sessionID)
+	var callModel *Model
+	if overrides.Model != "" {
+		var err error
+		model, err = c.overrideModel(ctx, model, overrides.Model)
+		if err != nil {
+			return nil, err
+		}
+		callModel = &model
+	}
 	maxTokens := model.CatwalkCfg.DefaultMaxTokens
 	if model.ModelCfg.MaxTokens != 0 {
 		maxTokens = model.ModelCfg.MaxTokens
@@ -129,8 +358,11 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 	}
 
 	mergedOptions, temp, topP, topK, freqPenalty, presPenalty := mergeCallOptions(model, providerCfg)
+	if overrides.Temperature != nil {
+		temp = overrides.Temperature
+	}
 
-	return c.currentAgent.Run(ctx, SessionAgentCall{
+	result, err := c.currentAgent.Run(ctx, SessionAgentCall{
 		SessionID:        sessionID,
 		Prompt:           prompt,
 		Attachments:      attachments,
@@ -141,7 +373,96 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 		TopK:             topK,
 		FrequencyPenalty: freqPenalty,
 		PresencePenalty:  presPenalty,
+		Model:            callModel,
 	})
+	// A queued prompt returns right away; the hooks run once the session
+	// is done with all of them.
+	if c.hooks.Has(hooks.OnSessionEnd) && !c.currentAgent.IsSessionBusy(sessionID) {
+		c.hooks.Run(context.WithoutCancel(ctx), "", hooks.Payload{
+			Event:     hooks.OnSessionEnd,
+			SessionID: sessionID,
+		})
+	}
+	return result, err
+}
+
+func (c *coordinator) RunWithSchema(ctx context.Context, sessionID, prompt string, outputSchema *OutputSchema, attachments ...message.Attachment) (json.RawMessage, error) {
+	if c.IsSessionBusy(sessionID) {
+		// Run would only queue the prompt.
+		return nil, ErrSessionBusy
+	}
+	if _, err := c.Run(ctx, sessionID, prompt, attachments...); err != nil {
+		return nil, err
+	}
+
+	model := c.sessionModel(sessionID)
+	providerCfg, ok := c.cfg.Providers.Get(model.ModelCfg.Provider)
+	if !ok {
+		return nil, errors.New("model provider not configured")
+	}
+	return c.currentAgent.GenerateObject(ctx, sessionID, outputSchema, getProviderOptions(model, providerCfg))
+}
+
+// sessionModel returns the current model with the reasoning level set for
+// the session, if any.
+func (c *coordinator) sessionModel(sessionID string) Model {
+	model := c.currentAgent.Model()
+	if level, ok := c.reasoningLevels.Get(sessionID); ok {
+		model.Reasoning = level
+	}
+	return model
+}
+
+// ReasoningLevel implements Coordinator.
+func (c *coordinator) ReasoningLevel(sessionID string) (ReasoningLevel, bool) {
+	if level, ok := c.reasoningLevels.Get(sessionID); ok {
+		return level, true
+	}
+	model := c.currentAgent.Model()
+	providerCfg, _ := c.cfg.Providers.Get(model.ModelCfg.Provider)
+	return configuredReasoningLevel(model, providerCfg.Type), false
+}
+
+// SetReasoningLevel implements Coordinator.
+func (c *coordinator) SetReasoningLevel(sessionID string, level ReasoningLevel) {
+	c.reasoningLevels.Set(sessionID, level)
+}
+
+// Scope implements Coordinator.
+func (c *coordinator) Scope(sessionID string) string {
+	if scope, ok := c.scopes.Get(sessionID); ok {
+		return scope
+	}
+	return c.cfg.Scope()
+}
+
+// SetScope implements Coordinator.
+func (c *coordinator) SetScope(sessionID, dir string) error {
+	scope, err := fsext.ResolveScope(c.cfg.WorkingDir(), dir)
+	if err != nil {
+		return err
+	}
+	if sessionID == "" {
+		return c.cfg.SetScope(scope)
+	}
+	c.scopes.Set(sessionID, scope)
+	return nil
+}
+
+// sessionScope returns the scope of a session, which sub-agent sessions
+// take from the session that started them.
+func (c *coordinator) sessionScope(ctx context.Context, sessionID string) string {
+	for range maxScopeDepth {
+		if scope, ok := c.scopes.Get(sessionID); ok {
+			return scope
+		}
+		s, err := c.sessions.Get(ctx, sessionID)
+		if err != nil || s.ParentSessionID == "" {
+			break
+		}
+		sessionID = s.ParentSessionID
+	}
+	return c.cfg.Scope()
 }
 
 func getProviderOptions(model Model, providerCfg config.ProviderConfig) fantasy.ProviderOptions {
@@ -192,6 +513,13 @@ func getProviderOptions(model Model, providerCfg config.ProviderConfig) fantasy.
 		return options
 	}
 
+	if model.Reasoning != "" {
+		setReasoningLevel(mergedOptions, model, providerCfg.Type)
+		// The level replaces the reasoning configuration of the model.
+		model.ModelCfg.Think = false
+		model.ModelCfg.ReasoningEffort = ""
+	}
+
 	switch providerCfg.Type {
 	case openai.Name, azure.Name:
 		_, hasReasoningEffort := mergedOptions["reasoning_effort"]
@@ -250,7 +578,7 @@ func getProviderOptions(model Model, providerCfg config.ProviderConfig) fantasy.
 		if err == nil {
 			options[google.Name] = parsed
 		}
-	case openaicompat.Name:
+	case openaicompat.Name, copilot.Name:
 		_, hasReasoningEffort := mergedOptions["reasoning_effort"]
 		if !hasReasoningEffort && model.ModelCfg.ReasoningEffort != "" {
 			mergedOptions["reasoning_effort"] = model.ModelCfg.ReasoningEffort
@@ -292,6 +620,7 @@ func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, age
 		largeProviderCfg.SystemPromptPrefix,
 		systemPrompt,
 		c.cfg.Options.DisableAutoSummarize,
+		c.cfg.Options.Compaction,
 		c.permissions.SkipRequests(),
 		c.sessions,
 		c.messages,
@@ -335,21 +664,32 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 		}
 	}
 
+	var fetchCacheDir string
+	if !c.cfg.Tools.Fetch.DisableCache {
+		fetchCacheDir = filepath.Join(c.cfg.Options.DataDirectory, "fetch_cache")
+	}
+
 	allTools = append(allTools,
-		tools.NewBashTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Options.Attribution, modelName),
+		tools.NewBashTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Options.Attribution, modelName, c.cfg.Options.Tools.ShellType()),
 		tools.NewJobOutputTool(),
 		tools.NewJobKillTool(),
-		tools.NewDownloadTool(c.permissions, c.cfg.WorkingDir(), nil),
-		tools.NewEditTool(c.lspClients, c.permissions, c.history, c.cfg.WorkingDir()),
-		tools.NewMultiEditTool(c.lspClients, c.permissions, c.history, c.cfg.WorkingDir()),
-		tools.NewFetchTool(c.permissions, c.cfg.WorkingDir(), nil),
+		tools.NewRunTestsTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Options.Tools.ShellType(), c.cfg.Tools.RunTests),
+		tools.NewDownloadTool(c.permissions, c.cfg.WorkingDir(), nil, c.cfg.Tools.Download),
+		tools.NewEditTool(c.lspClients, c.permissions, c.history, c.editLocks, c.trash, c.cfg.WorkingDir()),
+		tools.NewMultiEditTool(c.lspClients, c.permissions, c.history, c.editLocks, c.trash, c.cfg.WorkingDir()),
+		tools.NewApplyPatchTool(c.lspClients, c.permissions, c.history, c.editLocks, c.trash, c.cfg.WorkingDir()),
+		tools.NewFetchTool(c.permissions, c.cfg.WorkingDir(), nil, c.cfg.Tools.Fetch, fetchCacheDir),
 		tools.NewGlobTool(c.cfg.WorkingDir()),
 		tools.NewGrepTool(c.cfg.WorkingDir()),
+		tools.NewGitLogTool(c.cfg.WorkingDir()),
+		tools.NewGitBlameTool(c.cfg.WorkingDir()),
 		tools.NewLsTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Tools.Ls),
-		tools.NewSourcegraphTool(nil),
-		tools.NewViewTool(c.lspClients, c.permissions, c.cfg.WorkingDir()),
-		tools.NewWriteTool(c.lspClients, c.permissions, c.history, c.cfg.WorkingDir()),
+		tools.NewSourcegraphTool(nil, c.cfg.Tools.Sourcegraph),
+		tools.NewViewTool(c.lspClients, c.permissions, c.cfg.WorkingDir(), c.cfg.Tools.View),
+		tools.NewWriteTool(c.lspClients, c.permissions, c.history, c.editLocks, c.trash, c.cfg.WorkingDir()),
+		tools.NewTodoTool(c.sessions),
 	)
+	allTools = append(allTools, tools.NewProjectCommandTools(c.cfg.WorkingDir(), c.cfg.Options.Tools.ShellType(), c.cfg.Project.Commands)...)
 
 	if len(c.cfg.LSP) > 0 {
 		allTools = append(allTools, tools.NewDiagnosticsTool(c.lspClients), tools.NewReferencesTool(c.lspClients))
@@ -387,7 +727,10 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 	slices.SortFunc(filteredTools, func(a, b fantasy.AgentTool) int {
 		return strings.Compare(a.Info().Name, b.Info().Name)
 	})
-	return filteredTools, nil
+	filteredTools = withLimits(agent.ToolLimitsFor(c.cfg.Options.Tools), c.extendableTools, filteredTools)
+	filteredTools = withTelemetry(withCaching(c.toolCache, withDiagnostics(c.lspClients, c.cfg.WorkingDir(), filteredTools)))
+	filteredTools = withScope(c.cfg.WorkingDir(), c.sessionScope, filteredTools)
+	return withSkipping(c.runningTools, withHooks(c.hooks, filteredTools)), nil
 }
 
 // TODO: when we support multiple agents we need to change this so that we pass in the agent specific model config
@@ -474,7 +817,7 @@ func (c *coordinator) buildAgentModels(ctx context.Context) (Model, Model, error
 		}, nil
 }
 
-func (c *coordinator) buildAnthropicProvider(baseURL, apiKey string, headers map[string]string) (fantasy.Provider, error) {
+func (c *coordinator) buildAnthropicProvider(httpClient *http.Client, baseURL, apiKey string, headers map[string]string) (fantasy.Provider, error) {
 	var opts []anthropic.Option
 
 	if strings.HasPrefix(apiKey, "Bearer ") {
@@ -495,21 +838,19 @@ func (c *coordinator) buildAnthropicProvider(baseURL, apiKey string, headers map
 		opts = append(opts, anthropic.WithBaseURL(baseURL))
 	}
 
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient != nil {
 		opts = append(opts, anthropic.WithHTTPClient(httpClient))
 	}
 
 	return anthropic.New(opts...)
 }
 
-func (c *coordinator) buildOpenaiProvider(baseURL, apiKey string, headers map[string]string) (fantasy.Provider, error) {
+func (c *coordinator) buildOpenaiProvider(httpClient *http.Client, baseURL, apiKey string, headers map[string]string, extraBody map[string]any) (fantasy.Provider, error) {
 	opts := []openai.Option{
 		openai.WithAPIKey(apiKey),
 		openai.WithUseResponsesAPI(),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient != nil {
 		opts = append(opts, openai.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -518,15 +859,17 @@ func (c *coordinator) buildOpenaiProvider(baseURL, apiKey string, headers map[st
 	if baseURL != "" {
 		opts = append(opts, openai.WithBaseURL(baseURL))
 	}
+	for extraKey, extraValue := range extraBody {
+		opts = append(opts, openai.WithSDKOptions(openaisdk.WithJSONSet(extraKey, extraValue)))
+	}
 	return openai.New(opts...)
 }
 
-func (c *coordinator) buildOpenrouterProvider(_, apiKey string, headers map[string]string) (fantasy.Provider, error) {
+func (c *coordinator) buildOpenrouterProvider(httpClient *http.Client, _, apiKey string, headers map[string]string) (fantasy.Provider, error) {
 	opts := []openrouter.Option{
 		openrouter.WithAPIKey(apiKey),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient != nil {
 		opts = append(opts, openrouter.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -535,13 +878,12 @@ func (c *coordinator) buildOpenrouterProvider(_, apiKey string, headers map[stri
 	return openrouter.New(opts...)
 }
 
-func (c *coordinator) buildOpenaiCompatProvider(baseURL, apiKey string, headers map[string]string, extraBody map[string]any) (fantasy.Provider, error) {
+func (c *coordinator) buildOpenaiCompatProvider(httpClient *http.Client, baseURL, apiKey string, headers map[string]string, extraBody map[string]any) (fantasy.Provider, error) {
 	opts := []openaicompat.Option{
 		openaicompat.WithBaseURL(baseURL),
 		openaicompat.WithAPIKey(apiKey),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient != nil {
 		opts = append(opts, openaicompat.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -555,14 +897,13 @@ func (c *coordinator) buildOpenaiCompatProvider(baseURL, apiKey string, headers
 	return openaicompat.New(opts...)
 }
 
-func (c *coordinator) buildAzureProvider(baseURL, apiKey string, headers map[string]string, options map[string]string) (fantasy.Provider, error) {
+func (c *coordinator) buildAzureProvider(httpClient *http.Client, baseURL, apiKey string, headers map[string]string, options map[string]string) (fantasy.Provider, error) {
 	opts := []azure.Option{
 		azure.WithBaseURL(baseURL),
 		azure.WithAPIKey(apiKey),
 		azure.WithUseResponsesAPI(),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient != nil {
 		opts = append(opts, azure.WithHTTPClient(httpClient))
 	}
 	if options == nil {
@@ -578,10 +919,9 @@ func (c *coordinator) buildAzureProvider(baseURL, apiKey string, headers map[str
 	return azure.New(opts...)
 }
 
-func (c *coordinator) buildBedrockProvider(headers map[string]string) (fantasy.Provider, error) {
+func (c *coordinator) buildBedrockProvider(httpClient *http.Client, headers map[string]string) (fantasy.Provider, error) {
 	var opts []bedrock.Option
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient != nil {
 		opts = append(opts, bedrock.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -594,13 +934,12 @@ func (c *coordinator) buildBedrockProvider(headers map[string]string) (fantasy.P
 	return bedrock.New(opts...)
 }
 
-func (c *coordinator) buildGoogleProvider(baseURL, apiKey string, headers map[string]string) (fantasy.Provider, error) {
+func (c *coordinator) buildGoogleProvider(httpClient *http.Client, baseURL, apiKey string, headers map[string]string) (fantasy.Provider, error) {
 	opts := []google.Option{
 		google.WithBaseURL(baseURL),
 		google.WithGeminiAPIKey(apiKey),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient != nil {
 		opts = append(opts, google.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -609,10 +948,9 @@ func (c *coordinator) buildGoogleProvider(baseURL, apiKey string, headers map[st
 	return google.New(opts...)
 }
 
-func (c *coordinator) buildGoogleVertexProvider(headers map[string]string, options map[string]string) (fantasy.Provider, error) {
+func (c *coordinator) buildGoogleVertexProvider(httpClient *http.Client, headers map[string]string, options map[string]string) (fantasy.Provider, error) {
 	opts := []google.Option{}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient != nil {
 		opts = append(opts, google.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -627,6 +965,60 @@ func (c *coordinator) buildGoogleVertexProvider(headers map[string]string, optio
 	return google.New(opts...)
 }
 
+// buildHTTPClient returns the HTTP client providers should use.
+func (c *coordinator) buildHTTPClient(providerCfg config.ProviderConfig) *http.Client {
+	keepAlive := providerCfg.Timeouts.KeepAliveInterval()
+	key := providerTransportKey{keepAlive: keepAlive, network: httpext.DefaultTransport()}
+	conns := c.providerTransports.GetOrSet(key, func() *http.Transport {
+		c.dropProviderTransports(key.network)
+		return newProviderTransport(keepAlive)
+	})
+	var transport http.RoundTripper = newTimeoutTransport(conns, providerCfg.Timeouts)
+	if mode, dir, err := replay.FromEnv(c.cfg.Options.DataDirectory); err != nil {
+		slog.Warn("Ignoring CRUSH_REPLAY", "error", err)
+	} else if mode != replay.Off {
+		apiKey, _ := c.cfg.Resolve(providerCfg.APIKey)
+		transport = &replay.Transport{Mode: mode, Dir: dir, Scrubber: replay.NewScrubber(apiKey), Base: transport}
+	}
+	if c.cfg.Options.Debug {
+		transport = &log.HTTPRoundTripLogger{Transport: transport}
+	}
+	if c.cfg.Options.DebugTranscript {
+		transport = transcript.NewRecorder(c.cfg.Options.DataDirectory, providerCfg.ID, transport)
+	}
+	if refresh := providerCfg.OAuthRefresher(); providerCfg.OAuthToken != nil && refresh != nil {
+		transport = &oauth.RefreshTransport{
+			Base:    transport,
+			Token:   func() *oauth.Token { return c.oauthToken(providerCfg.ID) },
+			Refresh: refresh,
+			Save: func(token *oauth.Token) error {
+				return c.cfg.SetProviderAPIKey(providerCfg.ID, token)
+			},
+			Lock: c.oauthRefreshLocks.GetOrSet(providerCfg.ID, func() *sync.Mutex { return &sync.Mutex{} }),
+		}
+	}
+	if providerCfg.Type == copilot.Name {
+		transport = &copilot.Transport{Base: transport}
+	}
+	if limit := providerCfg.MaxConcurrentRequests; limit > 0 {
+		limiter, ok := c.requestLimiters.Get(providerCfg.ID)
+		if !ok || cap(limiter.slots) != limit {
+			limiter = newRequestLimiter(providerCfg.ID, limit)
+			c.requestLimiters.Set(providerCfg.ID, limiter)
+		}
+		transport = &limitedTransport{base: transport, limiter: limiter}
+	}
+	return &http.Client{Transport: transport}
+}
+
+func (c *coordinator) oauthToken(providerID string) *oauth.Token {
+	providerCfg, ok := c.cfg.Providers.Get(providerID)
+	if !ok {
+		return nil
+	}
+	return providerCfg.OAuthToken
+}
+
 func (c *coordinator) isAnthropicThinking(model config.SelectedModel) bool {
 	if model.Think {
 		return true
@@ -663,24 +1055,25 @@ func (c *coordinator) buildProvider(providerCfg config.ProviderConfig, model con
 
 	apiKey, _ := c.cfg.Resolve(providerCfg.APIKey)
 	baseURL, _ := c.cfg.Resolve(providerCfg.BaseURL)
+	httpClient := c.buildHTTPClient(providerCfg)
 
 	switch providerCfg.Type {
 	case openai.Name:
-		return c.buildOpenaiProvider(baseURL, apiKey, headers)
+		return c.buildOpenaiProvider(httpClient, baseURL, apiKey, headers, providerCfg.ExtraBody)
 	case anthropic.Name:
-		return c.buildAnthropicProvider(baseURL, apiKey, headers)
+		return c.buildAnthropicProvider(httpClient, baseURL, apiKey, headers)
 	case openrouter.Name:
-		return c.buildOpenrouterProvider(baseURL, apiKey, headers)
+		return c.buildOpenrouterProvider(httpClient, baseURL, apiKey, headers)
 	case azure.Name:
-		return c.buildAzureProvider(baseURL, apiKey, headers, providerCfg.ExtraParams)
+		return c.buildAzureProvider(httpClient, baseURL, apiKey, headers, providerCfg.ExtraParams)
 	case bedrock.Name:
-		return c.buildBedrockProvider(headers)
+		return c.buildBedrockProvider(httpClient, headers)
 	case google.Name:
-		return c.buildGoogleProvider(baseURL, apiKey, headers)
+		return c.buildGoogleProvider(httpClient, baseURL, apiKey, headers)
 	case "google-vertex":
-		return c.buildGoogleVertexProvider(headers, providerCfg.ExtraParams)
-	case openaicompat.Name:
-		return c.buildOpenaiCompatProvider(baseURL, apiKey, headers, providerCfg.ExtraBody)
+		return c.buildGoogleVertexProvider(httpClient, headers, providerCfg.ExtraParams)
+	case openaicompat.Name, copilot.Name:
+		return c.buildOpenaiCompatProvider(httpClient, baseURL, apiKey, headers, providerCfg.ExtraBody)
 	default:
 		return nil, fmt.Errorf("provider type not supported: %q", providerCfg.Type)
 	}
@@ -698,10 +1091,16 @@ func isExactoSupported(modelID string) bool {
 }
 
 func (c *coordinator) Cancel(sessionID string) {
+	if cancel, ok := c.comparisons.Get(sessionID); ok {
+		cancel()
+	}
 	c.currentAgent.Cancel(sessionID)
 }
 
 func (c *coordinator) CancelAll() {
+	for cancel := range c.comparisons.Seq() {
+		cancel()
+	}
 	c.currentAgent.CancelAll()
 }
 
@@ -714,6 +1113,9 @@ func (c *coordinator) IsBusy() bool {
 }
 
 func (c *coordinator) IsSessionBusy(sessionID string) bool {
+	if _, ok := c.comparisons.Get(sessionID); ok {
+		return true
+	}
 	return c.currentAgent.IsSessionBusy(sessionID)
 }
 
@@ -728,6 +1130,7 @@ func (c *coordinator) UpdateModels(ctx context.Context) error {
 		return err
 	}
 	c.currentAgent.SetModels(large, small)
+	c.hooks.Set(c.cfg.Hooks)
 
 	agentCfg, ok := c.cfg.Agents[config.AgentCoder]
 	if !ok {
@@ -739,6 +1142,8 @@ func (c *coordinator) UpdateModels(ctx context.Context) error {
 		return err
 	}
 	c.currentAgent.SetTools(tools)
+	c.currentAgent.SetDisableAutoSummarize(c.cfg.Options.DisableAutoSummarize)
+	c.currentAgent.SetCompaction(c.cfg.Options.Compaction)
 	return nil
 }
 
@@ -746,6 +1151,15 @@ func (c *coordinator) QueuedPrompts(sessionID string) int {
 	return c.currentAgent.QueuedPrompts(sessionID)
 }
 
+func (c *coordinator) ContextPreview(ctx context.Context, sessionID string) (ContextPreview, error) {
+	preview, err := c.currentAgent.ContextPreview(ctx, sessionID)
+	if err != nil {
+		return ContextPreview{}, err
+	}
+	preview.ContextFiles = contextFileParts(prompt.ContextFiles(*c.cfg), c.cfg.WorkingDir())
+	return preview, nil
+}
+
 func (c *coordinator) Summarize(ctx context.Context, sessionID string) error {
 	providerCfg, ok := c.cfg.Providers.Get(c.currentAgent.Model().ModelCfg.Provider)
 	if !ok {
@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"context"
+	"slices"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/lsp"
+)
+
+// diagnosedTool adds the diagnostics of the files a shell command changed
+// to its result, as the edit tools do for the files they edit. The changes
+// are found in the git status of the working directory, before and after
+// the command.
+type diagnosedTool struct {
+	fantasy.AgentTool
+	lspClients *csync.Map[string, *lsp.Client]
+	workingDir string
+}
+
+func withDiagnostics(lspClients *csync.Map[string, *lsp.Client], workingDir string, agentTools []fantasy.AgentTool) []fantasy.AgentTool {
+	wrapped := make([]fantasy.AgentTool, len(agentTools))
+	for i, tool := range agentTools {
+		if tool.Info().Name != tools.BashToolName {
+			wrapped[i] = tool
+			continue
+		}
+		wrapped[i] = &diagnosedTool{AgentTool: tool, lspClients: lspClients, workingDir: workingDir}
+	}
+	return wrapped
+}
+
+func (t *diagnosedTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	if !t.autoDiagnostics() {
+		return t.AgentTool.Run(ctx, call)
+	}
+	before, err := changedFileStamps(ctx, t.workingDir)
+	if err != nil {
+		return t.AgentTool.Run(ctx, call)
+	}
+	resp, err := t.AgentTool.Run(ctx, call)
+	if err != nil {
+		return resp, err
+	}
+	after, err := changedFileStamps(ctx, t.workingDir)
+	if err != nil {
+		return resp, nil
+	}
+
+	var changed []string
+	for path, stamp := range after {
+		if before[path] != stamp && stamp != "" {
+			changed = append(changed, path)
+		}
+	}
+	// Files changed back to how they were committed are no longer listed.
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			if _, ok := fileStamp(path); ok {
+				changed = append(changed, path)
+			}
+		}
+	}
+	if len(changed) == 0 {
+		return resp, nil
+	}
+	slices.Sort(changed)
+	return tools.WithDiagnostics(ctx, t.lspClients, resp, changed...), nil
+}
+
+func (t *diagnosedTool) autoDiagnostics() bool {
+	for client := range t.lspClients.Seq() {
+		if client.AutoDiagnostics() {
+			return true
+		}
+	}
+	return false
+}
+
+// changedFileStamps returns the stamps of the files git status lists in
+// the repository dir is in, by path. Deleted files have empty stamps.
+func changedFileStamps(ctx context.Context, dir string) (map[string]string, error) {
+	files, err := fsext.GitChangedFiles(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	stamps := make(map[string]string, len(files))
+	for _, path := range files {
+		stamps[path], _ = fileStamp(path)
+	}
+	return stamps, nil
+}
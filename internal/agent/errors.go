@@ -3,6 +3,10 @@ package agent
 import (
 	"context"
 	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
 )
 
 var (
@@ -10,8 +14,57 @@ var (
 	ErrSessionBusy      = errors.New("session is currently processing another request")
 	ErrEmptyPrompt      = errors.New("prompt is empty")
 	ErrSessionMissing   = errors.New("session id is missing")
+
+	ErrOutputSchemaViolation = errors.New("response doesn't conform to the output schema")
+	ErrSubAgentCancelled     = errors.New("sub-agent canceled by user")
+	ErrSubAgentOverBudget    = errors.New("sub-agent went over its budget")
 )
 
 func isCancelledErr(err error) bool {
 	return errors.Is(err, context.Canceled) || errors.Is(err, ErrRequestCancelled)
 }
+
+// isStreamInterrupted reports whether err looks like the connection to the
+// provider dropped while a response was being streamed, as opposed to the
+// provider rejecting the request.
+func isStreamInterrupted(err error) bool {
+	if err == nil || isCancelledErr(err) {
+		return false
+	}
+	if isStreamStalled(err) {
+		return true
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	// Provider SDKs don't always wrap the underlying transport error, so fall
+	// back to matching on the message.
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{
+		"unexpected eof",
+		"connection reset",
+		"broken pipe",
+		"stream error",
+		"http2: response body closed",
+		"use of closed network connection",
+	} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// isStreamStalled reports whether err is the provider going silent for
+// longer than the stream idle timeout of its transport.
+func isStreamStalled(err error) bool {
+	return errors.Is(err, errStreamStalled) ||
+		(err != nil && strings.Contains(err.Error(), errStreamStalled.Error()))
+}
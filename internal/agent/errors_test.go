@@ -0,0 +1,113 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+
+	"charm.land/fantasy"
+	"charm.land/fantasy/providers/openai"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsStreamInterrupted(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"canceled", context.Canceled, false},
+		{"user canceled", fmt.Errorf("run: %w", ErrRequestCancelled), false},
+		{"unexpected eof", fmt.Errorf("stream: %w", io.ErrUnexpectedEOF), true},
+		{"connection reset", fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{"unwrapped message", errors.New("read tcp 10.0.0.1:443: connection reset by peer"), true},
+		{"http2 stream error", errors.New("stream error: stream ID 3; INTERNAL_ERROR"), true},
+		{"stalled stream", fmt.Errorf("stream: %w", errStreamStalled), true},
+		{"unwrapped stalled stream", errors.New("sse: " + errStreamStalled.Error()), true},
+		{"provider error", errors.New("invalid api key"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, isStreamInterrupted(tt.err))
+		})
+	}
+}
+
+// droppingModel is a language model whose first stream drops after some
+// text, and whose next ones answer.
+type droppingModel struct {
+	fantasy.LanguageModel
+	prompts []fantasy.Prompt
+}
+
+func (m *droppingModel) Stream(_ context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	m.prompts = append(m.prompts, call.Prompt)
+	dropped := len(m.prompts) == 1
+	return func(yield func(fantasy.StreamPart) bool) {
+		if dropped {
+			_ = yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeTextStart, ID: "text"}) &&
+				yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeTextDelta, ID: "text", Delta: "hel"}) &&
+				yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeError, Error: io.ErrUnexpectedEOF})
+			return
+		}
+		_ = yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeTextStart, ID: "text"}) &&
+			yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeTextDelta, ID: "text", Delta: "lo"}) &&
+			yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeTextEnd, ID: "text"}) &&
+			yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeFinish, FinishReason: fantasy.FinishReasonStop})
+	}, nil
+}
+
+func (m *droppingModel) Provider() string { return "fake" }
+func (m *droppingModel) Model() string    { return "fake" }
+
+func TestRunContinuesDroppedStream(t *testing.T) {
+	env := testEnv(t)
+	cfg, err := config.Init(env.workingDir, "", false)
+	require.NoError(t, err)
+	// Streams of responses models are continued on their own.
+	cfg.Providers.Set("dropping", config.ProviderConfig{ID: "dropping", Type: openai.Name})
+
+	model := &droppingModel{}
+	large := Model{
+		Model:      model,
+		CatwalkCfg: catwalk.Model{ID: "gpt-4.1", ContextWindow: 200000, DefaultMaxTokens: 10000},
+		ModelCfg:   config.SelectedModel{Provider: "dropping", Model: "gpt-4.1"},
+	}
+	agent := NewSessionAgent(SessionAgentOptions{LargeModel: large, SmallModel: large, IsYolo: true, Sessions: env.sessions, Messages: env.messages})
+	sess, err := env.sessions.Create(t.Context(), "dropped")
+	require.NoError(t, err)
+	// Not the first message, for no title to be generated.
+	_, err = env.messages.Create(t.Context(), sess.ID, message.CreateMessageParams{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: "hello"}},
+	})
+	require.NoError(t, err)
+
+	_, err = agent.Run(t.Context(), SessionAgentCall{SessionID: sess.ID, Prompt: "say hello", MaxOutputTokens: 100})
+	require.NoError(t, err)
+
+	require.Len(t, model.prompts, 2)
+	retry := model.prompts[1]
+	require.Equal(t, fantasy.MessageRoleAssistant, retry[len(retry)-2].Role, "the partial response is sent")
+	last := retry[len(retry)-1]
+	require.Equal(t, fantasy.MessageRoleUser, last.Role)
+	require.Equal(t, ContinuePrompt, last.Content[0].(fantasy.TextPart).Text, "the model is asked to continue it")
+
+	msgs, err := env.messages.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	var texts []string
+	for _, msg := range msgs {
+		texts = append(texts, string(msg.Role)+": "+msg.Content().Text)
+	}
+	require.Equal(t, []string{"user: hello", "user: say hello", "assistant: hel", "assistant: lo"}, texts, "the continue prompt isn't added to the conversation")
+}
@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"bytes"
+	"image"
+	_ "image/jpeg" // register the decoders used to size image attachments
+	_ "image/png"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/message"
+)
+
+const (
+	// imagePixelsPerToken and maxImageTokens follow how Anthropic sizes
+	// images, which is close enough for other providers.
+	imagePixelsPerToken = 750
+	maxImageTokens      = 1600
+)
+
+// EstimateTokens approximates how many tokens text takes without a
+// tokenizer: a token for every six letters of a word, every three digits
+// of a number, and every other character but spaces.
+func EstimateTokens(text string) int {
+	tokens := 0
+	letters, digits := 0, 0
+	flush := func() {
+		tokens += (letters+5)/6 + (digits+2)/3
+		letters, digits = 0, 0
+	}
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r):
+			if digits > 0 {
+				flush()
+			}
+			letters++
+		case unicode.IsDigit(r):
+			if letters > 0 {
+				flush()
+			}
+			digits++
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			tokens++
+		}
+	}
+	flush()
+	return tokens
+}
+
+// EstimatePrompt approximates the input tokens of sending prompt with
+// attachments, and what they cost with the prices of model. It leaves out
+// the conversation sent along with it.
+func EstimatePrompt(model catwalk.Model, prompt string, attachments []message.Attachment) (int, float64) {
+	tokens := EstimateTokens(prompt)
+	for _, a := range attachments {
+		tokens += estimateAttachmentTokens(a)
+	}
+	return tokens, model.CostPer1MIn / 1e6 * float64(tokens)
+}
+
+func estimateAttachmentTokens(a message.Attachment) int {
+	switch {
+	case strings.HasPrefix(a.MimeType, "text/"):
+		return EstimateTokens(string(a.Content))
+	case strings.HasPrefix(a.MimeType, "image/"):
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(a.Content))
+		if err != nil {
+			return maxImageTokens
+		}
+		return min(maxImageTokens, max(1, cfg.Width*cfg.Height/imagePixelsPerToken))
+	default:
+		return len(a.Content) / 4
+	}
+}
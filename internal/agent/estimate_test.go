@@ -0,0 +1,39 @@
+package agent
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, 0, EstimateTokens(""))
+	require.Equal(t, 0, EstimateTokens("  \n\t"))
+	require.Equal(t, 2, EstimateTokens("hello world"))
+	require.Equal(t, 4, EstimateTokens("internationalization"), "long words take more tokens")
+	require.Equal(t, 2, EstimateTokens("2026"))
+	require.Equal(t, 6, EstimateTokens("fix(ui): it"))
+}
+
+func TestEstimatePrompt(t *testing.T) {
+	t.Parallel()
+
+	var img bytes.Buffer
+	require.NoError(t, png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 300, 250))))
+
+	model := catwalk.Model{CostPer1MIn: 3}
+	tokens, cost := EstimatePrompt(model, "hello world", []message.Attachment{
+		{MimeType: "text/plain", Content: []byte("some notes")},
+		{MimeType: "image/png", Content: img.Bytes()},
+		{MimeType: "image/png", Content: []byte("not a png")},
+	})
+	require.Equal(t, 2+2+100+maxImageTokens, tokens)
+	require.InDelta(t, 3*float64(tokens)/1e6, cost, 1e-12)
+}
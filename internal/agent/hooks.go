@@ -0,0 +1,120 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/hooks"
+)
+
+// fileEditTools are the tools that change files, which on_file_edit hooks
+// run after.
+var fileEditTools = []string{tools.EditToolName, tools.MultiEditToolName, tools.ApplyPatchToolName, tools.WriteToolName}
+
+// hookedTool runs the pre_tool_use and post_tool_use hooks around a tool,
+// and the on_file_edit hooks after it changed a file.
+type hookedTool struct {
+	fantasy.AgentTool
+	hooks *hooks.Runner
+}
+
+func withHooks(runner *hooks.Runner, agentTools []fantasy.AgentTool) []fantasy.AgentTool {
+	if !runner.Has(hooks.PreToolUse) && !runner.Has(hooks.PostToolUse) && !runner.Has(hooks.OnFileEdit) {
+		return agentTools
+	}
+	wrapped := make([]fantasy.AgentTool, len(agentTools))
+	for i, tool := range agentTools {
+		wrapped[i] = &hookedTool{AgentTool: tool, hooks: runner}
+	}
+	return wrapped
+}
+
+func (t *hookedTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	name := t.Info().Name
+	sessionID := tools.GetSessionFromContext(ctx)
+
+	if t.hooks.Has(hooks.PreToolUse) {
+		result := t.hooks.Run(ctx, name, hooks.Payload{
+			Event:     hooks.PreToolUse,
+			SessionID: sessionID,
+			ToolName:  name,
+			ToolInput: toolInput(call.Input),
+		})
+		if result.Blocked {
+			return fantasy.NewTextErrorResponse("Tool call blocked by a hook: " + result.Reason), nil
+		}
+		if result.ToolInput != nil {
+			call.Input = string(result.ToolInput)
+		}
+	}
+
+	resp, err := t.AgentTool.Run(ctx, call)
+	if err != nil {
+		return resp, err
+	}
+
+	if t.hooks.Has(hooks.PostToolUse) {
+		result := t.hooks.Run(ctx, name, hooks.Payload{
+			Event:      hooks.PostToolUse,
+			SessionID:  sessionID,
+			ToolName:   name,
+			ToolInput:  toolInput(call.Input),
+			ToolOutput: resp.Content,
+			ToolError:  resp.IsError,
+		})
+		if result.Blocked {
+			resp.Content += "\n\nHook feedback: " + result.Reason
+			resp.IsError = true
+		}
+	}
+
+	if !resp.IsError && slices.Contains(fileEditTools, name) && t.hooks.Has(hooks.OnFileEdit) {
+		for _, filePath := range editedFiles(name, call, resp) {
+			result := t.hooks.Run(ctx, filePath, hooks.Payload{
+				Event:     hooks.OnFileEdit,
+				SessionID: sessionID,
+				ToolName:  name,
+				FilePath:  filePath,
+			})
+			if result.Blocked {
+				// The file was changed anyway; let the model know what the
+				// hook didn't like so it can fix it.
+				resp.Content += "\n\nHook feedback: " + result.Reason
+			}
+		}
+	}
+	return resp, nil
+}
+
+// editedFiles returns the files changed by a call to one of fileEditTools.
+func editedFiles(name string, call fantasy.ToolCall, resp fantasy.ToolResponse) []string {
+	if name == tools.ApplyPatchToolName {
+		var meta tools.ApplyPatchResponseMetadata
+		_ = json.Unmarshal([]byte(resp.Metadata), &meta)
+		var paths []string
+		for _, file := range meta.Files {
+			if !file.Deleted {
+				paths = append(paths, file.FilePath)
+			}
+		}
+		return paths
+	}
+	var params struct {
+		FilePath string `json:"file_path"`
+	}
+	_ = json.Unmarshal([]byte(call.Input), &params)
+	return []string{params.FilePath}
+}
+
+// toolInput returns input as raw JSON, or as a JSON string if it isn't
+// valid JSON.
+func toolInput(input string) json.RawMessage {
+	if json.Valid([]byte(input)) {
+		return json.RawMessage(input)
+	}
+	data, _ := json.Marshal(input)
+	return data
+}
@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/charmbracelet/crush/internal/message"
+)
+
+// MarkInterrupted finishes the messages of a session left streaming by a run
+// that stopped before it could clean up, as when Crush exits in the middle of
+// it. Assistant messages are finished as canceled, tool calls without a
+// result get an error one, and unfinished summaries are deleted.
+func MarkInterrupted(ctx context.Context, messages message.Service, sessionID string) error {
+	msgs, err := messages.List(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to list messages: %w", err)
+	}
+	results := make(map[string]bool)
+	for _, msg := range msgs {
+		for _, result := range msg.ToolResults() {
+			results[result.ToolCallID] = true
+		}
+	}
+
+	for _, msg := range msgs {
+		if msg.Role != message.Assistant {
+			continue
+		}
+		if msg.IsSummaryMessage && !msg.IsFinished() {
+			if err := messages.Delete(ctx, msg.ID); err != nil {
+				return fmt.Errorf("failed to delete summary: %w", err)
+			}
+			continue
+		}
+		if !msg.IsFinished() {
+			msg.FinishThinking()
+			for _, tc := range msg.ToolCalls() {
+				if !tc.Finished {
+					tc.Finished = true
+					if tc.Input == "" {
+						tc.Input = "{}"
+					}
+					msg.AddToolCall(tc)
+				}
+			}
+			msg.AddFinish(message.FinishReasonCanceled, "Crush exited", "")
+			if err := messages.Update(ctx, msg); err != nil {
+				return fmt.Errorf("failed to update message: %w", err)
+			}
+		}
+		for _, tc := range msg.ToolCalls() {
+			if results[tc.ID] {
+				continue
+			}
+			_, err := messages.Create(ctx, sessionID, message.CreateMessageParams{
+				Role: message.Tool,
+				Parts: []message.ContentPart{message.ToolResult{
+					ToolCallID: tc.ID,
+					Name:       tc.Name,
+					Content:    "Tool execution canceled as Crush exited",
+					IsError:    true,
+				}},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to add tool result: %w", err)
+			}
+		}
+	}
+	return nil
+}
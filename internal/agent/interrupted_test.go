@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkInterrupted(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+	sessions := session.NewService(q)
+	messages := message.NewService(q)
+
+	sess, err := sessions.Create(t.Context(), "interrupted")
+	require.NoError(t, err)
+	create := func(params message.CreateMessageParams) message.Message {
+		msg, err := messages.Create(t.Context(), sess.ID, params)
+		require.NoError(t, err)
+		return msg
+	}
+	create(message.CreateMessageParams{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "look"}}})
+	done := create(message.CreateMessageParams{Role: message.Assistant, Parts: []message.ContentPart{
+		message.ToolCall{ID: "done", Name: "view", Input: `{"file_path":"a"}`, Finished: true},
+		message.Finish{Reason: message.FinishReasonToolUse},
+	}})
+	create(message.CreateMessageParams{Role: message.Tool, Parts: []message.ContentPart{message.ToolResult{ToolCallID: "done", Name: "view", Content: "a"}}})
+	streaming := create(message.CreateMessageParams{Role: message.Assistant, Parts: []message.ContentPart{
+		message.TextContent{Text: "Let me run it"},
+		message.ToolCall{ID: "running", Name: "bash"},
+	}})
+	summary := create(message.CreateMessageParams{Role: message.Assistant, IsSummaryMessage: true})
+
+	require.NoError(t, MarkInterrupted(t.Context(), messages, sess.ID))
+
+	msgs, err := messages.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Len(t, msgs, 5)
+	for _, msg := range msgs {
+		require.NotEqual(t, summary.ID, msg.ID)
+	}
+
+	got, err := messages.Get(t.Context(), done.ID)
+	require.NoError(t, err)
+	require.Equal(t, message.FinishReasonToolUse, got.FinishReason())
+
+	got, err = messages.Get(t.Context(), streaming.ID)
+	require.NoError(t, err)
+	require.Equal(t, message.FinishReasonCanceled, got.FinishReason())
+	require.True(t, got.ToolCalls()[0].Finished)
+	require.Equal(t, "{}", got.ToolCalls()[0].Input)
+
+	results := msgs[4].ToolResults()
+	require.Len(t, results, 1)
+	require.Equal(t, "running", results[0].ToolCallID)
+	require.True(t, results[0].IsError)
+
+	// Marking again changes nothing.
+	require.NoError(t, MarkInterrupted(t.Context(), messages, sess.ID))
+	again, err := messages.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Len(t, again, 5)
+}
@@ -0,0 +1,208 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/pubsub"
+)
+
+// errToolTimedOut is the cause of the cancellation of a tool call that ran
+// past its timeout.
+var errToolTimedOut = errors.New("tool call timed out")
+
+// ToolProgress is published while a tool call with a timeout or a soft
+// timeout runs, for the UI to tell when it's slow and offer to extend it.
+type ToolProgress struct {
+	ToolCallID string
+	SessionID  string
+	StartedAt  time.Time
+	// SlowAt is when the call runs past its soft timeout, zero without one.
+	SlowAt time.Time
+	// Deadline is when the call is stopped, zero without a timeout.
+	Deadline time.Time
+	Done     bool
+}
+
+// Slow reports whether the call ran past its soft timeout at now.
+func (p ToolProgress) Slow(now time.Time) bool {
+	return !p.Done && !p.SlowAt.IsZero() && !now.Before(p.SlowAt)
+}
+
+var toolProgressBroker = pubsub.NewBroker[ToolProgress]()
+
+// SubscribeToolProgress returns a channel for tool call progress events.
+func SubscribeToolProgress(ctx context.Context) <-chan pubsub.Event[ToolProgress] {
+	return toolProgressBroker.Subscribe(ctx)
+}
+
+// limitedTool stops a tool call after its configured timeout and cuts its
+// output to the configured size. Calls with a timeout or a soft timeout can
+// be extended while they run, see [coordinator.ExtendToolCall].
+type limitedTool struct {
+	fantasy.AgentTool
+	limits config.ToolLimits
+	// extendable holds the extend functions of the running tool calls by ID.
+	extendable *csync.Map[string, func() bool]
+}
+
+// withLimits applies the limits of options.tools to the tools that have
+// any, by name or through the "*" entry.
+func withLimits(limits map[string]config.ToolLimits, extendable *csync.Map[string, func() bool], agentTools []fantasy.AgentTool) []fantasy.AgentTool {
+	if len(limits) == 0 {
+		return agentTools
+	}
+	wrapped := make([]fantasy.AgentTool, len(agentTools))
+	for i, tool := range agentTools {
+		toolLimits, ok := limits[tool.Info().Name]
+		if !ok {
+			toolLimits = limits["*"]
+		}
+		if toolLimits.Timeout <= 0 && toolLimits.SoftTimeout <= 0 && toolLimits.MaxOutputBytes <= 0 {
+			wrapped[i] = tool
+			continue
+		}
+		wrapped[i] = &limitedTool{AgentTool: tool, limits: toolLimits, extendable: extendable}
+	}
+	return wrapped
+}
+
+func (t *limitedTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	resp, err := t.run(ctx, call)
+	if err != nil {
+		return resp, err
+	}
+	return truncateOutput(resp, t.limits.MaxOutputBytes), nil
+}
+
+func (t *limitedTool) run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	timeout := time.Duration(t.limits.Timeout) * time.Second
+	softTimeout := time.Duration(t.limits.SoftTimeout) * time.Second
+	if timeout <= 0 && softTimeout <= 0 {
+		return t.AgentTool.Run(ctx, call)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var mu sync.Mutex
+	progress := ToolProgress{
+		ToolCallID: call.ID,
+		SessionID:  tools.GetSessionFromContext(ctx),
+		StartedAt:  time.Now(),
+	}
+	var timer *time.Timer
+	// start starts the timeouts of the call over from now, which extends it.
+	start := func(now time.Time) bool {
+		if timeout > 0 {
+			if timer == nil {
+				timer = time.AfterFunc(timeout, func() { cancel(errToolTimedOut) })
+			} else if !timer.Reset(timeout) {
+				// Too late, the call was stopped already.
+				return false
+			}
+			progress.Deadline = now.Add(timeout)
+		}
+		if softTimeout > 0 {
+			progress.SlowAt = now.Add(softTimeout)
+		}
+		return true
+	}
+	start(progress.StartedAt)
+	toolProgressBroker.Publish(pubsub.CreatedEvent, progress)
+	if t.extendable != nil {
+		t.extendable.Set(call.ID, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			if progress.Done || !start(time.Now()) {
+				return false
+			}
+			toolProgressBroker.Publish(pubsub.UpdatedEvent, progress)
+			return true
+		})
+		defer t.extendable.Del(call.ID)
+	}
+	defer func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if timer != nil {
+			timer.Stop()
+		}
+		progress.Done = true
+		toolProgressBroker.Publish(pubsub.UpdatedEvent, progress)
+	}()
+
+	type result struct {
+		resp fantasy.ToolResponse
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp, err := t.AgentTool.Run(ctx, call)
+		done <- result{resp, err}
+	}()
+
+	select {
+	case r := <-done:
+		if errors.Is(context.Cause(ctx), errToolTimedOut) {
+			return timedOutResponse(time.Since(progress.StartedAt)), nil
+		}
+		return r.resp, r.err
+	case <-ctx.Done():
+		if errors.Is(context.Cause(ctx), errToolTimedOut) {
+			// Don't wait for tools that don't stop when cancelled.
+			return timedOutResponse(time.Since(progress.StartedAt)), nil
+		}
+		r := <-done
+		return r.resp, r.err
+	}
+}
+
+// ExtendToolCall starts the timeout and the soft timeout of a running tool
+// call over, giving it as long again. It reports whether the call was
+// running with a timeout or a soft timeout.
+func (c *coordinator) ExtendToolCall(toolCallID string) bool {
+	extend, ok := c.extendableTools.Get(toolCallID)
+	return ok && extend()
+}
+
+func timedOutResponse(elapsed time.Duration) fantasy.ToolResponse {
+	return fantasy.NewTextErrorResponse(fmt.Sprintf("The tool call was stopped after running for %s, past the timeout configured for this tool. Try a smaller or faster call.", elapsed.Round(time.Second)))
+}
+
+// truncateOutput cuts the content of resp to maxBytes, noting it in the
+// content for the model and in the metadata.
+func truncateOutput(resp fantasy.ToolResponse, maxBytes int) fantasy.ToolResponse {
+	if maxBytes <= 0 || len(resp.Content) <= maxBytes {
+		return resp
+	}
+	size := len(resp.Content)
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(resp.Content[cut]) {
+		cut--
+	}
+	resp.Content = resp.Content[:cut] + fmt.Sprintf("\n\n[Output truncated: showing %d of %d bytes]", cut, size)
+
+	metadata := map[string]json.RawMessage{}
+	if resp.Metadata != "" {
+		if err := json.Unmarshal([]byte(resp.Metadata), &metadata); err != nil {
+			// Leave metadata that isn't an object as is.
+			return resp
+		}
+	}
+	metadata["output_truncated"] = json.RawMessage("true")
+	metadata["output_bytes"] = json.RawMessage(strconv.Itoa(size))
+	if data, err := json.Marshal(metadata); err == nil {
+		resp.Metadata = string(data)
+	}
+	return resp
+}
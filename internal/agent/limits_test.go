@@ -0,0 +1,115 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/stretchr/testify/require"
+)
+
+// outputTool returns the same response for every call.
+type outputTool struct {
+	fantasy.AgentTool
+	resp fantasy.ToolResponse
+}
+
+func (t *outputTool) Info() fantasy.ToolInfo {
+	return fantasy.ToolInfo{Name: "output"}
+}
+
+func (t *outputTool) Run(context.Context, fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	return t.resp, nil
+}
+
+func TestToolTimeout(t *testing.T) {
+	t.Parallel()
+
+	tool := &blockingTool{started: make(chan struct{}), release: make(chan struct{})}
+	defer close(tool.release)
+	limited := &limitedTool{AgentTool: tool, limits: config.ToolLimits{Timeout: 1}}
+
+	resp, err := limited.Run(t.Context(), fantasy.ToolCall{ID: "call-1"})
+	require.NoError(t, err)
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "stopped after running for 1s")
+}
+
+func TestToolMaxOutputBytes(t *testing.T) {
+	t.Parallel()
+
+	tool := &outputTool{resp: fantasy.WithResponseMetadata(
+		fantasy.NewTextResponse(strings.Repeat("é", 10)),
+		map[string]any{"exit_code": 0},
+	)}
+	wrapped := withLimits(map[string]config.ToolLimits{"*": {MaxOutputBytes: 5}}, nil, []fantasy.AgentTool{tool})[0]
+
+	resp, err := wrapped.Run(t.Context(), fantasy.ToolCall{ID: "call-1"})
+	require.NoError(t, err)
+	require.Equal(t, "éé\n\n[Output truncated: showing 4 of 20 bytes]", resp.Content, "runes aren't split")
+
+	var meta map[string]any
+	require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+	require.Equal(t, map[string]any{"exit_code": 0.0, "output_truncated": true, "output_bytes": 20.0}, meta)
+
+	unlimited := withLimits(map[string]config.ToolLimits{"other": {MaxOutputBytes: 5}}, nil, []fantasy.AgentTool{tool})[0]
+	require.Same(t, tool, unlimited, "tools without limits aren't wrapped")
+}
+
+func TestExtendToolCall(t *testing.T) {
+	t.Parallel()
+
+	c := &coordinator{extendableTools: csync.NewMap[string, func() bool]()}
+	tool := &blockingTool{started: make(chan struct{}), release: make(chan struct{})}
+	wrapped := &limitedTool{
+		AgentTool:  tool,
+		limits:     config.ToolLimits{Timeout: 60, SoftTimeout: 30},
+		extendable: c.extendableTools,
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	events := SubscribeToolProgress(ctx)
+	next := func() ToolProgress {
+		t.Helper()
+		for {
+			select {
+			case event := <-events:
+				if event.Payload.ToolCallID == "extend-call" {
+					return event.Payload
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("no tool progress published")
+			}
+		}
+	}
+
+	done := make(chan fantasy.ToolResponse, 1)
+	go func() {
+		resp, _ := wrapped.Run(t.Context(), fantasy.ToolCall{ID: "extend-call"})
+		done <- resp
+	}()
+	<-tool.started
+
+	started := next()
+	require.Equal(t, started.StartedAt.Add(30*time.Second), started.SlowAt)
+	require.Equal(t, started.StartedAt.Add(60*time.Second), started.Deadline)
+	require.False(t, started.Slow(started.StartedAt.Add(29*time.Second)))
+	require.True(t, started.Slow(started.SlowAt))
+
+	require.True(t, c.ExtendToolCall("extend-call"))
+	extended := next()
+	require.Equal(t, started.StartedAt, extended.StartedAt)
+	require.True(t, extended.Deadline.After(started.Deadline) || extended.Deadline.Equal(started.Deadline))
+	require.Equal(t, extended.SlowAt.Add(30*time.Second), extended.Deadline)
+
+	close(tool.release)
+	require.Equal(t, "done", (<-done).Content)
+	require.True(t, next().Done)
+	require.False(t, c.ExtendToolCall("extend-call"), "the call is done")
+}
@@ -0,0 +1,136 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/crush/internal/config"
+)
+
+// promptOverrides are the parameters a prompt sets for itself with
+// directives at its start, like "!model gpt-4o-mini !temperature 0.2 ...".
+// They only apply to that prompt, leaving the session's model as it is.
+type promptOverrides struct {
+	// Model is the ID of a model, optionally prefixed by the ID of its
+	// provider and a slash.
+	Model       string
+	Temperature *float64
+	// Compare holds the two models to send the prompt to side by side,
+	// given like Model.
+	Compare []string
+}
+
+// promptDirectives are the directives a prompt can start with.
+var promptDirectives = []string{"!model", "!temperature", "!compare"}
+
+// HasPromptOverrides reports whether prompt starts with a directive like
+// !model.
+func HasPromptOverrides(prompt string) bool {
+	name, _ := nextField(strings.TrimLeftFunc(prompt, unicode.IsSpace))
+	return slices.Contains(promptDirectives, name)
+}
+
+// parsePromptOverrides reads the directives at the start of prompt and
+// returns them with the rest of the prompt.
+func parsePromptOverrides(prompt string) (promptOverrides, string, error) {
+	var overrides promptOverrides
+	rest := strings.TrimLeftFunc(prompt, unicode.IsSpace)
+	for {
+		name, after := nextField(rest)
+		if !slices.Contains(promptDirectives, name) {
+			return overrides, rest, nil
+		}
+		value, after := nextField(strings.TrimLeftFunc(after, unicode.IsSpace))
+		if value == "" {
+			return overrides, prompt, fmt.Errorf("%s needs a value", name)
+		}
+		switch name {
+		case "!model":
+			overrides.Model = value
+		case "!temperature":
+			temperature, err := strconv.ParseFloat(value, 64)
+			if err != nil || temperature < 0 || temperature > 2 {
+				return overrides, prompt, fmt.Errorf("invalid temperature %q: use a number between 0 and 2", value)
+			}
+			overrides.Temperature = &temperature
+		case "!compare":
+			second, tail := nextField(strings.TrimLeftFunc(after, unicode.IsSpace))
+			if second == "" {
+				return overrides, prompt, fmt.Errorf("%s needs two models", name)
+			}
+			overrides.Compare = []string{value, second}
+			after = tail
+		}
+		rest = strings.TrimLeftFunc(after, unicode.IsSpace)
+	}
+}
+
+// nextField splits the first whitespace separated field off s.
+func nextField(s string) (string, string) {
+	i := strings.IndexFunc(s, unicode.IsSpace)
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], s[i:]
+}
+
+// overrideModel builds the model a prompt asked for with the !model
+// directive. A model ID without a provider is looked up in the provider of
+// the current model first, then in the others.
+func (c *coordinator) overrideModel(ctx context.Context, current Model, spec string) (Model, error) {
+	selected, ok := c.findModel(current.ModelCfg.Provider, spec)
+	if !ok {
+		return Model{}, fmt.Errorf("model %q not found in the configured providers", spec)
+	}
+	providerCfg, _ := c.cfg.Providers.Get(selected.Provider)
+	provider, err := c.buildProvider(providerCfg, selected)
+	if err != nil {
+		return Model{}, err
+	}
+	languageModel, err := provider.LanguageModel(ctx, selected.Model)
+	if err != nil {
+		return Model{}, err
+	}
+	catwalkModel := c.cfg.GetModel(selected.Provider, selected.Model)
+	return Model{
+		Model:      languageModel,
+		CatwalkCfg: *catwalkModel,
+		ModelCfg:   selected,
+	}, nil
+}
+
+func (c *coordinator) findModel(currentProvider, spec string) (config.SelectedModel, bool) {
+	if providerID, modelID, ok := strings.Cut(spec, "/"); ok && c.cfg.GetModel(providerID, modelID) != nil {
+		return c.selectedModel(providerID, modelID), true
+	}
+	if c.cfg.GetModel(currentProvider, spec) != nil {
+		return c.selectedModel(currentProvider, spec), true
+	}
+	for _, providerID := range slices.Sorted(maps.Keys(maps.Collect(c.cfg.Providers.Seq2()))) {
+		if c.cfg.GetModel(providerID, spec) != nil {
+			return c.selectedModel(providerID, spec), true
+		}
+	}
+	return config.SelectedModel{}, false
+}
+
+// selectedModel returns the configuration of the large model when it is
+// the one asked for, so that its settings are kept.
+func (c *coordinator) selectedModel(providerID, modelID string) config.SelectedModel {
+	for _, selected := range c.cfg.Models {
+		if selected.Provider == providerID && selected.Model == modelID {
+			return selected
+		}
+	}
+	model := c.cfg.GetModel(providerID, modelID)
+	return config.SelectedModel{
+		Provider:  providerID,
+		Model:     modelID,
+		MaxTokens: model.DefaultMaxTokens,
+	}
+}
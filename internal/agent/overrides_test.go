@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePromptOverrides(t *testing.T) {
+	t.Parallel()
+
+	temperature := func(v float64) *float64 { return &v }
+
+	for _, tc := range []struct {
+		prompt    string
+		overrides promptOverrides
+		rest      string
+		err       string
+	}{
+		{prompt: "fix the tests", rest: "fix the tests"},
+		{prompt: "!model gpt-4o-mini fix the tests", overrides: promptOverrides{Model: "gpt-4o-mini"}, rest: "fix the tests"},
+		{
+			prompt:    "  !temperature 0.2 !model openrouter/qwen/qwen3-coder\nfix\nthe tests",
+			overrides: promptOverrides{Model: "openrouter/qwen/qwen3-coder", Temperature: temperature(0.2)},
+			rest:      "fix\nthe tests",
+		},
+		{prompt: "explain !model in the docs", rest: "explain !model in the docs"},
+		{prompt: "!modeling is hard", rest: "!modeling is hard"},
+		{prompt: "!compare gpt-4o sonnet explain this", overrides: promptOverrides{Compare: []string{"gpt-4o", "sonnet"}}, rest: "explain this"},
+		{prompt: "!model", err: "!model needs a value"},
+		{prompt: "!compare gpt-4o", err: "!compare needs two models"},
+		{prompt: "!temperature hot say hi", err: `invalid temperature "hot"`},
+		{prompt: "!temperature 3 say hi", err: `invalid temperature "3"`},
+	} {
+		overrides, rest, err := parsePromptOverrides(tc.prompt)
+		if tc.err != "" {
+			require.ErrorContains(t, err, tc.err, tc.prompt)
+			continue
+		}
+		require.NoError(t, err, tc.prompt)
+		require.Equal(t, tc.overrides, overrides, tc.prompt)
+		require.Equal(t, tc.rest, rest, tc.prompt)
+	}
+}
+
+func TestHasPromptOverrides(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, HasPromptOverrides("!model gpt-4o fix the tests"))
+	require.True(t, HasPromptOverrides("  !compare gpt-4o sonnet explain this"))
+	require.False(t, HasPromptOverrides("!git status"))
+	require.False(t, HasPromptOverrides("! model"))
+	require.False(t, HasPromptOverrides("fix the tests"))
+}
@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/agent/prompt"
+)
+
+// ContextPreview is what the next request of a session sends to the model,
+// with the estimated tokens of each part.
+type ContextPreview struct {
+	Model         string
+	ContextWindow int64
+	// SystemPrompt is the tokens of the system prompt, the context files
+	// included.
+	SystemPrompt int
+	ContextFiles []ContextPart
+	// Summary is the tokens of the summary the history starts with when the
+	// session was summarized, and History those of the messages after it,
+	// once compacted.
+	Summary  int
+	History  int
+	Messages int
+	Tools    []ContextPart
+	// ReportedTokens is the context the provider reported after the last
+	// response of the session.
+	ReportedTokens int64
+}
+
+// ContextPart is a part of a request, like a context file or a tool.
+type ContextPart struct {
+	Name   string
+	Tokens int
+}
+
+// ToolTokens returns the tokens of the tool schemas.
+func (p ContextPreview) ToolTokens() int {
+	tokens := 0
+	for _, tool := range p.Tools {
+		tokens += tool.Tokens
+	}
+	return tokens
+}
+
+// Total returns the tokens of the whole request.
+func (p ContextPreview) Total() int {
+	return p.SystemPrompt + p.Summary + p.History + p.ToolTokens()
+}
+
+// ContextPreview returns what the next request of the session with
+// sessionID would send, before the new prompt.
+func (a *sessionAgent) ContextPreview(ctx context.Context, sessionID string) (ContextPreview, error) {
+	model := a.largeModel
+	preview := ContextPreview{
+		Model:         model.CatwalkCfg.Name,
+		ContextWindow: int64(model.CatwalkCfg.ContextWindow),
+		SystemPrompt:  EstimateTokens(a.promptPrefix(model)) + EstimateTokens(a.systemPrompt),
+	}
+
+	for _, tool := range a.tools {
+		info := tool.Info()
+		schema, err := json.Marshal(map[string]any{
+			"name":        info.Name,
+			"description": info.Description,
+			"parameters":  info.Parameters,
+			"required":    info.Required,
+		})
+		if err != nil {
+			return ContextPreview{}, fmt.Errorf("failed to encode tool %s: %w", info.Name, err)
+		}
+		preview.Tools = append(preview.Tools, ContextPart{Name: info.Name, Tokens: EstimateTokens(string(schema))})
+	}
+	slices.SortFunc(preview.Tools, func(a, b ContextPart) int { return b.Tokens - a.Tokens })
+
+	if sessionID == "" {
+		return preview, nil
+	}
+	currentSession, err := a.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return ContextPreview{}, fmt.Errorf("failed to get session: %w", err)
+	}
+	preview.ReportedTokens = currentSession.PromptTokens + currentSession.CompletionTokens
+	msgs, err := a.getSessionMessages(ctx, currentSession)
+	if err != nil {
+		return ContextPreview{}, fmt.Errorf("failed to get session messages: %w", err)
+	}
+	if len(msgs) > 0 && msgs[0].ID == currentSession.SummaryMessageID {
+		preview.Summary = estimateMessagesTokens(msgs[0].ToAIMessage())
+	}
+
+	// Compact the history the way the next request will.
+	history, _ := a.preparePrompt(msgs)
+	if a.compaction != nil {
+		history = synopsizeToolResults(history, a.compaction.SynopsisAfterTurns)
+	}
+	if cw := preview.ContextWindow; cw > 0 {
+		budget := int(cw - compactionThreshold(cw))
+		history = compactMessages(a.compaction.GetStrategy(), history, budget)
+	}
+	preview.Messages = len(history)
+	preview.History = max(0, estimateMessagesTokens(history)-preview.Summary)
+	return preview, nil
+}
+
+// contextFileParts returns the context files with their tokens, the largest
+// first, with paths relative to workingDir.
+func contextFileParts(files []prompt.ContextFile, workingDir string) []ContextPart {
+	parts := make([]ContextPart, 0, len(files))
+	for _, file := range files {
+		name := file.Path
+		if rel, err := filepath.Rel(workingDir, file.Path); err == nil && !strings.HasPrefix(rel, "..") {
+			name = filepath.ToSlash(rel)
+		}
+		parts = append(parts, ContextPart{Name: name, Tokens: EstimateTokens(file.Content)})
+	}
+	slices.SortFunc(parts, func(a, b ContextPart) int {
+		if a.Tokens != b.Tokens {
+			return b.Tokens - a.Tokens
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return parts
+}
@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/prompt"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextPreview(t *testing.T) {
+	env := testEnv(t)
+	_, err := config.Init(env.workingDir, "", false)
+	require.NoError(t, err)
+	type echoParams struct {
+		Text string `json:"text" description:"The text to echo"`
+	}
+	echo := fantasy.NewAgentTool("echo", "Echoes the text back", func(ctx context.Context, params echoParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+		return fantasy.NewTextResponse(params.Text), nil
+	})
+	model := &objectModel{}
+	agent := testSessionAgent(env, model, model, "You are a helpful assistant.", echo)
+
+	preview, err := agent.ContextPreview(t.Context(), "")
+	require.NoError(t, err)
+	require.Equal(t, EstimateTokens("You are a helpful assistant."), preview.SystemPrompt)
+	require.Len(t, preview.Tools, 1)
+	require.Equal(t, "echo", preview.Tools[0].Name)
+	require.Positive(t, preview.Tools[0].Tokens)
+	require.Zero(t, preview.History)
+
+	sess, err := env.sessions.Create(t.Context(), "preview")
+	require.NoError(t, err)
+	for _, m := range []message.CreateMessageParams{
+		{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "What does main.go do?"}}},
+		{Role: message.Assistant, Parts: []message.ContentPart{message.TextContent{Text: "It prints hello world."}}},
+	} {
+		_, err := env.messages.Create(t.Context(), sess.ID, m)
+		require.NoError(t, err)
+	}
+	preview, err = agent.ContextPreview(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, 2, preview.Messages)
+	require.Positive(t, preview.History)
+	require.Zero(t, preview.Summary)
+	require.Equal(t, preview.SystemPrompt+preview.History+preview.ToolTokens(), preview.Total())
+}
+
+func TestContextFileParts(t *testing.T) {
+	t.Parallel()
+
+	parts := contextFileParts([]prompt.ContextFile{
+		{Path: "/project/AGENTS.md", Content: "short"},
+		{Path: "/project/docs/guide.md", Content: "a much longer guide to the project"},
+		{Path: "/home/user/.config/crush/CRUSH.md", Content: "global"},
+	}, "/project")
+	require.Equal(t, []ContextPart{
+		{Name: "docs/guide.md", Tokens: EstimateTokens("a much longer guide to the project")},
+		{Name: "/home/user/.config/crush/CRUSH.md", Tokens: 1},
+		{Name: "AGENTS.md", Tokens: 1},
+	}, parts)
+}
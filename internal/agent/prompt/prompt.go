@@ -20,6 +20,8 @@ import (
 type Prompt struct {
 	name       string
 	template   string
+	override   string
+	appendix   string
 	now        func() time.Time
 	platform   string
 	workingDir string
@@ -62,6 +64,22 @@ func WithWorkingDir(workingDir string) Option {
 	}
 }
 
+// WithSystemPrompt replaces the prompt template with systemPrompt, which is
+// either the template itself or the path to a file containing it.
+func WithSystemPrompt(systemPrompt string) Option {
+	return func(p *Prompt) {
+		p.override = systemPrompt
+	}
+}
+
+// WithSystemPromptAppend appends text, or the contents of the file it points
+// to, to the prompt template.
+func WithSystemPromptAppend(text string) Option {
+	return func(p *Prompt) {
+		p.appendix = text
+	}
+}
+
 func NewPrompt(name, promptTemplate string, opts ...Option) (*Prompt, error) {
 	p := &Prompt{
 		name:     name,
@@ -75,7 +93,11 @@ func NewPrompt(name, promptTemplate string, opts ...Option) (*Prompt, error) {
 }
 
 func (p *Prompt) Build(ctx context.Context, provider, model string, cfg config.Config) (string, error) {
-	t, err := template.New(p.name).Parse(p.template)
+	tmpl, err := p.buildTemplate(cfg)
+	if err != nil {
+		return "", err
+	}
+	t, err := template.New(p.name).Parse(tmpl)
 	if err != nil {
 		return "", fmt.Errorf("parsing template: %w", err)
 	}
@@ -91,6 +113,71 @@ func (p *Prompt) Build(ctx context.Context, provider, model string, cfg config.C
 	return sb.String(), nil
 }
 
+func (p *Prompt) buildTemplate(cfg config.Config) (string, error) {
+	tmpl := p.template
+	if p.override != "" {
+		override, err := p.loadText(p.override, cfg)
+		if err != nil {
+			return "", err
+		}
+		tmpl = override
+	}
+	if p.appendix != "" {
+		appendix, err := p.loadText(p.appendix, cfg)
+		if err != nil {
+			return "", err
+		}
+		tmpl = strings.TrimRight(tmpl, "\n") + "\n\n" + appendix
+	}
+	return tmpl, nil
+}
+
+// loadText returns the contents of the file value points to, relative to
+// the working directory, or value itself if it isn't a path. Paths to
+// nothing but a file are errors, for a typo not to become the prompt.
+func (p *Prompt) loadText(value string, cfg config.Config) (string, error) {
+	if !looksLikePath(value) {
+		return value, nil
+	}
+	path := expandPath(value, cfg)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(cmp.Or(p.workingDir, cfg.WorkingDir()), path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("prompt file %s: %w", value, err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("prompt file %s is a directory", value)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading prompt file: %w", err)
+	}
+	return string(content), nil
+}
+
+// looksLikePath reports whether value is the path of a prompt file rather
+// than the prompt itself: a single line that starts like a path, or a
+// single word with a separator or an extension, like prompts/coder.md.
+func looksLikePath(value string) bool {
+	if value == "" || strings.ContainsRune(value, '\n') {
+		return false
+	}
+	for _, prefix := range []string{"./", "../", "~/", "$", `.\`, `..\`} {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	if filepath.IsAbs(value) {
+		return true
+	}
+	if strings.ContainsAny(value, " \t") {
+		return false
+	}
+	return strings.ContainsAny(value, `/\`) || len(filepath.Ext(value)) > 1
+}
+
 func processFile(filePath string) *ContextFile {
 	content, err := os.ReadFile(filePath)
 	if err != nil {
@@ -150,18 +237,6 @@ func (p *Prompt) promptData(ctx context.Context, provider, model string, cfg con
 	workingDir := cmp.Or(p.workingDir, cfg.WorkingDir())
 	platform := cmp.Or(p.platform, runtime.GOOS)
 
-	files := map[string][]ContextFile{}
-
-	for _, pth := range cfg.Options.ContextPaths {
-		expanded := expandPath(pth, cfg)
-		pathKey := strings.ToLower(expanded)
-		if _, ok := files[pathKey]; ok {
-			continue
-		}
-		content := processContextPath(expanded, cfg)
-		files[pathKey] = content
-	}
-
 	isGit := isGitRepo(cfg.WorkingDir())
 	data := PromptDat{
 		Provider:   provider,
@@ -180,12 +255,32 @@ func (p *Prompt) promptData(ctx context.Context, provider, model string, cfg con
 		}
 	}
 
-	for _, contextFiles := range files {
-		data.ContextFiles = append(data.ContextFiles, contextFiles...)
-	}
+	data.ContextFiles = ContextFiles(cfg)
 	return data, nil
 }
 
+// ContextFiles returns the files of the context paths of cfg, which the
+// system prompt includes.
+func ContextFiles(cfg config.Config) []ContextFile {
+	files := map[string][]ContextFile{}
+
+	for _, pth := range cfg.Options.ContextPaths {
+		expanded := expandPath(pth, cfg)
+		pathKey := strings.ToLower(expanded)
+		if _, ok := files[pathKey]; ok {
+			continue
+		}
+		content := processContextPath(expanded, cfg)
+		files[pathKey] = content
+	}
+
+	var contextFiles []ContextFile
+	for _, content := range files {
+		contextFiles = append(contextFiles, content...)
+	}
+	return contextFiles
+}
+
 func isGitRepo(dir string) bool {
 	_, err := os.Stat(filepath.Join(dir, ".git"))
 	return err == nil
@@ -0,0 +1,79 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildOverrides(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "coder.md"), []byte("Custom prompt on {{.Platform}}.\n"), 0o644))
+
+	cfg := config.Config{Options: &config.Options{}}
+	now := func() time.Time { return time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{
+			name: "built-in",
+			want: "Built-in prompt in " + dir + ".",
+		},
+		{
+			name: "inline override",
+			opts: []Option{WithSystemPrompt("Inline prompt on {{.Date}}.")},
+			want: "Inline prompt on 1/2/2025.",
+		},
+		{
+			name: "file override",
+			opts: []Option{WithSystemPrompt("coder.md")},
+			want: "Custom prompt on linux.\n",
+		},
+		{
+			name: "append",
+			opts: []Option{WithSystemPromptAppend("Be brief.")},
+			want: "Built-in prompt in " + dir + ".\n\nBe brief.",
+		},
+		{
+			name: "override and append from file",
+			opts: []Option{WithSystemPrompt("Short."), WithSystemPromptAppend(filepath.Join(dir, "coder.md"))},
+			want: "Short.\n\nCustom prompt on linux.\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := append([]Option{WithWorkingDir(dir), WithPlatform("linux"), WithTimeFunc(now)}, tt.opts...)
+			p, err := NewPrompt("test", "Built-in prompt in {{.WorkingDir}}.", opts...)
+			require.NoError(t, err)
+			got, err := p.Build(t.Context(), "", "", cfg)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestBuildMissingPromptFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "prompts"), 0o755))
+	cfg := config.Config{Options: &config.Options{}}
+
+	for _, value := range []string{"./prompts/sytem.md", "prompts/sytem.md", "sytem.md", "./prompts"} {
+		p, err := NewPrompt("test", "Built-in.", WithWorkingDir(dir), WithSystemPrompt(value))
+		require.NoError(t, err)
+		_, err = p.Build(t.Context(), "", "", cfg)
+		require.Error(t, err, value)
+	}
+}
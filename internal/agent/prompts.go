@@ -3,6 +3,8 @@ package agent
 import (
 	"context"
 	_ "embed"
+	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/crush/internal/agent/prompt"
 	"github.com/charmbracelet/crush/internal/config"
@@ -33,10 +35,36 @@ func taskPrompt(opts ...prompt.Option) (*prompt.Prompt, error) {
 	return systemPrompt, nil
 }
 
-func InitializePrompt(cfg config.Config) (string, error) {
+// InitializePrompt returns the prompt that creates or updates the context
+// file of the project. With a template, the file follows its structure.
+func InitializePrompt(cfg config.Config, tmpl *config.InitTemplate) (string, error) {
 	systemPrompt, err := prompt.NewPrompt("initialize", string(initializePromptTmpl))
 	if err != nil {
 		return "", err
 	}
-	return systemPrompt.Build(context.Background(), "", "", cfg)
+	initPrompt, err := systemPrompt.Build(context.Background(), "", "", cfg)
+	if err != nil || tmpl == nil || tmpl.Structure == "" {
+		return initPrompt, err
+	}
+	return fmt.Sprintf(
+		"%s\n\n**Template**: This is a %s project. Instead of choosing the structure yourself, give %s these sections, in this order, and leave out the ones you find nothing for:\n\n%s\n",
+		strings.TrimRight(initPrompt, "\n"), tmpl.Name, cfg.Options.InitializeAs, tmpl.Structure,
+	), nil
+}
+
+// UpdateContextPrompt returns the prompt that brings the context file of the
+// project up to date with changes, as described by drift.Check, showing the
+// update as a diff to be approved before writing it.
+func UpdateContextPrompt(cfg config.Config, changes []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s** may be out of date. The project changed since it was last written:\n\n", cfg.Options.InitializeAs)
+	for _, change := range changes {
+		fmt.Fprintf(&b, "- %s\n", change)
+	}
+	fmt.Fprintf(&b, `
+Read %[1]s, then look into these changes: what the new directories contain, and how the commands and settings in the changed build files differ from what %[1]s says. Only document what you actually observe.
+
+Do not write %[1]s yet. Reply with the update you propose as a unified diff of %[1]s, or say it's still up to date if nothing in it needs to change, and wait for me to approve the diff before writing it.
+`, cfg.Options.InitializeAs)
+	return b.String()
 }
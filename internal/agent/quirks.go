@@ -0,0 +1,169 @@
+package agent
+
+import (
+	"regexp"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+)
+
+// Quirks are the adjustments the requests to a model need, for the models
+// that don't follow the usual conventions.
+type Quirks struct {
+	// NoThink asks the model not to think when generating titles, with the
+	// /no_think switch of Qwen3 and similar hybrid models.
+	NoThink bool
+	// StripThinkTags removes the <think> blocks models write in the text
+	// of titles and summaries.
+	StripThinkTags bool
+	// MinTitleTokens is how many output tokens title generation gets at
+	// least, for models that think before answering.
+	MinTitleTokens int64
+	// NoSystemMessages sends the system prompt at the start of the first
+	// user message.
+	NoSystemMessages bool
+	// UserFirst starts the conversation with a user message.
+	UserFirst bool
+}
+
+// continueConversation is the user message put before conversations that
+// don't start with one when the model requires it, like after the oldest
+// turns were compacted away.
+const continueConversation = "Continue the conversation below."
+
+// on is the value of the quirks builtinQuirks turn on.
+var on = func() *bool { b := true; return &b }()
+
+// builtinQuirks are the quirks of the models known to need them, before
+// those of the configuration.
+var builtinQuirks = []config.ModelQuirk{
+	// Hybrid models think unless asked not to, which the short titles don't
+	// leave room for; the others ignore the switch.
+	{NoThink: on, StripThinkTags: on},
+	// Gemini 2.5 thinks before answering even when asked not to.
+	{Provider: "gemini", Model: "gemini-2.5-*", MinTitleTokens: 1024},
+	{Provider: "vertexai", Model: "gemini-2.5-*", MinTitleTokens: 1024},
+	{Model: "google/gemini-2.5-*", MinTitleTokens: 1024},
+	// Gemma has no system role and alternates user and model turns.
+	{Model: "*gemma*", NoSystemMessages: on, UserFirst: on},
+	// The first o1 models reject system messages.
+	{Model: "*o1-mini*", NoSystemMessages: on},
+	{Model: "*o1-preview*", NoSystemMessages: on},
+	// Mistral and DeepSeek Reasoner reject conversations that don't start
+	// with a user message.
+	{Model: "*mistral*", UserFirst: on},
+	{Model: "*deepseek-reasoner*", UserFirst: on},
+}
+
+// modelQuirks returns the quirks of model, from the built-in ones and
+// those of the configuration.
+func modelQuirks(model Model) Quirks {
+	providerType := ""
+	var configured []config.ModelQuirk
+	if cfg := config.Get(); cfg != nil {
+		if pc, ok := cfg.Providers.Get(model.ModelCfg.Provider); ok {
+			providerType = string(pc.Type)
+		}
+		if cfg.Options != nil {
+			configured = cfg.Options.Quirks
+		}
+	}
+	modelID := model.ModelCfg.Model
+	if modelID == "" {
+		modelID = model.CatwalkCfg.ID
+	}
+	return resolveQuirks(append(append([]config.ModelQuirk(nil), builtinQuirks...), configured...), model.ModelCfg.Provider, providerType, modelID)
+}
+
+// resolveQuirks applies the quirks that match the model with modelID of
+// the provider with providerID and providerType, in order.
+func resolveQuirks(quirks []config.ModelQuirk, providerID, providerType, modelID string) Quirks {
+	var q Quirks
+	for _, quirk := range quirks {
+		if quirk.Provider != "" && !matchGlob(quirk.Provider, providerID) && !matchGlob(quirk.Provider, providerType) {
+			continue
+		}
+		if quirk.Model != "" && !matchGlob(quirk.Model, modelID) {
+			continue
+		}
+		if quirk.NoThink != nil {
+			q.NoThink = *quirk.NoThink
+		}
+		if quirk.StripThinkTags != nil {
+			q.StripThinkTags = *quirk.StripThinkTags
+		}
+		if quirk.MinTitleTokens != 0 {
+			q.MinTitleTokens = quirk.MinTitleTokens
+		}
+		if quirk.NoSystemMessages != nil {
+			q.NoSystemMessages = *quirk.NoSystemMessages
+		}
+		if quirk.UserFirst != nil {
+			q.UserFirst = *quirk.UserFirst
+		}
+	}
+	return q
+}
+
+// matchGlob reports whether s matches pattern, ignoring case, where *
+// matches any text, slashes included.
+func matchGlob(pattern, s string) bool {
+	expr := "(?i)^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + "$"
+	matched, _ := regexp.MatchString(expr, s)
+	return matched
+}
+
+// applyMessageQuirks returns msgs adjusted to the quirks of the model they
+// are sent to.
+func applyMessageQuirks(q Quirks, msgs []fantasy.Message) []fantasy.Message {
+	if q.NoSystemMessages {
+		var system []string
+		rest := make([]fantasy.Message, 0, len(msgs))
+		for _, msg := range msgs {
+			if msg.Role != fantasy.MessageRoleSystem {
+				rest = append(rest, msg)
+				continue
+			}
+			for _, part := range msg.Content {
+				if text, ok := fantasy.AsMessagePart[fantasy.TextPart](part); ok && text.Text != "" {
+					system = append(system, text.Text)
+				}
+			}
+		}
+		msgs = rest
+		if len(system) > 0 {
+			instructions := fantasy.TextPart{Text: strings.Join(system, "\n\n")}
+			if len(msgs) > 0 && msgs[0].Role == fantasy.MessageRoleUser {
+				first := msgs[0]
+				first.Content = append([]fantasy.MessagePart{instructions}, first.Content...)
+				msgs = append([]fantasy.Message{first}, msgs[1:]...)
+			} else {
+				msgs = append([]fantasy.Message{fantasy.NewUserMessage(instructions.Text)}, msgs...)
+			}
+		}
+	}
+
+	if q.UserFirst {
+		i := 0
+		for i < len(msgs) && msgs[i].Role == fantasy.MessageRoleSystem {
+			i++
+		}
+		if i < len(msgs) && msgs[i].Role != fantasy.MessageRoleUser {
+			msgs = append(msgs[:i:i], append([]fantasy.Message{fantasy.NewUserMessage(continueConversation)}, msgs[i:]...)...)
+		}
+	}
+	return msgs
+}
+
+// stripThinkTags removes the thinking the model wrote in text: everything
+// up to the last </think>, or from an unclosed <think> on.
+func stripThinkTags(text string) string {
+	if i := strings.LastIndex(text, "</think>"); i >= 0 {
+		return text[i+len("</think>"):]
+	}
+	if i := strings.Index(text, "<think>"); i >= 0 {
+		return text[:i]
+	}
+	return text
+}
@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveQuirks(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	quirks := append(append([]config.ModelQuirk(nil), builtinQuirks...),
+		config.ModelQuirk{Provider: "local", NoThink: &off},
+		config.ModelQuirk{Provider: "openai-compat", Model: "my-model", MinTitleTokens: 200},
+	)
+
+	tests := []struct {
+		name                            string
+		providerID, providerType, model string
+		want                            Quirks
+	}{
+		{
+			name: "defaults", providerID: "anthropic", providerType: "anthropic", model: "claude-sonnet-4",
+			want: Quirks{NoThink: true, StripThinkTags: true},
+		},
+		{
+			name: "provider type", providerID: "google", providerType: "gemini", model: "gemini-2.5-flash",
+			want: Quirks{NoThink: true, StripThinkTags: true, MinTitleTokens: 1024},
+		},
+		{
+			name: "model with slashes", providerID: "openrouter", providerType: "openrouter", model: "google/gemma-3-27b-it",
+			want: Quirks{NoThink: true, StripThinkTags: true, NoSystemMessages: true, UserFirst: true},
+		},
+		{
+			name: "configured override", providerID: "local", providerType: "openai-compat", model: "My-Model",
+			want: Quirks{StripThinkTags: true, MinTitleTokens: 200},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, resolveQuirks(quirks, tt.providerID, tt.providerType, tt.model))
+		})
+	}
+}
+
+func TestApplyMessageQuirks(t *testing.T) {
+	t.Parallel()
+
+	msgs := []fantasy.Message{
+		fantasy.NewSystemMessage("prefix"),
+		fantasy.NewSystemMessage("You are a coding agent."),
+		{Role: fantasy.MessageRoleAssistant, Content: []fantasy.MessagePart{fantasy.TextPart{Text: "Done."}}},
+		fantasy.NewUserMessage("Thanks"),
+	}
+	require.Equal(t, msgs, applyMessageQuirks(Quirks{}, msgs))
+
+	userFirst := applyMessageQuirks(Quirks{UserFirst: true}, msgs)
+	require.Len(t, userFirst, 5)
+	require.Equal(t, fantasy.NewUserMessage(continueConversation), userFirst[2])
+	require.Equal(t, fantasy.MessageRoleAssistant, userFirst[3].Role)
+	require.Len(t, msgs, 4, "the messages are left as they are")
+
+	both := applyMessageQuirks(Quirks{NoSystemMessages: true, UserFirst: true}, msgs)
+	require.Len(t, both, 3)
+	require.Equal(t, fantasy.NewUserMessage("prefix\n\nYou are a coding agent."), both[0])
+	require.Equal(t, fantasy.MessageRoleAssistant, both[1].Role)
+
+	noSystem := applyMessageQuirks(Quirks{NoSystemMessages: true}, []fantasy.Message{
+		fantasy.NewSystemMessage("Be brief."),
+		fantasy.NewUserMessage("Hi"),
+	})
+	require.Equal(t, []fantasy.Message{{
+		Role:    fantasy.MessageRoleUser,
+		Content: []fantasy.MessagePart{fantasy.TextPart{Text: "Be brief."}, fantasy.TextPart{Text: "Hi"}},
+	}}, noSystem)
+}
+
+func TestStripThinkTags(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "Fix login bug", stripThinkTags("Fix login bug"))
+	require.Equal(t, " Fix login bug", stripThinkTags("<think>\nthe user wants</think> Fix login bug"))
+	require.Equal(t, "", stripThinkTags("<think>the user wants a title and"))
+}
@@ -0,0 +1,123 @@
+package agent
+
+import (
+	"cmp"
+	"slices"
+
+	"charm.land/fantasy/providers/anthropic"
+	"charm.land/fantasy/providers/azure"
+	"charm.land/fantasy/providers/google"
+	"charm.land/fantasy/providers/openai"
+	"charm.land/fantasy/providers/openaicompat"
+	"charm.land/fantasy/providers/openrouter"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/oauth/copilot"
+)
+
+// ReasoningLevel is how much the model reasons before answering. It's set
+// for a session at runtime, replacing the reasoning effort or thinking mode
+// of the model configuration.
+type ReasoningLevel string
+
+const (
+	ReasoningOff    ReasoningLevel = "off"
+	ReasoningLow    ReasoningLevel = "low"
+	ReasoningMedium ReasoningLevel = "medium"
+	ReasoningHigh   ReasoningLevel = "high"
+)
+
+var reasoningLevels = []ReasoningLevel{ReasoningOff, ReasoningLow, ReasoningMedium, ReasoningHigh}
+
+// thinkingBudgets are the token budgets of the levels, for the providers
+// that take a budget rather than an effort.
+var thinkingBudgets = map[ReasoningLevel]int64{
+	ReasoningLow:    2000,
+	ReasoningMedium: 8000,
+	ReasoningHigh:   16000,
+}
+
+// Next returns the level after l, going back to off after high.
+func (l ReasoningLevel) Next() ReasoningLevel {
+	i := slices.Index(reasoningLevels, l)
+	return reasoningLevels[(i+1)%len(reasoningLevels)]
+}
+
+// configuredReasoningLevel returns the level closest to the reasoning
+// configuration of a model.
+func configuredReasoningLevel(model Model, providerType catwalk.Type) ReasoningLevel {
+	switch providerType {
+	case anthropic.Name:
+		if model.ModelCfg.Think {
+			return ReasoningLow
+		}
+		return ReasoningOff
+	case google.Name:
+		// Thinking is always on with the low budget.
+		return ReasoningLow
+	}
+	effort := ReasoningLevel(cmp.Or(model.ModelCfg.ReasoningEffort, model.CatwalkCfg.DefaultReasoningEffort))
+	switch {
+	case effort == "":
+		return ReasoningOff
+	case slices.Contains(reasoningLevels, effort):
+		return effort
+	default:
+		return ReasoningLow
+	}
+}
+
+// setReasoningLevel sets the provider options for the reasoning level of
+// the model.
+func setReasoningLevel(options map[string]any, model Model, providerType catwalk.Type) {
+	level := model.Reasoning
+	switch providerType {
+	case anthropic.Name:
+		if level == ReasoningOff {
+			delete(options, "thinking")
+			return
+		}
+		options["thinking"] = map[string]any{
+			"budget_tokens": thinkingBudget(model, level),
+		}
+	case google.Name:
+		budget := int64(0)
+		if level != ReasoningOff {
+			budget = thinkingBudget(model, level)
+		}
+		options["thinking_config"] = map[string]any{
+			"thinking_budget":  budget,
+			"include_thoughts": level != ReasoningOff,
+		}
+	case openrouter.Name:
+		if level == ReasoningOff {
+			options["reasoning"] = map[string]any{"enabled": false}
+			return
+		}
+		options["reasoning"] = map[string]any{
+			"enabled": true,
+			"effort":  string(level),
+		}
+	case openai.Name, azure.Name, openaicompat.Name, copilot.Name:
+		if level != ReasoningOff {
+			options["reasoning_effort"] = string(level)
+			return
+		}
+		// Models that always reason get the lowest effort they support.
+		if len(model.CatwalkCfg.ReasoningLevels) > 0 {
+			options["reasoning_effort"] = model.CatwalkCfg.ReasoningLevels[0]
+			return
+		}
+		delete(options, "reasoning_effort")
+	}
+}
+
+// thinkingBudget returns the token budget of a level, leaving at least half
+// of the output tokens for the answer.
+func thinkingBudget(model Model, level ReasoningLevel) int64 {
+	budget := thinkingBudgets[level]
+	if maxTokens := cmp.Or(model.ModelCfg.MaxTokens, model.CatwalkCfg.DefaultMaxTokens); maxTokens > 0 {
+		// Anthropic doesn't take budgets under 1024 tokens.
+		budget = max(min(budget, maxTokens/2), 1024)
+	}
+	return budget
+}
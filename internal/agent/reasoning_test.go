@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"testing"
+
+	"charm.land/fantasy/providers/anthropic"
+	"charm.land/fantasy/providers/google"
+	"charm.land/fantasy/providers/openai"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReasoningLevelNext(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, ReasoningLow, ReasoningOff.Next())
+	require.Equal(t, ReasoningOff, ReasoningHigh.Next())
+	require.Equal(t, ReasoningOff, ReasoningLevel("").Next())
+}
+
+func TestGetProviderOptionsReasoningLevel(t *testing.T) {
+	t.Parallel()
+
+	t.Run("anthropic", func(t *testing.T) {
+		t.Parallel()
+		providerCfg := config.ProviderConfig{Type: anthropic.Name}
+		model := Model{
+			CatwalkCfg: catwalk.Model{DefaultMaxTokens: 64000},
+			ModelCfg:   config.SelectedModel{Think: true},
+			Reasoning:  ReasoningHigh,
+		}
+		opts := getProviderOptions(model, providerCfg)[anthropic.Name].(*anthropic.ProviderOptions)
+		require.Equal(t, int64(16000), opts.Thinking.BudgetTokens)
+
+		model.CatwalkCfg.DefaultMaxTokens = 10000
+		opts = getProviderOptions(model, providerCfg)[anthropic.Name].(*anthropic.ProviderOptions)
+		require.Equal(t, int64(5000), opts.Thinking.BudgetTokens, "half of the output tokens are left")
+
+		model.Reasoning = ReasoningOff
+		opts = getProviderOptions(model, providerCfg)[anthropic.Name].(*anthropic.ProviderOptions)
+		require.Nil(t, opts.Thinking, "the level replaces the thinking mode")
+	})
+
+	t.Run("openai", func(t *testing.T) {
+		t.Parallel()
+		providerCfg := config.ProviderConfig{Type: openai.Name}
+		model := Model{
+			CatwalkCfg: catwalk.Model{ID: "custom-reasoner", ReasoningLevels: []string{"low", "medium", "high"}},
+			ModelCfg:   config.SelectedModel{ReasoningEffort: "low"},
+			Reasoning:  ReasoningMedium,
+		}
+		opts := getProviderOptions(model, providerCfg)[openai.Name].(*openai.ProviderOptions)
+		require.Equal(t, openai.ReasoningEffortMedium, *opts.ReasoningEffort)
+
+		model.Reasoning = ReasoningOff
+		opts = getProviderOptions(model, providerCfg)[openai.Name].(*openai.ProviderOptions)
+		require.Equal(t, openai.ReasoningEffortLow, *opts.ReasoningEffort, "the lowest effort the model supports")
+	})
+
+	t.Run("google", func(t *testing.T) {
+		t.Parallel()
+		providerCfg := config.ProviderConfig{Type: google.Name}
+		model := Model{Reasoning: ReasoningOff}
+		opts := getProviderOptions(model, providerCfg)[google.Name].(*google.ProviderOptions)
+		require.Equal(t, int64(0), *opts.ThinkingConfig.ThinkingBudget)
+		require.False(t, *opts.ThinkingConfig.IncludeThoughts)
+	})
+}
+
+func TestConfiguredReasoningLevel(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, ReasoningLow, configuredReasoningLevel(Model{ModelCfg: config.SelectedModel{Think: true}}, anthropic.Name))
+	require.Equal(t, ReasoningOff, configuredReasoningLevel(Model{}, anthropic.Name))
+	require.Equal(t, ReasoningHigh, configuredReasoningLevel(Model{
+		CatwalkCfg: catwalk.Model{DefaultReasoningEffort: "medium"},
+		ModelCfg:   config.SelectedModel{ReasoningEffort: "high"},
+	}, openai.Name))
+	require.Equal(t, ReasoningLow, configuredReasoningLevel(Model{ModelCfg: config.SelectedModel{ReasoningEffort: "minimal"}}, openai.Name))
+}
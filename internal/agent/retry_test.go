@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingAgent records the calls it is asked to run.
+type recordingAgent struct {
+	SessionAgent
+	calls []SessionAgentCall
+}
+
+func (a *recordingAgent) Run(_ context.Context, call SessionAgentCall) (*fantasy.AgentResult, error) {
+	a.calls = append(a.calls, call)
+	return nil, nil
+}
+
+func (a *recordingAgent) IsSessionBusy(string) bool { return false }
+
+func (a *recordingAgent) Model() Model {
+	return Model{
+		CatwalkCfg: catwalk.Model{ID: "large", SupportsImages: true},
+		ModelCfg:   config.SelectedModel{Provider: "test", Model: "large"},
+	}
+}
+
+func TestCoordinatorRetry(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+	sessions := session.NewService(q)
+	messages := message.NewService(q)
+
+	agent := &recordingAgent{}
+	c := &coordinator{
+		cfg: &config.Config{Providers: csync.NewMapFrom(map[string]config.ProviderConfig{
+			"test": {ID: "test"},
+		})},
+		messages:        messages,
+		currentAgent:    agent,
+		reasoningLevels: csync.NewMap[string, ReasoningLevel](),
+		comparisons:     csync.NewMap[string, context.CancelFunc](),
+	}
+
+	sess, err := sessions.Create(t.Context(), "retry")
+	require.NoError(t, err)
+	_, err = c.Retry(t.Context(), sess.ID, "")
+	require.ErrorContains(t, err, "no message to retry")
+
+	create := func(role message.MessageRole, parts ...message.ContentPart) {
+		_, err := messages.Create(t.Context(), sess.ID, message.CreateMessageParams{Role: role, Parts: parts})
+		require.NoError(t, err)
+	}
+	create(message.User, message.TextContent{Text: "first"})
+	create(message.Assistant, message.TextContent{Text: "first answer"})
+	create(message.User, message.TextContent{Text: "second"}, message.BinaryContent{Path: "/tmp/shot.png", MIMEType: "image/png", Data: []byte("png")})
+	create(message.Assistant, message.ToolCall{ID: "call-1", Name: "view"})
+	create(message.Tool, message.ToolResult{ToolCallID: "call-1", Content: "file"})
+	create(message.Assistant, message.TextContent{Text: "second answer"})
+
+	_, err = c.Retry(t.Context(), sess.ID, "missing-model")
+	require.ErrorContains(t, err, `model "missing-model" not found`)
+
+	_, err = c.Retry(t.Context(), sess.ID, "")
+	require.NoError(t, err)
+	msgs, err := messages.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2, "the last turn is dropped")
+	require.Equal(t, "first answer", msgs[1].Content().Text)
+
+	require.Len(t, agent.calls, 1)
+	call := agent.calls[0]
+	require.Equal(t, "second", call.Prompt)
+	require.Nil(t, call.Model)
+	require.Equal(t, []message.Attachment{{
+		FilePath: "/tmp/shot.png",
+		FileName: "shot.png",
+		MimeType: "image/png",
+		Content:  []byte("png"),
+	}}, call.Attachments)
+}
+
+func TestCoordinatorResend(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+	sessions := session.NewService(q)
+	messages := message.NewService(q)
+
+	agent := &recordingAgent{}
+	c := &coordinator{
+		cfg: &config.Config{Providers: csync.NewMapFrom(map[string]config.ProviderConfig{
+			"test": {ID: "test"},
+		})},
+		messages:        messages,
+		currentAgent:    agent,
+		reasoningLevels: csync.NewMap[string, ReasoningLevel](),
+		comparisons:     csync.NewMap[string, context.CancelFunc](),
+	}
+
+	sess, err := sessions.Create(t.Context(), "resend")
+	require.NoError(t, err)
+	create := func(role message.MessageRole, parts ...message.ContentPart) message.Message {
+		msg, err := messages.Create(t.Context(), sess.ID, message.CreateMessageParams{Role: role, Parts: parts})
+		require.NoError(t, err)
+		return msg
+	}
+	first := create(message.User, message.TextContent{Text: "first"}, message.BinaryContent{Path: "/tmp/notes.txt", MIMEType: "text/plain", Data: []byte("notes")})
+	answer := create(message.Assistant, message.TextContent{Text: "first answer"})
+	create(message.User, message.TextContent{Text: "second"})
+	create(message.Assistant, message.TextContent{Text: "second answer"})
+
+	_, err = c.Resend(t.Context(), sess.ID, answer.ID, "edited")
+	require.ErrorContains(t, err, "no such message")
+	_, err = c.Resend(t.Context(), sess.ID, first.ID, " ")
+	require.ErrorIs(t, err, ErrEmptyPrompt)
+
+	_, err = c.Resend(t.Context(), sess.ID, first.ID, "first, edited")
+	require.NoError(t, err)
+	msgs, err := messages.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Empty(t, msgs, "the message and everything after it are dropped")
+
+	require.Len(t, agent.calls, 1)
+	call := agent.calls[0]
+	require.Equal(t, "first, edited", call.Prompt)
+	require.Equal(t, []message.Attachment{{
+		FilePath: "/tmp/notes.txt",
+		FileName: "notes.txt",
+		MimeType: "text/plain",
+		Content:  []byte("notes"),
+	}}, call.Attachments)
+}
@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"context"
+	"errors"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+)
+
+// Sample implements Coordinator. It answers with the large model, or the
+// small one when the server prefers speed or cost.
+func (c *coordinator) Sample(ctx context.Context, sessionID string, req mcp.SamplingRequest) (mcp.SamplingResult, error) {
+	large, small, err := c.buildAgentModels(ctx)
+	if err != nil {
+		return mcp.SamplingResult{}, err
+	}
+	model := large
+	if req.PreferFast {
+		model = small
+	}
+	providerCfg, ok := c.cfg.Providers.Get(model.ModelCfg.Provider)
+	if !ok {
+		return mcp.SamplingResult{}, errors.New("model provider not configured")
+	}
+
+	var prompt fantasy.Prompt
+	if providerCfg.SystemPromptPrefix != "" {
+		prompt = append(prompt, fantasy.NewSystemMessage(providerCfg.SystemPromptPrefix))
+	}
+	if req.SystemPrompt != "" {
+		prompt = append(prompt, fantasy.NewSystemMessage(req.SystemPrompt))
+	}
+	for _, msg := range req.Messages {
+		if msg.Role == "assistant" {
+			prompt = append(prompt, fantasy.Message{
+				Role:    fantasy.MessageRoleAssistant,
+				Content: []fantasy.MessagePart{fantasy.TextPart{Text: msg.Text}},
+			})
+			continue
+		}
+		prompt = append(prompt, fantasy.NewUserMessage(msg.Text))
+	}
+
+	// The server can't ask for more tokens than the model is configured for.
+	maxTokens := model.CatwalkCfg.DefaultMaxTokens
+	if model.ModelCfg.MaxTokens != 0 {
+		maxTokens = model.ModelCfg.MaxTokens
+	}
+	if req.MaxTokens > 0 && (maxTokens == 0 || req.MaxTokens < maxTokens) {
+		maxTokens = req.MaxTokens
+	}
+	call := fantasy.Call{
+		Prompt:          prompt,
+		Temperature:     req.Temperature,
+		ProviderOptions: getProviderOptions(model, providerCfg),
+	}
+	if maxTokens > 0 {
+		call.MaxOutputTokens = &maxTokens
+	}
+	resp, err := model.Model.Generate(ctx, call)
+	if err != nil {
+		return mcp.SamplingResult{}, err
+	}
+
+	// The completion is paid for by the session the server answers.
+	sess, err := c.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return mcp.SamplingResult{}, err
+	}
+	sess.Cost += usageCost(model, resp.Usage)
+	if _, err := c.sessions.Save(ctx, sess); err != nil {
+		return mcp.SamplingResult{}, err
+	}
+
+	stopReason := "endTurn"
+	if resp.FinishReason == fantasy.FinishReasonLength {
+		stopReason = "maxTokens"
+	}
+	return mcp.SamplingResult{
+		Model:      model.Model.Model(),
+		Text:       resp.Content.Text(),
+		StopReason: stopReason,
+	}, nil
+}
@@ -0,0 +1,158 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/fsext"
+)
+
+// maxScopeDepth is how many parent sessions are looked up for the scope of
+// a sub-agent session.
+const maxScopeDepth = 4
+
+// scopedParam is a path parameter of a tool that must be inside the scope
+// of the session.
+type scopedParam struct {
+	name string
+	// defaults tells that the parameter defaults to the scope when empty,
+	// rather than to the working directory.
+	defaults bool
+	// list tells that the parameter is a list of paths.
+	list bool
+}
+
+// scopedParams are the path parameters of the tools, by tool name.
+var scopedParams = map[string][]scopedParam{
+	tools.BashToolName:        {{name: "working_dir", defaults: true}},
+	tools.DownloadToolName:    {{name: "file_path"}},
+	tools.EditToolName:        {{name: "file_path"}},
+	tools.GitBlameToolName:    {{name: "path"}},
+	tools.GitLogToolName:      {{name: "path", defaults: true}},
+	tools.GlobToolName:        {{name: "path", defaults: true}},
+	tools.GrepToolName:        {{name: "path", defaults: true}},
+	tools.LSToolName:          {{name: "path", defaults: true}},
+	tools.MultiEditToolName:   {{name: "file_path"}},
+	tools.ReferencesToolName:  {{name: "path", defaults: true}},
+	tools.DiagnosticsToolName: {{name: "file_path"}},
+	tools.RunTestsToolName:    {{name: "files", list: true}},
+	tools.ViewToolName:        {{name: "file_path"}},
+	tools.WriteToolName:       {{name: "file_path"}},
+}
+
+// scopedTool keeps the file tools of a session inside its scope, a
+// directory of the working directory: paths out of it are rejected, and the
+// search tools search it by default. Commands of the bash tool start in the
+// scope, but aren't confined to it.
+type scopedTool struct {
+	fantasy.AgentTool
+	workingDir string
+	// scope returns the scope of a session.
+	scope func(ctx context.Context, sessionID string) string
+}
+
+func withScope(workingDir string, scope func(ctx context.Context, sessionID string) string, agentTools []fantasy.AgentTool) []fantasy.AgentTool {
+	wrapped := make([]fantasy.AgentTool, len(agentTools))
+	for i, tool := range agentTools {
+		name := tool.Info().Name
+		if _, ok := scopedParams[name]; !ok && name != tools.ApplyPatchToolName {
+			wrapped[i] = tool
+			continue
+		}
+		wrapped[i] = &scopedTool{AgentTool: tool, workingDir: workingDir, scope: scope}
+	}
+	return wrapped
+}
+
+func (t *scopedTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	scope := t.scope(ctx, tools.GetSessionFromContext(ctx))
+	if scope == "" || scope == t.workingDir {
+		return t.AgentTool.Run(ctx, call)
+	}
+	input, err := scopeInput(t.Info().Name, call.Input, t.workingDir, scope)
+	if err != nil {
+		return fantasy.NewTextErrorResponse(err.Error()), nil
+	}
+	call.Input = input
+	return t.AgentTool.Run(ctx, call)
+}
+
+// scopeInput checks the paths of the input of a tool call against scope,
+// and sets the ones that default to it.
+func scopeInput(toolName, input, workingDir, scope string) (string, error) {
+	var params map[string]any
+	if err := json.Unmarshal([]byte(input), &params); err != nil {
+		// Let the tool report the invalid input.
+		return input, nil
+	}
+
+	check := func(path string) error {
+		if !fsext.InScope(filepath.Clean(filepathext.SmartJoin(workingDir, path)), scope) {
+			rel, _ := filepath.Rel(workingDir, scope)
+			return fmt.Errorf("%s is outside the scope of this session: only files in %s can be used. Ask the user to change the scope if you need files out of it", path, filepath.ToSlash(rel))
+		}
+		return nil
+	}
+
+	if toolName == tools.ApplyPatchToolName {
+		patch, _ := params["patch"].(string)
+		filePatches, err := diff.ParsePatch(patch)
+		if err != nil {
+			return input, nil
+		}
+		for _, filePatch := range filePatches {
+			if err := check(filePatch.Path()); err != nil {
+				return "", err
+			}
+		}
+		return input, nil
+	}
+
+	changed := false
+	for _, param := range scopedParams[toolName] {
+		if param.list {
+			paths, _ := params[param.name].([]any)
+			for _, p := range paths {
+				if path, _ := p.(string); path != "" {
+					if err := check(path); err != nil {
+						return "", err
+					}
+				}
+			}
+			continue
+		}
+		path, _ := params[param.name].(string)
+		if path == "" {
+			if param.defaults {
+				params[param.name] = scope
+				changed = true
+			}
+			continue
+		}
+		if err := check(path); err != nil {
+			return "", err
+		}
+	}
+	if toolName == tools.GlobToolName {
+		pattern, _ := params["pattern"].(string)
+		if slices.Contains(strings.Split(filepath.ToSlash(pattern), "/"), "..") {
+			return "", fmt.Errorf("pattern %s leaves the scope of this session: use a path inside it instead of ..", pattern)
+		}
+	}
+	if !changed {
+		return input, nil
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,101 @@
+package agent
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopeInput(t *testing.T) {
+	t.Parallel()
+
+	workingDir := filepath.FromSlash("/repo")
+	scope := filepath.FromSlash("/repo/packages/api")
+
+	tests := []struct {
+		name   string
+		tool   string
+		input  string
+		params map[string]any
+		err    string
+	}{
+		{
+			name:  "file in scope",
+			tool:  tools.ViewToolName,
+			input: `{"file_path":"packages/api/main.go"}`,
+		},
+		{
+			name:  "absolute file in scope",
+			tool:  tools.EditToolName,
+			input: `{"file_path":"/repo/packages/api/handler.go","old_string":"a","new_string":"b"}`,
+		},
+		{
+			name:  "file out of scope",
+			tool:  tools.WriteToolName,
+			input: `{"file_path":"packages/web/index.ts","content":"x"}`,
+			err:   "packages/web/index.ts is outside the scope of this session: only files in packages/api can be used",
+		},
+		{
+			name:  "sibling with the same prefix",
+			tool:  tools.ViewToolName,
+			input: `{"file_path":"packages/api-old/main.go"}`,
+			err:   "outside the scope",
+		},
+		{
+			name:   "search defaults to the scope",
+			tool:   tools.GrepToolName,
+			input:  `{"pattern":"TODO"}`,
+			params: map[string]any{"pattern": "TODO", "path": scope},
+		},
+		{
+			name:   "bash starts in the scope",
+			tool:   tools.BashToolName,
+			input:  `{"command":"go test ./...","description":"Run tests"}`,
+			params: map[string]any{"command": "go test ./...", "description": "Run tests", "working_dir": scope},
+		},
+		{
+			name:  "tests of files in scope",
+			tool:  tools.RunTestsToolName,
+			input: `{"files":["packages/api/main.go","/repo/packages/api/handler.go"]}`,
+		},
+		{
+			name:  "tests of a file out of scope",
+			tool:  tools.RunTestsToolName,
+			input: `{"files":["packages/api/main.go","packages/web/index.ts"]}`,
+			err:   "packages/web/index.ts is outside the scope",
+		},
+		{
+			name:  "glob pattern leaving the scope",
+			tool:  tools.GlobToolName,
+			input: `{"pattern":"../web/**/*.ts"}`,
+			err:   "leaves the scope",
+		},
+		{
+			name:  "patch out of scope",
+			tool:  tools.ApplyPatchToolName,
+			input: `{"patch":"--- a/packages/api/a.go\n+++ b/packages/api/a.go\n@@ -1 +1 @@\n-a\n+b\n--- a/go.mod\n+++ b/go.mod\n@@ -1 +1 @@\n-a\n+b\n"}`,
+			err:   "go.mod is outside the scope",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			input, err := scopeInput(tt.tool, tt.input, workingDir, scope)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			if tt.params == nil {
+				require.Equal(t, tt.input, input)
+				return
+			}
+			var params map[string]any
+			require.NoError(t, json.Unmarshal([]byte(input), &params))
+			require.Equal(t, tt.params, params)
+		})
+	}
+}
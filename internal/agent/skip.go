@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"context"
+	"errors"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/csync"
+)
+
+// errToolSkipped is the cause of the cancellation of a skipped tool call.
+var errToolSkipped = errors.New("tool call skipped")
+
+// skippedToolMessage is the result the model gets for a skipped tool call.
+const skippedToolMessage = "The user skipped this tool call while it was running, so it has no result. Carry on without it, and don't run it again unless the user asks."
+
+// skippableTool lets a tool call be skipped while it runs: the call is
+// cancelled and gets an error result, and the run carries on.
+type skippableTool struct {
+	fantasy.AgentTool
+	// running holds the skip functions of the running tool calls by ID.
+	running *csync.Map[string, context.CancelFunc]
+}
+
+func withSkipping(running *csync.Map[string, context.CancelFunc], agentTools []fantasy.AgentTool) []fantasy.AgentTool {
+	wrapped := make([]fantasy.AgentTool, len(agentTools))
+	for i, tool := range agentTools {
+		wrapped[i] = &skippableTool{AgentTool: tool, running: running}
+	}
+	return wrapped
+}
+
+func (t *skippableTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	t.running.Set(call.ID, func() { cancel(errToolSkipped) })
+	defer t.running.Del(call.ID)
+
+	type result struct {
+		resp fantasy.ToolResponse
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp, err := t.AgentTool.Run(ctx, call)
+		done <- result{resp, err}
+	}()
+
+	select {
+	case r := <-done:
+		if errors.Is(context.Cause(ctx), errToolSkipped) {
+			return fantasy.NewTextErrorResponse(skippedToolMessage), nil
+		}
+		return r.resp, r.err
+	case <-ctx.Done():
+		if errors.Is(context.Cause(ctx), errToolSkipped) {
+			// Don't wait for tools that don't stop when cancelled.
+			return fantasy.NewTextErrorResponse(skippedToolMessage), nil
+		}
+		r := <-done
+		return r.resp, r.err
+	}
+}
+
+// SkipToolCall cancels the tool call with the given ID if it's running. The
+// model gets an error result for it and the run carries on.
+func (c *coordinator) SkipToolCall(toolCallID string) bool {
+	skip, ok := c.runningTools.Take(toolCallID)
+	if ok {
+		skip()
+	}
+	return ok
+}
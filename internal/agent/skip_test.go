@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingTool runs until it's released, ignoring cancellation.
+type blockingTool struct {
+	fantasy.AgentTool
+	started chan struct{}
+	release chan struct{}
+}
+
+func (t *blockingTool) Run(context.Context, fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	close(t.started)
+	<-t.release
+	return fantasy.NewTextResponse("done"), nil
+}
+
+func TestSkipToolCall(t *testing.T) {
+	t.Parallel()
+
+	c := &coordinator{runningTools: csync.NewMap[string, context.CancelFunc]()}
+	tool := &blockingTool{started: make(chan struct{}), release: make(chan struct{})}
+	defer close(tool.release)
+	wrapped := withSkipping(c.runningTools, []fantasy.AgentTool{tool})[0]
+
+	require.False(t, c.SkipToolCall("call-1"), "nothing is running")
+
+	type result struct {
+		resp fantasy.ToolResponse
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp, err := wrapped.Run(t.Context(), fantasy.ToolCall{ID: "call-1"})
+		done <- result{resp, err}
+	}()
+	<-tool.started
+
+	require.True(t, c.SkipToolCall("call-1"))
+	select {
+	case r := <-done:
+		require.NoError(t, r.err)
+		require.True(t, r.resp.IsError)
+		require.Equal(t, skippedToolMessage, r.resp.Content)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the skipped tool call didn't return")
+	}
+	_, running := c.runningTools.Get("call-1")
+	require.False(t, running)
+}
+
+func TestSkippableToolFinishes(t *testing.T) {
+	t.Parallel()
+
+	running := csync.NewMap[string, context.CancelFunc]()
+	tool := &blockingTool{started: make(chan struct{}), release: make(chan struct{})}
+	close(tool.release)
+	wrapped := withSkipping(running, []fantasy.AgentTool{tool})[0]
+
+	resp, err := wrapped.Run(t.Context(), fantasy.ToolCall{ID: "call-1"})
+	require.NoError(t, err)
+	require.Equal(t, "done", resp.Content)
+	require.Zero(t, running.Len())
+}
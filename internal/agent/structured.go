@@ -0,0 +1,156 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/kaptinlin/jsonschema"
+)
+
+// maxStructuredOutputAttempts is how many responses the model gets to
+// produce one that conforms to the output schema.
+const maxStructuredOutputAttempts = 3
+
+const structuredOutputPrompt = "Respond with the final result of the task above as JSON that conforms to the provided schema, and nothing else."
+
+// OutputSchema is a JSON schema the final response of a run must conform
+// to.
+type OutputSchema struct {
+	schema    fantasy.Schema
+	validator *jsonschema.Schema
+}
+
+// ParseOutputSchema parses a JSON schema. The full schema is used to
+// validate responses, while providers get the subset they support.
+func ParseOutputSchema(data []byte) (*OutputSchema, error) {
+	var s fantasy.Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid output schema: %w", err)
+	}
+	validator, err := jsonschema.NewCompiler().Compile(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output schema: %w", err)
+	}
+	return &OutputSchema{schema: s, validator: validator}, nil
+}
+
+// Validate checks that data conforms to the schema.
+func (s *OutputSchema) Validate(data []byte) error {
+	result := s.validator.ValidateJSON(data)
+	if result.IsValid() {
+		return nil
+	}
+	errs := result.GetDetailedErrors()
+	msgs := make([]string, 0, len(errs))
+	for _, path := range slices.Sorted(maps.Keys(errs)) {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", path, errs[path]))
+	}
+	return fmt.Errorf("%w: %s", ErrOutputSchemaViolation, strings.Join(msgs, "; "))
+}
+
+// GenerateObject asks the large model for a response to the session that
+// conforms to outputSchema, using the provider's structured output support.
+// Responses that don't conform are sent back to the model with the
+// validation error until it gets it right or runs out of attempts. The
+// response is saved to the session as an assistant message.
+func (a *sessionAgent) GenerateObject(ctx context.Context, sessionID string, outputSchema *OutputSchema, opts fantasy.ProviderOptions) (json.RawMessage, error) {
+	if a.IsSessionBusy(sessionID) {
+		return nil, ErrSessionBusy
+	}
+
+	currentSession, err := a.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	msgs, err := a.getSessionMessages(ctx, currentSession)
+	if err != nil {
+		return nil, err
+	}
+	history, _ := a.preparePrompt(msgs)
+
+	genCtx, cancel := context.WithCancel(ctx)
+	a.activeRequests.Set(sessionID, cancel)
+	defer a.activeRequests.Del(sessionID)
+	defer cancel()
+
+	var prompt fantasy.Prompt
+	if a.systemPromptPrefix != "" {
+		prompt = append(prompt, fantasy.NewSystemMessage(a.systemPromptPrefix))
+	}
+	prompt = append(prompt, history...)
+	prompt = append(prompt, fantasy.NewUserMessage(structuredOutputPrompt))
+	prompt = applyMessageQuirks(modelQuirks(a.largeModel), prompt)
+
+	var lastErr error
+	for range maxStructuredOutputAttempts {
+		resp, err := a.largeModel.Model.GenerateObject(genCtx, fantasy.ObjectCall{
+			Prompt:          prompt,
+			Schema:          outputSchema.schema,
+			SchemaName:      "result",
+			ProviderOptions: opts,
+		})
+
+		var raw string
+		var noObject *fantasy.NoObjectGeneratedError
+		switch {
+		case errors.As(err, &noObject):
+			a.updateSessionUsage(a.largeModel, &currentSession, noObject.Usage, nil)
+			raw = noObject.RawText
+			// The validator tells what's wrong with the response better than
+			// the provider, when there is JSON to validate.
+			if lastErr = outputSchema.Validate([]byte(raw)); lastErr == nil || !json.Valid([]byte(raw)) {
+				lastErr = fmt.Errorf("%w: %v", ErrOutputSchemaViolation, err)
+			}
+		case err != nil:
+			return nil, err
+		default:
+			a.updateSessionUsage(a.largeModel, &currentSession, resp.Usage, a.openrouterCost(resp.ProviderMetadata))
+			data, err := json.Marshal(resp.Object)
+			if err != nil {
+				return nil, err
+			}
+			if lastErr = outputSchema.Validate(data); lastErr == nil {
+				return data, a.saveObject(ctx, currentSession, data)
+			}
+			raw = string(data)
+		}
+
+		prompt = append(prompt,
+			fantasy.Message{
+				Role:    fantasy.MessageRoleAssistant,
+				Content: []fantasy.MessagePart{fantasy.TextPart{Text: raw}},
+			},
+			fantasy.NewUserMessage(fmt.Sprintf("That response doesn't conform to the schema (%v). Try again.", lastErr)),
+		)
+	}
+	if _, err := a.sessions.Save(ctx, currentSession); err != nil {
+		return nil, err
+	}
+	return nil, lastErr
+}
+
+func (a *sessionAgent) saveObject(ctx context.Context, currentSession session.Session, data json.RawMessage) error {
+	msg, err := a.messages.Create(ctx, currentSession.ID, message.CreateMessageParams{
+		Role:     message.Assistant,
+		Parts:    []message.ContentPart{message.TextContent{Text: string(data)}},
+		Model:    a.largeModel.Model.Model(),
+		Provider: a.largeModel.Model.Provider(),
+	})
+	if err != nil {
+		return err
+	}
+	msg.AddFinish(message.FinishReasonEndTurn, "", "")
+	if err := a.messages.Update(ctx, msg); err != nil {
+		return err
+	}
+	_, err = a.sessions.Save(ctx, currentSession)
+	return err
+}
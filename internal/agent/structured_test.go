@@ -0,0 +1,119 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+const testOutputSchema = `{
+	"type": "object",
+	"properties": {
+		"count": {"type": "integer", "minimum": 0}
+	},
+	"required": ["count"],
+	"additionalProperties": false
+}`
+
+// objectModel is a language model that returns canned objects.
+type objectModel struct {
+	fantasy.LanguageModel
+	objects []any
+	calls   []fantasy.ObjectCall
+}
+
+func (m *objectModel) GenerateObject(_ context.Context, call fantasy.ObjectCall) (*fantasy.ObjectResponse, error) {
+	m.calls = append(m.calls, call)
+	obj := m.objects[0]
+	m.objects = m.objects[1:]
+	return &fantasy.ObjectResponse{Object: obj}, nil
+}
+
+func (m *objectModel) Provider() string { return "fake" }
+func (m *objectModel) Model() string    { return "fake" }
+
+func TestOutputSchema(t *testing.T) {
+	t.Parallel()
+
+	s, err := ParseOutputSchema([]byte(testOutputSchema))
+	require.NoError(t, err)
+	require.Equal(t, "object", s.schema.Type)
+
+	require.NoError(t, s.Validate([]byte(`{"count": 2}`)))
+	require.ErrorIs(t, s.Validate([]byte(`{"count": -1}`)), ErrOutputSchemaViolation)
+	// Keywords providers don't get are still validated.
+	require.ErrorIs(t, s.Validate([]byte(`{"count": 1, "extra": true}`)), ErrOutputSchemaViolation)
+
+	_, err = ParseOutputSchema([]byte(`{"type":`))
+	require.Error(t, err)
+}
+
+func TestGenerateObjectRetries(t *testing.T) {
+	env := testEnv(t)
+	_, err := config.Init(env.workingDir, "", false)
+	require.NoError(t, err)
+	outputSchema, err := ParseOutputSchema([]byte(testOutputSchema))
+	require.NoError(t, err)
+
+	model := &objectModel{objects: []any{
+		map[string]any{"count": -1},
+		map[string]any{"count": 3},
+	}}
+	agent := testSessionAgent(env, model, model, "")
+
+	sess, err := env.sessions.Create(t.Context(), "structured")
+	require.NoError(t, err)
+	_, err = env.messages.Create(t.Context(), sess.ID, message.CreateMessageParams{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: "count to three"}},
+	})
+	require.NoError(t, err)
+
+	data, err := agent.GenerateObject(t.Context(), sess.ID, outputSchema, nil)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"count": 3}`, string(data))
+
+	require.Len(t, model.calls, 2)
+	retry := model.calls[1].Prompt
+	feedback := retry[len(retry)-1].Content[0].(fantasy.TextPart).Text
+	require.True(t, strings.Contains(feedback, "minimum"), feedback)
+
+	msgs, err := env.messages.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	require.Equal(t, message.Assistant, msgs[1].Role)
+	require.JSONEq(t, `{"count": 3}`, msgs[1].Content().Text)
+}
+
+func TestGenerateObjectGivesUp(t *testing.T) {
+	env := testEnv(t)
+	_, err := config.Init(env.workingDir, "", false)
+	require.NoError(t, err)
+	outputSchema, err := ParseOutputSchema([]byte(testOutputSchema))
+	require.NoError(t, err)
+
+	var objects []any
+	for range maxStructuredOutputAttempts {
+		objects = append(objects, map[string]any{"count": -1})
+	}
+	model := &objectModel{objects: objects}
+	agent := testSessionAgent(env, model, model, "")
+
+	sess, err := env.sessions.Create(t.Context(), "structured")
+	require.NoError(t, err)
+	_, err = env.messages.Create(t.Context(), sess.ID, message.CreateMessageParams{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: "count to minus one"}},
+	})
+	require.NoError(t, err)
+
+	_, err = agent.GenerateObject(t.Context(), sess.ID, outputSchema, nil)
+	require.ErrorIs(t, err, ErrOutputSchemaViolation)
+	require.ErrorContains(t, err, "minimum", "the error has the message of the validator")
+	require.Len(t, model.calls, maxStructuredOutputAttempts)
+}
@@ -0,0 +1,191 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/pubsub"
+)
+
+// SubAgentStatus is the state of a sub-agent run.
+type SubAgentStatus string
+
+const (
+	// SubAgentQueued means the sub-agent waits for a free slot, see
+	// options.max_sub_agents.
+	SubAgentQueued  SubAgentStatus = "queued"
+	SubAgentRunning SubAgentStatus = "running"
+	SubAgentDone    SubAgentStatus = "done"
+)
+
+// SubAgentProgress is published while a sub-agent started by the agent or
+// agentic_fetch tools runs.
+type SubAgentProgress struct {
+	// ToolCallID is the ID of the tool call that started the sub-agent.
+	ToolCallID string
+	SessionID  string
+	Status     SubAgentStatus
+	// Tool is the name of the tool the sub-agent is running, if any.
+	Tool string
+	// Tokens is the number of tokens the sub-agent used so far.
+	Tokens int64
+}
+
+// subAgentBudget bounds a sub-agent run. Zero fields don't bound it.
+type subAgentBudget struct {
+	// MaxSteps is the number of steps the sub-agent may take.
+	MaxSteps int
+	// MaxTokens is the number of tokens the sub-agent may use.
+	MaxTokens int64
+}
+
+// exceeded returns why p goes over the budget after steps steps, if it does.
+func (b subAgentBudget) exceeded(p SubAgentProgress, steps int) error {
+	if b.MaxSteps > 0 && steps > b.MaxSteps {
+		return fmt.Errorf("%w: it needed more than %d steps", ErrSubAgentOverBudget, b.MaxSteps)
+	}
+	if b.MaxTokens > 0 && p.Tokens > b.MaxTokens {
+		return fmt.Errorf("%w: it used %d tokens, more than %d", ErrSubAgentOverBudget, p.Tokens, b.MaxTokens)
+	}
+	return nil
+}
+
+var subAgentBroker = pubsub.NewBroker[SubAgentProgress]()
+
+// SubscribeSubAgents returns a channel for sub-agent progress events.
+func SubscribeSubAgents(ctx context.Context) <-chan pubsub.Event[SubAgentProgress] {
+	return subAgentBroker.Subscribe(ctx)
+}
+
+// runSubAgent runs agent for the tool call with the given ID. It waits for a
+// free slot when options.max_sub_agents sub-agents already run, and
+// publishes the progress of the run. The run can be cancelled on its own
+// with [coordinator.CancelSubAgent], in which case [ErrSubAgentCancelled] is
+// returned. The run is also cancelled when it goes over budget, in which case
+// an error wrapping [ErrSubAgentOverBudget] is returned.
+func (c *coordinator) runSubAgent(ctx context.Context, toolCallID string, agent SessionAgent, call SessionAgentCall, budget subAgentBudget) (*fantasy.AgentResult, error) {
+	waitCtx, stopWaiting := context.WithCancel(ctx)
+	defer stopWaiting()
+	c.subAgents.Set(toolCallID, func() {
+		stopWaiting()
+		agent.Cancel(call.SessionID)
+	})
+	defer c.subAgents.Del(toolCallID)
+
+	progress := SubAgentProgress{
+		ToolCallID: toolCallID,
+		SessionID:  call.SessionID,
+		Status:     SubAgentRunning,
+	}
+	defer func() {
+		progress.Status = SubAgentDone
+		progress.Tool = ""
+		subAgentBroker.Publish(pubsub.UpdatedEvent, progress)
+	}()
+
+	if c.subAgentSlots != nil {
+		select {
+		case c.subAgentSlots <- struct{}{}:
+		default:
+			progress.Status = SubAgentQueued
+			subAgentBroker.Publish(pubsub.CreatedEvent, progress)
+			select {
+			case c.subAgentSlots <- struct{}{}:
+			case <-waitCtx.Done():
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				return nil, ErrSubAgentCancelled
+			}
+			progress.Status = SubAgentRunning
+		}
+		defer func() { <-c.subAgentSlots }()
+	}
+	if waitCtx.Err() != nil && ctx.Err() == nil {
+		return nil, ErrSubAgentCancelled
+	}
+	subAgentBroker.Publish(pubsub.UpdatedEvent, progress)
+
+	trackCtx, stopTracking := context.WithCancel(waitCtx)
+	tracked := make(chan struct{})
+	var budgetErr error
+	go func() {
+		defer close(tracked)
+		budgetErr = c.trackSubAgent(trackCtx, &progress, budget)
+		if budgetErr != nil {
+			agent.Cancel(call.SessionID)
+		}
+	}()
+
+	result, err := agent.Run(ctx, call)
+	// Make sure no progress is published after the run is done.
+	stopTracking()
+	<-tracked
+	if budgetErr != nil {
+		return nil, budgetErr
+	}
+	if err != nil && ctx.Err() == nil && isCancelledErr(err) {
+		return nil, ErrSubAgentCancelled
+	}
+	return result, err
+}
+
+// trackSubAgent updates p with the tool a sub-agent runs and the tokens it
+// used as its messages and session are updated, and publishes it, until ctx
+// is done or the sub-agent goes over budget, in which case it returns why.
+func (c *coordinator) trackSubAgent(ctx context.Context, p *SubAgentProgress, budget subAgentBudget) error {
+	messages := c.messages.Subscribe(ctx)
+	sessions := c.sessions.Subscribe(ctx)
+	// Each step of the sub-agent starts with a new assistant message.
+	steps := 0
+	for {
+		before := *p
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			msg := event.Payload
+			if msg.SessionID != p.SessionID {
+				continue
+			}
+			switch msg.Role {
+			case message.Assistant:
+				if event.Type == pubsub.CreatedEvent {
+					steps++
+				}
+				if calls := msg.ToolCalls(); len(calls) > 0 {
+					p.Tool = calls[len(calls)-1].Name
+				}
+			case message.Tool:
+				p.Tool = ""
+			}
+		case event, ok := <-sessions:
+			if !ok {
+				return nil
+			}
+			if event.Payload.ID != p.SessionID {
+				continue
+			}
+			p.Tokens = event.Payload.PromptTokens + event.Payload.CompletionTokens
+		}
+		if *p != before && ctx.Err() == nil {
+			subAgentBroker.Publish(pubsub.UpdatedEvent, *p)
+		}
+		if err := budget.exceeded(*p, steps); err != nil {
+			return err
+		}
+	}
+}
+
+// CancelSubAgent cancels the sub-agent started by the tool call with the
+// given ID, leaving the run of its parent going.
+func (c *coordinator) CancelSubAgent(toolCallID string) {
+	if cancel, ok := c.subAgents.Take(toolCallID); ok {
+		cancel()
+	}
+}
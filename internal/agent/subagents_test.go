@@ -0,0 +1,222 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingAgent is a SessionAgent whose runs block until they are released
+// or cancelled.
+type blockingAgent struct {
+	SessionAgent
+	release chan struct{}
+	cancels *csync.Map[string, context.CancelFunc]
+}
+
+func (a *blockingAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy.AgentResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	a.cancels.Set(call.SessionID, cancel)
+	select {
+	case <-a.release:
+		return &fantasy.AgentResult{}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (a *blockingAgent) Cancel(sessionID string) {
+	if cancel, ok := a.cancels.Take(sessionID); ok {
+		cancel()
+	}
+}
+
+func TestRunSubAgent(t *testing.T) {
+	t.Parallel()
+
+	env := testEnv(t)
+	c := &coordinator{
+		sessions:      env.sessions,
+		messages:      env.messages,
+		subAgentSlots: make(chan struct{}, 1),
+		subAgents:     csync.NewMap[string, context.CancelFunc](),
+	}
+	agent := &blockingAgent{
+		release: make(chan struct{}),
+		cancels: csync.NewMap[string, context.CancelFunc](),
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	events := SubscribeSubAgents(ctx)
+	waitFor := func(toolCallID string, status SubAgentStatus) {
+		t.Helper()
+		for {
+			select {
+			case event := <-events:
+				if event.Payload.ToolCallID == toolCallID && event.Payload.Status == status {
+					return
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("sub-agent %s never became %s", toolCallID, status)
+			}
+		}
+	}
+
+	type outcome struct {
+		result *fantasy.AgentResult
+		err    error
+	}
+	run := func(toolCallID string) <-chan outcome {
+		done := make(chan outcome, 1)
+		go func() {
+			result, err := c.runSubAgent(t.Context(), toolCallID, agent, SessionAgentCall{SessionID: "session-" + toolCallID}, subAgentBudget{})
+			done <- outcome{result, err}
+		}()
+		return done
+	}
+
+	first := run("call-1")
+	waitFor("call-1", SubAgentRunning)
+
+	// Only one sub-agent may run at once, so the second one waits.
+	second := run("call-2")
+	waitFor("call-2", SubAgentQueued)
+
+	// Cancelling the running sub-agent frees its slot for the queued one.
+	c.CancelSubAgent("call-1")
+	out := <-first
+	require.ErrorIs(t, out.err, ErrSubAgentCancelled)
+	waitFor("call-1", SubAgentDone)
+	waitFor("call-2", SubAgentRunning)
+
+	agent.release <- struct{}{}
+	out = <-second
+	require.NoError(t, out.err)
+	require.NotNil(t, out.result)
+	waitFor("call-2", SubAgentDone)
+
+	// A queued sub-agent can be cancelled before it starts.
+	c.subAgentSlots <- struct{}{}
+	third := run("call-3")
+	waitFor("call-3", SubAgentQueued)
+	c.CancelSubAgent("call-3")
+	out = <-third
+	require.ErrorIs(t, out.err, ErrSubAgentCancelled)
+	<-c.subAgentSlots
+}
+
+func TestSubAgentProgressEvents(t *testing.T) {
+	t.Parallel()
+
+	env := testEnv(t)
+	c := &coordinator{
+		sessions:  env.sessions,
+		messages:  env.messages,
+		subAgents: csync.NewMap[string, context.CancelFunc](),
+	}
+	sess, err := env.sessions.Create(t.Context(), "sub-agent")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	events := SubscribeSubAgents(ctx)
+
+	agent := &blockingAgent{
+		release: make(chan struct{}),
+		cancels: csync.NewMap[string, context.CancelFunc](),
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.runSubAgent(t.Context(), "call-tokens", agent, SessionAgentCall{SessionID: sess.ID}, subAgentBudget{})
+		done <- err
+	}()
+
+	var got SubAgentProgress
+	next := func() {
+		t.Helper()
+		for {
+			select {
+			case event := <-events:
+				if event.Payload.ToolCallID == "call-tokens" {
+					got = event.Payload
+					return
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("no sub-agent progress published")
+			}
+		}
+	}
+	next()
+	require.Equal(t, SubAgentRunning, got.Status)
+
+	// Give the tracker time to subscribe before the session changes.
+	require.Eventually(t, func() bool {
+		sess.PromptTokens, sess.CompletionTokens = 1200, 300
+		if _, err := env.sessions.Save(t.Context(), sess); err != nil {
+			return false
+		}
+		select {
+		case event := <-events:
+			got = event.Payload
+			return event.Type == pubsub.UpdatedEvent && got.ToolCallID == "call-tokens" && got.Tokens == 1500
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+
+	agent.release <- struct{}{}
+	require.NoError(t, <-done)
+	next()
+	require.Equal(t, SubAgentDone, got.Status)
+	require.Equal(t, int64(1500), got.Tokens)
+}
+
+func TestRunSubAgentOverBudget(t *testing.T) {
+	t.Parallel()
+
+	env := testEnv(t)
+	c := &coordinator{
+		sessions:  env.sessions,
+		messages:  env.messages,
+		subAgents: csync.NewMap[string, context.CancelFunc](),
+	}
+	sess, err := env.sessions.Create(t.Context(), "sub-agent")
+	require.NoError(t, err)
+
+	agent := &blockingAgent{
+		release: make(chan struct{}),
+		cancels: csync.NewMap[string, context.CancelFunc](),
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.runSubAgent(t.Context(), "call-steps", agent, SessionAgentCall{SessionID: sess.ID}, subAgentBudget{MaxSteps: 2})
+		done <- err
+	}()
+	require.Eventually(t, func() bool {
+		_, ok := agent.cancels.Get(sess.ID)
+		return ok
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Every step starts with an assistant message, and the run is stopped
+	// when it starts more steps than its budget allows.
+	var runErr error
+	require.Eventually(t, func() bool {
+		_, err := env.messages.Create(t.Context(), sess.ID, message.CreateMessageParams{Role: message.Assistant})
+		require.NoError(t, err)
+		select {
+		case runErr = <-done:
+			return true
+		case <-time.After(10 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+	require.ErrorIs(t, runErr, ErrSubAgentOverBudget)
+	require.ErrorContains(t, runErr, "more than 2 steps")
+}
@@ -0,0 +1,32 @@
+package agent
+
+import (
+	"context"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/telemetry"
+)
+
+// measuredTool records a span and the duration of every call of a tool.
+type measuredTool struct {
+	fantasy.AgentTool
+}
+
+// withTelemetry measures the tool calls when telemetry is exported.
+func withTelemetry(agentTools []fantasy.AgentTool) []fantasy.AgentTool {
+	if !telemetry.Enabled() {
+		return agentTools
+	}
+	wrapped := make([]fantasy.AgentTool, len(agentTools))
+	for i, tool := range agentTools {
+		wrapped[i] = &measuredTool{AgentTool: tool}
+	}
+	return wrapped
+}
+
+func (t *measuredTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	ctx, toolCall := telemetry.StartToolCall(ctx, t.Info().Name, call.ID)
+	resp, err := t.AgentTool.Run(ctx, call)
+	toolCall.End(resp.IsError, err)
+	return resp, err
+}
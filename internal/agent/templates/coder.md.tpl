@@ -329,7 +329,12 @@ Git status (snapshot at conversation start - may be outdated):
 {{.GitStatus}}
 {{end}}
 </env>
-
+{{with .Config.Project.Commands.All}}
+<project_commands>
+The project declares these commands. Run them with their project_<name> tool instead of working out your own:
+{{range .}}- {{.Name}}: `{{.Command}}`
+{{end}}</project_commands>
+{{end}}
 {{if gt (len .Config.LSP) 0}}
 <lsp>
 Diagnostics (lint/typecheck) included in tool output.
@@ -0,0 +1,170 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/httpext"
+)
+
+// maxRequestRetries is how many times a request the provider didn't start
+// responding to in time is sent again.
+const maxRequestRetries = 2
+
+// timeoutError is the error of a request or a response that took too long.
+// It's a net.Error, for a stalled stream to be handled like a dropped
+// connection.
+type timeoutError struct {
+	msg string
+}
+
+func (e *timeoutError) Error() string   { return e.msg }
+func (e *timeoutError) Timeout() bool   { return true }
+func (e *timeoutError) Temporary() bool { return true }
+
+var (
+	errRequestTimeout = &timeoutError{"the provider didn't start responding in time"}
+	errStreamStalled  = &timeoutError{"the provider stopped streaming the response"}
+)
+
+var _ net.Error = (*timeoutError)(nil)
+
+// newProviderTransport returns the transport of the connections to a
+// provider, with the keep-alive probes of its timeouts: TCP ones, and HTTP/2
+// pings for the connections the provider keeps open without sending
+// anything.
+func newProviderTransport(keepAlive time.Duration) *http.Transport {
+	t := httpext.NewTransport()
+	if keepAlive <= 0 {
+		return t
+	}
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: keepAlive}
+	t.DialContext = dialer.DialContext
+	t.HTTP2 = &http.HTTP2Config{SendPingTimeout: keepAlive, PingTimeout: keepAlive}
+	return t
+}
+
+// providerTransportKey tells the transports of the connections to the
+// providers apart: by keep-alive interval, and by the transport of httpext
+// they're cloned from, which changes with the proxy and the CA certificates.
+type providerTransportKey struct {
+	keepAlive time.Duration
+	network   http.RoundTripper
+}
+
+// dropProviderTransports closes and forgets the transports to the providers
+// cloned from another transport than network, once the network options
+// changed.
+func (c *coordinator) dropProviderTransports(network http.RoundTripper) {
+	for key, t := range c.providerTransports.Seq2() {
+		if key.network != network {
+			c.providerTransports.Del(key)
+			t.CloseIdleConnections()
+		}
+	}
+}
+
+// timeoutTransport sends the requests to a provider again when it doesn't
+// start responding to them in time, and fails the responses that stop
+// streaming, so that stalled connections don't hang the session.
+type timeoutTransport struct {
+	base       http.RoundTripper
+	request    time.Duration
+	streamIdle time.Duration
+}
+
+func newTimeoutTransport(base http.RoundTripper, timeouts *config.ProviderTimeouts) *timeoutTransport {
+	return &timeoutTransport{
+		base:       base,
+		request:    timeouts.RequestTimeout(),
+		streamIdle: timeouts.StreamIdleTimeout(),
+	}
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := t.roundTrip(req)
+		if !errors.Is(err, errRequestTimeout) || attempt == maxRequestRetries {
+			return resp, err
+		}
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return nil, err
+			}
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+		slog.Warn("Provider didn't respond in time, retrying", "host", req.URL.Host, "timeout", t.request)
+	}
+}
+
+func (t *timeoutTransport) roundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancelCause(req.Context())
+	var timer *time.Timer
+	if t.request > 0 {
+		timer = time.AfterFunc(t.request, func() { cancel(errRequestTimeout) })
+	}
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	timedOut := timer != nil && !timer.Stop()
+	if err == nil && timedOut {
+		// The response came as the request timed out, too late to be read.
+		resp.Body.Close()
+		err = errRequestTimeout
+	}
+	if err != nil {
+		if errors.Is(context.Cause(ctx), errRequestTimeout) {
+			err = errRequestTimeout
+		}
+		cancel(nil)
+		return nil, err
+	}
+	resp.Body = &idleBody{ReadCloser: resp.Body, ctx: ctx, cancel: cancel, idle: t.streamIdle}
+	return resp, nil
+}
+
+// idleBody fails the reads of a response body that wait for data longer
+// than idle. Only the reads count, not the time between them, when the
+// response isn't read because a tool runs.
+type idleBody struct {
+	io.ReadCloser
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	idle   time.Duration
+	timer  *time.Timer
+}
+
+func (b *idleBody) Read(p []byte) (int, error) {
+	if b.idle <= 0 {
+		return b.ReadCloser.Read(p)
+	}
+	if b.timer == nil {
+		b.timer = time.AfterFunc(b.idle, func() { b.cancel(errStreamStalled) })
+	} else {
+		b.timer.Reset(b.idle)
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.timer.Stop()
+	if err != nil && errors.Is(context.Cause(b.ctx), errStreamStalled) {
+		slog.Warn("Provider stopped streaming the response", "timeout", b.idle)
+		return n, errStreamStalled
+	}
+	return n, err
+}
+
+func (b *idleBody) Close() error {
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	defer b.cancel(nil)
+	return b.ReadCloser.Close()
+}
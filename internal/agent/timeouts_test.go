@@ -0,0 +1,209 @@
+package agent
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeoutTransportRetriesSlowRequests(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+	var bodies []string
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		bodies = append(bodies, string(body))
+		if attempts.Add(1) == 1 {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+	})
+	transport := &timeoutTransport{base: base, request: 50 * time.Millisecond}
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, "http://example.com", strings.NewReader(`{"model":"m"}`))
+	require.NoError(t, err)
+	resp, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "ok", string(body))
+	require.Equal(t, []string{`{"model":"m"}`, `{"model":"m"}`}, bodies)
+}
+
+func TestTimeoutTransportGivesUp(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		attempts.Add(1)
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})
+	transport := &timeoutTransport{base: base, request: 10 * time.Millisecond}
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://example.com", nil)
+	require.NoError(t, err)
+	_, err = transport.RoundTrip(req)
+	require.ErrorIs(t, err, errRequestTimeout)
+	require.True(t, isStreamInterrupted(err))
+	require.Equal(t, int32(maxRequestRetries+1), attempts.Load())
+}
+
+func TestTimeoutTransportKeepsCancellation(t *testing.T) {
+	t.Parallel()
+
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})
+	transport := &timeoutTransport{base: base, request: time.Minute}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com", nil)
+	require.NoError(t, err)
+	cancel()
+	_, err = transport.RoundTrip(req)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestTimeoutTransportStalledStream(t *testing.T) {
+	t.Parallel()
+
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		r, w := io.Pipe()
+		go func() {
+			_, _ = w.Write([]byte("data: first\n\n"))
+			// Then nothing, until the request is canceled.
+			<-req.Context().Done()
+			w.CloseWithError(req.Context().Err())
+		}()
+		return &http.Response{StatusCode: http.StatusOK, Body: r}, nil
+	})
+	transport := &timeoutTransport{base: base, streamIdle: 50 * time.Millisecond}
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, "http://example.com", strings.NewReader("{}"))
+	require.NoError(t, err)
+	resp, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	buf := make([]byte, 64)
+	n, err := resp.Body.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, "data: first\n\n", string(buf[:n]))
+
+	_, err = resp.Body.Read(buf)
+	require.ErrorIs(t, err, errStreamStalled)
+	require.True(t, isStreamInterrupted(err))
+}
+
+func TestTimeoutTransportOnlyCountsReads(t *testing.T) {
+	t.Parallel()
+
+	base := roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("data: first\n\ndata: second\n\n"))}, nil
+	})
+	transport := &timeoutTransport{base: base, streamIdle: 20 * time.Millisecond}
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://example.com", nil)
+	require.NoError(t, err)
+	resp, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	buf := make([]byte, len("data: first\n\n"))
+	_, err = io.ReadFull(resp.Body, buf)
+	require.NoError(t, err)
+	// As if a tool ran before the rest of the response was read.
+	time.Sleep(60 * time.Millisecond)
+	rest, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "data: second\n\n", string(rest))
+}
+
+func TestDropProviderTransports(t *testing.T) {
+	t.Parallel()
+
+	before, after := &http.Transport{}, &http.Transport{}
+	c := &coordinator{providerTransports: csync.NewMap[providerTransportKey, *http.Transport]()}
+	stale := providerTransportKey{keepAlive: time.Minute, network: before}
+	current := providerTransportKey{keepAlive: time.Minute, network: after}
+	c.providerTransports.Set(stale, newProviderTransport(time.Minute))
+	c.providerTransports.Set(current, newProviderTransport(time.Minute))
+
+	c.dropProviderTransports(after)
+	_, ok := c.providerTransports.Get(stale)
+	require.False(t, ok, "the transports of the former network options are dropped")
+	_, ok = c.providerTransports.Get(current)
+	require.True(t, ok)
+}
+
+// stallingModel is a language model whose first stream stalls before
+// anything comes, and whose next ones answer.
+type stallingModel struct {
+	fantasy.LanguageModel
+	prompts []fantasy.Prompt
+}
+
+func (m *stallingModel) Stream(_ context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	m.prompts = append(m.prompts, call.Prompt)
+	stalled := len(m.prompts) == 1
+	return func(yield func(fantasy.StreamPart) bool) {
+		if stalled {
+			yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeError, Error: errStreamStalled})
+			return
+		}
+		_ = yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeTextStart, ID: "text"}) &&
+			yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeTextDelta, ID: "text", Delta: "done"}) &&
+			yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeTextEnd, ID: "text"}) &&
+			yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeFinish, FinishReason: fantasy.FinishReasonStop})
+	}, nil
+}
+
+func (m *stallingModel) Provider() string { return "fake" }
+func (m *stallingModel) Model() string    { return "fake" }
+
+func TestRunRetriesStalledStream(t *testing.T) {
+	env := testEnv(t)
+	_, err := config.Init(env.workingDir, "", false)
+	require.NoError(t, err)
+
+	model := &stallingModel{}
+	agent := testSessionAgent(env, model, model, "")
+	sess, err := env.sessions.Create(t.Context(), "stalled")
+	require.NoError(t, err)
+	// Not the first message, for no title to be generated.
+	_, err = env.messages.Create(t.Context(), sess.ID, message.CreateMessageParams{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: "hello"}},
+	})
+	require.NoError(t, err)
+
+	_, err = agent.Run(t.Context(), SessionAgentCall{SessionID: sess.ID, Prompt: "do it", MaxOutputTokens: 100})
+	require.NoError(t, err)
+
+	require.Len(t, model.prompts, 2)
+	require.Equal(t, model.prompts[0], model.prompts[1], "the same request is sent again")
+
+	msgs, err := env.messages.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	var texts []string
+	for _, msg := range msgs {
+		texts = append(texts, string(msg.Role)+": "+msg.Content().Text)
+	}
+	require.Equal(t, []string{"user: hello", "user: do it", "assistant: done"}, texts, "nothing is added to the conversation")
+}
@@ -0,0 +1,213 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/lsp"
+)
+
+// maxToolCacheEntries bounds the cached results; the cache starts over
+// when it's full.
+const maxToolCacheEntries = 256
+
+// toolCache holds the results of read-only tool calls with a stamp of the
+// files they read, so that a repeated call over unchanged files doesn't run
+// again. The diagnostics of the LSP servers change without the files
+// changing, so the ones of the view tool aren't cached but listed anew.
+type toolCache struct {
+	workingDir string
+	lspClients *csync.Map[string, *lsp.Client]
+
+	mu      sync.Mutex
+	entries map[string]toolCacheEntry
+}
+
+type toolCacheEntry struct {
+	stamp string
+	resp  fantasy.ToolResponse
+	// diagnosed is the file the diagnostics are listed for after the cached
+	// content, if any.
+	diagnosed string
+}
+
+// cachedTool answers calls from the cache when the files they read haven't
+// changed since.
+type cachedTool struct {
+	fantasy.AgentTool
+	cache *toolCache
+}
+
+func newToolCache(workingDir string, lspClients *csync.Map[string, *lsp.Client]) *toolCache {
+	return &toolCache{
+		workingDir: workingDir,
+		lspClients: lspClients,
+		entries:    make(map[string]toolCacheEntry),
+	}
+}
+
+// withCaching caches the results of the read-only tools over the files of
+// the working directory: view, grep, glob and ls.
+func withCaching(cache *toolCache, agentTools []fantasy.AgentTool) []fantasy.AgentTool {
+	wrapped := make([]fantasy.AgentTool, len(agentTools))
+	for i, tool := range agentTools {
+		switch tool.Info().Name {
+		case tools.ViewToolName, tools.GrepToolName, tools.GlobToolName, tools.LSToolName:
+			wrapped[i] = &cachedTool{AgentTool: tool, cache: cache}
+		default:
+			wrapped[i] = tool
+		}
+	}
+	return wrapped
+}
+
+func (t *cachedTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	key, stamp, ok := t.cache.stamp(ctx, t.Info().Name, call.Input)
+	if !ok {
+		return t.AgentTool.Run(ctx, call)
+	}
+	if entry, ok := t.cache.get(key, stamp); ok {
+		resp := entry.resp
+		if entry.diagnosed != "" && t.cache.lspClients != nil {
+			resp.Content += tools.FileDiagnostics(entry.diagnosed, t.cache.lspClients)
+		}
+		return resp, nil
+	}
+	resp, err := t.AgentTool.Run(ctx, call)
+	if err == nil && !resp.IsError {
+		entry := toolCacheEntry{stamp: stamp, resp: resp}
+		if t.Info().Name == tools.ViewToolName {
+			entry = viewCacheEntry(entry)
+		}
+		t.cache.set(key, entry)
+	}
+	return resp, err
+}
+
+// viewCacheEntry keeps the content of the file of a view response in entry,
+// without the diagnostics that follow it.
+func viewCacheEntry(entry toolCacheEntry) toolCacheEntry {
+	content, _, ok := strings.Cut(entry.resp.Content, tools.ViewFileEnd)
+	if !ok {
+		return entry
+	}
+	var meta tools.ViewResponseMetadata
+	if err := json.Unmarshal([]byte(entry.resp.Metadata), &meta); err != nil || meta.FilePath == "" {
+		return entry
+	}
+	entry.resp.Content = content + tools.ViewFileEnd
+	entry.diagnosed = meta.FilePath
+	return entry
+}
+
+func (c *toolCache) get(key, stamp string) (toolCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || entry.stamp != stamp {
+		return toolCacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (c *toolCache) set(key string, entry toolCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxToolCacheEntries {
+		clear(c.entries)
+	}
+	c.entries[key] = entry
+}
+
+// stamp returns the cache key of a call and a stamp of the files it reads,
+// or false when the call isn't cached: when its input doesn't parse, it
+// reads outside the working directory or files git ignores, or the files
+// can't be stamped.
+func (c *toolCache) stamp(ctx context.Context, name, input string) (string, string, bool) {
+	var params map[string]any
+	if err := json.Unmarshal([]byte(input), &params); err != nil {
+		return "", "", false
+	}
+	// Marshaling sorts the keys, so that the same input always makes the
+	// same key.
+	canonical, err := json.Marshal(params)
+	if err != nil {
+		return "", "", false
+	}
+	key := name + " " + string(canonical)
+
+	if noIgnore, _ := params["no_ignore"].(bool); noIgnore {
+		return "", "", false
+	}
+	param := "path"
+	if name == tools.ViewToolName {
+		param = "file_path"
+	}
+	path, _ := params[param].(string)
+	path = c.resolve(path)
+	if !fsext.HasPrefix(path, c.workingDir) {
+		return "", "", false
+	}
+
+	if name == tools.ViewToolName {
+		stamp, ok := fileStamp(path)
+		return key, stamp, ok
+	}
+	stamp, ok := gitStamp(ctx, c.workingDir)
+	return key, stamp, ok
+}
+
+func (c *toolCache) resolve(path string) string {
+	if path == "" {
+		return c.workingDir
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(c.workingDir, path)
+	}
+	return filepath.Clean(path)
+}
+
+// fileStamp identifies the version of a file by its modification time and
+// size.
+func fileStamp(path string) (string, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("%d:%d", info.ModTime().UnixNano(), info.Size()), true
+}
+
+// gitStamp identifies the state of the files of the git repository at dir
+// by its HEAD and the modification times of the files its status lists. It
+// doesn't see changes to files git ignores.
+func gitStamp(ctx context.Context, dir string) (string, bool) {
+	head, err := exec.CommandContext(ctx, "git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", false
+	}
+	files, err := fsext.GitChangedFiles(ctx, dir)
+	if err != nil {
+		return "", false
+	}
+
+	h := sha256.New()
+	h.Write(bytes.TrimSpace(head))
+	for _, path := range files {
+		stamp, _ := fileStamp(path)
+		fmt.Fprintf(h, "\x00%s\x00%s", path, stamp)
+	}
+	return hex.EncodeToString(h.Sum(nil)), true
+}
@@ -0,0 +1,156 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/stretchr/testify/require"
+)
+
+// countingTool answers every call with how many calls it has run.
+type countingTool struct {
+	name  string
+	calls int
+}
+
+func (t *countingTool) Info() fantasy.ToolInfo { return fantasy.ToolInfo{Name: t.name} }
+
+func (t *countingTool) Run(context.Context, fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	t.calls++
+	return fantasy.NewTextResponse("result"), nil
+}
+
+func (t *countingTool) ProviderOptions() fantasy.ProviderOptions { return nil }
+
+func (t *countingTool) SetProviderOptions(fantasy.ProviderOptions) {}
+
+func TestToolCacheView(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0o644))
+
+	view := &countingTool{name: "view"}
+	bash := &countingTool{name: "bash"}
+	wrapped := withCaching(newToolCache(dir, nil), []fantasy.AgentTool{view, bash})
+	run := func(tool fantasy.AgentTool, input string) {
+		_, err := tool.Run(t.Context(), fantasy.ToolCall{Input: input})
+		require.NoError(t, err)
+	}
+
+	run(wrapped[0], `{"file_path": "main.go"}`)
+	run(wrapped[0], `{"file_path":"main.go"}`)
+	run(wrapped[0], `{"file_path":"`+path+`"}`)
+	require.Equal(t, 2, view.calls, "the absolute path is another call")
+
+	require.NoError(t, os.WriteFile(path, []byte("package main\n\nfunc main() {}\n"), 0o644))
+	run(wrapped[0], `{"file_path":"main.go"}`)
+	require.Equal(t, 3, view.calls, "the file changed")
+
+	run(wrapped[0], `{"file_path":"`+filepath.Join(filepath.Dir(dir), "other.go")+`"}`)
+	run(wrapped[0], `{"file_path":"`+filepath.Join(filepath.Dir(dir), "other.go")+`"}`)
+	require.Equal(t, 5, view.calls, "files outside the working directory aren't cached")
+
+	run(wrapped[1], `{"command":"ls"}`)
+	run(wrapped[1], `{"command":"ls"}`)
+	require.Equal(t, 2, bash.calls)
+}
+
+func TestToolCacheGit(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	git("init", "-q")
+	write("a.txt", "a")
+	git("add", ".")
+	git("commit", "-q", "-m", "first")
+
+	grep := &countingTool{name: "grep"}
+	wrapped := withCaching(newToolCache(dir, nil), []fantasy.AgentTool{grep})[0]
+	run := func(input string) {
+		_, err := wrapped.Run(t.Context(), fantasy.ToolCall{Input: input})
+		require.NoError(t, err)
+	}
+
+	run(`{"pattern":"a"}`)
+	run(`{"pattern":"a"}`)
+	require.Equal(t, 1, grep.calls)
+
+	write("b.txt", "b")
+	run(`{"pattern":"a"}`)
+	require.Equal(t, 2, grep.calls, "a file was added")
+
+	write("b.txt", "bb")
+	run(`{"pattern":"a"}`)
+	require.Equal(t, 3, grep.calls, "an untracked file changed")
+
+	git("add", ".")
+	git("commit", "-q", "-m", "second")
+	run(`{"pattern":"a"}`)
+	require.Equal(t, 4, grep.calls, "a commit was made")
+
+	run(`{"pattern":"a","no_ignore":true}`)
+	run(`{"pattern":"a","no_ignore":true}`)
+	require.Equal(t, 6, grep.calls, "ignored files aren't watched")
+}
+
+// staleView answers view calls with the file and diagnostics that differ on
+// every call.
+type staleView struct {
+	path  string
+	calls int
+}
+
+func (t *staleView) Info() fantasy.ToolInfo { return fantasy.ToolInfo{Name: tools.ViewToolName} }
+
+func (t *staleView) Run(context.Context, fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	t.calls++
+	return fantasy.WithResponseMetadata(
+		fantasy.NewTextResponse(fmt.Sprintf("<file>\n1|package main%s<file_diagnostics>\nerror %d\n</file_diagnostics>\n", tools.ViewFileEnd, t.calls)),
+		tools.ViewResponseMetadata{FilePath: t.path, Content: "package main"},
+	), nil
+}
+
+func (t *staleView) ProviderOptions() fantasy.ProviderOptions { return nil }
+
+func (t *staleView) SetProviderOptions(fantasy.ProviderOptions) {}
+
+func TestToolCacheViewDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0o644))
+
+	view := &staleView{path: path}
+	wrapped := withCaching(newToolCache(dir, csync.NewMap[string, *lsp.Client]()), []fantasy.AgentTool{view})[0]
+
+	resp, err := wrapped.Run(t.Context(), fantasy.ToolCall{Input: `{"file_path":"main.go"}`})
+	require.NoError(t, err)
+	require.Contains(t, resp.Content, "error 1")
+
+	resp, err = wrapped.Run(t.Context(), fantasy.ToolCall{Input: `{"file_path":"main.go"}`})
+	require.NoError(t, err)
+	require.Equal(t, 1, view.calls)
+	require.Equal(t, "<file>\n1|package main"+tools.ViewFileEnd, resp.Content, "the diagnostics aren't replayed from the cache")
+}
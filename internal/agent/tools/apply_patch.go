@@ -0,0 +1,277 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/editlock"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/trash"
+)
+
+type ApplyPatchParams struct {
+	Patch string `json:"patch" description:"The unified diff to apply; it can change, create and delete several files"`
+}
+
+// PatchedFile is a file changed by a patch.
+type PatchedFile struct {
+	FilePath   string `json:"file_path"`
+	OldContent string `json:"old_content,omitempty"`
+	NewContent string `json:"new_content,omitempty"`
+	Created    bool   `json:"created,omitempty"`
+	Deleted    bool   `json:"deleted,omitempty"`
+	Additions  int    `json:"additions"`
+	Removals   int    `json:"removals"`
+}
+
+type ApplyPatchPermissionsParams struct {
+	Files []PatchedFile `json:"files"`
+}
+
+type ApplyPatchResponseMetadata struct {
+	Files     []PatchedFile `json:"files"`
+	Additions int           `json:"additions"`
+	Removals  int           `json:"removals"`
+}
+
+const ApplyPatchToolName = "apply_patch"
+
+//go:embed apply_patch.md
+var applyPatchDescription []byte
+
+func NewApplyPatchTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, locks *editlock.Registry, bin *trash.Store, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		ApplyPatchToolName,
+		string(applyPatchDescription),
+		func(ctx context.Context, params ApplyPatchParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if strings.TrimSpace(params.Patch) == "" {
+				return fantasy.NewTextErrorResponse("patch is required"), nil
+			}
+			filePatches, err := diff.ParsePatch(params.Patch)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("invalid patch: %s", err)), nil
+			}
+
+			patched, crlf, problems := preparePatch(filePatches, workingDir)
+			if len(problems) > 0 {
+				return fantasy.NewTextErrorResponse("patch not applied, no files were changed:\n- " + strings.Join(problems, "\n- ")), nil
+			}
+
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for applying a patch")
+			}
+
+			var meta ApplyPatchResponseMetadata
+			paths := make([]string, len(patched))
+			for i := range patched {
+				paths[i] = patched[i].FilePath
+				_, patched[i].Additions, patched[i].Removals = diff.GenerateDiff(
+					patched[i].OldContent,
+					patched[i].NewContent,
+					strings.TrimPrefix(patched[i].FilePath, workingDir),
+				)
+				meta.Additions += patched[i].Additions
+				meta.Removals += patched[i].Removals
+			}
+			meta.Files = patched
+
+			p := requestEdit(ctx, permissions, locks,
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        fsext.PathOrPrefix(patched[0].FilePath, workingDir),
+					ToolCallID:  call.ID,
+					ToolName:    ApplyPatchToolName,
+					Action:      "write",
+					Description: fmt.Sprintf("Apply a patch to %d file(s)", len(patched)),
+					Params:      ApplyPatchPermissionsParams{Files: patched},
+				},
+				paths...,
+			)
+			if !p {
+				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+			}
+
+			for _, file := range patched {
+				if !file.Created {
+					keepReplaced(bin, sessionID, file.FilePath)
+				}
+			}
+			if err := writePatchedFiles(patched, crlf); err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("patch not applied, no files were changed: %s", err)), nil
+			}
+
+			var output strings.Builder
+			var changed []string
+			fmt.Fprintf(&output, "Patch applied to %d file(s):\n", len(patched))
+			for _, file := range patched {
+				recordPatchHistory(ctx, files, sessionID, file)
+				acquireEditLocks(ctx, locks, file.FilePath)
+				if file.Deleted {
+					fmt.Fprintf(&output, "- deleted %s\n", file.FilePath)
+					continue
+				}
+				recordFileWrite(file.FilePath)
+				recordFileRead(file.FilePath)
+				changed = append(changed, file.FilePath)
+				verb := "changed"
+				if file.Created {
+					verb = "created"
+				}
+				fmt.Fprintf(&output, "- %s %s (+%d -%d)\n", verb, file.FilePath, file.Additions, file.Removals)
+			}
+
+			text := fmt.Sprintf("<result>\n%s</result>\n", output.String())
+			return WithDiagnostics(ctx, lspClients, fantasy.WithResponseMetadata(fantasy.NewTextResponse(text), meta), changed...), nil
+		})
+}
+
+// preparePatch applies the file patches in memory, checking them against
+// the files on disk. It returns every problem found so that they can all be
+// fixed at once.
+func preparePatch(filePatches []diff.FilePatch, workingDir string) (patched []PatchedFile, crlf map[string]bool, problems []string) {
+	crlf = make(map[string]bool)
+	seen := make(map[string]bool)
+	for _, filePatch := range filePatches {
+		filePath := filepathext.SmartJoin(workingDir, filePatch.Path())
+		if seen[filePath] {
+			problems = append(problems, fmt.Sprintf("%s: changed more than once; merge its hunks into one file diff", filePath))
+			continue
+		}
+		seen[filePath] = true
+
+		if filePatch.OldPath != "" && filePatch.NewPath != "" && filePatch.OldPath != filePatch.NewPath {
+			problems = append(problems, fmt.Sprintf("%s: renames aren't supported; delete the old file and create the new one", filePath))
+			continue
+		}
+
+		file := PatchedFile{
+			FilePath: filePath,
+			Created:  filePatch.OldPath == "",
+			Deleted:  filePatch.NewPath == "",
+		}
+		fileInfo, err := os.Stat(filePath)
+		switch {
+		case file.Created && err == nil:
+			problems = append(problems, fmt.Sprintf("%s: already exists", filePath))
+			continue
+		case file.Created:
+		case err != nil:
+			problems = append(problems, fmt.Sprintf("%s: %s", filePath, describeStatError(err)))
+			continue
+		case fileInfo.IsDir():
+			problems = append(problems, fmt.Sprintf("%s: is a directory", filePath))
+			continue
+		case getLastReadTime(filePath).IsZero():
+			problems = append(problems, fmt.Sprintf("%s: you must read the file before patching it. Use the View tool first", filePath))
+			continue
+		case fileInfo.ModTime().After(getLastReadTime(filePath)):
+			problems = append(problems, fmt.Sprintf("%s: modified since it was last read (mod time: %s, last read: %s)",
+				filePath, fileInfo.ModTime().Format(time.RFC3339), getLastReadTime(filePath).Format(time.RFC3339)))
+			continue
+		default:
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %s", filePath, err))
+				continue
+			}
+			file.OldContent, crlf[filePath] = fsext.ToUnixLineEndings(string(content))
+		}
+
+		file.NewContent, err = filePatch.Apply(file.OldContent)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		if file.Deleted && file.NewContent != "" {
+			problems = append(problems, fmt.Sprintf("%s: the patch deletes the file but doesn't remove all of its lines", filePath))
+			continue
+		}
+		patched = append(patched, file)
+	}
+	return patched, crlf, problems
+}
+
+func describeStatError(err error) string {
+	if errors.Is(err, os.ErrNotExist) {
+		return "file not found"
+	}
+	return err.Error()
+}
+
+// writePatchedFiles writes all the patched files, or none: when a write
+// fails, the files written before it are restored.
+func writePatchedFiles(patched []PatchedFile, crlf map[string]bool) error {
+	for i, file := range patched {
+		if err := writePatchedFile(file, crlf[file.FilePath]); err != nil {
+			for _, written := range patched[:i] {
+				if rollbackErr := rollbackPatchedFile(written, crlf[written.FilePath]); rollbackErr != nil {
+					slog.Error("Failed to restore file after a failed patch", "file", written.FilePath, "error", rollbackErr)
+				}
+			}
+			return fmt.Errorf("failed to write %s: %w", file.FilePath, err)
+		}
+	}
+	return nil
+}
+
+func writePatchedFile(file PatchedFile, crlf bool) error {
+	if file.Deleted {
+		return os.Remove(file.FilePath)
+	}
+	if file.Created {
+		if err := os.MkdirAll(filepath.Dir(file.FilePath), 0o755); err != nil {
+			return err
+		}
+	}
+	content := file.NewContent
+	if crlf {
+		content, _ = fsext.ToWindowsLineEndings(content)
+	}
+	return os.WriteFile(file.FilePath, []byte(content), 0o644)
+}
+
+func rollbackPatchedFile(file PatchedFile, crlf bool) error {
+	if file.Created {
+		return os.Remove(file.FilePath)
+	}
+	content := file.OldContent
+	if crlf {
+		content, _ = fsext.ToWindowsLineEndings(content)
+	}
+	return os.WriteFile(file.FilePath, []byte(content), 0o644)
+}
+
+// recordPatchHistory stores the versions of a patched file, like the edit
+// tool does.
+func recordPatchHistory(ctx context.Context, files history.Service, sessionID string, file PatchedFile) {
+	existing, err := files.GetByPathAndSession(ctx, file.FilePath, sessionID)
+	if err != nil {
+		if _, err := files.Create(ctx, sessionID, file.FilePath, file.OldContent); err != nil {
+			slog.Error("Error creating file history", "error", err)
+			return
+		}
+	} else if existing.Content != file.OldContent {
+		// The user changed the file; store an intermediate version.
+		if _, err := files.CreateVersion(ctx, sessionID, file.FilePath, file.OldContent); err != nil {
+			slog.Error("Error creating file history version", "error", err)
+		}
+	}
+	if _, err := files.CreateVersion(ctx, sessionID, file.FilePath, file.NewContent); err != nil {
+		slog.Error("Error creating file history version", "error", err)
+	}
+}
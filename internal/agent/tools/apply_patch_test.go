@@ -0,0 +1,121 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyPatch(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		recordFileRead(path)
+	}
+	read := func(name string) string {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		return string(content)
+	}
+	write("a.txt", "one\ntwo\nthree\n")
+	write("b.txt", "alpha\nbeta\n")
+	write("c.txt", "gone\n")
+
+	tool := NewApplyPatchTool(
+		csync.NewMap[string, *lsp.Client](),
+		&mockPermissionService{Broker: pubsub.NewBroker[permission.PermissionRequest]()},
+		&mockHistoryService{Broker: pubsub.NewBroker[history.File]()},
+		nil,
+		nil,
+		dir,
+	)
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "session")
+	run := func(patch string) fantasy.ToolResponse {
+		input, err := json.Marshal(ApplyPatchParams{Patch: patch})
+		require.NoError(t, err)
+		resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "call", Name: ApplyPatchToolName, Input: string(input)})
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := run(`--- a/a.txt
++++ b/a.txt
+@@ -1,3 +1,3 @@
+ one
+-two
++TWO
+ three
+--- a/b.txt
++++ b/b.txt
+@@ -1,2 +1,2 @@
+ alpha
+-gamma
++GAMMA
+`)
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "no files were changed")
+	require.Contains(t, resp.Content, "hunk 1 (@@ -1,2 +1,2 @@) doesn't match the content of b.txt")
+	require.Equal(t, "one\ntwo\nthree\n", read("a.txt"), "nothing is written when a hunk doesn't apply")
+
+	resp = run(`--- a/a.txt
++++ b/a.txt
+@@ -1,3 +1,3 @@
+ one
+-two
++TWO
+ three
+--- /dev/null
++++ b/sub/new.txt
+@@ -0,0 +1 @@
++new
+--- a/c.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-gone
+`)
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "Patch applied to 3 file(s)")
+	require.Equal(t, "one\nTWO\nthree\n", read("a.txt"))
+	require.Equal(t, "new\n", read("sub/new.txt"))
+	require.NoFileExists(t, filepath.Join(dir, "c.txt"))
+
+	var meta ApplyPatchResponseMetadata
+	require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+	require.Len(t, meta.Files, 3)
+	require.Equal(t, 2, meta.Additions)
+	require.Equal(t, 2, meta.Removals)
+}
+
+func TestWritePatchedFilesRollback(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing.txt")
+	require.NoError(t, os.WriteFile(existing, []byte("old\n"), 0o644))
+
+	err := writePatchedFiles([]PatchedFile{
+		{FilePath: existing, OldContent: "old\n", NewContent: "new\n"},
+		{FilePath: filepath.Join(dir, "created.txt"), NewContent: "created\n", Created: true},
+		// Fails: the parent is a file.
+		{FilePath: filepath.Join(existing, "child.txt"), OldContent: "x\n", NewContent: "y\n"},
+	}, nil)
+	require.Error(t, err)
+
+	content, err := os.ReadFile(existing)
+	require.NoError(t, err)
+	require.Equal(t, "old\n", string(content))
+	require.NoFileExists(t, filepath.Join(dir, "created.txt"))
+}
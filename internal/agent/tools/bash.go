@@ -31,6 +31,14 @@ type BashPermissionsParams struct {
 	Command         string `json:"command"`
 	WorkingDir      string `json:"working_dir"`
 	RunInBackground bool   `json:"run_in_background"`
+
+	// Risk is set for the commands that change things, with the part of
+	// the command that does and the state of the working tree it would
+	// change.
+	Risk        string `json:"risk,omitempty"`
+	RiskCommand string `json:"risk_command,omitempty"`
+	GitStatus   string `json:"git_status,omitempty"`
+	GitDiffStat string `json:"git_diff_stat,omitempty"`
 }
 
 type BashResponseMetadata struct {
@@ -64,6 +72,7 @@ type bashDescriptionData struct {
 	MaxOutputLength int
 	Attribution     config.Attribution
 	ModelName       string
+	Shell           string
 }
 
 var bannedCommands = []string{
@@ -139,7 +148,7 @@ var bannedCommands = []string{
 	"ufw",
 }
 
-func bashDescription(attribution *config.Attribution, modelName string) string {
+func bashDescription(attribution *config.Attribution, modelName string, shellType shell.ShellType) string {
 	bannedCommandsStr := strings.Join(bannedCommands, ", ")
 	var out bytes.Buffer
 	if err := bashDescriptionTpl.Execute(&out, bashDescriptionData{
@@ -147,6 +156,7 @@ func bashDescription(attribution *config.Attribution, modelName string) string {
 		MaxOutputLength: MaxOutputLength,
 		Attribution:     *attribution,
 		ModelName:       modelName,
+		Shell:           shellType.String(),
 	}); err != nil {
 		// this should never happen.
 		panic("failed to execute bash description template: " + err.Error())
@@ -186,10 +196,10 @@ func blockFuncs() []shell.BlockFunc {
 	}
 }
 
-func NewBashTool(permissions permission.Service, workingDir string, attribution *config.Attribution, modelName string) fantasy.AgentTool {
+func NewBashTool(permissions permission.Service, workingDir string, attribution *config.Attribution, modelName string, shellType shell.ShellType) fantasy.AgentTool {
 	return fantasy.NewAgentTool(
 		BashToolName,
-		string(bashDescription(attribution, modelName)),
+		string(bashDescription(attribution, modelName, shellType)),
 		func(ctx context.Context, params BashParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
 			if params.Command == "" {
 				return fantasy.NewTextErrorResponse("missing command"), nil
@@ -214,21 +224,31 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 			if sessionID == "" {
 				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for executing shell command")
 			}
+			permissionParams := BashPermissionsParams{
+				Description:     params.Description,
+				Command:         params.Command,
+				WorkingDir:      params.WorkingDir,
+				RunInBackground: params.RunInBackground,
+			}
+			var confirmation string
 			if !isSafeReadOnly {
-				p := permissions.Request(
-					permission.CreatePermissionRequest{
-						SessionID:   sessionID,
-						Path:        execWorkingDir,
-						ToolCallID:  call.ID,
-						ToolName:    BashToolName,
-						Action:      "execute",
-						Description: fmt.Sprintf("Execute command: %s", params.Command),
-						Params:      BashPermissionsParams(params),
-					},
-				)
-				if !p {
-					return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
-				}
+				confirmation = addRisk(ctx, &permissionParams, execWorkingDir)
+			}
+			p := permissions.Request(
+				permission.CreatePermissionRequest{
+					SessionID:    sessionID,
+					Path:         execWorkingDir,
+					ToolCallID:   call.ID,
+					ToolName:     BashToolName,
+					Action:       "execute",
+					Description:  fmt.Sprintf("Execute command: %s", params.Command),
+					Params:       permissionParams,
+					ReadOnly:     isSafeReadOnly,
+					Confirmation: confirmation,
+				},
+			)
+			if !p {
+				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
 			}
 
 			// If explicitly requested as background, start immediately with detached context
@@ -237,7 +257,7 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 				bgManager := shell.GetBackgroundShellManager()
 				bgManager.Cleanup()
 				// Use background context so it continues after tool returns
-				bgShell, err := bgManager.Start(context.Background(), execWorkingDir, blockFuncs(), params.Command, params.Description)
+				bgShell, err := bgManager.Start(context.Background(), execWorkingDir, shellType, blockFuncs(), params.Command, params.Description)
 				if err != nil {
 					return fantasy.ToolResponse{}, fmt.Errorf("error starting background shell: %w", err)
 				}
@@ -256,7 +276,7 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 						return fantasy.ToolResponse{}, fmt.Errorf("[Job %s] error executing command: %w", bgShell.ID, execErr)
 					}
 
-					stdout = formatOutput(stdout, stderr, execErr)
+					stdout = FormatOutput(stdout, stderr, execErr)
 
 					metadata := BashResponseMetadata{
 						StartTime:        startTime.UnixMilli(),
@@ -292,7 +312,7 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 			// Start with detached context so it can survive if moved to background
 			bgManager := shell.GetBackgroundShellManager()
 			bgManager.Cleanup()
-			bgShell, err := bgManager.Start(context.Background(), execWorkingDir, blockFuncs(), params.Command, params.Description)
+			bgShell, err := bgManager.Start(context.Background(), execWorkingDir, shellType, blockFuncs(), params.Command, params.Description)
 			if err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("error starting shell: %w", err)
 			}
@@ -337,7 +357,7 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 					return fantasy.ToolResponse{}, fmt.Errorf("[Job %s] error executing command: %w", bgShell.ID, execErr)
 				}
 
-				stdout = formatOutput(stdout, stderr, execErr)
+				stdout = FormatOutput(stdout, stderr, execErr)
 
 				metadata := BashResponseMetadata{
 					StartTime:        startTime.UnixMilli(),
@@ -368,8 +388,9 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 		})
 }
 
-// formatOutput formats the output of a completed command with error handling
-func formatOutput(stdout, stderr string, execErr error) string {
+// FormatOutput formats the output of a completed command with error handling,
+// truncating it as the bash tool does.
+func FormatOutput(stdout, stderr string, execErr error) string {
 	interrupted := shell.IsInterrupt(execErr)
 	exitCode := shell.ExitCode(execErr)
 
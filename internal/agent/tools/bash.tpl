@@ -1,10 +1,36 @@
 Executes bash commands with automatic background conversion for long-running tasks.
 
+{{ if eq .Shell "powershell" -}}
+<cross_platform>
+Commands run in PowerShell, not bash: use PowerShell syntax and cmdlets (Get-ChildItem, Select-String, $env:NAME).
+Quote paths with spaces: Set-Location 'C:\Program Files'.
+The working directory and environment variables set by a command are kept until it ends.
+</cross_platform>
+{{- else if eq .Shell "cmd" -}}
+<cross_platform>
+Commands run in Windows cmd.exe, not bash: use cmd syntax and commands (dir, type, set NAME=value, %NAME%).
+Quote paths with spaces: cd /d "C:\Program Files".
+The working directory and environment variables set by a command are kept until it ends.
+</cross_platform>
+{{- else if eq .Shell "container" -}}
+<cross_platform>
+Commands run with sh inside the project's devcontainer: use POSIX shell syntax and the toolchain installed in the container.
+The files are the same ones the other tools read and edit.
+Environment variables and directory changes are not kept between commands.
+</cross_platform>
+{{- else if eq .Shell "ssh" -}}
+<cross_platform>
+Commands run over SSH with sh on the remote host the project lives on: use POSIX shell syntax and the tools installed there.
+The files are the same ones the other tools read and edit.
+Environment variables and directory changes are not kept between commands.
+</cross_platform>
+{{- else -}}
 <cross_platform>
 Uses mvdan/sh interpreter (Bash-compatible on all platforms including Windows).
 Use forward slashes for paths: "ls C:/foo/bar" not "ls C:\foo\bar".
 Common shell builtins and core utils available on Windows.
 </cross_platform>
+{{- end }}
 
 <execution_steps>
 1. Directory Verification: If creating directories/files, use LS tool to verify parent exists
@@ -3,15 +3,19 @@ package tools
 import (
 	"context"
 	_ "embed"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"charm.land/fantasy"
 	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/filepathext"
 	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/lsp/util"
 	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
 )
 
@@ -21,6 +25,17 @@ type DiagnosticsParams struct {
 
 const DiagnosticsToolName = "lsp_diagnostics"
 
+// diagnosticsQuietPeriod is how long the diagnostics of a server have to
+// stay the same after a change for them to be taken as final.
+const diagnosticsQuietPeriod = 300 * time.Millisecond
+
+// DiagnosticsSummary counts the diagnostics of the files a tool changed. It
+// is in the metadata of the tool's result as "diagnostics".
+type DiagnosticsSummary struct {
+	Errors   int `json:"errors"`
+	Warnings int `json:"warnings"`
+}
+
 //go:embed diagnostics.md
 var diagnosticsDescription []byte
 
@@ -32,8 +47,9 @@ func NewDiagnosticsTool(lspClients *csync.Map[string, *lsp.Client]) fantasy.Agen
 			if lspClients.Len() == 0 {
 				return fantasy.NewTextErrorResponse("no LSP clients available"), nil
 			}
-			notifyLSPs(ctx, lspClients, params.FilePath)
-			output := getDiagnostics(params.FilePath, lspClients)
+			filePath := filepathext.Normalize(params.FilePath)
+			notifyLSPs(ctx, lspClients, filePath)
+			output := getDiagnostics(filePath, lspClients)
 			return fantasy.NewTextResponse(output), nil
 		})
 }
@@ -52,13 +68,87 @@ func notifyLSPs(ctx context.Context, lsps *csync.Map[string, *lsp.Client], filep
 	}
 }
 
+// FileDiagnostics returns the diagnostics of the LSP servers for the file
+// at filePath and for the rest of the project, as the view tool lists them.
+func FileDiagnostics(filePath string, lsps *csync.Map[string, *lsp.Client]) string {
+	return getDiagnostics(filePath, lsps)
+}
+
+// WithDiagnostics notifies the LSP servers with automatic diagnostics of
+// the changed files at paths, waits for their diagnostics to settle and
+// adds them to resp: all of them to the content, and a count of those of
+// the changed files to the metadata. resp is left as is when no server
+// handles the files.
+func WithDiagnostics(ctx context.Context, lsps *csync.Map[string, *lsp.Client], resp fantasy.ToolResponse, paths ...string) fantasy.ToolResponse {
+	clients := csync.NewMap[string, *lsp.Client]()
+	var wg sync.WaitGroup
+	for name, client := range lsps.Seq2() {
+		if !client.AutoDiagnostics() {
+			continue
+		}
+		notified := false
+		for _, path := range paths {
+			if !client.HandlesFile(path) {
+				continue
+			}
+			if err := client.OpenFileOnDemand(ctx, path); err != nil {
+				continue
+			}
+			_ = client.NotifyChange(ctx, path)
+			notified = true
+		}
+		if notified {
+			clients.Set(name, client)
+			wg.Go(func() { client.WaitForDiagnosticsToSettle(ctx, diagnosticsQuietPeriod, 5*time.Second) })
+		}
+	}
+	wg.Wait()
+	if clients.Len() == 0 {
+		return resp
+	}
+
+	currentFile := ""
+	if len(paths) == 1 {
+		currentFile = paths[0]
+	}
+	resp.Content += getDiagnostics(currentFile, clients)
+
+	var summary DiagnosticsSummary
+	for client := range clients.Seq() {
+		for _, path := range paths {
+			for _, diag := range client.GetFileDiagnostics(util.URIFromPath(path)) {
+				switch diag.Severity {
+				case protocol.SeverityError:
+					summary.Errors++
+				case protocol.SeverityWarning:
+					summary.Warnings++
+				}
+			}
+		}
+	}
+	metadata := map[string]json.RawMessage{}
+	if resp.Metadata != "" {
+		if err := json.Unmarshal([]byte(resp.Metadata), &metadata); err != nil {
+			// Leave metadata that isn't an object as is.
+			return resp
+		}
+	}
+	if data, err := json.Marshal(summary); err == nil {
+		metadata["diagnostics"] = data
+	}
+	if data, err := json.Marshal(metadata); err == nil {
+		resp.Metadata = string(data)
+	}
+	return resp
+}
+
 func getDiagnostics(filePath string, lsps *csync.Map[string, *lsp.Client]) string {
 	fileDiagnostics := []string{}
 	projectDiagnostics := []string{}
 
 	for lspName, client := range lsps.Seq2() {
 		for location, diags := range client.GetDiagnostics() {
-			path, err := location.Path()
+			path, err := util.PathFromURI(location)
 			if err != nil {
 				slog.Error("Failed to convert diagnostic location URI to path", "uri", location, "error", err)
 				continue
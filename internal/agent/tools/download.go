@@ -3,48 +3,74 @@ package tools
 import (
 	"cmp"
 	"context"
+	"crypto/sha256"
 	_ "embed"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/httpext"
 	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
 )
 
 type DownloadParams struct {
 	URL      string `json:"url" description:"The URL to download from"`
 	FilePath string `json:"file_path" description:"The local file path where the downloaded content should be saved"`
 	Timeout  int    `json:"timeout,omitempty" description:"Optional timeout in seconds (max 600)"`
+	SHA256   string `json:"sha256,omitempty" description:"Optional hex SHA256 checksum the downloaded file must match; the file is deleted if it doesn't"`
 }
 
 type DownloadPermissionsParams struct {
 	URL      string `json:"url"`
 	FilePath string `json:"file_path"`
 	Timeout  int    `json:"timeout,omitempty"`
+	SHA256   string `json:"sha256,omitempty"`
 }
 
-const DownloadToolName = "download"
+// DownloadProgress is published while the download tool receives a file.
+type DownloadProgress struct {
+	ToolCallID string
+	SessionID  string
+	// Downloaded is the number of bytes received so far, including those of
+	// the interrupted download that was resumed.
+	Downloaded int64
+	// Total is the size of the file, or 0 when the server didn't tell.
+	Total int64
+}
+
+const (
+	DownloadToolName = "download"
+
+	// downloadProgressInterval is how often progress is published at most.
+	downloadProgressInterval = 100 * time.Millisecond
+)
 
 //go:embed download.md
 var downloadDescription []byte
 
-func NewDownloadTool(permissions permission.Service, workingDir string, client *http.Client) fantasy.AgentTool {
+var downloadBroker = pubsub.NewBroker[DownloadProgress]()
+
+// SubscribeDownloads returns a channel for download progress events.
+func SubscribeDownloads(ctx context.Context) <-chan pubsub.Event[DownloadProgress] {
+	return downloadBroker.Subscribe(ctx)
+}
+
+func NewDownloadTool(permissions permission.Service, workingDir string, client *http.Client, opts config.ToolDownload) fantasy.AgentTool {
 	if client == nil {
-		client = &http.Client{
-			Timeout: 5 * time.Minute, // Default 5 minute timeout for downloads
-			Transport: &http.Transport{
-				MaxIdleConns:        100,
-				MaxIdleConnsPerHost: 10,
-				IdleConnTimeout:     90 * time.Second,
-			},
-		}
+		client = httpext.NewPooledClient(5 * time.Minute) // Default 5 minute timeout for downloads
 	}
+	maxSize := opts.MaxSizeBytes()
 	return fantasy.NewAgentTool(
 		DownloadToolName,
 		string(downloadDescription),
@@ -61,6 +87,13 @@ func NewDownloadTool(permissions permission.Service, workingDir string, client *
 				return fantasy.NewTextErrorResponse("URL must start with http:// or https://"), nil
 			}
 
+			params.SHA256 = strings.ToLower(strings.TrimSpace(params.SHA256))
+			if params.SHA256 != "" {
+				if _, err := hex.DecodeString(params.SHA256); err != nil || len(params.SHA256) != sha256.Size*2 {
+					return fantasy.NewTextErrorResponse("sha256 must be the 64 hex digits of a SHA256 checksum"), nil
+				}
+			}
+
 			filePath := filepathext.SmartJoin(workingDir, params.FilePath)
 			relPath, _ := filepath.Rel(workingDir, filePath)
 			relPath = filepath.ToSlash(cmp.Or(relPath, filePath))
@@ -97,61 +130,212 @@ func NewDownloadTool(permissions permission.Service, workingDir string, client *
 				defer cancel()
 			}
 
-			req, err := http.NewRequestWithContext(requestCtx, "GET", params.URL, nil)
-			if err != nil {
-				return fantasy.ToolResponse{}, fmt.Errorf("failed to create request: %w", err)
-			}
-
-			req.Header.Set("User-Agent", "crush/1.0")
-
-			resp, err := client.Do(req)
-			if err != nil {
-				return fantasy.ToolResponse{}, fmt.Errorf("failed to download from URL: %w", err)
-			}
-			defer resp.Body.Close()
-
-			if resp.StatusCode != http.StatusOK {
-				return fantasy.NewTextErrorResponse(fmt.Sprintf("Request failed with status code: %d", resp.StatusCode)), nil
-			}
-
-			// Check content length if available
-			maxSize := int64(100 * 1024 * 1024) // 100MB
-			if resp.ContentLength > maxSize {
-				return fantasy.NewTextErrorResponse(fmt.Sprintf("File too large: %d bytes (max %d bytes)", resp.ContentLength, maxSize)), nil
-			}
-
 			// Create parent directories if they don't exist
 			if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("failed to create parent directories: %w", err)
 			}
 
-			// Create the output file
-			outFile, err := os.Create(filePath)
-			if err != nil {
-				return fantasy.ToolResponse{}, fmt.Errorf("failed to create output file: %w", err)
+			d := &download{
+				client:  client,
+				url:     params.URL,
+				path:    partialDownloadPath(filePath, params.URL),
+				maxSize: maxSize,
+				progress: DownloadProgress{
+					ToolCallID: call.ID,
+					SessionID:  sessionID,
+				},
 			}
-			defer outFile.Close()
-
-			// Copy data with size limit
-			limitedReader := io.LimitReader(resp.Body, maxSize)
-			bytesWritten, err := io.Copy(outFile, limitedReader)
+			contentType, err := d.run(requestCtx)
 			if err != nil {
-				return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
+				var failure downloadError
+				if errors.As(err, &failure) {
+					return fantasy.NewTextErrorResponse(failure.Error()), nil
+				}
+				return fantasy.ToolResponse{}, err
 			}
 
-			// Check if we hit the size limit
-			if bytesWritten == maxSize {
-				// Clean up the file since it might be incomplete
-				os.Remove(filePath)
-				return fantasy.NewTextErrorResponse(fmt.Sprintf("File too large: exceeded %d bytes limit", maxSize)), nil
+			if params.SHA256 != "" {
+				sum, err := fileSHA256(d.path)
+				if err != nil {
+					return fantasy.ToolResponse{}, fmt.Errorf("failed to hash the downloaded file: %w", err)
+				}
+				if sum != params.SHA256 {
+					// Resuming wouldn't help: start over on the next call.
+					os.Remove(d.path)
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("Checksum mismatch: expected SHA256 %s, got %s. The downloaded file was deleted.", params.SHA256, sum)), nil
+				}
+			}
+			if err := os.Rename(d.path, filePath); err != nil {
+				return fantasy.ToolResponse{}, fmt.Errorf("failed to move the downloaded file into place: %w", err)
 			}
 
-			contentType := resp.Header.Get("Content-Type")
-			responseMsg := fmt.Sprintf("Successfully downloaded %d bytes to %s", bytesWritten, relPath)
+			responseMsg := fmt.Sprintf("Successfully downloaded %d bytes to %s", d.progress.Downloaded, relPath)
+			if d.resumedAt > 0 {
+				responseMsg += fmt.Sprintf(", resuming after %d bytes", d.resumedAt)
+			}
 			if contentType != "" {
 				responseMsg += fmt.Sprintf(" (Content-Type: %s)", contentType)
 			}
+			if params.SHA256 != "" {
+				responseMsg += "\nSHA256 verified: " + params.SHA256
+			}
 
 			return fantasy.NewTextResponse(responseMsg), nil
 		})
 }
+
+// downloadError is a failure to report to the model, which it may fix by
+// calling the tool again, rather than an error of the tool.
+type downloadError string
+
+func (e downloadError) Error() string {
+	return string(e)
+}
+
+// download receives a file into path, resuming from what's already there.
+type download struct {
+	client  *http.Client
+	url     string
+	path    string
+	maxSize int64
+
+	resumedAt   int64
+	progress    DownloadProgress
+	publishedAt time.Time
+}
+
+// run downloads the file, appending to what an interrupted download left at
+// d.path when the server supports ranges, and returns its content type.
+// What was received is kept when the download is interrupted, for the next
+// call to resume it.
+func (d *download) run(ctx context.Context) (string, error) {
+	var offset int64
+	if info, err := os.Stat(d.path); err == nil {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", d.url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "crush/1.0")
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		if offset > 0 {
+			return "", downloadError(fmt.Sprintf("Failed to resume the download after %d bytes: %v. Call the tool again to retry.", offset, err))
+		}
+		return "", fmt.Errorf("failed to download from URL: %w", err)
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0 && contentRangeStart(resp) == offset:
+		flags = os.O_WRONLY | os.O_APPEND
+		d.resumedAt = offset
+		d.progress.Downloaded = offset
+		if resp.ContentLength >= 0 {
+			d.progress.Total = offset + resp.ContentLength
+		}
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		os.Remove(d.path)
+		return "", downloadError("The server sent another range than the one asked for to resume the download. The partial file was deleted, call the tool again to download the file from the start.")
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The interrupted download already had the whole file.
+		d.resumedAt = offset
+		d.progress.Downloaded = offset
+		d.progress.Total = offset
+		return "", nil
+	case resp.StatusCode == http.StatusOK:
+		// The server ignored the range, or there was none: start over.
+		d.progress.Total = max(resp.ContentLength, 0)
+	default:
+		return "", downloadError(fmt.Sprintf("Request failed with status code: %d", resp.StatusCode))
+	}
+
+	if d.maxSize > 0 && d.progress.Total > d.maxSize {
+		os.Remove(d.path)
+		return "", downloadError(fmt.Sprintf("File too large: %d bytes (max %d bytes)", d.progress.Total, d.maxSize))
+	}
+
+	outFile, err := os.OpenFile(d.path, flags, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer outFile.Close()
+
+	body := io.Reader(resp.Body)
+	if d.maxSize > 0 {
+		// One more byte than allowed tells a file that is too large.
+		body = io.LimitReader(resp.Body, d.maxSize-d.progress.Downloaded+1)
+	}
+	_, err = io.Copy(outFile, io.TeeReader(body, d))
+	d.publish(true)
+	if err != nil {
+		return "", downloadError(fmt.Sprintf("Download interrupted after %d bytes: %v. The partial file is kept, call the tool again with the same url and file_path to resume.", d.progress.Downloaded, err))
+	}
+	if d.maxSize > 0 && d.progress.Downloaded > d.maxSize {
+		os.Remove(d.path)
+		return "", downloadError(fmt.Sprintf("File too large: exceeded %d bytes limit", d.maxSize))
+	}
+	return resp.Header.Get("Content-Type"), nil
+}
+
+// Write counts the bytes received and publishes the progress.
+func (d *download) Write(p []byte) (int, error) {
+	d.progress.Downloaded += int64(len(p))
+	d.publish(false)
+	return len(p), nil
+}
+
+func (d *download) publish(force bool) {
+	if !force && time.Since(d.publishedAt) < downloadProgressInterval {
+		return
+	}
+	d.publishedAt = time.Now()
+	downloadBroker.Publish(pubsub.UpdatedEvent, d.progress)
+}
+
+// partialDownloadPath returns where the file at path is downloaded from url
+// until it's complete, and where an interrupted download is kept to be
+// resumed. It depends on url, so that only a download of the same URL
+// resumes it.
+func partialDownloadPath(path, url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return fmt.Sprintf("%s.%s.part", path, hex.EncodeToString(sum[:4]))
+}
+
+// contentRangeStart returns where the range of a partial response starts, or
+// -1 if it doesn't say.
+func contentRangeStart(resp *http.Response) int64 {
+	rest, ok := strings.CutPrefix(resp.Header.Get("Content-Range"), "bytes ")
+	if !ok {
+		return -1
+	}
+	start, _, ok := strings.Cut(rest, "-")
+	if !ok {
+		return -1
+	}
+	n, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,148 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownload(t *testing.T) {
+	t.Parallel()
+
+	content := bytes.Repeat([]byte("0123456789"), 10_000)
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+	var mu sync.Mutex
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		mu.Unlock()
+		http.ServeContent(w, r, "data.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	newTool := func(maxSize int64) fantasy.AgentTool {
+		return NewDownloadTool(
+			&mockPermissionService{Broker: pubsub.NewBroker[permission.PermissionRequest]()},
+			dir,
+			server.Client(),
+			config.ToolDownload{MaxSize: &maxSize},
+		)
+	}
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "session")
+	run := func(tool fantasy.AgentTool, params DownloadParams) fantasy.ToolResponse {
+		t.Helper()
+		params.URL = server.URL
+		input, err := json.Marshal(params)
+		require.NoError(t, err)
+		resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "call", Name: DownloadToolName, Input: string(input)})
+		require.NoError(t, err)
+		return resp
+	}
+	read := func(name string) []byte {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		return data
+	}
+	tool := newTool(0)
+
+	resp := run(tool, DownloadParams{FilePath: "full.bin", SHA256: strings.ToUpper(checksum)})
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "Successfully downloaded 100000 bytes to full.bin")
+	require.Contains(t, resp.Content, "SHA256 verified: "+checksum)
+	require.Equal(t, content, read("full.bin"))
+
+	// An interrupted download resumes from what it got.
+	part := partialDownloadPath(filepath.Join(dir, "resumed.bin"), server.URL)
+	require.NoError(t, os.WriteFile(part, content[:30_000], 0o644))
+	resp = run(tool, DownloadParams{FilePath: "resumed.bin"})
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "resuming after 30000 bytes")
+	mu.Lock()
+	require.Equal(t, "bytes=30000-", ranges[len(ranges)-1])
+	mu.Unlock()
+	require.Equal(t, content, read("resumed.bin"))
+	require.NoFileExists(t, part)
+
+	// So does one that got the whole file before it was interrupted.
+	require.NoError(t, os.WriteFile(part, content, 0o644))
+	resp = run(tool, DownloadParams{FilePath: "resumed.bin", SHA256: checksum})
+	require.False(t, resp.IsError, resp.Content)
+	require.Equal(t, content, read("resumed.bin"))
+
+	resp = run(tool, DownloadParams{FilePath: "bad.bin", SHA256: strings.Repeat("0", 64)})
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "Checksum mismatch")
+	require.NoFileExists(t, filepath.Join(dir, "bad.bin"))
+	require.NoFileExists(t, partialDownloadPath(filepath.Join(dir, "bad.bin"), server.URL))
+
+	resp = run(tool, DownloadParams{FilePath: "bad.bin", SHA256: "abc"})
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "64 hex digits")
+
+	resp = run(newTool(50_000), DownloadParams{FilePath: "large.bin"})
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "File too large: 100000 bytes (max 50000 bytes)")
+	require.NoFileExists(t, filepath.Join(dir, "large.bin"))
+}
+
+func TestDownloadProgress(t *testing.T) {
+	t.Parallel()
+
+	content := bytes.Repeat([]byte("x"), 1_000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "data.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(server.Close)
+
+	events := SubscribeDownloads(t.Context())
+	tool := NewDownloadTool(
+		&mockPermissionService{Broker: pubsub.NewBroker[permission.PermissionRequest]()},
+		t.TempDir(),
+		server.Client(),
+		config.ToolDownload{},
+	)
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "session")
+	input := `{"url": "` + server.URL + `", "file_path": "data.bin"}`
+	resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "progress-call", Name: DownloadToolName, Input: input})
+	require.NoError(t, err)
+	require.False(t, resp.IsError, resp.Content)
+
+	for event := range events {
+		if event.Payload.ToolCallID != "progress-call" || event.Payload.Downloaded < event.Payload.Total {
+			continue
+		}
+		require.Equal(t, DownloadProgress{ToolCallID: "progress-call", SessionID: "session", Downloaded: 1_000, Total: 1_000}, event.Payload)
+		break
+	}
+}
+
+func TestContentRangeStart(t *testing.T) {
+	t.Parallel()
+
+	start := func(header string) int64 {
+		return contentRangeStart(&http.Response{Header: http.Header{"Content-Range": {header}}})
+	}
+	require.Equal(t, int64(500), start("bytes 500-999/1000"))
+	require.Equal(t, int64(-1), start(""))
+	require.Equal(t, int64(-1), start("bytes */1000"))
+}
@@ -13,12 +13,14 @@ import (
 	"charm.land/fantasy"
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/editlock"
 	"github.com/charmbracelet/crush/internal/filepathext"
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/history"
 
 	"github.com/charmbracelet/crush/internal/lsp"
 	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/trash"
 )
 
 type EditParams struct {
@@ -50,10 +52,12 @@ type editContext struct {
 	ctx         context.Context
 	permissions permission.Service
 	files       history.Service
+	locks       *editlock.Registry
+	trash       *trash.Store
 	workingDir  string
 }
 
-func NewEditTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, workingDir string) fantasy.AgentTool {
+func NewEditTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, locks *editlock.Registry, bin *trash.Store, workingDir string) fantasy.AgentTool {
 	return fantasy.NewAgentTool(
 		EditToolName,
 		string(editDescription),
@@ -67,7 +71,7 @@ func NewEditTool(lspClients *csync.Map[string, *lsp.Client], permissions permiss
 			var response fantasy.ToolResponse
 			var err error
 
-			editCtx := editContext{ctx, permissions, files, workingDir}
+			editCtx := editContext{ctx, permissions, files, locks, bin, workingDir}
 
 			if params.OldString == "" {
 				response, err = createNewFile(editCtx, params.FilePath, params.NewString, call)
@@ -93,15 +97,20 @@ func NewEditTool(lspClients *csync.Map[string, *lsp.Client], permissions permiss
 				return response, nil
 			}
 
-			notifyLSPs(ctx, lspClients, params.FilePath)
-
-			text := fmt.Sprintf("<result>\n%s\n</result>\n", response.Content)
-			text += getDiagnostics(params.FilePath, lspClients)
-			response.Content = text
-			return response, nil
+			response.Content = fmt.Sprintf("<result>\n%s\n</result>\n", response.Content)
+			return WithDiagnostics(ctx, lspClients, response, params.FilePath), nil
 		})
 }
 
+// withMatchNote adds how old_string was matched to the result, when it
+// wasn't matched exactly.
+func withMatchNote(result, note string) string {
+	if note == "" {
+		return result
+	}
+	return result + "\n" + note
+}
+
 func createNewFile(edit editContext, filePath, content string, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
 	fileInfo, err := os.Stat(filePath)
 	if err == nil {
@@ -128,7 +137,7 @@ func createNewFile(edit editContext, filePath, content string, call fantasy.Tool
 		content,
 		strings.TrimPrefix(filePath, edit.workingDir),
 	)
-	p := edit.permissions.Request(
+	p := requestEdit(edit.ctx, edit.permissions, edit.locks,
 		permission.CreatePermissionRequest{
 			SessionID:   sessionID,
 			Path:        fsext.PathOrPrefix(filePath, edit.workingDir),
@@ -142,6 +151,7 @@ func createNewFile(edit editContext, filePath, content string, call fantasy.Tool
 				NewContent: content,
 			},
 		},
+		filePath,
 	)
 	if !p {
 		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
@@ -167,6 +177,7 @@ func createNewFile(edit editContext, filePath, content string, call fantasy.Tool
 	}
 
 	recordFileWrite(filePath)
+	acquireEditLocks(edit.ctx, edit.locks, filePath)
 	recordFileRead(filePath)
 
 	return fantasy.WithResponseMetadata(
@@ -213,27 +224,23 @@ func deleteContent(edit editContext, filePath, oldString string, replaceAll bool
 
 	oldContent, isCrlf := fsext.ToUnixLineEndings(string(content))
 
-	var newContent string
+	var newContent, note string
 	var deletionCount int
 
 	if replaceAll {
 		newContent = strings.ReplaceAll(oldContent, oldString, "")
 		deletionCount = strings.Count(oldContent, oldString)
 		if deletionCount == 0 {
-			return fantasy.NewTextErrorResponse("old_string not found in file. Make sure it matches exactly, including whitespace and line breaks"), nil
+			return fantasy.NewTextErrorResponse(oldStringNotFound(oldContent, oldString).Error()), nil
 		}
 	} else {
-		index := strings.Index(oldContent, oldString)
-		if index == -1 {
-			return fantasy.NewTextErrorResponse("old_string not found in file. Make sure it matches exactly, including whitespace and line breaks"), nil
-		}
-
-		lastIndex := strings.LastIndex(oldContent, oldString)
-		if index != lastIndex {
-			return fantasy.NewTextErrorResponse("old_string appears multiple times in the file. Please provide more context to ensure a unique match, or set replace_all to true"), nil
+		match, err := matchEdit(oldContent, oldString, "")
+		if err != nil {
+			return fantasy.NewTextErrorResponse(err.Error()), nil
 		}
+		note = match.note
 
-		newContent = oldContent[:index] + oldContent[index+len(oldString):]
+		newContent = oldContent[:match.start] + match.newString + oldContent[match.end:]
 		deletionCount = 1
 	}
 
@@ -249,7 +256,7 @@ func deleteContent(edit editContext, filePath, oldString string, replaceAll bool
 		strings.TrimPrefix(filePath, edit.workingDir),
 	)
 
-	p := edit.permissions.Request(
+	p := requestEdit(edit.ctx, edit.permissions, edit.locks,
 		permission.CreatePermissionRequest{
 			SessionID:   sessionID,
 			Path:        fsext.PathOrPrefix(filePath, edit.workingDir),
@@ -263,6 +270,7 @@ func deleteContent(edit editContext, filePath, oldString string, replaceAll bool
 				NewContent: newContent,
 			},
 		},
+		filePath,
 	)
 	if !p {
 		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
@@ -272,6 +280,7 @@ func deleteContent(edit editContext, filePath, oldString string, replaceAll bool
 		newContent, _ = fsext.ToWindowsLineEndings(newContent)
 	}
 
+	keepReplaced(edit.trash, sessionID, filePath)
 	err = os.WriteFile(filePath, []byte(newContent), 0o644)
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
@@ -300,10 +309,11 @@ func deleteContent(edit editContext, filePath, oldString string, replaceAll bool
 	}
 
 	recordFileWrite(filePath)
+	acquireEditLocks(edit.ctx, edit.locks, filePath)
 	recordFileRead(filePath)
 
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse("Content deleted from file: "+filePath),
+		fantasy.NewTextResponse(withMatchNote("Content deleted from file: "+filePath, note)),
 		EditResponseMetadata{
 			OldContent: oldContent,
 			NewContent: newContent,
@@ -346,27 +356,23 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 
 	oldContent, isCrlf := fsext.ToUnixLineEndings(string(content))
 
-	var newContent string
+	var newContent, note string
 	var replacementCount int
 
 	if replaceAll {
 		newContent = strings.ReplaceAll(oldContent, oldString, newString)
 		replacementCount = strings.Count(oldContent, oldString)
 		if replacementCount == 0 {
-			return fantasy.NewTextErrorResponse("old_string not found in file. Make sure it matches exactly, including whitespace and line breaks"), nil
+			return fantasy.NewTextErrorResponse(oldStringNotFound(oldContent, oldString).Error()), nil
 		}
 	} else {
-		index := strings.Index(oldContent, oldString)
-		if index == -1 {
-			return fantasy.NewTextErrorResponse("old_string not found in file. Make sure it matches exactly, including whitespace and line breaks"), nil
-		}
-
-		lastIndex := strings.LastIndex(oldContent, oldString)
-		if index != lastIndex {
-			return fantasy.NewTextErrorResponse("old_string appears multiple times in the file. Please provide more context to ensure a unique match, or set replace_all to true"), nil
+		match, err := matchEdit(oldContent, oldString, newString)
+		if err != nil {
+			return fantasy.NewTextErrorResponse(err.Error()), nil
 		}
+		note = match.note
 
-		newContent = oldContent[:index] + newString + oldContent[index+len(oldString):]
+		newContent = oldContent[:match.start] + match.newString + oldContent[match.end:]
 		replacementCount = 1
 	}
 
@@ -384,7 +390,7 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 		strings.TrimPrefix(filePath, edit.workingDir),
 	)
 
-	p := edit.permissions.Request(
+	p := requestEdit(edit.ctx, edit.permissions, edit.locks,
 		permission.CreatePermissionRequest{
 			SessionID:   sessionID,
 			Path:        fsext.PathOrPrefix(filePath, edit.workingDir),
@@ -398,6 +404,7 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 				NewContent: newContent,
 			},
 		},
+		filePath,
 	)
 	if !p {
 		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
@@ -407,6 +414,7 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 		newContent, _ = fsext.ToWindowsLineEndings(newContent)
 	}
 
+	keepReplaced(edit.trash, sessionID, filePath)
 	err = os.WriteFile(filePath, []byte(newContent), 0o644)
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
@@ -435,10 +443,11 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 	}
 
 	recordFileWrite(filePath)
+	acquireEditLocks(edit.ctx, edit.locks, filePath)
 	recordFileRead(filePath)
 
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse("Content replaced in file: "+filePath),
+		fantasy.NewTextResponse(withMatchNote("Content replaced in file: "+filePath, note)),
 		EditResponseMetadata{
 			OldContent: oldContent,
 			NewContent: newContent,
@@ -0,0 +1,57 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/editlock"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+// concurrentEditConfirmation is what the user types to change files another
+// session changed recently.
+const concurrentEditConfirmation = "yes"
+
+// requestEdit asks for permission to change the files at paths like
+// permissions.Request, but has the user confirm it even for allowed tools
+// when another session changed one of them recently. Sub-agent sessions
+// hold the locks of the session that started them.
+func requestEdit(ctx context.Context, permissions permission.Service, locks *editlock.Registry, req permission.CreatePermissionRequest, paths ...string) bool {
+	if warning := concurrentEditWarning(locks, GetRootSessionFromContext(ctx), paths); warning != "" {
+		req.Confirmation = concurrentEditConfirmation
+		req.Warning = warning
+	}
+	return permissions.Request(req)
+}
+
+// concurrentEditWarning returns a warning naming the files of paths another
+// session holds the lock on, or "" when there are none.
+func concurrentEditWarning(locks *editlock.Registry, sessionID string, paths []string) string {
+	var held []string
+	for _, path := range paths {
+		lock, ok := locks.HeldByOther(path, sessionID)
+		if !ok {
+			continue
+		}
+		holder := "another session"
+		if !lock.SameProcess() {
+			holder = fmt.Sprintf("a session of another Crush instance (pid %d)", lock.PID)
+		}
+		held = append(held, fmt.Sprintf("%s was changed by %s %s ago.", path, holder, time.Since(lock.Time).Round(time.Second)))
+	}
+	return strings.Join(held, " ")
+}
+
+// acquireEditLocks gives the locks on the files at paths to the session of
+// ctx, which just changed them.
+func acquireEditLocks(ctx context.Context, locks *editlock.Registry, paths ...string) {
+	sessionID := GetRootSessionFromContext(ctx)
+	for _, path := range paths {
+		if err := locks.Acquire(path, sessionID); err != nil {
+			slog.Warn("Could not lock edited file", "path", path, "error", err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/editlock"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingPermissionService grants every request and records the last one.
+type recordingPermissionService struct {
+	mockPermissionService
+	last permission.CreatePermissionRequest
+}
+
+func (r *recordingPermissionService) Request(req permission.CreatePermissionRequest) bool {
+	r.last = req
+	return true
+}
+
+func TestRequestEdit(t *testing.T) {
+	t.Parallel()
+
+	locks := editlock.ForDataDir(t.TempDir())
+	permissions := &recordingPermissionService{
+		mockPermissionService: mockPermissionService{Broker: pubsub.NewBroker[permission.PermissionRequest]()},
+	}
+	req := permission.CreatePermissionRequest{SessionID: "session-2", ToolName: EditToolName, Path: "/work"}
+	session1 := sessionContext(t.Context(), "session-1", "")
+	session2 := sessionContext(t.Context(), "session-2", "")
+
+	require.True(t, requestEdit(session2, permissions, locks, req, "/work/main.go"))
+	require.Empty(t, permissions.last.Confirmation)
+	require.Empty(t, permissions.last.Warning)
+
+	acquireEditLocks(session1, locks, "/work/main.go")
+	require.True(t, requestEdit(session2, permissions, locks, req, "/work/other.go", "/work/main.go"))
+	require.Equal(t, concurrentEditConfirmation, permissions.last.Confirmation)
+	require.Contains(t, permissions.last.Warning, "/work/main.go was changed by another session")
+	require.NotContains(t, permissions.last.Warning, "other.go")
+
+	acquireEditLocks(session2, locks, "/work/main.go")
+	require.True(t, requestEdit(session2, permissions, locks, req, "/work/main.go"))
+	require.Empty(t, permissions.last.Warning)
+
+	subAgent := sessionContext(t.Context(), "sub-agent", "session-2")
+	req.SessionID = "sub-agent"
+	require.True(t, requestEdit(subAgent, permissions, locks, req, "/work/main.go"))
+	require.Empty(t, permissions.last.Warning, "sub-agents share the locks of their session")
+	acquireEditLocks(subAgent, locks, "/work/main.go")
+	_, ok := locks.HeldByOther("/work/main.go", "session-2")
+	require.False(t, ok)
+}
+
+// sessionContext returns ctx for the session with sessionID, started by
+// rootSessionID when it isn't empty.
+func sessionContext(ctx context.Context, sessionID, rootSessionID string) context.Context {
+	if rootSessionID != "" {
+		ctx = context.WithValue(ctx, RootSessionIDContextKey, rootSessionID)
+	}
+	return context.WithValue(ctx, SessionIDContextKey, sessionID)
+}
@@ -0,0 +1,220 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// minClosestMatchScore is how similar lines must be, between 0 and 1, to
+	// be suggested as the closest match of an old_string that isn't found.
+	minClosestMatchScore = 0.5
+	// minAnchoredLineScore is how similar the lines between matching first
+	// and last lines must be to replace them.
+	minAnchoredLineScore = 0.8
+)
+
+// editMatch is where an old_string was found in a file.
+type editMatch struct {
+	start, end int
+	// newString is the replacement, reindented like the file for tolerant
+	// matches.
+	newString string
+	// note tells the model how a tolerant match was found, for it to check
+	// the result. It's empty for exact matches.
+	note string
+}
+
+// matchEdit finds the one occurrence of oldString in content. When there's
+// none, it looks for lines that only differ in whitespace, then for lines
+// anchored by the first and last lines of oldString with nearly the same
+// lines in between. The errors describe the closest match for the model to
+// retry with.
+func matchEdit(content, oldString, newString string) (editMatch, error) {
+	index := strings.Index(content, oldString)
+	if index != -1 {
+		if lastIndex := strings.LastIndex(content, oldString); lastIndex != index {
+			return editMatch{}, fmt.Errorf("old_string appears multiple times in the file, at lines %s. Please provide more context to ensure a unique match, or set replace_all to true", formatLineNumbers(occurrenceLines(content, oldString)))
+		}
+		return editMatch{start: index, end: index + len(oldString), newString: newString}, nil
+	}
+
+	lines := strings.Split(content, "\n")
+	oldLines, newString := splitEditLines(oldString, newString)
+	for _, tolerant := range []struct {
+		match func(fileLines, oldLines []string) bool
+		how   string
+		check string
+	}{
+		{matchIgnoringWhitespace, "ignoring whitespace differences", ""},
+		{matchAnchored, "by its first and last lines", ". Check that the lines in between were the ones to replace"},
+	} {
+		var found []int
+		for i := 0; i+len(oldLines) <= len(lines); i++ {
+			if tolerant.match(lines[i:i+len(oldLines)], oldLines) {
+				found = append(found, i)
+			}
+		}
+		switch len(found) {
+		case 0:
+			continue
+		case 1:
+			first, last := found[0], found[0]+len(oldLines)-1
+			return editMatch{
+				start:     lineOffset(lines, first),
+				end:       lineOffset(lines, last) + len(lines[last]),
+				newString: reindent(newString, oldLines, lines[first:last+1]),
+				note:      fmt.Sprintf("old_string matched lines %d-%d %s%s", first+1, last+1, tolerant.how, tolerant.check),
+			}, nil
+		default:
+			for i := range found {
+				found[i]++
+			}
+			return editMatch{}, fmt.Errorf("old_string isn't in the file as is, and matches lines %s %s. Please provide the exact text, with more context to ensure a unique match", formatLineNumbers(found), tolerant.how)
+		}
+	}
+	return editMatch{}, oldStringNotFound(content, oldString)
+}
+
+// oldStringNotFound returns the error for an old_string that isn't in the
+// file, suggesting the closest lines as the old_string to retry with.
+func oldStringNotFound(content, oldString string) error {
+	const msg = "old_string not found in file. Make sure it matches exactly, including whitespace and line breaks"
+	lines := strings.Split(content, "\n")
+	oldLines, _ := splitEditLines(oldString, "")
+	if len(oldLines) > len(lines) {
+		return errors.New(msg)
+	}
+
+	best, bestScore := 0, 0.0
+	for i := 0; i+len(oldLines) <= len(lines); i++ {
+		score := 0.0
+		for j, oldLine := range oldLines {
+			score += lineSimilarity(lines[i+j], oldLine)
+		}
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if bestScore/float64(len(oldLines)) < minClosestMatchScore {
+		return fmt.Errorf("%s. No similar lines were found; view the file again to see its current content", msg)
+	}
+	closest := strings.Join(lines[best:best+len(oldLines)], "\n")
+	return fmt.Errorf("%s. The closest match is at lines %d-%d. To retry, use it as old_string:\n<suggested_old_string>\n%s\n</suggested_old_string>", msg, best+1, best+len(oldLines), closest)
+}
+
+// splitEditLines splits oldString into lines, dropping the newline it ends
+// with, and drops it from newString too to keep the line after the match.
+func splitEditLines(oldString, newString string) ([]string, string) {
+	if trimmed, ok := strings.CutSuffix(oldString, "\n"); ok {
+		oldString = trimmed
+		newString = strings.TrimSuffix(newString, "\n")
+	}
+	return strings.Split(oldString, "\n"), newString
+}
+
+func matchIgnoringWhitespace(fileLines, oldLines []string) bool {
+	for i, oldLine := range oldLines {
+		if normalizeWhitespace(fileLines[i]) != normalizeWhitespace(oldLine) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchAnchored(fileLines, oldLines []string) bool {
+	last := len(oldLines) - 1
+	if len(oldLines) < 3 ||
+		normalizeWhitespace(fileLines[0]) != normalizeWhitespace(oldLines[0]) ||
+		normalizeWhitespace(fileLines[last]) != normalizeWhitespace(oldLines[last]) {
+		return false
+	}
+	for i := 1; i < last; i++ {
+		if lineSimilarity(fileLines[i], oldLines[i]) < minAnchoredLineScore {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeWhitespace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// lineSimilarity returns how similar two lines are, ignoring whitespace,
+// from 0 to 1, by the length of their common prefix and suffix.
+func lineSimilarity(a, b string) float64 {
+	a, b = normalizeWhitespace(a), normalizeWhitespace(b)
+	if a == b {
+		return 1
+	}
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	return float64(prefix+suffix) / float64(max(len(a), len(b)))
+}
+
+// reindent changes the indentation of newString from the one of oldLines to
+// the one of the matching file lines.
+func reindent(newString string, oldLines, fileLines []string) string {
+	for i, oldLine := range oldLines {
+		if strings.TrimSpace(oldLine) == "" {
+			continue
+		}
+		oldIndent := leadingWhitespace(oldLine)
+		fileIndent := leadingWhitespace(fileLines[i])
+		if oldIndent == fileIndent {
+			return newString
+		}
+		newLines := strings.Split(newString, "\n")
+		for j, line := range newLines {
+			if rest, ok := strings.CutPrefix(line, oldIndent); ok && strings.TrimSpace(line) != "" {
+				newLines[j] = fileIndent + rest
+			}
+		}
+		return strings.Join(newLines, "\n")
+	}
+	return newString
+}
+
+func leadingWhitespace(s string) string {
+	return s[:len(s)-len(strings.TrimLeft(s, " \t"))]
+}
+
+// lineOffset returns the offset of the line with the given index.
+func lineOffset(lines []string, index int) int {
+	offset := 0
+	for _, line := range lines[:index] {
+		offset += len(line) + 1
+	}
+	return offset
+}
+
+// occurrenceLines returns the line numbers where s occurs in content.
+func occurrenceLines(content, s string) []int {
+	var lines []int
+	offset := 0
+	for {
+		i := strings.Index(content[offset:], s)
+		if i == -1 {
+			return lines
+		}
+		offset += i
+		lines = append(lines, strings.Count(content[:offset], "\n")+1)
+		offset += max(len(s), 1)
+	}
+}
+
+func formatLineNumbers(lines []int) string {
+	numbers := make([]string, len(lines))
+	for i, line := range lines {
+		numbers[i] = fmt.Sprint(line)
+	}
+	return strings.Join(numbers, ", ")
+}
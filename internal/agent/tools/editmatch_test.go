@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchEdit(t *testing.T) {
+	t.Parallel()
+
+	content := "func main() {\n\tif ok {\n\t\tfmt.Println(\"hello\")\n\t}\n}\n"
+	apply := func(m editMatch) string {
+		return content[:m.start] + m.newString + content[m.end:]
+	}
+
+	t.Run("exact", func(t *testing.T) {
+		t.Parallel()
+		m, err := matchEdit(content, `fmt.Println("hello")`, `fmt.Println("bye")`)
+		require.NoError(t, err)
+		require.Empty(t, m.note)
+		require.Equal(t, "func main() {\n\tif ok {\n\t\tfmt.Println(\"bye\")\n\t}\n}\n", apply(m))
+	})
+
+	t.Run("whitespace", func(t *testing.T) {
+		t.Parallel()
+		m, err := matchEdit(content, "  if ok {\n    fmt.Println(\"hello\")\n  }\n", "  if !ok {\n    return\n  }\n")
+		require.NoError(t, err)
+		require.Equal(t, "old_string matched lines 2-4 ignoring whitespace differences", m.note)
+		require.Equal(t, "func main() {\n\tif !ok {\n\t  return\n\t}\n}\n", apply(m), "the indentation of the first line is the file's")
+	})
+
+	t.Run("anchored", func(t *testing.T) {
+		t.Parallel()
+		m, err := matchEdit(content, "\tif ok {\n\t\tfmt.Println(\"helo\")\n\t}", "\tif ok {\n\t\tfmt.Println(\"bye\")\n\t}")
+		require.NoError(t, err)
+		require.Contains(t, m.note, "old_string matched lines 2-4 by its first and last lines")
+		require.Equal(t, "func main() {\n\tif ok {\n\t\tfmt.Println(\"bye\")\n\t}\n}\n", apply(m))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		t.Parallel()
+		_, err := matchEdit(content, "\tif ok {\n\t\tlog.Println(\"hello\")\n\t} else {", "")
+		require.ErrorContains(t, err, "old_string not found in file")
+		require.ErrorContains(t, err, "The closest match is at lines 2-4")
+		require.ErrorContains(t, err, "<suggested_old_string>\n\tif ok {\n\t\tfmt.Println(\"hello\")\n\t}\n</suggested_old_string>")
+
+		_, err = matchEdit(content, "nothing like it", "")
+		require.ErrorContains(t, err, "No similar lines were found")
+	})
+
+	t.Run("multiple", func(t *testing.T) {
+		t.Parallel()
+		_, err := matchEdit("a\nb\na\n", "a", "c")
+		require.ErrorContains(t, err, "old_string appears multiple times in the file, at lines 1, 3")
+
+		_, err = matchEdit("  a\nb\n\ta\n", "a \n", "c")
+		require.ErrorContains(t, err, "matches lines 1, 3 ignoring whitespace differences")
+	})
+}
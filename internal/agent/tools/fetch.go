@@ -1,11 +1,15 @@
 package tools
 
 import (
+	"cmp"
 	"context"
 	_ "embed"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 	"unicode/utf8"
@@ -13,25 +17,38 @@ import (
 	"charm.land/fantasy"
 	md "github.com/JohannesKaufmann/html-to-markdown"
 	"github.com/PuerkitoBio/goquery"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/httpext"
 	"github.com/charmbracelet/crush/internal/permission"
 )
 
-const FetchToolName = "fetch"
+const (
+	FetchToolName = "fetch"
+
+	// maxFetchTimeout is the longest a fetch waits for a page, in seconds.
+	maxFetchTimeout = 120
+)
 
 //go:embed fetch.md
 var fetchDescription []byte
 
-func NewFetchTool(permissions permission.Service, workingDir string, client *http.Client) fantasy.AgentTool {
+// FetchResponseMetadata describes the fetched page, for citing it.
+type FetchResponseMetadata struct {
+	PageMetadata
+	FetchedAt time.Time `json:"fetched_at"`
+	// Cached is set when the page came from the cache, because the server
+	// said it didn't change or couldn't be reached.
+	Cached bool `json:"cached,omitempty"`
+}
+
+// NewFetchTool returns the fetch tool. Pages are kept in cacheDir, unless
+// it's empty.
+func NewFetchTool(permissions permission.Service, workingDir string, client *http.Client, opts config.ToolFetch, cacheDir string) fantasy.AgentTool {
 	if client == nil {
-		client = &http.Client{
-			Timeout: 30 * time.Second,
-			Transport: &http.Transport{
-				MaxIdleConns:        100,
-				MaxIdleConnsPerHost: 10,
-				IdleConnTimeout:     90 * time.Second,
-			},
-		}
+		client = httpext.NewPooledClient(maxFetchTimeout * time.Second)
 	}
+	robots := newRobotsChecker(client)
+	cache := fetchCache{dir: cacheDir}
 
 	return fantasy.NewAgentTool(
 		FetchToolName,
@@ -49,6 +66,10 @@ func NewFetchTool(permissions permission.Service, workingDir string, client *htt
 			if !strings.HasPrefix(params.URL, "http://") && !strings.HasPrefix(params.URL, "https://") {
 				return fantasy.NewTextErrorResponse("URL must start with http:// or https://"), nil
 			}
+			pageURL, err := url.Parse(params.URL)
+			if err != nil {
+				return fantasy.NewTextErrorResponse("Invalid URL: " + err.Error()), nil
+			}
 
 			sessionID := GetSessionFromContext(ctx)
 			if sessionID == "" {
@@ -72,15 +93,12 @@ func NewFetchTool(permissions permission.Service, workingDir string, client *htt
 			}
 
 			// Handle timeout with context
-			requestCtx := ctx
-			if params.Timeout > 0 {
-				maxTimeout := 120 // 2 minutes
-				if params.Timeout > maxTimeout {
-					params.Timeout = maxTimeout
-				}
-				var cancel context.CancelFunc
-				requestCtx, cancel = context.WithTimeout(ctx, time.Duration(params.Timeout)*time.Second)
-				defer cancel()
+			timeout := min(cmp.Or(params.Timeout, opts.TimeoutSeconds()), maxFetchTimeout)
+			requestCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			if opts.RespectsRobots() && !robots.allowed(requestCtx, pageURL) {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("The robots.txt file of %s disallows fetching %s. Don't try to get around it: tell the user, who can set tools.fetch.respect_robots to false in the configuration to allow it.", pageURL.Host, params.URL)), nil
 			}
 
 			req, err := http.NewRequestWithContext(requestCtx, "GET", params.URL, nil)
@@ -89,69 +107,65 @@ func NewFetchTool(permissions permission.Service, workingDir string, client *htt
 			}
 
 			req.Header.Set("User-Agent", "crush/1.0")
+			cached, hasCached := cache.get(params.URL)
+			if hasCached {
+				if cached.ETag != "" {
+					req.Header.Set("If-None-Match", cached.ETag)
+				}
+				if cached.LastModified != "" {
+					req.Header.Set("If-Modified-Since", cached.LastModified)
+				}
+			}
 
+			var page fetchCacheEntry
+			var fromCache bool
+			// unreachable is why the cached page is used, if the site
+			// couldn't be reached.
+			var unreachable error
 			resp, err := client.Do(req)
-			if err != nil {
+			switch {
+			case err != nil && hasCached:
+				page, fromCache, unreachable = cached, true, err
+			case err != nil:
 				return fantasy.ToolResponse{}, fmt.Errorf("failed to fetch URL: %w", err)
-			}
-			defer resp.Body.Close()
-
-			if resp.StatusCode != http.StatusOK {
+			case resp.StatusCode == http.StatusNotModified && hasCached:
+				resp.Body.Close()
+				page, fromCache = cached, true
+				page.FetchedAt = time.Now()
+				cache.touch(params.URL)
+			case resp.StatusCode != http.StatusOK:
+				resp.Body.Close()
 				return fantasy.NewTextErrorResponse(fmt.Sprintf("Request failed with status code: %d", resp.StatusCode)), nil
+			default:
+				page, err = readFetchResponse(resp, params.URL)
+				if err != nil {
+					return fantasy.NewTextErrorResponse("Failed to fetch URL: " + err.Error()), nil
+				}
+				if err := cache.put(page); err != nil {
+					slog.Warn("Failed to cache fetched page", "url", params.URL, "error", err)
+				}
 			}
 
-			maxSize := int64(5 * 1024 * 1024) // 5MB
-			body, err := io.ReadAll(io.LimitReader(resp.Body, maxSize))
+			content, meta, err := formatFetchedPage(page, format)
 			if err != nil {
-				return fantasy.NewTextErrorResponse("Failed to read response body: " + err.Error()), nil
+				return fantasy.NewTextErrorResponse("Failed to fetch URL: " + err.Error()), nil
 			}
+			meta.Cached = fromCache
 
-			content := string(body)
-
-			isValidUt8 := utf8.ValidString(content)
-			if !isValidUt8 {
-				return fantasy.NewTextErrorResponse("Response content is not valid UTF-8"), nil
+			var header []string
+			if meta.Title != "" {
+				header = append(header, "Title: "+meta.Title)
 			}
-			contentType := resp.Header.Get("Content-Type")
-
-			switch format {
-			case "text":
-				if strings.Contains(contentType, "text/html") {
-					text, err := extractTextFromHTML(content)
-					if err != nil {
-						return fantasy.NewTextErrorResponse("Failed to extract text from HTML: " + err.Error()), nil
-					}
-					content = text
-				}
-
-			case "markdown":
-				if strings.Contains(contentType, "text/html") {
-					markdown, err := convertHTMLToMarkdown(content)
-					if err != nil {
-						return fantasy.NewTextErrorResponse("Failed to convert HTML to Markdown: " + err.Error()), nil
-					}
-					content = markdown
-				}
-
-				content = "```\n" + content + "\n```"
-
-			case "html":
-				// return only the body of the HTML document
-				if strings.Contains(contentType, "text/html") {
-					doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
-					if err != nil {
-						return fantasy.NewTextErrorResponse("Failed to parse HTML: " + err.Error()), nil
-					}
-					body, err := doc.Find("body").Html()
-					if err != nil {
-						return fantasy.NewTextErrorResponse("Failed to extract body from HTML: " + err.Error()), nil
-					}
-					if body == "" {
-						return fantasy.NewTextErrorResponse("No body content found in HTML"), nil
-					}
-					content = "<html>\n<body>\n" + body + "\n</body>\n</html>"
-				}
+			if isHTML(page.ContentType) {
+				header = append(header, "URL: "+meta.URL)
+			}
+			if unreachable != nil {
+				header = append(header, fmt.Sprintf("Fetched: %s (cached copy, the site couldn't be reached: %v)", page.FetchedAt.Format(time.RFC3339), unreachable))
 			}
+			if len(header) > 0 {
+				content = strings.Join(header, "\n") + "\n\n" + content
+			}
+
 			// calculate byte size of content
 			contentSize := int64(len(content))
 			if contentSize > MaxReadSize {
@@ -159,20 +173,97 @@ func NewFetchTool(permissions permission.Service, workingDir string, client *htt
 				content += fmt.Sprintf("\n\n[Content truncated to %d bytes]", MaxReadSize)
 			}
 
-			return fantasy.NewTextResponse(content), nil
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(content), meta), nil
 		})
 }
 
-func extractTextFromHTML(html string) (string, error) {
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+// readFetchResponse reads the page in resp, fetched from rawURL.
+func readFetchResponse(resp *http.Response, rawURL string) (fetchCacheEntry, error) {
+	defer resp.Body.Close()
+
+	maxSize := int64(5 * 1024 * 1024) // 5MB
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSize))
 	if err != nil {
-		return "", err
+		return fetchCacheEntry{}, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	text := doc.Find("body").Text()
-	text = strings.Join(strings.Fields(text), " ")
+	content := string(body)
+	if !utf8.ValidString(content) {
+		return fetchCacheEntry{}, errors.New("response content is not valid UTF-8")
+	}
+
+	return fetchCacheEntry{
+		URL:          rawURL,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FinalURL:     resp.Request.URL.String(),
+		ContentType:  resp.Header.Get("Content-Type"),
+		FetchedAt:    time.Now(),
+		Body:         content,
+	}, nil
+}
+
+// formatFetchedPage returns the content of page in format, along with its
+// metadata. The main content of HTML pages is extracted unless the format
+// is html.
+func formatFetchedPage(page fetchCacheEntry, format string) (string, FetchResponseMetadata, error) {
+	meta := FetchResponseMetadata{
+		PageMetadata: PageMetadata{URL: page.FinalURL},
+		FetchedAt:    page.FetchedAt,
+	}
+	content := page.Body
+	if !isHTML(page.ContentType) {
+		if format == "markdown" {
+			content = "```\n" + content + "\n```"
+		}
+		return content, meta, nil
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return "", meta, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+	pageURL, err := url.Parse(page.FinalURL)
+	if err != nil {
+		return "", meta, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	switch format {
+	case "text":
+		readable, pageMeta := extractReadable(doc, pageURL)
+		meta.PageMetadata = pageMeta
+		content = strings.Join(strings.Fields(readable.Text()), " ")
+
+	case "markdown":
+		readable, pageMeta := extractReadable(doc, pageURL)
+		meta.PageMetadata = pageMeta
+		html, err := readable.Html()
+		if err != nil {
+			return "", meta, fmt.Errorf("failed to extract content from HTML: %w", err)
+		}
+		markdown, err := convertHTMLToMarkdown(html)
+		if err != nil {
+			return "", meta, fmt.Errorf("failed to convert HTML to Markdown: %w", err)
+		}
+		content = "```\n" + markdown + "\n```"
+
+	case "html":
+		// return only the body of the HTML document
+		meta.PageMetadata = pageMetadata(doc, pageURL)
+		body, err := doc.Find("body").Html()
+		if err != nil {
+			return "", meta, fmt.Errorf("failed to extract body from HTML: %w", err)
+		}
+		if body == "" {
+			return "", meta, errors.New("no body content found in HTML")
+		}
+		content = "<html>\n<body>\n" + body + "\n</body>\n</html>"
+	}
+	return content, meta, nil
+}
 
-	return text, nil
+func isHTML(contentType string) bool {
+	return strings.Contains(contentType, "text/html")
 }
 
 func convertHTMLToMarkdown(html string) (string, error) {
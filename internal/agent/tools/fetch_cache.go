@@ -0,0 +1,86 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// fetchCacheMaxAge is how long fetched pages are kept without being fetched
+// again.
+const fetchCacheMaxAge = 30 * 24 * time.Hour
+
+// fetchCache keeps fetched pages that have an ETag or a last modification
+// time on disk, for the next fetch to ask the server whether they changed
+// and skip downloading them again if they didn't.
+type fetchCache struct {
+	dir string
+}
+
+type fetchCacheEntry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FinalURL     string    `json:"final_url"`
+	ContentType  string    `json:"content_type"`
+	FetchedAt    time.Time `json:"fetched_at"`
+	Body         string    `json:"body"`
+}
+
+// path returns the file of the entry for url.
+func (c fetchCache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:16])+".json")
+}
+
+// get returns the entry for url, if there's one.
+func (c fetchCache) get(url string) (fetchCacheEntry, bool) {
+	var entry fetchCacheEntry
+	if c.dir == "" {
+		return entry, false
+	}
+	data, err := os.ReadFile(c.path(url))
+	if err != nil {
+		return entry, false
+	}
+	if err := json.Unmarshal(data, &entry); err != nil || entry.URL != url {
+		return entry, false
+	}
+	return entry, true
+}
+
+// put keeps entry, if the server gave a way to tell whether it changed, and
+// removes the entries that weren't fetched for fetchCacheMaxAge.
+func (c fetchCache) put(entry fetchCacheEntry) error {
+	if c.dir == "" || (entry.ETag == "" && entry.LastModified == "") {
+		return nil
+	}
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(c.path(entry.URL), data, 0o600); err != nil {
+		return err
+	}
+
+	files, _ := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil && time.Since(info.ModTime()) > fetchCacheMaxAge {
+			os.Remove(file)
+		}
+	}
+	return nil
+}
+
+// touch marks the entry for url as fetched again, after the server said it
+// didn't change.
+func (c fetchCache) touch(url string) {
+	now := time.Now()
+	os.Chtimes(c.path(url), now, now)
+}
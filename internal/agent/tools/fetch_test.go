@@ -0,0 +1,196 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/stretchr/testify/require"
+)
+
+const articlePage = `<!DOCTYPE html>
+<html>
+<head>
+	<title>Release notes</title>
+	<link rel="canonical" href="/blog/release">
+</head>
+<body>
+	<nav><a href="/">Home</a> <a href="/blog">Blog</a></nav>
+	<div class="sidebar"><p>Subscribe to our newsletter, it's great, really, we promise.</p></div>
+	<div id="post">
+		<h1>Release notes</h1>
+		<p>This release makes the parser faster, smaller, and easier to extend with plugins.</p>
+		<p>It also fixes crashes on empty files, which were reported by many of you, thanks.</p>
+		<p>See <a href="/docs">the docs</a> for the details. <img src="data:image/png;base64,AAAA"></p>
+	</div>
+	<div class="footer"><p>Copyright 2025, all rights reserved, everywhere, forever.</p></div>
+</body>
+</html>`
+
+func TestExtractReadable(t *testing.T) {
+	t.Parallel()
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(articlePage))
+	require.NoError(t, err)
+	pageURL, _ := url.Parse("https://example.com/blog/release?ref=feed")
+	content, meta := extractReadable(doc, pageURL)
+	require.Equal(t, PageMetadata{Title: "Release notes", URL: "https://example.com/blog/release"}, meta)
+
+	text := strings.Join(strings.Fields(content.Text()), " ")
+	require.True(t, strings.HasPrefix(text, "Release notes This release makes the parser faster"), text)
+	require.NotContains(t, text, "Home")
+	require.NotContains(t, text, "newsletter")
+	require.NotContains(t, text, "Copyright")
+
+	html, err := content.Html()
+	require.NoError(t, err)
+	require.Contains(t, html, `href="https://example.com/docs"`)
+	require.NotContains(t, html, "data:image")
+}
+
+func TestRobots(t *testing.T) {
+	t.Parallel()
+
+	robots := `
+User-agent: *
+Disallow: /private/
+Allow: /private/public$
+
+User-agent: Googlebot
+User-agent: Crush # us
+Disallow: /search
+Disallow: /*.pdf$
+Allow: /search/about
+`
+	rules := parseRobots(strings.NewReader(robots), robotsUserAgent)
+	for path, allowed := range map[string]bool{
+		"/":                 true,
+		"/private/secrets":  true,
+		"/search?q=crush":   false,
+		"/search/about":     true,
+		"/papers/paper.pdf": false,
+		"/papers/pdf.html":  true,
+	} {
+		require.Equal(t, allowed, rules.allowed(path), path)
+	}
+
+	rules = parseRobots(strings.NewReader(robots), "otherbot")
+	require.False(t, rules.allowed("/private/secrets"))
+	require.True(t, rules.allowed("/private/public"))
+	require.False(t, rules.allowed("/private/public/more"))
+	require.True(t, rules.allowed("/search"))
+}
+
+func TestFetch(t *testing.T) {
+	t.Parallel()
+
+	var pageRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+		case "/private":
+			w.Write([]byte("secret"))
+		default:
+			pageRequests.Add(1)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Header().Set("ETag", `"v1"`)
+			w.Write([]byte(articlePage))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	cacheDir := t.TempDir()
+	tool := NewFetchTool(
+		&mockPermissionService{Broker: pubsub.NewBroker[permission.PermissionRequest]()},
+		t.TempDir(),
+		server.Client(),
+		config.ToolFetch{},
+		cacheDir,
+	)
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "session")
+	run := func(path, format string) fantasy.ToolResponse {
+		t.Helper()
+		input, err := json.Marshal(FetchParams{URL: server.URL + path, Format: format})
+		require.NoError(t, err)
+		resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "call", Name: FetchToolName, Input: string(input)})
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := run("/blog/release", "markdown")
+	require.False(t, resp.IsError, resp.Content)
+	require.True(t, strings.HasPrefix(resp.Content, "Title: Release notes\nURL: "+server.URL+"/blog/release\n\n```\n"), resp.Content)
+	require.Contains(t, resp.Content, "[the docs]("+server.URL+"/docs)")
+	require.NotContains(t, resp.Content, "newsletter")
+	var meta FetchResponseMetadata
+	require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+	require.Equal(t, "Release notes", meta.Title)
+	require.False(t, meta.Cached)
+	require.False(t, meta.FetchedAt.IsZero())
+
+	// The page didn't change, so the server doesn't send it again.
+	cached := run("/blog/release", "markdown")
+	require.Equal(t, resp.Content, cached.Content)
+	require.NoError(t, json.Unmarshal([]byte(cached.Metadata), &meta))
+	require.True(t, meta.Cached)
+	require.Equal(t, int32(2), pageRequests.Load())
+
+	resp = run("/private", "text")
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "robots.txt")
+}
+
+func TestWebFetchAllowedDomains(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/away" {
+			http.Redirect(w, r, "https://example.com/", http.StatusFound)
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+	t.Cleanup(server.Close)
+
+	opts := config.ToolAgenticFetch{AllowedDomains: []string{"127.0.0.1", "go.dev"}}
+	require.True(t, opts.AllowsHost("pkg.go.dev"))
+	require.False(t, opts.AllowsHost("notgo.dev"))
+
+	tool := NewWebFetchTool(t.TempDir(), server.Client(), opts)
+	run := func(url string) fantasy.ToolResponse {
+		t.Helper()
+		input, err := json.Marshal(WebFetchParams{URL: url})
+		require.NoError(t, err)
+		resp, err := tool.Run(t.Context(), fantasy.ToolCall{ID: "call", Name: WebFetchToolName, Input: string(input)})
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := run(server.URL + "/")
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "hello")
+
+	resp = run("https://example.com/")
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "example.com isn't one of 127.0.0.1, go.dev")
+
+	// Redirects can't leave the allowed domains either.
+	resp = run(server.URL + "/away")
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, ErrDomainNotAllowed.Error())
+}
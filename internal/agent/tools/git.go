@@ -0,0 +1,307 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/filepathext"
+)
+
+type GitLogParams struct {
+	Path      string `json:"path,omitempty" description:"The file or directory to list the commits of (defaults to the whole repository)"`
+	StartLine int    `json:"start_line,omitempty" description:"With path a file, the first line of a range to follow the history of, showing how each commit changed it"`
+	EndLine   int    `json:"end_line,omitempty" description:"The last line of the range (defaults to start_line)"`
+	Revision  string `json:"revision,omitempty" description:"The commit or range to list, like main..HEAD (defaults to HEAD)"`
+	Grep      string `json:"grep,omitempty" description:"Only list the commits whose message matches this regular expression"`
+	Patch     bool   `json:"patch,omitempty" description:"Set to true to include the diff of each commit"`
+	Limit     int    `json:"limit,omitempty" description:"The most commits to list (defaults to 20, at most 100)"`
+}
+
+type GitLogResponseMetadata struct {
+	Path    string `json:"path,omitempty"`
+	Commits int    `json:"commits"`
+}
+
+type GitBlameParams struct {
+	Path      string `json:"path" description:"The file to annotate"`
+	StartLine int    `json:"start_line,omitempty" description:"The first line to annotate (defaults to 1)"`
+	EndLine   int    `json:"end_line,omitempty" description:"The last line to annotate (defaults to 500 lines from start_line)"`
+	Revision  string `json:"revision,omitempty" description:"The commit to annotate the file at (defaults to the working tree)"`
+}
+
+type GitBlameResponseMetadata struct {
+	Path    string `json:"path"`
+	Lines   int    `json:"lines"`
+	Commits int    `json:"commits"`
+}
+
+const (
+	GitLogToolName   = "git_log"
+	GitBlameToolName = "git_blame"
+
+	defaultGitLogLimit = 20
+	maxGitLogLimit     = 100
+	// maxBlameLines is the most lines git_blame annotates at once.
+	maxBlameLines = 500
+)
+
+//go:embed git_log.md
+var gitLogDescription []byte
+
+//go:embed git_blame.md
+var gitBlameDescription []byte
+
+// NewGitLogTool returns a tool that lists the commits of the repository the
+// working directory is in. Like the other tools that only read, it doesn't
+// ask for permission.
+func NewGitLogTool(workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		GitLogToolName,
+		string(gitLogDescription),
+		func(ctx context.Context, params GitLogParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			path, err := gitPath(workingDir, params.Path)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			if err := checkRevision(params.Revision); err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			args, err := gitLogArgs(params, path)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			out, err := runGit(ctx, workingDir, args...)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			commits := strings.Count("\n"+out, "\ncommit ")
+			if commits == 0 {
+				out = "No commits found"
+			}
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(truncateOutput(out)),
+				GitLogResponseMetadata{Path: params.Path, Commits: commits},
+			), nil
+		})
+}
+
+// gitLogArgs returns the arguments of git log for params, with path relative
+// to the working directory.
+func gitLogArgs(params GitLogParams, path string) ([]string, error) {
+	limit := params.Limit
+	if limit <= 0 {
+		limit = defaultGitLogLimit
+	}
+	limit = min(limit, maxGitLogLimit)
+
+	args := []string{
+		"log",
+		"--no-ext-diff",
+		"--no-color",
+		"--date=short",
+		// Like git's own format, with the full message indented.
+		"--format=commit %h%nAuthor: %an <%ae>%nDate:   %ad%n%n%w(0,4,4)%B",
+		"--max-count=" + strconv.Itoa(limit),
+	}
+	if params.Grep != "" {
+		args = append(args, "--grep="+params.Grep)
+	}
+	if params.StartLine > 0 {
+		if path == "" {
+			return nil, errors.New("path is required with start_line")
+		}
+		end := max(params.EndLine, params.StartLine)
+		args = append(args, fmt.Sprintf("-L%d,%d:%s", params.StartLine, end, path))
+	} else if params.Patch {
+		args = append(args, "--patch")
+	}
+	if params.Revision != "" {
+		args = append(args, params.Revision)
+	}
+	// A range of lines is followed through renames by -L, which takes no
+	// path.
+	if path != "" && params.StartLine <= 0 {
+		args = append(args, "--", path)
+	}
+	return args, nil
+}
+
+// NewGitBlameTool returns a tool that shows the commit that last changed
+// each line of a file. Like the other tools that only read, it doesn't ask
+// for permission.
+func NewGitBlameTool(workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		GitBlameToolName,
+		string(gitBlameDescription),
+		func(ctx context.Context, params GitBlameParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.Path == "" {
+				return fantasy.NewTextErrorResponse("path is required"), nil
+			}
+			path, err := gitPath(workingDir, params.Path)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			if err := checkRevision(params.Revision); err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			start := max(params.StartLine, 1)
+			end := params.EndLine
+			if end < start || end-start >= maxBlameLines {
+				end = start + maxBlameLines - 1
+			}
+			args := []string{"blame", "--line-porcelain", fmt.Sprintf("-L%d,%d", start, end)}
+			if params.Revision != "" {
+				args = append(args, params.Revision)
+			}
+			args = append(args, "--", path)
+
+			out, err := runGit(ctx, workingDir, args...)
+			if err != nil {
+				// Ranges past the end of the file are an error to git.
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			lines, commits := parseBlame(out)
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(formatBlame(lines, commits)),
+				GitBlameResponseMetadata{Path: params.Path, Lines: len(lines), Commits: len(commits)},
+			), nil
+		})
+}
+
+// blameCommit is a commit lines are blamed on.
+type blameCommit struct {
+	hash    string
+	author  string
+	date    string
+	summary string
+}
+
+// blameLine is a line of a file with the commit that last changed it.
+type blameLine struct {
+	number  int
+	hash    string
+	content string
+}
+
+// parseBlame parses the output of git blame --line-porcelain into the lines
+// and the commits they are blamed on, in the order they first appear.
+func parseBlame(out string) ([]blameLine, []blameCommit) {
+	var lines []blameLine
+	var commits []blameCommit
+	seen := make(map[string]bool)
+	var current blameCommit
+	var number int
+
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if content, ok := strings.CutPrefix(line, "\t"); ok {
+			if !seen[current.hash] {
+				seen[current.hash] = true
+				commits = append(commits, current)
+			}
+			lines = append(lines, blameLine{number: number, hash: current.hash, content: content})
+			continue
+		}
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "author":
+			current.author = value
+		case "author-time":
+			if sec, err := strconv.ParseInt(value, 10, 64); err == nil {
+				current.date = time.Unix(sec, 0).UTC().Format(time.DateOnly)
+			}
+		case "summary":
+			current.summary = value
+		default:
+			// The header of a line is the hash of the commit, the line in
+			// the commit and the line in the file.
+			fields := strings.Fields(line)
+			if len(fields) >= 3 && len(fields[0]) >= 40 && isHex(fields[0]) {
+				current = blameCommit{hash: fields[0][:8]}
+				number, _ = strconv.Atoi(fields[2])
+			}
+		}
+	}
+	return lines, commits
+}
+
+// formatBlame returns the lines as text for the model, each with the commit
+// that last changed it, followed by the messages of the commits.
+func formatBlame(lines []blameLine, commits []blameCommit) string {
+	if len(lines) == 0 {
+		return "No lines to annotate"
+	}
+	byHash := make(map[string]blameCommit, len(commits))
+	for _, c := range commits {
+		byHash[c.hash] = c
+	}
+	width := len(strconv.Itoa(lines[len(lines)-1].number))
+
+	var sb strings.Builder
+	for _, l := range lines {
+		c := byHash[l.hash]
+		fmt.Fprintf(&sb, "%*d %s %s %s | %s\n", width, l.number, l.hash, c.date, c.author, l.content)
+	}
+	sb.WriteString("\n<commits>\n")
+	for _, c := range commits {
+		fmt.Fprintf(&sb, "%s %s %s: %s\n", c.hash, c.date, c.author, c.summary)
+	}
+	sb.WriteString("</commits>\n")
+	return truncateOutput(sb.String())
+}
+
+// gitPath returns path relative to the working directory, or an error when
+// it is out of it.
+func gitPath(workingDir, path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	rel, ok := relativeTo(workingDir, filepathext.SmartJoin(workingDir, path))
+	if !ok {
+		return "", fmt.Errorf("path %s is outside the working directory", path)
+	}
+	return rel, nil
+}
+
+// checkRevision rejects revisions git would take for options.
+func checkRevision(revision string) error {
+	if strings.HasPrefix(revision, "-") {
+		return fmt.Errorf("invalid revision %q", revision)
+	}
+	return nil
+}
+
+// runGit runs git in dir and returns its output, or an error with what git
+// printed when it fails.
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	out, err := exec.CommandContext(ctx, "git", append([]string{"-C", dir, "--no-pager"}, args...)...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return string(out), nil
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
+		}
+	}
+	return true
+}
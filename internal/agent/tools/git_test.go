@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/require"
+)
+
+// gitRepo returns a repository with two commits to main.go, the second one
+// changing its second line.
+func gitRepo(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=Ada", "-c", "user.email=ada@example.com", "-c", "commit.gpgsign=false"}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE=2024-03-01T10:00:00Z", "GIT_COMMITTER_DATE=2024-03-01T10:00:00Z")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git("init", "-q")
+	writeFiles(t, dir, map[string]string{"main.go": "package main\n\nfunc main() {}\n"})
+	git("add", ".")
+	git("commit", "-q", "-m", "Add main")
+	writeFiles(t, dir, map[string]string{"main.go": "package main\n// Entry point.\nfunc main() {}\n"})
+	git("commit", "-q", "-am", "Document main", "-m", "The entry point was unclear.")
+	return dir
+}
+
+func TestGitLogTool(t *testing.T) {
+	t.Parallel()
+
+	dir := gitRepo(t)
+	tool := NewGitLogTool(dir)
+	run := func(params GitLogParams) fantasy.ToolResponse {
+		t.Helper()
+		input, err := json.Marshal(params)
+		require.NoError(t, err)
+		resp, err := tool.Run(t.Context(), fantasy.ToolCall{ID: "log", Name: GitLogToolName, Input: string(input)})
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := run(GitLogParams{})
+	require.False(t, resp.IsError)
+	require.Contains(t, resp.Content, "Author: Ada <ada@example.com>\nDate:   2024-03-01\n\n    Document main\n    \n    The entry point was unclear.")
+	var meta GitLogResponseMetadata
+	require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+	require.Equal(t, 2, meta.Commits)
+
+	resp = run(GitLogParams{Path: "main.go", StartLine: 2})
+	require.Contains(t, resp.Content, "+// Entry point.")
+
+	resp = run(GitLogParams{Grep: "^Add"})
+	require.Contains(t, resp.Content, "Add main")
+	require.NotContains(t, resp.Content, "Document main")
+
+	require.True(t, run(GitLogParams{Path: "../elsewhere"}).IsError)
+	require.True(t, run(GitLogParams{Revision: "--output=/tmp/x"}).IsError)
+}
+
+func TestGitBlameTool(t *testing.T) {
+	t.Parallel()
+
+	dir := gitRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n// Entry point.\nfunc main() { run() }\n"), 0o644))
+	tool := NewGitBlameTool(dir)
+	input, err := json.Marshal(GitBlameParams{Path: "main.go", StartLine: 2})
+	require.NoError(t, err)
+
+	resp, err := tool.Run(t.Context(), fantasy.ToolCall{ID: "blame", Name: GitBlameToolName, Input: string(input)})
+	require.NoError(t, err)
+	require.False(t, resp.IsError, resp.Content)
+	lines, commits := parseBlame(mustGit(t, dir, "blame", "--line-porcelain", "-L2,3", "--", "main.go"))
+	require.Len(t, lines, 2)
+	require.Equal(t, 2, lines[0].number)
+	require.Equal(t, "// Entry point.", lines[0].content)
+	require.Len(t, commits, 2)
+	require.Equal(t, blameCommit{hash: lines[0].hash, author: "Ada", date: "2024-03-01", summary: "Document main"}, commits[0])
+	require.Equal(t, "00000000", commits[1].hash, "changes that aren't committed are blamed on no commit")
+
+	require.Equal(t, formatBlame(lines, commits), resp.Content)
+	require.Contains(t, resp.Content, "2 "+lines[0].hash+" 2024-03-01 Ada | // Entry point.\n")
+	require.Contains(t, resp.Content, "<commits>\n"+lines[0].hash+" 2024-03-01 Ada: Document main\n")
+}
+
+func mustGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := runGit(t.Context(), dir, args...)
+	require.NoError(t, err)
+	return out
+}
@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/filepathext"
 	"github.com/charmbracelet/crush/internal/fsext"
 )
 
@@ -21,8 +22,9 @@ const GlobToolName = "glob"
 var globDescription []byte
 
 type GlobParams struct {
-	Pattern string `json:"pattern" description:"The glob pattern to match files against"`
-	Path    string `json:"path,omitempty" description:"The directory to search in. Defaults to the current working directory."`
+	Pattern  string `json:"pattern" description:"The glob pattern to match files against"`
+	Path     string `json:"path,omitempty" description:"The directory to search in. Defaults to the current working directory."`
+	NoIgnore bool   `json:"no_ignore,omitempty" description:"If true, files skipped by .gitignore, .crushignore and the ignore patterns are included. Default is false."`
 }
 
 type GlobResponseMetadata struct {
@@ -39,12 +41,12 @@ func NewGlobTool(workingDir string) fantasy.AgentTool {
 				return fantasy.NewTextErrorResponse("pattern is required"), nil
 			}
 
-			searchPath := params.Path
-			if searchPath == "" {
-				searchPath = workingDir
+			searchPath := workingDir
+			if params.Path != "" {
+				searchPath = filepathext.SmartJoin(workingDir, params.Path)
 			}
 
-			files, truncated, err := globFiles(ctx, params.Pattern, searchPath, 100)
+			files, truncated, err := globFiles(ctx, params.Pattern, searchPath, 100, params.NoIgnore)
 			if err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("error finding files: %w", err)
 			}
@@ -70,21 +72,22 @@ func NewGlobTool(workingDir string) fantasy.AgentTool {
 		})
 }
 
-func globFiles(ctx context.Context, pattern, searchPath string, limit int) ([]string, bool, error) {
+func globFiles(ctx context.Context, pattern, searchPath string, limit int, noIgnore bool) ([]string, bool, error) {
 	cmdRg := getRgCmd(ctx, pattern)
 	if cmdRg != nil {
 		cmdRg.Dir = searchPath
-		matches, err := runRipgrep(cmdRg, searchPath, limit)
+		cmdRg.Args = append(cmdRg.Args, rgIgnoreArgs(searchPath, noIgnore)...)
+		matches, err := runRipgrep(cmdRg, searchPath, limit, noIgnore)
 		if err == nil {
 			return matches, len(matches) >= limit && limit > 0, nil
 		}
 		slog.Warn("Ripgrep execution failed, falling back to doublestar", "error", err)
 	}
 
-	return fsext.GlobWithDoubleStar(pattern, searchPath, limit)
+	return fsext.GlobWithDoubleStar(pattern, searchPath, limit, noIgnore)
 }
 
-func runRipgrep(cmd *exec.Cmd, searchRoot string, limit int) ([]string, error) {
+func runRipgrep(cmd *exec.Cmd, searchRoot string, limit int, noIgnore bool) ([]string, error) {
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		if ee, ok := err.(*exec.ExitError); ok && ee.ExitCode() == 1 {
@@ -93,6 +96,7 @@ func runRipgrep(cmd *exec.Cmd, searchRoot string, limit int) ([]string, error) {
 		return nil, fmt.Errorf("ripgrep: %w\n%s", err, out)
 	}
 
+	walker := fsext.NewFastGlobWalker(searchRoot, noIgnore)
 	var matches []string
 	for p := range bytes.SplitSeq(out, []byte{0}) {
 		if len(p) == 0 {
@@ -102,7 +106,7 @@ func runRipgrep(cmd *exec.Cmd, searchRoot string, limit int) ([]string, error) {
 		if !filepath.IsAbs(absPath) {
 			absPath = filepath.Join(searchRoot, absPath)
 		}
-		if fsext.SkipHidden(absPath) {
+		if (!noIgnore && fsext.SkipHidden(absPath)) || walker.ShouldSkip(absPath) {
 			continue
 		}
 		matches = append(matches, absPath)
@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/filepathext"
 	"github.com/charmbracelet/crush/internal/fsext"
 )
 
@@ -77,6 +78,8 @@ type GrepParams struct {
 	Path        string `json:"path,omitempty" description:"The directory to search in. Defaults to the current working directory."`
 	Include     string `json:"include,omitempty" description:"File pattern to include in the search (e.g. \"*.js\", \"*.{ts,tsx}\")"`
 	LiteralText bool   `json:"literal_text,omitempty" description:"If true, the pattern will be treated as literal text with special regex characters escaped. Default is false."`
+	Multiline   bool   `json:"multiline,omitempty" description:"If true, the pattern can match across lines, with . matching newlines. Default is false."`
+	NoIgnore    bool   `json:"no_ignore,omitempty" description:"If true, files skipped by .gitignore, .crushignore and the ignore patterns are included. Default is false."`
 }
 
 type grepMatch struct {
@@ -87,9 +90,18 @@ type grepMatch struct {
 	lineText string
 }
 
+// GrepMatch is where a match starts, for the TUI to list and open.
+type GrepMatch struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Preview string `json:"preview"`
+}
+
 type GrepResponseMetadata struct {
-	NumberOfMatches int  `json:"number_of_matches"`
-	Truncated       bool `json:"truncated"`
+	NumberOfMatches int         `json:"number_of_matches"`
+	Truncated       bool        `json:"truncated"`
+	Matches         []GrepMatch `json:"matches,omitempty"`
 }
 
 const (
@@ -127,12 +139,12 @@ func NewGrepTool(workingDir string) fantasy.AgentTool {
 				searchPattern = escapeRegexPattern(params.Pattern)
 			}
 
-			searchPath := params.Path
-			if searchPath == "" {
-				searchPath = workingDir
+			searchPath := workingDir
+			if params.Path != "" {
+				searchPath = filepathext.SmartJoin(workingDir, params.Path)
 			}
 
-			matches, truncated, err := searchFiles(ctx, searchPattern, searchPath, params.Include, 100)
+			matches, truncated, err := searchFiles(ctx, searchPattern, searchPath, params.Include, 100, params.Multiline, params.NoIgnore)
 			if err != nil {
 				return fantasy.NewTextErrorResponse(fmt.Sprintf("error searching files: %v", err)), nil
 			}
@@ -172,20 +184,29 @@ func NewGrepTool(workingDir string) fantasy.AgentTool {
 				}
 			}
 
+			metadata := GrepResponseMetadata{
+				NumberOfMatches: len(matches),
+				Truncated:       truncated,
+			}
+			for _, match := range matches {
+				metadata.Matches = append(metadata.Matches, GrepMatch{
+					Path:    filepath.ToSlash(match.path),
+					Line:    match.lineNum,
+					Column:  match.charNum,
+					Preview: strings.TrimSpace(match.lineText),
+				})
+			}
 			return fantasy.WithResponseMetadata(
 				fantasy.NewTextResponse(output.String()),
-				GrepResponseMetadata{
-					NumberOfMatches: len(matches),
-					Truncated:       truncated,
-				},
+				metadata,
 			), nil
 		})
 }
 
-func searchFiles(ctx context.Context, pattern, rootPath, include string, limit int) ([]grepMatch, bool, error) {
-	matches, err := searchWithRipgrep(ctx, pattern, rootPath, include)
+func searchFiles(ctx context.Context, pattern, rootPath, include string, limit int, multiline, noIgnore bool) ([]grepMatch, bool, error) {
+	matches, err := searchWithRipgrep(ctx, pattern, rootPath, include, multiline, noIgnore)
 	if err != nil {
-		matches, err = searchFilesWithRegex(pattern, rootPath, include)
+		matches, err = searchFilesWithRegex(pattern, rootPath, include, multiline, noIgnore)
 		if err != nil {
 			return nil, false, err
 		}
@@ -203,19 +224,12 @@ func searchFiles(ctx context.Context, pattern, rootPath, include string, limit i
 	return matches, truncated, nil
 }
 
-func searchWithRipgrep(ctx context.Context, pattern, path, include string) ([]grepMatch, error) {
-	cmd := getRgSearchCmd(ctx, pattern, path, include)
+func searchWithRipgrep(ctx context.Context, pattern, path, include string, multiline, noIgnore bool) ([]grepMatch, error) {
+	cmd := getRgSearchCmd(ctx, pattern, path, include, multiline)
 	if cmd == nil {
 		return nil, fmt.Errorf("ripgrep not found in $PATH")
 	}
-
-	// Only add ignore files if they exist
-	for _, ignoreFile := range []string{".gitignore", ".crushignore"} {
-		ignorePath := filepath.Join(path, ignoreFile)
-		if _, err := os.Stat(ignorePath); err == nil {
-			cmd.Args = append(cmd.Args, "--ignore-file", ignorePath)
-		}
-	}
+	cmd.Args = append(cmd.Args, rgIgnoreArgs(path, noIgnore)...)
 
 	output, err := cmd.Output()
 	if err != nil {
@@ -225,6 +239,7 @@ func searchWithRipgrep(ctx context.Context, pattern, path, include string) ([]gr
 		return nil, err
 	}
 
+	walker := fsext.NewFastGlobWalker(path, noIgnore)
 	var matches []grepMatch
 	for line := range bytes.SplitSeq(bytes.TrimSpace(output), []byte{'\n'}) {
 		if len(line) == 0 {
@@ -234,7 +249,7 @@ func searchWithRipgrep(ctx context.Context, pattern, path, include string) ([]gr
 		if err := json.Unmarshal(line, &match); err != nil {
 			continue
 		}
-		if match.Type != "match" {
+		if match.Type != "match" || walker.ShouldSkip(match.Data.Path.Text) {
 			continue
 		}
 		for _, m := range match.Data.Submatches {
@@ -242,12 +257,14 @@ func searchWithRipgrep(ctx context.Context, pattern, path, include string) ([]gr
 			if err != nil {
 				continue // Skip files we can't access
 			}
+			// Multiline matches span several lines; show the first one.
+			lineText, _, _ := strings.Cut(match.Data.Lines.Text, "\n")
 			matches = append(matches, grepMatch{
 				path:     match.Data.Path.Text,
 				modTime:  fi.ModTime(),
 				lineNum:  match.Data.LineNumber,
 				charNum:  m.Start + 1, // ensure 1-based
-				lineText: strings.TrimSpace(match.Data.Lines.Text),
+				lineText: strings.TrimSpace(lineText),
 			})
 			// only get the first match of each line
 			break
@@ -272,9 +289,12 @@ type ripgrepMatch struct {
 	} `json:"data"`
 }
 
-func searchFilesWithRegex(pattern, rootPath, include string) ([]grepMatch, error) {
+func searchFilesWithRegex(pattern, rootPath, include string, multiline, noIgnore bool) ([]grepMatch, error) {
 	matches := []grepMatch{}
 
+	if multiline {
+		pattern = "(?s)" + pattern
+	}
 	// Use cached regex compilation
 	regex, err := searchRegexCache.get(pattern)
 	if err != nil {
@@ -291,7 +311,7 @@ func searchFilesWithRegex(pattern, rootPath, include string) ([]grepMatch, error
 	}
 
 	// Create walker with gitignore and crushignore support
-	walker := fsext.NewFastGlobWalker(rootPath)
+	walker := fsext.NewFastGlobWalker(rootPath, noIgnore)
 
 	err = filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -321,7 +341,11 @@ func searchFilesWithRegex(pattern, rootPath, include string) ([]grepMatch, error
 			return nil
 		}
 
-		match, lineNum, charNum, lineText, err := fileContainsPattern(path, regex)
+		find := fileContainsPattern
+		if multiline {
+			find = fileContainsMultilinePattern
+		}
+		match, lineNum, charNum, lineText, err := find(path, regex)
 		if err != nil {
 			return nil // Skip files we can't read
 		}
@@ -375,6 +399,28 @@ func fileContainsPattern(filePath string, pattern *regexp.Regexp) (bool, int, in
 	return false, 0, 0, "", scanner.Err()
 }
 
+// fileContainsMultilinePattern is like fileContainsPattern, for patterns
+// that can match across lines.
+func fileContainsMultilinePattern(filePath string, pattern *regexp.Regexp) (bool, int, int, string, error) {
+	// Only search text files.
+	if !isTextFile(filePath) {
+		return false, 0, 0, "", nil
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return false, 0, 0, "", err
+	}
+	loc := pattern.FindIndex(content)
+	if loc == nil {
+		return false, 0, 0, "", nil
+	}
+	lineStart := bytes.LastIndexByte(content[:loc[0]], '\n') + 1
+	line, _, _ := bytes.Cut(content[lineStart:], []byte{'\n'})
+	lineNum := bytes.Count(content[:loc[0]], []byte{'\n'}) + 1
+	return true, lineNum, loc[0] - lineStart + 1, strings.TrimRight(string(line), "\r"), nil
+}
+
 // isTextFile checks if a file is a text file by examining its MIME type.
 func isTextFile(filePath string) bool {
 	file, err := os.Open(filePath)
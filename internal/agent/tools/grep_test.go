@@ -1,11 +1,13 @@
 package tools
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"regexp"
 	"testing"
 
+	"charm.land/fantasy"
 	"github.com/stretchr/testify/require"
 )
 
@@ -85,9 +87,11 @@ func TestGrepWithIgnoreFiles(t *testing.T) {
 
 	// Test both implementations
 	for name, fn := range map[string]func(pattern, path, include string) ([]grepMatch, error){
-		"regex": searchFilesWithRegex,
+		"regex": func(pattern, path, include string) ([]grepMatch, error) {
+			return searchFilesWithRegex(pattern, path, include, false, false)
+		},
 		"rg": func(pattern, path, include string) ([]grepMatch, error) {
-			return searchWithRipgrep(t.Context(), pattern, path, include)
+			return searchWithRipgrep(t.Context(), pattern, path, include, false, false)
 		},
 	} {
 		t.Run(name, func(t *testing.T) {
@@ -121,6 +125,86 @@ func TestGrepWithIgnoreFiles(t *testing.T) {
 	}
 }
 
+func TestGrepNoIgnore(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+
+	for path, content := range map[string]string{
+		"file1.txt":           "hello world",
+		"ignored/file2.txt":   "hello world",
+		"node_modules/lib.js": "hello world",
+	} {
+		fullPath := filepath.Join(tempDir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0o755))
+		require.NoError(t, os.WriteFile(fullPath, []byte(content), 0o644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, ".crushignore"), []byte("ignored/\n"), 0o644))
+
+	matches, err := searchFilesWithRegex("hello world", tempDir, "", false, false)
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+
+	matches, err = searchFilesWithRegex("hello world", tempDir, "", false, true)
+	require.NoError(t, err)
+	require.Len(t, matches, 3, "ignored files are included")
+}
+
+func TestGrepMultiline(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+	content := "package main\n\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "main.go"), []byte(content), 0o644))
+
+	for name, fn := range map[string]func(pattern, path string, multiline bool) ([]grepMatch, error){
+		"regex": func(pattern, path string, multiline bool) ([]grepMatch, error) {
+			return searchFilesWithRegex(pattern, path, "", multiline, false)
+		},
+		"rg": func(pattern, path string, multiline bool) ([]grepMatch, error) {
+			return searchWithRipgrep(t.Context(), pattern, path, "", multiline, false)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if name == "rg" && getRg() == "" {
+				t.Skip("rg is not in $PATH")
+			}
+
+			matches, err := fn(`main\(\) \{.*Println`, tempDir, false)
+			require.NoError(t, err)
+			require.Empty(t, matches)
+
+			matches, err = fn(`main\(\) \{.*Println`, tempDir, true)
+			require.NoError(t, err)
+			require.Len(t, matches, 1)
+			require.Equal(t, 3, matches[0].lineNum)
+			require.Equal(t, 6, matches[0].charNum)
+			require.Equal(t, "func main() {", matches[0].lineText)
+		})
+	}
+}
+
+func TestGrepResponseMatches(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "a.txt"), []byte("one\n  hello world\n"), 0o644))
+
+	input, err := json.Marshal(GrepParams{Pattern: "world"})
+	require.NoError(t, err)
+	resp, err := NewGrepTool(tempDir).Run(t.Context(), fantasy.ToolCall{ID: "call", Name: GrepToolName, Input: string(input)})
+	require.NoError(t, err)
+
+	var meta GrepResponseMetadata
+	require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+	require.Len(t, meta.Matches, 1)
+	require.Equal(t, GrepMatch{
+		Path:    filepath.ToSlash(filepath.Join(tempDir, "a.txt")),
+		Line:    2,
+		Column:  9,
+		Preview: "hello world",
+	}, meta.Matches[0])
+}
+
 func TestSearchImplementations(t *testing.T) {
 	t.Parallel()
 	tempDir := t.TempDir()
@@ -145,9 +229,11 @@ func TestSearchImplementations(t *testing.T) {
 	require.NoError(t, os.WriteFile(filepath.Join(tempDir, ".crushignore"), []byte("file5.txt\n"), 0o644))
 
 	for name, fn := range map[string]func(pattern, path, include string) ([]grepMatch, error){
-		"regex": searchFilesWithRegex,
+		"regex": func(pattern, path, include string) ([]grepMatch, error) {
+			return searchFilesWithRegex(pattern, path, include, false, false)
+		},
 		"rg": func(pattern, path, include string) ([]grepMatch, error) {
-			return searchWithRipgrep(t.Context(), pattern, path, include)
+			return searchWithRipgrep(t.Context(), pattern, path, include, false, false)
 		},
 	} {
 		t.Run(name, func(t *testing.T) {
@@ -396,9 +482,11 @@ func TestColumnMatch(t *testing.T) {
 
 	// Test both implementations
 	for name, fn := range map[string]func(pattern, path, include string) ([]grepMatch, error){
-		"regex": searchFilesWithRegex,
+		"regex": func(pattern, path, include string) ([]grepMatch, error) {
+			return searchFilesWithRegex(pattern, path, include, false, false)
+		},
 		"rg": func(pattern, path, include string) ([]grepMatch, error) {
-			return searchWithRipgrep(t.Context(), pattern, path, include)
+			return searchWithRipgrep(t.Context(), pattern, path, include, false, false)
 		},
 	} {
 		t.Run(name, func(t *testing.T) {
@@ -17,15 +17,17 @@ import (
 )
 
 type LSParams struct {
-	Path   string   `json:"path,omitempty" description:"The path to the directory to list (defaults to current working directory)"`
-	Ignore []string `json:"ignore,omitempty" description:"List of glob patterns to ignore"`
-	Depth  int      `json:"depth,omitempty" description:"The maximum depth to traverse"`
+	Path     string   `json:"path,omitempty" description:"The path to the directory to list (defaults to current working directory)"`
+	Ignore   []string `json:"ignore,omitempty" description:"List of glob patterns to ignore"`
+	Depth    int      `json:"depth,omitempty" description:"The maximum depth to traverse"`
+	NoIgnore bool     `json:"no_ignore,omitempty" description:"If true, files skipped by .gitignore, .crushignore and the ignore patterns are included. Default is false."`
 }
 
 type LSPermissionsParams struct {
-	Path   string   `json:"path"`
-	Ignore []string `json:"ignore"`
-	Depth  int      `json:"depth"`
+	Path     string   `json:"path"`
+	Ignore   []string `json:"ignore"`
+	Depth    int      `json:"depth"`
+	NoIgnore bool     `json:"no_ignore"`
 }
 
 type TreeNode struct {
@@ -53,7 +55,7 @@ func NewLsTool(permissions permission.Service, workingDir string, lsConfig confi
 		LSToolName,
 		string(lsDescription),
 		func(ctx context.Context, params LSParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
-			searchPath, err := fsext.Expand(cmp.Or(params.Path, workingDir))
+			searchPath, err := fsext.Expand(filepath.ToSlash(cmp.Or(params.Path, workingDir)))
 			if err != nil {
 				return fantasy.NewTextErrorResponse(fmt.Sprintf("error expanding path: %v", err)), nil
 			}
@@ -120,6 +122,7 @@ func ListDirectoryTree(searchPath string, params LSParams, lsConfig config.ToolL
 		params.Ignore,
 		cmp.Or(params.Depth, depth),
 		maxFiles,
+		params.NoIgnore,
 	)
 	if err != nil {
 		return "", LSResponseMetadata{}, fmt.Errorf("error listing directory: %w", err)
@@ -104,7 +104,7 @@ func (m *Tool) Run(ctx context.Context, params fantasy.ToolCall) (fantasy.ToolRe
 		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
 	}
 
-	content, err := mcp.RunTool(ctx, m.mcpName, m.tool.Name, params.Input)
+	content, err := mcp.RunTool(ctx, mcp.Caller{SessionID: sessionID, ToolCallID: params.ID}, m.mcpName, m.tool.Name, params.Input)
 	if err != nil {
 		return fantasy.NewTextErrorResponse(err.Error()), nil
 	}
@@ -20,6 +20,8 @@ import (
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/httpext"
+	"github.com/charmbracelet/crush/internal/keychain"
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/version"
@@ -40,6 +42,12 @@ const (
 	StateStarting
 	StateConnected
 	StateError
+	// StateDegraded means the server dropped the connection, and Crush is
+	// reconnecting to it. Its tools stay available meanwhile.
+	StateDegraded
+	// StateOffline means Crush couldn't reconnect to the server for a while.
+	// Its tools are removed, and reconnecting goes on less often.
+	StateOffline
 )
 
 func (s State) String() string {
@@ -52,6 +60,10 @@ func (s State) String() string {
 		return "connected"
 	case StateError:
 		return "error"
+	case StateDegraded:
+		return "degraded"
+	case StateOffline:
+		return "offline"
 	default:
 		return "unknown"
 	}
@@ -89,6 +101,11 @@ type ClientInfo struct {
 	Client      *mcp.ClientSession
 	Counts      Counts
 	ConnectedAt time.Time
+	// Reconnects is the number of failed attempts to reconnect to a
+	// degraded or offline server so far.
+	Reconnects int
+	// RetryAt is when the next attempt to reconnect is made.
+	RetryAt time.Time
 }
 
 // SubscribeEvents returns a channel for MCP events
@@ -108,8 +125,12 @@ func GetState(name string) (ClientInfo, bool) {
 
 // Close closes all MCP clients. This should be called during application shutdown.
 func Close() error {
+	stopReconnecting("")
 	var errs []error
 	for name, session := range sessions.Seq2() {
+		// Forget about the session first, for its watcher not to restart the
+		// server.
+		sessions.Del(name)
 		if err := session.Close(); err != nil &&
 			!errors.Is(err, io.EOF) &&
 			!errors.Is(err, context.Canceled) &&
@@ -122,7 +143,9 @@ func Close() error {
 }
 
 // Initialize initializes MCP clients based on the provided configuration.
-func Initialize(ctx context.Context, permissions permission.Service, cfg *config.Config) {
+func Initialize(ctx context.Context, perms permission.Service, cfg *config.Config) {
+	// Sampling requests ask for permission.
+	permissions.Store(&perms)
 	var wg sync.WaitGroup
 	// Initialize states for all configured MCPs
 	for name, m := range cfg.MCP {
@@ -135,69 +158,131 @@ func Initialize(ctx context.Context, permissions permission.Service, cfg *config
 		// Set initial starting state
 		updateState(name, StateStarting, nil, nil, Counts{})
 
-		wg.Add(1)
-		go func(name string, m config.MCPConfig) {
-			defer func() {
-				wg.Done()
-				if r := recover(); r != nil {
-					var err error
-					switch v := r.(type) {
-					case error:
-						err = v
-					case string:
-						err = fmt.Errorf("panic: %s", v)
-					default:
-						err = fmt.Errorf("panic: %v", v)
-					}
-					updateState(name, StateError, err, nil, Counts{})
-					slog.Error("panic in mcp client initialization", "error", err, "name", name)
-				}
-			}()
-
-			// createSession handles its own timeout internally.
-			session, err := createSession(ctx, name, m, cfg.Resolver())
-			if err != nil {
-				return
-			}
+		wg.Go(func() {
+			startClient(ctx, cfg, name, m)
+		})
+	}
+	wg.Wait()
+}
 
-			tools, err := getTools(ctx, session)
-			if err != nil {
-				slog.Error("error listing tools", "error", err)
-				updateState(name, StateError, err, nil, Counts{})
-				session.Close()
-				return
-			}
+// Restart stops the client of the named MCP server and starts it again with
+// its current configuration, or leaves it stopped if it was disabled or
+// removed from the configuration.
+func Restart(ctx context.Context, cfg *config.Config, name string) {
+	stopClient(name)
+	m, ok := cfg.MCP[name]
+	switch {
+	case !ok:
+		return
+	case m.Disabled:
+		updateState(name, StateDisabled, nil, nil, Counts{})
+		return
+	}
+	updateState(name, StateStarting, nil, nil, Counts{})
+	startClient(ctx, cfg, name, m)
+}
 
-			prompts, err := getPrompts(ctx, session)
-			if err != nil {
-				slog.Error("error listing prompts", "error", err)
-				updateState(name, StateError, err, nil, Counts{})
-				session.Close()
-				return
+// stopClient closes the session of an MCP client and forgets about its
+// tools, prompts and state.
+func stopClient(name string) {
+	stopReconnecting(name)
+	crashes.Del(name)
+	if session, ok := sessions.Take(name); ok {
+		if err := session.Close(); err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, context.Canceled) {
+			slog.Warn("Failed to close mcp client", "name", name, "error", err)
+		}
+	}
+	updateTools(name, nil)
+	updatePrompts(name, nil)
+	if _, ok := states.Take(name); ok {
+		broker.Publish(pubsub.DeletedEvent, Event{
+			Type:  EventStateChanged,
+			Name:  name,
+			State: StateDisabled,
+		})
+	}
+}
+
+// startClient connects to an MCP server and lists its tools and prompts.
+func startClient(ctx context.Context, cfg *config.Config, name string, m config.MCPConfig) {
+	defer func() {
+		if r := recover(); r != nil {
+			var err error
+			switch v := r.(type) {
+			case error:
+				err = v
+			case string:
+				err = fmt.Errorf("panic: %s", v)
+			default:
+				err = fmt.Errorf("panic: %v", v)
 			}
+			updateState(name, StateError, err, nil, Counts{})
+			slog.Error("panic in mcp client initialization", "error", err, "name", name)
+		}
+	}()
 
-			updateTools(name, tools)
-			updatePrompts(name, prompts)
-			sessions.Set(name, session)
+	if cfg.Options.Offline && (m.Type == config.MCPHttp || m.Type == config.MCPSSE) {
+		updateState(name, StateError, fmt.Errorf("can't connect to remote MCP server: %w", config.ErrOffline), nil, Counts{})
+		return
+	}
+	session, tools, prompts, err := dial(ctx, cfg, name, m)
+	if err != nil {
+		updateState(name, StateError, err, nil, Counts{})
+		return
+	}
+	addClient(ctx, cfg, name, m, session, tools, prompts)
+}
 
-			updateState(name, StateConnected, nil, session, Counts{
-				Tools:   len(tools),
-				Prompts: len(prompts),
-			})
-		}(name, m)
+// dial connects to an MCP server and lists its tools and prompts.
+func dial(ctx context.Context, cfg *config.Config, name string, m config.MCPConfig) (*mcp.ClientSession, []*Tool, []*Prompt, error) {
+	// connect handles its own timeout internally.
+	session, err := connect(ctx, name, m, cfg.Resolver(), dataDirectory(cfg))
+	if err != nil {
+		return nil, nil, nil, err
 	}
-	wg.Wait()
+
+	tools, err := getTools(ctx, session)
+	if err != nil {
+		slog.Error("error listing tools", "error", err)
+		session.Close()
+		return nil, nil, nil, err
+	}
+
+	prompts, err := getPrompts(ctx, session)
+	if err != nil {
+		slog.Error("error listing prompts", "error", err)
+		session.Close()
+		return nil, nil, nil, err
+	}
+	return session, tools, prompts, nil
+}
+
+// addClient makes the tools and prompts of a connected MCP server
+// available, and watches its connection to reconnect when it drops.
+func addClient(ctx context.Context, cfg *config.Config, name string, m config.MCPConfig, session *mcp.ClientSession, tools []*Tool, prompts []*Prompt) {
+	updateTools(name, tools)
+	updatePrompts(name, prompts)
+	sessions.Set(name, session)
+
+	updateState(name, StateConnected, nil, session, Counts{
+		Tools:   len(tools),
+		Prompts: len(prompts),
+	})
+	go watch(ctx, cfg, name, m, session)
 }
 
 func getOrRenewClient(ctx context.Context, name string) (*mcp.ClientSession, error) {
 	sess, ok := sessions.Get(name)
 	if !ok {
+		if state, _ := states.Get(name); state.State == StateDegraded {
+			m := config.Get().MCP[name]
+			return nil, fmt.Errorf("mcp '%s' lost its connection and is %s", name, recovering(m))
+		}
 		return nil, fmt.Errorf("mcp '%s' not available", name)
 	}
 
 	cfg := config.Get()
 	m := cfg.MCP[name]
-	state, _ := states.Get(name)
 
 	timeout := mcpTimeout(m)
 	pingCtx, cancel := context.WithTimeout(ctx, timeout)
@@ -206,89 +291,120 @@ func getOrRenewClient(ctx context.Context, name string) (*mcp.ClientSession, err
 	if err == nil {
 		return sess, nil
 	}
-	updateState(name, StateError, maybeTimeoutErr(err, timeout), nil, state.Counts)
-
-	sess, err = createSession(ctx, name, m, cfg.Resolver())
-	if err != nil {
-		return nil, err
-	}
-
-	updateState(name, StateConnected, nil, sess, state.Counts)
-	sessions.Set(name, sess)
-	return sess, nil
+	return renewClient(ctx, cfg, name, m, sess, maybeTimeoutErr(err, timeout))
 }
 
 // updateState updates the state of an MCP client and publishes an event
 func updateState(name string, state State, err error, client *mcp.ClientSession, counts Counts) {
-	info := ClientInfo{
+	setState(ClientInfo{
 		Name:   name,
 		State:  state,
 		Error:  err,
 		Client: client,
 		Counts: counts,
-	}
-	switch state {
+	})
+}
+
+// setState sets the state of an MCP client and publishes an event.
+func setState(info ClientInfo) {
+	switch info.State {
 	case StateConnected:
 		info.ConnectedAt = time.Now()
-	case StateError:
-		sessions.Del(name)
+	case StateError, StateDegraded, StateOffline:
+		sessions.Del(info.Name)
 	}
-	states.Set(name, info)
+	states.Set(info.Name, info)
 
 	// Publish state change event
 	broker.Publish(pubsub.UpdatedEvent, Event{
 		Type:   EventStateChanged,
-		Name:   name,
-		State:  state,
-		Error:  err,
-		Counts: counts,
+		Name:   info.Name,
+		State:  info.State,
+		Error:  info.Error,
+		Counts: info.Counts,
 	})
 }
 
-func createSession(ctx context.Context, name string, m config.MCPConfig, resolver config.VariableResolver) (*mcp.ClientSession, error) {
+// Test connects to an MCP server that doesn't have to be configured yet,
+// returning how many tools and prompts it has.
+func Test(ctx context.Context, name string, m config.MCPConfig, resolver config.VariableResolver) (Counts, error) {
+	session, err := connect(ctx, name, m, resolver, "")
+	if err != nil {
+		return Counts{}, err
+	}
+	defer session.Close()
+
+	tools, err := getTools(ctx, session)
+	if err != nil {
+		return Counts{}, fmt.Errorf("failed to list tools: %w", err)
+	}
+	prompts, err := getPrompts(ctx, session)
+	if err != nil {
+		return Counts{}, fmt.Errorf("failed to list prompts: %w", err)
+	}
+	return Counts{Tools: len(tools), Prompts: len(prompts)}, nil
+}
+
+// connect starts a session with an MCP server. The stderr of servers started
+// with a command is appended to their log in dataDir, unless it's empty.
+func connect(ctx context.Context, name string, m config.MCPConfig, resolver config.VariableResolver, dataDir string) (*mcp.ClientSession, error) {
 	timeout := mcpTimeout(m)
 	mcpCtx, cancel := context.WithCancel(ctx)
 	cancelTimer := time.AfterFunc(timeout, cancel)
 
 	transport, err := createTransport(mcpCtx, m, resolver)
 	if err != nil {
-		updateState(name, StateError, err, nil, Counts{})
 		slog.Error("error creating mcp client", "error", err, "name", name)
 		cancel()
 		cancelTimer.Stop()
 		return nil, err
 	}
 
+	opts := &mcp.ClientOptions{
+		ToolListChangedHandler: func(context.Context, *mcp.ToolListChangedRequest) {
+			broker.Publish(pubsub.UpdatedEvent, Event{
+				Type: EventToolsListChanged,
+				Name: name,
+			})
+		},
+		PromptListChangedHandler: func(context.Context, *mcp.PromptListChangedRequest) {
+			broker.Publish(pubsub.UpdatedEvent, Event{
+				Type: EventPromptsListChanged,
+				Name: name,
+			})
+		},
+		LoggingMessageHandler: func(_ context.Context, req *mcp.LoggingMessageRequest) {
+			slog.Info("mcp log", "name", name, "data", req.Params.Data)
+		},
+		KeepAlive: keepAlive(m),
+	}
+	// Handling sampling requests advertises the capability to the server.
+	if m.Sampling {
+		opts.CreateMessageHandler = func(ctx context.Context, req *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+			return sample(ctx, name, req.Params)
+		}
+	}
 	client := mcp.NewClient(
 		&mcp.Implementation{
 			Name:    "crush",
 			Version: version.Version,
 			Title:   "Crush",
 		},
-		&mcp.ClientOptions{
-			ToolListChangedHandler: func(context.Context, *mcp.ToolListChangedRequest) {
-				broker.Publish(pubsub.UpdatedEvent, Event{
-					Type: EventToolsListChanged,
-					Name: name,
-				})
-			},
-			PromptListChangedHandler: func(context.Context, *mcp.PromptListChangedRequest) {
-				broker.Publish(pubsub.UpdatedEvent, Event{
-					Type: EventPromptsListChanged,
-					Name: name,
-				})
-			},
-			LoggingMessageHandler: func(_ context.Context, req *mcp.LoggingMessageRequest) {
-				slog.Info("mcp log", "name", name, "data", req.Params.Data)
-			},
-			KeepAlive: time.Minute * 10,
-		},
+		opts,
 	)
 
+	if ct, ok := transport.(*mcp.CommandTransport); ok && dataDir != "" {
+		if f, err := openLog(dataDir, name); err != nil {
+			slog.Warn("failed to open mcp server log", "name", name, "error", err)
+		} else {
+			// The process gets its own copy of the file once started.
+			defer f.Close()
+			ct.Command.Stderr = f
+		}
+	}
 	session, err := client.Connect(mcpCtx, transport, nil)
 	if err != nil {
-		err = maybeStdioErr(err, transport)
-		updateState(name, StateError, maybeTimeoutErr(err, timeout), nil, Counts{})
+		err = maybeTimeoutErr(maybeStdioErr(err, transport), timeout)
 		slog.Error("error starting mcp client", "error", err, "name", name)
 		cancel()
 		cancelTimer.Stop()
@@ -347,9 +463,13 @@ func createTransport(ctx context.Context, m config.MCPConfig, resolver config.Va
 		if strings.TrimSpace(m.URL) == "" {
 			return nil, fmt.Errorf("mcp http config requires a non-empty 'url' field")
 		}
+		headers, err := resolvedHeaders(m)
+		if err != nil {
+			return nil, err
+		}
 		client := &http.Client{
 			Transport: &headerRoundTripper{
-				headers: m.ResolvedHeaders(),
+				headers: headers,
 			},
 		}
 		return &mcp.StreamableClientTransport{
@@ -360,9 +480,13 @@ func createTransport(ctx context.Context, m config.MCPConfig, resolver config.Va
 		if strings.TrimSpace(m.URL) == "" {
 			return nil, fmt.Errorf("mcp sse config requires a non-empty 'url' field")
 		}
+		headers, err := resolvedHeaders(m)
+		if err != nil {
+			return nil, err
+		}
 		client := &http.Client{
 			Transport: &headerRoundTripper{
-				headers: m.ResolvedHeaders(),
+				headers: headers,
 			},
 		}
 		return &mcp.SSEClientTransport{
@@ -374,6 +498,16 @@ func createTransport(ctx context.Context, m config.MCPConfig, resolver config.Va
 	}
 }
 
+// resolvedHeaders returns the headers of m, with a hint on storing their
+// secrets when the OS keychain doesn't have them.
+func resolvedHeaders(m config.MCPConfig) (map[string]string, error) {
+	headers, err := m.ResolvedHeaders()
+	if errors.Is(err, keychain.ErrNotFound) {
+		return nil, fmt.Errorf("%w, store it with crush mcp login", err)
+	}
+	return headers, err
+}
+
 type headerRoundTripper struct {
 	headers map[string]string
 }
@@ -382,7 +516,16 @@ func (rt headerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error
 	for k, v := range rt.headers {
 		req.Header.Set(k, v)
 	}
-	return http.DefaultTransport.RoundTrip(req)
+	return httpext.DefaultTransport().RoundTrip(req)
+}
+
+// keepAlive returns how often the server is pinged. Remote servers are
+// pinged more often, to notice sooner when they drop the connection.
+func keepAlive(m config.MCPConfig) time.Duration {
+	if m.Type == config.MCPStdio {
+		return time.Minute * 10
+	}
+	return time.Second * 30
 }
 
 func mcpTimeout(m config.MCPConfig) time.Duration {
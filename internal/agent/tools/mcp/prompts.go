@@ -47,6 +47,10 @@ func GetPromptMessages(ctx context.Context, clientName, promptName string, args
 // RefreshPrompts gets the updated list of prompts from the MCP and updates the
 // global state.
 func RefreshPrompts(ctx context.Context, name string) {
+	if isReconnecting(name) {
+		slog.Debug("refresh prompts: reconnecting, they're listed once reconnected", "name", name)
+		return
+	}
 	session, ok := sessions.Get(name)
 	if !ok {
 		slog.Warn("refresh prompts: no session", "name", name)
@@ -0,0 +1,199 @@
+package mcp
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+var (
+	// reconnectBaseDelay is the wait before the first attempt to reconnect,
+	// doubled after each failed one up to reconnectMaxDelay.
+	reconnectBaseDelay = time.Second
+	reconnectMaxDelay  = 2 * time.Minute
+)
+
+// offlineAfter is the number of failed attempts to reconnect after which a
+// server is offline.
+const offlineAfter = 5
+
+var (
+	reconnectsMu sync.Mutex
+	// reconnects holds the function stopping the reconnection of each server
+	// Crush is reconnecting to.
+	reconnects = map[string]context.CancelFunc{}
+)
+
+// watch waits for the session of a server to end, and reconnects to the
+// server, or restarts it when it was started with a command, when it wasn't
+// Crush that ended it. Servers crashing more than stdioMaxRestarts times in a
+// row aren't restarted anymore.
+func watch(ctx context.Context, cfg *config.Config, name string, m config.MCPConfig, session *mcp.ClientSession) {
+	err := session.Wait()
+	if current, ok := sessions.Get(name); !ok || current != session || ctx.Err() != nil {
+		return
+	}
+	if m.Type != config.MCPStdio {
+		reconnect(ctx, cfg, name, m, cmp.Or(err, errors.New("connection closed")))
+		return
+	}
+
+	err = cmp.Or(err, errors.New("process exited"))
+	info, _ := states.Get(name)
+	if n := countCrash(name, info.ConnectedAt); n > stdioMaxRestarts {
+		giveUp(name, fmt.Errorf("crashed %d times in a row: %w", n, err))
+		return
+	}
+	reconnect(ctx, cfg, name, m, err)
+}
+
+// renewClient replaces the session of a server that stopped answering pings,
+// restarting it when it was started with a command. When the server can't be
+// reached, reconnecting goes on in the background.
+func renewClient(ctx context.Context, cfg *config.Config, name string, m config.MCPConfig, old *mcp.ClientSession, cause error) (*mcp.ClientSession, error) {
+	// Forget about the old session first, for its watcher not to reconnect
+	// too.
+	sessions.Del(name)
+	old.Close()
+	slog.Warn("mcp server stopped answering, "+recovering(m), "name", name, "error", cause)
+
+	// The session outlives the tool call renewing it.
+	ctx = context.WithoutCancel(ctx)
+	session, tools, prompts, err := dial(ctx, cfg, name, m)
+	if err != nil {
+		go reconnect(ctx, cfg, name, m, err)
+		return nil, fmt.Errorf("mcp '%s' stopped answering and is %s: %w", name, recovering(m), err)
+	}
+	addClient(ctx, cfg, name, m, session, tools, prompts)
+	return session, nil
+}
+
+// reconnect connects to a server again after it dropped the connection,
+// waiting twice as long after each failed attempt. The server is degraded,
+// keeping its tools, until offlineAfter attempts failed, and then offline
+// until it can be reached again or it's stopped. Servers started with a
+// command are given up on after stdioMaxRestarts failed attempts instead.
+//
+// Changes to the tools and prompts of the server meanwhile aren't refreshed
+// on their own: they're all listed again once reconnected.
+func reconnect(ctx context.Context, cfg *config.Config, name string, m config.MCPConfig, cause error) {
+	reconnectsMu.Lock()
+	if _, ok := reconnects[name]; ok {
+		reconnectsMu.Unlock()
+		return
+	}
+	waitCtx, cancel := context.WithCancel(ctx)
+	reconnects[name] = cancel
+	reconnectsMu.Unlock()
+
+	slog.Warn("mcp server dropped the connection, "+recovering(m), "name", name, "error", cause)
+	prev, _ := states.Get(name)
+	counts := prev.Counts
+	for attempt := 0; ; attempt++ {
+		if m.Type == config.MCPStdio && attempt >= stdioMaxRestarts {
+			reconnectsMu.Lock()
+			if waitCtx.Err() != nil {
+				reconnectsMu.Unlock()
+				return
+			}
+			delete(reconnects, name)
+			reconnectsMu.Unlock()
+			cancel()
+			giveUp(name, fmt.Errorf("failed to restart %d times in a row: %w", attempt, cause))
+			return
+		}
+		state := StateDegraded
+		if attempt >= offlineAfter {
+			state = StateOffline
+			if attempt == offlineAfter {
+				updateTools(name, nil)
+				updatePrompts(name, nil)
+				counts = Counts{}
+			}
+		}
+		delay := reconnectDelay(attempt)
+		setState(ClientInfo{
+			Name:       name,
+			State:      state,
+			Error:      cause,
+			Counts:     counts,
+			Reconnects: attempt,
+			RetryAt:    time.Now().Add(delay),
+		})
+
+		select {
+		case <-waitCtx.Done():
+			return
+		case <-time.After(delay):
+		}
+		session, tools, prompts, err := dial(ctx, cfg, name, m)
+		if err != nil {
+			cause = err
+			continue
+		}
+
+		reconnectsMu.Lock()
+		if waitCtx.Err() != nil {
+			// The server was stopped while connecting to it.
+			reconnectsMu.Unlock()
+			session.Close()
+			return
+		}
+		delete(reconnects, name)
+		reconnectsMu.Unlock()
+		cancel()
+
+		slog.Info("Reconnected mcp client", "name", name, "attempts", attempt+1)
+		addClient(ctx, cfg, name, m, session, tools, prompts)
+		return
+	}
+}
+
+// isReconnecting tells whether Crush is reconnecting to the named server.
+func isReconnecting(name string) bool {
+	reconnectsMu.Lock()
+	defer reconnectsMu.Unlock()
+	_, ok := reconnects[name]
+	return ok
+}
+
+// stopReconnecting stops reconnecting to the named server, or to every
+// server if name is empty.
+func stopReconnecting(name string) {
+	reconnectsMu.Lock()
+	defer reconnectsMu.Unlock()
+	for n, cancel := range reconnects {
+		if name == "" || n == name {
+			cancel()
+			delete(reconnects, n)
+		}
+	}
+}
+
+// reconnectDelay returns the wait before the given attempt to reconnect,
+// counting from 0.
+func reconnectDelay(attempt int) time.Duration {
+	delay := reconnectBaseDelay
+	for range attempt {
+		delay *= 2
+		if delay >= reconnectMaxDelay {
+			return reconnectMaxDelay
+		}
+	}
+	return delay
+}
+
+// recovering tells what Crush does when the connection to a server drops.
+func recovering(m config.MCPConfig) string {
+	if m.Type == config.MCPStdio {
+		return "restarting"
+	}
+	return "reconnecting"
+}
@@ -0,0 +1,78 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconnectDelay(t *testing.T) {
+	require.Equal(t, time.Second, reconnectDelay(0))
+	require.Equal(t, 8*time.Second, reconnectDelay(3))
+	require.Equal(t, 2*time.Minute, reconnectDelay(7))
+	require.Equal(t, 2*time.Minute, reconnectDelay(100))
+}
+
+func TestReconnect(t *testing.T) {
+	baseDelay := reconnectBaseDelay
+	reconnectBaseDelay = 5 * time.Millisecond
+	t.Cleanup(func() { reconnectBaseDelay = baseDelay })
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "remote"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "echo"}, func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, any, error) {
+		return &mcp.CallToolResult{}, nil, nil
+	})
+	handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return server }, nil)
+	var up atomic.Bool
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(httpServer.Close)
+	t.Cleanup(func() { stopClient("remote") })
+
+	states.Set("remote", ClientInfo{Name: "remote", State: StateConnected, Counts: Counts{Tools: 1}})
+	allTools.Set("remote", []*Tool{{Name: "echo"}})
+	m := config.MCPConfig{Type: config.MCPHttp, URL: httpServer.URL}
+	go reconnect(t.Context(), &config.Config{}, "remote", m, errors.New("connection closed"))
+
+	waitForState := func(check func(ClientInfo) bool) ClientInfo {
+		t.Helper()
+		var info ClientInfo
+		require.Eventually(t, func() bool {
+			info, _ = GetState("remote")
+			return check(info)
+		}, 10*time.Second, 5*time.Millisecond)
+		return info
+	}
+
+	// The tools of a degraded server stay available.
+	info := waitForState(func(info ClientInfo) bool { return info.State == StateDegraded && info.Reconnects > 0 })
+	require.Equal(t, 1, info.Counts.Tools)
+	_, ok := allTools.Get("remote")
+	require.True(t, ok)
+
+	// Those of an offline one don't.
+	waitForState(func(info ClientInfo) bool { return info.State == StateOffline })
+	_, ok = allTools.Get("remote")
+	require.False(t, ok)
+
+	up.Store(true)
+	info = waitForState(func(info ClientInfo) bool { return info.State == StateConnected })
+	require.Equal(t, Counts{Tools: 1}, info.Counts)
+	tools, ok := allTools.Get("remote")
+	require.True(t, ok)
+	require.Equal(t, "echo", tools[0].Name)
+	require.False(t, isReconnecting("remote"))
+}
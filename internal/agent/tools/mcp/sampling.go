@@ -0,0 +1,180 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// SamplingPermission is the tool name permissions to answer sampling requests
+// are asked for with, the action being the name of the server.
+const SamplingPermission = "mcp_sampling"
+
+// SamplingRequest is a request of an MCP server for a completion of the
+// model, see https://modelcontextprotocol.io/specification/2025-06-18/client/sampling.
+type SamplingRequest struct {
+	Server       string            `json:"server"`
+	SystemPrompt string            `json:"system_prompt,omitempty"`
+	Messages     []SamplingMessage `json:"messages"`
+	MaxTokens    int64             `json:"max_tokens"`
+	Temperature  *float64          `json:"temperature,omitempty"`
+	// PreferFast tells whether the server cares more about the speed or the
+	// cost of the completion than about the intelligence of the model.
+	PreferFast bool `json:"prefer_fast,omitempty"`
+}
+
+// SamplingMessage is a message of a sampling request. Only text is
+// supported.
+type SamplingMessage struct {
+	Role string `json:"role"`
+	Text string `json:"text"`
+}
+
+// SamplingResult is the completion answering a sampling request.
+type SamplingResult struct {
+	Model string
+	Text  string
+	// StopReason is endTurn, maxTokens or stopSequence, like in the
+	// specification.
+	StopReason string
+}
+
+// Sampler generates the completion answering a sampling request made while
+// a tool call of the session ran.
+type Sampler func(ctx context.Context, sessionID string, req SamplingRequest) (SamplingResult, error)
+
+// Caller is the tool call of a session running an MCP tool. The requests the
+// server makes meanwhile, like sampling, are made on its behalf.
+type Caller struct {
+	SessionID  string
+	ToolCallID string
+}
+
+var (
+	sampler     atomic.Pointer[Sampler]
+	permissions atomic.Pointer[permission.Service]
+
+	callersMu sync.Mutex
+	// callers holds the tool calls running tools of each server, the latest
+	// last.
+	callers = map[string][]Caller{}
+)
+
+// SetSampler sets what answers the sampling requests of the servers that
+// are allowed to make them.
+func SetSampler(s Sampler) {
+	sampler.Store(&s)
+}
+
+// addCaller records that caller runs a tool of the named server, until the
+// returned function is called.
+func addCaller(name string, caller Caller) func() {
+	callersMu.Lock()
+	defer callersMu.Unlock()
+	callers[name] = append(callers[name], caller)
+	return func() {
+		callersMu.Lock()
+		defer callersMu.Unlock()
+		for i, c := range callers[name] {
+			if c == caller {
+				callers[name] = append(callers[name][:i], callers[name][i+1:]...)
+				break
+			}
+		}
+		if len(callers[name]) == 0 {
+			delete(callers, name)
+		}
+	}
+}
+
+// currentCaller returns the latest tool call running a tool of the named
+// server, if there's one.
+func currentCaller(name string) (Caller, bool) {
+	callersMu.Lock()
+	defer callersMu.Unlock()
+	running := callers[name]
+	if len(running) == 0 {
+		return Caller{}, false
+	}
+	return running[len(running)-1], true
+}
+
+// sample answers a sampling request of the named server. Servers may only
+// make them while one of their tools runs, and the user is asked for
+// permission first.
+func sample(ctx context.Context, name string, params *mcp.CreateMessageParams) (*mcp.CreateMessageResult, error) {
+	caller, ok := currentCaller(name)
+	if !ok {
+		return nil, errors.New("sampling is only allowed while one of the tools of the server runs")
+	}
+	s := sampler.Load()
+	if s == nil {
+		return nil, errors.New("sampling isn't available")
+	}
+	req, err := samplingRequest(name, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if p := permissions.Load(); p != nil {
+		data, _ := json.Marshal(req)
+		granted := (*p).Request(permission.CreatePermissionRequest{
+			SessionID:   caller.SessionID,
+			ToolCallID:  caller.ToolCallID,
+			Path:        ".",
+			ToolName:    SamplingPermission,
+			Action:      name,
+			Description: fmt.Sprintf("let the %s MCP server ask the model for a completion:", name),
+			Params:      string(data),
+		})
+		if !granted {
+			return nil, errors.New("the user denied the sampling request")
+		}
+	}
+
+	result, err := (*s)(ctx, caller.SessionID, req)
+	if err != nil {
+		slog.Error("error answering sampling request", "name", name, "error", err)
+		return nil, err
+	}
+	return &mcp.CreateMessageResult{
+		Content:    &mcp.TextContent{Text: result.Text},
+		Model:      result.Model,
+		Role:       "assistant",
+		StopReason: result.StopReason,
+	}, nil
+}
+
+// samplingRequest converts the parameters of a sampling request of the named
+// server.
+func samplingRequest(name string, params *mcp.CreateMessageParams) (SamplingRequest, error) {
+	req := SamplingRequest{
+		Server:       name,
+		SystemPrompt: params.SystemPrompt,
+		MaxTokens:    params.MaxTokens,
+	}
+	if params.Temperature != 0 {
+		req.Temperature = &params.Temperature
+	}
+	if prefs := params.ModelPreferences; prefs != nil {
+		req.PreferFast = max(prefs.SpeedPriority, prefs.CostPriority) > prefs.IntelligencePriority
+	}
+	for _, msg := range params.Messages {
+		text, ok := msg.Content.(*mcp.TextContent)
+		if !ok {
+			return SamplingRequest{}, fmt.Errorf("unsupported %T content in sampling request, only text is supported", msg.Content)
+		}
+		req.Messages = append(req.Messages, SamplingMessage{Role: string(msg.Role), Text: text.Text})
+	}
+	if len(req.Messages) == 0 {
+		return SamplingRequest{}, errors.New("sampling request without messages")
+	}
+	return req, nil
+}
@@ -0,0 +1,68 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampling(t *testing.T) {
+	var got SamplingRequest
+	var gotSessionID string
+	SetSampler(func(_ context.Context, sessionID string, req SamplingRequest) (SamplingResult, error) {
+		got, gotSessionID = req, sessionID
+		return SamplingResult{Model: "test-model", Text: "pong", StopReason: "endTurn"}, nil
+	})
+	t.Cleanup(func() { sampler.Store(nil) })
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "sampler"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "ask"}, func(ctx context.Context, req *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, any, error) {
+		result, err := req.Session.CreateMessage(ctx, &mcp.CreateMessageParams{
+			SystemPrompt:     "Answer briefly.",
+			Messages:         []*mcp.SamplingMessage{{Role: "user", Content: &mcp.TextContent{Text: "ping"}}},
+			MaxTokens:        100,
+			ModelPreferences: &mcp.ModelPreferences{SpeedPriority: 0.8, IntelligencePriority: 0.2},
+		})
+		if err != nil {
+			return &mcp.CallToolResult{IsError: true, Content: []mcp.Content{&mcp.TextContent{Text: err.Error()}}}, nil, nil
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{result.Content}}, nil, nil
+	})
+	httpServer := httptest.NewServer(mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return server }, nil))
+	t.Cleanup(httpServer.Close)
+
+	m := config.MCPConfig{Type: config.MCPHttp, URL: httpServer.URL, Sampling: true}
+	session, _, _, err := dial(t.Context(), &config.Config{}, "sampler", m)
+	require.NoError(t, err)
+	t.Cleanup(func() { session.Close() })
+	ask := func() *mcp.CallToolResult {
+		t.Helper()
+		result, err := session.CallTool(t.Context(), &mcp.CallToolParams{Name: "ask"})
+		require.NoError(t, err)
+		return result
+	}
+
+	// Sampling is only allowed while Crush runs a tool of the server.
+	result := ask()
+	require.True(t, result.IsError)
+	require.Contains(t, result.Content[0].(*mcp.TextContent).Text, "only allowed while one of the tools of the server runs")
+
+	remove := addCaller("sampler", Caller{SessionID: "session", ToolCallID: "call"})
+	result = ask()
+	remove()
+	require.False(t, result.IsError)
+	require.Equal(t, "pong", result.Content[0].(*mcp.TextContent).Text)
+	require.Equal(t, "session", gotSessionID)
+	require.Equal(t, SamplingRequest{
+		Server:       "sampler",
+		SystemPrompt: "Answer briefly.",
+		Messages:     []SamplingMessage{{Role: "user", Text: "ping"}},
+		MaxTokens:    100,
+		PreferFast:   true,
+	}, got)
+}
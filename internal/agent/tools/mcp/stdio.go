@@ -0,0 +1,91 @@
+package mcp
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/csync"
+)
+
+const (
+	// stdioMaxRestarts is the number of times in a row a server started with
+	// a command is restarted before Crush gives up on it.
+	stdioMaxRestarts = 3
+	// maxLogSize is the size past which the stderr log of a server is
+	// truncated when the server starts.
+	maxLogSize = 1 << 20
+)
+
+// stableAfter is how long a server has to run for its next crash not to
+// count as a crash in a row.
+var stableAfter = time.Minute
+
+// crashes holds the number of times in a row each server started with a
+// command crashed.
+var crashes = csync.NewMap[string, int]()
+
+// LogPath returns the file the stderr of the named server is written to.
+func LogPath(dataDir, name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '/' || r == os.PathSeparator {
+			return '_'
+		}
+		return r
+	}, name)
+	return filepath.Join(dataDir, "logs", "mcp", name+".log")
+}
+
+// openLog opens the stderr log of the named server for appending, starting
+// it over when it grew too big.
+func openLog(dataDir, name string) (*os.File, error) {
+	path := LogPath(dataDir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	flag := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if info, err := os.Stat(path); err == nil && info.Size() > maxLogSize {
+		flag |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flag, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(f, "--- started %s at %s ---\n", name, time.Now().Format(time.RFC3339))
+	return f, nil
+}
+
+// countCrash records that the named server crashed after being connected
+// since the given time, and returns how many times in a row it did.
+func countCrash(name string, connectedAt time.Time) int {
+	n := 1
+	if prev, ok := crashes.Get(name); ok && time.Since(connectedAt) < stableAfter {
+		n = prev + 1
+	}
+	crashes.Set(name, n)
+	return n
+}
+
+// giveUp stops restarting the named server, removing its tools and prompts.
+func giveUp(name string, err error) {
+	slog.Error("mcp server keeps crashing, giving up", "name", name, "error", err)
+	updateTools(name, nil)
+	updatePrompts(name, nil)
+	setState(ClientInfo{
+		Name:  name,
+		State: StateError,
+		Error: fmt.Errorf("%w, see crush mcp logs %s", err, name),
+	})
+}
+
+// dataDirectory returns the data directory of cfg, if it has one.
+func dataDirectory(cfg *config.Config) string {
+	if cfg.Options == nil {
+		return ""
+	}
+	return cfg.Options.DataDirectory
+}
@@ -0,0 +1,55 @@
+package mcp
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountCrash(t *testing.T) {
+	t.Cleanup(func() { crashes.Del("crashy") })
+
+	require.Equal(t, 1, countCrash("crashy", time.Now()))
+	require.Equal(t, 2, countCrash("crashy", time.Now()))
+	// A crash after running for a while starts counting over.
+	require.Equal(t, 1, countCrash("crashy", time.Now().Add(-2*stableAfter)))
+}
+
+func TestRestartGivesUp(t *testing.T) {
+	baseDelay := reconnectBaseDelay
+	reconnectBaseDelay = 5 * time.Millisecond
+	t.Cleanup(func() { reconnectBaseDelay = baseDelay })
+	t.Cleanup(func() { stopClient("crashy") })
+
+	t.Setenv("CRUSH_DISABLE_PROVIDER_AUTO_UPDATE", "1")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	dataDir := t.TempDir()
+	cfg, err := config.Load(t.TempDir(), dataDir, false)
+	require.NoError(t, err)
+	m := config.MCPConfig{Type: config.MCPStdio, Command: "sh", Args: []string{"-c", "echo boom >&2; exit 1"}}
+	states.Set("crashy", ClientInfo{Name: "crashy", State: StateConnected, Counts: Counts{Tools: 1}})
+	allTools.Set("crashy", []*Tool{{Name: "echo"}})
+	go reconnect(t.Context(), cfg, "crashy", m, errors.New("process exited"))
+
+	var info ClientInfo
+	require.Eventually(t, func() bool {
+		info, _ = GetState("crashy")
+		return info.State == StateError
+	}, 30*time.Second, 10*time.Millisecond)
+	require.ErrorContains(t, info.Error, "failed to restart 3 times in a row")
+	require.ErrorContains(t, info.Error, "see crush mcp logs crashy")
+	_, ok := allTools.Get("crashy")
+	require.False(t, ok)
+	require.False(t, isReconnecting("crashy"))
+
+	// What the server wrote to stderr is kept.
+	data, err := os.ReadFile(LogPath(dataDir, "crashy"))
+	require.NoError(t, err)
+	require.Contains(t, string(data), "--- started crashy at")
+	require.Contains(t, string(data), "boom")
+}
@@ -21,12 +21,13 @@ func Tools() iter.Seq2[string, []*Tool] {
 	return allTools.Seq2()
 }
 
-// RunTool runs an MCP tool with the given input parameters.
-func RunTool(ctx context.Context, name, toolName string, input string) (string, error) {
+// RunTool runs an MCP tool with the given input parameters for caller.
+func RunTool(ctx context.Context, caller Caller, name, toolName string, input string) (string, error) {
 	var args map[string]any
 	if err := json.Unmarshal([]byte(input), &args); err != nil {
 		return "", fmt.Errorf("error parsing parameters: %s", err)
 	}
+	defer addCaller(name, caller)()
 
 	c, err := getOrRenewClient(ctx, name)
 	if err != nil {
@@ -54,6 +55,10 @@ func RunTool(ctx context.Context, name, toolName string, input string) (string,
 // RefreshTools gets the updated list of tools from the MCP and updates the
 // global state.
 func RefreshTools(ctx context.Context, name string) {
+	if isReconnecting(name) {
+		slog.Debug("refresh tools: reconnecting, they're listed once reconnected", "name", name)
+		return
+	}
 	session, ok := sessions.Get(name)
 	if !ok {
 		slog.Warn("refresh tools: no session", "name", name)
@@ -13,11 +13,13 @@ import (
 	"charm.land/fantasy"
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/editlock"
 	"github.com/charmbracelet/crush/internal/filepathext"
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/history"
 	"github.com/charmbracelet/crush/internal/lsp"
 	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/trash"
 )
 
 type MultiEditOperation struct {
@@ -35,8 +37,26 @@ type MultiEditPermissionsParams struct {
 	FilePath   string `json:"file_path"`
 	OldContent string `json:"old_content,omitempty"`
 	NewContent string `json:"new_content,omitempty"`
+	// Hunks are the lines each edit that applies changes, for reviewing
+	// them one at a time.
+	Hunks []MultiEditHunk `json:"hunks,omitempty"`
 }
 
+// MultiEditHunk is the lines one edit changes, with a few lines around
+// them, as they are once the edits before it are applied.
+type MultiEditHunk struct {
+	// Index is the position of the edit in the call, starting at 1.
+	Index int `json:"index"`
+	// StartLine is the line of the file OldContent and NewContent start at.
+	StartLine  int    `json:"start_line"`
+	OldContent string `json:"old_content,omitempty"`
+	NewContent string `json:"new_content,omitempty"`
+}
+
+// hunkContextLines is how many unchanged lines a hunk has around the lines
+// its edit changes.
+const hunkContextLines = 3
+
 type FailedEdit struct {
 	Index int                `json:"index"`
 	Error string             `json:"error"`
@@ -57,7 +77,7 @@ const MultiEditToolName = "multiedit"
 //go:embed multiedit.md
 var multieditDescription []byte
 
-func NewMultiEditTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, workingDir string) fantasy.AgentTool {
+func NewMultiEditTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, locks *editlock.Registry, bin *trash.Store, workingDir string) fantasy.AgentTool {
 	return fantasy.NewAgentTool(
 		MultiEditToolName,
 		string(multieditDescription),
@@ -80,7 +100,7 @@ func NewMultiEditTool(lspClients *csync.Map[string, *lsp.Client], permissions pe
 			var response fantasy.ToolResponse
 			var err error
 
-			editCtx := editContext{ctx, permissions, files, workingDir}
+			editCtx := editContext{ctx, permissions, files, locks, bin, workingDir}
 			// Handle file creation case (first edit has empty old_string)
 			if len(params.Edits) > 0 && params.Edits[0].OldString == "" {
 				response, err = processMultiEditWithCreation(editCtx, params, call)
@@ -96,14 +116,9 @@ func NewMultiEditTool(lspClients *csync.Map[string, *lsp.Client], permissions pe
 				return response, nil
 			}
 
-			// Notify LSP clients about the change
-			notifyLSPs(ctx, lspClients, params.FilePath)
-
 			// Wait for LSP diagnostics and add them to the response
-			text := fmt.Sprintf("<result>\n%s\n</result>\n", response.Content)
-			text += getDiagnostics(params.FilePath, lspClients)
-			response.Content = text
-			return response, nil
+			response.Content = fmt.Sprintf("<result>\n%s\n</result>\n", response.Content)
+			return WithDiagnostics(ctx, lspClients, response, params.FilePath), nil
 		})
 }
 
@@ -139,12 +154,14 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 
 	// Start with the content from the first edit
 	currentContent := firstEdit.NewString
+	hunks := []MultiEditHunk{editHunk(1, "", currentContent)}
 
 	// Apply remaining edits to the content, tracking failures
 	var failedEdits []FailedEdit
+	var notes []string
 	for i := 1; i < len(params.Edits); i++ {
 		edit := params.Edits[i]
-		newContent, err := applyEditToContent(currentContent, edit)
+		newContent, note, err := applyEditToContent(currentContent, edit)
 		if err != nil {
 			failedEdits = append(failedEdits, FailedEdit{
 				Index: i + 1,
@@ -153,6 +170,12 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 			})
 			continue
 		}
+		if note != "" {
+			notes = append(notes, fmt.Sprintf("Edit %d: %s", i+1, note))
+		}
+		if newContent != currentContent {
+			hunks = append(hunks, editHunk(i+1, currentContent, newContent))
+		}
 		currentContent = newContent
 	}
 
@@ -165,7 +188,7 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 	// Check permissions
 	_, additions, removals := diff.GenerateDiff("", currentContent, strings.TrimPrefix(params.FilePath, edit.workingDir))
 
-	p := edit.permissions.Request(permission.CreatePermissionRequest{
+	p := requestEdit(edit.ctx, edit.permissions, edit.locks, permission.CreatePermissionRequest{
 		SessionID:   sessionID,
 		Path:        fsext.PathOrPrefix(params.FilePath, edit.workingDir),
 		ToolCallID:  call.ID,
@@ -176,8 +199,9 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 			FilePath:   params.FilePath,
 			OldContent: "",
 			NewContent: currentContent,
+			Hunks:      hunks,
 		},
-	})
+	}, params.FilePath)
 	if !p {
 		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
 	}
@@ -200,6 +224,7 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 	}
 
 	recordFileWrite(params.FilePath)
+	acquireEditLocks(edit.ctx, edit.locks, params.FilePath)
 	recordFileRead(params.FilePath)
 
 	editsApplied := len(params.Edits) - len(failedEdits)
@@ -211,7 +236,7 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 	}
 
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse(message),
+		fantasy.NewTextResponse(message+editsReport(failedEdits, notes)),
 		MultiEditResponseMetadata{
 			OldContent:   "",
 			NewContent:   currentContent,
@@ -263,8 +288,10 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 
 	// Apply all edits sequentially, tracking failures
 	var failedEdits []FailedEdit
+	var notes []string
+	var hunks []MultiEditHunk
 	for i, edit := range params.Edits {
-		newContent, err := applyEditToContent(currentContent, edit)
+		newContent, note, err := applyEditToContent(currentContent, edit)
 		if err != nil {
 			failedEdits = append(failedEdits, FailedEdit{
 				Index: i + 1,
@@ -273,6 +300,12 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 			})
 			continue
 		}
+		if note != "" {
+			notes = append(notes, fmt.Sprintf("Edit %d: %s", i+1, note))
+		}
+		if newContent != currentContent {
+			hunks = append(hunks, editHunk(i+1, currentContent, newContent))
+		}
 		currentContent = newContent
 	}
 
@@ -281,7 +314,7 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 		// If we have failed edits, report them
 		if len(failedEdits) > 0 {
 			return fantasy.WithResponseMetadata(
-				fantasy.NewTextErrorResponse(fmt.Sprintf("no changes made - all %d edit(s) failed", len(failedEdits))),
+				fantasy.NewTextErrorResponse(fmt.Sprintf("no changes made - all %d edit(s) failed", len(failedEdits))+editsReport(failedEdits, nil)),
 				MultiEditResponseMetadata{
 					EditsApplied: 0,
 					EditsFailed:  failedEdits,
@@ -299,7 +332,7 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 
 	// Generate diff and check permissions
 	_, additions, removals := diff.GenerateDiff(oldContent, currentContent, strings.TrimPrefix(params.FilePath, edit.workingDir))
-	p := edit.permissions.Request(permission.CreatePermissionRequest{
+	p := requestEdit(edit.ctx, edit.permissions, edit.locks, permission.CreatePermissionRequest{
 		SessionID:   sessionID,
 		Path:        fsext.PathOrPrefix(params.FilePath, edit.workingDir),
 		ToolCallID:  call.ID,
@@ -310,8 +343,9 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 			FilePath:   params.FilePath,
 			OldContent: oldContent,
 			NewContent: currentContent,
+			Hunks:      hunks,
 		},
-	})
+	}, params.FilePath)
 	if !p {
 		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
 	}
@@ -321,6 +355,7 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 	}
 
 	// Write the updated content
+	keepReplaced(edit.trash, sessionID, params.FilePath)
 	err = os.WriteFile(params.FilePath, []byte(currentContent), 0o644)
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
@@ -349,6 +384,7 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 	}
 
 	recordFileWrite(params.FilePath)
+	acquireEditLocks(edit.ctx, edit.locks, params.FilePath)
 	recordFileRead(params.FilePath)
 
 	editsApplied := len(params.Edits) - len(failedEdits)
@@ -360,7 +396,7 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 	}
 
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse(message),
+		fantasy.NewTextResponse(message+editsReport(failedEdits, notes)),
 		MultiEditResponseMetadata{
 			OldContent:   oldContent,
 			NewContent:   currentContent,
@@ -372,38 +408,72 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 	), nil
 }
 
-func applyEditToContent(content string, edit MultiEditOperation) (string, error) {
+// applyEditToContent applies an edit to content, returning the new content
+// and how the old_string was matched when it isn't exact.
+func applyEditToContent(content string, edit MultiEditOperation) (string, string, error) {
 	if edit.OldString == "" && edit.NewString == "" {
-		return content, nil
+		return content, "", nil
 	}
 
 	if edit.OldString == "" {
-		return "", fmt.Errorf("old_string cannot be empty for content replacement")
+		return "", "", fmt.Errorf("old_string cannot be empty for content replacement")
 	}
 
-	var newContent string
+	var newContent, note string
 	var replacementCount int
 
 	if edit.ReplaceAll {
 		newContent = strings.ReplaceAll(content, edit.OldString, edit.NewString)
 		replacementCount = strings.Count(content, edit.OldString)
 		if replacementCount == 0 {
-			return "", fmt.Errorf("old_string not found in content. Make sure it matches exactly, including whitespace and line breaks")
+			return "", "", oldStringNotFound(content, edit.OldString)
 		}
 	} else {
-		index := strings.Index(content, edit.OldString)
-		if index == -1 {
-			return "", fmt.Errorf("old_string not found in content. Make sure it matches exactly, including whitespace and line breaks")
-		}
-
-		lastIndex := strings.LastIndex(content, edit.OldString)
-		if index != lastIndex {
-			return "", fmt.Errorf("old_string appears multiple times in the content. Please provide more context to ensure a unique match, or set replace_all to true")
+		match, err := matchEdit(content, edit.OldString, edit.NewString)
+		if err != nil {
+			return "", "", err
 		}
 
-		newContent = content[:index] + edit.NewString + content[index+len(edit.OldString):]
+		newContent = content[:match.start] + match.newString + content[match.end:]
+		note = match.note
 		replacementCount = 1
 	}
 
-	return newContent, nil
+	return newContent, note, nil
+}
+
+// editsReport lists why edits failed and how the others were matched, for
+// the model to retry or check them.
+func editsReport(failedEdits []FailedEdit, notes []string) string {
+	var sb strings.Builder
+	for _, failed := range failedEdits {
+		fmt.Fprintf(&sb, "\n\nEdit %d failed: %s", failed.Index, failed.Error)
+	}
+	for _, note := range notes {
+		fmt.Fprintf(&sb, "\n\n%s", note)
+	}
+	return sb.String()
+}
+
+// editHunk returns the hunk of the edit at index, which changed before into
+// after: the lines from the first to the last that differ, with
+// hunkContextLines lines around them.
+func editHunk(index int, before, after string) MultiEditHunk {
+	oldLines := strings.SplitAfter(before, "\n")
+	newLines := strings.SplitAfter(after, "\n")
+	prefix := 0
+	for prefix < min(len(oldLines), len(newLines)) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < min(len(oldLines), len(newLines))-prefix && oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+	start := max(0, prefix-hunkContextLines)
+	return MultiEditHunk{
+		Index:      index,
+		StartLine:  start + 1,
+		OldContent: strings.Join(oldLines[start:min(len(oldLines), len(oldLines)-suffix+hunkContextLines)], ""),
+		NewContent: strings.Join(newLines[start:min(len(newLines), len(newLines)-suffix+hunkContextLines)], ""),
+	}
 }
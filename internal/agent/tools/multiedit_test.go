@@ -2,15 +2,19 @@ package tools
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/history"
 	"github.com/charmbracelet/crush/internal/lsp"
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/trust"
 	"github.com/stretchr/testify/require"
 )
 
@@ -32,6 +36,14 @@ func (m *mockPermissionService) AutoApproveSession(sessionID string) {}
 
 func (m *mockPermissionService) SetSkipRequests(skip bool) {}
 
+func (m *mockPermissionService) SetAllowedTools(tools []string) {}
+
+func (m *mockPermissionService) SetTrust(level trust.Level) {}
+
+func (m *mockPermissionService) SetTimeout(timeout time.Duration, action permission.TimeoutAction) {}
+
+func (m *mockPermissionService) SetApprovalExpiry(expiry time.Duration) {}
+
 func (m *mockPermissionService) SkipRequests() bool {
 	return false
 }
@@ -82,7 +94,7 @@ func TestApplyEditToContentPartialSuccess(t *testing.T) {
 	content := "line 1\nline 2\nline 3\n"
 
 	// Test successful edit.
-	newContent, err := applyEditToContent(content, MultiEditOperation{
+	newContent, _, err := applyEditToContent(content, MultiEditOperation{
 		OldString: "line 1",
 		NewString: "LINE 1",
 	})
@@ -91,7 +103,7 @@ func TestApplyEditToContentPartialSuccess(t *testing.T) {
 	require.Contains(t, newContent, "line 2")
 
 	// Test failed edit (string not found).
-	_, err = applyEditToContent(content, MultiEditOperation{
+	_, _, err = applyEditToContent(content, MultiEditOperation{
 		OldString: "line 99",
 		NewString: "LINE 99",
 	})
@@ -116,7 +128,7 @@ func TestMultiEditSequentialApplication(t *testing.T) {
 	files := &mockHistoryService{Broker: pubsub.NewBroker[history.File]()}
 
 	// Create multiedit tool.
-	_ = NewMultiEditTool(lspClients, permissions, files, tmpDir)
+	_ = NewMultiEditTool(lspClients, permissions, files, nil, nil, tmpDir)
 
 	// Simulate reading the file first.
 	recordFileRead(testFile)
@@ -136,7 +148,7 @@ func TestMultiEditSequentialApplication(t *testing.T) {
 	successCount := 0
 
 	for i, edit := range edits {
-		newContent, err := applyEditToContent(currentContent, edit)
+		newContent, _, err := applyEditToContent(currentContent, edit)
 		if err != nil {
 			failedEdits = append(failedEdits, FailedEdit{
 				Index: i + 1,
@@ -180,7 +192,7 @@ func TestMultiEditAllEditsSucceed(t *testing.T) {
 	successCount := 0
 
 	for _, edit := range edits {
-		newContent, err := applyEditToContent(currentContent, edit)
+		newContent, _, err := applyEditToContent(currentContent, edit)
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
@@ -208,7 +220,7 @@ func TestMultiEditAllEditsFail(t *testing.T) {
 	var failedEdits []FailedEdit
 
 	for i, edit := range edits {
-		newContent, err := applyEditToContent(currentContent, edit)
+		newContent, _, err := applyEditToContent(currentContent, edit)
 		if err != nil {
 			failedEdits = append(failedEdits, FailedEdit{
 				Index: i + 1,
@@ -223,3 +235,30 @@ func TestMultiEditAllEditsFail(t *testing.T) {
 	require.Len(t, failedEdits, 2)
 	require.Equal(t, content, currentContent, "Content should be unchanged")
 }
+
+func TestEditHunk(t *testing.T) {
+	t.Parallel()
+
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, fmt.Sprintf("line %d\n", i))
+	}
+	before := strings.Join(lines, "")
+	after := strings.Replace(before, "line 10\n", "LINE 10\nline 10.5\n", 1)
+
+	require.Equal(t, MultiEditHunk{
+		Index:      2,
+		StartLine:  7,
+		OldContent: "line 7\nline 8\nline 9\nline 10\nline 11\nline 12\nline 13\n",
+		NewContent: "line 7\nline 8\nline 9\nLINE 10\nline 10.5\nline 11\nline 12\nline 13\n",
+	}, editHunk(2, before, after))
+
+	hunk := editHunk(1, "a\nb\n", "a\nB\n")
+	require.Equal(t, 1, hunk.StartLine, "the context is cut at the start of the file")
+	require.Equal(t, "a\nb\n", hunk.OldContent, "and at its end")
+	require.Equal(t, "a\nB\n", hunk.NewContent)
+
+	hunk = editHunk(1, "", "package main\n")
+	require.Equal(t, 1, hunk.StartLine)
+	require.Equal(t, "package main\n", hunk.NewContent)
+}
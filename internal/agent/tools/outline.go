@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// maxOutlineEntries caps the outline of files with many declarations.
+	maxOutlineEntries = 300
+	// maxOutlineLineLength truncates long declarations in the outline.
+	maxOutlineLineLength = 160
+)
+
+// outlinePatterns match the lines that start a declaration or a section,
+// by file extension.
+var outlinePatterns = func() map[string]*regexp.Regexp {
+	var (
+		goDecl       = regexp.MustCompile(`^(func|type|var|const)\b`)
+		pythonDecl   = regexp.MustCompile(`^\s*(async\s+)?(def|class)\s`)
+		jsDecl       = regexp.MustCompile(`^\s{0,4}(export\s+)?(default\s+)?(abstract\s+)?(async\s+)?(function\*?|class|interface|type|enum)\s|^(export\s+)?const\s+\w+\s*=\s*(async\s+)?(\(|function)`)
+		rustDecl     = regexp.MustCompile(`^\s*(pub(\([\w:]+\))?\s+)?(async\s+)?(unsafe\s+)?(fn|struct|enum|trait|impl|mod|type|macro_rules!)\b`)
+		cLikeDecl    = regexp.MustCompile(`^[A-Za-z_][\w\s\*&:<>,]*\([^;]*$`)
+		classDecl    = regexp.MustCompile(`^\s*((public|private|protected|internal|static|abstract|final|sealed|open|data|async)\s+)*(class|interface|enum|record|struct|object|trait|fun|func|function)\s|^\s*(public|private|protected|internal|override)\s+[^=;]*\(`)
+		rubyDecl     = regexp.MustCompile(`^\s*(def|class|module)\s`)
+		shellDecl    = regexp.MustCompile(`^(function\s+)?[\w-]+\s*\(\)\s*\{?`)
+		markdownDecl = regexp.MustCompile(`^#{1,6}\s`)
+	)
+	patterns := map[string]*regexp.Regexp{
+		".go":   goDecl,
+		".py":   pythonDecl,
+		".rs":   rustDecl,
+		".rb":   rubyDecl,
+		".sh":   shellDecl,
+		".bash": shellDecl,
+		".zsh":  shellDecl,
+		".md":   markdownDecl,
+		".mdx":  markdownDecl,
+	}
+	for _, ext := range []string{".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx", ".mts", ".cts"} {
+		patterns[ext] = jsDecl
+	}
+	for _, ext := range []string{".c", ".h", ".cc", ".cpp", ".cxx", ".hpp", ".hh"} {
+		patterns[ext] = cLikeDecl
+	}
+	for _, ext := range []string{".java", ".kt", ".kts", ".cs", ".scala", ".swift", ".php"} {
+		patterns[ext] = classDecl
+	}
+	return patterns
+}()
+
+// fileOutline returns the lines of a file that start a declaration or a
+// section, numbered like the view tool output, and the number of lines of
+// the file. The outline is empty for file types without a pattern.
+func fileOutline(filePath string) (string, int, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	pattern := outlinePatterns[strings.ToLower(filepath.Ext(filePath))]
+	var entries []string
+	lineCount := 0
+	scanner := NewLineScanner(file)
+	for scanner.Scan() {
+		lineCount++
+		if pattern == nil || len(entries) == maxOutlineEntries {
+			continue
+		}
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || !pattern.MatchString(line) {
+			continue
+		}
+		if len(line) > maxOutlineLineLength {
+			line = line[:maxOutlineLineLength] + "..."
+		}
+		entries = append(entries, fmt.Sprintf("%6d|%s", lineCount, line))
+	}
+	if err := scanner.Err(); err != nil {
+		return "", 0, err
+	}
+	outline := strings.Join(entries, "\n")
+	if len(entries) == maxOutlineEntries {
+		outline += "\n(Outline truncated)"
+	}
+	return outline, lineCount, nil
+}
+
+// outlineResponse is the view tool output for a file too large to show at
+// once.
+func outlineResponse(filePath, outline string, lineCount int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "File %s has %d lines, too many to show at once.\n\n", filePath, lineCount)
+	if outline != "" {
+		sb.WriteString("<outline>\n")
+		sb.WriteString(outline)
+		sb.WriteString("\n</outline>\n\n")
+		sb.WriteString("Read the parts you need with start_line and end_line, using the line numbers of the outline.")
+	} else {
+		sb.WriteString("No outline is available for this file type. Read the parts you need with start_line and end_line, or use grep to find the lines to read.")
+	}
+	return sb.String()
+}
@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/shell"
+)
+
+// ProjectCommandToolPrefix prefixes the names of the tools that run the
+// commands the project declares, like project_build.
+const ProjectCommandToolPrefix = "project_"
+
+// ProjectCommandParams is empty: the tools run the declared command as is.
+type ProjectCommandParams struct{}
+
+type ProjectCommandResponseMetadata struct {
+	Name      string `json:"name"`
+	Command   string `json:"command"`
+	ExitCode  int    `json:"exit_code"`
+	Output    string `json:"output"`
+	StartTime int64  `json:"start_time"`
+	EndTime   int64  `json:"end_time"`
+}
+
+// NewProjectCommandTools returns a tool for each of the commands the
+// project declares. They don't ask for permission: the user declared them.
+func NewProjectCommandTools(workingDir string, shellType shell.ShellType, commands config.ProjectCommands) []fantasy.AgentTool {
+	var projectTools []fantasy.AgentTool
+	for _, command := range commands.All() {
+		projectTools = append(projectTools, newProjectCommandTool(workingDir, shellType, command))
+	}
+	return projectTools
+}
+
+func newProjectCommandTool(workingDir string, shellType shell.ShellType, command config.ProjectCommand) fantasy.AgentTool {
+	description := fmt.Sprintf(
+		"Runs the %s command of the project, `%s`, in the working directory and returns its output. The user declared it as the way to %s the project, so use this tool instead of running another command with bash. It takes no parameters.",
+		command.Name, command.Command, command.Name,
+	)
+	return fantasy.NewAgentTool(
+		ProjectCommandToolPrefix+command.Name,
+		description,
+		func(ctx context.Context, _ ProjectCommandParams, _ fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			startTime := time.Now()
+			sh := shell.NewShell(&shell.Options{WorkingDir: workingDir, Type: shellType})
+			stdout, stderr, execErr := sh.Exec(ctx, command.Command)
+			exitCode := shell.ExitCode(execErr)
+			if exitCode == 0 && execErr != nil && !shell.IsInterrupt(execErr) {
+				return fantasy.ToolResponse{}, fmt.Errorf("error running the %s command: %w", command.Name, execErr)
+			}
+
+			output := FormatOutput(stdout, stderr, execErr)
+			metadata := ProjectCommandResponseMetadata{
+				Name:      command.Name,
+				Command:   command.Command,
+				ExitCode:  exitCode,
+				Output:    output,
+				StartTime: startTime.UnixMilli(),
+				EndTime:   time.Now().UnixMilli(),
+			}
+			if output == "" {
+				output = BashNoOutput
+			}
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(output), metadata), nil
+		})
+}
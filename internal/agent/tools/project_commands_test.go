@@ -0,0 +1,42 @@
+package tools
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectCommandTools(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "VERSION"), []byte("1.2.3\n"), 0o644))
+
+	projectTools := NewProjectCommandTools(dir, shell.ShellTypePOSIX, config.ProjectCommands{
+		Build: "cat VERSION",
+		Test:  "echo failing >&2; exit 3",
+	})
+	require.Len(t, projectTools, 2)
+	require.Equal(t, "project_build", projectTools[0].Info().Name)
+	require.Contains(t, projectTools[0].Info().Description, "`cat VERSION`")
+	require.Equal(t, "project_test", projectTools[1].Info().Name)
+
+	resp, err := projectTools[0].Run(t.Context(), fantasy.ToolCall{ID: "build", Name: "project_build", Input: "{}"})
+	require.NoError(t, err)
+	require.Equal(t, "1.2.3\n", resp.Content)
+
+	resp, err = projectTools[1].Run(t.Context(), fantasy.ToolCall{ID: "test", Name: "project_test", Input: "{}"})
+	require.NoError(t, err)
+	require.Contains(t, resp.Content, "failing")
+	require.Contains(t, resp.Content, "Exit code 3")
+	var meta ProjectCommandResponseMetadata
+	require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+	require.Equal(t, "test", meta.Name)
+	require.Equal(t, 3, meta.ExitCode)
+}
@@ -0,0 +1,147 @@
+package tools
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// PageMetadata describes a fetched HTML page, for citing it.
+type PageMetadata struct {
+	Title string `json:"title,omitempty"`
+	// URL is the canonical URL of the page when it declares one, and the URL
+	// it was fetched from, after redirects, otherwise.
+	URL string `json:"url"`
+}
+
+// boilerplateTags are elements that are never part of the content of a page.
+const boilerplateTags = "script, style, noscript, template, svg, canvas, iframe, form, button, input, select, textarea, nav, header, footer, aside, " +
+	"[role=navigation], [role=banner], [role=contentinfo], [role=complementary], [aria-hidden=true], [hidden]"
+
+// boilerplatePattern matches the classes and ids of elements around the
+// content of a page, like menus, sidebars and cookie banners.
+var boilerplatePattern = regexp.MustCompile(`(?i)(^|[\s_-])(nav|navbar|menu|sidebar|footer|masthead|breadcrumbs?|comments?|cookies?|consent|banner|popup|modal|newsletter|subscribe|share|social|related|promo|sponsored|advert|ads?)($|[\s_-])`)
+
+// contentPattern matches the classes and ids of elements that hold the
+// content of a page, which are kept even if they also match
+// boilerplatePattern.
+var contentPattern = regexp.MustCompile(`(?i)(^|[\s_-])(article|content|main|post|entry|body|text|story)($|[\s_-])`)
+
+// extractReadable finds the main content of the HTML page in doc, the way
+// reader modes of browsers do, and returns it along with the metadata of
+// the page. pageURL is where the page was fetched from.
+func extractReadable(doc *goquery.Document, pageURL *url.URL) (*goquery.Selection, PageMetadata) {
+	meta := pageMetadata(doc, pageURL)
+
+	doc.Find(boilerplateTags).Remove()
+	doc.Find("[class], [id]").Each(func(_ int, s *goquery.Selection) {
+		attrs := s.AttrOr("class", "") + " " + s.AttrOr("id", "")
+		if boilerplatePattern.MatchString(attrs) && !contentPattern.MatchString(attrs) && !s.Is("body, main, article") {
+			s.Remove()
+		}
+	})
+
+	content := readableContent(doc)
+	// Inline images are only noise in text, and links and images relative to
+	// the page are useless out of it.
+	content.Find(`img[src^="data:"]`).Remove()
+	content.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+		s.SetAttr("href", resolveURL(pageURL, s.AttrOr("href", "")))
+	})
+	content.Find("img[src]").Each(func(_ int, s *goquery.Selection) {
+		s.SetAttr("src", resolveURL(pageURL, s.AttrOr("src", "")))
+	})
+	return content, meta
+}
+
+// readableContent returns the element of doc that holds its content: the
+// main element or the only article if there's one with enough text, and
+// otherwise the element whose paragraphs have the most text that isn't
+// links.
+func readableContent(doc *goquery.Document) *goquery.Selection {
+	for _, selector := range []string{"main", "[role=main]", "article"} {
+		s := doc.Find(selector)
+		if s.Length() == 1 && len(strings.TrimSpace(s.Text())) >= 200 {
+			return s
+		}
+	}
+
+	// The candidates are kept in the order of the page, for ties to go to
+	// the first one.
+	var candidates []*html.Node
+	scores := map[*html.Node]float64{}
+	doc.Find("p, pre, td, blockquote").Each(func(_ int, p *goquery.Selection) {
+		text := strings.TrimSpace(p.Text())
+		if len(text) < 25 {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")) + min(float64(len(text))/100, 3)
+		// The paragraph counts for its parent, and half as much for its
+		// grandparent, so that the element around the paragraphs wins.
+		for i, ancestor := range []*goquery.Selection{p.Parent(), p.Parent().Parent()} {
+			if ancestor.Length() == 0 {
+				break
+			}
+			node := ancestor.Get(0)
+			if _, ok := scores[node]; !ok {
+				candidates = append(candidates, node)
+			}
+			scores[node] += score / float64(i+1)
+		}
+	})
+	var best *goquery.Selection
+	var bestScore float64
+	for _, node := range candidates {
+		s := doc.FindNodes(node)
+		score := scores[node] * (1 - linkDensity(s))
+		if best == nil || score > bestScore {
+			best, bestScore = s, score
+		}
+	}
+	if best == nil {
+		return doc.Find("body")
+	}
+	return best
+}
+
+// linkDensity returns the share of the text of s that's in links.
+func linkDensity(s *goquery.Selection) float64 {
+	text := len(strings.TrimSpace(s.Text()))
+	if text == 0 {
+		return 0
+	}
+	var links int
+	s.Find("a").Each(func(_ int, a *goquery.Selection) {
+		links += len(strings.TrimSpace(a.Text()))
+	})
+	return float64(links) / float64(text)
+}
+
+func pageMetadata(doc *goquery.Document, pageURL *url.URL) PageMetadata {
+	meta := PageMetadata{URL: pageURL.String()}
+	for _, title := range []string{
+		doc.Find("head title").First().Text(),
+		doc.Find(`meta[property="og:title"]`).AttrOr("content", ""),
+		doc.Find("h1").First().Text(),
+	} {
+		if title = strings.Join(strings.Fields(title), " "); title != "" {
+			meta.Title = title
+			break
+		}
+	}
+	if canonical := doc.Find(`link[rel="canonical"]`).AttrOr("href", ""); canonical != "" {
+		meta.URL = resolveURL(pageURL, canonical)
+	}
+	return meta
+}
+
+func resolveURL(base *url.URL, ref string) string {
+	u, err := base.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
@@ -17,6 +17,7 @@ import (
 	"charm.land/fantasy"
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/lsp/util"
 	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
 )
 
@@ -49,7 +50,7 @@ func NewReferencesTool(lspClients *csync.Map[string, *lsp.Client]) fantasy.Agent
 
 			workingDir := cmp.Or(params.Path, ".")
 
-			matches, _, err := searchFiles(ctx, regexp.QuoteMeta(params.Symbol), workingDir, "", 100)
+			matches, _, err := searchFiles(ctx, regexp.QuoteMeta(params.Symbol), workingDir, "", 100, false, false)
 			if err != nil {
 				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to search for symbol: %s", err)), nil
 			}
@@ -157,7 +158,7 @@ func cleanupLocations(locations []protocol.Location) []protocol.Location {
 func groupByFilename(locations []protocol.Location) map[string][]protocol.Location {
 	files := make(map[string][]protocol.Location)
 	for _, loc := range locations {
-		path, err := loc.URI.Path()
+		path, err := util.PathFromURI(loc.URI)
 		if err != nil {
 			slog.Error("Failed to convert location URI to path", "uri", loc.URI, "error", err)
 			continue
@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"log/slog"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
@@ -37,13 +38,35 @@ func getRgCmd(ctx context.Context, globPattern string) *exec.Cmd {
 	return exec.CommandContext(ctx, name, args...)
 }
 
-func getRgSearchCmd(ctx context.Context, pattern, path, include string) *exec.Cmd {
+// rgIgnoreArgs returns the ripgrep arguments for the ignore files of the
+// search path, or to include the ignored files with noIgnore. Ripgrep only
+// reads .gitignore files by itself; its results are then checked against the
+// rest of the ignore rules.
+func rgIgnoreArgs(path string, noIgnore bool) []string {
+	if noIgnore {
+		return []string{"--no-ignore"}
+	}
+	var args []string
+	for _, ignoreFile := range []string{".gitignore", ".crushignore"} {
+		ignorePath := filepath.Join(path, ignoreFile)
+		if _, err := os.Stat(ignorePath); err == nil {
+			args = append(args, "--ignore-file", ignorePath)
+		}
+	}
+	return args
+}
+
+func getRgSearchCmd(ctx context.Context, pattern, path, include string, multiline bool) *exec.Cmd {
 	name := getRg()
 	if name == "" {
 		return nil
 	}
 	// Use -n to show line numbers, -0 for null separation to handle Windows paths
-	args := []string{"--json", "-H", "-n", "-0", pattern}
+	args := []string{"--json", "-H", "-n", "-0"}
+	if multiline {
+		args = append(args, "--multiline", "--multiline-dotall")
+	}
+	args = append(args, "--regexp", pattern)
 	if include != "" {
 		args = append(args, "--glob", include)
 	}
@@ -0,0 +1,413 @@
+package tools
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/charmbracelet/crush/internal/fsext"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// CommandRisk is how much a shell command can change, as far as the
+// heuristics of ClassifyCommand can tell.
+type CommandRisk int
+
+const (
+	// RiskUnknown commands get the usual permission request.
+	RiskUnknown CommandRisk = iota
+	// RiskMutating commands change files, the repository or other state,
+	// like rm, git push or database migrations.
+	RiskMutating
+	// RiskDestructive commands lose work or data in a way that is hard to
+	// undo, like rm -rf, git push --force or dropping a database.
+	RiskDestructive
+)
+
+// String returns the name of the risk, as used in permission requests.
+func (r CommandRisk) String() string {
+	switch r {
+	case RiskMutating:
+		return "mutating"
+	case RiskDestructive:
+		return "destructive"
+	}
+	return ""
+}
+
+// mutatingCommands change things whatever their arguments.
+var mutatingCommands = []string{
+	"chmod", "chown", "cp", "install", "ln", "mv", "rm", "rmdir", "unlink",
+	"alembic", "flyway", "goose", "liquibase", "migrate", "sqlx",
+}
+
+// destructiveCommands lose data whatever their arguments.
+var destructiveCommands = []string{
+	"dd", "dropdb", "mkfs", "shred", "truncate", "wipefs",
+}
+
+// mutatingGitCommands are the git subcommands that change the repository or
+// its remotes.
+var mutatingGitCommands = []string{
+	"am", "apply", "checkout", "cherry-pick", "clean", "commit", "merge", "mv",
+	"pull", "push", "rebase", "reset", "restore", "revert", "rm", "stash",
+	"switch",
+}
+
+// destructiveMigrationTasks are the tasks of the frameworks that roll back or
+// recreate a database, like rails db:drop or artisan migrate:fresh.
+var destructiveMigrationTasks = []string{
+	"db:drop", "db:reset", "db:rollback", "db:schema:load",
+	"migrate:fresh", "migrate:reset", "migrate:rollback",
+}
+
+// destructiveSubcommands are the subcommands of the tools that roll back,
+// recreate or empty a database, by tool. The migrate ones cover
+// golang-migrate and prisma migrate.
+var destructiveSubcommands = map[string][]string{
+	"alembic":      {"downgrade"},
+	"django-admin": {"flush"},
+	"goose":        {"down", "down-to", "reset"},
+	"manage.py":    {"flush"},
+	"migrate":      {"down", "drop", "reset"},
+	"redis-cli":    {"flushall", "flushdb"},
+	"sqlx":         {"drop", "reset"},
+	"valkey-cli":   {"flushall", "flushdb"},
+}
+
+// destructiveSQL are the statements that lose data when passed to a
+// database client.
+var destructiveSQL = []string{"drop database", "drop schema", "drop table", "truncate "}
+
+// ClassifyCommand returns the risk of a shell command and the part of it that
+// has this risk, which is the riskiest of its simple commands. Commands the
+// heuristics don't know are RiskUnknown.
+func ClassifyCommand(command string) (CommandRisk, string) {
+	risk, reason := RiskUnknown, ""
+	for _, args := range simpleCommands(command) {
+		if r := classifyArgs(args); r > risk {
+			risk, reason = r, strings.Join(args, " ")
+		}
+	}
+	return risk, reason
+}
+
+// simpleCommands returns the arguments of the simple commands of a POSIX
+// command line, or of its parts split on the usual separators when it
+// can't be parsed, like for PowerShell.
+func simpleCommands(command string) [][]string {
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil {
+		var commands [][]string
+		segments := strings.FieldsFunc(command, func(r rune) bool {
+			return strings.ContainsRune(";|&\n(){}", r)
+		})
+		for _, segment := range segments {
+			if args := strings.Fields(segment); len(args) > 0 {
+				commands = append(commands, args)
+			}
+		}
+		return commands
+	}
+	var commands [][]string
+	syntax.Walk(file, func(node syntax.Node) bool {
+		call, ok := node.(*syntax.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		args := make([]string, len(call.Args))
+		for i, word := range call.Args {
+			args[i] = literal(word)
+		}
+		commands = append(commands, args)
+		return true
+	})
+	return commands
+}
+
+// literal returns the value of word without its quotes, keeping expansions
+// as they are written.
+func literal(word *syntax.Word) string {
+	var b strings.Builder
+	for _, part := range word.Parts {
+		switch part := part.(type) {
+		case *syntax.Lit:
+			b.WriteString(part.Value)
+		case *syntax.SglQuoted:
+			b.WriteString(part.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range part.Parts {
+				if lit, ok := inner.(*syntax.Lit); ok {
+					b.WriteString(lit.Value)
+				}
+			}
+		}
+	}
+	return b.String()
+}
+
+// wrappers are the commands that run the command given after their options,
+// with the options of each that take a value.
+var wrappers = map[string][]string{
+	"command": nil,
+	"doas":    {"-u", "-C"},
+	"env":     {"-u", "--unset", "-C", "--chdir", "-S", "--split-string"},
+	"exec":    {"-a"},
+	"nice":    {"-n", "--adjustment"},
+	"nohup":   nil,
+	"sudo":    {"-u", "--user", "-g", "--group", "-h", "--host", "-p", "--prompt", "-C", "--close-from", "-D", "--chdir", "-r", "--role", "-t", "--type", "-U", "--other-user", "-T", "--command-timeout"},
+	"time":    {"-f", "--format", "-o", "--output"},
+}
+
+// unwrap returns the command args runs through sudo, env and the like,
+// without the wrappers, their options and the variables they set.
+func unwrap(args []string) []string {
+	for len(args) > 1 {
+		valued, ok := wrappers[args[0]]
+		if !ok {
+			return args
+		}
+		args = args[1:]
+	options:
+		for len(args) > 0 {
+			arg := args[0]
+			switch {
+			case arg == "--":
+				args = args[1:]
+				break options
+			case arg == "-S" || arg == "--split-string":
+				// env runs the command in the value of the option.
+				if len(args) > 1 {
+					args = append(strings.Fields(args[1]), args[2:]...)
+				} else {
+					args = args[1:]
+				}
+				break options
+			case slices.Contains(valued, arg):
+				args = args[min(2, len(args)):]
+			case strings.HasPrefix(arg, "-") && len(arg) > 1:
+				args = args[1:]
+			case isAssignment(arg):
+				args = args[1:]
+			default:
+				break options
+			}
+		}
+	}
+	return args
+}
+
+// isAssignment reports whether arg sets a variable, like NAME=value.
+func isAssignment(arg string) bool {
+	name, _, ok := strings.Cut(arg, "=")
+	if !ok || name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+func classifyArgs(args []string) CommandRisk {
+	args = unwrap(args)
+	if len(args) == 0 {
+		return RiskUnknown
+	}
+	name := strings.ToLower(args[0])
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.TrimSuffix(name, ".exe")
+	rest := args[1:]
+	line := strings.ToLower(strings.Join(args, " "))
+
+	switch {
+	case slices.Contains(destructiveCommands, name):
+		return RiskDestructive
+	case name == "rm":
+		if hasFlag(rest, "r", "recursive") || hasFlag(rest, "f", "force") {
+			return RiskDestructive
+		}
+		return RiskMutating
+	case name == "find":
+		if slices.Contains(rest, "-delete") {
+			return RiskDestructive
+		}
+		if slices.Contains(rest, "-exec") || slices.Contains(rest, "-execdir") {
+			return RiskMutating
+		}
+		return RiskUnknown
+	case name == "git":
+		return classifyGit(rest)
+	case name == "sed" || name == "perl":
+		if hasFlag(rest, "i", "in-place") {
+			return RiskMutating
+		}
+		return RiskUnknown
+	case name == "psql" || name == "mysql" || name == "sqlite3" || name == "sqlcmd":
+		for _, statement := range destructiveSQL {
+			if strings.Contains(line, statement) {
+				return RiskDestructive
+			}
+		}
+		return RiskUnknown
+	case slices.Contains(mutatingCommands, name):
+		if isDestructiveMigration(args) {
+			return RiskDestructive
+		}
+		return RiskMutating
+	}
+
+	// Migrations run by frameworks: rails db:migrate, manage.py migrate,
+	// prisma migrate, artisan migrate:fresh and the like.
+	if isDestructiveMigration(args) {
+		return RiskDestructive
+	}
+	for _, arg := range rest {
+		arg = strings.ToLower(arg)
+		if arg == "migrate" || strings.HasPrefix(arg, "migrate:") || strings.HasPrefix(arg, "db:") {
+			return RiskMutating
+		}
+	}
+	return RiskUnknown
+}
+
+// classifyGit returns the risk of the git subcommand with args.
+func classifyGit(args []string) CommandRisk {
+	// Skip the global options, like -C dir.
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		if (args[0] == "-C" || args[0] == "-c") && len(args) > 1 {
+			args = args[1:]
+		}
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return RiskUnknown
+	}
+	sub, rest := args[0], args[1:]
+	switch sub {
+	case "push":
+		if hasFlag(rest, "f", "force") || hasFlag(rest, "", "force-with-lease") || hasFlag(rest, "d", "delete") || hasFlag(rest, "", "mirror") {
+			return RiskDestructive
+		}
+		for _, arg := range rest {
+			if strings.HasPrefix(arg, "+") || strings.HasPrefix(arg, ":") {
+				return RiskDestructive
+			}
+		}
+	case "reset":
+		if slices.Contains(rest, "--hard") {
+			return RiskDestructive
+		}
+	case "clean":
+		if hasFlag(rest, "f", "force") {
+			return RiskDestructive
+		}
+	case "checkout":
+		if slices.Contains(rest, "--") || slices.Contains(rest, ".") || hasFlag(rest, "f", "force") {
+			return RiskDestructive
+		}
+	case "restore":
+		if !hasFlag(rest, "S", "staged") || hasFlag(rest, "W", "worktree") {
+			return RiskDestructive
+		}
+	case "stash":
+		if len(rest) > 0 && (rest[0] == "drop" || rest[0] == "clear") {
+			return RiskDestructive
+		}
+	case "branch":
+		if hasFlag(rest, "D", "") || (hasFlag(rest, "d", "delete") && hasFlag(rest, "f", "force")) {
+			return RiskDestructive
+		}
+		if hasFlag(rest, "d", "delete") || hasFlag(rest, "m", "move") {
+			return RiskMutating
+		}
+		return RiskUnknown
+	case "tag":
+		if hasFlag(rest, "d", "delete") {
+			return RiskMutating
+		}
+		return RiskUnknown
+	}
+	if slices.Contains(mutatingGitCommands, sub) {
+		return RiskMutating
+	}
+	return RiskUnknown
+}
+
+// isDestructiveMigration reports whether the arguments of a command roll
+// back, recreate or empty a database, like rails db:drop, prisma migrate
+// reset or redis-cli flushall.
+func isDestructiveMigration(args []string) bool {
+	for i, arg := range args {
+		arg = strings.ToLower(arg)
+		if slices.Contains(destructiveMigrationTasks, arg) {
+			return true
+		}
+		if j := strings.LastIndexAny(arg, `/\`); j >= 0 {
+			arg = arg[j+1:]
+		}
+		subcommands, ok := destructiveSubcommands[strings.TrimSuffix(arg, ".exe")]
+		if !ok {
+			continue
+		}
+		for _, next := range args[i+1:] {
+			if slices.Contains(subcommands, strings.ToLower(next)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasFlag reports whether args have the short flag, also within combined
+// flags like -rf, or the long one.
+func hasFlag(args []string, short, long string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			return false
+		}
+		if long != "" && (arg == "--"+long || strings.HasPrefix(arg, "--"+long+"=")) {
+			return true
+		}
+		if short != "" && len(arg) > 1 && arg[0] == '-' && arg[1] != '-' && strings.Contains(arg[1:], short) {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	// gitContextTimeout is how long getting the state of the working tree
+	// for a permission request can take.
+	gitContextTimeout = 2 * time.Second
+	// destructiveConfirmation is what the user types to run a destructive
+	// command.
+	destructiveConfirmation = "yes"
+)
+
+// addRisk classifies the command of params and, when it changes things, adds
+// its risk and the state of the working tree in dir to params. It returns
+// the confirmation the user has to type for destructive commands.
+func addRisk(ctx context.Context, params *BashPermissionsParams, dir string) string {
+	risk, command := ClassifyCommand(params.Command)
+	if risk == RiskUnknown {
+		return ""
+	}
+	params.Risk = risk.String()
+	params.RiskCommand = command
+
+	gitCtx, cancel := context.WithTimeout(ctx, gitContextTimeout)
+	defer cancel()
+	params.GitStatus, params.GitDiffStat = fsext.GitWorkingTree(gitCtx, dir)
+
+	if risk == RiskDestructive {
+		return destructiveConfirmation
+	}
+	return ""
+}
@@ -0,0 +1,62 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		command string
+		risk    CommandRisk
+		reason  string
+	}{
+		{command: "go test ./...", risk: RiskUnknown},
+		{command: "git status && git log", risk: RiskUnknown},
+		{command: "rm notes.txt", risk: RiskMutating, reason: "rm notes.txt"},
+		{command: "rm -rf build", risk: RiskDestructive, reason: "rm -rf build"},
+		{command: "sudo rm --recursive /tmp/x", risk: RiskDestructive, reason: "sudo rm --recursive /tmp/x"},
+		{command: "go build ./... && git push origin main", risk: RiskMutating, reason: "git push origin main"},
+		{command: "git push --force origin main", risk: RiskDestructive, reason: "git push --force origin main"},
+		{command: "git push origin +main", risk: RiskDestructive, reason: "git push origin +main"},
+		{command: "git -C repo reset --hard HEAD~1", risk: RiskDestructive, reason: "git -C repo reset --hard HEAD~1"},
+		{command: "git restore --staged a.go", risk: RiskMutating, reason: "git restore --staged a.go"},
+		{command: "git restore a.go", risk: RiskDestructive, reason: "git restore a.go"},
+		{command: "git branch -D topic", risk: RiskDestructive, reason: "git branch -D topic"},
+		{command: "git branch", risk: RiskUnknown},
+		{command: "find . -name '*.orig' -delete", risk: RiskDestructive, reason: "find . -name *.orig -delete"},
+		{command: "sed -i 's/a/b/' main.go", risk: RiskMutating, reason: "sed -i s/a/b/ main.go"},
+		{command: "sed 's/a/b/' main.go", risk: RiskUnknown},
+		{command: "python manage.py migrate", risk: RiskMutating, reason: "python manage.py migrate"},
+		{command: "bin/rails db:migrate", risk: RiskMutating, reason: "bin/rails db:migrate"},
+		{command: "npx prisma migrate reset --force", risk: RiskDestructive, reason: "npx prisma migrate reset --force"},
+		{command: "goose down", risk: RiskDestructive, reason: "goose down"},
+		{command: "python manage.py flush --no-input", risk: RiskDestructive, reason: "python manage.py flush --no-input"},
+		{command: "redis-cli -n 2 FLUSHDB", risk: RiskDestructive, reason: "redis-cli -n 2 FLUSHDB"},
+		{command: "go test ./internal/message -run TestFlush", risk: RiskUnknown},
+		{command: "go test ./... -run TestMigrateDown", risk: RiskUnknown},
+		{command: `psql -c "DROP TABLE users"`, risk: RiskDestructive, reason: "psql -c DROP TABLE users"},
+		{command: "Remove-Item x; rm -r y", risk: RiskDestructive, reason: "rm -r y"},
+		{command: "env X=1 rm -rf ~", risk: RiskDestructive, reason: "env X=1 rm -rf ~"},
+		{command: "env -u HOME -- rm -rf /", risk: RiskDestructive, reason: "env -u HOME -- rm -rf /"},
+		{command: `env -S "rm -rf /"`, risk: RiskDestructive, reason: "env -S rm -rf /"},
+		{command: "sudo -u root rm -rf /", risk: RiskDestructive, reason: "sudo -u root rm -rf /"},
+		{command: "sudo --user=root -E rm -rf /", risk: RiskDestructive, reason: "sudo --user=root -E rm -rf /"},
+		{command: "nice -n 10 rm -rf /", risk: RiskDestructive, reason: "nice -n 10 rm -rf /"},
+		{command: "nohup nice -5 git push --force", risk: RiskDestructive, reason: "nohup nice -5 git push --force"},
+		{command: "nohup time -f %e sudo -u deploy goose down", risk: RiskDestructive, reason: "nohup time -f %e sudo -u deploy goose down"},
+		{command: "env", risk: RiskUnknown},
+		{command: "sudo -u root", risk: RiskUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			t.Parallel()
+			risk, reason := ClassifyCommand(tt.command)
+			require.Equal(t, tt.risk, risk)
+			require.Equal(t, tt.reason, reason)
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/csync"
+)
+
+// robotsUserAgent is the name the fetch tool looks for in robots.txt files.
+const robotsUserAgent = "crush"
+
+// robotsRules are the rules of a robots.txt file that apply to Crush.
+type robotsRules struct {
+	allow    []string
+	disallow []string
+}
+
+// robotsChecker tells whether robots.txt files allow fetching URLs. It keeps
+// the rules of each site it fetched the robots.txt file of.
+type robotsChecker struct {
+	client *http.Client
+	rules  *csync.Map[string, robotsRules]
+}
+
+func newRobotsChecker(client *http.Client) *robotsChecker {
+	return &robotsChecker{client: client, rules: csync.NewMap[string, robotsRules]()}
+}
+
+// allowed tells whether the robots.txt file of the site of u allows Crush to
+// fetch it. Everything is allowed when the site has no robots.txt file, or
+// it can't be fetched.
+func (c *robotsChecker) allowed(ctx context.Context, u *url.URL) bool {
+	site := u.Scheme + "://" + u.Host
+	rules, ok := c.rules.Get(site)
+	if !ok {
+		rules = c.fetch(ctx, site)
+		c.rules.Set(site, rules)
+	}
+	return rules.allowed(u.RequestURI())
+}
+
+func (c *robotsChecker) fetch(ctx context.Context, site string) robotsRules {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", site+"/robots.txt", nil)
+	if err != nil {
+		return robotsRules{}
+	}
+	req.Header.Set("User-Agent", "crush/1.0")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return robotsRules{}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return robotsRules{}
+	}
+	// Like the crawlers of search engines, only look at the first 500KB.
+	return parseRobots(io.LimitReader(resp.Body, 500*1024), robotsUserAgent)
+}
+
+// parseRobots returns the rules of the robots.txt file in r for agent: those
+// of the groups naming it, or of the groups for every agent if none does.
+func parseRobots(r io.Reader, agent string) robotsRules {
+	var own, everyone robotsRules
+	var matchesAgent, matchesEveryone, inRules, named bool
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			// User agents after rules start a new group.
+			if inRules {
+				matchesAgent, matchesEveryone, inRules = false, false, false
+			}
+			name := strings.ToLower(value)
+			matchesAgent = matchesAgent || name == agent
+			named = named || matchesAgent
+			matchesEveryone = matchesEveryone || name == "*"
+		case "allow", "disallow":
+			inRules = true
+			// An empty rule allows everything, which is the default.
+			if value == "" {
+				continue
+			}
+			for _, rules := range []struct {
+				matches bool
+				rules   *robotsRules
+			}{{matchesAgent, &own}, {matchesEveryone, &everyone}} {
+				if !rules.matches {
+					continue
+				}
+				if key == "allow" {
+					rules.rules.allow = append(rules.rules.allow, value)
+				} else {
+					rules.rules.disallow = append(rules.rules.disallow, value)
+				}
+			}
+		}
+	}
+	if named {
+		return own
+	}
+	return everyone
+}
+
+// allowed tells whether the rules allow fetching path, with its query. The
+// longest rule matching it applies, and allow rules win ties.
+func (r robotsRules) allowed(path string) bool {
+	longest := func(patterns []string) int {
+		n := -1
+		for _, pattern := range patterns {
+			if len(pattern) > n && robotsMatch(pattern, path) {
+				n = len(pattern)
+			}
+		}
+		return n
+	}
+	return longest(r.allow) >= longest(r.disallow)
+}
+
+// robotsMatch tells whether the robots.txt pattern matches path: whether
+// path starts with it, where * matches any characters and a final $ the
+// end of path.
+func robotsMatch(pattern, path string) bool {
+	if !strings.ContainsAny(pattern, "*$") {
+		return strings.HasPrefix(path, pattern)
+	}
+	expr := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+	if strings.HasSuffix(expr, `\$`) {
+		expr = strings.TrimSuffix(expr, `\$`) + "$"
+	}
+	re, err := regexp.Compile("^" + expr)
+	return err == nil && re.MatchString(path)
+}
@@ -0,0 +1,296 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/shell"
+)
+
+type RunTestsParams struct {
+	Files  []string `json:"files,omitempty" description:"The files to run the related tests of (defaults to the files changed in the git working tree)"`
+	All    bool     `json:"all,omitempty" description:"Set to true to run all the tests instead of the related ones"`
+	Filter string   `json:"filter,omitempty" description:"Only run the tests whose names match this pattern"`
+}
+
+// TestRun is a run of the tests of a framework.
+type TestRun struct {
+	Framework string `json:"framework"`
+	Command   string `json:"command"`
+	ExitCode  int    `json:"exit_code"`
+}
+
+type RunTestsResponseMetadata struct {
+	Runs    []TestRun `json:"runs"`
+	Passed  int       `json:"passed"`
+	Failed  int       `json:"failed"`
+	Skipped int       `json:"skipped"`
+	// Failures are the failed tests, with their output.
+	Failures []TestResult `json:"failures,omitempty"`
+	// Coverage is of the changed files, or of the files with the most
+	// lines the tests didn't run when all of them ran.
+	Coverage  []FileCoverage `json:"coverage,omitempty"`
+	StartTime int64          `json:"start_time"`
+	EndTime   int64          `json:"end_time"`
+}
+
+const (
+	RunTestsToolName = "run_tests"
+
+	// maxFailureOutput is the most output shown for a failure.
+	maxFailureOutput = 4000
+)
+
+//go:embed run_tests.md
+var runTestsDescription []byte
+
+// testPlan is a command to run the tests of a framework.
+type testPlan struct {
+	framework testFramework
+	command   string
+	// coverage is whether the command writes coverage.
+	coverage bool
+}
+
+// planTests returns the commands that run the tests related to files, or
+// all the tests when files is nil. The commands write coverage to
+// coverageDir for the frameworks it is enabled for, unless it is empty.
+func planTests(root string, files []string, filter string, sh shell.ShellType, cfg config.ToolRunTests, coverageDir string) ([]testPlan, error) {
+	var detected []testFramework
+	for _, f := range testFrameworks {
+		if f.detect(root) {
+			detected = append(detected, f)
+		}
+	}
+	if len(detected) == 0 {
+		return nil, fmt.Errorf("no supported test framework found in %s (go test, cargo test, jest and pytest are)", root)
+	}
+
+	var plans []testPlan
+	for _, f := range detected {
+		var handled []string
+		if files != nil {
+			handled = []string{}
+			for _, file := range files {
+				if f.handles(file) {
+					handled = append(handled, file)
+				}
+			}
+			if len(handled) == 0 {
+				continue
+			}
+		}
+		coverage := coverageDir != "" && f.coverageArgs != nil && cfg.CoverageEnabled(f.name)
+		var extra []string
+		if coverage {
+			extra = f.coverageArgs(coverageDir)
+		}
+		if args, ok := f.command(root, handled, filter, extra); ok {
+			plans = append(plans, testPlan{framework: f, command: quoteAll(sh, args), coverage: coverage})
+		}
+	}
+	return plans, nil
+}
+
+// relativeFiles returns the files inside root, relative to it.
+func relativeFiles(root string, files []string) []string {
+	rel := []string{}
+	for _, file := range files {
+		if r, ok := relativeTo(root, file); ok {
+			rel = append(rel, r)
+		}
+	}
+	return rel
+}
+
+// relativeTo returns file, absolute or relative to root, relative to root,
+// or false when it isn't in root.
+func relativeTo(root, file string) (string, bool) {
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(root, file)
+	}
+	rel, err := filepath.Rel(root, file)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
+
+func NewRunTestsTool(permissions permission.Service, workingDir string, shellType shell.ShellType, cfg config.ToolRunTests) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		RunTestsToolName,
+		string(runTestsDescription),
+		func(ctx context.Context, params RunTestsParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for running tests")
+			}
+
+			var files []string
+			if !params.All {
+				files = params.Files
+				if len(files) == 0 {
+					changed, err := fsext.GitChangedFiles(ctx, workingDir)
+					if err != nil {
+						return fantasy.NewTextErrorResponse("could not list the changed files, provide files or set all: " + err.Error()), nil
+					}
+					files = changed
+				}
+				files = relativeFiles(workingDir, files)
+				if len(files) == 0 {
+					return fantasy.NewTextResponse("No changed files in the working directory, so there are no related tests to run. Set all to run all the tests."), nil
+				}
+			}
+
+			coverageDir, err := os.MkdirTemp("", "crush-coverage-")
+			if err != nil {
+				return fantasy.ToolResponse{}, fmt.Errorf("error creating coverage directory: %w", err)
+			}
+			defer os.RemoveAll(coverageDir)
+
+			plans, err := planTests(workingDir, files, params.Filter, shellType, cfg, coverageDir)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			if len(plans) == 0 {
+				return fantasy.NewTextResponse("No tests are related to the files. Set all to run all the tests."), nil
+			}
+
+			commands := make([]string, len(plans))
+			for i, p := range plans {
+				commands[i] = p.command
+			}
+			command := strings.Join(commands, "\n")
+			p := permissions.Request(
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        workingDir,
+					ToolCallID:  call.ID,
+					ToolName:    RunTestsToolName,
+					Action:      "execute",
+					Description: fmt.Sprintf("Run tests: %s", command),
+					Params: BashPermissionsParams{
+						Description: "Run tests",
+						Command:     command,
+					},
+				},
+			)
+			if !p {
+				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+			}
+
+			metadata := RunTestsResponseMetadata{StartTime: time.Now().UnixMilli()}
+			hits := make(lineHits)
+			for _, plan := range plans {
+				sh := shell.NewShell(&shell.Options{
+					WorkingDir: workingDir,
+					Type:       shellType,
+					BlockFuncs: blockFuncs(),
+				})
+				stdout, stderr, execErr := sh.Exec(ctx, plan.command)
+				if shell.IsInterrupt(execErr) {
+					return fantasy.NewTextErrorResponse("the tests were interrupted"), nil
+				}
+				exitCode := shell.ExitCode(execErr)
+				if exitCode == 0 && execErr != nil {
+					return fantasy.ToolResponse{}, fmt.Errorf("error running tests: %w", execErr)
+				}
+				metadata.Runs = append(metadata.Runs, TestRun{
+					Framework: plan.framework.name,
+					Command:   plan.command,
+					ExitCode:  exitCode,
+				})
+
+				results := plan.framework.parse(stdout)
+				if len(results) == 0 && exitCode != 0 {
+					// Nothing ran, so the output is of what kept the tests
+					// from running.
+					results = []TestResult{{
+						Name:   "(build)",
+						Status: TestFailed,
+						Output: strings.TrimSpace(stdout + "\n" + stderr),
+					}}
+				}
+				for _, r := range results {
+					switch r.Status {
+					case TestPassed:
+						metadata.Passed++
+					case TestSkipped:
+						metadata.Skipped++
+					case TestFailed:
+						metadata.Failed++
+						r.Output = tailOutput(r.Output, maxFailureOutput)
+						metadata.Failures = append(metadata.Failures, r)
+					}
+				}
+
+				if plan.coverage {
+					planHits, err := plan.framework.parseCoverage(workingDir, coverageDir)
+					if err != nil {
+						slog.Debug("Could not read test coverage", "framework", plan.framework.name, "error", err)
+						continue
+					}
+					for file, lines := range planHits {
+						for line, hit := range lines {
+							hits.add(file, line, hit)
+						}
+					}
+				}
+			}
+			metadata.Coverage = summarizeCoverage(hits, files)
+			metadata.EndTime = time.Now().UnixMilli()
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(formatTestResults(metadata)), metadata), nil
+		})
+}
+
+// tailOutput returns the end of output, where the reason of a failure
+// usually is, when it is longer than limit.
+func tailOutput(output string, limit int) string {
+	output = strings.TrimSpace(output)
+	if len(output) <= limit {
+		return output
+	}
+	return "... (truncated)\n" + output[len(output)-limit:]
+}
+
+// formatTestResults returns the results as text for the model.
+func formatTestResults(m RunTestsResponseMetadata) string {
+	var sb strings.Builder
+	for _, run := range m.Runs {
+		fmt.Fprintf(&sb, "<command framework=%q exit_code=\"%d\">%s</command>\n", run.Framework, run.ExitCode, run.Command)
+	}
+	fmt.Fprintf(&sb, "\n%d passed, %d failed, %d skipped\n", m.Passed, m.Failed, m.Skipped)
+	for _, f := range m.Failures {
+		name := f.Name
+		if f.Location != "" {
+			name = f.Location + " " + name
+		}
+		fmt.Fprintf(&sb, "\n<failure test=%q>\n%s\n</failure>\n", name, f.Output)
+	}
+	if len(m.Coverage) > 0 {
+		sb.WriteString("\n<coverage>\n")
+		for _, fc := range m.Coverage {
+			fmt.Fprintf(&sb, "%s: %d%% of %d lines", fc.File, fc.Percent(), fc.Lines)
+			if len(fc.Uncovered) > 0 {
+				ranges := make([]string, len(fc.Uncovered))
+				for i, r := range fc.Uncovered {
+					ranges[i] = r.String()
+				}
+				fmt.Fprintf(&sb, ", not run: %s", strings.Join(ranges, ", "))
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("</coverage>\n")
+	}
+	return sb.String()
+}
@@ -2,21 +2,27 @@ package tools
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	_ "embed"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
 	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/httpext"
 )
 
 type SourcegraphParams struct {
 	Query         string `json:"query" description:"The Sourcegraph search query"`
+	PatternType   string `json:"pattern_type,omitempty" description:"How the query is interpreted: keyword (default), standard, regexp or structural"`
 	Count         int    `json:"count,omitempty" description:"Optional number of results to return (default: 10, max: 20)"`
+	Page          int    `json:"page,omitempty" description:"Optional page of results to return, starting at 1 (default: 1)"`
 	ContextWindow int    `json:"context_window,omitempty" description:"The context around the match to return (default: 10 lines)"`
 	Timeout       int    `json:"timeout,omitempty" description:"Optional timeout in seconds (max 120)"`
 }
@@ -24,24 +30,66 @@ type SourcegraphParams struct {
 type SourcegraphResponseMetadata struct {
 	NumberOfMatches int  `json:"number_of_matches"`
 	Truncated       bool `json:"truncated"`
+	// HasMore is set when there's a next page of results.
+	HasMore bool `json:"has_more,omitempty"`
 }
 
-const SourcegraphToolName = "sourcegraph"
+const (
+	SourcegraphToolName = "sourcegraph"
+
+	// sourcegraphResultLimit is how many results Sourcegraph returns for
+	// queries without a count filter.
+	sourcegraphResultLimit = 30
+)
 
 //go:embed sourcegraph.md
 var sourcegraphDescription []byte
 
-func NewSourcegraphTool(client *http.Client) fantasy.AgentTool {
+// sourcegraphPatternTypes are the pattern types the tool accepts.
+var sourcegraphPatternTypes = []string{"keyword", "standard", "regexp", "structural"}
+
+// sourcegraphResponse is the response to the search query of the tool.
+type sourcegraphResponse struct {
+	Data struct {
+		Search struct {
+			Results struct {
+				MatchCount  int                 `json:"matchCount"`
+				LimitHit    bool                `json:"limitHit"`
+				ResultCount int                 `json:"resultCount"`
+				Results     []sourcegraphResult `json:"results"`
+			} `json:"results"`
+		} `json:"search"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+type sourcegraphResult struct {
+	TypeName   string `json:"__typename"`
+	Repository struct {
+		Name string `json:"name"`
+	} `json:"repository"`
+	File struct {
+		Path    string `json:"path"`
+		URL     string `json:"url"`
+		Content string `json:"content"`
+	} `json:"file"`
+	LineMatches []struct {
+		Preview string `json:"preview"`
+		// LineNumber starts at 0.
+		LineNumber int `json:"lineNumber"`
+	} `json:"lineMatches"`
+}
+
+// NewSourcegraphTool returns the sourcegraph tool, which searches the
+// instance opts point to, with its token if there's one.
+func NewSourcegraphTool(client *http.Client, opts config.ToolSourcegraph) fantasy.AgentTool {
 	if client == nil {
-		client = &http.Client{
-			Timeout: 30 * time.Second,
-			Transport: &http.Transport{
-				MaxIdleConns:        100,
-				MaxIdleConnsPerHost: 10,
-				IdleConnTimeout:     90 * time.Second,
-			},
-		}
+		client = httpext.NewPooledClient(30 * time.Second)
 	}
+	endpoint := opts.Endpoint()
+	token := opts.ResolvedToken()
 	return fantasy.NewAgentTool(
 		SourcegraphToolName,
 		string(sourcegraphDescription),
@@ -50,11 +98,17 @@ func NewSourcegraphTool(client *http.Client) fantasy.AgentTool {
 				return fantasy.NewTextErrorResponse("Query parameter is required"), nil
 			}
 
+			params.PatternType = cmp.Or(strings.ToLower(params.PatternType), "keyword")
+			if !slices.Contains(sourcegraphPatternTypes, params.PatternType) {
+				return fantasy.NewTextErrorResponse("pattern_type must be one of: " + strings.Join(sourcegraphPatternTypes, ", ")), nil
+			}
+
 			if params.Count <= 0 {
 				params.Count = 10
 			} else if params.Count > 20 {
 				params.Count = 20 // Limit to 20 results
 			}
+			params.Page = max(params.Page, 1)
 
 			if params.ContextWindow <= 0 {
 				params.ContextWindow = 10 // Default context window
@@ -72,6 +126,15 @@ func NewSourcegraphTool(client *http.Client) fantasy.AgentTool {
 				defer cancel()
 			}
 
+			// Results of the pages before this one are fetched again to be
+			// skipped. Matches rather than results count towards the limit,
+			// so ask for twice as many.
+			offset := (params.Page - 1) * params.Count
+			query := params.Query
+			if limit := 2 * (offset + params.Count); limit > sourcegraphResultLimit && !strings.Contains(query, "count:") {
+				query += fmt.Sprintf(" count:%d", limit)
+			}
+
 			type graphqlRequest struct {
 				Query     string `json:"query"`
 				Variables struct {
@@ -80,9 +143,9 @@ func NewSourcegraphTool(client *http.Client) fantasy.AgentTool {
 			}
 
 			request := graphqlRequest{
-				Query: "query Search($query: String!) { search(query: $query, version: V2, patternType: keyword ) { results { matchCount, limitHit, resultCount, approximateResultCount, missing { name }, timedout { name }, indexUnavailable, results { __typename, ... on FileMatch { repository { name }, file { path, url, content }, lineMatches { preview, lineNumber, offsetAndLengths } } } } } }",
+				Query: "query Search($query: String!) { search(query: $query, version: V2, patternType: " + params.PatternType + " ) { results { matchCount, limitHit, resultCount, approximateResultCount, missing { name }, timedout { name }, indexUnavailable, results { __typename, ... on FileMatch { repository { name }, file { path, url, content }, lineMatches { preview, lineNumber, offsetAndLengths } } } } } }",
 			}
-			request.Variables.Query = params.Query
+			request.Variables.Query = query
 
 			graphqlQueryBytes, err := json.Marshal(request)
 			if err != nil {
@@ -93,7 +156,7 @@ func NewSourcegraphTool(client *http.Client) fantasy.AgentTool {
 			req, err := http.NewRequestWithContext(
 				requestCtx,
 				"POST",
-				"https://sourcegraph.com/.api/graphql",
+				endpoint+"/.api/graphql",
 				bytes.NewBuffer([]byte(graphqlQuery)),
 			)
 			if err != nil {
@@ -102,6 +165,9 @@ func NewSourcegraphTool(client *http.Client) fantasy.AgentTool {
 
 			req.Header.Set("Content-Type", "application/json")
 			req.Header.Set("User-Agent", "crush/1.0")
+			if token != "" {
+				req.Header.Set("Authorization", "token "+token)
+			}
 
 			resp, err := client.Do(req)
 			if err != nil {
@@ -122,146 +188,98 @@ func NewSourcegraphTool(client *http.Client) fantasy.AgentTool {
 				return fantasy.ToolResponse{}, fmt.Errorf("failed to read response body: %w", err)
 			}
 
-			var result map[string]any
+			var result sourcegraphResponse
 			if err = json.Unmarshal(body, &result); err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 			}
 
-			formattedResults, err := formatSourcegraphResults(result, params.ContextWindow)
-			if err != nil {
-				return fantasy.NewTextErrorResponse("Failed to format results: " + err.Error()), nil
-			}
-
-			return fantasy.NewTextResponse(formattedResults), nil
+			formattedResults, metadata := formatSourcegraphResults(result, endpoint, params, offset)
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(formattedResults), metadata), nil
 		})
 }
 
-func formatSourcegraphResults(result map[string]any, contextWindow int) (string, error) {
+// formatSourcegraphResults formats the page of results of params starting
+// at offset, each match referenced as repository/path:line with a link to
+// the line on the instance at endpoint.
+func formatSourcegraphResults(result sourcegraphResponse, endpoint string, params SourcegraphParams, offset int) (string, SourcegraphResponseMetadata) {
 	var buffer strings.Builder
 
-	if errors, ok := result["errors"].([]any); ok && len(errors) > 0 {
+	if len(result.Errors) > 0 {
 		buffer.WriteString("## Sourcegraph API Error\n\n")
-		for _, err := range errors {
-			if errMap, ok := err.(map[string]any); ok {
-				if message, ok := errMap["message"].(string); ok {
-					buffer.WriteString(fmt.Sprintf("- %s\n", message))
-				}
-			}
+		for _, err := range result.Errors {
+			buffer.WriteString(fmt.Sprintf("- %s\n", err.Message))
 		}
-		return buffer.String(), nil
-	}
-
-	data, ok := result["data"].(map[string]any)
-	if !ok {
-		return "", fmt.Errorf("invalid response format: missing data field")
-	}
-
-	search, ok := data["search"].(map[string]any)
-	if !ok {
-		return "", fmt.Errorf("invalid response format: missing search field")
+		return buffer.String(), SourcegraphResponseMetadata{}
 	}
 
-	searchResults, ok := search["results"].(map[string]any)
-	if !ok {
-		return "", fmt.Errorf("invalid response format: missing results field")
+	search := result.Data.Search.Results
+	metadata := SourcegraphResponseMetadata{
+		NumberOfMatches: search.MatchCount,
+		Truncated:       search.LimitHit,
 	}
 
-	matchCount, _ := searchResults["matchCount"].(float64)
-	resultCount, _ := searchResults["resultCount"].(float64)
-	limitHit, _ := searchResults["limitHit"].(bool)
-
 	buffer.WriteString("# Sourcegraph Search Results\n\n")
-	buffer.WriteString(fmt.Sprintf("Found %d matches across %d results\n", int(matchCount), int(resultCount)))
+	buffer.WriteString(fmt.Sprintf("Found %d matches across %d results\n", search.MatchCount, search.ResultCount))
 
-	if limitHit {
+	if search.LimitHit {
 		buffer.WriteString("(Result limit reached, try a more specific query)\n")
 	}
 
-	buffer.WriteString("\n")
-
-	results, ok := searchResults["results"].([]any)
-	if !ok || len(results) == 0 {
-		buffer.WriteString("No results found. Try a different query.\n")
-		return buffer.String(), nil
+	var files []sourcegraphResult
+	for _, res := range search.Results {
+		if res.TypeName == "FileMatch" {
+			files = append(files, res)
+		}
 	}
-
-	maxResults := 10
-	if len(results) > maxResults {
-		results = results[:maxResults]
+	if len(files) == 0 {
+		buffer.WriteString("\nNo results found. Try a different query.\n")
+		return buffer.String(), metadata
+	}
+	if offset >= len(files) {
+		buffer.WriteString(fmt.Sprintf("\nNo results on page %d, there are %d results.\n", params.Page, len(files)))
+		return buffer.String(), metadata
 	}
 
-	for i, res := range results {
-		fileMatch, ok := res.(map[string]any)
-		if !ok {
-			continue
-		}
-
-		typeName, _ := fileMatch["__typename"].(string)
-		if typeName != "FileMatch" {
-			continue
-		}
-
-		repo, _ := fileMatch["repository"].(map[string]any)
-		file, _ := fileMatch["file"].(map[string]any)
-		lineMatches, _ := fileMatch["lineMatches"].([]any)
-
-		if repo == nil || file == nil {
-			continue
-		}
-
-		repoName, _ := repo["name"].(string)
-		filePath, _ := file["path"].(string)
-		fileURL, _ := file["url"].(string)
-		fileContent, _ := file["content"].(string)
+	end := min(offset+params.Count, len(files))
+	metadata.HasMore = end < len(files) || search.LimitHit
+	buffer.WriteString(fmt.Sprintf("Showing results %d-%d\n\n", offset+1, end))
 
-		buffer.WriteString(fmt.Sprintf("## Result %d: %s/%s\n\n", i+1, repoName, filePath))
+	for i, file := range files[offset:end] {
+		name := file.Repository.Name + "/" + file.File.Path
+		buffer.WriteString(fmt.Sprintf("## Result %d: %s\n\n", offset+i+1, name))
 
-		if fileURL != "" {
-			buffer.WriteString(fmt.Sprintf("URL: %s\n\n", fileURL))
-		}
+		lines := strings.Split(file.File.Content, "\n")
+		seen := map[int]bool{}
+		for _, lineMatch := range file.LineMatches {
+			lineNumber := lineMatch.LineNumber + 1
+			if seen[lineNumber] {
+				continue
+			}
+			seen[lineNumber] = true
 
-		if len(lineMatches) > 0 {
-			for _, lm := range lineMatches {
-				lineMatch, ok := lm.(map[string]any)
-				if !ok {
-					continue
+			buffer.WriteString(fmt.Sprintf("%s:%d", name, lineNumber))
+			if file.File.URL != "" {
+				buffer.WriteString(fmt.Sprintf(" (%s%s?L%d)", endpoint, file.File.URL, lineNumber))
+			}
+			buffer.WriteString("\n```\n")
+			if file.File.Content != "" {
+				for j := max(1, lineNumber-params.ContextWindow); j < lineNumber && j <= len(lines); j++ {
+					buffer.WriteString(fmt.Sprintf("%d| %s\n", j, lines[j-1]))
 				}
-
-				lineNumber, _ := lineMatch["lineNumber"].(float64)
-				preview, _ := lineMatch["preview"].(string)
-
-				if fileContent != "" {
-					lines := strings.Split(fileContent, "\n")
-
-					buffer.WriteString("```\n")
-
-					startLine := max(1, int(lineNumber)-contextWindow)
-
-					for j := startLine - 1; j < int(lineNumber)-1 && j < len(lines); j++ {
-						if j >= 0 {
-							buffer.WriteString(fmt.Sprintf("%d| %s\n", j+1, lines[j]))
-						}
-					}
-
-					buffer.WriteString(fmt.Sprintf("%d|  %s\n", int(lineNumber), preview))
-
-					endLine := int(lineNumber) + contextWindow
-
-					for j := int(lineNumber); j < endLine && j < len(lines); j++ {
-						if j < len(lines) {
-							buffer.WriteString(fmt.Sprintf("%d| %s\n", j+1, lines[j]))
-						}
-					}
-
-					buffer.WriteString("```\n\n")
-				} else {
-					buffer.WriteString("```\n")
-					buffer.WriteString(fmt.Sprintf("%d| %s\n", int(lineNumber), preview))
-					buffer.WriteString("```\n\n")
+			}
+			buffer.WriteString(fmt.Sprintf("%d|  %s\n", lineNumber, lineMatch.Preview))
+			if file.File.Content != "" {
+				for j := lineNumber + 1; j <= lineNumber+params.ContextWindow && j <= len(lines); j++ {
+					buffer.WriteString(fmt.Sprintf("%d| %s\n", j, lines[j-1]))
 				}
 			}
+			buffer.WriteString("```\n\n")
 		}
 	}
 
-	return buffer.String(), nil
+	if metadata.HasMore {
+		buffer.WriteString(fmt.Sprintf("There are more results: call the tool again with page %d to see them.\n", params.Page+1))
+	}
+
+	return buffer.String(), metadata
 }
@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourcegraph(t *testing.T) {
+	t.Parallel()
+
+	type graphqlRequest struct {
+		Query     string `json:"query"`
+		Variables struct {
+			Query string `json:"query"`
+		} `json:"variables"`
+	}
+	requests := make(chan graphqlRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/.api/graphql", r.URL.Path)
+		assert.Equal(t, "token secret", r.Header.Get("Authorization"))
+		var req graphqlRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests <- req
+
+		var results []string
+		for i := range 25 {
+			results = append(results, fmt.Sprintf(`{"__typename": "FileMatch", "repository": {"name": "git.example.com/app"}, "file": {"path": "file%d.go", "url": "/git.example.com/app/-/blob/file%d.go", "content": "package app\n\nfunc main() {\n}"}, "lineMatches": [{"preview": "func main() {", "lineNumber": 2}]}`, i+1, i+1))
+		}
+		fmt.Fprintf(w, `{"data": {"search": {"results": {"matchCount": 25, "limitHit": false, "resultCount": 25, "results": [%s]}}}}`, strings.Join(results, ","))
+	}))
+	t.Cleanup(server.Close)
+
+	tool := NewSourcegraphTool(server.Client(), config.ToolSourcegraph{URL: server.URL + "/", Token: "secret"})
+	run := func(params SourcegraphParams) fantasy.ToolResponse {
+		t.Helper()
+		input, err := json.Marshal(params)
+		require.NoError(t, err)
+		resp, err := tool.Run(context.Background(), fantasy.ToolCall{ID: "call", Name: SourcegraphToolName, Input: string(input)})
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := run(SourcegraphParams{Query: "func main() {:[body]}", PatternType: "structural", ContextWindow: 1})
+	require.False(t, resp.IsError, resp.Content)
+	req := <-requests
+	require.Contains(t, req.Query, "patternType: structural")
+	require.Equal(t, "func main() {:[body]}", req.Variables.Query)
+	require.Contains(t, resp.Content, "Showing results 1-10\n")
+	require.Contains(t, resp.Content, "## Result 1: git.example.com/app/file1.go\n\n"+
+		"git.example.com/app/file1.go:3 ("+server.URL+"/git.example.com/app/-/blob/file1.go?L3)\n"+
+		"```\n2| \n3|  func main() {\n4| }\n```\n")
+	require.Contains(t, resp.Content, "call the tool again with page 2")
+	var meta SourcegraphResponseMetadata
+	require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+	require.Equal(t, SourcegraphResponseMetadata{NumberOfMatches: 25, HasMore: true}, meta)
+
+	resp = run(SourcegraphParams{Query: "lang:go main", Count: 20, Page: 2})
+	require.False(t, resp.IsError, resp.Content)
+	require.Equal(t, "lang:go main count:80", (<-requests).Variables.Query)
+	require.Contains(t, resp.Content, "Showing results 21-25\n")
+	require.Contains(t, resp.Content, "## Result 21: git.example.com/app/file21.go")
+	require.NotContains(t, resp.Content, "page 3")
+
+	resp = run(SourcegraphParams{Query: "main", PatternType: "fuzzy"})
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "pattern_type must be one of")
+}
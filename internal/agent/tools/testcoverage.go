@@ -0,0 +1,252 @@
+package tools
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxCoverageFiles is the most files coverage is reported for when all the
+// tests ran.
+const maxCoverageFiles = 20
+
+// LineRange is a range of lines, both included.
+type LineRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+func (r LineRange) String() string {
+	if r.Start == r.End {
+		return strconv.Itoa(r.Start)
+	}
+	return fmt.Sprintf("%d-%d", r.Start, r.End)
+}
+
+// FileCoverage is how much of a file the tests ran.
+type FileCoverage struct {
+	// File is relative to the working directory.
+	File string `json:"file"`
+	// Lines counts the lines with code, Covered the ones the tests ran.
+	Lines     int         `json:"lines"`
+	Covered   int         `json:"covered"`
+	Uncovered []LineRange `json:"uncovered,omitempty"`
+}
+
+// Percent returns the share of the lines of the file the tests ran.
+func (fc FileCoverage) Percent() int {
+	if fc.Lines == 0 {
+		return 100
+	}
+	return fc.Covered * 100 / fc.Lines
+}
+
+// lineHits maps the files, relative to the root with forward slashes, to
+// whether the tests ran each of their lines with code.
+type lineHits map[string]map[int]bool
+
+// add records whether the tests ran a line. A line is run when any of the
+// code on it is.
+func (h lineHits) add(file string, line int, hit bool) {
+	if h[file] == nil {
+		h[file] = make(map[int]bool)
+	}
+	h[file][line] = h[file][line] || hit
+}
+
+// summarizeCoverage returns the coverage of files, paths relative to the
+// root, or of the files with the most lines the tests didn't run when files
+// is nil.
+func summarizeCoverage(hits lineHits, files []string) []FileCoverage {
+	var wanted map[string]bool
+	if files != nil {
+		wanted = make(map[string]bool)
+		for _, f := range files {
+			wanted[filepath.ToSlash(f)] = true
+		}
+	}
+
+	var summary []FileCoverage
+	uncoveredLines := make(map[string]int)
+	for file, lines := range hits {
+		if wanted != nil && !wanted[file] {
+			continue
+		}
+		fc := FileCoverage{File: filepath.FromSlash(file), Lines: len(lines)}
+		var missed []int
+		for line, hit := range lines {
+			if hit {
+				fc.Covered++
+			} else {
+				missed = append(missed, line)
+			}
+		}
+		if wanted == nil && len(missed) == 0 {
+			continue
+		}
+		slices.Sort(missed)
+		for _, line := range missed {
+			if n := len(fc.Uncovered); n > 0 && fc.Uncovered[n-1].End == line-1 {
+				fc.Uncovered[n-1].End = line
+				continue
+			}
+			fc.Uncovered = append(fc.Uncovered, LineRange{Start: line, End: line})
+		}
+		uncoveredLines[fc.File] = len(missed)
+		summary = append(summary, fc)
+	}
+
+	if wanted != nil {
+		slices.SortFunc(summary, func(a, b FileCoverage) int { return strings.Compare(a.File, b.File) })
+		return summary
+	}
+	slices.SortFunc(summary, func(a, b FileCoverage) int {
+		return cmp.Or(
+			cmp.Compare(uncoveredLines[b.File], uncoveredLines[a.File]),
+			strings.Compare(a.File, b.File),
+		)
+	})
+	if len(summary) > maxCoverageFiles {
+		summary = summary[:maxCoverageFiles]
+	}
+	return summary
+}
+
+// relativeCoverageFile returns the path of a file coverage names relative to
+// root, with forward slashes, or false when it isn't in root.
+func relativeCoverageFile(root, file string) (string, bool) {
+	rel, ok := relativeTo(root, file)
+	return filepath.ToSlash(rel), ok
+}
+
+const goCoverageFile = "cover.out"
+
+func goCoverageArgs(dir string) []string {
+	return []string{"-coverprofile=" + filepath.Join(dir, goCoverageFile)}
+}
+
+var (
+	goModulePath = regexp.MustCompile(`(?m)^module\s+"?([^"\s]+)"?`)
+	goCoverBlock = regexp.MustCompile(`^(.+):(\d+)\.\d+,(\d+)\.\d+ \d+ (\d+)$`)
+)
+
+// parseGoCoverage reads a cover profile, where files are named by their
+// import path.
+func parseGoCoverage(root, dir string) (lineHits, error) {
+	mod, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+	m := goModulePath.FindSubmatch(mod)
+	if m == nil {
+		return nil, fmt.Errorf("no module path in go.mod")
+	}
+	prefix := string(m[1]) + "/"
+
+	f, err := os.Open(filepath.Join(dir, goCoverageFile))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hits := make(lineHits)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		m := goCoverBlock.FindStringSubmatch(scanner.Text())
+		if m == nil || !strings.HasPrefix(m[1], prefix) {
+			continue
+		}
+		file := strings.TrimPrefix(m[1], prefix)
+		start, _ := strconv.Atoi(m[2])
+		end, _ := strconv.Atoi(m[3])
+		count, _ := strconv.Atoi(m[4])
+		for line := start; line <= end; line++ {
+			hits.add(file, line, count > 0)
+		}
+	}
+	return hits, scanner.Err()
+}
+
+func jestCoverageArgs(dir string) []string {
+	return []string{"--coverage", "--coverageReporters=json", "--coverageDirectory=" + dir}
+}
+
+// jestCoverage is the coverage-final.json file of istanbul, by file.
+type jestCoverage map[string]struct {
+	StatementMap map[string]struct {
+		Start struct{ Line int } `json:"start"`
+		End   struct{ Line int } `json:"end"`
+	} `json:"statementMap"`
+	S map[string]int `json:"s"`
+}
+
+func parseJestCoverage(root, dir string) (lineHits, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "coverage-final.json"))
+	if err != nil {
+		return nil, err
+	}
+	var coverage jestCoverage
+	if err := json.Unmarshal(data, &coverage); err != nil {
+		return nil, err
+	}
+	hits := make(lineHits)
+	for path, fc := range coverage {
+		file, ok := relativeCoverageFile(root, path)
+		if !ok {
+			continue
+		}
+		for id, statement := range fc.StatementMap {
+			for line := statement.Start.Line; line <= statement.End.Line; line++ {
+				hits.add(file, line, fc.S[id] > 0)
+			}
+		}
+	}
+	return hits, nil
+}
+
+const pytestCoverageFile = "coverage.json"
+
+// pytestCoverageArgs needs the pytest-cov plugin.
+func pytestCoverageArgs(dir string) []string {
+	return []string{"--cov=.", "--cov-report=json:" + filepath.Join(dir, pytestCoverageFile)}
+}
+
+// pytestCoverage is the JSON report of coverage.py.
+type pytestCoverage struct {
+	Files map[string]struct {
+		ExecutedLines []int `json:"executed_lines"`
+		MissingLines  []int `json:"missing_lines"`
+	} `json:"files"`
+}
+
+func parsePytestCoverage(root, dir string) (lineHits, error) {
+	data, err := os.ReadFile(filepath.Join(dir, pytestCoverageFile))
+	if err != nil {
+		return nil, err
+	}
+	var coverage pytestCoverage
+	if err := json.Unmarshal(data, &coverage); err != nil {
+		return nil, err
+	}
+	hits := make(lineHits)
+	for path, fc := range coverage.Files {
+		file, ok := relativeCoverageFile(root, path)
+		if !ok {
+			continue
+		}
+		for _, line := range fc.ExecutedLines {
+			hits.add(file, line, true)
+		}
+		for _, line := range fc.MissingLines {
+			hits.add(file, line, false)
+		}
+	}
+	return hits, nil
+}
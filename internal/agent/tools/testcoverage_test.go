@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanTestsCoverage(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":          "module example.com/m\n",
+		"a.go":            "package m\n",
+		"pytest.ini":      "[pytest]\n",
+		"calc.py":         "",
+		"test_calc.py":    "",
+		"jest.config.cjs": "",
+	})
+	files := []string{"a.go", "calc.py", "web/app.js"}
+
+	plans, err := planTests(root, files, "", shell.ShellTypePOSIX, config.ToolRunTests{}, "/tmp/cov")
+	require.NoError(t, err)
+	require.Len(t, plans, 3)
+	require.Equal(t, "go test -json -coverprofile=/tmp/cov/cover.out .", plans[0].command)
+	require.True(t, plans[0].coverage)
+	require.Equal(t, "npx jest --json --passWithNoTests --coverage --coverageReporters=json --coverageDirectory=/tmp/cov --findRelatedTests web/app.js", plans[1].command)
+	require.True(t, plans[1].coverage)
+	// pytest needs a plugin for coverage, so it is off by default.
+	require.Equal(t, "pytest -rA -q test_calc.py", plans[2].command)
+	require.False(t, plans[2].coverage)
+
+	cfg := config.ToolRunTests{Coverage: map[string]bool{"go": false, "pytest": true}}
+	plans, err = planTests(root, files, "", shell.ShellTypePOSIX, cfg, "/tmp/cov")
+	require.NoError(t, err)
+	require.Equal(t, "go test -json .", plans[0].command)
+	require.False(t, plans[0].coverage)
+	require.Equal(t, "pytest -rA -q --cov=. --cov-report=json:/tmp/cov/coverage.json test_calc.py", plans[2].command)
+	require.True(t, plans[2].coverage)
+}
+
+func TestParseGoCoverage(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	dir := t.TempDir()
+	writeFiles(t, root, map[string]string{"go.mod": "module example.com/m\n\ngo 1.25\n"})
+	writeFiles(t, dir, map[string]string{goCoverageFile: `mode: set
+example.com/m/pkg/a.go:3.20,5.2 1 1
+example.com/m/pkg/a.go:5.2,8.3 2 0
+example.com/m/pkg/a.go:10.1,10.9 1 0
+example.com/other/b.go:1.1,2.2 1 0
+`})
+
+	hits, err := parseGoCoverage(root, dir)
+	require.NoError(t, err)
+	require.Equal(t, lineHits{"pkg/a.go": {3: true, 4: true, 5: true, 6: false, 7: false, 8: false, 10: false}}, hits)
+}
+
+func TestParseJestCoverage(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"coverage-final.json": `{
+  "` + filepath.ToSlash(filepath.Join(root, "src", "sum.js")) + `": {
+    "statementMap": {
+      "0": {"start": {"line": 1, "column": 0}, "end": {"line": 1, "column": 20}},
+      "1": {"start": {"line": 3, "column": 2}, "end": {"line": 4, "column": 3}}
+    },
+    "s": {"0": 2, "1": 0}
+  }
+}`})
+
+	hits, err := parseJestCoverage(root, dir)
+	require.NoError(t, err)
+	require.Equal(t, lineHits{"src/sum.js": {1: true, 3: false, 4: false}}, hits)
+}
+
+func TestParsePytestCoverage(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{pytestCoverageFile: `{"files": {
+  "app/calc.py": {"executed_lines": [1, 2, 4], "missing_lines": [5, 6]}
+}}`})
+
+	hits, err := parsePytestCoverage(root, dir)
+	require.NoError(t, err)
+	require.Equal(t, lineHits{"app/calc.py": {1: true, 2: true, 4: true, 5: false, 6: false}}, hits)
+}
+
+func TestSummarizeCoverage(t *testing.T) {
+	t.Parallel()
+
+	hits := lineHits{
+		"a.go":     {1: true, 2: false, 3: false, 5: false, 6: true},
+		"b.go":     {1: true},
+		"c/c.go":   {1: false, 2: false, 3: false, 4: false},
+		"other.go": {1: true, 2: false},
+	}
+
+	require.Equal(t, []FileCoverage{
+		{File: "a.go", Lines: 5, Covered: 2, Uncovered: []LineRange{{2, 3}, {5, 5}}},
+		{File: "b.go", Lines: 1, Covered: 1},
+	}, summarizeCoverage(hits, []string{"a.go", "b.go", "README.md"}))
+
+	// With all the tests, the files the tests ran completely are left out
+	// and the others come by the lines the tests didn't run.
+	summary := summarizeCoverage(hits, nil)
+	require.Len(t, summary, 3)
+	require.Equal(t, filepath.FromSlash("c/c.go"), summary[0].File)
+	require.Equal(t, "a.go", summary[1].File)
+	require.Equal(t, "other.go", summary[2].File)
+
+	text := formatTestResults(RunTestsResponseMetadata{Coverage: summarizeCoverage(hits, []string{"a.go"})})
+	require.Contains(t, text, "<coverage>\na.go: 40% of 5 lines, not run: 2-3, 5\n</coverage>")
+}
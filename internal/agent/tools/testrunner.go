@@ -0,0 +1,488 @@
+package tools
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/shell"
+)
+
+// TestStatus is the outcome of a test.
+type TestStatus string
+
+const (
+	TestPassed  TestStatus = "passed"
+	TestFailed  TestStatus = "failed"
+	TestSkipped TestStatus = "skipped"
+)
+
+// TestResult is the outcome of a test, with its output when it failed.
+type TestResult struct {
+	Name string `json:"name"`
+	// Location is the package, file or crate of the test, when known.
+	Location string     `json:"location,omitempty"`
+	Status   TestStatus `json:"status"`
+	Output   string     `json:"output,omitempty"`
+}
+
+// testFramework runs the tests of a kind of project.
+type testFramework struct {
+	name string
+	// detect reports whether the project in root uses the framework.
+	detect func(root string) bool
+	// handles reports whether the framework runs the tests of file.
+	handles func(file string) bool
+	// command returns the arguments of the command that runs the tests
+	// related to files, paths relative to root, or all the tests when files
+	// is nil, with extra among its flags. It returns false when no tests
+	// relate to files.
+	command func(root string, files []string, filter string, extra []string) ([]string, bool)
+	// parse returns the results in the output of the command.
+	parse func(stdout string) []TestResult
+	// coverageArgs returns the flags that make the command write coverage
+	// to dir. It is nil for the frameworks coverage isn't supported for.
+	coverageArgs func(dir string) []string
+	// parseCoverage returns the lines the tests ran, from the coverage the
+	// command wrote to dir.
+	parseCoverage func(root, dir string) (lineHits, error)
+}
+
+// testFrameworks are the supported frameworks, in the order they are
+// preferred for a file.
+var testFrameworks = []testFramework{
+	{
+		name:    "go",
+		detect:  func(root string) bool { return fileExists(filepath.Join(root, "go.mod")) },
+		handles: hasExt(".go"),
+		command: goTestCommand,
+		parse:   parseGoTest,
+
+		coverageArgs:  goCoverageArgs,
+		parseCoverage: parseGoCoverage,
+	},
+	{
+		name:    "cargo",
+		detect:  func(root string) bool { return fileExists(filepath.Join(root, "Cargo.toml")) },
+		handles: hasExt(".rs"),
+		command: cargoTestCommand,
+		parse:   parseCargoTest,
+	},
+	{
+		name:    "jest",
+		detect:  detectJest,
+		handles: hasExt(".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs"),
+		command: jestCommand,
+		parse:   parseJest,
+
+		coverageArgs:  jestCoverageArgs,
+		parseCoverage: parseJestCoverage,
+	},
+	{
+		name:    "pytest",
+		detect:  detectPytest,
+		handles: hasExt(".py"),
+		command: pytestCommand,
+		parse:   parsePytest,
+
+		coverageArgs:  pytestCoverageArgs,
+		parseCoverage: parsePytestCoverage,
+	},
+}
+
+func hasExt(exts ...string) func(string) bool {
+	return func(file string) bool {
+		return slices.Contains(exts, strings.ToLower(filepath.Ext(file)))
+	}
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// fileContains reports whether the file at path contains s.
+func fileContains(path, s string) bool {
+	data, err := os.ReadFile(path)
+	return err == nil && strings.Contains(string(data), s)
+}
+
+// quoteAll quotes words for sh.
+func quoteAll(sh shell.ShellType, words []string) string {
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = shell.Quote(sh, w)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// goTestCommand runs the packages of the files.
+func goTestCommand(root string, files []string, filter string, extra []string) ([]string, bool) {
+	pkgs := []string{"./..."}
+	if files != nil {
+		pkgs = nil
+		for _, file := range files {
+			dir := filepath.Dir(file)
+			if matches, _ := filepath.Glob(filepath.Join(root, dir, "*.go")); len(matches) == 0 {
+				continue
+			}
+			pkg := "./" + filepath.ToSlash(dir)
+			if dir == "." {
+				pkg = "."
+			}
+			if !slices.Contains(pkgs, pkg) {
+				pkgs = append(pkgs, pkg)
+			}
+		}
+		if len(pkgs) == 0 {
+			return nil, false
+		}
+		slices.Sort(pkgs)
+	}
+	args := append([]string{"go", "test", "-json"}, extra...)
+	if filter != "" {
+		args = append(args, "-run", filter)
+	}
+	return append(args, pkgs...), true
+}
+
+// goTestEvent is a line of the output of go test -json.
+type goTestEvent struct {
+	Action     string
+	Package    string
+	ImportPath string
+	Test       string
+	Output     string
+}
+
+func parseGoTest(stdout string) []TestResult {
+	type key struct{ pkg, test string }
+	outputs := make(map[key]*strings.Builder)
+	output := func(k key) *strings.Builder {
+		if outputs[k] == nil {
+			outputs[k] = &strings.Builder{}
+		}
+		return outputs[k]
+	}
+
+	var results []TestResult
+	failedTests := make(map[string]bool)
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var e goTestEvent
+		if json.Unmarshal(scanner.Bytes(), &e) != nil {
+			continue
+		}
+		switch e.Action {
+		case "output":
+			output(key{e.Package, e.Test}).WriteString(e.Output)
+		case "build-output":
+			output(key{e.ImportPath, ""}).WriteString(e.Output)
+		case "pass", "fail", "skip":
+			status := map[string]TestStatus{"pass": TestPassed, "fail": TestFailed, "skip": TestSkipped}[e.Action]
+			if e.Test == "" {
+				// A package that fails without a failing test didn't
+				// build, or failed outside of its tests.
+				if status == TestFailed && !failedTests[e.Package] {
+					results = append(results, TestResult{
+						Name:     "(package)",
+						Location: e.Package,
+						Status:   TestFailed,
+						Output:   output(key{e.Package, ""}).String(),
+					})
+				}
+				continue
+			}
+			r := TestResult{Name: e.Test, Location: e.Package, Status: status}
+			if status == TestFailed {
+				failedTests[e.Package] = true
+				r.Output = output(key{e.Package, e.Test}).String()
+			}
+			results = append(results, r)
+		}
+	}
+	return results
+}
+
+// cargoTestCommand runs the crates of the files.
+func cargoTestCommand(root string, files []string, filter string, extra []string) ([]string, bool) {
+	args := append([]string{"cargo", "test"}, extra...)
+	if files != nil {
+		var crates []string
+		for _, file := range files {
+			crate, ok := cargoCrate(root, filepath.Dir(file))
+			if !ok {
+				continue
+			}
+			if !slices.Contains(crates, crate) {
+				crates = append(crates, crate)
+			}
+		}
+		if len(crates) == 0 {
+			return nil, false
+		}
+		slices.Sort(crates)
+		for _, crate := range crates {
+			args = append(args, "-p", crate)
+		}
+	}
+	if filter != "" {
+		args = append(args, filter)
+	}
+	return args, true
+}
+
+var cargoPackageName = regexp.MustCompile(`(?m)^\s*name\s*=\s*"([^"]+)"`)
+
+// cargoCrate returns the name of the crate dir, relative to root, is in.
+func cargoCrate(root, dir string) (string, bool) {
+	for {
+		data, err := os.ReadFile(filepath.Join(root, dir, "Cargo.toml"))
+		if err == nil {
+			if _, pkg, ok := strings.Cut(string(data), "[package]"); ok {
+				if m := cargoPackageName.FindStringSubmatch(pkg); m != nil {
+					return m[1], true
+				}
+			}
+		}
+		if dir == "." || dir == "" {
+			return "", false
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+var (
+	cargoTestLine   = regexp.MustCompile(`^test (\S+) \.\.\. (ok|FAILED|ignored)`)
+	cargoFailureSep = regexp.MustCompile(`^---- (\S+) stdout ----$`)
+)
+
+func parseCargoTest(stdout string) []TestResult {
+	var results []TestResult
+	failures := make(map[string]*strings.Builder)
+	var current *strings.Builder
+	for line := range strings.SplitSeq(stdout, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if m := cargoTestLine.FindStringSubmatch(line); m != nil {
+			status := map[string]TestStatus{"ok": TestPassed, "FAILED": TestFailed, "ignored": TestSkipped}[m[2]]
+			results = append(results, TestResult{Name: m[1], Status: status})
+			current = nil
+			continue
+		}
+		if m := cargoFailureSep.FindStringSubmatch(line); m != nil {
+			current = &strings.Builder{}
+			failures[m[1]] = current
+			continue
+		}
+		if line == "failures:" || strings.HasPrefix(line, "test result:") {
+			current = nil
+			continue
+		}
+		if current != nil {
+			current.WriteString(line + "\n")
+		}
+	}
+	for i, r := range results {
+		if out, ok := failures[r.Name]; ok && r.Status == TestFailed {
+			results[i].Output = out.String()
+		}
+	}
+	return results
+}
+
+func detectJest(root string) bool {
+	if matches, _ := filepath.Glob(filepath.Join(root, "jest.config.*")); len(matches) > 0 {
+		return true
+	}
+	return fileContains(filepath.Join(root, "package.json"), `"jest"`)
+}
+
+// jestCommand runs the tests jest finds related to the files.
+func jestCommand(_ string, files []string, filter string, extra []string) ([]string, bool) {
+	args := append([]string{"npx", "jest", "--json", "--passWithNoTests"}, extra...)
+	if filter != "" {
+		args = append(args, "-t", filter)
+	}
+	if files != nil {
+		args = append(args, "--findRelatedTests")
+		for _, file := range files {
+			args = append(args, filepath.ToSlash(file))
+		}
+	}
+	return args, true
+}
+
+// jestReport is the output of jest --json.
+type jestReport struct {
+	TestResults []struct {
+		Name             string `json:"name"`
+		Status           string `json:"status"`
+		Message          string `json:"message"`
+		AssertionResults []struct {
+			FullName        string   `json:"fullName"`
+			Status          string   `json:"status"`
+			FailureMessages []string `json:"failureMessages"`
+		} `json:"assertionResults"`
+	} `json:"testResults"`
+}
+
+func parseJest(stdout string) []TestResult {
+	start := strings.Index(stdout, "{")
+	if start < 0 {
+		return nil
+	}
+	var report jestReport
+	if json.Unmarshal([]byte(stdout[start:]), &report) != nil {
+		return nil
+	}
+	var results []TestResult
+	for _, file := range report.TestResults {
+		if len(file.AssertionResults) == 0 && file.Status == "failed" {
+			// The file failed to run, like when it doesn't compile.
+			results = append(results, TestResult{Name: "(suite)", Location: file.Name, Status: TestFailed, Output: file.Message})
+			continue
+		}
+		for _, a := range file.AssertionResults {
+			r := TestResult{Name: a.FullName, Location: file.Name}
+			switch a.Status {
+			case "passed":
+				r.Status = TestPassed
+			case "failed":
+				r.Status = TestFailed
+				r.Output = strings.Join(a.FailureMessages, "\n")
+			default:
+				r.Status = TestSkipped
+			}
+			results = append(results, r)
+		}
+	}
+	return results
+}
+
+func detectPytest(root string) bool {
+	return fileExists(filepath.Join(root, "pytest.ini")) ||
+		fileExists(filepath.Join(root, "conftest.py")) ||
+		fileContains(filepath.Join(root, "pyproject.toml"), "[tool.pytest") ||
+		fileContains(filepath.Join(root, "setup.cfg"), "[tool:pytest]") ||
+		fileContains(filepath.Join(root, "tox.ini"), "[pytest]")
+}
+
+// isPytestFile reports whether name is the name of a pytest test file.
+func isPytestFile(name string) bool {
+	return strings.HasPrefix(name, "test_") && strings.HasSuffix(name, ".py") ||
+		strings.HasSuffix(name, "_test.py")
+}
+
+// pytestCommand runs the test files among files, and those named after
+// the other files.
+func pytestCommand(root string, files []string, filter string, extra []string) ([]string, bool) {
+	args := append([]string{"pytest", "-rA", "-q"}, extra...)
+	if filter != "" {
+		args = append(args, "-k", filter)
+	}
+	if files == nil {
+		return args, true
+	}
+
+	var tests []string
+	wanted := make(map[string]bool)
+	for _, file := range files {
+		if isPytestFile(filepath.Base(file)) {
+			if fileExists(filepath.Join(root, file)) {
+				tests = append(tests, filepath.ToSlash(file))
+			}
+			continue
+		}
+		stem := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		wanted["test_"+stem+".py"] = true
+		wanted[stem+"_test.py"] = true
+	}
+	if len(wanted) > 0 {
+		_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			name := d.Name()
+			if d.IsDir() {
+				if path != root && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "venv" || name == "__pycache__") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if wanted[name] {
+				if rel, err := filepath.Rel(root, path); err == nil {
+					tests = append(tests, filepath.ToSlash(rel))
+				}
+			}
+			return nil
+		})
+	}
+	if len(tests) == 0 {
+		return nil, false
+	}
+	slices.Sort(tests)
+	tests = slices.Compact(tests)
+	return append(args, tests...), true
+}
+
+var (
+	pytestSummaryLine = regexp.MustCompile(`^(PASSED|FAILED|ERROR|XFAIL|XPASS) (\S+)`)
+	pytestSkippedLine = regexp.MustCompile(`^SKIPPED \[\d+\] (\S+?):(?:\d+:)? (.*)$`)
+	pytestSection     = regexp.MustCompile(`^_{3,} (.+?) _{3,}$`)
+	pytestHeader      = regexp.MustCompile(`^={3,} (.+?) ={3,}$`)
+)
+
+func parsePytest(stdout string) []TestResult {
+	var results []TestResult
+	failures := make(map[string]*strings.Builder)
+	var current *strings.Builder
+	inFailures := false
+	for line := range strings.SplitSeq(stdout, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if m := pytestHeader.FindStringSubmatch(line); m != nil {
+			inFailures = m[1] == "FAILURES" || m[1] == "ERRORS"
+			current = nil
+			continue
+		}
+		if inFailures {
+			if m := pytestSection.FindStringSubmatch(line); m != nil {
+				current = &strings.Builder{}
+				failures[strings.TrimPrefix(m[1], "ERROR at setup of ")] = current
+				continue
+			}
+			if current != nil {
+				current.WriteString(line + "\n")
+			}
+			continue
+		}
+		if m := pytestSkippedLine.FindStringSubmatch(line); m != nil {
+			results = append(results, TestResult{Name: m[2], Location: m[1], Status: TestSkipped})
+			continue
+		}
+		m := pytestSummaryLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		location, name, _ := strings.Cut(m[2], "::")
+		r := TestResult{Name: name, Location: location}
+		switch m[1] {
+		case "PASSED", "XFAIL":
+			r.Status = TestPassed
+		default:
+			r.Status = TestFailed
+			// Sections are named after the test, with dots between a
+			// class and its methods.
+			if out, ok := failures[strings.ReplaceAll(name, "::", ".")]; ok {
+				r.Output = out.String()
+			}
+		}
+		results = append(results, r)
+	}
+	return results
+}
@@ -0,0 +1,242 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func planCommands(t *testing.T, root string, files []string, filter string) []string {
+	t.Helper()
+	plans, err := planTests(root, files, filter, shell.ShellTypePOSIX, config.ToolRunTests{}, "")
+	require.NoError(t, err)
+	var commands []string
+	for _, p := range plans {
+		commands = append(commands, p.command)
+	}
+	return commands
+}
+
+func TestPlanTests(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no framework", func(t *testing.T) {
+		t.Parallel()
+		_, err := planTests(t.TempDir(), nil, "", shell.ShellTypePOSIX, config.ToolRunTests{}, "")
+		require.ErrorContains(t, err, "no supported test framework")
+	})
+
+	t.Run("go", func(t *testing.T) {
+		t.Parallel()
+		root := t.TempDir()
+		writeFiles(t, root, map[string]string{
+			"go.mod":          "module example.com/m\n",
+			"main.go":         "package main\n",
+			"pkg/a/a.go":      "package a\n",
+			"pkg/a/a_test.go": "package a\n",
+			"pkg/b/b.go":      "package b\n",
+		})
+		require.Equal(t, []string{"go test -json ./..."}, planCommands(t, root, nil, ""))
+		require.Equal(t,
+			[]string{"go test -json -run 'TestA$' . ./pkg/a"},
+			planCommands(t, root, []string{"pkg/a/a.go", "pkg/a/a_test.go", "main.go", "README.md"}, "TestA$"),
+		)
+		// Deleted packages have nothing to run.
+		require.Empty(t, planCommands(t, root, []string{"pkg/gone/gone.go"}, ""))
+	})
+
+	t.Run("cargo", func(t *testing.T) {
+		t.Parallel()
+		root := t.TempDir()
+		writeFiles(t, root, map[string]string{
+			"Cargo.toml":             "[workspace]\nmembers = [\"crates/*\"]\n",
+			"crates/core/Cargo.toml": "[package]\nname = \"core\"\nversion = \"0.1.0\"\n",
+			"crates/core/src/lib.rs": "",
+			"crates/cli/Cargo.toml":  "[package]\nname = \"cli\"\n",
+			"crates/cli/src/main.rs": "",
+		})
+		require.Equal(t,
+			[]string{"cargo test -p cli -p core parses"},
+			planCommands(t, root, []string{"crates/core/src/lib.rs", "crates/cli/src/main.rs"}, "parses"),
+		)
+	})
+
+	t.Run("jest", func(t *testing.T) {
+		t.Parallel()
+		root := t.TempDir()
+		writeFiles(t, root, map[string]string{
+			"package.json": `{"devDependencies": {"jest": "^29"}}`,
+		})
+		require.Equal(t,
+			[]string{"npx jest --json --passWithNoTests --findRelatedTests src/sum.ts"},
+			planCommands(t, root, []string{"src/sum.ts", "src/style.css"}, ""),
+		)
+	})
+
+	t.Run("pytest", func(t *testing.T) {
+		t.Parallel()
+		root := t.TempDir()
+		writeFiles(t, root, map[string]string{
+			"pyproject.toml":        "[tool.pytest.ini_options]\n",
+			"app/calc.py":           "",
+			"tests/test_calc.py":    "",
+			"tests/test_other.py":   "",
+			"tests/my file_test.py": "",
+		})
+		require.Equal(t,
+			[]string{"pytest -rA -q -k add 'tests/my file_test.py' tests/test_calc.py"},
+			planCommands(t, root, []string{"app/calc.py", "tests/my file_test.py"}, "add"),
+		)
+		require.Empty(t, planCommands(t, root, []string{"app/untested.py"}, ""))
+	})
+
+	t.Run("several frameworks", func(t *testing.T) {
+		t.Parallel()
+		root := t.TempDir()
+		writeFiles(t, root, map[string]string{
+			"go.mod":         "module example.com/m\n",
+			"main.go":        "package main\n",
+			"jest.config.js": "",
+		})
+		require.Equal(t,
+			[]string{"go test -json .", "npx jest --json --passWithNoTests --findRelatedTests web/app.js"},
+			planCommands(t, root, []string{"main.go", "web/app.js"}, ""),
+		)
+	})
+}
+
+func TestRelativeFiles(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	require.Equal(t,
+		[]string{"a.go", filepath.Join("pkg", "b.go")},
+		relativeFiles(root, []string{
+			filepath.Join(root, "a.go"),
+			filepath.Join("pkg", "b.go"),
+			filepath.Join(filepath.Dir(root), "outside.go"),
+		}),
+	)
+}
+
+func TestParseGoTest(t *testing.T) {
+	t.Parallel()
+
+	output := `{"Action":"run","Package":"m/a","Test":"TestOK"}
+{"Action":"output","Package":"m/a","Test":"TestOK","Output":"=== RUN   TestOK\n"}
+{"Action":"pass","Package":"m/a","Test":"TestOK"}
+{"Action":"run","Package":"m/a","Test":"TestBad"}
+{"Action":"output","Package":"m/a","Test":"TestBad","Output":"    a_test.go:9: want 1, got 2\n"}
+{"Action":"fail","Package":"m/a","Test":"TestBad"}
+{"Action":"skip","Package":"m/a","Test":"TestLater"}
+{"Action":"fail","Package":"m/a"}
+{"ImportPath":"m/b","Action":"build-output","Output":"b/b.go:3:1: syntax error\n"}
+{"Action":"start","Package":"m/b"}
+{"Action":"fail","Package":"m/b"}
+`
+	require.Equal(t, []TestResult{
+		{Name: "TestOK", Location: "m/a", Status: TestPassed},
+		{Name: "TestBad", Location: "m/a", Status: TestFailed, Output: "    a_test.go:9: want 1, got 2\n"},
+		{Name: "TestLater", Location: "m/a", Status: TestSkipped},
+		{Name: "(package)", Location: "m/b", Status: TestFailed, Output: "b/b.go:3:1: syntax error\n"},
+	}, parseGoTest(output))
+}
+
+func TestParseCargoTest(t *testing.T) {
+	t.Parallel()
+
+	output := `running 3 tests
+test tests::adds ... ok
+test tests::subtracts ... FAILED
+test tests::later ... ignored
+
+failures:
+
+---- tests::subtracts stdout ----
+thread 'tests::subtracts' panicked at src/lib.rs:12:9:
+assertion failed
+
+failures:
+    tests::subtracts
+
+test result: FAILED. 1 passed; 1 failed; 1 ignored
+`
+	require.Equal(t, []TestResult{
+		{Name: "tests::adds", Status: TestPassed},
+		{Name: "tests::subtracts", Status: TestFailed, Output: "thread 'tests::subtracts' panicked at src/lib.rs:12:9:\nassertion failed\n\n"},
+		{Name: "tests::later", Status: TestSkipped},
+	}, parseCargoTest(output))
+}
+
+func TestParseJest(t *testing.T) {
+	t.Parallel()
+
+	output := `{"numFailedTests":1,"testResults":[
+{"name":"/p/sum.test.ts","status":"failed","assertionResults":[
+  {"fullName":"sum adds","status":"passed","failureMessages":[]},
+  {"fullName":"sum subtracts","status":"failed","failureMessages":["Expected: 1\nReceived: 2"]},
+  {"fullName":"sum later","status":"pending","failureMessages":[]}]},
+{"name":"/p/broken.test.ts","status":"failed","message":"SyntaxError: Unexpected token","assertionResults":[]}]}`
+	require.Equal(t, []TestResult{
+		{Name: "sum adds", Location: "/p/sum.test.ts", Status: TestPassed},
+		{Name: "sum subtracts", Location: "/p/sum.test.ts", Status: TestFailed, Output: "Expected: 1\nReceived: 2"},
+		{Name: "sum later", Location: "/p/sum.test.ts", Status: TestSkipped},
+		{Name: "(suite)", Location: "/p/broken.test.ts", Status: TestFailed, Output: "SyntaxError: Unexpected token"},
+	}, parseJest(output))
+	require.Empty(t, parseJest("npm ERR! could not determine executable to run"))
+}
+
+func TestParsePytest(t *testing.T) {
+	t.Parallel()
+
+	output := `..Fs                                                          [100%]
+=================================== FAILURES ===================================
+_____________________________ TestCalc.test_sub ______________________________
+
+    def test_sub(self):
+>       assert sub(2, 1) == 2
+E       assert 1 == 2
+
+tests/test_calc.py:9: AssertionError
+=========================== short test summary info ============================
+PASSED tests/test_calc.py::test_add
+PASSED tests/test_calc.py::test_mul
+FAILED tests/test_calc.py::TestCalc::test_sub - assert 1 == 2
+SKIPPED [1] tests/test_calc.py:12: not ready
+============== 1 failed, 2 passed, 1 skipped in 0.03s ==============
+`
+	results := parsePytest(output)
+	require.Len(t, results, 4)
+	require.Equal(t, TestResult{Name: "test_add", Location: "tests/test_calc.py", Status: TestPassed}, results[0])
+	require.Equal(t, "TestCalc::test_sub", results[2].Name)
+	require.Equal(t, TestFailed, results[2].Status)
+	require.Contains(t, results[2].Output, "E       assert 1 == 2")
+	require.Equal(t, TestResult{Name: "not ready", Location: "tests/test_calc.py", Status: TestSkipped}, results[3])
+}
+
+func TestFormatTestResults(t *testing.T) {
+	t.Parallel()
+
+	text := formatTestResults(RunTestsResponseMetadata{
+		Runs:     []TestRun{{Framework: "go", Command: "go test -json ./a", ExitCode: 1}},
+		Passed:   3,
+		Failed:   1,
+		Failures: []TestResult{{Name: "TestBad", Location: "m/a", Status: TestFailed, Output: "want 1, got 2"}},
+	})
+	require.Contains(t, text, `<command framework="go" exit_code="1">go test -json ./a</command>`)
+	require.Contains(t, text, "3 passed, 1 failed, 0 skipped")
+	require.Contains(t, text, "<failure test=\"m/a TestBad\">\nwant 1, got 2\n</failure>")
+}
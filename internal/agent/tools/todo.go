@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+const TodoToolName = "todo"
+
+//go:embed todo.md
+var todoDescription []byte
+
+type TodoItem struct {
+	Content string `json:"content" description:"What needs to be done, in the imperative"`
+	Status  string `json:"status" description:"One of pending, in_progress, or completed"`
+}
+
+type TodoParams struct {
+	Todos []TodoItem `json:"todos" description:"The complete task list, replacing the previous one"`
+}
+
+type TodoResponseMetadata struct {
+	Todos []session.Todo `json:"todos"`
+}
+
+func NewTodoTool(sessions session.Service) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		TodoToolName,
+		string(todoDescription),
+		func(ctx context.Context, params TodoParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for updating todos")
+			}
+
+			todos := make([]session.Todo, 0, len(params.Todos))
+			for i, item := range params.Todos {
+				content := strings.TrimSpace(item.Content)
+				if content == "" {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("todo %d has no content", i+1)), nil
+				}
+				status := session.TodoStatus(item.Status)
+				switch status {
+				case session.TodoStatusPending, session.TodoStatusInProgress, session.TodoStatusCompleted:
+				default:
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("todo %d has an invalid status %q: use pending, in_progress, or completed", i+1, item.Status)), nil
+				}
+				todos = append(todos, session.Todo{Content: content, Status: status})
+			}
+
+			if _, err := sessions.SetTodos(ctx, sessionID, todos); err != nil {
+				return fantasy.ToolResponse{}, fmt.Errorf("failed to save todos: %w", err)
+			}
+
+			metadata := TodoResponseMetadata{Todos: todos}
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(formatTodos(todos)), metadata), nil
+		})
+}
+
+func formatTodos(todos []session.Todo) string {
+	if len(todos) == 0 {
+		return "Todo list cleared."
+	}
+	var sb strings.Builder
+	var done int
+	for _, todo := range todos {
+		mark := " "
+		switch todo.Status {
+		case session.TodoStatusInProgress:
+			mark = "~"
+		case session.TodoStatusCompleted:
+			mark = "x"
+			done++
+		}
+		fmt.Fprintf(&sb, "- [%s] %s\n", mark, todo.Content)
+	}
+	fmt.Fprintf(&sb, "\n%d of %d done.", done, len(todos))
+	return sb.String()
+}
@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTodoTool(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	sessions := session.NewService(db.New(conn))
+
+	sess, err := sessions.Create(t.Context(), "todos")
+	require.NoError(t, err)
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, sess.ID)
+	tool := NewTodoTool(sessions)
+
+	resp, err := tool.Run(ctx, fantasy.ToolCall{
+		ID:    "call-1",
+		Name:  TodoToolName,
+		Input: `{"todos": [{"content": "Write the parser", "status": "completed"}, {"content": "Add tests", "status": "in_progress"}]}`,
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError)
+	require.Equal(t, "- [x] Write the parser\n- [~] Add tests\n\n1 of 2 done.", resp.Content)
+
+	// Usage updates don't overwrite the list.
+	sess.PromptTokens = 10
+	_, err = sessions.Save(t.Context(), sess)
+	require.NoError(t, err)
+
+	sess, err = sessions.Get(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, []session.Todo{
+		{Content: "Write the parser", Status: session.TodoStatusCompleted},
+		{Content: "Add tests", Status: session.TodoStatusInProgress},
+	}, sess.Todos)
+
+	resp, err = tool.Run(ctx, fantasy.ToolCall{
+		ID:    "call-2",
+		Name:  TodoToolName,
+		Input: `{"todos": [{"content": "Ship it", "status": "done"}]}`,
+	})
+	require.NoError(t, err)
+	require.True(t, resp.IsError)
+
+	resp, err = tool.Run(ctx, fantasy.ToolCall{
+		ID:    "call-3",
+		Name:  TodoToolName,
+		Input: `{"todos": []}`,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Todo list cleared.", resp.Content)
+	sess, err = sessions.Get(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Empty(t, sess.Todos)
+}
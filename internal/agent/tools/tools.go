@@ -5,13 +5,15 @@ import (
 )
 
 type (
-	sessionIDContextKey string
-	messageIDContextKey string
+	sessionIDContextKey     string
+	rootSessionIDContextKey string
+	messageIDContextKey     string
 )
 
 const (
-	SessionIDContextKey sessionIDContextKey = "session_id"
-	MessageIDContextKey messageIDContextKey = "message_id"
+	SessionIDContextKey     sessionIDContextKey     = "session_id"
+	RootSessionIDContextKey rootSessionIDContextKey = "root_session_id"
+	MessageIDContextKey     messageIDContextKey     = "message_id"
 )
 
 func GetSessionFromContext(ctx context.Context) string {
@@ -26,6 +28,15 @@ func GetSessionFromContext(ctx context.Context) string {
 	return s
 }
 
+// GetRootSessionFromContext returns the session that started the sub-agent
+// session of ctx, or the session of ctx when it isn't one of a sub-agent.
+func GetRootSessionFromContext(ctx context.Context) string {
+	if s, ok := ctx.Value(RootSessionIDContextKey).(string); ok && s != "" {
+		return s
+	}
+	return GetSessionFromContext(ctx)
+}
+
 func GetMessageFromContext(ctx context.Context) string {
 	messageID := ctx.Value(MessageIDContextKey)
 	if messageID == nil {
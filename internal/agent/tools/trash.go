@@ -0,0 +1,17 @@
+package tools
+
+import (
+	"log/slog"
+
+	"github.com/charmbracelet/crush/internal/trash"
+)
+
+// keepReplaced keeps the contents of the files at paths in the trash before
+// the session replaces or deletes them, for crush restore.
+func keepReplaced(bin *trash.Store, sessionID string, paths ...string) {
+	for _, path := range paths {
+		if err := bin.Save(sessionID, path); err != nil {
+			slog.Warn("Could not keep replaced file", "path", path, "error", err)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"unicode/utf8"
 
 	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/filepathext"
 	"github.com/charmbracelet/crush/internal/lsp"
@@ -22,15 +23,19 @@ import (
 var viewDescription []byte
 
 type ViewParams struct {
-	FilePath string `json:"file_path" description:"The path to the file to read"`
-	Offset   int    `json:"offset,omitempty" description:"The line number to start reading from (0-based)"`
-	Limit    int    `json:"limit,omitempty" description:"The number of lines to read (defaults to 2000)"`
+	FilePath  string `json:"file_path" description:"The path to the file to read"`
+	Offset    int    `json:"offset,omitempty" description:"The line number to start reading from (0-based)"`
+	Limit     int    `json:"limit,omitempty" description:"The number of lines to read (defaults to 2000)"`
+	StartLine int    `json:"start_line,omitempty" description:"The first line to read (1-based), instead of offset"`
+	EndLine   int    `json:"end_line,omitempty" description:"The last line to read (1-based, inclusive), instead of limit"`
 }
 
 type ViewPermissionsParams struct {
-	FilePath string `json:"file_path"`
-	Offset   int    `json:"offset"`
-	Limit    int    `json:"limit"`
+	FilePath  string `json:"file_path"`
+	Offset    int    `json:"offset"`
+	Limit     int    `json:"limit"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
 }
 
 type viewTool struct {
@@ -51,7 +56,11 @@ const (
 	MaxLineLength    = 2000
 )
 
-func NewViewTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, workingDir string) fantasy.AgentTool {
+// ViewFileEnd ends the content of the file in the response of the view
+// tool, before the diagnostics of the LSP servers.
+const ViewFileEnd = "\n</file>\n"
+
+func NewViewTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, workingDir string, viewConfig config.ToolView) fantasy.AgentTool {
 	return fantasy.NewAgentTool(
 		ViewToolName,
 		string(viewDescription),
@@ -136,17 +145,6 @@ func NewViewTool(lspClients *csync.Map[string, *lsp.Client], permissions permiss
 				return fantasy.NewTextErrorResponse(fmt.Sprintf("Path is a directory, not a file: %s", filePath)), nil
 			}
 
-			// Check file size
-			if fileInfo.Size() > MaxReadSize {
-				return fantasy.NewTextErrorResponse(fmt.Sprintf("File is too large (%d bytes). Maximum size is %d bytes",
-					fileInfo.Size(), MaxReadSize)), nil
-			}
-
-			// Set default limit if not provided
-			if params.Limit <= 0 {
-				params.Limit = DefaultReadLimit
-			}
-
 			// Check if it's an image file
 			isImage, imageType := isImageFile(filePath)
 			// TODO: handle images
@@ -154,6 +152,34 @@ func NewViewTool(lspClients *csync.Map[string, *lsp.Client], permissions permiss
 				return fantasy.NewTextErrorResponse(fmt.Sprintf("This is an image file of type: %s\n", imageType)), nil
 			}
 
+			hasRange := params.Offset > 0 || params.Limit > 0 || params.StartLine > 0 || params.EndLine > 0
+			if params.StartLine > 0 {
+				params.Offset = params.StartLine - 1
+			}
+			if params.EndLine > 0 {
+				if params.EndLine <= params.Offset {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("end_line %d is before the first line to read, %d", params.EndLine, params.Offset+1)), nil
+				}
+				params.Limit = params.EndLine - params.Offset
+			}
+
+			// Large files are shown as an outline, unless a range is
+			// requested.
+			if !hasRange && (fileInfo.Size() > MaxReadSize || viewConfig.OutlineThreshold() > 0) {
+				outline, lineCount, err := fileOutline(filePath)
+				if err != nil {
+					return fantasy.ToolResponse{}, fmt.Errorf("error reading file: %w", err)
+				}
+				if fileInfo.Size() > MaxReadSize || lineCount > viewConfig.OutlineThreshold() {
+					return fantasy.NewTextResponse(outlineResponse(filePath, outline, lineCount)), nil
+				}
+			}
+
+			// Set default limit if not provided
+			if params.Limit <= 0 {
+				params.Limit = DefaultReadLimit
+			}
+
 			// Read the file content
 			content, lineCount, err := readTextFile(filePath, params.Offset, params.Limit)
 			isValidUt8 := utf8.ValidString(content)
@@ -174,7 +200,7 @@ func NewViewTool(lspClients *csync.Map[string, *lsp.Client], permissions permiss
 				output += fmt.Sprintf("\n\n(File has more lines. Use 'offset' parameter to read beyond line %d)",
 					params.Offset+len(strings.Split(content, "\n")))
 			}
-			output += "\n</file>\n"
+			output += ViewFileEnd
 			output += getDiagnostics(filePath, lspClients)
 			recordFileRead(filePath)
 			return fantasy.WithResponseMetadata(
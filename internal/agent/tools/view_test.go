@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestViewLargeFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	var sb strings.Builder
+	sb.WriteString("package main\n\n")
+	for i := range 100 {
+		fmt.Fprintf(&sb, "func f%d() {\n\tprintln(%d)\n}\n\n", i, i)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(sb.String()), 0o644))
+
+	threshold := 50
+	tool := NewViewTool(csync.NewMap[string, *lsp.Client](), nil, dir, config.ToolView{OutlineLines: &threshold})
+	view := func(input string) fantasy.ToolResponse {
+		t.Helper()
+		resp, err := tool.Run(t.Context(), fantasy.ToolCall{ID: "call", Name: ViewToolName, Input: input})
+		require.NoError(t, err)
+		require.False(t, resp.IsError, resp.Content)
+		return resp
+	}
+
+	resp := view(`{"file_path": "main.go"}`)
+	require.Contains(t, resp.Content, "has 402 lines, too many to show at once")
+	require.Contains(t, resp.Content, "     3|func f0() {\n     7|func f1() {")
+	require.NotContains(t, resp.Content, "println")
+	require.Empty(t, resp.Metadata, "outlines aren't file contents")
+
+	resp = view(`{"file_path": "main.go", "start_line": 7, "end_line": 9}`)
+	require.Equal(t, "<file>\n     7|func f1() {\n     8|\tprintln(1)\n     9|}\n\n(File has more lines. Use 'offset' parameter to read beyond line 9)\n</file>\n", resp.Content)
+
+	threshold = 0
+	resp = view(`{"file_path": "main.go"}`)
+	require.Contains(t, resp.Content, "println(99)")
+}
+
+func TestFileOutline(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"README.md": "# Title\n\nText\n\n## Usage\n\n```sh\nls\n```\n",
+		"app.py":    "import os\n\nclass App:\n    def run(self):\n        pass\n\nasync def main():\n    pass\n",
+		"data.csv":  "a,b\n1,2\n",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	outline, lines, err := fileOutline(filepath.Join(dir, "README.md"))
+	require.NoError(t, err)
+	require.Equal(t, 9, lines)
+	require.Equal(t, "     1|# Title\n     5|## Usage", outline)
+
+	outline, _, err = fileOutline(filepath.Join(dir, "app.py"))
+	require.NoError(t, err)
+	require.Equal(t, "     3|class App:\n     4|    def run(self):\n     7|async def main():", outline)
+
+	outline, lines, err = fileOutline(filepath.Join(dir, "data.csv"))
+	require.NoError(t, err)
+	require.Equal(t, 2, lines)
+	require.Empty(t, outline)
+}
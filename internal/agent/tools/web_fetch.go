@@ -3,30 +3,69 @@ package tools
 import (
 	"context"
 	_ "embed"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"time"
 
 	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/httpext"
 )
 
 //go:embed web_fetch.md
 var webFetchToolDescription []byte
 
-// NewWebFetchTool creates a simple web fetch tool for sub-agents (no permissions needed).
-func NewWebFetchTool(workingDir string, client *http.Client) fantasy.AgentTool {
-	if client == nil {
-		client = &http.Client{
-			Timeout: 30 * time.Second,
-			Transport: &http.Transport{
-				MaxIdleConns:        100,
-				MaxIdleConnsPerHost: 10,
-				IdleConnTimeout:     90 * time.Second,
-			},
+// ErrDomainNotAllowed is returned for URLs outside the domains the
+// agentic_fetch tool may visit.
+var ErrDomainNotAllowed = errors.New("domain not allowed")
+
+// CheckAllowedDomain returns an error wrapping [ErrDomainNotAllowed] when opts
+// doesn't allow visiting rawURL.
+func CheckAllowedDomain(rawURL string, opts config.ToolAgenticFetch) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	if !opts.AllowsHost(u.Hostname()) {
+		return fmt.Errorf("%w: %s isn't one of %s", ErrDomainNotAllowed, u.Hostname(), strings.Join(opts.AllowedDomains, ", "))
+	}
+	return nil
+}
+
+// RestrictToAllowedDomains returns a copy of client that refuses to follow
+// redirects to the domains opts doesn't allow visiting.
+func RestrictToAllowedDomains(client *http.Client, opts config.ToolAgenticFetch) *http.Client {
+	if len(opts.AllowedDomains) == 0 {
+		return client
+	}
+	restricted := *client
+	restricted.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if err := CheckAllowedDomain(req.URL.String(), opts); err != nil {
+			return err
 		}
+		if client.CheckRedirect != nil {
+			return client.CheckRedirect(req, via)
+		}
+		// The default policy of http.Client.
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
 	}
+	return &restricted
+}
+
+// NewWebFetchTool creates a simple web fetch tool for sub-agents (no
+// permissions needed). It only visits the domains opts allows.
+func NewWebFetchTool(workingDir string, client *http.Client, opts config.ToolAgenticFetch) fantasy.AgentTool {
+	if client == nil {
+		client = httpext.NewPooledClient(30 * time.Second)
+	}
+	client = RestrictToAllowedDomains(client, opts)
 
 	return fantasy.NewAgentTool(
 		WebFetchToolName,
@@ -35,6 +74,9 @@ func NewWebFetchTool(workingDir string, client *http.Client) fantasy.AgentTool {
 			if params.URL == "" {
 				return fantasy.NewTextErrorResponse("url is required"), nil
 			}
+			if err := CheckAllowedDomain(params.URL, opts); err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("Can't fetch %s: %s. Only fetch pages of the allowed domains.", params.URL, err)), nil
+			}
 
 			content, err := FetchURLAndConvert(ctx, client, params.URL)
 			if err != nil {
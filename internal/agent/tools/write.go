@@ -13,12 +13,14 @@ import (
 	"charm.land/fantasy"
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/editlock"
 	"github.com/charmbracelet/crush/internal/filepathext"
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/history"
 
 	"github.com/charmbracelet/crush/internal/lsp"
 	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/trash"
 )
 
 //go:embed write.md
@@ -50,7 +52,7 @@ type WriteResponseMetadata struct {
 
 const WriteToolName = "write"
 
-func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, workingDir string) fantasy.AgentTool {
+func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, locks *editlock.Registry, bin *trash.Store, workingDir string) fantasy.AgentTool {
 	return fantasy.NewAgentTool(
 		WriteToolName,
 		string(writeDescription),
@@ -110,7 +112,7 @@ func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permis
 				strings.TrimPrefix(filePath, workingDir),
 			)
 
-			p := permissions.Request(
+			p := requestEdit(ctx, permissions, locks,
 				permission.CreatePermissionRequest{
 					SessionID:   sessionID,
 					Path:        fsext.PathOrPrefix(filePath, workingDir),
@@ -124,11 +126,13 @@ func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permis
 						NewContent: params.Content,
 					},
 				},
+				filePath,
 			)
 			if !p {
 				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
 			}
 
+			keepReplaced(bin, sessionID, filePath)
 			err = os.WriteFile(filePath, []byte(params.Content), 0o644)
 			if err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("error writing file: %w", err)
@@ -157,19 +161,17 @@ func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permis
 			}
 
 			recordFileWrite(filePath)
+			acquireEditLocks(ctx, locks, filePath)
 			recordFileRead(filePath)
 
-			notifyLSPs(ctx, lspClients, params.FilePath)
-
 			result := fmt.Sprintf("File successfully written: %s", filePath)
 			result = fmt.Sprintf("<result>\n%s\n</result>", result)
-			result += getDiagnostics(filePath, lspClients)
-			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(result),
+			return WithDiagnostics(ctx, lspClients, fantasy.WithResponseMetadata(fantasy.NewTextResponse(result),
 				WriteResponseMetadata{
 					Diff:      diff,
 					Additions: additions,
 					Removals:  removals,
 				},
-			), nil
+			), filePath), nil
 		})
 }
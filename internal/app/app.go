@@ -4,33 +4,40 @@ package app
 
 import (
 	"context"
-	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
 	tea "charm.land/bubbletea/v2"
-	"charm.land/fantasy"
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/agent/tools"
 	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/devcontainer"
+	"github.com/charmbracelet/crush/internal/drafts"
+	"github.com/charmbracelet/crush/internal/editlock"
 	"github.com/charmbracelet/crush/internal/format"
 	"github.com/charmbracelet/crush/internal/history"
 	"github.com/charmbracelet/crush/internal/log"
 	"github.com/charmbracelet/crush/internal/lsp"
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/prompthistory"
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/charmbracelet/crush/internal/telemetry"
 	"github.com/charmbracelet/crush/internal/term"
+	"github.com/charmbracelet/crush/internal/trust"
 	"github.com/charmbracelet/crush/internal/tui/components/anim"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/update"
@@ -39,11 +46,25 @@ import (
 	"github.com/charmbracelet/x/exp/charmtone"
 )
 
+const (
+	// shutdownTimeout is how long Crush takes at most to save the runs it
+	// stops when it exits.
+	shutdownTimeout = 10 * time.Second
+	// clientCloseTimeout is how long closing the LSP and MCP clients can
+	// take.
+	clientCloseTimeout = 5 * time.Second
+)
+
 type App struct {
 	Sessions    session.Service
 	Messages    message.Service
 	History     history.Service
 	Permissions permission.Service
+	// Prompts are the prompts submitted in the project, recalled in the
+	// editor.
+	Prompts *prompthistory.History
+	// Drafts are the unsent prompts in the editor of each session.
+	Drafts *drafts.Store
 
 	AgentCoordinator agent.Coordinator
 
@@ -51,6 +72,13 @@ type App struct {
 
 	config *config.Config
 
+	trustStore *trust.Store
+	trust      trust.Level
+
+	devcontainer       *devcontainer.Config
+	devcontainerChoice devcontainer.Choice
+	devcontainerErr    error
+
 	serviceEventsWG *sync.WaitGroup
 	eventsCtx       context.Context
 	events          chan tea.Msg
@@ -62,11 +90,16 @@ type App struct {
 }
 
 // New initializes a new applcation instance.
-func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
-	q := db.New(conn)
+func New(ctx context.Context, q db.Store, cfg *config.Config) (*App, error) {
 	sessions := session.NewService(q)
-	messages := message.NewService(q)
-	files := history.NewService(q, conn)
+	messages, err := message.NewBatchedService(ctx, q, filepath.Join(cfg.Options.DataDirectory, "wal"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize message service: %w", err)
+	}
+	files := history.NewService(q)
+	// Keep the prompts and drafts encrypted too if the store encrypts
+	// messages.
+	sealer, _ := q.(db.Sealer)
 	skipPermissionsRequests := cfg.Permissions != nil && cfg.Permissions.SkipRequests
 	allowedTools := []string{}
 	if cfg.Permissions != nil && cfg.Permissions.AllowedTools != nil {
@@ -78,32 +111,78 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 		Messages:    messages,
 		History:     files,
 		Permissions: permission.NewPermissionService(cfg.WorkingDir(), skipPermissionsRequests, allowedTools),
+		Prompts:     prompthistory.ForDataDir(cfg.Options.DataDirectory, sealer),
+		Drafts:      drafts.ForDataDir(cfg.Options.DataDirectory, sealer),
 		LSPClients:  csync.NewMap[string, *lsp.Client](),
 
 		globalCtx: ctx,
 
 		config: cfg,
 
+		trustStore: trust.DefaultStore(),
+
 		events:          make(chan tea.Msg, 100),
 		serviceEventsWG: &sync.WaitGroup{},
 		tuiWG:           &sync.WaitGroup{},
 	}
 
+	// Ask for permissions according to the trust of the working directory,
+	// and leave out the commands of the project until it's trusted, before
+	// anything runs.
+	app.trust, _, err = app.trustStore.Lookup(cfg.WorkingDir())
+	if err != nil {
+		slog.Error("Failed to look up the trust of the working directory", "error", err)
+	}
+	app.Permissions.SetTrust(app.trust)
+	app.setPermissionTimeouts()
+	if _, err := cfg.AllowProjectCommands(app.trust == trust.Trusted); err != nil {
+		return nil, fmt.Errorf("failed to leave out the commands of the project: %w", err)
+	}
+
 	app.setupEvents()
+	if cfg.Options.EventSocket {
+		app.serveEvents()
+	}
+
+	// Run the tools in the devcontainer of the project, when chosen,
+	// before the LSP clients start.
+	app.setupDevcontainer(ctx)
 
 	// Initialize LSP clients in the background.
 	app.initLSPClients(ctx)
 
 	// Check for updates in the background.
-	go app.checkForUpdates(ctx)
+	if !cfg.Options.Offline {
+		go app.checkForUpdates(ctx)
+	}
 
 	go func() {
 		slog.Info("Initializing MCP clients")
 		mcp.Initialize(ctx, app.Permissions, cfg)
 	}()
 
-	// cleanup database upon app shutdown
-	app.cleanupFuncs = append(app.cleanupFuncs, conn.Close, mcp.Close)
+	// Flush buffered messages and cleanup database upon app shutdown.
+	flushMessages := func() error {
+		return messages.Close(context.Background())
+	}
+	app.cleanupFuncs = append(app.cleanupFuncs, flushMessages, q.Close, closeMCP)
+
+	// Export traces and metrics, if configured, before the agents start.
+	telemetryCfg := cfg.Options.Telemetry
+	if cfg.Options.Offline && telemetryCfg != nil && telemetryCfg.OTLPEndpoint != "" {
+		slog.Warn("Not exporting telemetry", "error", config.ErrOffline)
+		telemetryCfg = nil
+	}
+	shutdownTelemetry, err := telemetry.Init(ctx, telemetryCfg)
+	if err != nil {
+		slog.Error("Failed to initialize telemetry", "error", err)
+	} else {
+		app.cleanupFuncs = append(app.cleanupFuncs, func() error {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			return shutdownTelemetry(shutdownCtx)
+		})
+	}
 
 	// TODO: remove the concept of agent config, most likely.
 	if !cfg.IsConfigured() {
@@ -116,14 +195,37 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 	return app, nil
 }
 
+// Trust returns the trust level of the working directory.
+func (app *App) Trust() trust.Level {
+	return app.trust
+}
+
+// SetTrust marks the working directory with level, and asks for permissions
+// accordingly from now on. The hooks, servers and commands of the project
+// start or stop with its trust.
+func (app *App) SetTrust(level trust.Level) error {
+	if err := app.trustStore.Set(app.config.WorkingDir(), level); err != nil {
+		return err
+	}
+	app.trust = level
+	app.Permissions.SetTrust(level)
+	event, err := app.config.AllowProjectCommands(level == trust.Trusted)
+	if err != nil {
+		return err
+	}
+	app.applyConfigReload(app.globalCtx, event)
+	return nil
+}
+
 // Config returns the application configuration.
 func (app *App) Config() *config.Config {
 	return app.config
 }
 
 // RunNonInteractive runs the application in non-interactive mode with the
-// given prompt, printing to stdout.
-func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt string, quiet bool) error {
+// given prompt, printing to stdout. With an output schema, only the final
+// response conforming to it is printed.
+func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt string, quiet bool, outputSchema *agent.OutputSchema) error {
 	slog.Info("Running in non-interactive mode")
 
 	ctx, cancel := context.WithCancel(ctx)
@@ -182,23 +284,29 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt
 	// Automatically approve all permission requests for this non-interactive
 	// session.
 	app.Permissions.AutoApproveSession(sess.ID)
+	defer app.ReleaseEditLocks(sess.ID)
 
 	type response struct {
-		result *fantasy.AgentResult
+		object json.RawMessage
 		err    error
 	}
 	done := make(chan response, 1)
 
 	go func(ctx context.Context, sessionID, prompt string) {
-		result, err := app.AgentCoordinator.Run(ctx, sess.ID, prompt)
-		if err != nil {
-			done <- response{
-				err: fmt.Errorf("failed to start agent processing stream: %w", err),
-			}
+		var res response
+		if outputSchema != nil {
+			res.object, res.err = app.AgentCoordinator.RunWithSchema(ctx, sessionID, prompt, outputSchema)
+		} else {
+			_, res.err = app.AgentCoordinator.Run(ctx, sessionID, prompt)
 		}
-		done <- response{
-			result: result,
+		switch {
+		case errors.Is(res.err, agent.ErrOutputSchemaViolation):
+			// The run went through, the final response is what's wrong: the
+			// error tells the schema validator's message as it is.
+		case res.err != nil:
+			res.err = fmt.Errorf("failed to start agent processing stream: %w", res.err)
 		}
+		done <- res
 	}(ctx, sess.ID, prompt)
 
 	messageEvents := app.Messages.Subscribe(ctx)
@@ -232,11 +340,14 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt
 				}
 				return fmt.Errorf("agent processing failed: %w", result.err)
 			}
+			if result.object != nil {
+				_, _ = output.Write(result.object)
+			}
 			return nil
 
 		case event := <-messageEvents:
 			msg := event.Payload
-			if msg.SessionID == sess.ID && msg.Role == message.Assistant && len(msg.Parts) > 0 {
+			if outputSchema == nil && msg.SessionID == sess.ID && msg.Role == message.Assistant && len(msg.Parts) > 0 {
 				stopSpinner()
 
 				content := msg.Content().String()
@@ -273,6 +384,11 @@ func (app *App) setupEvents() {
 	setupSubscriber(ctx, app.serviceEventsWG, "history", app.History.Subscribe, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "mcp", mcp.SubscribeEvents, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "lsp", SubscribeLSPEvents, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "sub-agents", agent.SubscribeSubAgents, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "downloads", tools.SubscribeDownloads, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "tool-progress", agent.SubscribeToolProgress, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "request-queue", agent.SubscribeRequestQueue, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "config", config.SubscribeReloads, app.events)
 	cleanupFunc := func() error {
 		cancel()
 		app.serviceEventsWG.Wait()
@@ -333,6 +449,7 @@ func (app *App) InitCoderAgent(ctx context.Context) error {
 		slog.Error("Failed to create coder agent", "err", err)
 		return err
 	}
+	mcp.SetSampler(app.AgentCoordinator.Sample)
 	return nil
 }
 
@@ -368,23 +485,58 @@ func (app *App) Subscribe(program *tea.Program) {
 	}
 }
 
+// AddCleanup adds a function to run on shutdown, after the LSP clients and
+// the other cleanups.
+func (app *App) AddCleanup(cleanup func() error) {
+	app.cleanupFuncs = append(app.cleanupFuncs, cleanup)
+}
+
 // Shutdown performs a graceful shutdown of the application.
 func (app *App) Shutdown() {
-	if app.AgentCoordinator != nil {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// Stop the runs, and finish the messages of those that couldn't in
+	// time.
+	if app.AgentCoordinator != nil && app.AgentCoordinator.IsBusy() {
+		var busy []string
+		sessions, err := app.Sessions.List(ctx)
+		if err != nil {
+			slog.Error("Failed to list sessions on shutdown", "error", err)
+		}
+		for _, s := range sessions {
+			if app.AgentCoordinator.IsSessionBusy(s.ID) {
+				busy = append(busy, s.ID)
+			}
+		}
 		app.AgentCoordinator.CancelAll()
+		for _, sessionID := range busy {
+			if err := agent.MarkInterrupted(ctx, app.Messages, sessionID); err != nil {
+				slog.Error("Failed to save interrupted session", "session_id", sessionID, "error", err)
+			}
+		}
 	}
 
 	// Kill all background shells.
 	shell.GetBackgroundShellManager().KillAll()
 
+	// Let the other instances change the files the sessions changed.
+	if err := editlock.ForDataDir(app.config.Options.DataDirectory).ReleaseProcess(); err != nil {
+		slog.Error("Failed to release edit locks on shutdown", "error", err)
+	}
+
 	// Shutdown all LSP clients.
+	var wg sync.WaitGroup
 	for name, client := range app.LSPClients.Seq2() {
-		shutdownCtx, cancel := context.WithTimeout(app.globalCtx, 5*time.Second)
-		if err := client.Close(shutdownCtx); err != nil {
-			slog.Error("Failed to shutdown LSP client", "name", name, "error", err)
-		}
-		cancel()
+		wg.Go(func() {
+			shutdownCtx, cancel := context.WithTimeout(ctx, clientCloseTimeout)
+			defer cancel()
+			if err := client.Close(shutdownCtx); err != nil {
+				slog.Error("Failed to shutdown LSP client", "name", name, "error", err)
+			}
+		})
 	}
+	wg.Wait()
 
 	// Call call cleanup functions.
 	for _, cleanup := range app.cleanupFuncs {
@@ -396,6 +548,27 @@ func (app *App) Shutdown() {
 	}
 }
 
+// ReleaseEditLocks lets the other sessions change the files the session with
+// sessionID changed, once it ended.
+func (app *App) ReleaseEditLocks(sessionID string) {
+	if err := editlock.ForDataDir(app.config.Options.DataDirectory).Release(sessionID); err != nil {
+		slog.Warn("Failed to release edit locks", "session_id", sessionID, "error", err)
+	}
+}
+
+// closeMCP closes the MCP clients, giving up on those that take longer than
+// clientCloseTimeout.
+func closeMCP() error {
+	done := make(chan error, 1)
+	go func() { done <- mcp.Close() }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(clientCloseTimeout):
+		return fmt.Errorf("timed out closing MCP clients")
+	}
+}
+
 // checkForUpdates checks for available updates.
 func (app *App) checkForUpdates(ctx context.Context) {
 	checkCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/charmbracelet/crush/internal/devcontainer"
+	"github.com/charmbracelet/crush/internal/shell"
+)
+
+// setupDevcontainer finds the devcontainer of the project, and has the tools
+// run in it when the user chose so.
+func (app *App) setupDevcontainer(ctx context.Context) {
+	// In remote mode the tools already run on the remote host.
+	if shell.CurrentRemote() != nil {
+		return
+	}
+	cfg, err := devcontainer.Find(app.config.WorkingDir())
+	if err != nil {
+		slog.Warn("Failed to read the devcontainer configuration", "error", err)
+		return
+	}
+	if cfg == nil {
+		return
+	}
+	app.devcontainer = cfg
+	app.devcontainerChoice, err = devcontainer.LoadChoice(app.config.Options.DataDirectory)
+	if err != nil {
+		slog.Warn("Failed to read the devcontainer choice", "error", err)
+	}
+	if app.devcontainerChoice == devcontainer.Use {
+		if app.devcontainerErr = app.useDevcontainer(ctx); app.devcontainerErr != nil {
+			slog.Warn("Running the tools on the host", "error", app.devcontainerErr)
+		}
+	}
+}
+
+// useDevcontainer has the bash tool, and the LSP clients started from now
+// on, run in the running container of the project.
+func (app *App) useDevcontainer(ctx context.Context) error {
+	container, err := devcontainer.FindContainer(ctx, app.config.WorkingDir(), app.devcontainer)
+	if err != nil {
+		return err
+	}
+	shell.SetRemote(&shell.Remote{
+		Container: container.ID,
+		User:      app.devcontainer.User(),
+		Dir:       container.WorkspaceFolder,
+		MountDir:  app.config.WorkingDir(),
+	})
+	slog.Info("Running the tools in the devcontainer", "container", container.ID, "workspace", container.WorkspaceFolder)
+	return nil
+}
+
+// Devcontainer returns the devcontainer configuration of the project, or nil
+// when it has none, and whether the user chose to run the tools in it.
+func (app *App) Devcontainer() (*devcontainer.Config, devcontainer.Choice) {
+	return app.devcontainer, app.devcontainerChoice
+}
+
+// DevcontainerError returns why the tools run on the host even though the
+// user chose the devcontainer, like when it isn't running.
+func (app *App) DevcontainerError() error {
+	return app.devcontainerErr
+}
+
+// SetDevcontainerChoice saves choice for the project and applies it right
+// away: the agent gets its tools again and the LSP clients are restarted,
+// in the container or on the host.
+func (app *App) SetDevcontainerChoice(ctx context.Context, choice devcontainer.Choice) error {
+	if err := devcontainer.SaveChoice(app.config.Options.DataDirectory, choice); err != nil {
+		return err
+	}
+	app.devcontainerChoice = choice
+	app.devcontainerErr = nil
+
+	running := shell.CurrentRemote() != nil
+	if choice == devcontainer.Use {
+		if err := app.useDevcontainer(ctx); err != nil {
+			app.devcontainerErr = err
+			return err
+		}
+	} else {
+		if !running {
+			return nil
+		}
+		shell.SetRemote(nil)
+	}
+
+	if app.AgentCoordinator != nil {
+		if err := app.UpdateAgentModel(ctx); err != nil {
+			slog.Error("Failed to update the agent tools", "error", err)
+		}
+	}
+	for name := range app.config.LSP {
+		app.restartLSPClient(ctx, name)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/drift"
+	"github.com/charmbracelet/crush/internal/pubsub"
+)
+
+// contextDriftInterval is how often the context file of the project is
+// compared against the project.
+const contextDriftInterval = time.Hour
+
+// WatchContextDrift checks whether the context file of the project is out of
+// date now and every hour, sending a [pubsub.ContextDriftMsg] when what
+// changed since it was written differs from the last time. It returns when
+// the application shuts down.
+func (app *App) WatchContextDrift() {
+	if app.config.Options.DisableContextDriftCheck {
+		return
+	}
+	ctx, cancel := context.WithCancel(app.globalCtx)
+	app.cleanupFuncs = append(app.cleanupFuncs, func() error {
+		cancel()
+		return nil
+	})
+
+	ticker := time.NewTicker(contextDriftInterval)
+	defer ticker.Stop()
+	var reported []string
+	for {
+		changes, err := drift.Check(app.config.Options.DataDirectory, app.config.WorkingDir(), app.config.Options.InitializeAs)
+		if err != nil {
+			slog.Error("Failed to check the context file for drift", "error", err)
+		} else if len(changes) > 0 && !slices.Equal(changes, reported) {
+			reported = changes
+			select {
+			case app.events <- pubsub.ContextDriftMsg{File: app.config.Options.InitializeAs, Changes: changes}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"sync"
+
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/eventapi"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+// EventSocketPath returns the path of the socket the events of the
+// instance are served at, when enabled.
+func EventSocketPath(dataDir string) string {
+	return filepath.Join(dataDir, "events.sock")
+}
+
+// serveEvents serves the events of the instance over a unix socket for
+// external tools, until it shuts down.
+func (app *App) serveEvents() {
+	server, err := eventapi.Listen(EventSocketPath(app.config.Options.DataDirectory), app.config.WorkingDir())
+	if err != nil {
+		slog.Warn("Failed to serve the event API", "error", err)
+		return
+	}
+	slog.Info("Serving the event API", "path", server.Path())
+
+	ctx, cancel := context.WithCancel(app.globalCtx)
+	var wg sync.WaitGroup
+	app.cleanupFuncs = append(app.cleanupFuncs, func() error {
+		cancel()
+		wg.Wait()
+		return server.Close()
+	})
+
+	usage := make(map[string]eventapi.Usage)
+	forwardEvents(ctx, &wg, app.Sessions.Subscribe, func(e pubsub.Event[session.Session]) {
+		sess, u := eventapi.FromSession(e.Payload)
+		server.Publish(eventapi.TopicSessions, string(e.Type), sess)
+		if e.Type == pubsub.DeletedEvent {
+			delete(usage, u.SessionID)
+			return
+		}
+		// Sessions are updated for more than their usage, like their title.
+		if prev, ok := usage[u.SessionID]; ok && prev == u {
+			return
+		}
+		usage[u.SessionID] = u
+		server.Publish(eventapi.TopicUsage, string(e.Type), u)
+	})
+	forwardEvents(ctx, &wg, app.Messages.Subscribe, func(e pubsub.Event[message.Message]) {
+		server.Publish(eventapi.TopicMessages, string(e.Type), eventapi.FromMessage(e.Payload))
+	})
+	forwardEvents(ctx, &wg, app.Permissions.Subscribe, func(e pubsub.Event[permission.PermissionRequest]) {
+		server.Publish(eventapi.TopicPermissions, string(e.Type), eventapi.FromPermission(e.Payload))
+	})
+	forwardEvents(ctx, &wg, app.Permissions.SubscribeNotifications, func(e pubsub.Event[permission.PermissionNotification]) {
+		server.Publish(eventapi.TopicPermissions, "answered", eventapi.FromPermissionNotification(e.Payload))
+	})
+	forwardEvents(ctx, &wg, mcp.SubscribeEvents, func(e pubsub.Event[mcp.Event]) {
+		server.Publish(eventapi.TopicMCP, string(e.Type), eventapi.FromMCPEvent(e.Payload))
+	})
+	forwardEvents(ctx, &wg, SubscribeLSPEvents, func(e pubsub.Event[LSPEvent]) {
+		state := eventapi.ServerState{
+			Name:        e.Payload.Name,
+			State:       e.Payload.State.String(),
+			Diagnostics: e.Payload.DiagnosticCount,
+		}
+		if e.Payload.Error != nil {
+			state.Error = e.Payload.Error.Error()
+		}
+		server.Publish(eventapi.TopicLSP, string(e.Type), state)
+	})
+}
+
+// forwardEvents calls publish with the events of subscriber until ctx is
+// done.
+func forwardEvents[T any](
+	ctx context.Context,
+	wg *sync.WaitGroup,
+	subscriber func(context.Context) <-chan pubsub.Event[T],
+	publish func(pubsub.Event[T]),
+) {
+	wg.Go(func() {
+		for event := range subscriber(ctx) {
+			publish(event)
+		}
+	})
+}
@@ -0,0 +1,25 @@
+package app
+
+import (
+	"log/slog"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/focus"
+)
+
+// ServeFocus sends the files and selections that editor plugins send with
+// crush focus to the TUI as [focus.Request] messages, until the application
+// shuts down.
+func (app *App) ServeFocus(program *tea.Program) {
+	if app.config.Options.DisableFocusSocket {
+		return
+	}
+	server, err := focus.Listen(focus.SocketPath(app.config.Options.DataDirectory), func(req focus.Request) {
+		program.Send(req)
+	})
+	if err != nil {
+		slog.Warn("Failed to listen for focus requests", "error", err)
+		return
+	}
+	app.cleanupFuncs = append(app.cleanupFuncs, server.Close)
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+// WatchConfig reloads the configuration when its files change, restarting
+// the MCP and LSP servers whose configuration changed. It returns when the
+// application shuts down.
+func (app *App) WatchConfig() {
+	ctx, cancel := context.WithCancel(app.globalCtx)
+	app.cleanupFuncs = append(app.cleanupFuncs, func() error {
+		cancel()
+		return nil
+	})
+
+	events := config.SubscribeReloads(ctx)
+	go func() {
+		if err := app.config.Watch(ctx); err != nil {
+			slog.Error("Failed to watch the configuration", "error", err)
+		}
+	}()
+	for event := range events {
+		app.applyConfigReload(ctx, event.Payload)
+	}
+}
+
+func (app *App) applyConfigReload(ctx context.Context, event config.ReloadEvent) {
+	if event.Err != nil {
+		return
+	}
+	if len(event.RestartRequired) > 0 {
+		slog.Info("Configuration changes need a restart", "settings", event.RestartRequired)
+	}
+	if slices.Contains(event.Reloaded, "permissions") {
+		app.Permissions.SetAllowedTools(app.config.Permissions.AllowedTools)
+		app.setPermissionTimeouts()
+	}
+	for _, name := range event.MCP {
+		slog.Info("Restarting MCP client", "name", name)
+		go mcp.Restart(ctx, app.config, name)
+	}
+	for _, name := range event.LSP {
+		slog.Info("Restarting LSP client", "name", name)
+		app.restartLSPClient(ctx, name)
+	}
+	tools := len(event.LSP) > 0 || slices.Contains(event.Reloaded, "hooks") || slices.Contains(event.Reloaded, "project")
+	if tools && app.AgentCoordinator != nil {
+		// The LSP tools are only available with LSP servers configured, and
+		// the hooks and project commands are part of the tools.
+		if err := app.UpdateAgentModel(ctx); err != nil {
+			slog.Error("Failed to update the agent after reloading", "error", err)
+		}
+	}
+}
+
+// restartLSPClient shuts down the named LSP client and starts it again with
+// its current configuration, unless it was disabled or removed.
+func (app *App) restartLSPClient(ctx context.Context, name string) {
+	if client, ok := app.LSPClients.Take(name); ok {
+		shutdownCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		if err := client.Close(shutdownCtx); err != nil {
+			slog.Error("Failed to shutdown LSP client", "name", name, "error", err)
+		}
+		cancel()
+	}
+	clientConfig, ok := app.config.LSP[name]
+	switch {
+	case !ok:
+		updateLSPState(name, lsp.StateDisabled, nil, nil, 0)
+		lspStates.Del(name)
+	case clientConfig.Disabled:
+		updateLSPState(name, lsp.StateDisabled, nil, nil, 0)
+	default:
+		go app.createAndStartLSPClient(ctx, name, clientConfig)
+	}
+}
+
+// setPermissionTimeouts applies the permission timeout and approval expiry of
+// the configuration.
+func (app *App) setPermissionTimeouts() {
+	var action config.PermissionTimeoutAction
+	if app.config.Permissions != nil {
+		action = app.config.Permissions.TimeoutAction
+	}
+	app.Permissions.SetTimeout(app.config.Permissions.TimeoutDuration(), permission.TimeoutAction(action))
+	app.Permissions.SetApprovalExpiry(app.config.Permissions.ApprovalExpiryDuration())
+}
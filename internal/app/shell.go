@@ -0,0 +1,51 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/shell"
+)
+
+// shellCommandTimeout is how long a command run from the prompt can take.
+const shellCommandTimeout = 2 * time.Minute
+
+// RunShellCommand runs a command in the working directory without the agent
+// and adds it and its output to the session as a user message, so that the
+// agent sees them with the next prompt.
+func (app *App) RunShellCommand(ctx context.Context, sessionID, command string) error {
+	execCtx, cancel := context.WithTimeout(ctx, shellCommandTimeout)
+	defer cancel()
+
+	sh := shell.NewShell(&shell.Options{
+		WorkingDir: app.config.WorkingDir(),
+		Type:       app.config.Options.Tools.ShellType(),
+	})
+	stdout, stderr, execErr := sh.Exec(execCtx, command)
+	output := tools.FormatOutput(stdout, stderr, execErr)
+
+	_, err := app.Messages.Create(ctx, sessionID, message.CreateMessageParams{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: shellCommandMessage(command, output)}},
+	})
+	return err
+}
+
+// shellCommandMessage returns the text of the message with a command and its
+// output.
+func shellCommandMessage(command, output string) string {
+	output = strings.TrimRight(output, "\n")
+	if output == "" {
+		output = tools.BashNoOutput
+	}
+	block := "$ " + command + "\n" + output
+	fence := "```"
+	for strings.Contains(block, fence) {
+		fence += "`"
+	}
+	return fmt.Sprintf("I ran this in the shell:\n\n%s\n%s\n%s", fence, block, fence)
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/filepicker"
+)
+
+// NewSessionFromTemplate creates a session that starts with the messages of
+// the session template with name.
+func (app *App) NewSessionFromTemplate(ctx context.Context, name string) (session.Session, error) {
+	t, err := app.config.SessionTemplate(name)
+	if err != nil {
+		return session.Session{}, err
+	}
+	// Read the attachments first so that a missing file doesn't leave a
+	// half seeded session behind.
+	params := make([]message.CreateMessageParams, 0, len(t.Messages))
+	for _, msg := range t.Messages {
+		p, err := templateMessage(msg, app.config.WorkingDir())
+		if err != nil {
+			return session.Session{}, fmt.Errorf("session template %s: %w", name, err)
+		}
+		params = append(params, p)
+	}
+
+	title := t.Title
+	if title == "" {
+		title = t.Name
+	}
+	s, err := app.Sessions.Create(ctx, title)
+	if err != nil {
+		return session.Session{}, err
+	}
+	for _, p := range params {
+		if _, err := app.Messages.Create(ctx, s.ID, p); err != nil {
+			return session.Session{}, err
+		}
+	}
+	return s, nil
+}
+
+// templateMessage returns the message to create for msg, reading its
+// attachments relative to workingDir.
+func templateMessage(msg config.SessionTemplateMessage, workingDir string) (message.CreateMessageParams, error) {
+	role := message.User
+	if msg.Role == "system" {
+		role = message.System
+	}
+	parts := []message.ContentPart{message.TextContent{Text: msg.Content}}
+	for _, path := range msg.Attachments {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workingDir, path)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return message.CreateMessageParams{}, err
+		}
+		if info.Size() > filepicker.MaxAttachmentSize {
+			return message.CreateMessageParams{}, fmt.Errorf("%s is too large, max 5MB", filepath.Base(path))
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return message.CreateMessageParams{}, err
+		}
+		parts = append(parts, message.BinaryContent{
+			Path:     path,
+			MIMEType: http.DetectContentType(content[:min(512, len(content))]),
+			Data:     content,
+		})
+	}
+	return message.CreateMessageParams{Role: role, Parts: parts}, nil
+}
@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/keychain"
+	"github.com/charmbracelet/crush/internal/oauth"
+	"github.com/charmbracelet/crush/internal/oauth/chatgpt"
+	"github.com/charmbracelet/crush/internal/oauth/copilot"
+	"github.com/charmbracelet/x/term"
+	"github.com/pkg/browser"
+	"github.com/spf13/cobra"
+)
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Manage provider credentials",
+	Long: `Manage provider API keys stored in the OS keychain (macOS Keychain,
+Windows Credential Manager, or the Secret Service on Linux) instead of in
+plain text in the configuration.`,
+	Example: `
+# Store an API key for OpenAI, prompting for it
+crush auth login openai
+
+# Store an API key read from stdin
+echo $OPENAI_API_KEY | crush auth login openai
+
+# Log in with a ChatGPT Plus, Pro, or Team subscription instead of an API key
+crush auth login openai --oauth
+
+# Log in to GitHub Copilot with the GitHub device flow
+crush auth login copilot
+
+# Remove the stored key
+crush auth logout openai
+  `,
+}
+
+var authLoginCmd = &cobra.Command{
+	Use:   "login <provider>",
+	Short: "Store a provider API key in the OS keychain",
+	Long: `Store a provider API key in the OS keychain and point the provider's
+api_key at it with a keychain:<provider> reference.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		providerID := args[0]
+		cfg, err := authConfig(cmd, providerID)
+		if err != nil {
+			return err
+		}
+
+		if providerID == copilot.Name {
+			return loginCopilot(cmd, cfg)
+		}
+		if useOAuth, _ := cmd.Flags().GetBool("oauth"); useOAuth {
+			if providerID != string(catwalk.InferenceProviderOpenAI) {
+				return fmt.Errorf("OAuth login from the command line isn't supported for %s", providerID)
+			}
+			return loginChatGPT(cmd, cfg)
+		}
+
+		apiKey, err := readAPIKey(cmd, providerID)
+		if err != nil {
+			return err
+		}
+		if apiKey == "" {
+			return errors.New("no API key provided")
+		}
+
+		if err := keychain.Set(providerID, apiKey); err != nil {
+			return fmt.Errorf("failed to save API key to the OS keychain: %w", err)
+		}
+		if err := cfg.SetConfigField(apiKeyField(providerID), "keychain:"+providerID); err != nil {
+			return err
+		}
+		cmd.Printf("Saved API key for %s to the OS keychain.\n", providerID)
+		return nil
+	},
+}
+
+var authLogoutCmd = &cobra.Command{
+	Use:   "logout <provider>",
+	Short: "Remove stored provider credentials",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		providerID := args[0]
+		cfg, err := authConfig(cmd, providerID)
+		if err != nil {
+			return err
+		}
+
+		keychainErr := keychain.Delete(providerID)
+		removed := keychainErr == nil
+
+		pc, ok := cfg.Providers.Get(providerID)
+		switch {
+		case ok && pc.Type == copilot.Name:
+			if err := cfg.RemoveConfigField("providers." + providerID); err != nil {
+				return err
+			}
+			removed = true
+		case ok && pc.OAuthToken != nil:
+			if err := cmp.Or(
+				cfg.RemoveConfigField(fmt.Sprintf("providers.%s.oauth", providerID)),
+				cfg.RemoveConfigField(apiKeyField(providerID)),
+			); err != nil {
+				return err
+			}
+			removed = true
+		case ok && pc.APIKey == "keychain:"+providerID:
+			if err := cfg.RemoveConfigField(apiKeyField(providerID)); err != nil {
+				return err
+			}
+		}
+		if !removed {
+			if keychainErr != nil && !errors.Is(keychainErr, keychain.ErrNotFound) {
+				return fmt.Errorf("failed to remove API key from the OS keychain: %w", keychainErr)
+			}
+			return fmt.Errorf("no stored credentials for %s", providerID)
+		}
+		cmd.Printf("Removed stored credentials for %s.\n", providerID)
+		return nil
+	},
+}
+
+func init() {
+	authLoginCmd.Flags().Bool("oauth", false, "Log in with a ChatGPT subscription instead of an API key (openai only)")
+	authCmd.AddCommand(authLoginCmd, authLogoutCmd)
+}
+
+// loginChatGPT runs the ChatGPT OAuth flow in the browser and saves the
+// resulting token as the openai provider's credentials.
+func loginChatGPT(cmd *cobra.Command, cfg *config.Config) error {
+	verifier, challenge, err := oauth.GetChallenge()
+	if err != nil {
+		return err
+	}
+	state, _, err := oauth.GetChallenge()
+	if err != nil {
+		return err
+	}
+	authURL, err := chatgpt.AuthorizeURL(challenge, state)
+	if err != nil {
+		return err
+	}
+
+	cmd.PrintErrln("Opening your browser to log in to ChatGPT. If it doesn't open, visit:")
+	cmd.PrintErrln(authURL)
+	_ = browser.OpenURL(authURL)
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Minute)
+	defer cancel()
+	code, err := chatgpt.WaitForCode(ctx, state)
+	if err != nil {
+		return err
+	}
+	token, err := chatgpt.ExchangeToken(ctx, code, verifier)
+	if err != nil {
+		return err
+	}
+	if err := cfg.SetProviderAPIKey(string(catwalk.InferenceProviderOpenAI), token); err != nil {
+		return err
+	}
+	cmd.Println("Logged in to ChatGPT.")
+	return nil
+}
+
+// loginCopilot runs the GitHub device flow and saves a copilot provider with
+// the resulting token and the models available to the account.
+func loginCopilot(cmd *cobra.Command, cfg *config.Config) error {
+	ctx := cmd.Context()
+	dc, err := copilot.RequestDeviceCode(ctx)
+	if err != nil {
+		return err
+	}
+	cmd.PrintErrf("Open %s and enter the code %s to log in to GitHub Copilot.\n", dc.VerificationURI, dc.UserCode)
+	_ = browser.OpenURL(dc.VerificationURI)
+
+	githubToken, err := copilot.PollAccessToken(ctx, dc)
+	if err != nil {
+		return err
+	}
+	token, baseURL, err := copilot.ExchangeToken(ctx, githubToken)
+	if err != nil {
+		return fmt.Errorf("%w; make sure your GitHub account has a Copilot subscription", err)
+	}
+	models, err := copilot.Models(ctx, baseURL, token.AccessToken)
+	if err != nil {
+		return err
+	}
+	if len(models) == 0 {
+		return errors.New("no Copilot chat models are available to your account")
+	}
+
+	field := func(name string) string { return fmt.Sprintf("providers.%s.%s", copilot.Name, name) }
+	if err := cmp.Or(
+		cfg.SetConfigField(field("type"), copilot.Name),
+		cfg.SetConfigField(field("name"), "GitHub Copilot"),
+		cfg.SetConfigField(field("base_url"), baseURL),
+		cfg.SetConfigField(field("models"), models),
+		cfg.SetConfigField(field("api_key"), token.AccessToken),
+		cfg.SetConfigField(field("oauth"), token),
+	); err != nil {
+		return err
+	}
+	cmd.Printf("Logged in to GitHub Copilot with %d models available.\n", len(models))
+	return nil
+}
+
+func apiKeyField(providerID string) string {
+	return fmt.Sprintf("providers.%s.api_key", providerID)
+}
+
+// authConfig loads the configuration and checks providerID is a known or
+// configured provider.
+func authConfig(cmd *cobra.Command, providerID string) (*config.Config, error) {
+	cwd, err := ResolveCwd(cmd)
+	if err != nil {
+		return nil, err
+	}
+	dataDir, _ := cmd.Flags().GetString("data-dir")
+	cfg, err := config.Load(cwd, dataDir, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %v", err)
+	}
+
+	if _, ok := cfg.Providers.Get(providerID); ok || providerID == copilot.Name {
+		return cfg, nil
+	}
+	known, err := config.Providers(cfg)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(known))
+	for _, p := range known {
+		if string(p.ID) == providerID {
+			return cfg, nil
+		}
+		ids = append(ids, string(p.ID))
+	}
+	return nil, fmt.Errorf("unknown provider %q, known providers: %s", providerID, strings.Join(ids, ", "))
+}
+
+// readAPIKey prompts for the API key on a terminal, or reads it from stdin
+// otherwise.
+func readAPIKey(cmd *cobra.Command, providerID string) (string, error) {
+	key, err := readSecret(cmd, "API key for "+providerID)
+	if err != nil {
+		return "", fmt.Errorf("failed to read API key: %w", err)
+	}
+	return key, nil
+}
+
+// readSecret prompts for a secret described by label without echoing it on
+// a terminal, or reads it from stdin otherwise.
+func readSecret(cmd *cobra.Command, label string) (string, error) {
+	if term.IsTerminal(os.Stdin.Fd()) {
+		cmd.PrintErrf("%s: ", label)
+		secret, err := term.ReadPassword(os.Stdin.Fd())
+		cmd.PrintErrln()
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(secret)), nil
+	}
+	line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
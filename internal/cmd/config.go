@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/oauth/copilot"
+	"github.com/charmbracelet/x/exp/charmtone"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the configuration",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the configuration for problems",
+	Long: `Check the configuration files Crush loads, from the global one to the
+one closest to the working directory, against the configuration schema.
+Invalid values are reported as errors, with the file and line they are on,
+and unknown keys as warnings.
+
+The configuration is then loaded to report providers and models that would be
+skipped, and each provider is contacted to check that it is reachable.`,
+	Example: `
+# Validate the configuration used in the current directory
+crush config validate
+
+# Skip the provider connection checks
+crush config validate --offline
+  `,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		offline, _ := cmd.Flags().GetBool("offline")
+
+		cwd, err := ResolveCwd(cmd)
+		if err != nil {
+			return err
+		}
+		files := config.ConfigFiles(cwd)
+		var diags []config.Diagnostic
+		for _, file := range files {
+			fileDiags, err := config.ValidateFile(file)
+			if err != nil {
+				return fmt.Errorf("failed to validate %s: %w", file, err)
+			}
+			diags = append(diags, fileDiags...)
+		}
+
+		if !hasErrors(diags) {
+			dataDir, _ := cmd.Flags().GetString("data-dir")
+			cfg, err := config.Load(cwd, dataDir, false)
+			if err != nil {
+				diags = append(diags, config.Diagnostic{
+					Severity: config.SeverityError,
+					Message:  err.Error(),
+				})
+			} else {
+				for _, warning := range cfg.Warnings() {
+					diags = append(diags, config.Diagnostic{
+						Severity: config.SeverityWarning,
+						Message:  warning,
+					})
+				}
+				if !offline {
+					diags = append(diags, checkProviders(cfg)...)
+				}
+			}
+		}
+
+		printDiagnostics(cmd.OutOrStdout(), files, diags)
+		if n := countSeverity(diags, config.SeverityError); n > 0 {
+			return fmt.Errorf("the configuration has %d error(s)", n)
+		}
+		return nil
+	},
+}
+
+func init() {
+	configValidateCmd.Flags().Bool("offline", false, "Don't check that the providers are reachable")
+	configCmd.AddCommand(configValidateCmd)
+}
+
+// checkProviders reports the enabled providers that can't be reached with
+// their configured endpoint and credentials.
+func checkProviders(cfg *config.Config) []config.Diagnostic {
+	providers := slices.DeleteFunc(cfg.EnabledProviders(), func(p config.ProviderConfig) bool {
+		// Only these have a simple endpoint to check against.
+		switch p.Type {
+		case catwalk.TypeOpenAI, catwalk.TypeOpenAICompat, catwalk.TypeOpenRouter, copilot.Name,
+			catwalk.TypeAnthropic, catwalk.TypeGoogle:
+			return false
+		}
+		return true
+	})
+
+	diags := make([]config.Diagnostic, len(providers))
+	var wg sync.WaitGroup
+	for i, p := range providers {
+		wg.Go(func() {
+			if err := p.TestConnection(cfg.Resolver()); err != nil {
+				diags[i] = config.Diagnostic{
+					Severity: config.SeverityWarning,
+					Message:  fmt.Sprintf("provider %q is unreachable: %v", p.ID, err),
+				}
+			}
+		})
+	}
+	wg.Wait()
+	return slices.DeleteFunc(diags, func(d config.Diagnostic) bool {
+		return d.Message == ""
+	})
+}
+
+func printDiagnostics(w io.Writer, files []string, diags []config.Diagnostic) {
+	subtle := lipgloss.NewStyle().Foreground(charmtone.Squid)
+	styles := map[config.Severity]lipgloss.Style{
+		config.SeverityError:   lipgloss.NewStyle().Foreground(charmtone.Sriracha),
+		config.SeverityWarning: lipgloss.NewStyle().Foreground(charmtone.Mustard),
+	}
+
+	if len(files) == 0 {
+		lipgloss.Fprintln(w, subtle.Render("No configuration files found."))
+	}
+	for _, file := range files {
+		lipgloss.Fprintln(w, subtle.Render("Checked "+file))
+	}
+	for _, d := range diags {
+		lipgloss.Fprintln(w, styles[d.Severity].Render(d.String()))
+	}
+
+	errs := countSeverity(diags, config.SeverityError)
+	warnings := countSeverity(diags, config.SeverityWarning)
+	if errs == 0 && warnings == 0 {
+		lipgloss.Fprintln(w, "The configuration is valid.")
+		return
+	}
+	lipgloss.Fprintln(w, fmt.Sprintf("%d error(s), %d warning(s).", errs, warnings))
+}
+
+func hasErrors(diags []config.Diagnostic) bool {
+	return countSeverity(diags, config.SeverityError) > 0
+}
+
+func countSeverity(diags []config.Diagnostic, severity config.Severity) int {
+	var n int
+	for _, d := range diags {
+		if d.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/event"
+	"github.com/charmbracelet/crush/internal/focus"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+var focusCmd = &cobra.Command{
+	Use:   "focus",
+	Short: "Add a file or a selection to the prompt of a running Crush",
+	Long: `Add a file, a line of it or a selection to the prompt of the Crush running in
+the project, for editor plugins. Nothing is sent until you send the prompt.
+When Crush isn't running and this is run in a terminal, Crush starts with the
+file in the prompt.`,
+	Example: `
+# Mention a file and the line the cursor is on
+crush focus --file internal/app/app.go --line 42
+
+# Add a selection, read from the standard input
+crush focus --file main.go --line 10 --end-line 24 --selection - < selection.txt
+  `,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, _ := cmd.Flags().GetString("file")
+		line, _ := cmd.Flags().GetInt("line")
+		endLine, _ := cmd.Flags().GetInt("end-line")
+		selection, _ := cmd.Flags().GetString("selection")
+
+		// The file is relative to where this runs, not to --cwd.
+		file, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		if selection == "-" {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return fmt.Errorf("failed to read the selection: %w", err)
+			}
+			selection = string(data)
+		}
+		req := focus.Request{File: file, Line: line, EndLine: endLine, Selection: selection}
+		if err := req.Validate(); err != nil {
+			return err
+		}
+
+		cwd, err := ResolveCwd(cmd)
+		if err != nil {
+			return err
+		}
+		dataDir, _ := cmd.Flags().GetString("data-dir")
+		cfg, err := config.Load(cwd, dataDir, false)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %v", err)
+		}
+		err = focus.Send(focus.SocketPath(cfg.Options.DataDirectory), req)
+		if !errors.Is(err, focus.ErrNotRunning) {
+			return err
+		}
+		if !term.IsTerminal(os.Stdin.Fd()) || !term.IsTerminal(os.Stdout.Fd()) {
+			return fmt.Errorf("%w: start it with crush in %s", err, cfg.WorkingDir())
+		}
+		defer event.AppExited()
+		return runTUI(cmd, tuiOptions{prompt: req.Prompt(cfg.WorkingDir())})
+	},
+}
+
+func init() {
+	focusCmd.Flags().String("file", "", "File to add to the prompt")
+	focusCmd.Flags().Int("line", 0, "Line of the file the cursor is on, or where the selection starts")
+	focusCmd.Flags().Int("end-line", 0, "Line where the selection ends")
+	focusCmd.Flags().String("selection", "", "Selected text, or - to read it from the standard input")
+	_ = focusCmd.MarkFlagRequired("file")
+}
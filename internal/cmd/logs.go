@@ -63,10 +63,10 @@ var logsCmd = &cobra.Command{
 		}
 
 		if follow {
-			return followLogs(cmd.Context(), logsFile, tailLines)
+			return followLogs(cmd.Context(), logsFile, tailLines, printLogLine)
 		}
 
-		return showLogs(logsFile, tailLines)
+		return showLogs(logsFile, tailLines, printLogLine)
 	},
 }
 
@@ -75,7 +75,9 @@ func init() {
 	logsCmd.Flags().IntP("tail", "t", defaultTailLines, "Show only the last N lines default: 1000 for performance")
 }
 
-func followLogs(ctx context.Context, logsFile string, tailLines int) error {
+// followLogs prints the last tailLines lines of logsFile with printLine, and
+// then the lines added to it until ctx is done.
+func followLogs(ctx context.Context, logsFile string, tailLines int, printLine func(string)) error {
 	t, err := tail.TailFile(logsFile, tail.Config{
 		Follow: false,
 		ReOpen: false,
@@ -98,7 +100,7 @@ func followLogs(ctx context.Context, logsFile string, tailLines int) error {
 	t.Stop()
 
 	for _, line := range lines {
-		printLogLine(line)
+		printLine(line)
 	}
 
 	if len(lines) == tailLines {
@@ -123,14 +125,15 @@ func followLogs(ctx context.Context, logsFile string, tailLines int) error {
 			if line.Err != nil {
 				continue
 			}
-			printLogLine(line.Text)
+			printLine(line.Text)
 		case <-ctx.Done():
 			return nil
 		}
 	}
 }
 
-func showLogs(logsFile string, tailLines int) error {
+// showLogs prints the last tailLines lines of logsFile with printLine.
+func showLogs(logsFile string, tailLines int, printLine func(string)) error {
 	t, err := tail.TailFile(logsFile, tail.Config{
 		Follow:      false,
 		ReOpen:      false,
@@ -154,7 +157,7 @@ func showLogs(logsFile string, tailLines int) error {
 	}
 
 	for _, line := range lines {
-		printLogLine(line)
+		printLine(line)
 	}
 
 	if len(lines) == tailLines {
@@ -0,0 +1,303 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"charm.land/lipgloss/v2/table"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Manage MCP servers",
+	Long: `Add, remove, list and test the MCP servers Crush uses.
+
+Servers are added to and removed from the global data configuration. A running
+Crush picks the changes up without restarting.`,
+	Example: `
+# Add a stdio server, checking that it starts
+crush mcp add filesystem -- npx -y @modelcontextprotocol/server-filesystem /tmp
+
+# Add an HTTP server with a header
+crush mcp add github --url https://api.githubcopilot.com/mcp/ --header 'Authorization=Bearer $GH_PAT'
+
+# List the configured servers
+crush mcp list
+
+# Check that a server can be reached and count its tools
+crush mcp test github
+
+# Store the Authorization header of a server in the OS keychain
+crush mcp login github
+
+# Remove a server
+crush mcp remove github
+  `,
+}
+
+var mcpAddCmd = &cobra.Command{
+	Use:   "add <name> [command] [args...]",
+	Short: "Add an MCP server",
+	Long: `Add an MCP server started with the given command, or reached at --url.
+Put the command after -- when it has flags of its own. The server is contacted
+before it's saved, unless --no-test is given.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		url, _ := cmd.Flags().GetString("url")
+		sse, _ := cmd.Flags().GetBool("sse")
+		env, _ := cmd.Flags().GetStringToString("env")
+		headers, _ := cmd.Flags().GetStringToString("header")
+		timeout, _ := cmd.Flags().GetInt("timeout")
+		noTest, _ := cmd.Flags().GetBool("no-test")
+		sampling, _ := cmd.Flags().GetBool("sampling")
+
+		name := args[0]
+		m := config.MCPConfig{
+			Env:      env,
+			Headers:  headers,
+			Timeout:  timeout,
+			Sampling: sampling,
+		}
+		switch {
+		case url != "" && len(args) > 1:
+			return fmt.Errorf("give either a command or --url, not both")
+		case url != "":
+			m.Type = config.MCPHttp
+			if sse {
+				m.Type = config.MCPSSE
+			}
+			m.URL = url
+		case len(args) > 1:
+			m.Type = config.MCPStdio
+			m.Command = args[1]
+			m.Args = args[2:]
+		default:
+			return fmt.Errorf("give the command starting the server or its --url")
+		}
+
+		cfg, err := mcpConfig(cmd)
+		if err != nil {
+			return err
+		}
+		if _, ok := cfg.MCP[name]; ok {
+			return fmt.Errorf("an MCP server named %q already exists", name)
+		}
+		if !noTest {
+			counts, err := mcp.Test(cmd.Context(), name, m, cfg.Resolver())
+			if err != nil {
+				return fmt.Errorf("failed to connect to %q, use --no-test to add it anyway: %w", name, err)
+			}
+			cmd.Println(mcpCountsMessage(name, counts))
+		}
+		if err := cfg.SetMCP(name, m); err != nil {
+			return err
+		}
+		cmd.Printf("Added the %q MCP server.\n", name)
+		return nil
+	},
+}
+
+var mcpRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove an MCP server",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := mcpConfig(cmd)
+		if err != nil {
+			return err
+		}
+		if err := cfg.RemoveMCP(args[0]); err != nil {
+			return err
+		}
+		cmd.Printf("Removed the %q MCP server.\n", args[0])
+		return nil
+	},
+}
+
+var mcpListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the configured MCP servers",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := mcpConfig(cmd)
+		if err != nil {
+			return err
+		}
+		servers := cfg.MCP.Sorted()
+		if len(servers) == 0 {
+			cmd.PrintErrln("No MCP servers configured.")
+			return nil
+		}
+
+		if !term.IsTerminal(os.Stdout.Fd()) {
+			for _, s := range servers {
+				cmd.Println(strings.Join([]string{s.Name, string(s.MCP.Type), mcpTarget(s.MCP), mcpStatus(s.MCP)}, "\t"))
+			}
+			return nil
+		}
+		t := table.New().
+			Border(lipgloss.RoundedBorder()).
+			StyleFunc(func(row, col int) lipgloss.Style {
+				return lipgloss.NewStyle().Padding(0, 1)
+			}).
+			Headers("Name", "Type", "Command or URL", "Status")
+		for _, s := range servers {
+			t.Row(s.Name, string(s.MCP.Type), mcpTarget(s.MCP), mcpStatus(s.MCP))
+		}
+		lipgloss.Println(t)
+		return nil
+	},
+}
+
+var mcpTestCmd = &cobra.Command{
+	Use:   "test <name>",
+	Short: "Check that an MCP server can be reached",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := mcpConfig(cmd)
+		if err != nil {
+			return err
+		}
+		name := args[0]
+		m, ok := cfg.MCP[name]
+		if !ok {
+			return fmt.Errorf("no MCP server named %q", name)
+		}
+		counts, err := mcp.Test(cmd.Context(), name, m, cfg.Resolver())
+		if err != nil {
+			return fmt.Errorf("failed to connect to %q: %w", name, err)
+		}
+		cmd.Println(mcpCountsMessage(name, counts))
+		return nil
+	},
+}
+
+var mcpLoginCmd = &cobra.Command{
+	Use:   "login <name> [header]",
+	Short: "Store a header of an MCP server in the OS keychain",
+	Long: `Prompt for the value of a header of an MCP server, Authorization unless
+another is given, store it in the OS keychain, and point the header at it in
+the global data configuration. The value is asked for once, and never written
+to a configuration file.
+
+Headers can also refer to secrets themselves, with keychain:<name> or
+file:<path> as their last word, as in "Bearer file:~/.secrets/github".`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := mcpConfig(cmd)
+		if err != nil {
+			return err
+		}
+		name, header := args[0], "Authorization"
+		if len(args) > 1 {
+			header = args[1]
+		}
+		m, ok := cfg.MCP[name]
+		if !ok {
+			return fmt.Errorf("no MCP server named %q", name)
+		}
+		if m.Type == config.MCPStdio || m.Type == "" {
+			return fmt.Errorf("the %q MCP server runs a command, it has no headers", name)
+		}
+
+		value, err := readSecret(cmd, fmt.Sprintf("%s header for %s", header, name))
+		if err != nil {
+			return fmt.Errorf("failed to read the header: %w", err)
+		}
+		if value == "" {
+			return errors.New("no header value provided")
+		}
+		if err := cfg.SetMCPHeaderSecret(name, header, value); err != nil {
+			return err
+		}
+		cmd.Printf("Saved the %s header of the %q MCP server to the OS keychain.\n", header, name)
+		return nil
+	},
+}
+
+var mcpLogsCmd = &cobra.Command{
+	Use:   "logs <name>",
+	Short: "View the stderr output of an MCP server",
+	Long: `View what an MCP server started with a command wrote to stderr. Crush
+keeps it in the data directory, starting it over once it grows past 1MB.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := mcpConfig(cmd)
+		if err != nil {
+			return err
+		}
+		name := args[0]
+		m, ok := cfg.MCP[name]
+		if !ok {
+			return fmt.Errorf("no MCP server named %q", name)
+		}
+		if m.Type != config.MCPStdio && m.Type != "" {
+			return fmt.Errorf("the %q MCP server doesn't run a command, it has no logs", name)
+		}
+		follow, _ := cmd.Flags().GetBool("follow")
+		tailLines, _ := cmd.Flags().GetInt("tail")
+
+		logsFile := mcp.LogPath(cfg.Options.DataDirectory, name)
+		if _, err := os.Stat(logsFile); os.IsNotExist(err) {
+			return fmt.Errorf("no logs for %q, Crush hasn't started it yet", name)
+		}
+		printLine := func(line string) { fmt.Fprintln(cmd.OutOrStdout(), line) }
+		if follow {
+			return followLogs(cmd.Context(), logsFile, tailLines, printLine)
+		}
+		return showLogs(logsFile, tailLines, printLine)
+	},
+}
+
+func init() {
+	mcpAddCmd.Flags().String("url", "", "URL of an HTTP or SSE server")
+	mcpAddCmd.Flags().Bool("sse", false, "Connect to the --url with server-sent events")
+	mcpAddCmd.Flags().StringToString("env", nil, "Environment variable for the server command, as KEY=VALUE")
+	mcpAddCmd.Flags().StringToString("header", nil, "HTTP header for the server, as KEY=VALUE")
+	mcpAddCmd.Flags().Int("timeout", 0, "Connection timeout in seconds")
+	mcpAddCmd.Flags().Bool("no-test", false, "Don't check that the server can be reached")
+	mcpAddCmd.Flags().Bool("sampling", false, "Let the server ask the model for completions while its tools run")
+
+	mcpLogsCmd.Flags().BoolP("follow", "f", false, "Follow log output")
+	mcpLogsCmd.Flags().IntP("tail", "t", defaultTailLines, "Show only the last N lines")
+
+	mcpCmd.AddCommand(mcpAddCmd, mcpRemoveCmd, mcpListCmd, mcpTestCmd, mcpLoginCmd, mcpLogsCmd)
+}
+
+func mcpConfig(cmd *cobra.Command) (*config.Config, error) {
+	cwd, err := ResolveCwd(cmd)
+	if err != nil {
+		return nil, err
+	}
+	dataDir, _ := cmd.Flags().GetString("data-dir")
+	cfg, err := config.Load(cwd, dataDir, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %v", err)
+	}
+	return cfg, nil
+}
+
+func mcpTarget(m config.MCPConfig) string {
+	if m.Type == config.MCPStdio || m.Type == "" {
+		return strings.Join(append([]string{m.Command}, m.Args...), " ")
+	}
+	return m.URL
+}
+
+func mcpStatus(m config.MCPConfig) string {
+	if m.Disabled {
+		return "disabled"
+	}
+	return "enabled"
+}
+
+func mcpCountsMessage(name string, counts mcp.Counts) string {
+	return fmt.Sprintf("Connected to %q: %d tools, %d prompts.", name, counts.Tools, counts.Prompts)
+}
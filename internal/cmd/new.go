@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/event"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/spf13/cobra"
+)
+
+var newCmd = &cobra.Command{
+	Use:   "new",
+	Short: "Start a new session from a template",
+	Long: `Start a new session that begins with the messages of a session template,
+for repeatable workflows like release checklists. Templates are the
+.crush/templates/sessions/<name>.json files of the project.`,
+	Example: `
+# Start a session from .crush/templates/sessions/bugfix.json
+crush new --template bugfix
+  `,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		template, _ := cmd.Flags().GetString("template")
+		return runTUI(cmd, tuiOptions{
+			newSession: func(ctx context.Context, app *app.App) (session.Session, error) {
+				return app.NewSessionFromTemplate(ctx, template)
+			},
+		})
+	},
+	PostRun: func(cmd *cobra.Command, args []string) {
+		event.AppExited()
+	},
+}
+
+func init() {
+	newCmd.Flags().StringP("template", "t", "", "Name of the session template to start from")
+	_ = newCmd.MarkFlagRequired("template")
+}
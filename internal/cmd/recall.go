@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/recall"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/x/exp/charmtone"
+	"github.com/spf13/cobra"
+)
+
+// recallSnippetWidth is the width of the snippets shown for each answer.
+const recallSnippetWidth = 100
+
+var recallCmd = &cobra.Command{
+	Use:   "recall <query>",
+	Short: "Search the answers given in previous sessions",
+	Long: `Search the assistant's answers across all sessions for the given words.
+Answers containing every word are listed, newest first.`,
+	Example: `
+# Find earlier answers about migrations
+crush recall database migrations
+
+# Print the whole answers instead of snippets
+crush recall --full --limit 3 goose
+  `,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		limit, _ := cmd.Flags().GetInt("limit")
+		full, _ := cmd.Flags().GetBool("full")
+
+		cwd, err := ResolveCwd(cmd)
+		if err != nil {
+			return err
+		}
+		dataDir, _ := cmd.Flags().GetString("data-dir")
+		cfg, err := config.Load(cwd, dataDir, false)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %v", err)
+		}
+		store, err := openStore(cmd.Context(), cfg)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		query := strings.Join(args, " ")
+		results, err := recall.Search(cmd.Context(), message.NewService(store), session.NewService(store), query, limit)
+		if err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			cmd.PrintErrln("No matching answers found.")
+			return nil
+		}
+
+		header := lipgloss.NewStyle().Bold(true).Foreground(charmtone.Charple)
+		label := lipgloss.NewStyle().Foreground(charmtone.Squid)
+		w := cmd.OutOrStdout()
+		for i, r := range results {
+			if i > 0 {
+				lipgloss.Fprintln(w)
+			}
+			title := r.SessionTitle
+			if title == "" {
+				title = "Untitled session"
+			}
+			lipgloss.Fprintln(w, header.Render(title)+" "+label.Render(fmt.Sprintf(
+				"%s %s",
+				time.Unix(r.Message.CreatedAt, 0).Format("2006-01-02 15:04"),
+				r.Message.SessionID,
+			)))
+			if full {
+				lipgloss.Fprintln(w, strings.TrimSpace(r.Text()))
+			} else {
+				lipgloss.Fprintln(w, recall.Snippet(r.Text(), query, recallSnippetWidth))
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	recallCmd.Flags().IntP("limit", "n", 10, "Maximum number of answers to show, 0 for all")
+	recallCmd.Flags().Bool("full", false, "Print the whole answers instead of snippets")
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/remote"
+	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/spf13/cobra"
+)
+
+// setupRemote mounts the project of the remote spec, makes its mount the
+// working directory and has the bash tool run its commands on the host.
+func setupRemote(ctx context.Context, cmd *cobra.Command, spec string) (*remote.Mount, error) {
+	if cwd, _ := cmd.Flags().GetString("cwd"); cwd != "" {
+		return nil, errors.New("--cwd and --remote can't be used together: give the remote directory in --remote")
+	}
+	target, err := remote.Parse(spec)
+	if err != nil {
+		return nil, err
+	}
+	mount, err := remote.NewMount(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chdir(mount.Dir); err != nil {
+		_ = mount.Close()
+		return nil, fmt.Errorf("failed to change directory: %v", err)
+	}
+	shell.SetRemote(&shell.Remote{
+		Host:     target.Host,
+		Dir:      target.Dir,
+		MountDir: mount.Dir,
+	})
+	return mount, nil
+}
+
+// remoteDataDir returns the local data directory of a remote project, which
+// keeps the database off the mount.
+func remoteDataDir(target remote.Target) string {
+	return filepath.Join(filepath.Dir(config.GlobalConfigData()), "remote", target.ID())
+}
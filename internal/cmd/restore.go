@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"charm.land/lipgloss/v2"
+	"charm.land/lipgloss/v2/table"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/trash"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore [file]",
+	Short: "Restore a file the agent replaced",
+	Long: `Restore the content a file had before the edit and write tools of a session
+replaced or deleted it. The contents are kept in the data directory for the
+days set by options.trash.retention_days, 7 by default. The content the file
+has when it is restored is kept too, so that a restore can be undone.`,
+	Example: `
+# Restore the content main.go had before it was last replaced
+crush restore main.go
+
+# List the kept contents of main.go, newest first
+crush restore --list main.go
+
+# Restore the second newest one
+crush restore --version 2 main.go
+
+# Print what a session replaced instead of restoring it
+crush restore --session 3f1c2a --print main.go
+
+# List the kept contents of every file
+crush restore --list
+  `,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		list, _ := cmd.Flags().GetBool("list")
+		version, _ := cmd.Flags().GetInt("version")
+		sessionID, _ := cmd.Flags().GetString("session")
+		printContent, _ := cmd.Flags().GetBool("print")
+
+		if len(args) == 0 && !list {
+			return fmt.Errorf("a file is required unless --list is given")
+		}
+		if version < 1 {
+			return fmt.Errorf("--version must be 1 or more")
+		}
+
+		cwd, err := ResolveCwd(cmd)
+		if err != nil {
+			return err
+		}
+		dataDir, _ := cmd.Flags().GetString("data-dir")
+		cfg, err := config.Load(cwd, dataDir, false)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %v", err)
+		}
+		retention := cfg.Options.Trash.Retention()
+		if retention == 0 {
+			// Restore what was kept before the trash was disabled.
+			retention = trash.DefaultRetention
+		}
+		store := trash.ForDataDir(cfg.Options.DataDirectory, retention)
+		if err := store.Prune(); err != nil {
+			return err
+		}
+
+		var path string
+		if len(args) == 1 {
+			if path, err = filepath.Abs(args[0]); err != nil {
+				return err
+			}
+		}
+		entries, err := store.List(path)
+		if err != nil {
+			return err
+		}
+		entries = filterEntriesBySession(entries, sessionID)
+
+		if list {
+			return listTrash(cmd, entries, cwd)
+		}
+		if len(entries) == 0 {
+			return fmt.Errorf("no kept contents of %s", args[0])
+		}
+		if version > len(entries) {
+			return fmt.Errorf("%s has %d kept contents", args[0], len(entries))
+		}
+		entry := entries[version-1]
+
+		if printContent {
+			content, err := store.Content(entry)
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(content)
+			return err
+		}
+		if err := store.Restore(entry); err != nil {
+			return err
+		}
+		cmd.Printf("Restored %s as it was before %s\n", args[0], entry.Time.Format(time.DateTime))
+		return nil
+	},
+}
+
+func init() {
+	restoreCmd.Flags().Bool("list", false, "List the kept contents instead of restoring one")
+	restoreCmd.Flags().Int("version", 1, "Which kept content to restore, 1 being the newest")
+	restoreCmd.Flags().String("session", "", "Only use the contents kept for this session")
+	restoreCmd.Flags().Bool("print", false, "Print the kept content instead of restoring it")
+}
+
+// filterEntriesBySession returns the entries kept for the session with
+// sessionID, or all of them when it is empty.
+func filterEntriesBySession(entries []trash.Entry, sessionID string) []trash.Entry {
+	if sessionID == "" {
+		return entries
+	}
+	var filtered []trash.Entry
+	for _, e := range entries {
+		if e.SessionID == sessionID {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+func listTrash(cmd *cobra.Command, entries []trash.Entry, cwd string) error {
+	if len(entries) == 0 {
+		cmd.PrintErrln("No kept contents found.")
+		return nil
+	}
+
+	rows := make([][]string, 0, len(entries))
+	versions := make(map[string]int)
+	for _, e := range entries {
+		versions[e.Path]++
+		path := e.Path
+		if rel, err := filepath.Rel(cwd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+		rows = append(rows, []string{
+			path,
+			strconv.Itoa(versions[e.Path]),
+			e.Time.Format(time.DateTime),
+			e.SessionID,
+			formatSize(e.Size),
+		})
+	}
+
+	if !term.IsTerminal(os.Stdout.Fd()) {
+		for _, row := range rows {
+			cmd.Println(strings.Join(row, "\t"))
+		}
+		return nil
+	}
+	t := table.New().
+		Border(lipgloss.RoundedBorder()).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			return lipgloss.NewStyle().Padding(0, 1)
+		}).
+		Headers("File", "Version", "Replaced", "Session", "Size").
+		Rows(rows...)
+	lipgloss.Println(t)
+	return nil
+}
+
+// formatSize formats a size in a human-readable way, e.g. 12.3 KB.
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
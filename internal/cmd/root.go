@@ -17,8 +17,10 @@ import (
 	"github.com/charmbracelet/colorprofile"
 	"github.com/charmbracelet/crush/internal/app"
 	"github.com/charmbracelet/crush/internal/config"
-	"github.com/charmbracelet/crush/internal/db"
 	"github.com/charmbracelet/crush/internal/event"
+	"github.com/charmbracelet/crush/internal/remote"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/shell"
 	termutil "github.com/charmbracelet/crush/internal/term"
 	"github.com/charmbracelet/crush/internal/tui"
 	"github.com/charmbracelet/crush/internal/version"
@@ -33,7 +35,10 @@ import (
 func init() {
 	rootCmd.PersistentFlags().StringP("cwd", "c", "", "Current working directory")
 	rootCmd.PersistentFlags().StringP("data-dir", "D", "", "Custom crush data directory")
+	rootCmd.PersistentFlags().String("scope", "", "Restrict the file tools to a directory of the project, like a package of a monorepo")
+	rootCmd.PersistentFlags().String("remote", "", "Work on a project on another host over SSH, as [user@]host:path")
 	rootCmd.PersistentFlags().BoolP("debug", "d", false, "Debug")
+	rootCmd.PersistentFlags().Bool("offline", false, "Block all network access but the requests to the providers")
 	rootCmd.Flags().BoolP("help", "h", false, "Help")
 	rootCmd.Flags().BoolP("yolo", "y", false, "Automatically accept all permissions (dangerous mode)")
 
@@ -43,6 +48,18 @@ func init() {
 		updateProvidersCmd,
 		logsCmd,
 		schemaCmd,
+		transcriptCmd,
+		storageCmd,
+		recallCmd,
+		configCmd,
+		authCmd,
+		mcpCmd,
+		trustCmd,
+		scheduleCmd,
+		sessionsCmd,
+		newCmd,
+		focusCmd,
+		restoreCmd,
 	)
 }
 
@@ -65,6 +82,12 @@ crush -d -c /path/to/project
 # Run with custom data directory
 crush -D /path/to/custom/.crush
 
+# Only work on one package of a monorepo
+crush --scope packages/api
+
+# Work on a project on a remote host over SSH
+crush --remote user@devbox:/srv/project
+
 # Print version
 crush -v
 
@@ -73,40 +96,75 @@ crush run "Explain the use of context in Go"
 
 # Run in dangerous mode (auto-accept all permissions)
 crush -y
+
+# Only reach the network to talk to the providers
+crush --offline
   `,
-	RunE: func(cmd *cobra.Command, args []string) error {
-		app, err := setupAppWithProgressBar(cmd)
-		if err != nil {
-			return err
-		}
-		defer app.Shutdown()
-
-		event.AppInitialized()
-
-		// Set up the TUI.
-		var env uv.Environ = os.Environ()
-		ui := tui.New(app)
-		ui.QueryVersion = shouldQueryTerminalVersion(env)
-
-		program := tea.NewProgram(
-			ui,
-			tea.WithEnvironment(env),
-			tea.WithContext(cmd.Context()),
-			tea.WithFilter(tui.MouseEventFilter)) // Filter mouse events based on focus state
-		go app.Subscribe(program)
-
-		if _, err := program.Run(); err != nil {
-			event.Error(err)
-			slog.Error("TUI run error", "error", err)
-			return errors.New("Crush crashed. If metrics are enabled, we were notified about it. If you'd like to report it, please copy the stacktrace above and open an issue at https://github.com/charmbracelet/crush/issues/new?template=bug.yml") //nolint:staticcheck
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// The environment variable turns offline mode on for every command,
+		// including the ones that only read parts of the configuration.
+		if offline, _ := cmd.Flags().GetBool("offline"); offline {
+			return os.Setenv("CRUSH_OFFLINE", "1")
 		}
 		return nil
 	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTUI(cmd, tuiOptions{})
+	},
 	PostRun: func(cmd *cobra.Command, args []string) {
 		event.AppExited()
 	},
 }
 
+// tuiOptions change how the interactive mode starts.
+type tuiOptions struct {
+	// newSession creates the session the TUI opens, when it isn't nil.
+	newSession func(context.Context, *app.App) (session.Session, error)
+	// prompt is text the prompt starts with.
+	prompt string
+}
+
+// runTUI runs the interactive mode.
+func runTUI(cmd *cobra.Command, opts tuiOptions) error {
+	app, err := setupAppWithProgressBar(cmd)
+	if err != nil {
+		return err
+	}
+	defer app.Shutdown()
+
+	event.AppInitialized()
+
+	// Set up the TUI.
+	var env uv.Environ = os.Environ()
+	ui := tui.New(app)
+	ui.QueryVersion = shouldQueryTerminalVersion(env)
+	ui.Prompt = opts.prompt
+	if opts.newSession != nil {
+		s, err := opts.newSession(cmd.Context(), app)
+		if err != nil {
+			return err
+		}
+		ui.Session = &s
+	}
+
+	program := tea.NewProgram(
+		ui,
+		tea.WithEnvironment(env),
+		tea.WithContext(cmd.Context()),
+		tea.WithFilter(tui.MouseEventFilter)) // Filter mouse events based on focus state
+	go app.Subscribe(program)
+	app.ServeFocus(program)
+	go app.WatchConfig()
+	go app.WatchContextDrift()
+
+	if _, err := program.Run(); err != nil {
+		event.Error(err)
+		slog.Error("TUI run error", "error", err)
+		return errors.New("Crush crashed. If metrics are enabled, we were notified about it. If you'd like to report it, please copy the stacktrace above and open an issue at https://github.com/charmbracelet/crush/issues/new?template=bug.yml") //nolint:staticcheck
+	}
+	return nil
+}
+
 var heartbit = lipgloss.NewStyle().Foreground(charmtone.Dolly).SetString(`
     ▄▄▄▄▄▄▄▄    ▄▄▄▄▄▄▄▄
   ███████████  ███████████
@@ -161,21 +219,50 @@ func setupAppWithProgressBar(cmd *cobra.Command) (*app.App, error) {
 
 // setupApp handles the common setup logic for both interactive and non-interactive modes.
 // It returns the app instance, config, cleanup function, and any error.
-func setupApp(cmd *cobra.Command) (*app.App, error) {
+func setupApp(cmd *cobra.Command) (_ *app.App, err error) {
 	debug, _ := cmd.Flags().GetBool("debug")
 	yolo, _ := cmd.Flags().GetBool("yolo")
 	dataDir, _ := cmd.Flags().GetString("data-dir")
+	remoteSpec, _ := cmd.Flags().GetString("remote")
+	scope, _ := cmd.Flags().GetString("scope")
 	ctx := cmd.Context()
 
-	cwd, err := ResolveCwd(cmd)
-	if err != nil {
-		return nil, err
+	var cwd string
+	var mount *remote.Mount
+	if remoteSpec != "" {
+		if wd, _ := os.Getwd(); config.Offline(wd) {
+			return nil, fmt.Errorf("can't work on %s: %w", remoteSpec, config.ErrOffline)
+		}
+		mount, err = setupRemote(ctx, cmd, remoteSpec)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err != nil {
+				shell.SetRemote(nil)
+				_ = mount.Close()
+			}
+		}()
+		cwd = mount.Dir
+		if dataDir == "" {
+			dataDir = remoteDataDir(mount.Target)
+		}
+	} else {
+		cwd, err = ResolveCwd(cmd)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	cfg, err := config.Init(cwd, dataDir, debug)
 	if err != nil {
 		return nil, err
 	}
+	if scope != "" {
+		if err := cfg.SetScope(scope); err != nil {
+			return nil, err
+		}
+	}
 
 	if cfg.Permissions == nil {
 		cfg.Permissions = &config.Permissions{}
@@ -186,18 +273,25 @@ func setupApp(cmd *cobra.Command) (*app.App, error) {
 		return nil, err
 	}
 
-	// Connect to DB; this will also run migrations.
-	conn, err := db.Connect(ctx, cfg.Options.DataDirectory)
+	// Connect to storage; this will also run migrations.
+	store, err := openStore(ctx, cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	appInstance, err := app.New(ctx, conn, cfg)
+	appInstance, err := app.New(ctx, store, cfg)
 	if err != nil {
 		slog.Error("Failed to create app instance", "error", err)
 		return nil, err
 	}
 
+	if mount != nil {
+		appInstance.AddCleanup(func() error {
+			shell.SetRemote(nil)
+			return mount.Close()
+		})
+	}
+
 	if shouldEnableMetrics() {
 		event.Init()
 	}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/charmbracelet/crush/internal/agent"
 	"github.com/spf13/cobra"
 )
 
@@ -26,9 +27,25 @@ crush run "What is this code doing?" <<< prrr.go
 
 # Run in quiet mode (hide the spinner)
 crush run --quiet "Generate a README for this project"
+
+# Print the result as JSON conforming to a schema
+crush run --output-schema schema.json "List the TODOs in this project"
   `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		quiet, _ := cmd.Flags().GetBool("quiet")
+		schemaPath, _ := cmd.Flags().GetString("output-schema")
+
+		var outputSchema *agent.OutputSchema
+		if schemaPath != "" {
+			data, err := os.ReadFile(schemaPath)
+			if err != nil {
+				return fmt.Errorf("failed to read output schema: %w", err)
+			}
+			outputSchema, err = agent.ParseOutputSchema(data)
+			if err != nil {
+				return err
+			}
+		}
 
 		app, err := setupApp(cmd)
 		if err != nil {
@@ -58,10 +75,11 @@ crush run --quiet "Generate a README for this project"
 		//     echo "Do something fancy" | crush run > output.txt
 		//
 		// TODO: We currently need to press ^c twice to cancel. Fix that.
-		return app.RunNonInteractive(cmd.Context(), os.Stdout, prompt, quiet)
+		return app.RunNonInteractive(cmd.Context(), os.Stdout, prompt, quiet, outputSchema)
 	},
 }
 
 func init() {
 	runCmd.Flags().BoolP("quiet", "q", false, "Hide spinner")
+	runCmd.Flags().String("output-schema", "", "Path to a JSON schema the final response must conform to")
 }
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"charm.land/lipgloss/v2"
+	"charm.land/lipgloss/v2/table"
+	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/notify"
+	"github.com/charmbracelet/crush/internal/schedule"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Run prompts on a schedule",
+	Long: `Add, remove and list prompts that run on cron-like schedules, and serve them.
+
+Tasks are stored per project and only run while 'crush schedule serve' is
+running in the project. Each run gets its own session, with every permission
+request approved, like 'crush run'.`,
+	Example: `
+# Review the open TODOs every Monday at 9:00
+crush schedule add "0 9 * * 1" --prompt "Review the TODOs and summarize them"
+
+# Check the build every hour
+crush schedule add @hourly --prompt "Run the tests and report failures"
+
+# List the scheduled tasks
+crush schedule list
+
+# Run the tasks as they come due, until interrupted
+crush schedule serve
+
+# Remove a task
+crush schedule remove 4b2f7c3e
+  `,
+}
+
+var scheduleAddCmd = &cobra.Command{
+	Use:   "add <schedule>",
+	Short: "Schedule a prompt",
+	Long: `Schedule a prompt to run at the times matching a cron expression of five
+fields: minute, hour, day of month, month and day of week, in local time.
+@hourly, @daily, @weekly, @monthly and @yearly work too.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		prompt, _ := cmd.Flags().GetString("prompt")
+
+		store, err := scheduleStore(cmd)
+		if err != nil {
+			return err
+		}
+		task, err := store.Add(args[0], prompt)
+		if err != nil {
+			return err
+		}
+		spec, _ := schedule.Parse(task.Cron)
+		cmd.Printf("Scheduled task %s, next running at %s.\n", task.ID, spec.Next(time.Now()).Format(time.DateTime))
+		return nil
+	},
+}
+
+var scheduleRemoveCmd = &cobra.Command{
+	Use:   "remove <id>",
+	Short: "Remove a scheduled task",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := scheduleStore(cmd)
+		if err != nil {
+			return err
+		}
+		if err := store.Remove(args[0]); err != nil {
+			return err
+		}
+		cmd.Printf("Removed scheduled task %s.\n", args[0])
+		return nil
+	},
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the scheduled tasks",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := scheduleStore(cmd)
+		if err != nil {
+			return err
+		}
+		tasks, err := store.List()
+		if err != nil {
+			return err
+		}
+		if len(tasks) == 0 {
+			cmd.PrintErrln("No scheduled tasks.")
+			return nil
+		}
+
+		rows := make([][]string, 0, len(tasks))
+		for _, task := range tasks {
+			lastRun := "never"
+			if !task.LastRun.IsZero() {
+				lastRun = task.LastRun.Format(time.DateTime)
+				if task.LastError != "" {
+					lastRun += " (failed)"
+				}
+			}
+			rows = append(rows, []string{task.ID, task.Cron, lastRun, task.Prompt})
+		}
+
+		if !term.IsTerminal(os.Stdout.Fd()) {
+			for _, row := range rows {
+				cmd.Println(strings.Join(row, "\t"))
+			}
+			return nil
+		}
+		t := table.New().
+			Border(lipgloss.RoundedBorder()).
+			StyleFunc(func(row, col int) lipgloss.Style {
+				return lipgloss.NewStyle().Padding(0, 1)
+			}).
+			Headers("ID", "Schedule", "Last Run", "Prompt").
+			Rows(rows...)
+		lipgloss.Println(t)
+		return nil
+	},
+}
+
+var scheduleServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run the scheduled tasks as they come due",
+	Long: `Run the scheduled tasks of the project as they come due, one at a time,
+until interrupted. Runs missed while nothing was serving are skipped. When
+notifications are configured, one is sent as each run ends.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		app, err := setupApp(cmd)
+		if err != nil {
+			return err
+		}
+		defer app.Shutdown()
+
+		cfg := app.Config()
+		if !cfg.IsConfigured() {
+			return fmt.Errorf("no providers configured - please run 'crush' to set up a provider interactively")
+		}
+
+		ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer cancel()
+
+		store := schedule.ProjectStore(cfg.Options.DataDirectory)
+		notifier := notify.New(cfg.Options.Notifications, cfg.WorkingDir())
+		cmd.PrintErrln("Serving scheduled tasks, press ctrl+c to stop.")
+		return schedule.Serve(ctx, store, runScheduledTask(app, notifier, cmd.OutOrStdout()))
+	},
+}
+
+func init() {
+	scheduleAddCmd.Flags().StringP("prompt", "p", "", "Prompt to run")
+	_ = scheduleAddCmd.MarkFlagRequired("prompt")
+
+	scheduleCmd.AddCommand(scheduleAddCmd, scheduleRemoveCmd, scheduleListCmd, scheduleServeCmd)
+}
+
+func scheduleStore(cmd *cobra.Command) (*schedule.Store, error) {
+	cwd, err := ResolveCwd(cmd)
+	if err != nil {
+		return nil, err
+	}
+	dataDir, _ := cmd.Flags().GetString("data-dir")
+	cfg, err := config.Load(cwd, dataDir, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %v", err)
+	}
+	return schedule.ProjectStore(cfg.Options.DataDirectory), nil
+}
+
+// runScheduledTask runs each task in a new session, reporting the outcome
+// to w and the notifier.
+func runScheduledTask(app *app.App, notifier *notify.Notifier, w io.Writer) schedule.RunFunc {
+	return func(ctx context.Context, task schedule.Task) (string, error) {
+		const maxPromptLengthForTitle = 100
+		title := task.Prompt
+		if len(title) > maxPromptLengthForTitle {
+			title = title[:maxPromptLengthForTitle] + "..."
+		}
+		sess, err := app.Sessions.Create(ctx, "Scheduled: "+title)
+		if err != nil {
+			return "", fmt.Errorf("failed to create session for scheduled task: %w", err)
+		}
+		app.Permissions.AutoApproveSession(sess.ID)
+		defer app.ReleaseEditLocks(sess.ID)
+
+		fmt.Fprintf(w, "%s Running task %s in session %s\n", time.Now().Format(time.DateTime), task.ID, sess.ID)
+		_, err = app.AgentCoordinator.Run(ctx, sess.ID, task.Prompt)
+		if ctx.Err() != nil {
+			return sess.ID, err
+		}
+
+		title, body := "Scheduled task finished", fmt.Sprintf("Task %s finished", task.ID)
+		if err != nil {
+			title, body = "Scheduled task failed", fmt.Sprintf("Task %s failed: %v", task.ID, err)
+		}
+		fmt.Fprintf(w, "%s %s\n", time.Now().Format(time.DateTime), body)
+		fmt.Fprint(w, notifier.Sequence(title, body))
+		notifier.Run(ctx, title, body)
+		return sess.ID, err
+	}
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"charm.land/lipgloss/v2"
+	"charm.land/lipgloss/v2/table"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "Manage the sessions of the project",
+}
+
+var sessionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the sessions of the project",
+	Long: `List the sessions of the project, newest first. Sessions are tagged from
+the sessions dialog or the "Tag Session" command.`,
+	Example: `
+# List every session
+crush sessions list
+
+# List the sessions tagged refactor
+crush sessions list --tag refactor
+  `,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tags, _ := cmd.Flags().GetStringSlice("tag")
+
+		cwd, err := ResolveCwd(cmd)
+		if err != nil {
+			return err
+		}
+		dataDir, _ := cmd.Flags().GetString("data-dir")
+		cfg, err := config.Load(cwd, dataDir, false)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %v", err)
+		}
+		store, err := openStore(cmd.Context(), cfg)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		all, err := session.NewService(store).List(cmd.Context())
+		if err != nil {
+			return err
+		}
+		sessions := filterSessionsByTags(all, tags)
+		if len(sessions) == 0 {
+			cmd.PrintErrln("No sessions found.")
+			return nil
+		}
+
+		rows := make([][]string, 0, len(sessions))
+		for _, s := range sessions {
+			title := s.Title
+			if title == "" {
+				title = "Untitled session"
+			}
+			rows = append(rows, []string{
+				s.ID,
+				time.Unix(s.UpdatedAt, 0).Format(time.DateTime),
+				strings.Join(s.Tags, " "),
+				title,
+			})
+		}
+
+		if !term.IsTerminal(os.Stdout.Fd()) {
+			for _, row := range rows {
+				cmd.Println(strings.Join(row, "\t"))
+			}
+			return nil
+		}
+		t := table.New().
+			Border(lipgloss.RoundedBorder()).
+			StyleFunc(func(row, col int) lipgloss.Style {
+				return lipgloss.NewStyle().Padding(0, 1)
+			}).
+			Headers("ID", "Updated", "Tags", "Title").
+			Rows(rows...)
+		lipgloss.Println(t)
+		return nil
+	},
+}
+
+func init() {
+	sessionsListCmd.Flags().StringSlice("tag", nil, "Only list the sessions with this tag; repeat it to require several")
+
+	sessionsCmd.AddCommand(sessionsListCmd)
+}
+
+// filterSessionsByTags returns the sessions tagged with all of tags.
+func filterSessionsByTags(sessions []session.Session, tags []string) []session.Session {
+	var filtered []session.Session
+	for _, s := range sessions {
+		tagged := true
+		for _, tag := range tags {
+			if !s.HasTag(tag) {
+				tagged = false
+				break
+			}
+		}
+		if tagged {
+			filtered = append(filtered, s)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/keychain"
+	"github.com/spf13/cobra"
+)
+
+// storageKeychainKey is the keychain entry holding the random storage
+// encryption key used when no passphrase is configured.
+const storageKeychainKey = "storage-key"
+
+var storageCmd = &cobra.Command{
+	Use:   "storage",
+	Short: "Manage stored sessions and messages",
+	Long: `Manage the database Crush stores sessions and messages in.
+
+Stop any running Crush instance using the same storage before running these
+commands.`,
+	Example: `
+# Encrypt existing messages after enabling options.storage.encrypt
+crush storage encrypt
+
+# Decrypt all messages before disabling options.storage.encrypt
+crush storage decrypt
+  `,
+}
+
+var storageEncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt messages stored before encryption was enabled",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return rewriteStoredMessages(cmd, true)
+	},
+}
+
+var storageDecryptCmd = &cobra.Command{
+	Use:   "decrypt",
+	Short: "Decrypt all stored messages",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return rewriteStoredMessages(cmd, false)
+	},
+}
+
+func init() {
+	storageCmd.AddCommand(storageEncryptCmd, storageDecryptCmd)
+}
+
+func rewriteStoredMessages(cmd *cobra.Command, encrypt bool) error {
+	cwd, err := ResolveCwd(cmd)
+	if err != nil {
+		return err
+	}
+	dataDir, _ := cmd.Flags().GetString("data-dir")
+	cfg, err := config.Load(cwd, dataDir, false)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %v", err)
+	}
+	if encrypt && !cfg.Options.Storage.Encrypt {
+		return errors.New("enable options.storage.encrypt before encrypting existing messages")
+	}
+
+	// Use the plain store: the messages are rewritten as they are.
+	store, err := openPlainStore(cmd.Context(), cfg)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	c, err := storageCipher(cmd.Context(), cfg, store)
+	if err != nil {
+		return err
+	}
+
+	var count int
+	if encrypt {
+		count, err = db.EncryptMessages(cmd.Context(), store, c)
+	} else {
+		count, err = db.DecryptMessages(cmd.Context(), store, c)
+	}
+	if err != nil {
+		return err
+	}
+	if encrypt {
+		cmd.Printf("Encrypted %d messages.\n", count)
+	} else {
+		cmd.Printf("Decrypted %d messages.\n", count)
+	}
+	return nil
+}
+
+// openStore connects to the configured storage backend, encrypting message
+// content if enabled.
+func openStore(ctx context.Context, cfg *config.Config) (db.Store, error) {
+	store, err := openPlainStore(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.Options.Storage.Encrypt {
+		return store, nil
+	}
+	c, err := storageCipher(ctx, cfg, store)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	return db.NewEncryptedStore(store, c), nil
+}
+
+func openPlainStore(ctx context.Context, cfg *config.Config) (db.Store, error) {
+	dsn, err := cfg.Resolve(cfg.Options.Storage.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve storage dsn: %w", err)
+	}
+	return db.Open(ctx, db.StoreOptions{
+		Driver:  string(cfg.Options.Storage.Driver),
+		DSN:     dsn,
+		DataDir: cfg.Options.DataDirectory,
+	})
+}
+
+// storageCipher returns the cipher of the database of q for the configured
+// passphrase, or for a random key kept in the OS keychain, creating it if
+// needed.
+func storageCipher(ctx context.Context, cfg *config.Config, q db.Querier) (*db.Cipher, error) {
+	passphrase, err := cfg.Resolve(cfg.Options.Storage.Passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve storage passphrase: %w", err)
+	}
+	salt, err := db.EncryptionSalt(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if passphrase != "" {
+		return db.NewPassphraseCipher(passphrase, salt)
+	}
+
+	encoded, err := keychain.Get(storageKeychainKey)
+	switch {
+	case errors.Is(err, keychain.ErrNotFound):
+		key := make([]byte, db.KeySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		encoded = base64.StdEncoding.EncodeToString(key)
+		if err := keychain.Set(storageKeychainKey, encoded); err != nil {
+			return nil, fmt.Errorf("failed to save storage key to the OS keychain, set options.storage.passphrase instead: %w", err)
+		}
+	case err != nil:
+		return nil, fmt.Errorf("failed to read storage key from the OS keychain, set options.storage.passphrase instead: %w", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage key in the OS keychain: %w", err)
+	}
+	return db.NewKeyCipher(key, salt)
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/transcript"
+	"github.com/charmbracelet/x/exp/charmtone"
+	"github.com/spf13/cobra"
+)
+
+var transcriptCmd = &cobra.Command{
+	Use:   "transcript",
+	Short: "Inspect recorded provider transcripts",
+	Long: `Inspect the provider request/response pairs recorded when the
+debug_transcript option is enabled. Transcripts are stored, redacted, under
+the data directory.
+
+With --follow, print the conversation of the active session as plain text
+instead, as messages finish. Useful with a screen reader next to the TUI.`,
+	Example: `
+# Follow the active session as plain text
+crush transcript --follow
+
+# List sessions with recorded transcripts
+crush transcript list
+
+# Show the transcript for a session
+crush transcript show 4b2f7c3e-1d2a-4e9b-8c7d-0a1b2c3d4e5f
+
+# Dump the raw entries as JSON
+crush transcript show --json 4b2f7c3e-1d2a-4e9b-8c7d-0a1b2c3d4e5f
+  `,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		follow, _ := cmd.Flags().GetBool("follow")
+		if !follow {
+			return cmd.Help()
+		}
+		sessionID, _ := cmd.Flags().GetString("session")
+
+		cwd, err := ResolveCwd(cmd)
+		if err != nil {
+			return err
+		}
+		dataDir, _ := cmd.Flags().GetString("data-dir")
+		cfg, err := config.Load(cwd, dataDir, false)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %v", err)
+		}
+		store, err := openStore(cmd.Context(), cfg)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer cancel()
+		follower := newTranscriptFollower(message.NewService(store), session.NewService(store), cmd.OutOrStdout(), sessionID)
+		return follower.Run(ctx)
+	},
+}
+
+var transcriptListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List sessions with recorded transcripts",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dataDir, err := transcriptDataDir(cmd)
+		if err != nil {
+			return err
+		}
+		sessions, err := transcript.Sessions(dataDir)
+		if err != nil {
+			return fmt.Errorf("failed to list transcripts: %w", err)
+		}
+		if len(sessions) == 0 {
+			cmd.PrintErrln("No transcripts found. Enable them with the options.debug_transcript setting.")
+			return nil
+		}
+		for _, s := range sessions {
+			cmd.Println(s)
+		}
+		return nil
+	},
+}
+
+var transcriptShowCmd = &cobra.Command{
+	Use:   "show <session>",
+	Short: "Show the recorded transcript for a session",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		dataDir, err := transcriptDataDir(cmd)
+		if err != nil {
+			return err
+		}
+		entries, err := transcript.Load(dataDir, args[0])
+		if err != nil {
+			return err
+		}
+
+		if asJSON {
+			bts, err := json.MarshalIndent(entries, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal transcript: %w", err)
+			}
+			cmd.Println(string(bts))
+			return nil
+		}
+		printTranscript(cmd.OutOrStdout(), entries)
+		return nil
+	},
+}
+
+func init() {
+	transcriptCmd.Flags().BoolP("follow", "f", false, "Print the active session as plain text as messages finish")
+	transcriptCmd.Flags().StringP("session", "s", "", "Session to follow instead of the most recently updated one")
+	transcriptShowCmd.Flags().Bool("json", false, "Output the raw entries as JSON")
+	transcriptCmd.AddCommand(transcriptListCmd, transcriptShowCmd)
+}
+
+func transcriptDataDir(cmd *cobra.Command) (string, error) {
+	cwd, err := ResolveCwd(cmd)
+	if err != nil {
+		return "", err
+	}
+	dataDir, _ := cmd.Flags().GetString("data-dir")
+	cfg, err := config.Load(cwd, dataDir, false)
+	if err != nil {
+		return "", fmt.Errorf("failed to load configuration: %v", err)
+	}
+	return cfg.Options.DataDirectory, nil
+}
+
+func printTranscript(w io.Writer, entries []transcript.Entry) {
+	header := lipgloss.NewStyle().Bold(true).Foreground(charmtone.Charple)
+	label := lipgloss.NewStyle().Foreground(charmtone.Squid)
+	failure := lipgloss.NewStyle().Foreground(charmtone.Sriracha)
+
+	for i, e := range entries {
+		status := "no response"
+		if e.Response != nil {
+			status = e.Response.Status
+		}
+		lipgloss.Fprintln(w, header.Render(fmt.Sprintf(
+			"#%d %s %s %s → %s (%dms)",
+			i+1,
+			e.Time.Format("2006-01-02 15:04:05"),
+			e.Request.Method,
+			e.Request.URL,
+			status,
+			e.DurationMS,
+		)))
+		if e.Provider != "" {
+			lipgloss.Fprintln(w, label.Render("Provider: ")+e.Provider)
+		}
+		if e.Error != "" {
+			lipgloss.Fprintln(w, failure.Render("Error: "+e.Error))
+		}
+		lipgloss.Fprintln(w, label.Render("Request:"))
+		fmt.Fprintln(w, indentJSON(e.Request.Body))
+		if e.Response != nil {
+			lipgloss.Fprintln(w, label.Render("Response:"))
+			fmt.Fprintln(w, indentJSON(e.Response.Body))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func indentJSON(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return "(empty)"
+	}
+	// Non-JSON bodies (e.g. server-sent events) are stored as JSON strings.
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var b bytes.Buffer
+	if err := json.Indent(&b, raw, "", "  "); err != nil {
+		return string(raw)
+	}
+	return b.String()
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+// followInterval is how often the database is polled for new messages.
+// The TUI runs in another process, so there are no events to subscribe to.
+const followInterval = 500 * time.Millisecond
+
+// transcriptFollower prints the finished messages of a session as plain
+// text, following the most recently updated session unless one is given.
+type transcriptFollower struct {
+	messages  message.Service
+	sessions  session.Service
+	w         io.Writer
+	sessionID string
+
+	current string
+	printed map[string]bool
+}
+
+func newTranscriptFollower(messages message.Service, sessions session.Service, w io.Writer, sessionID string) *transcriptFollower {
+	return &transcriptFollower{
+		messages:  messages,
+		sessions:  sessions,
+		w:         w,
+		sessionID: sessionID,
+		printed:   make(map[string]bool),
+	}
+}
+
+// Run polls until the context is done.
+func (f *transcriptFollower) Run(ctx context.Context) error {
+	ticker := time.NewTicker(followInterval)
+	defer ticker.Stop()
+	for {
+		if err := f.poll(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (f *transcriptFollower) poll(ctx context.Context) error {
+	sess, err := f.activeSession(ctx)
+	if err != nil || sess == nil {
+		return err
+	}
+	if sess.ID != f.current {
+		f.current = sess.ID
+		fmt.Fprintf(f.w, "Session: %s\n\n", sess.Title)
+	}
+
+	msgs, err := f.messages.List(ctx, sess.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list messages: %w", err)
+	}
+	for _, msg := range msgs {
+		if f.printed[msg.ID] {
+			continue
+		}
+		// Keep the order: nothing after a message still being written is
+		// printed before it.
+		if !msg.IsFinished() {
+			break
+		}
+		f.printed[msg.ID] = true
+		if text := plainMessage(msg); text != "" {
+			fmt.Fprintf(f.w, "%s\n\n", text)
+		}
+	}
+	return nil
+}
+
+func (f *transcriptFollower) activeSession(ctx context.Context) (*session.Session, error) {
+	if f.sessionID != "" {
+		sess, err := f.sessions.Get(ctx, f.sessionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get session: %w", err)
+		}
+		return &sess, nil
+	}
+	sessions, err := f.sessions.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	var active *session.Session
+	for i := range sessions {
+		if active == nil || sessions[i].UpdatedAt > active.UpdatedAt {
+			active = &sessions[i]
+		}
+	}
+	return active, nil
+}
+
+// plainMessage renders a message and, for assistant messages, its tool
+// calls. Their results follow in the tool message.
+func plainMessage(msg message.Message) string {
+	parts := []string{msg.PlainText()}
+	for _, call := range msg.ToolCalls() {
+		parts = append(parts, message.PlainToolCall(call, nil, false))
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n"))
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranscriptFollower(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+	sessions := session.NewService(q)
+	messages := message.NewService(q)
+
+	sess, err := sessions.Create(t.Context(), "Fix the build")
+	require.NoError(t, err)
+	_, err = messages.Create(t.Context(), sess.ID, message.CreateMessageParams{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: "why does it fail?"}},
+	})
+	require.NoError(t, err)
+	answer, err := messages.Create(t.Context(), sess.ID, message.CreateMessageParams{
+		Role: message.Assistant,
+		Parts: []message.ContentPart{
+			message.TextContent{Text: "Let me look."},
+			message.ToolCall{ID: "call-1", Name: "view", Input: `{"file_path":"main.go"}`, Finished: true},
+		},
+	})
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	f := newTranscriptFollower(messages, sessions, &out, "")
+	require.NoError(t, f.poll(t.Context()))
+	require.Equal(t, "Session: Fix the build\n\nYou: why does it fail?\n\n", out.String(),
+		"unfinished messages wait")
+
+	answer.AddFinish(message.FinishReasonToolUse, "", "")
+	require.NoError(t, messages.Update(t.Context(), answer))
+	_, err = messages.Create(t.Context(), sess.ID, message.CreateMessageParams{
+		Role:  message.Tool,
+		Parts: []message.ContentPart{message.ToolResult{ToolCallID: "call-1", Name: "view", Content: "package main"}},
+	})
+	require.NoError(t, err)
+
+	out.Reset()
+	require.NoError(t, f.poll(t.Context()))
+	require.Equal(t, "Assistant: Let me look.\nTool view: {\"file_path\":\"main.go\"}\n\n"+
+		"Result of view: package main\n\n", out.String())
+
+	out.Reset()
+	require.NoError(t, f.poll(t.Context()))
+	require.Empty(t, out.String(), "messages are printed once")
+}
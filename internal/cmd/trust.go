@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/trust"
+	"github.com/charmbracelet/x/exp/charmtone"
+	"github.com/spf13/cobra"
+)
+
+var trustCmd = &cobra.Command{
+	Use:   "trust [directory]",
+	Short: "Mark a directory as trusted or untrusted",
+	Long: `Mark a directory, and the directories inside it, as trusted or untrusted.
+In trusted directories read-only commands run without asking for permission.
+In untrusted ones every tool call that needs permission asks, and the tools
+allowed by the configuration are ignored. Until a directory is trusted, the
+hooks, MCP and LSP servers, project commands and notification command of its
+configuration don't run. Crush asks the first time it runs in a directory
+that isn't marked either way.`,
+	Example: `
+# Trust the current directory
+crush trust
+
+# Always ask for permission in a checkout of someone else's code
+crush trust --untrusted ~/src/unknown-repo
+
+# Forget the trust level of a directory, to be asked again
+crush trust --forget ~/src/unknown-repo
+
+# List the marked directories
+crush trust --list
+  `,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		untrusted, _ := cmd.Flags().GetBool("untrusted")
+		forget, _ := cmd.Flags().GetBool("forget")
+		list, _ := cmd.Flags().GetBool("list")
+
+		store := trust.DefaultStore()
+		if list {
+			return listTrust(cmd, store)
+		}
+
+		dir, err := ResolveCwd(cmd)
+		if err != nil {
+			return err
+		}
+		if len(args) == 1 {
+			dir, err = filepath.Abs(args[0])
+			if err != nil {
+				return err
+			}
+		}
+
+		level := trust.Trusted
+		switch {
+		case untrusted && forget:
+			return fmt.Errorf("--untrusted and --forget can't be used together")
+		case untrusted:
+			level = trust.Untrusted
+		case forget:
+			level = trust.Unknown
+		}
+		if err := store.Set(dir, level); err != nil {
+			return err
+		}
+
+		switch level {
+		case trust.Unknown:
+			cmd.Printf("Forgot the trust level of %s\n", dir)
+			if inherited, from, err := store.Lookup(dir); err == nil && inherited != trust.Unknown {
+				cmd.Printf("It's still %s through %s\n", inherited, from)
+			}
+		default:
+			cmd.Printf("Marked %s as %s\n", dir, level)
+		}
+		return nil
+	},
+}
+
+func listTrust(cmd *cobra.Command, store *trust.Store) error {
+	dirs, err := store.List()
+	if err != nil {
+		return err
+	}
+	if len(dirs) == 0 {
+		cmd.PrintErrln("No directories are marked as trusted or untrusted.")
+		return nil
+	}
+	trusted := lipgloss.NewStyle().Foreground(charmtone.Guac)
+	untrusted := lipgloss.NewStyle().Foreground(charmtone.Coral)
+	w := cmd.OutOrStdout()
+	for _, dir := range slices.Sorted(maps.Keys(dirs)) {
+		style := trusted
+		if dirs[dir] == trust.Untrusted {
+			style = untrusted
+		}
+		lipgloss.Fprintln(w, style.Render(fmt.Sprintf("%-9s", dirs[dir]))+" "+dir)
+	}
+	return nil
+}
+
+func init() {
+	trustCmd.Flags().Bool("untrusted", false, "Mark the directory as untrusted")
+	trustCmd.Flags().Bool("forget", false, "Forget the trust level of the directory")
+	trustCmd.Flags().Bool("list", false, "List the marked directories")
+}
@@ -13,7 +13,8 @@ import (
 var updateProvidersCmd = &cobra.Command{
 	Use:   "update-providers [path-or-url]",
 	Short: "Update providers",
-	Long:  `Update the list of providers from a specified local path or remote URL.`,
+	Long: `Update the list of providers from a specified local path or remote URL.
+In offline mode only local paths and the embedded version can be used.`,
 	Example: `
 # Update providers remotely from Catwalk
 crush update-providers
@@ -36,7 +37,14 @@ crush update-providers embedded
 			pathOrUrl = args[0]
 		}
 
-		if err := config.UpdateProviders(pathOrUrl); err != nil {
+		cwd, err := ResolveCwd(cmd)
+		if err != nil {
+			return err
+		}
+		if err := config.ConfigureNetwork(); err != nil {
+			return err
+		}
+		if err := config.UpdateProviders(pathOrUrl, config.Offline(cwd)); err != nil {
 			return err
 		}
 
@@ -3,8 +3,10 @@ package config
 import (
 	"cmp"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net/http"
 	"net/url"
 	"os"
@@ -15,7 +17,14 @@ import (
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/env"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/httpext"
 	"github.com/charmbracelet/crush/internal/oauth"
+	"github.com/charmbracelet/crush/internal/oauth/chatgpt"
+	"github.com/charmbracelet/crush/internal/oauth/claude"
+	"github.com/charmbracelet/crush/internal/oauth/copilot"
+	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/charmbracelet/crush/internal/trash"
 	"github.com/invopop/jsonschema"
 	"github.com/tidwall/sjson"
 )
@@ -91,13 +100,17 @@ type ProviderConfig struct {
 	// The provider's API endpoint.
 	BaseURL string `json:"base_url,omitempty" jsonschema:"description=Base URL for the provider's API,format=uri,example=https://api.openai.com/v1"`
 	// The provider type, e.g. "openai", "anthropic", etc. if empty it defaults to openai.
-	Type catwalk.Type `json:"type,omitempty" jsonschema:"description=Provider type that determines the API format,enum=openai,enum=openai-compat,enum=anthropic,enum=gemini,enum=azure,enum=vertexai,default=openai"`
+	Type catwalk.Type `json:"type,omitempty" jsonschema:"description=Provider type that determines the API format,enum=openai,enum=openai-compat,enum=anthropic,enum=gemini,enum=azure,enum=vertexai,enum=copilot,default=openai"`
 	// The provider's API key.
 	APIKey string `json:"api_key,omitempty" jsonschema:"description=API key for authentication with the provider,example=$OPENAI_API_KEY"`
 	// OAuthToken for providers that use OAuth2 authentication.
 	OAuthToken *oauth.Token `json:"oauth,omitempty" jsonschema:"description=OAuth2 token for authentication with the provider"`
 	// Marks the provider as disabled.
 	Disable bool `json:"disable,omitempty" jsonschema:"description=Whether this provider is disabled,default=false"`
+	// How many requests to the provider can run at once, 0 for no limit.
+	MaxConcurrentRequests int `json:"max_concurrent_requests,omitempty" jsonschema:"description=How many requests to the provider can run at once across sessions and sub-agents. 0 doesn't limit them,default=0,example=2"`
+	// How long the requests to the provider wait for it.
+	Timeouts *ProviderTimeouts `json:"timeouts,omitempty" jsonschema:"description=How long the requests to the provider wait for it before they are retried"`
 
 	// Custom system prompt prefix.
 	SystemPromptPrefix string `json:"system_prompt_prefix,omitempty" jsonschema:"description=Custom prefix to add to system prompts for this provider"`
@@ -105,7 +118,7 @@ type ProviderConfig struct {
 	// Extra headers to send with each request to the provider.
 	ExtraHeaders map[string]string `json:"extra_headers,omitempty" jsonschema:"description=Additional HTTP headers to send with requests"`
 	// Extra body
-	ExtraBody map[string]any `json:"extra_body,omitempty" jsonschema:"description=Additional fields to include in request bodies, only works with openai-compatible providers"`
+	ExtraBody map[string]any `json:"extra_body,omitempty" jsonschema:"description=Additional fields to include in request bodies, only works with openai and openai-compatible providers"`
 
 	ProviderOptions map[string]any `json:"provider_options,omitempty" jsonschema:"description=Additional provider-specific options for this provider"`
 
@@ -116,6 +129,62 @@ type ProviderConfig struct {
 	Models []catwalk.Model `json:"models,omitempty" jsonschema:"description=List of models available from this provider"`
 }
 
+// SetupOAuth configures the provider to authenticate with its OAuth token.
+func (pc *ProviderConfig) SetupOAuth() {
+	if pc.Type == copilot.Name {
+		pc.SetupCopilot()
+		return
+	}
+	if pc.ID == string(catwalk.InferenceProviderOpenAI) {
+		pc.SetupChatGPT()
+		return
+	}
+	pc.SetupClaudeCode()
+}
+
+// OAuthRefresher returns the function that refreshes the provider's OAuth
+// token, or nil if the provider doesn't support OAuth.
+func (pc *ProviderConfig) OAuthRefresher() oauth.RefreshFunc {
+	switch {
+	case pc.Type == copilot.Name:
+		return copilot.RefreshToken
+	case pc.ID == string(catwalk.InferenceProviderAnthropic):
+		return claude.RefreshToken
+	case pc.ID == string(catwalk.InferenceProviderOpenAI):
+		return chatgpt.RefreshToken
+	}
+	return nil
+}
+
+// SetupChatGPT configures the provider to use a ChatGPT subscription through
+// the Codex endpoint instead of the OpenAI API.
+func (pc *ProviderConfig) SetupChatGPT() {
+	pc.APIKey = pc.OAuthToken.AccessToken
+	pc.BaseURL = chatgpt.BaseURL
+	if pc.ExtraHeaders == nil {
+		pc.ExtraHeaders = make(map[string]string)
+	}
+	pc.ExtraHeaders["chatgpt-account-id"] = pc.OAuthToken.AccountID
+	pc.ExtraHeaders["OpenAI-Beta"] = "responses=experimental"
+	pc.ExtraHeaders["originator"] = "codex_cli_rs"
+	// The Codex endpoint doesn't keep responses around.
+	if pc.ExtraBody == nil {
+		pc.ExtraBody = make(map[string]any)
+	}
+	pc.ExtraBody["store"] = false
+}
+
+// SetupCopilot configures the provider to use a GitHub Copilot token and the
+// editor headers Copilot requires.
+func (pc *ProviderConfig) SetupCopilot() {
+	pc.APIKey = pc.OAuthToken.AccessToken
+	pc.BaseURL = cmp.Or(pc.BaseURL, copilot.BaseURL)
+	if pc.ExtraHeaders == nil {
+		pc.ExtraHeaders = make(map[string]string)
+	}
+	maps.Copy(pc.ExtraHeaders, copilot.Headers)
+}
+
 func (pc *ProviderConfig) SetupClaudeCode() {
 	pc.APIKey = fmt.Sprintf("Bearer %s", pc.OAuthToken.AccessToken)
 	pc.SystemPromptPrefix = "You are Claude Code, Anthropic's official CLI for Claude."
@@ -148,9 +217,10 @@ type MCPConfig struct {
 	URL      string            `json:"url,omitempty" jsonschema:"description=URL for HTTP or SSE MCP servers,format=uri,example=http://localhost:3000/mcp"`
 	Disabled bool              `json:"disabled,omitempty" jsonschema:"description=Whether this MCP server is disabled,default=false"`
 	Timeout  int               `json:"timeout,omitempty" jsonschema:"description=Timeout in seconds for MCP server connections,default=15,example=30,example=60,example=120"`
+	Sampling bool              `json:"sampling,omitempty" jsonschema:"description=Let the server ask the model for completions while its tools run. Each request asks for permission,default=false"`
 
 	// TODO: maybe make it possible to get the value from the env
-	Headers map[string]string `json:"headers,omitempty" jsonschema:"description=HTTP headers for HTTP/SSE MCP servers"`
+	Headers map[string]string `json:"headers,omitempty" jsonschema:"description=HTTP headers for HTTP/SSE MCP servers. Values support $(command) and $VAR and may end with a keychain:<name> or file:<path> reference"`
 }
 
 type LSPConfig struct {
@@ -162,15 +232,92 @@ type LSPConfig struct {
 	RootMarkers []string          `json:"root_markers,omitempty" jsonschema:"description=Files or directories that indicate the project root,example=go.mod,example=package.json,example=Cargo.toml"`
 	InitOptions map[string]any    `json:"init_options,omitempty" jsonschema:"description=Initialization options passed to the LSP server during initialize request"`
 	Options     map[string]any    `json:"options,omitempty" jsonschema:"description=LSP server-specific settings passed during initialization"`
+	// AutoDiagnostics defaults to true.
+	AutoDiagnostics *bool `json:"auto_diagnostics,omitempty" jsonschema:"description=Attach the diagnostics of the files the agent changes to the results of its edits and commands,default=true"`
 }
 
 type TUIOptions struct {
 	CompactMode bool   `json:"compact_mode,omitempty" jsonschema:"description=Enable compact mode for the TUI interface,default=false"`
 	DiffMode    string `json:"diff_mode,omitempty" jsonschema:"description=Diff mode for the TUI interface,enum=unified,enum=split"`
+	Accessible  bool   `json:"accessible,omitempty" jsonschema:"description=Screen reader friendly mode with plain text messages and no animations or sidebar,default=false"`
+	FileView    bool   `json:"file_view,omitempty" jsonschema:"description=Show the file the agent last edited or a pinned one next to the chat,default=false"`
+	FileTree    bool   `json:"file_tree,omitempty" jsonschema:"description=Show the working directory as a tree next to the chat with the files the agent changed marked,default=false"`
+	FileDrop    string `json:"file_drop,omitempty" jsonschema:"description=What dropping files on the window does: attach images and mention other files or mention all files,enum=attach,enum=mention,default=attach"`
 	// Here we can add themes later or any TUI related options
 	//
 
 	Completions Completions `json:"completions,omitzero" jsonschema:"description=Completions UI options"`
+	Syntax      Syntax      `json:"syntax,omitzero" jsonschema:"description=Syntax highlighting options"`
+	Macros      []Macro     `json:"macros,omitempty" jsonschema:"description=Actions of the commands dialog that run a sequence of commands"`
+	StatusBar   StatusBar   `json:"status_bar,omitzero" jsonschema:"description=Segments shown in the status bar"`
+
+	CompactLayout CompactLayout `json:"compact_layout,omitzero" jsonschema:"description=When the TUI switches to the compact layout on its own"`
+}
+
+// StatusBar defines the segments shown next to the help in the status bar.
+type StatusBar struct {
+	Segments []string        `json:"segments,omitempty" jsonschema:"description=Segments to show in order: model; cost; context; lsp or the name of a custom segment. Defaults to the custom segments,example=model,example=context"`
+	Custom   []StatusSegment `json:"custom,omitempty" jsonschema:"description=Segments showing the output of a shell command"`
+}
+
+// StatusSegment is a status bar segment showing the first line of the output
+// of a shell command, run again on an interval.
+type StatusSegment struct {
+	Name     string `json:"name" jsonschema:"required,description=Name of the segment to list it in segments,example=branch"`
+	Command  string `json:"command" jsonschema:"required,description=Shell command whose first line of output is shown,example=git branch --show-current"`
+	Interval int    `json:"interval,omitempty" jsonschema:"description=Seconds between runs of the command,default=10,example=60"`
+}
+
+// IntervalDuration returns how long to wait between runs of the command.
+func (s StatusSegment) IntervalDuration() time.Duration {
+	if s.Interval <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(s.Interval) * time.Second
+}
+
+// SegmentNames returns the segments to show, in order.
+func (s StatusBar) SegmentNames() []string {
+	if len(s.Segments) > 0 {
+		return s.Segments
+	}
+	names := make([]string, 0, len(s.Custom))
+	for _, segment := range s.Custom {
+		names = append(names, segment.Name)
+	}
+	return names
+}
+
+const (
+	// FileDropAttach attaches the images dropped on the window and mentions
+	// the other files in the prompt.
+	FileDropAttach = "attach"
+	// FileDropMention mentions all the files dropped on the window in the
+	// prompt.
+	FileDropMention = "mention"
+)
+
+// Macro is an action of the commands dialog that runs a sequence of commands,
+// e.g. to compact the session, switch the model and send a prompt.
+type Macro struct {
+	Name        string `json:"name" jsonschema:"required,description=Title of the action in the commands dialog,example=Review with another model"`
+	Description string `json:"description,omitempty" jsonschema:"description=Description of the action in the commands dialog"`
+	// Run is parsed by the commands dialog.
+	Run string `json:"run" jsonschema:"required,description=Steps separated by semicolons: new; compact; model provider/model; small_model provider/model; send a prompt; command the ID of another command. $NAME placeholders are asked for when the action runs,example=compact; model openai/gpt-4o; send Review the changes to $FILE"`
+}
+
+// CompactLayout defines when the TUI switches between the full and the compact
+// layout as the window is resized, unless compact_mode keeps it compact.
+type CompactLayout struct {
+	Width      *int `json:"width,omitempty" jsonschema:"description=Width below which the TUI switches to the compact layout. 0 doesn't switch on the width,default=120,example=100"`
+	Height     *int `json:"height,omitempty" jsonschema:"description=Height below which the TUI switches to the compact layout. 0 doesn't switch on the height,default=30,example=24"`
+	Hysteresis *int `json:"hysteresis,omitempty" jsonschema:"description=How many columns and rows past the width and height the window has to grow to switch back to the full layout,default=4,example=0"`
+}
+
+// Thresholds returns the width and height below which the layout is compact,
+// and how far past them the window has to grow for it to be full again.
+func (c CompactLayout) Thresholds() (width, height, hysteresis int) {
+	return ptrValOr(c.Width, 120), ptrValOr(c.Height, 30), max(0, ptrValOr(c.Hysteresis, 4))
 }
 
 // Completions defines options for the completions UI.
@@ -183,9 +330,107 @@ func (c Completions) Limits() (depth, items int) {
 	return ptrValOr(c.MaxDepth, 0), ptrValOr(c.MaxItems, 0)
 }
 
+// Syntax defines options for the syntax highlighting of code.
+type Syntax struct {
+	Style     string            `json:"style,omitempty" jsonschema:"description=Chroma style to highlight code with instead of the colors of the theme,example=monokai,example=github-dark"`
+	Languages map[string]string `json:"languages,omitempty" jsonschema:"description=Languages to highlight files with by file extension or name such as .tpl: html or Jenkinsfile: groovy"`
+	MaxSize   *int              `json:"max_size,omitempty" jsonschema:"description=Size in bytes above which code is shown without highlighting. 0 always highlights code,default=262144,example=1048576"`
+}
+
+// MaxSizeBytes returns the size in bytes above which code isn't highlighted,
+// or 0 to always highlight it.
+func (s Syntax) MaxSizeBytes() int {
+	return ptrValOr(s.MaxSize, 256*1024)
+}
+
 type Permissions struct {
 	AllowedTools []string `json:"allowed_tools,omitempty" jsonschema:"description=List of tools that don't require permission prompts,example=bash,example=view"` // Tools that don't require permission prompts
 	SkipRequests bool     `json:"-"`                                                                                                                              // Automatically accept all permissions (YOLO mode)
+	// Timeout keeps a prompt no one answers from stalling the run forever.
+	Timeout       int                     `json:"timeout,omitempty" jsonschema:"description=Seconds a permission prompt waits for an answer before timeout_action is taken. 0 means it waits forever,default=0,example=300"`
+	TimeoutAction PermissionTimeoutAction `json:"timeout_action,omitempty" jsonschema:"description=What is done with a prompt no one answered in time: keep waiting and notify again each timeout; deny it; or grant it if it only reads and deny it otherwise,enum=keep-waiting,enum=deny,enum=allow-readonly,default=keep-waiting"`
+	// ApprovalExpiry keeps a tool allowed for the session from staying
+	// allowed for the rest of a long session.
+	ApprovalExpiry int `json:"approval_expiry,omitempty" jsonschema:"description=Seconds after which a tool call allowed for the session asks again. 0 means it stays allowed for the whole session,default=0,example=3600"`
+}
+
+type PermissionTimeoutAction string
+
+const (
+	PermissionTimeoutKeepWaiting   PermissionTimeoutAction = "keep-waiting"
+	PermissionTimeoutDeny          PermissionTimeoutAction = "deny"
+	PermissionTimeoutAllowReadOnly PermissionTimeoutAction = "allow-readonly"
+)
+
+// TimeoutDuration returns how long permission prompts wait for an answer,
+// or 0 when they wait forever.
+func (p *Permissions) TimeoutDuration() time.Duration {
+	if p == nil || p.Timeout <= 0 {
+		return 0
+	}
+	return time.Duration(p.Timeout) * time.Second
+}
+
+// ApprovalExpiryDuration returns how long the permissions granted for the
+// session last, or 0 when they last for the whole session.
+func (p *Permissions) ApprovalExpiryDuration() time.Duration {
+	if p == nil || p.ApprovalExpiry <= 0 {
+		return 0
+	}
+	return time.Duration(p.ApprovalExpiry) * time.Second
+}
+
+// Project is what the agent is told about the project.
+type Project struct {
+	Commands ProjectCommands `json:"commands,omitzero" jsonschema:"description=The canonical commands of the project. They are listed in the system prompt and each one is a tool the agent runs without asking"`
+}
+
+// ProjectCommands are the commands the project declares, so the agent
+// doesn't have to work them out every session.
+type ProjectCommands struct {
+	Build  string `json:"build,omitempty" jsonschema:"description=Command that builds the project,example=go build ./..."`
+	Lint   string `json:"lint,omitempty" jsonschema:"description=Command that lints the project,example=golangci-lint run"`
+	Test   string `json:"test,omitempty" jsonschema:"description=Command that runs the tests of the project,example=go test ./..."`
+	Format string `json:"format,omitempty" jsonschema:"description=Command that formats the code of the project,example=gofumpt -w ."`
+}
+
+// ProjectCommand is a command the project declares, named after what it
+// does.
+type ProjectCommand struct {
+	Name    string
+	Command string
+}
+
+// All returns the declared commands, in the order build, lint, test and
+// format.
+func (c ProjectCommands) All() []ProjectCommand {
+	var commands []ProjectCommand
+	for _, command := range []ProjectCommand{
+		{"build", c.Build},
+		{"lint", c.Lint},
+		{"test", c.Test},
+		{"format", c.Format},
+	} {
+		if strings.TrimSpace(command.Command) != "" {
+			commands = append(commands, command)
+		}
+	}
+	return commands
+}
+
+// Hooks are shell commands run on lifecycle events. Each command gets the
+// event as JSON on stdin.
+type Hooks struct {
+	PreToolUse   []Hook `json:"pre_tool_use,omitempty" jsonschema:"description=Commands run before a tool is called. Exit code 2 blocks the call with stderr as the reason; printing {\"tool_input\": {...}} rewrites its input"`
+	PostToolUse  []Hook `json:"post_tool_use,omitempty" jsonschema:"description=Commands run after a tool is called. Exit code 2 reports stderr back to the model as an error"`
+	OnSessionEnd []Hook `json:"on_session_end,omitempty" jsonschema:"description=Commands run when the agent finishes working on a session"`
+	OnFileEdit   []Hook `json:"on_file_edit,omitempty" jsonschema:"description=Commands run after the agent edits or writes a file"`
+}
+
+type Hook struct {
+	Command string `json:"command" jsonschema:"required,description=Shell command to run,example=./scripts/lint-gate.sh"`
+	Matcher string `json:"matcher,omitempty" jsonschema:"description=Regular expression matched against the tool name (or the file path for on_file_edit); the hook runs for everything if empty,example=^(edit|write)$"`
+	Timeout int    `json:"timeout,omitempty" jsonschema:"description=Timeout in seconds,default=60"`
 }
 
 type TrailerStyle string
@@ -202,6 +447,12 @@ type Attribution struct {
 	GeneratedWith bool         `json:"generated_with,omitempty" jsonschema:"description=Add Generated with Crush line to commit messages and issues and PRs,default=true"`
 }
 
+// JSONSchema returns the JSON schema of the configuration file.
+func JSONSchema() ([]byte, error) {
+	reflector := new(jsonschema.Reflector)
+	return json.MarshalIndent(reflector.Reflect(&Config{}), "", "  ")
+}
+
 // JSONSchemaExtend marks the co_authored_by field as deprecated in the schema.
 func (Attribution) JSONSchemaExtend(schema *jsonschema.Schema) {
 	if schema.Properties != nil {
@@ -212,17 +463,359 @@ func (Attribution) JSONSchemaExtend(schema *jsonschema.Schema) {
 }
 
 type Options struct {
-	ContextPaths              []string     `json:"context_paths,omitempty" jsonschema:"description=Paths to files containing context information for the AI,example=.cursorrules,example=CRUSH.md"`
-	TUI                       *TUIOptions  `json:"tui,omitempty" jsonschema:"description=Terminal user interface options"`
-	Debug                     bool         `json:"debug,omitempty" jsonschema:"description=Enable debug logging,default=false"`
-	DebugLSP                  bool         `json:"debug_lsp,omitempty" jsonschema:"description=Enable debug logging for LSP servers,default=false"`
-	DisableAutoSummarize      bool         `json:"disable_auto_summarize,omitempty" jsonschema:"description=Disable automatic conversation summarization,default=false"`
-	DataDirectory             string       `json:"data_directory,omitempty" jsonschema:"description=Directory for storing application data (relative to working directory),default=.crush,example=.crush"` // Relative to the cwd
-	DisabledTools             []string     `json:"disabled_tools" jsonschema:"description=Tools to disable"`
-	DisableProviderAutoUpdate bool         `json:"disable_provider_auto_update,omitempty" jsonschema:"description=Disable providers auto-update,default=false"`
-	Attribution               *Attribution `json:"attribution,omitempty" jsonschema:"description=Attribution settings for generated content"`
-	DisableMetrics            bool         `json:"disable_metrics,omitempty" jsonschema:"description=Disable sending metrics,default=false"`
-	InitializeAs              string       `json:"initialize_as,omitempty" jsonschema:"description=Name of the context file to create/update during project initialization,default=AGENTS.md,example=AGENTS.md,example=CRUSH.md,example=CLAUDE.md,example=docs/LLMs.md"`
+	ContextPaths              []string       `json:"context_paths,omitempty" jsonschema:"description=Paths to files containing context information for the AI,example=.cursorrules,example=CRUSH.md"`
+	TUI                       *TUIOptions    `json:"tui,omitempty" jsonschema:"description=Terminal user interface options"`
+	Debug                     bool           `json:"debug,omitempty" jsonschema:"description=Enable debug logging,default=false"`
+	DebugLSP                  bool           `json:"debug_lsp,omitempty" jsonschema:"description=Enable debug logging for LSP servers,default=false"`
+	DebugTranscript           bool           `json:"debug_transcript,omitempty" jsonschema:"description=Save redacted provider request/response pairs as JSON files under the data directory,default=false"`
+	DisableAutoSummarize      bool           `json:"disable_auto_summarize,omitempty" jsonschema:"description=Disable automatic conversation summarization,default=false"`
+	DataDirectory             string         `json:"data_directory,omitempty" jsonschema:"description=Directory for storing application data (relative to working directory),default=.crush,example=.crush"` // Relative to the cwd
+	DisabledTools             []string       `json:"disabled_tools" jsonschema:"description=Tools to disable"`
+	DisableProviderAutoUpdate bool           `json:"disable_provider_auto_update,omitempty" jsonschema:"description=Disable providers auto-update,default=false"`
+	Offline                   bool           `json:"offline,omitempty" jsonschema:"description=Block all network access but the requests to the providers: no providers auto-update or update checks or metrics and no web tools or remote MCP servers,default=false"`
+	Attribution               *Attribution   `json:"attribution,omitempty" jsonschema:"description=Attribution settings for generated content"`
+	DisableMetrics            bool           `json:"disable_metrics,omitempty" jsonschema:"description=Disable sending metrics,default=false"`
+	InitializeAs              string         `json:"initialize_as,omitempty" jsonschema:"description=Name of the context file to create/update during project initialization,default=AGENTS.md,example=AGENTS.md,example=CRUSH.md,example=CLAUDE.md,example=docs/LLMs.md"`
+	DisableContextDriftCheck  bool           `json:"disable_context_drift_check,omitempty" jsonschema:"description=Disable the hourly check for changes to the project that leave the context file out of date,default=false"`
+	Storage                   *Storage       `json:"storage,omitempty" jsonschema:"description=Where sessions and messages are stored"`
+	Notifications             *Notifications `json:"notifications,omitempty" jsonschema:"description=Notifications sent when the agent finishes or needs permission while the terminal is unfocused"`
+	MaxSubAgents              int            `json:"max_sub_agents,omitempty" jsonschema:"description=Maximum number of sub-agents (agent and agentic_fetch tools) running at once; the rest wait for a free slot. 0 means no limit,default=0,example=2"`
+	PromptCache               *PromptCache   `json:"prompt_cache,omitempty" jsonschema:"description=How requests are marked for the providers' prompt caches"`
+	IgnorePatterns            []string       `json:"ignore_patterns,omitempty" jsonschema:"description=Patterns in .gitignore syntax for files the file tools and completions skip on top of the ones in .gitignore and .crushignore files,example=*.generated.go,example=testdata/"`
+	Tools                     ToolOptions    `json:"tools,omitzero" jsonschema:"description=The shell of the bash tool and the limits of the tool calls by tool name; the * entry applies to the tools without their own. MCP tools are named mcp_<server>_<tool>"`
+	Telemetry                 *Telemetry     `json:"telemetry,omitempty" jsonschema:"description=OpenTelemetry export of traces and metrics for agent runs and provider and tool calls"`
+	Network                   *Network       `json:"network,omitempty" jsonschema:"description=The proxy and the CA certificates of the HTTP requests Crush makes; only read from the global config"`
+	Compaction                *Compaction    `json:"compaction,omitempty" jsonschema:"description=How the conversation is made to fit the context window of the model when it grows too long"`
+	Quirks                    []ModelQuirk   `json:"quirks,omitempty" jsonschema:"description=Adjustments of the requests to the models that don't follow the usual conventions; applied after the built-in ones in order"`
+	DisableFocusSocket        bool           `json:"disable_focus_socket,omitempty" jsonschema:"description=Don't listen for the files and selections editor plugins add to the prompt with crush focus,default=false"`
+	EventSocket               bool           `json:"event_socket,omitempty" jsonschema:"description=Serve the events of the running instance as JSON lines over the events.sock unix socket in the data directory for external tools,default=false"`
+	Trash                     *Trash         `json:"trash,omitempty" jsonschema:"description=Where the contents files had before the tools replaced them are kept for crush restore"`
+}
+
+// ModelQuirk adjusts the requests sent to the models it matches. The fields
+// left unset keep the value of the quirks before it, so that an entry only
+// changes what it sets.
+type ModelQuirk struct {
+	Provider         string `json:"provider,omitempty" jsonschema:"description=Glob matched against the ID and the type of the provider; empty matches every provider,example=gemini,example=openrouter"`
+	Model            string `json:"model,omitempty" jsonschema:"description=Glob matched against the ID of the model; empty matches every model,example=gemini-2.5-*,example=*gemma*"`
+	NoThink          *bool  `json:"no_think,omitempty" jsonschema:"description=Ask the model not to think when generating titles with /no_think"`
+	StripThinkTags   *bool  `json:"strip_think_tags,omitempty" jsonschema:"description=Remove the think tags the model writes in the text of titles and summaries"`
+	MinTitleTokens   int64  `json:"min_title_tokens,omitempty" jsonschema:"description=Output tokens title generation gets at least; for models that think before answering,example=1024"`
+	NoSystemMessages *bool  `json:"no_system_messages,omitempty" jsonschema:"description=Send the system prompt at the start of the first user message as the model has no system messages"`
+	UserFirst        *bool  `json:"user_first,omitempty" jsonschema:"description=Start the conversation with a user message as the model rejects others first"`
+}
+
+type CompactionStrategy string
+
+const (
+	// CompactionSummary replaces the conversation with a summary.
+	CompactionSummary CompactionStrategy = "summary"
+	// CompactionSlidingWindow leaves the oldest turns out of the requests.
+	CompactionSlidingWindow CompactionStrategy = "sliding_window"
+	// CompactionPruneToolResults leaves the largest old tool outputs out of
+	// the requests first, and then the oldest turns.
+	CompactionPruneToolResults CompactionStrategy = "prune_tool_results"
+	// CompactionHybrid summarizes the conversation but the latest turns,
+	// which are kept as they are.
+	CompactionHybrid CompactionStrategy = "hybrid"
+)
+
+// Compaction configures how a conversation that no longer fits the context
+// window is compacted. Only the summary strategies are affected by
+// DisableAutoSummarize; the others never change the stored messages.
+type Compaction struct {
+	Strategy CompactionStrategy `json:"strategy,omitempty" jsonschema:"description=How the conversation is compacted: summarize it; leave the oldest turns out; leave old tool outputs out and then the oldest turns; or summarize it but the latest turns,enum=summary,enum=sliding_window,enum=prune_tool_results,enum=hybrid,default=summary"`
+	// RetainTokens defaults to a quarter of the context window.
+	RetainTokens int `json:"retain_tokens,omitempty" jsonschema:"description=Estimated tokens of the latest turns the hybrid strategy keeps as they are,example=30000"`
+	// SynopsisAfterTurns applies whatever the strategy, and doesn't change
+	// the stored messages either.
+	SynopsisAfterTurns int `json:"synopsis_after_turns,omitempty" jsonschema:"description=Turns after which large tool outputs are sent as short synopses of their first and last lines; user and assistant text is kept as it is. 0 keeps them whole,default=0,example=3"`
+}
+
+// GetStrategy returns the configured strategy, or the summary one.
+func (c *Compaction) GetStrategy() CompactionStrategy {
+	if c == nil || c.Strategy == "" {
+		return CompactionSummary
+	}
+	return c.Strategy
+}
+
+// GetRetainTokens returns the tokens the hybrid strategy keeps of a model
+// with the given context window.
+func (c *Compaction) GetRetainTokens(contextWindow int64) int {
+	if c != nil && c.RetainTokens > 0 {
+		return c.RetainTokens
+	}
+	return int(contextWindow / 4)
+}
+
+// Trash configures how long the contents files had before the edit and
+// write tools replaced them are kept in the data directory.
+type Trash struct {
+	Disabled bool `json:"disabled,omitempty" jsonschema:"description=Don't keep the contents files had before the tools replaced them,default=false"`
+	// RetentionDays defaults to 7.
+	RetentionDays int `json:"retention_days,omitempty" jsonschema:"description=Days the replaced contents are kept before they are deleted,default=7,minimum=1,example=30"`
+}
+
+// Retention returns how long the replaced contents are kept, or 0 when they
+// aren't.
+func (t *Trash) Retention() time.Duration {
+	switch {
+	case t == nil || t.RetentionDays <= 0 && !t.Disabled:
+		return trash.DefaultRetention
+	case t.Disabled:
+		return 0
+	}
+	return time.Duration(t.RetentionDays) * 24 * time.Hour
+}
+
+// Telemetry configures the OTLP export of traces and metrics. Nothing is
+// exported when OTLPEndpoint is empty.
+type Telemetry struct {
+	OTLPEndpoint string            `json:"otlp_endpoint,omitempty" jsonschema:"description=URL of the OTLP/HTTP collector,example=http://localhost:4318"`
+	Headers      map[string]string `json:"headers,omitempty" jsonschema:"description=Headers sent with every export request such as an API key; values support $(command) and $VAR"`
+	ServiceName  string            `json:"service_name,omitempty" jsonschema:"description=service.name of the exported resource,default=crush"`
+}
+
+const (
+	// DefaultRequestTimeout is how long a request waits for a provider to
+	// start responding by default. Some proxies only respond with the first
+	// token, which reasoning models can take minutes to get to.
+	DefaultRequestTimeout = 5 * time.Minute
+	// DefaultStreamIdleTimeout is how long a streamed response can go without
+	// data by default, long enough for the models that stream nothing while
+	// they reason.
+	DefaultStreamIdleTimeout = 10 * time.Minute
+	// DefaultKeepAlive is the default interval of the keep-alive probes of
+	// the connections to a provider.
+	DefaultKeepAlive = 30 * time.Second
+)
+
+// ProviderTimeouts bound how long the requests to a provider wait for it, in
+// seconds, so that stalled connections are dropped and retried instead of
+// hanging the session. 0 uses the default and a negative value disables the
+// timeout.
+type ProviderTimeouts struct {
+	Request    int `json:"request,omitempty" jsonschema:"description=Seconds a request waits for the provider to start responding before it is retried. 0 uses the default and a negative value waits forever,default=300,example=60"`
+	StreamIdle int `json:"stream_idle,omitempty" jsonschema:"description=Seconds a streamed response can go without data before it is considered stalled. 0 uses the default and a negative value waits forever,default=600,example=120"`
+	KeepAlive  int `json:"keep_alive,omitempty" jsonschema:"description=Seconds between the keep-alive probes of the connections to the provider. 0 uses the default and a negative value disables them,default=30,example=15"`
+}
+
+// RequestTimeout returns how long a request waits for the provider to start
+// responding, 0 for no limit.
+func (t *ProviderTimeouts) RequestTimeout() time.Duration {
+	if t == nil {
+		return DefaultRequestTimeout
+	}
+	return timeoutSeconds(t.Request, DefaultRequestTimeout)
+}
+
+// StreamIdleTimeout returns how long a streamed response can go without
+// data, 0 for no limit.
+func (t *ProviderTimeouts) StreamIdleTimeout() time.Duration {
+	if t == nil {
+		return DefaultStreamIdleTimeout
+	}
+	return timeoutSeconds(t.StreamIdle, DefaultStreamIdleTimeout)
+}
+
+// KeepAliveInterval returns the interval of the keep-alive probes, 0 for
+// none.
+func (t *ProviderTimeouts) KeepAliveInterval() time.Duration {
+	if t == nil {
+		return DefaultKeepAlive
+	}
+	return timeoutSeconds(t.KeepAlive, DefaultKeepAlive)
+}
+
+func timeoutSeconds(seconds int, def time.Duration) time.Duration {
+	switch {
+	case seconds == 0:
+		return def
+	case seconds < 0:
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Network configures the proxy and the CA certificates of the HTTP requests
+// to the providers, Catwalk, the web tools and the MCP servers.
+type Network struct {
+	Proxy    string   `json:"proxy,omitempty" jsonschema:"description=URL of the proxy of the HTTP requests. HTTPS_PROXY and HTTP_PROXY are used when empty,example=http://proxy.example.com:3128"`
+	NoProxy  []string `json:"no_proxy,omitempty" jsonschema:"description=Hosts reached without the proxy in the syntax of NO_PROXY which is used when empty,example=.internal.example.com,example=10.0.0.0/8"`
+	CABundle string   `json:"ca_bundle,omitempty" jsonschema:"description=Path of a PEM file of root CA certificates trusted on top of the system ones such as the one of a TLS-intercepting proxy,example=/etc/ssl/certs/corporate-ca.pem"`
+}
+
+// ToolOptions hold the "shell" the bash tool runs commands in, and the
+// limits of the tool calls under the names of the tools.
+type ToolOptions struct {
+	Shell  string
+	Limits map[string]ToolLimits
+}
+
+// ShellType returns the type of the shell of the bash tool, which runs the
+// commands on the remote host in remote mode, or in the devcontainer.
+func (o ToolOptions) ShellType() shell.ShellType {
+	if r := shell.CurrentRemote(); r != nil {
+		if r.Container != "" {
+			return shell.ShellTypeContainer
+		}
+		return shell.ShellTypeSSH
+	}
+	shellType, _ := shell.ParseShellType(o.Shell)
+	return shellType
+}
+
+func (o *ToolOptions) UnmarshalJSON(data []byte) error {
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	*o = ToolOptions{}
+	for name, raw := range entries {
+		if name == "shell" {
+			if err := json.Unmarshal(raw, &o.Shell); err != nil {
+				return fmt.Errorf("invalid tools.shell: %w", err)
+			}
+			if _, err := shell.ParseShellType(o.Shell); err != nil {
+				return fmt.Errorf("invalid tools.shell: %w", err)
+			}
+			continue
+		}
+		var limits ToolLimits
+		if err := json.Unmarshal(raw, &limits); err != nil {
+			return fmt.Errorf("invalid tools.%s: %w", name, err)
+		}
+		if o.Limits == nil {
+			o.Limits = make(map[string]ToolLimits)
+		}
+		o.Limits[name] = limits
+	}
+	return nil
+}
+
+func (o ToolOptions) MarshalJSON() ([]byte, error) {
+	entries := make(map[string]any, len(o.Limits)+1)
+	for name, limits := range o.Limits {
+		entries[name] = limits
+	}
+	if o.Shell != "" {
+		entries["shell"] = o.Shell
+	}
+	return json.Marshal(entries)
+}
+
+// JSONSchema describes the shell entry and the limits under any other name.
+func (ToolOptions) JSONSchema() *jsonschema.Schema {
+	properties := jsonschema.NewProperties()
+	properties.Set("shell", &jsonschema.Schema{
+		Type:        "string",
+		Description: "Shell the bash tool runs commands in: the built-in POSIX interpreter or the PowerShell or cmd.exe of the system",
+		Enum:        []any{"posix", "powershell", "cmd"},
+		Default:     "posix",
+	})
+	limits := (&jsonschema.Reflector{DoNotReference: true}).Reflect(&ToolLimits{})
+	limits.Version, limits.ID = "", ""
+	return &jsonschema.Schema{
+		Type:                 "object",
+		Properties:           properties,
+		AdditionalProperties: limits,
+	}
+}
+
+// ToolLimits bound the calls of a tool. Zero values mean no limit.
+type ToolLimits struct {
+	Timeout        int `json:"timeout,omitempty" jsonschema:"description=Seconds a call can run before it's stopped. 0 means no limit,default=0,example=120"`
+	SoftTimeout    int `json:"soft_timeout,omitempty" jsonschema:"description=Seconds after which a call is flagged as slow and can be extended or cancelled. 0 means no warning,default=0,example=60"`
+	MaxOutputBytes int `json:"max_output_bytes,omitempty" jsonschema:"description=Size in bytes the output of a call is cut to. 0 means no limit,default=0,example=30000"`
+}
+
+type DesktopNotification string
+
+const (
+	DesktopNotificationOSC777 DesktopNotification = "osc777"
+	DesktopNotificationOSC9   DesktopNotification = "osc9"
+)
+
+// Notifications are sent when an agent run completes or a permission prompt
+// appears while the terminal window is unfocused.
+type Notifications struct {
+	Bell    bool                `json:"bell,omitempty" jsonschema:"description=Ring the terminal bell,default=false"`
+	Desktop DesktopNotification `json:"desktop,omitempty" jsonschema:"description=Escape sequence used to show a desktop notification: osc777 (Ghostty/WezTerm/foot) or osc9 (iTerm2/Windows Terminal),enum=osc777,enum=osc9"`
+	// Command gets the notification title and body in the
+	// CRUSH_NOTIFICATION_TITLE and CRUSH_NOTIFICATION_BODY environment
+	// variables.
+	Command string `json:"command,omitempty" jsonschema:"description=Shell command run for each notification; gets CRUSH_NOTIFICATION_TITLE and CRUSH_NOTIFICATION_BODY in its environment,example=notify-send \"$CRUSH_NOTIFICATION_TITLE\" \"$CRUSH_NOTIFICATION_BODY\""`
+}
+
+type PromptCacheTarget string
+
+const (
+	PromptCacheSystem   PromptCacheTarget = "system"
+	PromptCacheTools    PromptCacheTarget = "tools"
+	PromptCacheMessages PromptCacheTarget = "messages"
+)
+
+// PromptCache configures prompt caching. Anthropic and Bedrock only cache
+// what's marked with a breakpoint, up to 4 per request; OpenAI and Gemini
+// cache on their own, and are only given a key or cached content to use.
+type PromptCache struct {
+	Disabled bool `json:"disabled,omitempty" jsonschema:"description=Disable prompt caching,default=false"`
+	// Mark defaults to all the targets.
+	Mark []PromptCacheTarget `json:"mark,omitempty" jsonschema:"description=Parts of the request marked as cache breakpoints: the system prompt, the tools, and the latest messages,enum=system,enum=tools,enum=messages"`
+	// Breakpoints defaults to 4, the most Anthropic allows. The system prompt
+	// and the tools take one each, and the latest messages the rest.
+	Breakpoints int `json:"breakpoints,omitempty" jsonschema:"description=Maximum number of cache breakpoints per request,default=4,minimum=1,maximum=4"`
+	// SessionKey helps OpenAI route the requests of a session to the same
+	// cache.
+	SessionKey bool `json:"session_key,omitempty" jsonschema:"description=Send the session ID as the OpenAI prompt cache key,default=false"`
+	// CachedContent has to be created with the Gemini API beforehand.
+	CachedContent string `json:"cached_content,omitempty" jsonschema:"description=Gemini cached content to serve requests from,example=cachedContents/abc123"`
+}
+
+// CacheBreakpoints returns whether the system prompt and the tools are
+// marked as cache breakpoints, and how many of the latest messages are. The
+// breakpoints go to them in that order until there are none left.
+func (p *PromptCache) CacheBreakpoints() (system, tools bool, messages int) {
+	if p != nil && p.Disabled {
+		return false, false, 0
+	}
+	left := 4
+	if p != nil && p.Breakpoints > 0 {
+		left = min(p.Breakpoints, 4)
+	}
+	marks := func(target PromptCacheTarget) bool {
+		return p == nil || len(p.Mark) == 0 || slices.Contains(p.Mark, target)
+	}
+	if marks(PromptCacheSystem) && left > 0 {
+		system = true
+		left--
+	}
+	if marks(PromptCacheTools) && left > 0 {
+		tools = true
+		left--
+	}
+	if marks(PromptCacheMessages) {
+		messages = left
+	}
+	return system, tools, messages
+}
+
+type StorageDriver string
+
+const (
+	StorageDriverSQLite   StorageDriver = "sqlite"
+	StorageDriverPostgres StorageDriver = "postgres"
+)
+
+type Storage struct {
+	Driver StorageDriver `json:"driver,omitempty" jsonschema:"description=Storage backend for sessions and messages,enum=sqlite,enum=postgres,default=sqlite"`
+	// DSN supports the same variable and command expansion as API keys.
+	DSN     string `json:"dsn,omitempty" jsonschema:"description=Connection string for the postgres driver,example=postgres://crush@localhost:5432/crush?search_path=my_project,example=$CRUSH_DATABASE_URL"`
+	Encrypt bool   `json:"encrypt,omitempty" jsonschema:"description=Encrypt message content at rest,default=false"`
+	// Passphrase supports the same variable and command expansion as API
+	// keys. When empty, a random key kept in the OS keychain is used.
+	Passphrase string `json:"passphrase,omitempty" jsonschema:"description=Passphrase the encryption key is derived from; defaults to a key stored in the OS keychain,example=$CRUSH_STORAGE_PASSPHRASE"`
 }
 
 type MCPs map[string]MCPConfig
@@ -271,21 +864,43 @@ func (l LSPConfig) ResolvedEnv() []string {
 	return resolveEnvs(l.Env)
 }
 
+// AutoDiagnosticsEnabled reports whether the diagnostics of the server are
+// attached to the results of the tools that change files.
+func (l LSPConfig) AutoDiagnosticsEnabled() bool {
+	return l.AutoDiagnostics == nil || *l.AutoDiagnostics
+}
+
 func (m MCPConfig) ResolvedEnv() []string {
 	return resolveEnvs(m.Env)
 }
 
-func (m MCPConfig) ResolvedHeaders() map[string]string {
+// ResolvedHeaders returns the headers sent to the server, with their
+// variables, commands and secret references resolved, see [resolveHeader].
+func (m MCPConfig) ResolvedHeaders() (map[string]string, error) {
 	resolver := NewShellVariableResolver(env.New())
-	for e, v := range m.Headers {
-		var err error
-		m.Headers[e], err = resolver.ResolveValue(v)
+	headers := make(map[string]string, len(m.Headers))
+	for h, v := range m.Headers {
+		resolved, err := resolveHeader(resolver, v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve the %s header: %w", h, err)
+		}
+		headers[h] = resolved
+	}
+	return headers, nil
+}
+
+func (t Telemetry) ResolvedHeaders() map[string]string {
+	resolver := NewShellVariableResolver(env.New())
+	headers := make(map[string]string, len(t.Headers))
+	for h, v := range t.Headers {
+		resolved, err := resolver.ResolveValue(v)
 		if err != nil {
-			slog.Error("error resolving header variable", "error", err, "variable", e, "value", v)
+			slog.Error("error resolving header variable", "error", err, "header", h, "value", v)
 			continue
 		}
+		headers[h] = resolved
 	}
-	return m.Headers
+	return headers
 }
 
 type Agent struct {
@@ -309,10 +924,49 @@ type Agent struct {
 
 	// Overrides the context paths for this agent
 	ContextPaths []string `json:"context_paths,omitempty"`
+
+	// Replaces and extends the built-in system prompt, see [AgentOverride].
+	SystemPrompt       string `json:"system_prompt,omitempty"`
+	SystemPromptAppend string `json:"system_prompt_append,omitempty"`
+
+	// ToolLimits replace the limits of options.tools for the tool calls of
+	// the agent, see [AgentOverride].
+	ToolLimits map[string]ToolLimits `json:"tool_limits,omitempty"`
+}
+
+// ToolLimitsFor returns the limits of the tool calls of the agent: those of
+// options.tools with the ones of the agent on top.
+func (a Agent) ToolLimitsFor(options ToolOptions) map[string]ToolLimits {
+	if len(a.ToolLimits) == 0 {
+		return options.Limits
+	}
+	limits := maps.Clone(options.Limits)
+	if limits == nil {
+		limits = make(map[string]ToolLimits, len(a.ToolLimits))
+	}
+	maps.Copy(limits, a.ToolLimits)
+	return limits
+}
+
+// AgentOverride customizes the system prompt and the tool limits of a
+// built-in agent. Both prompt fields take either the text itself or the path
+// to a file containing it.
+type AgentOverride struct {
+	SystemPrompt       string `json:"system_prompt,omitempty" jsonschema:"description=Replaces the built-in system prompt; either the prompt itself or a path to a file containing it. Supports template variables like {{.WorkingDir}} {{.Platform}} and {{.Date}},example=~/.config/crush/coder.md"`
+	SystemPromptAppend string `json:"system_prompt_append,omitempty" jsonschema:"description=Appended to the system prompt; either the text itself or a path to a file containing it. Supports the same template variables,example=Always answer in British English."`
+	// Tools limits the tool calls of the agent like options.tools does,
+	// replacing the entries of options.tools with the same name.
+	Tools map[string]ToolLimits `json:"tools,omitempty" jsonschema:"description=Limits of the tool calls of the agent by tool name or * replacing the entries of options.tools with the same name"`
 }
 
 type Tools struct {
-	Ls ToolLs `json:"ls,omitzero"`
+	Ls           ToolLs           `json:"ls,omitzero"`
+	View         ToolView         `json:"view,omitzero"`
+	Download     ToolDownload     `json:"download,omitzero"`
+	Fetch        ToolFetch        `json:"fetch,omitzero"`
+	Sourcegraph  ToolSourcegraph  `json:"sourcegraph,omitzero"`
+	AgenticFetch ToolAgenticFetch `json:"agentic_fetch,omitzero"`
+	RunTests     ToolRunTests     `json:"run_tests,omitzero"`
 }
 
 type ToolLs struct {
@@ -324,6 +978,103 @@ func (t ToolLs) Limits() (depth, items int) {
 	return ptrValOr(t.MaxDepth, 0), ptrValOr(t.MaxItems, 0)
 }
 
+type ToolView struct {
+	OutlineLines *int `json:"outline_lines,omitempty" jsonschema:"description=Files with more lines are shown as an outline unless the view tool asks for a range of lines. 0 always shows the whole file,default=1000,example=500"`
+}
+
+// OutlineThreshold returns the number of lines above which the view tool
+// shows an outline instead of the whole file, or 0 to always show the file.
+func (t ToolView) OutlineThreshold() int {
+	return ptrValOr(t.OutlineLines, 1000)
+}
+
+type ToolDownload struct {
+	MaxSize *int64 `json:"max_size,omitempty" jsonschema:"description=Size in bytes above which the download tool stops a download and deletes what it got. 0 means no limit,default=104857600,example=1073741824"`
+}
+
+// MaxSizeBytes returns the size in bytes above which downloads are stopped,
+// or 0 for no limit.
+func (t ToolDownload) MaxSizeBytes() int64 {
+	return ptrValOr(t.MaxSize, 100*1024*1024)
+}
+
+type ToolRunTests struct {
+	Coverage map[string]bool `json:"coverage,omitempty" jsonschema:"description=Turns reporting the lines the tests didn't run on or off by framework. go and jest report them by default. pytest needs the pytest-cov plugin so it is off unless turned on. cargo doesn't support it,example={\"go\":false,\"pytest\":true}"`
+}
+
+// CoverageEnabled reports whether the run_tests tool reports the coverage of
+// the tests of framework.
+func (t ToolRunTests) CoverageEnabled(framework string) bool {
+	if enabled, ok := t.Coverage[framework]; ok {
+		return enabled
+	}
+	return framework == "go" || framework == "jest"
+}
+
+type ToolFetch struct {
+	Timeout       *int  `json:"timeout,omitempty" jsonschema:"description=Seconds the fetch tool waits for a page when the call doesn't say. At most 120,default=30,example=60"`
+	RespectRobots *bool `json:"respect_robots,omitempty" jsonschema:"description=Refuse to fetch the pages the robots.txt file of their site disallows,default=true"`
+	DisableCache  bool  `json:"disable_cache,omitempty" jsonschema:"description=Don't keep fetched pages on disk to skip downloading them again when they didn't change,default=false"`
+}
+
+// TimeoutSeconds returns how long the fetch tool waits for a page when the
+// call doesn't say.
+func (t ToolFetch) TimeoutSeconds() int {
+	return ptrValOr(t.Timeout, 30)
+}
+
+// RespectsRobots tells whether the fetch tool follows robots.txt files.
+func (t ToolFetch) RespectsRobots() bool {
+	return ptrValOr(t.RespectRobots, true)
+}
+
+type ToolSourcegraph struct {
+	URL   string `json:"url,omitempty" jsonschema:"description=URL of the Sourcegraph instance the sourcegraph tool searches,default=https://sourcegraph.com,example=https://sourcegraph.example.com"`
+	Token string `json:"token,omitempty" jsonschema:"description=Access token for the Sourcegraph instance. Supports $VAR and $(command),example=$SRC_ACCESS_TOKEN"`
+}
+
+// Endpoint returns the URL of the Sourcegraph instance to search.
+func (t ToolSourcegraph) Endpoint() string {
+	return strings.TrimSuffix(cmp.Or(t.URL, "https://sourcegraph.com"), "/")
+}
+
+// ResolvedToken returns the access token for the Sourcegraph instance, with
+// its variables and commands resolved.
+func (t ToolSourcegraph) ResolvedToken() string {
+	if t.Token == "" {
+		return ""
+	}
+	token, err := NewShellVariableResolver(env.New()).ResolveValue(t.Token)
+	if err != nil {
+		slog.Error("error resolving sourcegraph token", "error", err)
+		return ""
+	}
+	return token
+}
+
+type ToolAgenticFetch struct {
+	AllowedDomains []string `json:"allowed_domains,omitempty" jsonschema:"description=Domains the agentic_fetch tool may visit along with their subdomains. Any domain when empty,example=go.dev"`
+	MaxSteps       int      `json:"max_steps,omitempty" jsonschema:"description=Steps the agentic_fetch sub-agent may take per call before it's stopped. No limit when 0,default=0,example=10"`
+	MaxTokens      int64    `json:"max_tokens,omitempty" jsonschema:"description=Tokens the agentic_fetch sub-agent may use per call before it's stopped. No limit when 0,default=0,example=100000"`
+}
+
+// AllowsHost tells whether the agentic_fetch tool may visit host: any host
+// when no domains are allowed, or else the allowed domains and their
+// subdomains.
+func (t ToolAgenticFetch) AllowsHost(host string) bool {
+	if len(t.AllowedDomains) == 0 {
+		return true
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, domain := range t.AllowedDomains {
+		domain = strings.ToLower(strings.Trim(strings.TrimPrefix(domain, "*."), "."))
+		if domain != "" && (host == domain || strings.HasSuffix(host, "."+domain)) {
+			return true
+		}
+	}
+	return false
+}
+
 // Config holds the configuration for crush.
 type Config struct {
 	Schema string `json:"$schema,omitempty"`
@@ -332,6 +1083,8 @@ type Config struct {
 	Models map[SelectedModelType]SelectedModel `json:"models,omitempty" jsonschema:"description=Model configurations for different model types,example={\"large\":{\"model\":\"gpt-4o\",\"provider\":\"openai\"}}"`
 	// Recently used models stored in the data directory config.
 	RecentModels map[SelectedModelType][]SelectedModel `json:"recent_models,omitempty" jsonschema:"description=Recently used models sorted by most recent first"`
+	// Models starred in the models dialog, stored in the data directory config.
+	FavoriteModels []SelectedModel `json:"favorite_models,omitempty" jsonschema:"description=Models starred in the models dialog"`
 
 	// The providers that are configured
 	Providers *csync.Map[string, ProviderConfig] `json:"providers,omitempty" jsonschema:"description=AI provider configurations"`
@@ -346,20 +1099,69 @@ type Config struct {
 
 	Tools Tools `json:"tools,omitzero" jsonschema:"description=Tool configurations"`
 
+	Hooks Hooks `json:"hooks,omitzero" jsonschema:"description=Shell commands run on lifecycle events"`
+
+	Project Project `json:"project,omitzero" jsonschema:"description=What the agent is told about the project"`
+
+	AgentOverrides map[string]AgentOverride `json:"agents,omitempty" jsonschema:"description=System prompt and tool limit overrides for the built-in agents keyed by agent ID (coder or task)"`
+
 	Agents map[string]Agent `json:"-"`
 
 	// Internal
 	workingDir string `json:"-"`
+	// scope is the directory inside workingDir new sessions are restricted
+	// to, from the --scope flag.
+	scope string
+	// untrusted leaves out the settings of the project that run commands,
+	// see [Config.AllowProjectCommands].
+	untrusted bool
 	// TODO: find a better way to do this this should probably not be part of the config
 	resolver       VariableResolver
 	dataConfigDir  string             `json:"-"`
 	knownProviders []catwalk.Provider `json:"-"`
+	// warnings are the problems found while loading, like skipped
+	// providers.
+	warnings []string
+}
+
+// Warnings returns the problems found while loading the configuration that
+// didn't stop it from loading, like providers that were skipped.
+func (c *Config) Warnings() []string {
+	return c.warnings
+}
+
+// skipProvider removes a provider that can't be used, logging and recording
+// why.
+func (c *Config) skipProvider(id, reason string, args ...any) {
+	slog.Warn("Skipping provider "+reason, append([]any{"provider", id}, args...)...)
+	c.warnings = append(c.warnings, fmt.Sprintf("provider %q is skipped %s", id, reason))
+	c.Providers.Del(id)
 }
 
 func (c *Config) WorkingDir() string {
 	return c.workingDir
 }
 
+// Scope returns the directory new sessions are restricted to, or the
+// working directory when they aren't.
+func (c *Config) Scope() string {
+	if c.scope == "" {
+		return c.workingDir
+	}
+	return c.scope
+}
+
+// SetScope restricts new sessions to dir, a directory inside the working
+// directory, relative to it or absolute.
+func (c *Config) SetScope(dir string) error {
+	scope, err := fsext.ResolveScope(c.workingDir, dir)
+	if err != nil {
+		return err
+	}
+	c.scope = scope
+	return nil
+}
+
 func (c *Config) EnabledProviders() []ProviderConfig {
 	var enabled []ProviderConfig
 	for p := range c.Providers.Seq() {
@@ -429,6 +1231,22 @@ func (c *Config) SetCompactMode(enabled bool) error {
 	return c.SetConfigField("options.tui.compact_mode", enabled)
 }
 
+func (c *Config) SetFileView(enabled bool) error {
+	if c.Options == nil {
+		c.Options = &Options{}
+	}
+	c.Options.TUI.FileView = enabled
+	return c.SetConfigField("options.tui.file_view", enabled)
+}
+
+func (c *Config) SetFileTree(enabled bool) error {
+	if c.Options == nil {
+		c.Options = &Options{}
+	}
+	c.Options.TUI.FileTree = enabled
+	return c.SetConfigField("options.tui.file_tree", enabled)
+}
+
 func (c *Config) Resolve(key string) (string, error) {
 	if c.resolver == nil {
 		return "", fmt.Errorf("no variable resolver configured")
@@ -447,6 +1265,19 @@ func (c *Config) UpdatePreferredModel(modelType SelectedModelType, model Selecte
 	return nil
 }
 
+// SwapPreferredModels makes the large model the small one and the other way
+// around.
+func (c *Config) SwapPreferredModels() error {
+	large, small := c.Models[SelectedModelTypeLarge], c.Models[SelectedModelTypeSmall]
+	if large.Model == "" || small.Model == "" {
+		return fmt.Errorf("both a large and a small model must be selected to swap them")
+	}
+	if err := c.UpdatePreferredModel(SelectedModelTypeLarge, small); err != nil {
+		return err
+	}
+	return c.UpdatePreferredModel(SelectedModelTypeSmall, large)
+}
+
 func (c *Config) SetConfigField(key string, value any) error {
 	// read the data
 	data, err := os.ReadFile(c.dataConfigDir)
@@ -465,6 +1296,27 @@ func (c *Config) SetConfigField(key string, value any) error {
 	if err := os.WriteFile(c.dataConfigDir, []byte(newValue), 0o600); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
+	ownWrites.Set(c.dataConfigDir, newValue)
+	return nil
+}
+
+func (c *Config) RemoveConfigField(key string) error {
+	data, err := os.ReadFile(c.dataConfigDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	newValue, err := sjson.Delete(string(data), key)
+	if err != nil {
+		return fmt.Errorf("failed to remove config field %s: %w", key, err)
+	}
+	if err := os.WriteFile(c.dataConfigDir, []byte(newValue), 0o600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	ownWrites.Set(c.dataConfigDir, newValue)
 	return nil
 }
 
@@ -489,7 +1341,7 @@ func (c *Config) SetProviderAPIKey(providerID string, apiKey any) error {
 		setKeyOrToken = func() {
 			providerConfig.APIKey = v.AccessToken
 			providerConfig.OAuthToken = v
-			providerConfig.SetupClaudeCode()
+			providerConfig.SetupOAuth()
 		}
 	}
 
@@ -572,28 +1424,70 @@ func (c *Config) recordRecentModel(modelType SelectedModelType, model SelectedMo
 	return nil
 }
 
+// IsFavoriteModel reports whether the model of the given provider is starred.
+func (c *Config) IsFavoriteModel(provider, model string) bool {
+	return slices.ContainsFunc(c.FavoriteModels, func(m SelectedModel) bool {
+		return m.Provider == provider && m.Model == model
+	})
+}
+
+// ToggleFavoriteModel stars the model of the given provider, or unstars it if
+// it already is, and reports whether it is starred afterwards.
+func (c *Config) ToggleFavoriteModel(provider, model string) (bool, error) {
+	if provider == "" || model == "" {
+		return false, nil
+	}
+
+	starred := !c.IsFavoriteModel(provider, model)
+	updated := slices.DeleteFunc(slices.Clone(c.FavoriteModels), func(m SelectedModel) bool {
+		return m.Provider == provider && m.Model == model
+	})
+	if starred {
+		updated = append(updated, SelectedModel{Provider: provider, Model: model})
+	}
+
+	if err := c.SetConfigField("favorite_models", updated); err != nil {
+		return !starred, fmt.Errorf("failed to persist favorite models: %w", err)
+	}
+	c.FavoriteModels = updated
+	return starred, nil
+}
+
 func allToolNames() []string {
 	return []string{
 		"agent",
 		"bash",
 		"job_output",
 		"job_kill",
+		"run_tests",
+		"project_build",
+		"project_lint",
+		"project_test",
+		"project_format",
 		"download",
 		"edit",
 		"multiedit",
+		"apply_patch",
 		"lsp_diagnostics",
 		"lsp_references",
 		"fetch",
 		"agentic_fetch",
 		"glob",
 		"grep",
+		"git_log",
+		"git_blame",
 		"ls",
 		"sourcegraph",
 		"view",
 		"write",
+		"todo",
 	}
 }
 
+// offlineDisabledTools are the tools that reach the network, which the
+// agents don't get in offline mode.
+var offlineDisabledTools = []string{"download", "fetch", "agentic_fetch", "sourcegraph"}
+
 func resolveAllowedTools(allTools []string, disabledTools []string) []string {
 	if disabledTools == nil {
 		return allTools
@@ -603,7 +1497,7 @@ func resolveAllowedTools(allTools []string, disabledTools []string) []string {
 }
 
 func resolveReadOnlyTools(tools []string) []string {
-	readOnlyTools := []string{"glob", "grep", "ls", "sourcegraph", "view"}
+	readOnlyTools := []string{"glob", "grep", "git_log", "git_blame", "ls", "sourcegraph", "view"}
 	// filter to only include tools that are in allowedtools (include mode)
 	return filterSlice(tools, readOnlyTools, true)
 }
@@ -621,7 +1515,11 @@ func filterSlice(data []string, mask []string, include bool) []string {
 }
 
 func (c *Config) SetupAgents() {
-	allowedTools := resolveAllowedTools(allToolNames(), c.Options.DisabledTools)
+	disabledTools := c.Options.DisabledTools
+	if c.Options.Offline {
+		disabledTools = append(slices.Clone(disabledTools), offlineDisabledTools...)
+	}
+	allowedTools := resolveAllowedTools(allToolNames(), disabledTools)
 
 	agents := map[string]Agent{
 		AgentCoder: {
@@ -644,6 +1542,14 @@ func (c *Config) SetupAgents() {
 			AllowedMCP: map[string][]string{},
 		},
 	}
+	for id, override := range c.AgentOverrides {
+		if agent, ok := agents[id]; ok {
+			agent.SystemPrompt = override.SystemPrompt
+			agent.SystemPromptAppend = override.SystemPromptAppend
+			agent.ToolLimits = override.Tools
+			agents[id] = agent
+		}
+	}
 	c.Agents = agents
 }
 
@@ -656,7 +1562,7 @@ func (c *ProviderConfig) TestConnection(resolver VariableResolver) error {
 	headers := make(map[string]string)
 	apiKey, _ := resolver.ResolveValue(c.APIKey)
 	switch c.Type {
-	case catwalk.TypeOpenAI, catwalk.TypeOpenAICompat, catwalk.TypeOpenRouter:
+	case catwalk.TypeOpenAI, catwalk.TypeOpenAICompat, catwalk.TypeOpenRouter, copilot.Name:
 		baseURL, _ := resolver.ResolveValue(c.BaseURL)
 		if baseURL == "" {
 			baseURL = "https://api.openai.com/v1"
@@ -688,7 +1594,7 @@ func (c *ProviderConfig) TestConnection(resolver VariableResolver) error {
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	client := &http.Client{}
+	client := httpext.NewClient(0)
 	req, err := http.NewRequestWithContext(ctx, "GET", testURL, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request for provider %s: %w", c.ID, err)
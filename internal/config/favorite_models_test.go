@@ -0,0 +1,67 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestToggleFavoriteModel(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cfg := &Config{}
+	cfg.setDefaults(dir, "")
+	cfg.dataConfigDir = filepath.Join(dir, "config.json")
+
+	starred, err := cfg.ToggleFavoriteModel("openai", "gpt-4o")
+	require.NoError(t, err)
+	require.True(t, starred)
+	starred, err = cfg.ToggleFavoriteModel("anthropic", "claude")
+	require.NoError(t, err)
+	require.True(t, starred)
+	require.True(t, cfg.IsFavoriteModel("openai", "gpt-4o"))
+	require.False(t, cfg.IsFavoriteModel("openai", "claude"))
+
+	out := readConfigJSON(t, cfg.dataConfigDir)
+	favorites, ok := out["favorite_models"].([]any)
+	require.True(t, ok)
+	require.Len(t, favorites, 2)
+
+	starred, err = cfg.ToggleFavoriteModel("openai", "gpt-4o")
+	require.NoError(t, err)
+	require.False(t, starred)
+	require.Equal(t, []SelectedModel{{Provider: "anthropic", Model: "claude"}}, cfg.FavoriteModels)
+
+	out = readConfigJSON(t, cfg.dataConfigDir)
+	favorites, ok = out["favorite_models"].([]any)
+	require.True(t, ok)
+	require.Len(t, favorites, 1)
+}
+
+func TestSwapPreferredModels(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cfg := &Config{}
+	cfg.setDefaults(dir, "")
+	cfg.dataConfigDir = filepath.Join(dir, "config.json")
+
+	require.Error(t, cfg.SwapPreferredModels())
+
+	large := SelectedModel{Provider: "openai", Model: "gpt-4o", MaxTokens: 4096}
+	small := SelectedModel{Provider: "anthropic", Model: "claude"}
+	require.NoError(t, cfg.UpdatePreferredModel(SelectedModelTypeLarge, large))
+	require.NoError(t, cfg.UpdatePreferredModel(SelectedModelTypeSmall, small))
+
+	require.NoError(t, cfg.SwapPreferredModels())
+	require.Equal(t, small, cfg.Models[SelectedModelTypeLarge])
+	require.Equal(t, large, cfg.Models[SelectedModelTypeSmall])
+
+	models, ok := readConfigJSON(t, cfg.dataConfigDir)["models"].(map[string]any)
+	require.True(t, ok)
+	persisted, ok := models[string(SelectedModelTypeLarge)].(map[string]any)
+	require.True(t, ok)
+	require.Equal(t, "claude", persisted["model"])
+}
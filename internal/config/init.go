@@ -28,6 +28,7 @@ func Init(workingDir, dataDir string, debug bool) (*Config, error) {
 		return nil, err
 	}
 	instance.Store(cfg)
+	fsext.SetIgnorePatterns(cfg.Options.IgnorePatterns)
 	return instance.Load(), nil
 }
 
@@ -103,7 +104,7 @@ func contextPathsExist(dir string) (bool, error) {
 
 // dirHasNoVisibleFiles returns true if the directory has no files/dirs after applying ignore rules
 func dirHasNoVisibleFiles(dir string) (bool, error) {
-	files, _, err := fsext.ListDirectory(dir, nil, 1, 1)
+	files, _, err := fsext.ListDirectory(dir, nil, 1, 1, false)
 	if err != nil {
 		return false, err
 	}
@@ -22,8 +22,10 @@ import (
 	"github.com/charmbracelet/crush/internal/event"
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/httpext"
 	"github.com/charmbracelet/crush/internal/log"
-	"github.com/charmbracelet/crush/internal/oauth/claude"
+	"github.com/charmbracelet/crush/internal/oauth"
+	"github.com/charmbracelet/crush/internal/oauth/copilot"
 	powernapConfig "github.com/charmbracelet/x/powernap/pkg/config"
 )
 
@@ -57,6 +59,17 @@ func Load(workingDir, dataDir string, debug bool) (*Config, error) {
 
 	cfg.setDefaults(workingDir, dataDir)
 
+	// Before anything reaches the network, Catwalk included. Only the
+	// network options of the user apply.
+	network, err := globalNetwork()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load network options: %w", err)
+	}
+	cfg.Options.Network = network
+	if err := httpext.Configure(network.httpOptions()); err != nil {
+		return nil, fmt.Errorf("invalid network options: %w", err)
+	}
+
 	if debug {
 		cfg.Options.Debug = true
 	}
@@ -94,6 +107,7 @@ func Load(workingDir, dataDir string, debug bool) (*Config, error) {
 
 	if !cfg.IsConfigured() {
 		slog.Warn("No providers configured")
+		cfg.warnings = append(cfg.warnings, "no providers are configured")
 		return cfg, nil
 	}
 
@@ -185,41 +199,31 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 			maps.Copy(headers, config.ExtraHeaders)
 		}
 		prepared := ProviderConfig{
-			ID:                 string(p.ID),
-			Name:               p.Name,
-			BaseURL:            p.APIEndpoint,
-			APIKey:             p.APIKey,
-			OAuthToken:         config.OAuthToken,
-			Type:               p.Type,
-			Disable:            config.Disable,
-			SystemPromptPrefix: config.SystemPromptPrefix,
-			ExtraHeaders:       headers,
-			ExtraBody:          config.ExtraBody,
-			ExtraParams:        make(map[string]string),
-			Models:             p.Models,
-		}
-
-		if p.ID == catwalk.InferenceProviderAnthropic && config.OAuthToken != nil {
-			if config.OAuthToken.IsExpired() {
-				newToken, err := claude.RefreshToken(context.TODO(), config.OAuthToken.RefreshToken)
-				if err == nil {
-					slog.Info("Successfully refreshed Anthropic OAuth token")
-					config.OAuthToken = newToken
-					prepared.OAuthToken = newToken
-					if err := cmp.Or(
-						c.SetConfigField("providers.anthropic.api_key", newToken.AccessToken),
-						c.SetConfigField("providers.anthropic.oauth", newToken),
-					); err != nil {
-						return err
-					}
-				} else {
-					slog.Error("Failed to refresh Anthropic OAuth token", "error", err)
-					event.Error(err)
+			ID:                    string(p.ID),
+			Name:                  p.Name,
+			BaseURL:               p.APIEndpoint,
+			APIKey:                p.APIKey,
+			OAuthToken:            config.OAuthToken,
+			Type:                  p.Type,
+			Disable:               config.Disable,
+			MaxConcurrentRequests: config.MaxConcurrentRequests,
+			Timeouts:              config.Timeouts,
+			SystemPromptPrefix:    config.SystemPromptPrefix,
+			ExtraHeaders:          headers,
+			ExtraBody:             config.ExtraBody,
+			ExtraParams:           make(map[string]string),
+			Models:                p.Models,
+		}
+
+		if config.OAuthToken != nil {
+			if refresh := prepared.OAuthRefresher(); refresh != nil {
+				token, err := c.refreshOAuthToken(string(p.ID), config.OAuthToken, refresh)
+				if err != nil {
+					return err
 				}
-			} else {
-				slog.Info("Using existing non-expired Anthropic OAuth token")
+				prepared.OAuthToken = token
+				prepared.SetupOAuth()
 			}
-			prepared.SetupClaudeCode()
 		}
 
 		switch p.ID {
@@ -227,8 +231,7 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 		case catwalk.InferenceProviderVertexAI:
 			if !hasVertexCredentials(env) {
 				if configExists {
-					slog.Warn("Skipping Vertex AI provider due to missing credentials")
-					c.Providers.Del(string(p.ID))
+					c.skipProvider(string(p.ID), "due to missing Vertex AI credentials")
 				}
 				continue
 			}
@@ -238,8 +241,7 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 			endpoint, err := resolver.ResolveValue(p.APIEndpoint)
 			if err != nil || endpoint == "" {
 				if configExists {
-					slog.Warn("Skipping Azure provider due to missing API endpoint", "provider", p.ID, "error", err)
-					c.Providers.Del(string(p.ID))
+					c.skipProvider(string(p.ID), "due to missing API endpoint", "error", err)
 				}
 				continue
 			}
@@ -248,8 +250,7 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 		case catwalk.InferenceProviderBedrock:
 			if !hasAWSCredentials(env) {
 				if configExists {
-					slog.Warn("Skipping Bedrock provider due to missing AWS credentials")
-					c.Providers.Del(string(p.ID))
+					c.skipProvider(string(p.ID), "due to missing AWS credentials")
 				}
 				continue
 			}
@@ -267,8 +268,7 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 			v, err := resolver.ResolveValue(p.APIKey)
 			if v == "" || err != nil {
 				if configExists {
-					slog.Warn("Skipping provider due to missing API key", "provider", p.ID)
-					c.Providers.Del(string(p.ID))
+					c.skipProvider(string(p.ID), "due to missing API key")
 				}
 				continue
 			}
@@ -291,9 +291,19 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 		if providerConfig.Type == "" {
 			providerConfig.Type = catwalk.TypeOpenAICompat
 		}
-		if !slices.Contains(catwalk.KnownProviderTypes(), providerConfig.Type) {
-			slog.Warn("Skipping custom provider due to unsupported provider type", "provider", id)
-			c.Providers.Del(id)
+		if providerConfig.Type == copilot.Name {
+			if providerConfig.OAuthToken == nil {
+				c.skipProvider(id, "because it isn't logged in")
+				continue
+			}
+			token, err := c.refreshOAuthToken(id, providerConfig.OAuthToken, providerConfig.OAuthRefresher())
+			if err != nil {
+				return err
+			}
+			providerConfig.OAuthToken = token
+			providerConfig.SetupOAuth()
+		} else if !slices.Contains(catwalk.KnownProviderTypes(), providerConfig.Type) {
+			c.skipProvider(id, "due to unsupported provider type", "type", providerConfig.Type)
 			continue
 		}
 
@@ -306,13 +316,7 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 			slog.Warn("Provider is missing API key, this might be OK for local providers", "provider", id)
 		}
 		if providerConfig.BaseURL == "" {
-			slog.Warn("Skipping custom provider due to missing API endpoint", "provider", id)
-			c.Providers.Del(id)
-			continue
-		}
-		if len(providerConfig.Models) == 0 {
-			slog.Warn("Skipping custom provider because the provider has no models", "provider", id)
-			c.Providers.Del(id)
+			c.skipProvider(id, "due to missing API endpoint")
 			continue
 		}
 		apiKey, err := resolver.ResolveValue(providerConfig.APIKey)
@@ -321,8 +325,18 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 		}
 		baseURL, err := resolver.ResolveValue(providerConfig.BaseURL)
 		if baseURL == "" || err != nil {
-			slog.Warn("Skipping custom provider due to missing API endpoint", "provider", id, "error", err)
-			c.Providers.Del(id)
+			c.skipProvider(id, "due to missing API endpoint", "error", err)
+			continue
+		}
+		if providerConfig.Type == catwalk.TypeOpenAICompat && c.dataConfigDir != "" {
+			probed, err := c.modelProbeCache().models(baseURL, apiKey, providerConfig.ExtraHeaders)
+			if err != nil {
+				slog.Warn("Failed to probe provider models", "provider", id, "error", err)
+			}
+			providerConfig.Models = mergeProbedModels(providerConfig.Models, probed)
+		}
+		if len(providerConfig.Models) == 0 {
+			c.skipProvider(id, "because the provider has no models")
 			continue
 		}
 
@@ -331,6 +345,31 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 	return nil
 }
 
+// refreshOAuthToken refreshes token if it expired and saves the new one to
+// the config file. If refreshing fails the old token is returned so the
+// request fails with the provider's own error.
+func (c *Config) refreshOAuthToken(providerID string, token *oauth.Token, refresh oauth.RefreshFunc) (*oauth.Token, error) {
+	if !token.IsExpired() {
+		slog.Info("Using existing non-expired OAuth token", "provider", providerID)
+		return token, nil
+	}
+	newToken, err := refresh(context.TODO(), token.RefreshToken)
+	if err != nil {
+		slog.Error("Failed to refresh OAuth token", "provider", providerID, "error", err)
+		event.Error(err)
+		return token, nil
+	}
+	slog.Info("Successfully refreshed OAuth token", "provider", providerID)
+	newToken.AccountID = cmp.Or(newToken.AccountID, token.AccountID)
+	if err := cmp.Or(
+		c.SetConfigField(fmt.Sprintf("providers.%s.api_key", providerID), newToken.AccessToken),
+		c.SetConfigField(fmt.Sprintf("providers.%s.oauth", providerID), newToken),
+	); err != nil {
+		return nil, err
+	}
+	return newToken, nil
+}
+
 func (c *Config) setDefaults(workingDir, dataDir string) {
 	c.workingDir = workingDir
 	if c.Options == nil {
@@ -342,6 +381,12 @@ func (c *Config) setDefaults(workingDir, dataDir string) {
 	if c.Options.ContextPaths == nil {
 		c.Options.ContextPaths = []string{}
 	}
+	if c.Options.Storage == nil {
+		c.Options.Storage = &Storage{}
+	}
+	if c.Options.Storage.Driver == "" {
+		c.Options.Storage.Driver = StorageDriverSQLite
+	}
 	if dataDir != "" {
 		c.Options.DataDirectory = dataDir
 	} else if c.Options.DataDirectory == "" {
@@ -379,6 +424,15 @@ func (c *Config) setDefaults(workingDir, dataDir string) {
 		c.Options.DisableProviderAutoUpdate, _ = strconv.ParseBool(str)
 	}
 
+	if str, ok := os.LookupEnv("CRUSH_OFFLINE"); ok {
+		c.Options.Offline, _ = strconv.ParseBool(str)
+	}
+	if c.Options.Offline {
+		// The providers come from the cache or the embedded list instead.
+		c.Options.DisableProviderAutoUpdate = true
+		c.Options.DisableMetrics = true
+	}
+
 	if c.Options.Attribution == nil {
 		c.Options.Attribution = &Attribution{
 			TrailerStyle:  TrailerStyleAssistedBy,
@@ -529,6 +583,7 @@ func (c *Config) configureSelectedModels(knownProviders []catwalk.Provider) erro
 		}
 		model := c.GetModel(large.Provider, large.Model)
 		if model == nil {
+			c.warnings = append(c.warnings, fmt.Sprintf("large model %q of provider %q isn't available, using %q instead", large.Model, large.Provider, defaultLarge.Model))
 			large = defaultLarge
 			// override the model type to large
 			err := c.UpdatePreferredModel(SelectedModelTypeLarge, large)
@@ -573,6 +628,7 @@ func (c *Config) configureSelectedModels(knownProviders []catwalk.Provider) erro
 
 		model := c.GetModel(small.Provider, small.Model)
 		if model == nil {
+			c.warnings = append(c.warnings, fmt.Sprintf("small model %q of provider %q isn't available, using %q instead", small.Model, small.Provider, defaultSmall.Model))
 			small = defaultSmall
 			// override the model type to small
 			err := c.UpdatePreferredModel(SelectedModelTypeSmall, small)
@@ -614,10 +670,7 @@ func (c *Config) configureSelectedModels(knownProviders []catwalk.Provider) erro
 // lookupConfigs searches config files recursively from CWD up to FS root
 func lookupConfigs(cwd string) []string {
 	// prepend default config paths
-	configPaths := []string{
-		GlobalConfig(),
-		GlobalConfigData(),
-	}
+	configPaths := globalConfigs()
 
 	configNames := []string{appName + ".json", "." + appName + ".json"}
 
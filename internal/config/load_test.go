@@ -7,10 +7,12 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/env"
+	"github.com/charmbracelet/crush/internal/shell"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -37,6 +39,23 @@ func TestConfig_LoadFromReaders(t *testing.T) {
 	require.Equal(t, "https://api.openai.com/v2", pc.BaseURL)
 }
 
+func TestConfig_LoadToolOptions(t *testing.T) {
+	global := strings.NewReader(`{"options": {"tools": {"*": {"max_output_bytes": 1000}}}}`)
+	project := strings.NewReader(`{"options": {"tools": {"shell": "powershell", "bash": {"timeout": 60}}}}`)
+
+	loadedConfig, err := loadFromReaders([]io.Reader{global, project})
+	require.NoError(t, err)
+	tools := loadedConfig.Options.Tools
+	require.Equal(t, shell.ShellTypePowerShell, tools.ShellType())
+	require.Equal(t, map[string]ToolLimits{
+		"*":    {MaxOutputBytes: 1000},
+		"bash": {Timeout: 60},
+	}, tools.Limits)
+
+	_, err = loadFromReaders([]io.Reader{strings.NewReader(`{"options": {"tools": {"shell": "zsh"}}}`)})
+	require.ErrorContains(t, err, `unknown shell "zsh"`)
+}
+
 func TestConfig_setDefaults(t *testing.T) {
 	cfg := &Config{}
 
@@ -469,7 +488,7 @@ func TestConfig_setupAgentsWithNoDisabledTools(t *testing.T) {
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
-	assert.Equal(t, []string{"glob", "grep", "ls", "sourcegraph", "view"}, taskAgent.AllowedTools)
+	assert.Equal(t, []string{"glob", "grep", "git_log", "git_blame", "ls", "sourcegraph", "view"}, taskAgent.AllowedTools)
 }
 
 func TestConfig_setupAgentsWithDisabledTools(t *testing.T) {
@@ -487,11 +506,11 @@ func TestConfig_setupAgentsWithDisabledTools(t *testing.T) {
 	coderAgent, ok := cfg.Agents[AgentCoder]
 	require.True(t, ok)
 
-	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "multiedit", "lsp_diagnostics", "lsp_references", "fetch", "agentic_fetch", "glob", "ls", "sourcegraph", "view", "write"}, coderAgent.AllowedTools)
+	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "run_tests", "project_build", "project_lint", "project_test", "project_format", "multiedit", "apply_patch", "lsp_diagnostics", "lsp_references", "fetch", "agentic_fetch", "glob", "git_log", "git_blame", "ls", "sourcegraph", "view", "write", "todo"}, coderAgent.AllowedTools)
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
-	assert.Equal(t, []string{"glob", "ls", "sourcegraph", "view"}, taskAgent.AllowedTools)
+	assert.Equal(t, []string{"glob", "git_log", "git_blame", "ls", "sourcegraph", "view"}, taskAgent.AllowedTools)
 }
 
 func TestConfig_setupAgentsWithEveryReadOnlyToolDisabled(t *testing.T) {
@@ -500,6 +519,8 @@ func TestConfig_setupAgentsWithEveryReadOnlyToolDisabled(t *testing.T) {
 			DisabledTools: []string{
 				"glob",
 				"grep",
+				"git_log",
+				"git_blame",
 				"ls",
 				"sourcegraph",
 				"view",
@@ -510,13 +531,137 @@ func TestConfig_setupAgentsWithEveryReadOnlyToolDisabled(t *testing.T) {
 	cfg.SetupAgents()
 	coderAgent, ok := cfg.Agents[AgentCoder]
 	require.True(t, ok)
-	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "download", "edit", "multiedit", "lsp_diagnostics", "lsp_references", "fetch", "agentic_fetch", "write"}, coderAgent.AllowedTools)
+	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "run_tests", "project_build", "project_lint", "project_test", "project_format", "download", "edit", "multiedit", "apply_patch", "lsp_diagnostics", "lsp_references", "fetch", "agentic_fetch", "write", "todo"}, coderAgent.AllowedTools)
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
 	assert.Equal(t, []string{}, taskAgent.AllowedTools)
 }
 
+func TestConfig_setupAgentsOffline(t *testing.T) {
+	cfg := &Config{
+		Options: &Options{
+			Offline:       true,
+			DisabledTools: []string{"edit"},
+		},
+	}
+
+	cfg.SetupAgents()
+	coderAgent, ok := cfg.Agents[AgentCoder]
+	require.True(t, ok)
+	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "run_tests", "project_build", "project_lint", "project_test", "project_format", "multiedit", "apply_patch", "lsp_diagnostics", "lsp_references", "glob", "grep", "git_log", "git_blame", "ls", "view", "write", "todo"}, coderAgent.AllowedTools)
+	assert.Equal(t, []string{"edit"}, cfg.Options.DisabledTools)
+
+	taskAgent, ok := cfg.Agents[AgentTask]
+	require.True(t, ok)
+	assert.Equal(t, []string{"glob", "grep", "git_log", "git_blame", "ls", "view"}, taskAgent.AllowedTools)
+}
+
+func TestConfig_setDefaultsOffline(t *testing.T) {
+	t.Setenv("CRUSH_DISABLE_PROVIDER_AUTO_UPDATE", "0")
+	t.Setenv("CRUSH_OFFLINE", "1")
+
+	cfg := &Config{}
+	cfg.setDefaults(t.TempDir(), "")
+	require.True(t, cfg.Options.Offline)
+	require.True(t, cfg.Options.DisableProviderAutoUpdate)
+	require.True(t, cfg.Options.DisableMetrics)
+}
+
+func TestOffline(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("CRUSH_OFFLINE", "")
+	require.NoError(t, os.Unsetenv("CRUSH_OFFLINE"))
+	require.False(t, Offline(dir))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "crush.json"), []byte(`{"options": {"offline": true}}`), 0o644))
+	require.True(t, Offline(dir))
+
+	t.Setenv("CRUSH_OFFLINE", "false")
+	require.False(t, Offline(dir))
+}
+
+func TestUpdateProvidersOffline(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	err := UpdateProviders("https://catwalk.example.com", true)
+	require.ErrorIs(t, err, ErrOffline)
+	require.NoError(t, UpdateProviders("embedded", true))
+}
+
+func TestProviderTimeouts(t *testing.T) {
+	t.Parallel()
+
+	var timeouts *ProviderTimeouts
+	require.Equal(t, DefaultRequestTimeout, timeouts.RequestTimeout())
+	require.Equal(t, DefaultStreamIdleTimeout, timeouts.StreamIdleTimeout())
+	require.Equal(t, DefaultKeepAlive, timeouts.KeepAliveInterval())
+
+	timeouts = &ProviderTimeouts{Request: 60, StreamIdle: -1}
+	require.Equal(t, time.Minute, timeouts.RequestTimeout())
+	require.Zero(t, timeouts.StreamIdleTimeout())
+	require.Equal(t, DefaultKeepAlive, timeouts.KeepAliveInterval())
+}
+
+func TestConfig_configureProvidersKeepsTimeouts(t *testing.T) {
+	knownProviders := []catwalk.Provider{
+		{
+			ID:          "openai",
+			APIKey:      "$OPENAI_API_KEY",
+			APIEndpoint: "https://api.openai.com/v1",
+			Models:      []catwalk.Model{{ID: "test-model"}},
+		},
+	}
+	cfg := &Config{
+		Providers: csync.NewMapFrom(map[string]ProviderConfig{
+			"openai": {Timeouts: &ProviderTimeouts{StreamIdle: 120}},
+		}),
+	}
+	cfg.setDefaults("/tmp", "")
+
+	env := env.NewFromMap(map[string]string{"OPENAI_API_KEY": "test-key"})
+	resolver := NewEnvironmentVariableResolver(env)
+	require.NoError(t, cfg.configureProviders(env, resolver, knownProviders))
+
+	pc, ok := cfg.Providers.Get("openai")
+	require.True(t, ok)
+	require.Equal(t, 2*time.Minute, pc.Timeouts.StreamIdleTimeout())
+}
+
+func TestNetwork_httpOptions(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+
+	var n *Network
+	require.True(t, n.httpOptions().IsZero())
+
+	n = &Network{Proxy: "http://proxy.example.com:3128", CABundle: "certs/ca.pem"}
+	opts := n.httpOptions()
+	require.Equal(t, "http://proxy.example.com:3128", opts.Proxy)
+	require.Equal(t, filepath.Join(configHome, appName, "certs", "ca.pem"), opts.CABundle)
+
+	n.CABundle = filepath.Join(t.TempDir(), "ca.pem")
+	require.Equal(t, n.CABundle, n.httpOptions().CABundle)
+}
+
+func TestGlobalNetwork(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	network, err := globalNetwork()
+	require.NoError(t, err)
+	require.Nil(t, network)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(configHome, appName), 0o755))
+	require.NoError(t, os.WriteFile(GlobalConfig(), []byte(`{"options": {"network": {"proxy": "http://proxy.example.com:3128"}}}`), 0o600))
+	network, err = globalNetwork()
+	require.NoError(t, err)
+	require.Equal(t, "http://proxy.example.com:3128", network.Proxy)
+}
+
 func TestConfig_configureProvidersWithDisabledProvider(t *testing.T) {
 	knownProviders := []catwalk.Provider{
 		{
@@ -599,6 +744,7 @@ func TestConfig_configureProvidersCustomProviderValidation(t *testing.T) {
 		require.Equal(t, cfg.Providers.Len(), 0)
 		_, exists := cfg.Providers.Get("custom")
 		require.False(t, exists)
+		require.Equal(t, []string{`provider "custom" is skipped due to missing API endpoint`}, cfg.Warnings())
 	})
 
 	t.Run("custom provider with no models is removed", func(t *testing.T) {
@@ -1248,3 +1394,17 @@ func TestConfig_configureSelectedModels(t *testing.T) {
 		require.Equal(t, int64(100), large.MaxTokens)
 	})
 }
+
+func TestConfig_projectCommands(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := loadFromReaders([]io.Reader{
+		strings.NewReader(`{"project": {"commands": {"build": "make", "test": "make test"}}}`),
+		strings.NewReader(`{"project": {"commands": {"test": "make check", "format": "  "}}}`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, []ProjectCommand{
+		{Name: "build", Command: "make"},
+		{Name: "test", Command: "make check"},
+	}, cfg.Project.Commands.All())
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"path/filepath"
+
+	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/httpext"
+)
+
+// globalNetwork returns the network options of the global configuration
+// files. The ones of the projects are ignored, so that a cloned repository
+// can't send the requests, API keys included, through a proxy or a CA it
+// controls.
+func globalNetwork() (*Network, error) {
+	cfg, err := loadFromConfigPaths(globalConfigs())
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Options == nil {
+		return nil, nil
+	}
+	return cfg.Options.Network, nil
+}
+
+// httpOptions returns the options of the HTTP clients, with the path of the
+// CA bundle relative to the directory of the global configuration.
+func (n *Network) httpOptions() httpext.Options {
+	if n == nil {
+		return httpext.Options{}
+	}
+	bundle := n.CABundle
+	if bundle != "" {
+		bundle = home.Long(bundle)
+		if !filepath.IsAbs(bundle) {
+			bundle = filepath.Join(filepath.Dir(GlobalConfig()), bundle)
+		}
+	}
+	return httpext.Options{
+		Proxy:    n.Proxy,
+		NoProxy:  n.NoProxy,
+		CABundle: bundle,
+	}
+}
+
+// ConfigureNetwork sets up the HTTP clients from the network options of the
+// global configuration, like Load does, for the commands that don't need the
+// whole configuration.
+func ConfigureNetwork() error {
+	network, err := globalNetwork()
+	if err != nil {
+		return err
+	}
+	return httpext.Configure(network.httpOptions())
+}
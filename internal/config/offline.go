@@ -0,0 +1,36 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"strconv"
+)
+
+// ErrOffline is wrapped by the errors of what offline mode blocks.
+var ErrOffline = errors.New("network access is blocked in offline mode")
+
+// Offline reports whether offline mode is on for workingDir, as set by the
+// CRUSH_OFFLINE environment variable or the configuration files, without
+// loading the providers like Load does. It's meant for the commands that
+// don't need the whole configuration.
+func Offline(workingDir string) bool {
+	if str, ok := os.LookupEnv("CRUSH_OFFLINE"); ok {
+		offline, _ := strconv.ParseBool(str)
+		return offline
+	}
+	opts, err := loadOptions(workingDir)
+	return err == nil && opts.Offline
+}
+
+// loadOptions returns the options of the configuration files for
+// workingDir, without defaults.
+func loadOptions(workingDir string) (*Options, error) {
+	cfg, err := loadFromConfigPaths(lookupConfigs(workingDir))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Options == nil {
+		return &Options{}, nil
+	}
+	return cfg.Options, nil
+}
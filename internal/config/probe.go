@@ -0,0 +1,256 @@
+package config
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/httpext"
+)
+
+const (
+	// probeCacheTTL is how long the models of a provider are used before
+	// they are probed again.
+	probeCacheTTL = 24 * time.Hour
+	// probeTimeout is how long a probe waits for the provider.
+	probeTimeout = 5 * time.Second
+)
+
+// probedModels is what is cached of the models of an OpenAI-compatible
+// provider.
+type probedModels struct {
+	ProbedAt time.Time       `json:"probed_at"`
+	Models   []catwalk.Model `json:"models"`
+}
+
+// modelsResponse is the /models response of OpenAI-compatible servers.
+// Besides the ID, servers report the capabilities of models in different
+// ways, so the fields of the most common ones are read.
+type modelsResponse struct {
+	Data []probeModel `json:"data"`
+}
+
+type probeModel struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+
+	// OpenRouter, Together and Fireworks.
+	ContextLength int64 `json:"context_length"`
+	// Groq.
+	ContextWindow int64 `json:"context_window"`
+	// Mistral and LM Studio.
+	MaxContextLength int64 `json:"max_context_length"`
+	// vLLM.
+	MaxModelLen int64 `json:"max_model_len"`
+
+	// OpenRouter.
+	TopProvider struct {
+		MaxCompletionTokens int64 `json:"max_completion_tokens"`
+	} `json:"top_provider"`
+	SupportedParameters []string `json:"supported_parameters"`
+	Architecture        struct {
+		InputModalities []string `json:"input_modalities"`
+	} `json:"architecture"`
+
+	// A list like ["tool_use", "vision"] for LM Studio, an object like
+	// {"function_calling": true, "vision": true} for Mistral.
+	Capabilities json.RawMessage `json:"capabilities"`
+}
+
+// capabilities returns the capabilities the server reported for the model.
+// Capabilities it doesn't report are missing from the map.
+func (m probeModel) capabilities() map[string]bool {
+	caps := map[string]bool{}
+	var list []string
+	if json.Unmarshal(m.Capabilities, &list) == nil {
+		for _, c := range list {
+			caps[c] = true
+		}
+	} else {
+		_ = json.Unmarshal(m.Capabilities, &caps)
+	}
+	// Missing parameters or modalities in a list that is there are
+	// reported as unsupported.
+	if params := m.SupportedParameters; len(params) > 0 {
+		caps["tools"] = slices.Contains(params, "tools")
+		caps["reasoning"] = slices.Contains(params, "reasoning")
+	}
+	if modalities := m.Architecture.InputModalities; len(modalities) > 0 {
+		caps["image"] = slices.Contains(modalities, "image")
+	}
+	return caps
+}
+
+// supportsTools reports whether the model can call tools, assuming it can
+// when the server doesn't say.
+func (m probeModel) supportsTools() bool {
+	caps := m.capabilities()
+	for _, name := range []string{"tools", "tool_use", "function_calling"} {
+		if supported, ok := caps[name]; ok {
+			return supported
+		}
+	}
+	return true
+}
+
+// supportsImages reports whether the model takes images, guessing from its
+// ID when the server doesn't say.
+func (m probeModel) supportsImages() bool {
+	caps := m.capabilities()
+	for _, name := range []string{"image", "vision"} {
+		if supported, ok := caps[name]; ok {
+			return supported
+		}
+	}
+	id := strings.ToLower(m.ID)
+	for _, hint := range []string{"vision", "-vl", "llava", "gpt-4o", "gpt-4.1", "gemma-3", "pixtral"} {
+		if strings.Contains(id, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m probeModel) model() catwalk.Model {
+	caps := m.capabilities()
+	return catwalk.Model{
+		ID:               m.ID,
+		Name:             cmp.Or(m.Name, m.ID),
+		ContextWindow:    cmp.Or(m.ContextLength, m.ContextWindow, m.MaxContextLength, m.MaxModelLen),
+		DefaultMaxTokens: m.TopProvider.MaxCompletionTokens,
+		CanReason:        caps["reasoning"],
+		SupportsImages:   m.supportsImages(),
+	}
+}
+
+// probeModels lists the models of an OpenAI-compatible provider. Models that
+// report they can't call tools are left out, since the agent needs them.
+func probeModels(ctx context.Context, baseURL, apiKey string, headers map[string]string) ([]catwalk.Model, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(baseURL, "/")+"/models", nil)
+	if err != nil {
+		return nil, err
+	}
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := httpext.NewClient(probeTimeout).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("listing models failed with status %s", resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var mr modelsResponse
+	if err := json.Unmarshal(body, &mr); err != nil {
+		return nil, fmt.Errorf("failed to parse models: %w", err)
+	}
+
+	var models []catwalk.Model
+	for _, m := range mr.Data {
+		if m.ID == "" || !m.supportsTools() {
+			continue
+		}
+		models = append(models, m.model())
+	}
+	return models, nil
+}
+
+// modelProbeCache keeps the probed models of providers by base URL in a JSON
+// file, so that they aren't listed on every start and are still known when
+// the provider can't be reached.
+type modelProbeCache struct {
+	path string
+}
+
+func (c modelProbeCache) load() map[string]probedModels {
+	cache := map[string]probedModels{}
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		slog.Warn("Failed to parse probed models cache", "path", c.path, "error", err)
+	}
+	return cache
+}
+
+func (c modelProbeCache) save(cache map[string]probedModels) {
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		slog.Warn("Failed to save probed models", "error", err)
+		return
+	}
+	if err := os.WriteFile(c.path, data, 0o644); err != nil {
+		slog.Warn("Failed to save probed models", "error", err)
+	}
+}
+
+// models returns the models of the provider at baseURL, probing it when the
+// cached ones are missing or stale. Stale models are used when probing
+// fails.
+func (c modelProbeCache) models(baseURL, apiKey string, headers map[string]string) ([]catwalk.Model, error) {
+	cache := c.load()
+	cached, ok := cache[baseURL]
+	if ok && time.Since(cached.ProbedAt) < probeCacheTTL {
+		return cached.Models, nil
+	}
+	models, err := probeModels(context.Background(), baseURL, apiKey, headers)
+	if err != nil {
+		if ok {
+			slog.Warn("Failed to probe models, using cached ones", "base_url", baseURL, "error", err)
+			return cached.Models, nil
+		}
+		return nil, err
+	}
+	cache[baseURL] = probedModels{ProbedAt: time.Now(), Models: models}
+	c.save(cache)
+	return models, nil
+}
+
+// mergeProbedModels returns the probed models when none are configured.
+// Otherwise only the configured models are kept, with the fields left empty
+// in the configuration filled from the probed model with the same ID.
+func mergeProbedModels(configured, probed []catwalk.Model) []catwalk.Model {
+	if len(configured) == 0 {
+		return probed
+	}
+	merged := slices.Clone(configured)
+	for i := range merged {
+		m := &merged[i]
+		j := slices.IndexFunc(probed, func(p catwalk.Model) bool { return p.ID == m.ID })
+		if j < 0 {
+			continue
+		}
+		m.Name = cmp.Or(m.Name, probed[j].Name)
+		m.ContextWindow = cmp.Or(m.ContextWindow, probed[j].ContextWindow)
+		m.DefaultMaxTokens = cmp.Or(m.DefaultMaxTokens, probed[j].DefaultMaxTokens)
+	}
+	return merged
+}
+
+// modelProbeCache returns the cache of probed models, next to the data
+// config file.
+func (c *Config) modelProbeCache() modelProbeCache {
+	return modelProbeCache{path: filepath.Join(filepath.Dir(c.dataConfigDir), "probed_models.json")}
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/env"
+	"github.com/stretchr/testify/require"
+)
+
+const probeModelsJSON = `{"object":"list","data":[
+	{"id":"router/coder","name":"Coder","context_length":131072,
+	 "top_provider":{"max_completion_tokens":16384},
+	 "supported_parameters":["tools","reasoning"],
+	 "architecture":{"input_modalities":["text","image"]}},
+	{"id":"router/chat","context_length":8192,"supported_parameters":["temperature"]},
+	{"id":"local-model","max_context_length":32768,"capabilities":["tool_use"]},
+	{"id":"mistral-small","max_context_length":128000,"capabilities":{"function_calling":true,"vision":true}},
+	{"id":"qwen2.5-vl","max_model_len":65536}
+]}`
+
+func newProbeServer(t *testing.T, requests *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path != "/v1/models" || r.Header.Get("Authorization") != "Bearer test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(probeModelsJSON))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestProbeModels(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	srv := newProbeServer(t, &requests)
+
+	models, err := probeModels(t.Context(), srv.URL+"/v1/", "test-key", nil)
+	require.NoError(t, err)
+	require.Equal(t, []catwalk.Model{
+		{ID: "router/coder", Name: "Coder", ContextWindow: 131072, DefaultMaxTokens: 16384, CanReason: true, SupportsImages: true},
+		{ID: "local-model", Name: "local-model", ContextWindow: 32768},
+		{ID: "mistral-small", Name: "mistral-small", ContextWindow: 128000, SupportsImages: true},
+		{ID: "qwen2.5-vl", Name: "qwen2.5-vl", ContextWindow: 65536, SupportsImages: true},
+	}, models)
+
+	_, err = probeModels(t.Context(), srv.URL+"/v1", "wrong-key", nil)
+	require.ErrorContains(t, err, "401")
+}
+
+func TestConfig_configureProvidersProbesModels(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	srv := newProbeServer(t, &requests)
+	dataDir := t.TempDir()
+
+	newConfig := func(baseURL string, models []catwalk.Model) *Config {
+		cfg := &Config{
+			Providers: csync.NewMapFrom(map[string]ProviderConfig{
+				"local": {
+					APIKey:  "test-key",
+					BaseURL: baseURL,
+					Type:    catwalk.TypeOpenAICompat,
+					Models:  models,
+				},
+			}),
+		}
+		cfg.setDefaults(dataDir, "")
+		cfg.dataConfigDir = filepath.Join(dataDir, "crush.json")
+		env := env.NewFromMap(map[string]string{})
+		require.NoError(t, cfg.configureProviders(env, NewEnvironmentVariableResolver(env), []catwalk.Provider{}))
+		return cfg
+	}
+
+	cfg := newConfig(srv.URL+"/v1", nil)
+	pc, ok := cfg.Providers.Get("local")
+	require.True(t, ok)
+	require.Len(t, pc.Models, 4)
+	require.Equal(t, int32(1), requests.Load())
+
+	// Configured models are kept as they are, with only what is missing
+	// filled in, and the models come from the cache.
+	cfg = newConfig(srv.URL+"/v1", []catwalk.Model{
+		{ID: "local-model", Name: "My Model", DefaultMaxTokens: 4096},
+		{ID: "unlisted"},
+	})
+	pc, ok = cfg.Providers.Get("local")
+	require.True(t, ok)
+	require.Equal(t, []catwalk.Model{
+		{ID: "local-model", Name: "My Model", ContextWindow: 32768, DefaultMaxTokens: 4096},
+		{ID: "unlisted"},
+	}, pc.Models)
+	require.Equal(t, int32(1), requests.Load())
+
+	// A provider that can't be probed and has no models is skipped.
+	cfg = newConfig(srv.URL+"/missing", nil)
+	_, ok = cfg.Providers.Get("local")
+	require.False(t, ok)
+}
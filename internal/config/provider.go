@@ -77,7 +77,7 @@ func loadProvidersFromCache(path string) ([]catwalk.Provider, error) {
 	return providers, nil
 }
 
-func UpdateProviders(pathOrUrl string) error {
+func UpdateProviders(pathOrUrl string, offline bool) error {
 	var providers []catwalk.Provider
 	pathOrUrl = cmp.Or(pathOrUrl, os.Getenv("CATWALK_URL"), defaultCatwalkURL)
 
@@ -85,6 +85,9 @@ func UpdateProviders(pathOrUrl string) error {
 	case pathOrUrl == "embedded":
 		providers = embedded.GetAll()
 	case strings.HasPrefix(pathOrUrl, "http://") || strings.HasPrefix(pathOrUrl, "https://"):
+		if offline {
+			return fmt.Errorf("can't fetch providers from %s, update them from a local file or the embedded list instead: %w", pathOrUrl, ErrOffline)
+		}
 		var err error
 		providers, err = catwalk.NewWithURL(pathOrUrl).GetProviders()
 		if err != nil {
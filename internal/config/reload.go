@@ -0,0 +1,307 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDelay is how long to wait for more changes before reloading, as
+// editors often write a file in several steps.
+const reloadDelay = 250 * time.Millisecond
+
+// reloadableSections are the top-level configuration sections applied
+// without restarting.
+var reloadableSections = []string{"permissions", "mcp", "lsp"}
+
+// ReloadEvent is published when the configuration files change.
+type ReloadEvent struct {
+	// Reloaded are the sections that were applied, like "mcp".
+	Reloaded []string
+	// MCP and LSP are the names of the servers that were added, removed or
+	// changed, and have to be restarted.
+	MCP []string
+	LSP []string
+	// RestartRequired are the settings that changed but only apply after a
+	// restart, like "options.debug".
+	RestartRequired []string
+	// Err is set when the configuration couldn't be loaded, in which case the
+	// current one is kept.
+	Err error
+}
+
+var reloadBroker = pubsub.NewBroker[ReloadEvent]()
+
+// SubscribeReloads returns a channel for configuration reloads.
+func SubscribeReloads(ctx context.Context) <-chan pubsub.Event[ReloadEvent] {
+	return reloadBroker.Subscribe(ctx)
+}
+
+// ownWrites holds what Crush itself last wrote to each configuration file,
+// like the selected model, so those writes aren't reported as changes.
+var ownWrites = csync.NewMap[string, string]()
+
+// Watch reloads the configuration when one of its files changes, until ctx
+// is done. The permissions and the MCP and LSP servers are updated in place,
+// and a [ReloadEvent] is published for each change.
+func (c *Config) Watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch the configuration: %w", err)
+	}
+	defer watcher.Close()
+
+	w := &configWatcher{cfg: c}
+	paths := lookupConfigs(c.workingDir)
+	w.files = readConfigFiles(paths)
+	if _, raw, err := parseConfigFiles(paths, w.files); err == nil {
+		w.raw = raw
+	}
+
+	dirs := []string{c.workingDir}
+	for _, path := range paths {
+		dirs = append(dirs, filepath.Dir(path))
+	}
+	slices.Sort(dirs)
+	for _, dir := range slices.Compact(dirs) {
+		// Editors replace files, so the directories are watched instead.
+		if err := watcher.Add(dir); err != nil {
+			slog.Debug("Not watching configuration directory", "dir", dir, "error", err)
+		}
+	}
+
+	timer := time.NewTimer(reloadDelay)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if ev.Has(fsnotify.Chmod) || !isConfigFileName(filepath.Base(ev.Name)) {
+				continue
+			}
+			timer.Reset(reloadDelay)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Warn("Error watching the configuration", "error", err)
+		case <-timer.C:
+			if event, ok := w.reload(); ok {
+				reloadBroker.Publish(pubsub.UpdatedEvent, event)
+			}
+		}
+	}
+}
+
+func isConfigFileName(name string) bool {
+	return name == appName+".json" || name == "."+appName+".json"
+}
+
+type configWatcher struct {
+	cfg *Config
+	// files are the contents of the configuration files at the last reload.
+	files map[string]string
+	// raw is the merged configuration at the last reload, by section.
+	raw map[string]json.RawMessage
+}
+
+// reload loads the configuration files again and applies what changed. It
+// reports whether there's anything to tell about.
+func (w *configWatcher) reload() (ReloadEvent, bool) {
+	paths := lookupConfigs(w.cfg.workingDir)
+	files := readConfigFiles(paths)
+	if maps.Equal(files, w.files) {
+		return ReloadEvent{}, false
+	}
+	onlyOwnWrites := true
+	for path := range joinKeys(files, w.files) {
+		if files[path] == w.files[path] {
+			continue
+		}
+		if own, ok := ownWrites.Get(path); !ok || own != files[path] {
+			onlyOwnWrites = false
+		}
+	}
+	w.files = files
+
+	cfg, raw, err := parseConfigFiles(paths, files)
+	if err != nil {
+		slog.Warn("Failed to reload the configuration", "error", err)
+		return ReloadEvent{Err: err}, true
+	}
+	previous := w.raw
+	w.raw = raw
+	if onlyOwnWrites {
+		// Already applied when they were written.
+		return ReloadEvent{}, false
+	}
+
+	var event ReloadEvent
+	for _, key := range changedKeys(previous, raw) {
+		section, _, _ := strings.Cut(key, ".")
+		if !slices.Contains(reloadableSections, section) {
+			event.RestartRequired = append(event.RestartRequired, key)
+		}
+	}
+	cfg.setDefaults(w.cfg.workingDir, w.cfg.Options.DataDirectory)
+	if w.cfg.untrusted {
+		// The servers of an untrusted project don't run.
+		global, err := loadGlobal(w.cfg.workingDir, w.cfg.Options.DataDirectory)
+		if err != nil {
+			slog.Warn("Failed to reload the configuration", "error", err)
+			return ReloadEvent{Err: err}, true
+		}
+		cfg.MCP, cfg.LSP = global.MCP, global.LSP
+	}
+	w.cfg.applyReload(cfg, &event)
+	return event, len(event.Reloaded) > 0 || len(event.RestartRequired) > 0
+}
+
+// applyReload updates the sections of the configuration that can change at
+// runtime from a newly loaded one.
+func (c *Config) applyReload(loaded *Config, event *ReloadEvent) {
+	var current, next Permissions
+	if c.Permissions != nil {
+		current = *c.Permissions
+	}
+	if loaded.Permissions != nil {
+		next = *loaded.Permissions
+	}
+	if !slices.Equal(current.AllowedTools, next.AllowedTools) ||
+		current.Timeout != next.Timeout || current.TimeoutAction != next.TimeoutAction ||
+		current.ApprovalExpiry != next.ApprovalExpiry {
+		if c.Permissions == nil {
+			c.Permissions = &Permissions{}
+		}
+		c.Permissions.AllowedTools = next.AllowedTools
+		c.Permissions.Timeout = next.Timeout
+		c.Permissions.TimeoutAction = next.TimeoutAction
+		c.Permissions.ApprovalExpiry = next.ApprovalExpiry
+		event.Reloaded = append(event.Reloaded, "permissions")
+	}
+	c.applyServers(loaded, event)
+}
+
+// applyServers updates the MCP and LSP servers from a newly loaded
+// configuration.
+func (c *Config) applyServers(loaded *Config, event *ReloadEvent) {
+	if event.MCP = changedServers(c.MCP, loaded.MCP); len(event.MCP) > 0 {
+		c.MCP = loaded.MCP
+		event.Reloaded = append(event.Reloaded, "mcp")
+	}
+	if event.LSP = changedServers(c.LSP, loaded.LSP); len(event.LSP) > 0 {
+		c.LSP = loaded.LSP
+		event.Reloaded = append(event.Reloaded, "lsp")
+	}
+}
+
+// changedServers returns the names of the servers added, removed or changed.
+func changedServers[T any](current, loaded map[string]T) []string {
+	var names []string
+	for name := range joinKeys(current, loaded) {
+		a, inCurrent := current[name]
+		b, inLoaded := loaded[name]
+		if inCurrent != inLoaded || !reflect.DeepEqual(a, b) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// changedKeys returns the sections that differ between two configurations,
+// or their direct children for sections that are objects in both, in the
+// dotted form used in the docs, like options.debug.
+func changedKeys(previous, current map[string]json.RawMessage) []string {
+	var keys []string
+	for _, key := range slices.Sorted(joinKeys(previous, current)) {
+		a, b := previous[key], current[key]
+		if string(a) == string(b) {
+			continue
+		}
+		var aFields, bFields map[string]json.RawMessage
+		if json.Unmarshal(a, &aFields) != nil || json.Unmarshal(b, &bFields) != nil || aFields == nil || bFields == nil {
+			keys = append(keys, key)
+			continue
+		}
+		for _, field := range slices.Sorted(joinKeys(aFields, bFields)) {
+			if string(aFields[field]) != string(bFields[field]) {
+				keys = append(keys, key+"."+field)
+			}
+		}
+	}
+	return keys
+}
+
+// joinKeys returns the keys of both maps, once each.
+func joinKeys[V any](a, b map[string]V) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for k := range a {
+			if !yield(k) {
+				return
+			}
+		}
+		for k := range b {
+			if _, ok := a[k]; ok {
+				continue
+			}
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// readConfigFiles returns the contents of the configuration files that
+// exist.
+func readConfigFiles(paths []string) map[string]string {
+	files := make(map[string]string, len(paths))
+	for _, path := range paths {
+		if data, err := os.ReadFile(path); err == nil {
+			files[path] = string(data)
+		}
+	}
+	return files
+}
+
+// parseConfigFiles merges the configuration files read, returning it as a
+// configuration and by section.
+func parseConfigFiles(paths []string, files map[string]string) (*Config, map[string]json.RawMessage, error) {
+	var readers []io.Reader
+	for _, path := range paths {
+		if content, ok := files[path]; ok {
+			readers = append(readers, strings.NewReader(content))
+		}
+	}
+	cfg, err := loadFromReaders(readers)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, nil, err
+	}
+	return cfg, raw, nil
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigWatcherReload(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "crush.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"permissions": {"allowed_tools": ["view"]},
+		"mcp": {"docs": {"type": "http", "url": "https://example.com/mcp"}},
+		"options": {"debug": false}
+	}`), 0o600))
+
+	paths := lookupConfigs(dir)
+	files := readConfigFiles(paths)
+	cfg, raw, err := parseConfigFiles(paths, files)
+	require.NoError(t, err)
+	cfg.setDefaults(dir, "")
+	cfg.dataConfigDir = path
+	w := &configWatcher{cfg: cfg, files: files, raw: raw}
+
+	_, ok := w.reload()
+	require.False(t, ok, "nothing changed")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"permissions": {"allowed_tools": ["view", "ls"], "timeout": 300, "timeout_action": "deny"},
+		"mcp": {
+			"docs": {"type": "http", "url": "https://example.com/mcp"},
+			"github": {"type": "stdio", "command": "github-mcp"}
+		},
+		"options": {"debug": true}
+	}`), 0o600))
+	event, ok := w.reload()
+	require.True(t, ok)
+	require.NoError(t, event.Err)
+	require.Equal(t, []string{"permissions", "mcp"}, event.Reloaded)
+	require.Equal(t, []string{"github"}, event.MCP)
+	require.Empty(t, event.LSP)
+	require.Equal(t, []string{"options.debug"}, event.RestartRequired)
+	require.Equal(t, []string{"view", "ls"}, cfg.Permissions.AllowedTools)
+	require.Equal(t, 5*time.Minute, cfg.Permissions.TimeoutDuration())
+	require.Equal(t, PermissionTimeoutDeny, cfg.Permissions.TimeoutAction)
+	require.Contains(t, cfg.MCP, "github")
+	require.False(t, cfg.Options.Debug, "needs a restart")
+
+	// What Crush writes itself is already applied.
+	require.NoError(t, cfg.SetDiffMode("split"))
+	_, ok = w.reload()
+	require.False(t, ok)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"options": `), 0o600))
+	event, ok = w.reload()
+	require.True(t, ok)
+	require.Error(t, event.Err)
+	require.Contains(t, cfg.MCP, "github", "the current configuration is kept")
+}
+
+func TestChangedKeys(t *testing.T) {
+	t.Parallel()
+
+	_, previous, err := parseConfigFiles([]string{"a"}, map[string]string{
+		"a": `{"options": {"debug": true, "tui": {"compact_mode": true}}, "models": {"large": {"model": "a", "provider": "p"}}}`,
+	})
+	require.NoError(t, err)
+	_, current, err := parseConfigFiles([]string{"a"}, map[string]string{
+		"a": `{"options": {"debug": true, "tui": {"compact_mode": false}}, "lsp": {"gopls": {"command": "gopls"}}}`,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"lsp", "models", "options.tui"}, changedKeys(previous, current))
+}
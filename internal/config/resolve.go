@@ -3,13 +3,58 @@ package config
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/crush/internal/env"
+	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/keychain"
 	"github.com/charmbracelet/crush/internal/shell"
 )
 
+// keychainPrefix marks values stored in the OS keychain, e.g.
+// "keychain:openai".
+const keychainPrefix = "keychain:"
+
+// resolveKeychain returns the keychain secret for a "keychain:<name>" value.
+func resolveKeychain(value string) (string, bool, error) {
+	name, ok := strings.CutPrefix(value, keychainPrefix)
+	if !ok {
+		return "", false, nil
+	}
+	secret, err := keychain.Get(name)
+	if err != nil {
+		return "", true, fmt.Errorf("failed to read %q from the OS keychain: %w", name, err)
+	}
+	return secret, true, nil
+}
+
+// filePrefix marks values read from a file, e.g. "file:~/.secrets/github".
+const filePrefix = "file:"
+
+// resolveHeader resolves the value of an MCP header with resolver. Its last
+// word can also be a keychain:<name> reference to a secret in the OS
+// keychain, or a file:<path> reference to a file holding it, as in
+// "Bearer keychain:github".
+func resolveHeader(resolver VariableResolver, value string) (string, error) {
+	prefix, ref := "", value
+	if i := strings.LastIndexByte(value, ' '); i >= 0 {
+		prefix, ref = value[:i+1], value[i+1:]
+	}
+	if secret, ok, err := resolveKeychain(ref); ok {
+		return prefix + secret, err
+	}
+	if path, ok := strings.CutPrefix(ref, filePrefix); ok {
+		data, err := os.ReadFile(home.Long(path))
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file: %w", err)
+		}
+		return prefix + strings.TrimSpace(string(data)), nil
+	}
+	return resolver.ResolveValue(value)
+}
+
 type VariableResolver interface {
 	ResolveValue(value string) (string, error)
 }
@@ -38,7 +83,12 @@ func NewShellVariableResolver(env env.Env) VariableResolver {
 // it will resolve shell-like variable substitution anywhere in the string, including:
 // - $(command) for command substitution
 // - $VAR or ${VAR} for environment variables
+// - keychain:<name> for secrets stored in the OS keychain
 func (r *shellVariableResolver) ResolveValue(value string) (string, error) {
+	if secret, ok, err := resolveKeychain(value); ok {
+		return secret, err
+	}
+
 	// Special case: lone $ is an error (backward compatibility)
 	if value == "$" {
 		return "", fmt.Errorf("invalid value format: %s", value)
@@ -161,8 +211,12 @@ func NewEnvironmentVariableResolver(env env.Env) VariableResolver {
 	}
 }
 
-// ResolveValue resolves environment variables from the provided env.Env.
+// ResolveValue resolves environment variables from the provided env.Env, and
+// keychain:<name> values from the OS keychain.
 func (r *environmentVariableResolver) ResolveValue(value string) (string, error) {
+	if secret, ok, err := resolveKeychain(value); ok {
+		return secret, err
+	}
 	if !strings.HasPrefix(value, "$") {
 		return value, nil
 	}
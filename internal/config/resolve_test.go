@@ -3,10 +3,14 @@ package config
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/charmbracelet/crush/internal/env"
+	"github.com/charmbracelet/crush/internal/keychain"
 	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
 )
 
 // mockShell implements the Shell interface for testing
@@ -330,3 +334,48 @@ func TestNewEnvironmentVariableResolver(t *testing.T) {
 	require.NotNil(t, resolver)
 	require.Implements(t, (*VariableResolver)(nil), resolver)
 }
+
+func TestResolveKeychain(t *testing.T) {
+	keyring.MockInit()
+	require.NoError(t, keychain.Set("openai", "sk-from-keychain"))
+
+	resolvers := map[string]VariableResolver{
+		"shell":       NewShellVariableResolver(env.NewFromMap(nil)),
+		"environment": NewEnvironmentVariableResolver(env.NewFromMap(nil)),
+	}
+	for name, resolver := range resolvers {
+		t.Run(name, func(t *testing.T) {
+			result, err := resolver.ResolveValue("keychain:openai")
+			require.NoError(t, err)
+			require.Equal(t, "sk-from-keychain", result)
+
+			_, err = resolver.ResolveValue("keychain:missing")
+			require.ErrorIs(t, err, keychain.ErrNotFound)
+		})
+	}
+}
+
+func TestResolveHeader(t *testing.T) {
+	keyring.MockInit()
+	require.NoError(t, keychain.Set("github", "ghp_keychain"))
+	secretFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(secretFile, []byte("ghp_file\n"), 0o600))
+
+	resolver := NewShellVariableResolver(env.NewFromMap(map[string]string{"GH_PAT": "ghp_env"}))
+	for value, want := range map[string]string{
+		"Bearer keychain:github":    "Bearer ghp_keychain",
+		"keychain:github":           "ghp_keychain",
+		"Bearer file:" + secretFile: "Bearer ghp_file",
+		"Bearer $GH_PAT":            "Bearer ghp_env",
+		"application/json":          "application/json",
+	} {
+		got, err := resolveHeader(resolver, value)
+		require.NoError(t, err, value)
+		require.Equal(t, want, got, value)
+	}
+
+	_, err := resolveHeader(resolver, "Bearer keychain:missing")
+	require.ErrorIs(t, err, keychain.ErrNotFound)
+	_, err = resolveHeader(resolver, "Bearer file:"+filepath.Join(t.TempDir(), "missing"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// SessionTemplate is a series of messages a new session starts with, for a
+// repeatable workflow like a release checklist.
+type SessionTemplate struct {
+	Name     string                   `json:"-"`
+	Title    string                   `json:"title,omitempty"`
+	Messages []SessionTemplateMessage `json:"messages"`
+}
+
+// SessionTemplateMessage is a message of a session template.
+type SessionTemplateMessage struct {
+	// Role is either "user", the default, or "system".
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content"`
+	// Attachments are paths of files to attach, relative to the project.
+	Attachments []string `json:"attachments,omitempty"`
+}
+
+// SessionTemplatesDir returns the directory of the session templates of the
+// project: a <name>.json file for each one.
+func (c *Config) SessionTemplatesDir() string {
+	return filepath.Join(c.Options.DataDirectory, "templates", "sessions")
+}
+
+// SessionTemplateNames returns the names of the session templates of the
+// project, sorted.
+func (c *Config) SessionTemplateNames() ([]string, error) {
+	entries, err := os.ReadDir(c.SessionTemplatesDir())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), ".json"); ok && !entry.IsDir() && name != "" {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// SessionTemplate loads the session template with name.
+func (c *Config) SessionTemplate(name string) (SessionTemplate, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return SessionTemplate{}, fmt.Errorf("invalid session template name %q", name)
+	}
+	data, err := os.ReadFile(filepath.Join(c.SessionTemplatesDir(), name+".json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		names, _ := c.SessionTemplateNames()
+		if len(names) == 0 {
+			return SessionTemplate{}, fmt.Errorf("session template %q not found: there are no templates in %s", name, c.SessionTemplatesDir())
+		}
+		return SessionTemplate{}, fmt.Errorf("session template %q not found, available: %s", name, strings.Join(names, ", "))
+	}
+	if err != nil {
+		return SessionTemplate{}, err
+	}
+	var t SessionTemplate
+	if err := json.Unmarshal(data, &t); err != nil {
+		return SessionTemplate{}, fmt.Errorf("invalid session template %s: %w", name+".json", err)
+	}
+	t.Name = name
+	if len(t.Messages) == 0 {
+		return SessionTemplate{}, fmt.Errorf("session template %s has no messages", name+".json")
+	}
+	for i, msg := range t.Messages {
+		switch msg.Role {
+		case "", "user", "system":
+		default:
+			return SessionTemplate{}, fmt.Errorf("message %d of session template %s: unknown role %q", i+1, name+".json", msg.Role)
+		}
+		if strings.TrimSpace(msg.Content) == "" && len(msg.Attachments) == 0 {
+			return SessionTemplate{}, fmt.Errorf("message %d of session template %s is empty", i+1, name+".json")
+		}
+	}
+	return t, nil
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionTemplate(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{Options: &Options{DataDirectory: t.TempDir()}}
+	_, err := cfg.SessionTemplate("bugfix")
+	require.ErrorContains(t, err, "there are no templates")
+
+	dir := cfg.SessionTemplatesDir()
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	write := func(name, data string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644))
+	}
+	write("bugfix.json", `{
+		"title": "Bug fix",
+		"messages": [
+			{"role": "system", "content": "Write a failing test first."},
+			{"content": "Here is the bug report.", "attachments": ["report.txt"]}
+		]
+	}`)
+	write("release.json", `{"messages": [{"role": "assistant", "content": "Done."}]}`)
+	write("empty.json", `{"messages": [{"content": " "}]}`)
+	write("notes.md", "not a template")
+
+	names, err := cfg.SessionTemplateNames()
+	require.NoError(t, err)
+	require.Equal(t, []string{"bugfix", "empty", "release"}, names)
+
+	tmpl, err := cfg.SessionTemplate("bugfix")
+	require.NoError(t, err)
+	require.Equal(t, SessionTemplate{
+		Name:  "bugfix",
+		Title: "Bug fix",
+		Messages: []SessionTemplateMessage{
+			{Role: "system", Content: "Write a failing test first."},
+			{Content: "Here is the bug report.", Attachments: []string{"report.txt"}},
+		},
+	}, tmpl)
+
+	_, err = cfg.SessionTemplate("release")
+	require.ErrorContains(t, err, `unknown role "assistant"`)
+	_, err = cfg.SessionTemplate("empty")
+	require.ErrorContains(t, err, "is empty")
+	_, err = cfg.SessionTemplate("missing")
+	require.ErrorContains(t, err, "available: bugfix, empty, release")
+	_, err = cfg.SessionTemplate("../bugfix")
+	require.ErrorContains(t, err, "invalid session template name")
+}
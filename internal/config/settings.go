@@ -0,0 +1,185 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/keychain"
+)
+
+// ToolNames returns the names of the built-in tools, which can be disabled
+// with the disabled_tools option.
+func ToolNames() []string {
+	return allToolNames()
+}
+
+func (c *Config) SetDisableAutoSummarize(disabled bool) error {
+	c.Options.DisableAutoSummarize = disabled
+	return c.SetConfigField("options.disable_auto_summarize", disabled)
+}
+
+func (c *Config) SetDiffMode(mode string) error {
+	c.Options.TUI.DiffMode = mode
+	return c.SetConfigField("options.tui.diff_mode", mode)
+}
+
+func (c *Config) SetFileDrop(mode string) error {
+	c.Options.TUI.FileDrop = mode
+	return c.SetConfigField("options.tui.file_drop", mode)
+}
+
+func (c *Config) SetAttribution(attribution Attribution) error {
+	// The trailer style replaces the deprecated co_authored_by field.
+	attribution.CoAuthoredBy = nil
+	c.Options.Attribution = &attribution
+	if err := c.SetConfigField("options.attribution.trailer_style", attribution.TrailerStyle); err != nil {
+		return err
+	}
+	if err := c.RemoveConfigField("options.attribution.co_authored_by"); err != nil {
+		return err
+	}
+	return c.SetConfigField("options.attribution.generated_with", attribution.GeneratedWith)
+}
+
+// SetToolDisabled disables or enables a built-in tool, updating the tools
+// the agents are allowed to use.
+func (c *Config) SetToolDisabled(tool string, disabled bool) error {
+	if !slices.Contains(allToolNames(), tool) {
+		return fmt.Errorf("unknown tool %q", tool)
+	}
+	tools := slices.DeleteFunc(append([]string{}, c.Options.DisabledTools...), func(t string) bool {
+		return t == tool
+	})
+	if disabled {
+		tools = append(tools, tool)
+	}
+	c.Options.DisabledTools = tools
+	c.SetupAgents()
+	return c.SetConfigField("options.disabled_tools", tools)
+}
+
+// SetModelParameters replaces the selected model of the given type, keeping
+// the model and only changing its parameters, like the temperature.
+func (c *Config) SetModelParameters(modelType SelectedModelType, model SelectedModel) error {
+	current, ok := c.Models[modelType]
+	if !ok {
+		return fmt.Errorf("no %s model selected", modelType)
+	}
+	if current.Provider != model.Provider || current.Model != model.Model {
+		return fmt.Errorf("the %s model changed", modelType)
+	}
+	c.Models[modelType] = model
+	if err := c.SetConfigField(fmt.Sprintf("models.%s", modelType), model); err != nil {
+		return fmt.Errorf("failed to update model parameters: %w", err)
+	}
+	return nil
+}
+
+// SetMCP adds or replaces an MCP server in the global configuration.
+func (c *Config) SetMCP(name string, m MCPConfig) error {
+	if name == "" {
+		return fmt.Errorf("the MCP server needs a name")
+	}
+	if c.MCP == nil {
+		c.MCP = MCPs{}
+	}
+	c.MCP[name] = m
+	return c.SetConfigField("mcp."+escapeConfigKey(name), m)
+}
+
+// SetMCPDisabled disables or enables an MCP server, wherever it's set up.
+func (c *Config) SetMCPDisabled(name string, disabled bool) error {
+	m, ok := c.MCP[name]
+	if !ok {
+		return fmt.Errorf("no MCP server named %q", name)
+	}
+	m.Disabled = disabled
+	c.MCP[name] = m
+	return c.SetConfigField("mcp."+escapeConfigKey(name)+".disabled", disabled)
+}
+
+// RemoveMCP removes an MCP server from the global configuration. Servers
+// set up in other configuration files have to be removed from those.
+func (c *Config) RemoveMCP(name string) error {
+	if _, ok := c.MCP[name]; !ok {
+		return fmt.Errorf("no MCP server named %q", name)
+	}
+	if files := c.filesDefiningMCP(name, ""); len(files) > 0 {
+		return fmt.Errorf("the %q MCP server is set up in %s, remove it there", name, strings.Join(files, ", "))
+	}
+	for header, value := range c.MCP[name].Headers {
+		if key := MCPHeaderKeychainKey(name, header); value == keychainPrefix+key {
+			_ = keychain.Delete(key)
+		}
+	}
+	delete(c.MCP, name)
+	return c.RemoveConfigField("mcp." + escapeConfigKey(name))
+}
+
+// MCPHeaderKeychainKey returns the key the value of a header of an MCP
+// server is stored under in the OS keychain.
+func MCPHeaderKeychainKey(name, header string) string {
+	return "mcp:" + name + ":" + header
+}
+
+// SetMCPHeaderSecret stores the value of a header of an MCP server in the OS
+// keychain, and points the header at it in the global configuration. The
+// header can't be set in other configuration files, which would override it.
+func (c *Config) SetMCPHeaderSecret(name, header, value string) error {
+	m, ok := c.MCP[name]
+	if !ok {
+		return fmt.Errorf("no MCP server named %q", name)
+	}
+	if files := c.filesDefiningMCP(name, header); len(files) > 0 {
+		return fmt.Errorf("the %s header of the %q MCP server is set in %s, remove it there", header, name, strings.Join(files, ", "))
+	}
+	key := MCPHeaderKeychainKey(name, header)
+	if err := keychain.Set(key, value); err != nil {
+		return fmt.Errorf("failed to save the header to the OS keychain: %w", err)
+	}
+	m.Headers = maps.Clone(m.Headers)
+	if m.Headers == nil {
+		m.Headers = map[string]string{}
+	}
+	m.Headers[header] = keychainPrefix + key
+	c.MCP[name] = m
+	return c.SetConfigField("mcp."+escapeConfigKey(name)+".headers."+escapeConfigKey(header), keychainPrefix+key)
+}
+
+// filesDefiningMCP returns the configuration files, other than the global
+// data one, that set up the named MCP server, or the given header of it if
+// header isn't empty.
+func (c *Config) filesDefiningMCP(name, header string) []string {
+	var files []string
+	for path, content := range readConfigFiles(lookupConfigs(c.workingDir)) {
+		if path == c.dataConfigDir {
+			continue
+		}
+		var file struct {
+			MCP map[string]struct {
+				Headers map[string]string `json:"headers"`
+			} `json:"mcp"`
+		}
+		if json.Unmarshal([]byte(content), &file) != nil {
+			continue
+		}
+		m, ok := file.MCP[name]
+		if !ok {
+			continue
+		}
+		if _, set := m.Headers[header]; header == "" || set {
+			files = append(files, path)
+		}
+	}
+	slices.Sort(files)
+	return files
+}
+
+// escapeConfigKey escapes the characters with a meaning in the paths given
+// to [Config.SetConfigField].
+func escapeConfigKey(key string) string {
+	return strings.NewReplacer(".", `\.`, "*", `\*`, "?", `\?`).Replace(key)
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/keychain"
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
+)
+
+func newSettingsTestConfig(t *testing.T) *Config {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := &Config{}
+	cfg.setDefaults(dir, "")
+	cfg.dataConfigDir = filepath.Join(dir, "config.json")
+	return cfg
+}
+
+func readDataConfig(t *testing.T, cfg *Config) map[string]any {
+	t.Helper()
+	data, err := os.ReadFile(cfg.dataConfigDir)
+	require.NoError(t, err)
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(data, &m))
+	return m
+}
+
+func TestSetToolDisabled(t *testing.T) {
+	t.Parallel()
+
+	cfg := newSettingsTestConfig(t)
+	cfg.SetupAgents()
+
+	require.NoError(t, cfg.SetToolDisabled("bash", true))
+	require.NoError(t, cfg.SetToolDisabled("fetch", true))
+	require.Equal(t, []string{"bash", "fetch"}, cfg.Options.DisabledTools)
+	require.NotContains(t, cfg.Agents[AgentCoder].AllowedTools, "bash")
+
+	require.NoError(t, cfg.SetToolDisabled("bash", false))
+	require.Equal(t, []string{"fetch"}, cfg.Options.DisabledTools)
+	require.Contains(t, cfg.Agents[AgentCoder].AllowedTools, "bash")
+
+	options := readDataConfig(t, cfg)["options"].(map[string]any)
+	require.Equal(t, []any{"fetch"}, options["disabled_tools"])
+
+	require.Error(t, cfg.SetToolDisabled("nope", true))
+}
+
+func TestSetAttribution(t *testing.T) {
+	t.Parallel()
+
+	cfg := newSettingsTestConfig(t)
+	require.NoError(t, os.WriteFile(cfg.dataConfigDir, []byte(`{"options":{"attribution":{"co_authored_by":true}}}`), 0o600))
+
+	require.NoError(t, cfg.SetAttribution(Attribution{TrailerStyle: TrailerStyleNone, GeneratedWith: true}))
+	require.Equal(t, TrailerStyleNone, cfg.Options.Attribution.TrailerStyle)
+
+	options := readDataConfig(t, cfg)["options"].(map[string]any)
+	require.Equal(t, map[string]any{
+		"trailer_style":  "none",
+		"generated_with": true,
+	}, options["attribution"])
+}
+
+func TestSetModelParameters(t *testing.T) {
+	t.Parallel()
+
+	cfg := newSettingsTestConfig(t)
+	cfg.Models = map[SelectedModelType]SelectedModel{
+		SelectedModelTypeLarge: {Provider: "openai", Model: "gpt-4o"},
+	}
+
+	temperature := 0.2
+	require.NoError(t, cfg.SetModelParameters(SelectedModelTypeLarge, SelectedModel{
+		Provider:    "openai",
+		Model:       "gpt-4o",
+		Temperature: &temperature,
+	}))
+	require.Equal(t, 0.2, *cfg.Models[SelectedModelTypeLarge].Temperature)
+
+	large := readDataConfig(t, cfg)["models"].(map[string]any)["large"].(map[string]any)
+	require.Equal(t, 0.2, large["temperature"])
+
+	require.Error(t, cfg.SetModelParameters(SelectedModelTypeLarge, SelectedModel{Provider: "openai", Model: "o3"}))
+	require.Error(t, cfg.SetModelParameters(SelectedModelTypeSmall, SelectedModel{Provider: "openai", Model: "gpt-4o"}))
+}
+
+func TestSetAndRemoveMCP(t *testing.T) {
+	t.Parallel()
+
+	cfg := newSettingsTestConfig(t)
+	m := MCPConfig{Type: MCPHttp, URL: "https://example.com/mcp"}
+	require.NoError(t, cfg.SetMCP("docs.v1", m))
+	require.Equal(t, m, cfg.MCP["docs.v1"])
+	servers := readDataConfig(t, cfg)["mcp"].(map[string]any)
+	require.Contains(t, servers, "docs.v1", "the dot is part of the name")
+
+	require.NoError(t, cfg.SetMCPDisabled("docs.v1", true))
+	require.True(t, cfg.MCP["docs.v1"].Disabled)
+	servers = readDataConfig(t, cfg)["mcp"].(map[string]any)
+	require.Equal(t, true, servers["docs.v1"].(map[string]any)["disabled"])
+
+	require.NoError(t, cfg.RemoveMCP("docs.v1"))
+	require.NotContains(t, cfg.MCP, "docs.v1")
+	require.Empty(t, readDataConfig(t, cfg)["mcp"])
+	require.Error(t, cfg.RemoveMCP("docs.v1"))
+}
+
+func TestSetMCPHeaderSecret(t *testing.T) {
+	keyring.MockInit()
+
+	cfg := newSettingsTestConfig(t)
+	require.NoError(t, cfg.SetMCP("github", MCPConfig{Type: MCPHttp, URL: "https://example.com/mcp"}))
+	require.NoError(t, cfg.SetMCPHeaderSecret("github", "Authorization", "Bearer ghp_secret"))
+
+	headers, err := cfg.MCP["github"].ResolvedHeaders()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"Authorization": "Bearer ghp_secret"}, headers)
+	servers := readDataConfig(t, cfg)["mcp"].(map[string]any)
+	require.Equal(t, map[string]any{"Authorization": "keychain:mcp:github:Authorization"}, servers["github"].(map[string]any)["headers"])
+
+	// Removing the server removes its secrets.
+	require.NoError(t, cfg.RemoveMCP("github"))
+	_, err = keychain.Get(MCPHeaderKeychainKey("github", "Authorization"))
+	require.ErrorIs(t, err, keychain.ErrNotFound)
+	require.Error(t, cfg.SetMCPHeaderSecret("github", "Authorization", "Bearer ghp_secret"))
+}
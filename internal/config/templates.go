@@ -0,0 +1,164 @@
+package config
+
+import (
+	"cmp"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/home"
+	"github.com/tidwall/sjson"
+)
+
+//go:embed templates/init
+var builtinInitTemplates embed.FS
+
+// InitTemplate shapes the context file created on project initialization,
+// for a kind of project. Its structure is a markdown outline of the sections
+// the file should have, and it can recommend LSP and MCP servers for the
+// project configuration.
+type InitTemplate struct {
+	Name        string               `json:"-"`
+	Description string               `json:"description,omitempty"`
+	Structure   string               `json:"-"`
+	LSP         map[string]LSPConfig `json:"lsp,omitempty"`
+	MCP         map[string]MCPConfig `json:"mcp,omitempty"`
+}
+
+// InitTemplatesDir returns the directory of the user initialization
+// templates: a <name>.md file with the structure of each one, and an
+// optional <name>.json file with its description and servers.
+func InitTemplatesDir() string {
+	if xdgConfigHome := os.Getenv("XDG_CONFIG_HOME"); xdgConfigHome != "" {
+		return filepath.Join(xdgConfigHome, appName, "templates", "init")
+	}
+	return filepath.Join(home.Dir(), ".config", appName, "templates", "init")
+}
+
+// InitTemplates returns the built-in initialization templates and those of
+// the user, sorted by name. A user template replaces the built-in one with
+// the same name.
+func InitTemplates() ([]InitTemplate, error) {
+	builtin, err := fs.Sub(builtinInitTemplates, "templates/init")
+	if err != nil {
+		return nil, err
+	}
+	templates, err := loadInitTemplates(builtin)
+	if err != nil {
+		return nil, err
+	}
+	user, err := loadInitTemplates(os.DirFS(InitTemplatesDir()))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	for _, t := range user {
+		templates = slices.DeleteFunc(templates, func(b InitTemplate) bool { return b.Name == t.Name })
+		templates = append(templates, t)
+	}
+	slices.SortFunc(templates, func(a, b InitTemplate) int { return cmp.Compare(a.Name, b.Name) })
+	return templates, nil
+}
+
+func loadInitTemplates(fsys fs.FS) ([]InitTemplate, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	var templates []InitTemplate
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".md")
+		if entry.IsDir() || !ok || name == "" {
+			continue
+		}
+		structure, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		var t InitTemplate
+		data, err := fs.ReadFile(fsys, name+".json")
+		switch {
+		case err == nil:
+			if err := json.Unmarshal(data, &t); err != nil {
+				return nil, fmt.Errorf("invalid initialization template %s: %w", name+".json", err)
+			}
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, err
+		}
+		t.Name = name
+		t.Structure = strings.TrimSpace(string(structure))
+		templates = append(templates, t)
+	}
+	return templates, nil
+}
+
+// MissingServers returns the servers t recommends that aren't set up yet, as
+// "lsp.<name>" and "mcp.<name>", sorted.
+func (c *Config) MissingServers(t InitTemplate) []string {
+	var missing []string
+	for name := range t.LSP {
+		if _, ok := c.LSP[name]; !ok {
+			missing = append(missing, "lsp."+name)
+		}
+	}
+	for name := range t.MCP {
+		if _, ok := c.MCP[name]; !ok {
+			missing = append(missing, "mcp."+name)
+		}
+	}
+	slices.Sort(missing)
+	return missing
+}
+
+// ApplyInitTemplate adds the servers t recommends that aren't set up yet to
+// the configuration file of the project, crush.json unless there's only a
+// .crush.json, and returns them as [Config.MissingServers] does. The servers
+// start once the configuration is reloaded.
+func (c *Config) ApplyInitTemplate(t InitTemplate) ([]string, error) {
+	added := c.MissingServers(t)
+	if len(added) == 0 {
+		return nil, nil
+	}
+	configPath := projectConfigPath(c.workingDir)
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		data = []byte("{}")
+	}
+	content := string(data)
+	for _, key := range added {
+		section, name, _ := strings.Cut(key, ".")
+		var value any = t.LSP[name]
+		if section == "mcp" {
+			value = t.MCP[name]
+		}
+		content, err = sjson.Set(content, section+"."+escapeConfigKey(name), value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set config field %s: %w", key, err)
+		}
+	}
+	if err := os.WriteFile(configPath, []byte(content), 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write config file: %w", err)
+	}
+	return added, nil
+}
+
+// projectConfigPath returns the configuration file in dir, crush.json
+// unless there's only a .crush.json.
+func projectConfigPath(dir string) string {
+	name := appName + ".json"
+	hidden := filepath.Join(dir, "."+name)
+	if _, err := os.Stat(filepath.Join(dir, name)); os.IsNotExist(err) {
+		if _, err := os.Stat(hidden); err == nil {
+			return hidden
+		}
+	}
+	return filepath.Join(dir, name)
+}
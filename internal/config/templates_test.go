@@ -0,0 +1,93 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadInitTemplates(t *testing.T) {
+	t.Parallel()
+
+	templates, err := loadInitTemplates(fstest.MapFS{
+		"api.md":      {Data: []byte("\n## Endpoints\n")},
+		"api.json":    {Data: []byte(`{"description":"An API","lsp":{"gopls":{"command":"gopls"}}}`)},
+		"plain.md":    {Data: []byte("## Notes")},
+		"orphan.json": {Data: []byte(`{}`)},
+		"notes.txt":   {Data: []byte("not a template")},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []InitTemplate{
+		{Name: "api", Description: "An API", Structure: "## Endpoints", LSP: map[string]LSPConfig{"gopls": {Command: "gopls"}}},
+		{Name: "plain", Structure: "## Notes"},
+	}, templates)
+
+	_, err = loadInitTemplates(fstest.MapFS{
+		"broken.md":   {Data: []byte("## Notes")},
+		"broken.json": {Data: []byte(`{`)},
+	})
+	require.ErrorContains(t, err, "broken.json")
+}
+
+func TestInitTemplates(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	templatesDir := filepath.Join(dir, appName, "templates", "init")
+	require.Equal(t, templatesDir, InitTemplatesDir())
+
+	templates, err := InitTemplates()
+	require.NoError(t, err)
+	var names []string
+	for _, tmpl := range templates {
+		names = append(names, tmpl.Name)
+		require.NotEmpty(t, tmpl.Description, tmpl.Name)
+		require.NotEmpty(t, tmpl.Structure, tmpl.Name)
+	}
+	require.Equal(t, []string{"go-service", "library", "monorepo", "tui-app"}, names)
+
+	require.NoError(t, os.MkdirAll(templatesDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(templatesDir, "library.md"), []byte("## Mine"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(templatesDir, "cli.md"), []byte("## Flags"), 0o644))
+	templates, err = InitTemplates()
+	require.NoError(t, err)
+	require.Len(t, templates, 5)
+	require.Equal(t, InitTemplate{Name: "cli", Structure: "## Flags"}, templates[0])
+	require.Equal(t, InitTemplate{Name: "library", Structure: "## Mine"}, templates[2], "user templates replace the built-in ones")
+}
+
+func TestApplyInitTemplate(t *testing.T) {
+	t.Parallel()
+
+	cfg := newSettingsTestConfig(t)
+	cfg.LSP = LSPs{"gopls": {Command: "gopls"}}
+	tmpl := InitTemplate{
+		LSP: map[string]LSPConfig{
+			"gopls":   {Command: "gopls", Args: []string{"serve"}},
+			"pyright": {Command: "pyright-langserver"},
+		},
+		MCP: map[string]MCPConfig{"docs.site": {Type: MCPHttp, URL: "https://example.com/mcp"}},
+	}
+	require.Equal(t, []string{"lsp.pyright", "mcp.docs.site"}, cfg.MissingServers(tmpl))
+
+	configPath := filepath.Join(cfg.WorkingDir(), ".crush.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"options":{"debug":true}}`), 0o600))
+	added, err := cfg.ApplyInitTemplate(tmpl)
+	require.NoError(t, err)
+	require.Equal(t, []string{"lsp.pyright", "mcp.docs.site"}, added)
+
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"options": {"debug": true},
+		"lsp": {"pyright": {"command": "pyright-langserver"}},
+		"mcp": {"docs.site": {"type": "http", "url": "https://example.com/mcp"}}
+	}`, string(data))
+	require.NoFileExists(t, filepath.Join(cfg.WorkingDir(), "crush.json"))
+
+	added, err = cfg.ApplyInitTemplate(InitTemplate{LSP: map[string]LSPConfig{"gopls": {Command: "gopls"}}})
+	require.NoError(t, err)
+	require.Empty(t, added)
+}
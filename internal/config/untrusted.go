@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// globalConfigs returns the paths of the configuration files of the user,
+// as opposed to the ones of the projects.
+func globalConfigs() []string {
+	return []string{GlobalConfig(), GlobalConfigData()}
+}
+
+// loadGlobal loads the configuration of the user alone, without the files of
+// the project in workingDir.
+func loadGlobal(workingDir, dataDir string) (*Config, error) {
+	cfg, err := loadFromConfigPaths(globalConfigs())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the global configuration: %w", err)
+	}
+	cfg.setDefaults(workingDir, dataDir)
+	return cfg, nil
+}
+
+// AllowProjectCommands sets whether the settings that run commands come from
+// the configuration files of the project too, or only from the global ones:
+// the hooks, the MCP and LSP servers, the project commands and the
+// notification command. Projects only get to run commands in trusted
+// working directories, so that cloning a repository isn't enough for its
+// crush.json to run anything. The event lists what changed, with the MCP and
+// LSP servers to restart.
+func (c *Config) AllowProjectCommands(allow bool) (ReloadEvent, error) {
+	var event ReloadEvent
+	if c.untrusted == !allow {
+		return event, nil
+	}
+	var loaded *Config
+	var err error
+	if allow {
+		loaded, err = loadFromConfigPaths(lookupConfigs(c.workingDir))
+		if err == nil {
+			loaded.setDefaults(c.workingDir, c.Options.DataDirectory)
+		}
+	} else {
+		loaded, err = loadGlobal(c.workingDir, c.Options.DataDirectory)
+	}
+	if err != nil {
+		return event, err
+	}
+	c.untrusted = !allow
+	c.applyServers(loaded, &event)
+	if !reflect.DeepEqual(c.Hooks, loaded.Hooks) {
+		c.Hooks = loaded.Hooks
+		event.Reloaded = append(event.Reloaded, "hooks")
+	}
+	if c.Project != loaded.Project {
+		c.Project = loaded.Project
+		event.Reloaded = append(event.Reloaded, "project")
+	}
+	var command string
+	if loaded.Options.Notifications != nil {
+		command = loaded.Options.Notifications.Command
+	}
+	notifications := Notifications{Command: command}
+	if c.Options.Notifications != nil {
+		notifications.Bell = c.Options.Notifications.Bell
+		notifications.Desktop = c.Options.Notifications.Desktop
+	}
+	c.Options.Notifications = &notifications
+	return event, nil
+}
+
+// ProjectCommandsAllowed reports whether the settings that run commands come
+// from the configuration files of the project too.
+func (c *Config) ProjectCommandsAllowed() bool {
+	return !c.untrusted
+}
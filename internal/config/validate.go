@@ -0,0 +1,244 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/kaptinlin/jsonschema"
+)
+
+// Severity is how serious a configuration problem is.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a problem found in the configuration.
+type Diagnostic struct {
+	// File is the configuration file the problem is in, if any.
+	File string
+	// Line and Column locate the problem in File, starting at 1. They are 0
+	// when the problem isn't tied to a place in the file.
+	Line     int
+	Column   int
+	Severity Severity
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	switch {
+	case d.File == "":
+		return fmt.Sprintf("%s: %s", d.Severity, d.Message)
+	case d.Line == 0:
+		return fmt.Sprintf("%s: %s: %s", d.File, d.Severity, d.Message)
+	default:
+		return fmt.Sprintf("%s:%d:%d: %s: %s", d.File, d.Line, d.Column, d.Severity, d.Message)
+	}
+}
+
+// reportedKeywords are the schema keywords whose errors point at the value
+// that is wrong. Errors of the other keywords summarize those, or, like
+// required, don't apply to configuration files, which only set some fields.
+var reportedKeywords = []string{"type", "enum", "minimum", "maximum", "format"}
+
+var compiledSchema = sync.OnceValues(func() (*jsonschema.Schema, error) {
+	data, err := JSONSchema()
+	if err != nil {
+		return nil, err
+	}
+	return jsonschema.NewCompiler().Compile(data)
+})
+
+// ConfigFiles returns the configuration files [Load] reads for workingDir
+// that exist, from the lowest priority to the highest.
+func ConfigFiles(workingDir string) []string {
+	var files []string
+	for _, path := range lookupConfigs(workingDir) {
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// ValidateFile checks a configuration file against the configuration
+// schema. Invalid values are reported as errors and unknown keys as
+// warnings.
+func ValidateFile(path string) ([]Diagnostic, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return validateConfig(path, data)
+}
+
+func validateConfig(file string, data []byte) ([]Diagnostic, error) {
+	positions, offset, err := jsonPositions(data)
+	if err != nil {
+		line, column := lineColumn(data, offset)
+		return []Diagnostic{{
+			File:     file,
+			Line:     line,
+			Column:   column,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("invalid JSON: %v", err),
+		}}, nil
+	}
+
+	schema, err := compiledSchema()
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile configuration schema: %w", err)
+	}
+
+	var diags []Diagnostic
+	report := func(pointer string, err *jsonschema.EvaluationError) {
+		offset, ok := positions[pointer]
+		if !ok {
+			// The validator checks fields the file doesn't set as null.
+			return
+		}
+		d := Diagnostic{File: file, Severity: SeverityError}
+		d.Line, d.Column = lineColumn(data, offset)
+		switch {
+		case err.Keyword == "schema":
+			// Only keys not in the schema are matched against a false schema.
+			d.Severity = SeverityWarning
+			d.Message = fmt.Sprintf("unknown key %s", pointerPath(pointer))
+		case slices.Contains(reportedKeywords, err.Keyword):
+			d.Message = fmt.Sprintf("%s: %s", pointerPath(pointer), err.Error())
+		default:
+			return
+		}
+		diags = append(diags, d)
+	}
+	collectErrors(schema.ValidateJSON(data), "", report)
+
+	slices.SortStableFunc(diags, func(a, b Diagnostic) int {
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		return a.Column - b.Column
+	})
+	return diags, nil
+}
+
+// collectErrors calls report with the JSON pointer of the value and the
+// error for each error in the evaluation result.
+func collectErrors(result *jsonschema.EvaluationResult, pointer string, report func(string, *jsonschema.EvaluationError)) {
+	pointer += result.InstanceLocation
+	for _, key := range slices.Sorted(maps.Keys(result.Errors)) {
+		report(pointer, result.Errors[key])
+	}
+	for _, detail := range result.Details {
+		if !detail.IsValid() {
+			collectErrors(detail, pointer, report)
+		}
+	}
+}
+
+// jsonPositions maps the JSON pointer of every value in data to the offset
+// it starts at; for object members, that's the offset of the key. On syntax
+// errors, the offset of the error is returned.
+func jsonPositions(data []byte) (map[string]int, int, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	positions := map[string]int{}
+
+	var walk func(pointer string) error
+	walk = func(pointer string) error {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		delim, ok := tok.(json.Delim)
+		if !ok {
+			return nil
+		}
+		for i := 0; dec.More(); i++ {
+			offset := skipSeparators(data, int(dec.InputOffset()))
+			var child string
+			if delim == '{' {
+				tok, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				key, _ := tok.(string)
+				child = pointer + "/" + escapePointer(key)
+			} else {
+				child = pointer + "/" + strconv.Itoa(i)
+			}
+			positions[child] = offset
+			if err := walk(child); err != nil {
+				return err
+			}
+		}
+		// The closing delimiter.
+		_, err = dec.Token()
+		return err
+	}
+
+	positions[""] = skipSeparators(data, 0)
+	err := walk("")
+	if err == nil {
+		if _, err = dec.Token(); err == io.EOF {
+			return positions, 0, nil
+		} else if err == nil {
+			err = errors.New("unexpected data after the configuration")
+		}
+	}
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	offset := int(dec.InputOffset())
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		offset = int(syntaxErr.Offset)
+	}
+	return nil, offset, err
+}
+
+// skipSeparators returns the offset of the first token at or after offset.
+func skipSeparators(data []byte, offset int) int {
+	for offset < len(data) && strings.IndexByte(" \t\r\n,:", data[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+// lineColumn returns the line and column, starting at 1, of offset in data.
+func lineColumn(data []byte, offset int) (int, int) {
+	offset = min(offset, len(data))
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	lineStart := bytes.LastIndexByte(before, '\n') + 1
+	return line, utf8.RuneCount(before[lineStart:]) + 1
+}
+
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+// pointerPath turns a JSON pointer into the dotted path used in the docs,
+// e.g. /options/tui/compact_mode into options.tui.compact_mode.
+func pointerPath(pointer string) string {
+	if pointer == "" {
+		return "configuration"
+	}
+	parts := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, part := range parts {
+		parts[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(part)
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "crush.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+  "$schema": "https://charm.land/crush.json",
+  "options": {
+    "debug": "yes",
+    "contxt_paths": ["NOTES.md"],
+    "tui": {"compact_mode": true}
+  },
+  "providers": {
+    "local": {"type": "nope", "base_url": "http://localhost:8080/v1"}
+  }
+}`), 0o644))
+
+	diags, err := ValidateFile(path)
+	require.NoError(t, err)
+	require.Equal(t, []Diagnostic{
+		{File: path, Line: 4, Column: 5, Severity: SeverityError, Message: "options.debug: Value is string but should be boolean"},
+		{File: path, Line: 5, Column: 5, Severity: SeverityWarning, Message: "unknown key options.contxt_paths"},
+		{File: path, Line: 9, Column: 15, Severity: SeverityError, Message: "providers.local.type: Value nope should be one of the allowed values: openai, openai-compat, anthropic, gemini, azure, vertexai, copilot"},
+	}, diags)
+	require.Equal(t, path+":4:5: error: options.debug: Value is string but should be boolean", diags[0].String())
+}
+
+func TestValidateFileSyntaxError(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "crush.json")
+	require.NoError(t, os.WriteFile(path, []byte("{\n  \"options\": {\n    \"debug\": true,\n  }\n}\n"), 0o644))
+
+	diags, err := ValidateFile(path)
+	require.NoError(t, err)
+	require.Len(t, diags, 1)
+	require.Equal(t, SeverityError, diags[0].Severity)
+	require.Equal(t, 4, diags[0].Line)
+	require.Contains(t, diags[0].Message, "invalid JSON")
+}
@@ -4,3 +4,6 @@ import "embed"
 
 //go:embed migrations/*.sql
 var FS embed.FS
+
+//go:embed migrations_postgres/*.sql
+var postgresFS embed.FS
@@ -0,0 +1,283 @@
+package db
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// sealedPrefix marks message parts encrypted by a [Cipher].
+const sealedPrefix = "enc:v1:"
+
+const (
+	// KeySize is the size of the raw keys accepted by [NewKeyCipher].
+	KeySize = 32
+
+	saltSize         = 16
+	pbkdf2Iterations = 600_000
+)
+
+// ErrDecrypt is returned when stored content can't be decrypted, usually
+// because the passphrase or key changed.
+var ErrDecrypt = errors.New("failed to decrypt stored message, check your storage passphrase or key")
+
+// Cipher encrypts message content at rest with AES-256-GCM.
+//
+// Its key is derived once, from the passphrase or the random key and the
+// salt of the database, and every sealed value has a random nonce of its
+// own.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewPassphraseCipher returns a [Cipher] whose key is derived from
+// passphrase and the salt of the database with PBKDF2.
+func NewPassphraseCipher(passphrase string, salt []byte) (*Cipher, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase is empty")
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, pbkdf2Iterations, KeySize)
+	if err != nil {
+		return nil, err
+	}
+	return newCipher(key)
+}
+
+// NewKeyCipher returns a [Cipher] whose key is derived from a random key,
+// such as one kept in the OS keychain, and the salt of the database.
+func NewKeyCipher(key, salt []byte) (*Cipher, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", KeySize, len(key))
+	}
+	derived, err := hkdf.Key(sha256.New, key, salt, "crush storage", KeySize)
+	if err != nil {
+		return nil, err
+	}
+	return newCipher(derived)
+}
+
+func newCipher(key []byte) (*Cipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// EncryptionSalt returns the salt the keys of the database of q are derived
+// with, creating it the first time.
+func EncryptionSalt(ctx context.Context, q Querier) ([]byte, error) {
+	encoded, err := q.GetEncryptionSalt(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		salt := make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		// Another instance may create one at the same time, keep the one
+		// that made it.
+		if err := q.CreateEncryptionSalt(ctx, base64.StdEncoding.EncodeToString(salt)); err != nil {
+			return nil, fmt.Errorf("failed to save the encryption salt: %w", err)
+		}
+		encoded, err = q.GetEncryptionSalt(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the encryption salt: %w", err)
+	}
+	salt, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(salt) != saltSize {
+		return nil, fmt.Errorf("invalid encryption salt in the database")
+	}
+	return salt, nil
+}
+
+// IsSealed reports whether value was encrypted by a [Cipher].
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, sealedPrefix)
+}
+
+// Seal encrypts plaintext. Values that are already sealed are returned as
+// is.
+func (c *Cipher) Seal(plaintext string) (string, error) {
+	if IsSealed(plaintext) {
+		return plaintext, nil
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	out := make([]byte, 0, len(nonce)+len(plaintext)+c.aead.Overhead())
+	out = append(out, nonce...)
+	out = c.aead.Seal(out, nonce, []byte(plaintext), nil)
+	return sealedPrefix + base64.StdEncoding.EncodeToString(out), nil
+}
+
+// Open decrypts a value sealed by [Cipher.Seal]. Values that aren't sealed
+// are returned as is, so unencrypted rows keep working.
+func (c *Cipher) Open(value string) (string, error) {
+	if !IsSealed(value) {
+		return value, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, sealedPrefix))
+	if err != nil || len(data) < c.aead.NonceSize() {
+		return "", ErrDecrypt
+	}
+	nonce, data := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, data, nil)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return string(plaintext), nil
+}
+
+// Sealer is implemented by stores that encrypt message content, so content
+// persisted outside the store, like the message write-ahead log or the
+// prompt history, can be encrypted the same way.
+type Sealer interface {
+	Seal(plaintext string) (string, error)
+	Open(value string) (string, error)
+}
+
+// encryptedStore encrypts message parts on write and decrypts them on read.
+type encryptedStore struct {
+	Store
+	cipher *Cipher
+}
+
+// NewEncryptedStore wraps store so message content is encrypted at rest
+// with c.
+func NewEncryptedStore(store Store, c *Cipher) Store {
+	return &encryptedStore{Store: store, cipher: c}
+}
+
+func (s *encryptedStore) Seal(plaintext string) (string, error) {
+	return s.cipher.Seal(plaintext)
+}
+
+func (s *encryptedStore) Open(value string) (string, error) {
+	return s.cipher.Open(value)
+}
+
+func (s *encryptedStore) open(msg Message) (Message, error) {
+	parts, err := s.cipher.Open(msg.Parts)
+	if err != nil {
+		return Message{}, err
+	}
+	msg.Parts = parts
+	return msg, nil
+}
+
+func (s *encryptedStore) openAll(msgs []Message) ([]Message, error) {
+	for i, msg := range msgs {
+		opened, err := s.open(msg)
+		if err != nil {
+			return nil, err
+		}
+		msgs[i] = opened
+	}
+	return msgs, nil
+}
+
+func (s *encryptedStore) CreateMessage(ctx context.Context, arg CreateMessageParams) (Message, error) {
+	parts, err := s.cipher.Seal(arg.Parts)
+	if err != nil {
+		return Message{}, err
+	}
+	arg.Parts = parts
+	msg, err := s.Store.CreateMessage(ctx, arg)
+	if err != nil {
+		return Message{}, err
+	}
+	return s.open(msg)
+}
+
+func (s *encryptedStore) UpdateMessage(ctx context.Context, arg UpdateMessageParams) error {
+	parts, err := s.cipher.Seal(arg.Parts)
+	if err != nil {
+		return err
+	}
+	arg.Parts = parts
+	return s.Store.UpdateMessage(ctx, arg)
+}
+
+func (s *encryptedStore) GetMessage(ctx context.Context, id string) (Message, error) {
+	msg, err := s.Store.GetMessage(ctx, id)
+	if err != nil {
+		return Message{}, err
+	}
+	return s.open(msg)
+}
+
+func (s *encryptedStore) ListMessagesBySession(ctx context.Context, sessionID string) ([]Message, error) {
+	msgs, err := s.Store.ListMessagesBySession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return s.openAll(msgs)
+}
+
+func (s *encryptedStore) ListLastMessagesBySession(ctx context.Context, arg ListLastMessagesBySessionParams) ([]Message, error) {
+	msgs, err := s.Store.ListLastMessagesBySession(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	return s.openAll(msgs)
+}
+
+func (s *encryptedStore) ListAllMessages(ctx context.Context) ([]Message, error) {
+	msgs, err := s.Store.ListAllMessages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.openAll(msgs)
+}
+
+// EncryptMessages encrypts every stored message that isn't encrypted yet and
+// returns how many were changed. q must not be an encrypted store.
+func EncryptMessages(ctx context.Context, q Querier, c *Cipher) (int, error) {
+	return rewriteMessages(ctx, q, func(m Message) bool { return !IsSealed(m.Parts) }, c.Seal)
+}
+
+// DecryptMessages decrypts every stored message that's encrypted and returns
+// how many were changed. q must not be an encrypted store.
+func DecryptMessages(ctx context.Context, q Querier, c *Cipher) (int, error) {
+	return rewriteMessages(ctx, q, func(m Message) bool { return IsSealed(m.Parts) }, c.Open)
+}
+
+func rewriteMessages(ctx context.Context, q Querier, match func(Message) bool, rewrite func(string) (string, error)) (int, error) {
+	msgs, err := q.ListAllMessages(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	for _, msg := range msgs {
+		if !match(msg) {
+			continue
+		}
+		parts, err := rewrite(msg.Parts)
+		if err != nil {
+			return count, fmt.Errorf("message %s: %w", msg.ID, err)
+		}
+		if err := q.UpdateMessage(ctx, UpdateMessageParams{
+			ID:         msg.ID,
+			Parts:      parts,
+			FinishedAt: msg.FinishedAt,
+		}); err != nil {
+			return count, fmt.Errorf("message %s: %w", msg.ID, err)
+		}
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,139 @@
+package db
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCipher(t *testing.T) *Cipher {
+	t.Helper()
+	key := make([]byte, KeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	c, err := NewKeyCipher(key, testSalt(t))
+	require.NoError(t, err)
+	return c
+}
+
+func testSalt(t *testing.T) []byte {
+	t.Helper()
+	salt := make([]byte, saltSize)
+	_, err := rand.Read(salt)
+	require.NoError(t, err)
+	return salt
+}
+
+func TestCipher(t *testing.T) {
+	t.Parallel()
+
+	c := newTestCipher(t)
+	sealed, err := c.Seal(`[{"type":"text"}]`)
+	require.NoError(t, err)
+	require.True(t, IsSealed(sealed))
+	require.NotContains(t, sealed, "text")
+
+	again, err := c.Seal(sealed)
+	require.NoError(t, err)
+	require.Equal(t, sealed, again, "sealing is idempotent")
+
+	opened, err := c.Open(sealed)
+	require.NoError(t, err)
+	require.Equal(t, `[{"type":"text"}]`, opened)
+
+	plain, err := c.Open("[]")
+	require.NoError(t, err)
+	require.Equal(t, "[]", plain, "unencrypted values pass through")
+
+	_, err = newTestCipher(t).Open(sealed)
+	require.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestPassphraseCipher(t *testing.T) {
+	t.Parallel()
+
+	salt := testSalt(t)
+	c, err := NewPassphraseCipher("correct horse", salt)
+	require.NoError(t, err)
+	sealed, err := c.Seal("secret")
+	require.NoError(t, err)
+	again, err := c.Seal("secret")
+	require.NoError(t, err)
+	require.NotEqual(t, sealed, again, "every value has a nonce of its own")
+
+	// A new run derives the same key from the salt of the database.
+	c2, err := NewPassphraseCipher("correct horse", salt)
+	require.NoError(t, err)
+	opened, err := c2.Open(sealed)
+	require.NoError(t, err)
+	require.Equal(t, "secret", opened)
+
+	wrong, err := NewPassphraseCipher("battery staple", salt)
+	require.NoError(t, err)
+	_, err = wrong.Open(sealed)
+	require.ErrorIs(t, err, ErrDecrypt)
+
+	otherDB, err := NewPassphraseCipher("correct horse", testSalt(t))
+	require.NoError(t, err)
+	_, err = otherDB.Open(sealed)
+	require.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestEncryptionSalt(t *testing.T) {
+	t.Parallel()
+
+	store, err := Open(t.Context(), StoreOptions{DataDir: t.TempDir()})
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+
+	salt, err := EncryptionSalt(t.Context(), store)
+	require.NoError(t, err)
+	require.Len(t, salt, saltSize)
+	again, err := EncryptionSalt(t.Context(), store)
+	require.NoError(t, err)
+	require.Equal(t, salt, again, "the database keeps its salt")
+}
+
+func TestEncryptedStore(t *testing.T) {
+	t.Parallel()
+
+	plain, err := Open(t.Context(), StoreOptions{DataDir: t.TempDir()})
+	require.NoError(t, err)
+	t.Cleanup(func() { plain.Close() })
+
+	ctx := t.Context()
+	_, err = plain.CreateSession(ctx, CreateSessionParams{ID: "session", Title: "test"})
+	require.NoError(t, err)
+	_, err = plain.CreateMessage(ctx, CreateMessageParams{ID: "old", SessionID: "session", Role: "user", Parts: "[]"})
+	require.NoError(t, err)
+
+	c := newTestCipher(t)
+	store := NewEncryptedStore(plain, c)
+	msg, err := store.CreateMessage(ctx, CreateMessageParams{ID: "new", SessionID: "session", Role: "user", Parts: `["hello"]`})
+	require.NoError(t, err)
+	require.Equal(t, `["hello"]`, msg.Parts)
+
+	raw, err := plain.GetMessage(ctx, "new")
+	require.NoError(t, err)
+	require.True(t, IsSealed(raw.Parts))
+
+	// Messages stored before encryption was enabled are still readable.
+	msgs, err := store.ListMessagesBySession(ctx, "session")
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+
+	count, err := EncryptMessages(ctx, plain, c)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+	raw, err = plain.GetMessage(ctx, "old")
+	require.NoError(t, err)
+	require.True(t, IsSealed(raw.Parts))
+
+	count, err = DecryptMessages(ctx, plain, c)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	raw, err = plain.GetMessage(ctx, "new")
+	require.NoError(t, err)
+	require.Equal(t, `["hello"]`, raw.Parts)
+}
@@ -107,6 +107,99 @@ func (q *Queries) GetMessage(ctx context.Context, id string) (Message, error) {
 	return i, err
 }
 
+const listAllMessages = `-- name: ListAllMessages :many
+SELECT id, session_id, role, parts, model, created_at, updated_at, finished_at, provider, is_summary_message
+FROM messages
+ORDER BY created_at ASC
+`
+
+func (q *Queries) ListAllMessages(ctx context.Context) ([]Message, error) {
+	rows, err := q.query(ctx, q.listAllMessagesStmt, listAllMessages)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Message{}
+	for rows.Next() {
+		var i Message
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.Role,
+			&i.Parts,
+			&i.Model,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.FinishedAt,
+			&i.Provider,
+			&i.IsSummaryMessage,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLastMessagesBySession = `-- name: ListLastMessagesBySession :many
+SELECT id, session_id, role, parts, model, created_at, updated_at, finished_at, provider, is_summary_message
+FROM messages
+WHERE session_id = ? AND created_at >= COALESCE((
+    SELECT created_at
+    FROM messages
+    WHERE session_id = ?
+    ORDER BY created_at DESC
+    LIMIT 1 OFFSET ?
+), 0)
+ORDER BY created_at ASC
+`
+
+type ListLastMessagesBySessionParams struct {
+	SessionID   string `json:"session_id"`
+	SessionID_2 string `json:"session_id_2"`
+	Offset      int64  `json:"offset"`
+}
+
+func (q *Queries) ListLastMessagesBySession(ctx context.Context, arg ListLastMessagesBySessionParams) ([]Message, error) {
+	rows, err := q.query(ctx, q.listLastMessagesBySessionStmt, listLastMessagesBySession, arg.SessionID, arg.SessionID_2, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Message{}
+	for rows.Next() {
+		var i Message
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.Role,
+			&i.Parts,
+			&i.Model,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.FinishedAt,
+			&i.Provider,
+			&i.IsSummaryMessage,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listMessagesBySession = `-- name: ListMessagesBySession :many
 SELECT id, session_id, role, parts, model, created_at, updated_at, finished_at, provider, is_summary_message
 FROM messages
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE sessions ADD COLUMN tags TEXT;
+
+-- +goose Down
+ALTER TABLE sessions DROP COLUMN tags;
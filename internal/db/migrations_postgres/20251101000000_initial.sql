@@ -0,0 +1,99 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Column order must match the SQLite schema, since the generated queries
+-- scan rows positionally.
+
+-- Sessions
+CREATE TABLE IF NOT EXISTS sessions (
+    id TEXT PRIMARY KEY,
+    parent_session_id TEXT,
+    title TEXT NOT NULL,
+    message_count BIGINT NOT NULL DEFAULT 0 CHECK (message_count >= 0),
+    prompt_tokens BIGINT NOT NULL DEFAULT 0 CHECK (prompt_tokens >= 0),
+    completion_tokens BIGINT NOT NULL DEFAULT 0 CHECK (completion_tokens >= 0),
+    cost DOUBLE PRECISION NOT NULL DEFAULT 0.0 CHECK (cost >= 0.0),
+    updated_at BIGINT NOT NULL,  -- Unix timestamp in seconds
+    created_at BIGINT NOT NULL,  -- Unix timestamp in seconds
+    summary_message_id TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_created_at ON sessions (created_at);
+
+-- Files
+CREATE TABLE IF NOT EXISTS files (
+    id TEXT PRIMARY KEY,
+    session_id TEXT NOT NULL,
+    path TEXT NOT NULL,
+    content TEXT NOT NULL,
+    version BIGINT NOT NULL DEFAULT 0,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    FOREIGN KEY (session_id) REFERENCES sessions (id) ON DELETE CASCADE,
+    UNIQUE (path, session_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_files_session_id ON files (session_id);
+CREATE INDEX IF NOT EXISTS idx_files_path ON files (path);
+CREATE INDEX IF NOT EXISTS idx_files_created_at ON files (created_at);
+
+-- Messages
+CREATE TABLE IF NOT EXISTS messages (
+    id TEXT PRIMARY KEY,
+    session_id TEXT NOT NULL,
+    role TEXT NOT NULL,
+    parts TEXT NOT NULL DEFAULT '[]',
+    model TEXT,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    finished_at BIGINT,
+    provider TEXT,
+    is_summary_message BIGINT NOT NULL DEFAULT 0,
+    FOREIGN KEY (session_id) REFERENCES sessions (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_messages_session_id ON messages (session_id);
+CREATE INDEX IF NOT EXISTS idx_messages_created_at ON messages (created_at);
+
+CREATE OR REPLACE FUNCTION crush_set_updated_at() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = CAST(EXTRACT(EPOCH FROM NOW()) AS BIGINT);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER update_sessions_updated_at
+BEFORE UPDATE ON sessions
+FOR EACH ROW EXECUTE FUNCTION crush_set_updated_at();
+
+CREATE TRIGGER update_files_updated_at
+BEFORE UPDATE ON files
+FOR EACH ROW EXECUTE FUNCTION crush_set_updated_at();
+
+CREATE TRIGGER update_messages_updated_at
+BEFORE UPDATE ON messages
+FOR EACH ROW EXECUTE FUNCTION crush_set_updated_at();
+
+CREATE OR REPLACE FUNCTION crush_update_session_message_count() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE sessions SET message_count = message_count + 1 WHERE id = NEW.session_id;
+    ELSE
+        UPDATE sessions SET message_count = message_count - 1 WHERE id = OLD.session_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER update_session_message_count
+AFTER INSERT OR DELETE ON messages
+FOR EACH ROW EXECUTE FUNCTION crush_update_session_message_count();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS messages;
+DROP TABLE IF EXISTS files;
+DROP TABLE IF EXISTS sessions;
+DROP FUNCTION IF EXISTS crush_update_session_message_count;
+DROP FUNCTION IF EXISTS crush_set_updated_at;
+-- +goose StatementEnd
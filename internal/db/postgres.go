@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+)
+
+// ConnectPostgres opens a connection to the Postgres database at dsn and
+// applies any pending migrations.
+func ConnectPostgres(ctx context.Context, dsn string) (*sql.DB, error) {
+	if dsn == "" {
+		return nil, fmt.Errorf("options.storage.dsn is not set")
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// Verify connection
+	if err = db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	goose.SetBaseFS(postgresFS)
+
+	if err := goose.SetDialect("postgres"); err != nil {
+		slog.Error("Failed to set dialect", "error", err)
+		db.Close()
+		return nil, fmt.Errorf("failed to set dialect: %w", err)
+	}
+
+	if err := goose.Up(db, "migrations_postgres"); err != nil {
+		slog.Error("Failed to apply migrations", "error", err)
+		db.Close()
+		return nil, fmt.Errorf("failed to apply migrations: %w", err)
+	}
+
+	return db, nil
+}
+
+// postgresDB runs the SQLite flavored queries generated by sqlc against
+// Postgres, translating them on the fly.
+type postgresDB struct {
+	db *sql.DB
+}
+
+func (p postgresDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return p.db.ExecContext(ctx, toPostgres(query), args...)
+}
+
+func (p postgresDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return p.db.PrepareContext(ctx, toPostgres(query))
+}
+
+func (p postgresDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return p.db.QueryContext(ctx, toPostgres(query), args...)
+}
+
+func (p postgresDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return p.db.QueryRowContext(ctx, toPostgres(query), args...)
+}
+
+// sqliteNow is how the queries get the current Unix time in SQLite.
+const sqliteNow = "strftime('%s', 'now')"
+
+// toPostgres rewrites a SQLite query for Postgres: `?` placeholders become
+// numbered `$n` ones, and SQLite's time functions become their Postgres
+// equivalent.
+func toPostgres(query string) string {
+	query = strings.ReplaceAll(query, sqliteNow, "CAST(EXTRACT(EPOCH FROM NOW()) AS BIGINT)")
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n := 0
+	inString := false
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			inString = !inString
+		case r == '?' && !inString:
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// IsUniqueViolation reports whether err was caused by a unique constraint
+// violation, regardless of the storage backend.
+func IsUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "23505"
+	}
+	return strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToPostgres(t *testing.T) {
+	t.Parallel()
+
+	got := toPostgres(`UPDATE messages
+SET
+    parts = ?,
+    finished_at = ?,
+    updated_at = strftime('%s', 'now')
+WHERE id = ? AND role != '?'`)
+	require.Equal(t, `UPDATE messages
+SET
+    parts = $1,
+    finished_at = $2,
+    updated_at = CAST(EXTRACT(EPOCH FROM NOW()) AS BIGINT)
+WHERE id = $3 AND role != '?'`, got)
+}
+
+func TestIsUniqueViolation(t *testing.T) {
+	t.Parallel()
+
+	require.False(t, IsUniqueViolation(nil))
+	require.True(t, IsUniqueViolation(errors.New("constraint failed: UNIQUE constraint failed: files.path")))
+	require.True(t, IsUniqueViolation(&pgconn.PgError{Code: "23505"}))
+	require.False(t, IsUniqueViolation(&pgconn.PgError{Code: "23503"}))
+}
+
+func TestOpenSQLite(t *testing.T) {
+	t.Parallel()
+
+	store, err := Open(t.Context(), StoreOptions{DataDir: t.TempDir()})
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+
+	_, err = store.CreateSession(t.Context(), CreateSessionParams{ID: "session", Title: "test"})
+	require.NoError(t, err)
+
+	_, err = Open(t.Context(), StoreOptions{Driver: "mongodb"})
+	require.Error(t, err)
+}
@@ -33,7 +33,7 @@ INSERT INTO sessions (
     null,
     strftime('%s', 'now'),
     strftime('%s', 'now')
-) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id
+) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, input_tokens, cache_read_tokens, cache_creation_tokens, tags
 `
 
 type CreateSessionParams struct {
@@ -68,6 +68,11 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		&i.UpdatedAt,
 		&i.CreatedAt,
 		&i.SummaryMessageID,
+		&i.Todos,
+		&i.InputTokens,
+		&i.CacheReadTokens,
+		&i.CacheCreationTokens,
+		&i.Tags,
 	)
 	return i, err
 }
@@ -83,7 +88,7 @@ func (q *Queries) DeleteSession(ctx context.Context, id string) error {
 }
 
 const getSessionByID = `-- name: GetSessionByID :one
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, input_tokens, cache_read_tokens, cache_creation_tokens, tags
 FROM sessions
 WHERE id = ? LIMIT 1
 `
@@ -102,12 +107,17 @@ func (q *Queries) GetSessionByID(ctx context.Context, id string) (Session, error
 		&i.UpdatedAt,
 		&i.CreatedAt,
 		&i.SummaryMessageID,
+		&i.Todos,
+		&i.InputTokens,
+		&i.CacheReadTokens,
+		&i.CacheCreationTokens,
+		&i.Tags,
 	)
 	return i, err
 }
 
 const listSessions = `-- name: ListSessions :many
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, input_tokens, cache_read_tokens, cache_creation_tokens, tags
 FROM sessions
 WHERE parent_session_id is NULL
 ORDER BY created_at DESC
@@ -133,6 +143,11 @@ func (q *Queries) ListSessions(ctx context.Context) ([]Session, error) {
 			&i.UpdatedAt,
 			&i.CreatedAt,
 			&i.SummaryMessageID,
+			&i.Todos,
+			&i.InputTokens,
+			&i.CacheReadTokens,
+			&i.CacheCreationTokens,
+			&i.Tags,
 		); err != nil {
 			return nil, err
 		}
@@ -154,18 +169,24 @@ SET
     prompt_tokens = ?,
     completion_tokens = ?,
     summary_message_id = ?,
-    cost = ?
+    cost = ?,
+    input_tokens = ?,
+    cache_read_tokens = ?,
+    cache_creation_tokens = ?
 WHERE id = ?
-RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, input_tokens, cache_read_tokens, cache_creation_tokens, tags
 `
 
 type UpdateSessionParams struct {
-	Title            string         `json:"title"`
-	PromptTokens     int64          `json:"prompt_tokens"`
-	CompletionTokens int64          `json:"completion_tokens"`
-	SummaryMessageID sql.NullString `json:"summary_message_id"`
-	Cost             float64        `json:"cost"`
-	ID               string         `json:"id"`
+	Title               string         `json:"title"`
+	PromptTokens        int64          `json:"prompt_tokens"`
+	CompletionTokens    int64          `json:"completion_tokens"`
+	SummaryMessageID    sql.NullString `json:"summary_message_id"`
+	Cost                float64        `json:"cost"`
+	InputTokens         int64          `json:"input_tokens"`
+	CacheReadTokens     int64          `json:"cache_read_tokens"`
+	CacheCreationTokens int64          `json:"cache_creation_tokens"`
+	ID                  string         `json:"id"`
 }
 
 func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (Session, error) {
@@ -175,6 +196,9 @@ func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (S
 		arg.CompletionTokens,
 		arg.SummaryMessageID,
 		arg.Cost,
+		arg.InputTokens,
+		arg.CacheReadTokens,
+		arg.CacheCreationTokens,
 		arg.ID,
 	)
 	var i Session
@@ -189,6 +213,83 @@ func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (S
 		&i.UpdatedAt,
 		&i.CreatedAt,
 		&i.SummaryMessageID,
+		&i.Todos,
+		&i.InputTokens,
+		&i.CacheReadTokens,
+		&i.CacheCreationTokens,
+		&i.Tags,
+	)
+	return i, err
+}
+
+const updateSessionTags = `-- name: UpdateSessionTags :one
+UPDATE sessions
+SET
+    tags = ?
+WHERE id = ?
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, input_tokens, cache_read_tokens, cache_creation_tokens, tags
+`
+
+type UpdateSessionTagsParams struct {
+	Tags sql.NullString `json:"tags"`
+	ID   string         `json:"id"`
+}
+
+func (q *Queries) UpdateSessionTags(ctx context.Context, arg UpdateSessionTagsParams) (Session, error) {
+	row := q.queryRow(ctx, q.updateSessionTagsStmt, updateSessionTags, arg.Tags, arg.ID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.ParentSessionID,
+		&i.Title,
+		&i.MessageCount,
+		&i.PromptTokens,
+		&i.CompletionTokens,
+		&i.Cost,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.SummaryMessageID,
+		&i.Todos,
+		&i.InputTokens,
+		&i.CacheReadTokens,
+		&i.CacheCreationTokens,
+		&i.Tags,
+	)
+	return i, err
+}
+
+const updateSessionTodos = `-- name: UpdateSessionTodos :one
+UPDATE sessions
+SET
+    todos = ?
+WHERE id = ?
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, input_tokens, cache_read_tokens, cache_creation_tokens, tags
+`
+
+type UpdateSessionTodosParams struct {
+	Todos sql.NullString `json:"todos"`
+	ID    string         `json:"id"`
+}
+
+func (q *Queries) UpdateSessionTodos(ctx context.Context, arg UpdateSessionTodosParams) (Session, error) {
+	row := q.queryRow(ctx, q.updateSessionTodosStmt, updateSessionTodos, arg.Todos, arg.ID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.ParentSessionID,
+		&i.Title,
+		&i.MessageCount,
+		&i.PromptTokens,
+		&i.CompletionTokens,
+		&i.Cost,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.SummaryMessageID,
+		&i.Todos,
+		&i.InputTokens,
+		&i.CacheReadTokens,
+		&i.CacheCreationTokens,
+		&i.Tags,
 	)
 	return i, err
 }
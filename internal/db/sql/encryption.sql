@@ -0,0 +1,9 @@
+-- name: GetEncryptionSalt :one
+SELECT salt
+FROM encryption
+WHERE id = 1;
+
+-- name: CreateEncryptionSalt :exec
+INSERT INTO encryption (id, salt)
+VALUES (1, ?)
+ON CONFLICT (id) DO NOTHING;
@@ -9,6 +9,23 @@ FROM messages
 WHERE session_id = ?
 ORDER BY created_at ASC;
 
+-- name: ListLastMessagesBySession :many
+SELECT *
+FROM messages
+WHERE session_id = ? AND created_at >= COALESCE((
+    SELECT created_at
+    FROM messages
+    WHERE session_id = ?
+    ORDER BY created_at DESC
+    LIMIT 1 OFFSET ?
+), 0)
+ORDER BY created_at ASC;
+
+-- name: ListAllMessages :many
+SELECT *
+FROM messages
+ORDER BY created_at ASC;
+
 -- name: CreateMessage :one
 INSERT INTO messages (
     id,
@@ -41,10 +41,26 @@ SET
     prompt_tokens = ?,
     completion_tokens = ?,
     summary_message_id = ?,
-    cost = ?
+    cost = ?,
+    input_tokens = ?,
+    cache_read_tokens = ?,
+    cache_creation_tokens = ?
 WHERE id = ?
 RETURNING *;
 
+-- name: UpdateSessionTags :one
+UPDATE sessions
+SET
+    tags = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: UpdateSessionTodos :one
+UPDATE sessions
+SET
+    todos = ?
+WHERE id = ?
+RETURNING *;
 
 -- name: DeleteSession :exec
 DELETE FROM sessions
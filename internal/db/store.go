@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Storage drivers supported by [Open].
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+// Store is a storage backend for sessions, messages, and file history.
+type Store interface {
+	Querier
+	Close() error
+}
+
+// StoreOptions configures which storage backend [Open] connects to.
+type StoreOptions struct {
+	// Driver is one of [DriverSQLite] or [DriverPostgres]. Empty means
+	// SQLite.
+	Driver string
+	// DSN is the connection string, used by the Postgres driver.
+	DSN string
+	// DataDir is where the SQLite database lives.
+	DataDir string
+}
+
+type store struct {
+	*Queries
+	conn *sql.DB
+}
+
+// Open connects to the configured storage backend and applies any pending
+// migrations.
+func Open(ctx context.Context, opts StoreOptions) (Store, error) {
+	switch opts.Driver {
+	case "", DriverSQLite:
+		conn, err := Connect(ctx, opts.DataDir)
+		if err != nil {
+			return nil, err
+		}
+		return &store{Queries: New(conn), conn: conn}, nil
+	case DriverPostgres:
+		conn, err := ConnectPostgres(ctx, opts.DSN)
+		if err != nil {
+			return nil, err
+		}
+		return &store{Queries: New(postgresDB{conn}), conn: conn}, nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %q", opts.Driver)
+	}
+}
+
+func (s *store) Close() error {
+	return errors.Join(s.Queries.Close(), s.conn.Close())
+}
@@ -0,0 +1,63 @@
+package devcontainer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Choice is whether the user wants the tools of a project to run in its
+// devcontainer.
+type Choice string
+
+const (
+	// Unasked projects get asked the first time the app runs in them.
+	Unasked Choice = ""
+	Use     Choice = "use"
+	Ignore  Choice = "ignore"
+)
+
+type choiceFile struct {
+	Choice Choice `json:"choice"`
+}
+
+func choicePath(dataDir string) string {
+	return filepath.Join(dataDir, "devcontainer.json")
+}
+
+// LoadChoice returns the choice saved in the data directory of a project.
+func LoadChoice(dataDir string) (Choice, error) {
+	data, err := os.ReadFile(choicePath(dataDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return Unasked, nil
+	}
+	if err != nil {
+		return Unasked, fmt.Errorf("failed to read devcontainer choice: %w", err)
+	}
+	var file choiceFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return Unasked, fmt.Errorf("failed to read devcontainer choice: %w", err)
+	}
+	switch file.Choice {
+	case Use, Ignore:
+		return file.Choice, nil
+	}
+	return Unasked, nil
+}
+
+// SaveChoice saves choice in the data directory of a project.
+func SaveChoice(dataDir string, choice Choice) error {
+	data, err := json.Marshal(choiceFile{Choice: choice})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dataDir, 0o700); err != nil {
+		return fmt.Errorf("failed to save devcontainer choice: %w", err)
+	}
+	if err := os.WriteFile(choicePath(dataDir), data, 0o600); err != nil {
+		return fmt.Errorf("failed to save devcontainer choice: %w", err)
+	}
+	return nil
+}
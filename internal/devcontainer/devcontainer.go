@@ -0,0 +1,165 @@
+// Package devcontainer finds the development container a project declares
+// in a .devcontainer configuration, and the running container of it, so
+// that tools can run inside it while the app stays on the host.
+package devcontainer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Config is the part of a devcontainer.json that tools need.
+type Config struct {
+	// Path is the devcontainer.json file.
+	Path            string
+	Name            string `json:"name"`
+	WorkspaceFolder string `json:"workspaceFolder"`
+	RemoteUser      string `json:"remoteUser"`
+	ContainerUser   string `json:"containerUser"`
+}
+
+// User returns the user commands run as in the container, or "" for the
+// default one of the image.
+func (c *Config) User() string {
+	if c.RemoteUser != "" {
+		return c.RemoteUser
+	}
+	return c.ContainerUser
+}
+
+// Find returns the devcontainer configuration of the project in dir, or nil
+// when it has none. Like other devcontainer tools, it looks for
+// .devcontainer/devcontainer.json, .devcontainer.json and then the first
+// .devcontainer/<name>/devcontainer.json.
+func Find(dir string) (*Config, error) {
+	candidates := []string{
+		filepath.Join(dir, ".devcontainer", "devcontainer.json"),
+		filepath.Join(dir, ".devcontainer.json"),
+	}
+	nested, _ := filepath.Glob(filepath.Join(dir, ".devcontainer", "*", "devcontainer.json"))
+	candidates = append(candidates, nested...)
+	for _, candidate := range candidates {
+		data, err := os.ReadFile(candidate)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read devcontainer configuration: %w", err)
+		}
+		var cfg Config
+		if err := json.Unmarshal(standardize(data), &cfg); err != nil {
+			return nil, fmt.Errorf("invalid devcontainer configuration %s: %w", candidate, err)
+		}
+		cfg.Path = candidate
+		if cfg.WorkspaceFolder == "" {
+			cfg.WorkspaceFolder = path.Join("/workspaces", filepath.Base(dir))
+		}
+		return &cfg, nil
+	}
+	return nil, nil
+}
+
+// Container is a running container of a project.
+type Container struct {
+	ID string
+	// WorkspaceFolder is where the project is mounted in the container.
+	WorkspaceFolder string
+}
+
+type mount struct {
+	Source      string
+	Destination string
+}
+
+// FindContainer returns the running container of the project in dir, as
+// labeled by the devcontainer CLI and editors when they start it. The
+// workspace folder is the mount of dir in the container, or the one of cfg
+// when dir isn't mounted as is.
+func FindContainer(ctx context.Context, dir string, cfg *Config) (*Container, error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return nil, errors.New("docker isn't installed")
+	}
+	out, err := exec.CommandContext(ctx, "docker", "ps", "--quiet",
+		"--filter", "label=devcontainer.local_folder="+dir,
+	).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", commandError(err))
+	}
+	id, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	if id == "" {
+		return nil, errors.New("the devcontainer isn't running: start it with devcontainer up or your editor")
+	}
+
+	container := &Container{ID: id, WorkspaceFolder: cfg.WorkspaceFolder}
+	out, err = exec.CommandContext(ctx, "docker", "inspect", "--format", "{{json .Mounts}}", id).Output()
+	if err != nil {
+		return container, nil
+	}
+	var mounts []mount
+	if json.Unmarshal(out, &mounts) == nil {
+		for _, m := range mounts {
+			if filepath.Clean(m.Source) == filepath.Clean(dir) {
+				container.WorkspaceFolder = m.Destination
+				break
+			}
+		}
+	}
+	return container, nil
+}
+
+// commandError adds the error output of a failed command to err.
+func commandError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(exitErr.Stderr))
+	}
+	return err
+}
+
+// standardize turns the JSON with comments and trailing commas of
+// devcontainer.json files into standard JSON.
+func standardize(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c == '"':
+			// Copy the string as is, with its escapes.
+			start := i
+			for i++; i < len(data) && data[i] != '"'; i++ {
+				if data[i] == '\\' {
+					i++
+				}
+			}
+			out = append(out, data[start:min(i+1, len(data))]...)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			i--
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				return out
+			}
+			i += end + 3
+		case c == '}' || c == ']':
+			trimmed := bytes.TrimRight(out, " \t\r\n")
+			if len(trimmed) > 0 && trimmed[len(trimmed)-1] == ',' {
+				out = append(trimmed[:len(trimmed)-1], out[len(trimmed):]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,85 @@
+package devcontainer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+
+	t.Run("none", func(t *testing.T) {
+		t.Parallel()
+		cfg, err := Find(t.TempDir())
+		require.NoError(t, err)
+		require.Nil(t, cfg)
+	})
+
+	t.Run("comments and trailing commas", func(t *testing.T) {
+		t.Parallel()
+		dir := filepath.Join(t.TempDir(), "app")
+		path := filepath.Join(dir, ".devcontainer", "devcontainer.json")
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(`{
+	// The Go toolchain.
+	"name": "Go // 1.25",
+	/* "workspaceFolder": "/src", */
+	"remoteUser": "vscode",
+	"features": {"ghcr.io/devcontainers/features/go:1": {},},
+}`), 0o644))
+
+		cfg, err := Find(dir)
+		require.NoError(t, err)
+		require.Equal(t, &Config{
+			Path:            path,
+			Name:            "Go // 1.25",
+			WorkspaceFolder: "/workspaces/app",
+			RemoteUser:      "vscode",
+		}, cfg)
+		require.Equal(t, "vscode", cfg.User())
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		path := filepath.Join(dir, ".devcontainer", "backend", "devcontainer.json")
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(`{"workspaceFolder": "/src", "containerUser": "dev"}`), 0o644))
+
+		cfg, err := Find(dir)
+		require.NoError(t, err)
+		require.Equal(t, path, cfg.Path)
+		require.Equal(t, "/src", cfg.WorkspaceFolder)
+		require.Equal(t, "dev", cfg.User())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".devcontainer.json"), []byte(`{"name": }`), 0o644))
+		_, err := Find(dir)
+		require.Error(t, err)
+	})
+}
+
+func TestChoice(t *testing.T) {
+	t.Parallel()
+
+	dataDir := filepath.Join(t.TempDir(), ".crush")
+	choice, err := LoadChoice(dataDir)
+	require.NoError(t, err)
+	require.Equal(t, Unasked, choice)
+
+	require.NoError(t, SaveChoice(dataDir, Use))
+	choice, err = LoadChoice(dataDir)
+	require.NoError(t, err)
+	require.Equal(t, Use, choice)
+
+	require.NoError(t, SaveChoice(dataDir, Ignore))
+	choice, err = LoadChoice(dataDir)
+	require.NoError(t, err)
+	require.Equal(t, Ignore, choice)
+}
@@ -0,0 +1,239 @@
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FilePatch is the change of one file in a unified diff.
+type FilePatch struct {
+	// OldPath is empty for files the patch creates.
+	OldPath string
+	// NewPath is empty for files the patch deletes.
+	NewPath string
+	Hunks   []Hunk
+}
+
+// Hunk is a block of changed lines, with the context around them.
+type Hunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+	// Lines start with ' ', '-' or '+'.
+	Lines []string
+	// OldNoNewline and NewNoNewline are set when the hunk ends the file
+	// without a newline, before and after the change.
+	OldNoNewline, NewNoNewline bool
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// ParsePatch parses a unified diff covering one or more files, as made by
+// diff -u or git diff.
+func ParsePatch(patch string) ([]FilePatch, error) {
+	var files []FilePatch
+	lines := strings.Split(strings.ReplaceAll(patch, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if !strings.HasPrefix(line, "--- ") {
+			continue
+		}
+		if i+1 == len(lines) || !strings.HasPrefix(lines[i+1], "+++ ") {
+			return nil, fmt.Errorf("line %d: expected +++ after ---", i+2)
+		}
+		file := FilePatch{
+			OldPath: patchPath(line[4:], "a/"),
+			NewPath: patchPath(lines[i+1][4:], "b/"),
+		}
+		if file.OldPath == "" && file.NewPath == "" {
+			return nil, fmt.Errorf("line %d: no file path", i+1)
+		}
+		i += 2
+
+		for i < len(lines) && strings.HasPrefix(lines[i], "@@") {
+			hunk, next, err := parseHunk(lines, i)
+			if err != nil {
+				return nil, err
+			}
+			file.Hunks = append(file.Hunks, hunk)
+			i = next
+		}
+		if len(file.Hunks) == 0 {
+			return nil, fmt.Errorf("no hunks for %s", file.Path())
+		}
+		files = append(files, file)
+		i--
+	}
+	if len(files) == 0 {
+		return nil, errors.New("no file changes found; the patch must be a unified diff with ---/+++ headers and @@ hunks")
+	}
+	return files, nil
+}
+
+// Path returns the path of the file after the change, or before it for
+// deleted files.
+func (f FilePatch) Path() string {
+	if f.NewPath != "" {
+		return f.NewPath
+	}
+	return f.OldPath
+}
+
+// patchPath returns the path of a ---/+++ header, without the a/ or b/
+// prefix of git and the timestamp of diff, or "" for /dev/null.
+func patchPath(header, prefix string) string {
+	path, _, _ := strings.Cut(header, "\t")
+	path = strings.TrimSpace(path)
+	if path == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(path, prefix)
+}
+
+func parseHunk(lines []string, i int) (Hunk, int, error) {
+	m := hunkHeader.FindStringSubmatch(lines[i])
+	if m == nil {
+		return Hunk{}, 0, fmt.Errorf("line %d: invalid hunk header %q", i+1, lines[i])
+	}
+	count := func(s string) int {
+		if s == "" {
+			return 1
+		}
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+	hunk := Hunk{
+		OldLines: count(m[2]),
+		NewLines: count(m[4]),
+	}
+	hunk.OldStart, _ = strconv.Atoi(m[1])
+	hunk.NewStart, _ = strconv.Atoi(m[3])
+
+	oldLeft, newLeft := hunk.OldLines, hunk.NewLines
+	for i++; i < len(lines) && (oldLeft > 0 || newLeft > 0); i++ {
+		line := lines[i]
+		if line == "" {
+			if i == len(lines)-1 {
+				break
+			}
+			// Editors and models often drop the space of empty context
+			// lines.
+			line = " "
+		}
+		switch line[0] {
+		case ' ':
+			oldLeft--
+			newLeft--
+		case '-':
+			oldLeft--
+		case '+':
+			newLeft--
+		case '\\':
+			hunk.markNoNewline()
+			continue
+		default:
+			return Hunk{}, 0, fmt.Errorf("line %d: unexpected line in hunk %q", i+1, line)
+		}
+		hunk.Lines = append(hunk.Lines, line)
+	}
+	if oldLeft != 0 || newLeft != 0 {
+		return Hunk{}, 0, fmt.Errorf("hunk %q has %d line(s) missing", lines[i-1], max(oldLeft, newLeft))
+	}
+	if i < len(lines) && strings.HasPrefix(lines[i], `\`) {
+		hunk.markNoNewline()
+		i++
+	}
+	return hunk, i, nil
+}
+
+// markNoNewline handles a "\ No newline at end of file" line, which refers
+// to the line before it.
+func (h *Hunk) markNoNewline() {
+	if len(h.Lines) == 0 {
+		return
+	}
+	switch h.Lines[len(h.Lines)-1][0] {
+	case ' ':
+		h.OldNoNewline, h.NewNoNewline = true, true
+	case '-':
+		h.OldNoNewline = true
+	case '+':
+		h.NewNoNewline = true
+	}
+}
+
+// Apply applies the hunks to content. Hunks are looked for near the line
+// of their header first, so patches with slightly wrong line numbers still
+// apply, but their lines must match exactly.
+func (f FilePatch) Apply(content string) (string, error) {
+	lines := strings.Split(content, "\n")
+	newline := strings.HasSuffix(content, "\n")
+	if newline || content == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var out []string
+	pos := 0
+	for n, hunk := range f.Hunks {
+		var before, after []string
+		for _, line := range hunk.Lines {
+			if line[0] != '+' {
+				before = append(before, line[1:])
+			}
+			if line[0] != '-' {
+				after = append(after, line[1:])
+			}
+		}
+		var at int
+		if len(before) == 0 {
+			// Hunks without context insert after the line of their header.
+			at = min(max(hunk.OldStart, pos), len(lines))
+		} else {
+			at = findLines(lines, before, pos, hunk.OldStart-1)
+		}
+		if at == -1 {
+			return "", fmt.Errorf("hunk %d (@@ -%d,%d +%d,%d @@) doesn't match the content of %s", n+1, hunk.OldStart, hunk.OldLines, hunk.NewStart, hunk.NewLines, f.Path())
+		}
+		out = append(out, lines[pos:at]...)
+		out = append(out, after...)
+		pos = at + len(before)
+		if pos == len(lines) {
+			newline = !hunk.NewNoNewline
+		}
+	}
+	out = append(out, lines[pos:]...)
+
+	result := strings.Join(out, "\n")
+	if newline && len(out) > 0 {
+		result += "\n"
+	}
+	return result, nil
+}
+
+// findLines returns the index of want in lines, at or after from, looking
+// outwards from near first.
+func findLines(lines, want []string, from, near int) int {
+	matches := func(at int) bool {
+		if at < from || at+len(want) > len(lines) {
+			return false
+		}
+		for i, line := range want {
+			if lines[at+i] != line {
+				return false
+			}
+		}
+		return true
+	}
+	near = max(near, from)
+	for d := 0; near-d >= from || near+d <= len(lines); d++ {
+		if matches(near + d) {
+			return near + d
+		}
+		if d > 0 && matches(near-d) {
+			return near - d
+		}
+	}
+	return -1
+}
@@ -0,0 +1,92 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePatch(t *testing.T) {
+	t.Parallel()
+
+	patch := `diff --git a/main.go b/main.go
+index 3b18e51..a2c4b1f 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ package main
+
+-func main() {}
++func main() { run() }
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1,2 @@
++hello
++world
+\ No newline at end of file
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+`
+	files, err := ParsePatch(patch)
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+
+	require.Equal(t, "main.go", files[0].OldPath)
+	require.Equal(t, "main.go", files[0].NewPath)
+	require.Equal(t, []string{" package main", " ", "-func main() {}", "+func main() { run() }"}, files[0].Hunks[0].Lines)
+
+	require.Empty(t, files[1].OldPath)
+	require.Equal(t, "new.txt", files[1].Path())
+	require.True(t, files[1].Hunks[0].NewNoNewline)
+
+	require.Empty(t, files[2].NewPath)
+	require.Equal(t, "old.txt", files[2].Path())
+
+	_, err = ParsePatch("just some text")
+	require.Error(t, err)
+	_, err = ParsePatch("--- a/x\n+++ b/x\n@@ -1,2 +1,2 @@\n-a\n+b\n")
+	require.ErrorContains(t, err, "missing")
+}
+
+func TestFilePatchApply(t *testing.T) {
+	t.Parallel()
+
+	content := "one\ntwo\nthree\nfour\nfive\n"
+
+	t.Run("offset", func(t *testing.T) {
+		t.Parallel()
+		files, err := ParsePatch("--- a/f\n+++ b/f\n@@ -1,3 +1,3 @@\n three\n-four\n+FOUR\n five\n")
+		require.NoError(t, err)
+		got, err := files[0].Apply(content)
+		require.NoError(t, err)
+		require.Equal(t, "one\ntwo\nthree\nFOUR\nfive\n", got, "the hunk is found despite the wrong line number")
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		t.Parallel()
+		files, err := ParsePatch("--- a/f\n+++ b/f\n@@ -2,2 +2,2 @@\n two\n-six\n+SIX\n")
+		require.NoError(t, err)
+		_, err = files[0].Apply(content)
+		require.ErrorContains(t, err, "hunk 1 (@@ -2,2 +2,2 @@) doesn't match the content of f")
+	})
+
+	t.Run("new file", func(t *testing.T) {
+		t.Parallel()
+		files, err := ParsePatch("--- /dev/null\n+++ b/f\n@@ -0,0 +1,2 @@\n+a\n+b\n\\ No newline at end of file\n")
+		require.NoError(t, err)
+		got, err := files[0].Apply("")
+		require.NoError(t, err)
+		require.Equal(t, "a\nb", got)
+	})
+
+	t.Run("insert", func(t *testing.T) {
+		t.Parallel()
+		files, err := ParsePatch("--- a/f\n+++ b/f\n@@ -5,0 +6 @@\n+six\n")
+		require.NoError(t, err)
+		got, err := files[0].Apply(content)
+		require.NoError(t, err)
+		require.Equal(t, content+"six\n", got)
+	})
+}
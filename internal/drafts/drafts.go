@@ -0,0 +1,118 @@
+// Package drafts keeps what is written in the editor of each session and not
+// sent yet, so that it survives Crush quitting or crashing.
+package drafts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/message"
+)
+
+// newSessionKey is the name of the draft of a session not created yet.
+const newSessionKey = "new"
+
+// Draft is the text and attachments in the editor of a session.
+type Draft struct {
+	Text        string               `json:"text"`
+	Attachments []message.Attachment `json:"attachments,omitempty"`
+}
+
+// IsEmpty reports whether there is nothing to keep in the draft.
+func (d Draft) IsEmpty() bool {
+	return strings.TrimSpace(d.Text) == "" && len(d.Attachments) == 0
+}
+
+// Store keeps drafts as files in a directory, one per session.
+type Store struct {
+	dir    string
+	sealer db.Sealer
+}
+
+// New returns the store of the drafts in dir. The drafts are encrypted with
+// sealer, if not nil, like the messages of an encrypted store.
+func New(dir string, sealer db.Sealer) *Store {
+	return &Store{dir: dir, sealer: sealer}
+}
+
+// ForDataDir returns the store of the drafts in the data directory of a
+// project.
+func ForDataDir(dataDir string, sealer db.Sealer) *Store {
+	return New(filepath.Join(dataDir, "drafts"), sealer)
+}
+
+// Load returns the draft of the session with sessionID, or an empty one. An
+// empty sessionID is a session not created yet.
+func (s *Store) Load(sessionID string) (Draft, error) {
+	var d Draft
+	data, err := os.ReadFile(s.path(sessionID))
+	if errors.Is(err, os.ErrNotExist) {
+		return d, nil
+	}
+	if err != nil {
+		return d, fmt.Errorf("failed to read draft: %w", err)
+	}
+	switch {
+	case s.sealer != nil:
+		opened, err := s.sealer.Open(string(data))
+		if err != nil {
+			return d, fmt.Errorf("failed to read draft: %w", err)
+		}
+		data = []byte(opened)
+	case db.IsSealed(string(data)):
+		// Drafts encrypted before encryption was turned off can't be read
+		// anymore.
+		return d, nil
+	}
+	if err := json.Unmarshal(data, &d); err != nil {
+		return d, fmt.Errorf("failed to read draft: %w", err)
+	}
+	return d, nil
+}
+
+// Save keeps d as the draft of the session with sessionID, or deletes the
+// draft when d is empty.
+func (s *Store) Save(sessionID string, d Draft) error {
+	if d.IsEmpty() {
+		return s.Delete(sessionID)
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	if s.sealer != nil {
+		sealed, err := s.sealer.Seal(string(data))
+		if err != nil {
+			return fmt.Errorf("failed to save draft: %w", err)
+		}
+		data = []byte(sealed)
+	}
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to save draft: %w", err)
+	}
+	if err := fsext.WriteFileAtomic(s.path(sessionID), data, 0o600); err != nil {
+		return fmt.Errorf("failed to save draft: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes the draft of the session with sessionID.
+func (s *Store) Delete(sessionID string) error {
+	if err := os.Remove(s.path(sessionID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete draft: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) path(sessionID string) string {
+	if sessionID == "" {
+		sessionID = newSessionKey
+	}
+	return filepath.Join(s.dir, filepath.Base(sessionID)+".json")
+}
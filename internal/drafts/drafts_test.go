@@ -0,0 +1,67 @@
+package drafts
+
+import (
+	"os"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	s := ForDataDir(t.TempDir(), nil)
+	d, err := s.Load("")
+	require.NoError(t, err)
+	require.True(t, d.IsEmpty())
+
+	draft := Draft{
+		Text: "look at\nthis",
+		Attachments: []message.Attachment{
+			{FilePath: "/tmp/a.png", FileName: "a.png", MimeType: "image/png", Content: []byte{0x89, 'P', 'N', 'G'}},
+		},
+	}
+	require.NoError(t, s.Save("", draft))
+	require.NoError(t, s.Save("session-1", Draft{Text: "other"}))
+
+	d, err = New(s.dir, nil).Load("")
+	require.NoError(t, err)
+	require.Equal(t, draft, d)
+	d, err = s.Load("session-1")
+	require.NoError(t, err)
+	require.Equal(t, "other", d.Text)
+
+	require.NoError(t, s.Save("", Draft{Text: " \n"}))
+	_, err = os.Stat(s.path(""))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, s.Delete("session-1"))
+	require.NoError(t, s.Delete("session-1"))
+	d, err = s.Load("session-1")
+	require.NoError(t, err)
+	require.True(t, d.IsEmpty())
+}
+
+func TestStoreEncrypted(t *testing.T) {
+	t.Parallel()
+
+	c, err := db.NewKeyCipher(make([]byte, db.KeySize), make([]byte, 16))
+	require.NoError(t, err)
+
+	s := ForDataDir(t.TempDir(), c)
+	require.NoError(t, s.Save("session-1", Draft{Text: "the secret plan"}))
+
+	data, err := os.ReadFile(s.path("session-1"))
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "the secret plan")
+
+	d, err := New(s.dir, c).Load("session-1")
+	require.NoError(t, err)
+	require.Equal(t, "the secret plan", d.Text)
+
+	d, err = New(s.dir, nil).Load("session-1")
+	require.NoError(t, err)
+	require.True(t, d.IsEmpty())
+}
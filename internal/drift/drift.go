@@ -0,0 +1,229 @@
+// Package drift tells when the context file of a project, like AGENTS.md,
+// may be out of date. It keeps a snapshot of the structure of the project
+// and of its build files from when the context file was last written, and
+// compares the project against it.
+package drift
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/fsext"
+)
+
+const (
+	// SnapshotFilename is the file in the data directory the snapshot is
+	// kept in.
+	SnapshotFilename = "context_snapshot.json"
+
+	// Directories deeper than dirDepth are left out of the snapshot, and
+	// so are those after the first maxEntries files and directories.
+	dirDepth   = 3
+	maxEntries = 10_000
+)
+
+// buildFiles are the files, relative to the project, whose changes usually
+// mean the commands in the context file changed.
+var buildFiles = []string{
+	"Makefile",
+	"GNUmakefile",
+	"Taskfile.yml",
+	"Taskfile.yaml",
+	"justfile",
+	"Justfile",
+	"package.json",
+	"go.mod",
+	"go.work",
+	"Cargo.toml",
+	"pyproject.toml",
+	"setup.py",
+	"Gemfile",
+	"pom.xml",
+	"build.gradle",
+	"build.gradle.kts",
+	"CMakeLists.txt",
+	"Dockerfile",
+	"docker-compose.yml",
+	"compose.yaml",
+	".golangci.yml",
+	".golangci.yaml",
+}
+
+// Snapshot is the structure of a project when its context file was last
+// written.
+type Snapshot struct {
+	// ContextModTime is when the context file was last written.
+	ContextModTime time.Time `json:"context_mod_time"`
+	// Dirs are the directories of the project, relative to it and with
+	// forward slashes.
+	Dirs []string `json:"dirs"`
+	// Partial is set when the project has too many files for all of its
+	// directories to be listed, in which case they aren't compared.
+	Partial bool `json:"partial,omitempty"`
+	// BuildFiles are the hashes of the build files and CI workflows, by
+	// their paths.
+	BuildFiles map[string]string `json:"build_files"`
+}
+
+// Take takes a snapshot of the project in root, whose context file was last
+// written at contextModTime.
+func Take(root string, contextModTime time.Time) (Snapshot, error) {
+	s := Snapshot{ContextModTime: contextModTime, BuildFiles: map[string]string{}}
+	paths, truncated, err := fsext.ListDirectory(root, nil, dirDepth, maxEntries, false)
+	if err != nil {
+		return s, err
+	}
+	s.Partial = truncated
+	for _, path := range paths {
+		if !strings.HasSuffix(path, string(filepath.Separator)) {
+			continue
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			continue
+		}
+		// Hidden directories, like the data directory, aren't part of the
+		// structure of the project.
+		rel = filepath.ToSlash(rel)
+		if strings.HasPrefix(rel, ".") || strings.Contains(rel, "/.") {
+			continue
+		}
+		s.Dirs = append(s.Dirs, rel)
+	}
+	slices.Sort(s.Dirs)
+
+	files := slices.Clone(buildFiles)
+	workflows, _ := filepath.Glob(filepath.Join(root, ".github", "workflows", "*.y*ml"))
+	for _, workflow := range workflows {
+		rel, _ := filepath.Rel(root, workflow)
+		files = append(files, filepath.ToSlash(rel))
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(root, file))
+		if err != nil {
+			continue
+		}
+		sum := sha256.Sum256(data)
+		s.BuildFiles[file] = hex.EncodeToString(sum[:])
+	}
+	return s, nil
+}
+
+// Changes describes how current differs from s: the directories added and
+// removed, and the build files added, changed and removed. A directory
+// inside an added or removed one isn't listed on its own.
+func (s Snapshot) Changes(current Snapshot) []string {
+	var changes []string
+	if !s.Partial && !current.Partial {
+		for _, dir := range topmost(difference(current.Dirs, s.Dirs)) {
+			changes = append(changes, "new directory "+dir+"/")
+		}
+		for _, dir := range topmost(difference(s.Dirs, current.Dirs)) {
+			changes = append(changes, "removed directory "+dir+"/")
+		}
+	}
+
+	var files []string
+	for file := range current.BuildFiles {
+		files = append(files, file)
+	}
+	for file := range s.BuildFiles {
+		if _, ok := current.BuildFiles[file]; !ok {
+			files = append(files, file)
+		}
+	}
+	slices.Sort(files)
+	for _, file := range files {
+		before, had := s.BuildFiles[file]
+		after, has := current.BuildFiles[file]
+		switch {
+		case !had:
+			changes = append(changes, "new "+file)
+		case !has:
+			changes = append(changes, "removed "+file)
+		case before != after:
+			changes = append(changes, "changed "+file)
+		}
+	}
+	return changes
+}
+
+// difference returns the sorted entries of a that aren't in the sorted b.
+func difference(a, b []string) []string {
+	var diff []string
+	for _, entry := range a {
+		if _, found := slices.BinarySearch(b, entry); !found {
+			diff = append(diff, entry)
+		}
+	}
+	return diff
+}
+
+// topmost returns the sorted dirs that aren't inside another one of them.
+func topmost(dirs []string) []string {
+	var top []string
+	for _, dir := range dirs {
+		if len(top) > 0 && strings.HasPrefix(dir, top[len(top)-1]+"/") {
+			continue
+		}
+		top = append(top, dir)
+	}
+	return top
+}
+
+// Check compares the project in root against the snapshot kept in dataDir
+// and returns what changed since its context file, at contextFile relative
+// to root, was last written. A new snapshot is taken instead when there's
+// none yet or the context file was written since, which is when it was
+// created or updated. Nothing changed when there's no context file.
+func Check(dataDir, root, contextFile string) ([]string, error) {
+	info, err := os.Stat(filepath.Join(root, contextFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := Take(root, info.ModTime())
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dataDir, SnapshotFilename)
+	previous, err := load(path)
+	if err != nil || !previous.ContextModTime.Equal(info.ModTime()) {
+		return nil, save(path, current)
+	}
+	return previous.Changes(current), nil
+}
+
+func load(path string) (Snapshot, error) {
+	var s Snapshot
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("failed to parse context snapshot: %w", err)
+	}
+	return s, nil
+}
+
+func save(path string, s Snapshot) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
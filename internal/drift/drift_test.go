@@ -0,0 +1,93 @@
+package drift
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestTake(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example")
+	writeFile(t, filepath.Join(root, "internal", "api", "api.go"), "package api")
+	writeFile(t, filepath.Join(root, ".crush", "logs", "crush.log"), "")
+	writeFile(t, filepath.Join(root, ".github", "workflows", "build.yml"), "on: push")
+
+	s, err := Take(root, time.Time{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"internal", "internal/api"}, s.Dirs)
+	require.False(t, s.Partial)
+	require.Len(t, s.BuildFiles, 2)
+	require.Contains(t, s.BuildFiles, "go.mod")
+	require.Contains(t, s.BuildFiles, ".github/workflows/build.yml")
+}
+
+func TestChanges(t *testing.T) {
+	t.Parallel()
+
+	before := Snapshot{
+		Dirs:       []string{"cmd", "internal", "internal/old", "internal/old/store"},
+		BuildFiles: map[string]string{"Makefile": "1", "go.mod": "1", "package.json": "1"},
+	}
+	after := Snapshot{
+		Dirs:       []string{"cmd", "internal", "internal/api", "internal/api/v1"},
+		BuildFiles: map[string]string{"Makefile": "1", "go.mod": "2", "Taskfile.yml": "1"},
+	}
+	require.Empty(t, before.Changes(before))
+	require.Equal(t, []string{
+		"new directory internal/api/",
+		"removed directory internal/old/",
+		"new Taskfile.yml",
+		"changed go.mod",
+		"removed package.json",
+	}, before.Changes(after))
+
+	after.Partial = true
+	require.Equal(t, []string{"new Taskfile.yml", "changed go.mod", "removed package.json"}, before.Changes(after), "directories aren't compared when some are missing")
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	dataDir := filepath.Join(root, ".crush")
+	changes, err := Check(dataDir, root, "AGENTS.md")
+	require.NoError(t, err)
+	require.Empty(t, changes, "there's no context file")
+	require.NoFileExists(t, filepath.Join(dataDir, SnapshotFilename))
+
+	contextFile := filepath.Join(root, "AGENTS.md")
+	writeFile(t, contextFile, "# Agents")
+	writeFile(t, filepath.Join(root, "go.mod"), "module example")
+	changes, err = Check(dataDir, root, "AGENTS.md")
+	require.NoError(t, err)
+	require.Empty(t, changes, "the first check takes the snapshot")
+	require.FileExists(t, filepath.Join(dataDir, SnapshotFilename))
+
+	writeFile(t, filepath.Join(root, "go.mod"), "module example\n\ngo 1.25")
+	writeFile(t, filepath.Join(root, "pkg", "pkg.go"), "package pkg")
+	changes, err = Check(dataDir, root, "AGENTS.md")
+	require.NoError(t, err)
+	require.Equal(t, []string{"new directory pkg/", "changed go.mod"}, changes)
+
+	// Updating the context file takes a new snapshot.
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(contextFile, later, later))
+	changes, err = Check(dataDir, root, "AGENTS.md")
+	require.NoError(t, err)
+	require.Empty(t, changes)
+	changes, err = Check(dataDir, root, "AGENTS.md")
+	require.NoError(t, err)
+	require.Empty(t, changes)
+}
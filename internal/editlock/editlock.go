@@ -0,0 +1,141 @@
+// Package editlock keeps advisory locks on the files the sessions of a
+// project change, in its data directory, so that a session about to change
+// a file another session or Crush instance changed recently can ask first.
+// Nothing keeps a locked file from being changed.
+package editlock
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/fsext"
+)
+
+// Window is how long a session holds the lock on a file after changing it.
+const Window = 10 * time.Minute
+
+// Lock is held by the session that changed a file last.
+type Lock struct {
+	Path      string `json:"path"`
+	SessionID string `json:"session_id"`
+	// PID is the process of the Crush instance the session runs in.
+	PID  int       `json:"pid"`
+	Time time.Time `json:"time"`
+}
+
+// SameProcess reports whether the session holding the lock runs in this
+// Crush instance.
+func (l Lock) SameProcess() bool {
+	return l.PID == os.Getpid()
+}
+
+// Registry keeps the locks as files in a directory, one per locked file. A
+// nil registry holds no locks.
+type Registry struct {
+	dir     string
+	window  time.Duration
+	now     func() time.Time
+	running func(pid int) bool
+}
+
+// New returns the registry of the locks in dir, which are held for window.
+func New(dir string, window time.Duration) *Registry {
+	return &Registry{dir: dir, window: window, now: time.Now, running: processRunning}
+}
+
+// ForDataDir returns the registry of the locks in the data directory of a
+// project.
+func ForDataDir(dataDir string) *Registry {
+	return New(filepath.Join(dataDir, "edit_locks"), Window)
+}
+
+// HeldByOther returns the lock on the file at path when a session other
+// than sessionID holds it. Expired locks, and the ones of Crush instances
+// that exited, are deleted.
+func (r *Registry) HeldByOther(path, sessionID string) (Lock, bool) {
+	if r == nil {
+		return Lock{}, false
+	}
+	var l Lock
+	data, err := os.ReadFile(r.path(path))
+	if err != nil || json.Unmarshal(data, &l) != nil || l.Path != path {
+		return Lock{}, false
+	}
+	if r.now().Sub(l.Time) > r.window || !l.SameProcess() && !r.running(l.PID) {
+		_ = os.Remove(r.path(path))
+		return Lock{}, false
+	}
+	return l, l.SessionID != sessionID
+}
+
+// Acquire gives the lock on the file at path to the session with sessionID,
+// which just changed it.
+func (r *Registry) Acquire(path, sessionID string) error {
+	if r == nil {
+		return nil
+	}
+	data, err := json.Marshal(Lock{
+		Path:      path,
+		SessionID: sessionID,
+		PID:       os.Getpid(),
+		Time:      r.now(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(r.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	if err := fsext.WriteFileAtomic(r.path(path), data, 0o600); err != nil {
+		return fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	return nil
+}
+
+// Release gives up the locks the session with sessionID holds, once it
+// ended.
+func (r *Registry) Release(sessionID string) error {
+	return r.release(func(l Lock) bool { return l.SessionID == sessionID })
+}
+
+// ReleaseProcess gives up the locks the sessions of this Crush instance
+// hold, as it exits.
+func (r *Registry) ReleaseProcess() error {
+	return r.release(Lock.SameProcess)
+}
+
+// release deletes the locks held reports as given up.
+func (r *Registry) release(held func(Lock) bool) error {
+	if r == nil {
+		return nil
+	}
+	paths, err := filepath.Glob(filepath.Join(r.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, path := range paths {
+		var l Lock
+		data, err := os.ReadFile(path)
+		if err != nil || json.Unmarshal(data, &l) != nil || !held(l) {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// path returns the file of the lock on the file at path, named after its
+// hash to fit any path in a file name.
+func (r *Registry) path(path string) string {
+	sum := sha256.Sum256([]byte(path))
+	return filepath.Join(r.dir, hex.EncodeToString(sum[:16])+".json")
+}
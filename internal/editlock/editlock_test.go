@@ -0,0 +1,92 @@
+package editlock
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	r := ForDataDir(t.TempDir())
+	r.now = func() time.Time { return now }
+
+	_, ok := r.HeldByOther("/work/main.go", "session-1")
+	require.False(t, ok)
+
+	require.NoError(t, r.Acquire("/work/main.go", "session-1"))
+	_, ok = r.HeldByOther("/work/main.go", "session-1")
+	require.False(t, ok, "a session doesn't conflict with itself")
+	lock, ok := r.HeldByOther("/work/main.go", "session-2")
+	require.True(t, ok)
+	require.Equal(t, "session-1", lock.SessionID)
+	require.True(t, lock.SameProcess())
+	require.True(t, lock.Time.Equal(now))
+	_, ok = r.HeldByOther("/work/other.go", "session-2")
+	require.False(t, ok)
+
+	require.NoError(t, r.Acquire("/work/main.go", "session-2"))
+	lock, ok = r.HeldByOther("/work/main.go", "session-1")
+	require.True(t, ok)
+	require.Equal(t, "session-2", lock.SessionID)
+
+	now = now.Add(Window + time.Second)
+	_, ok = r.HeldByOther("/work/main.go", "session-1")
+	require.False(t, ok)
+	_, err := os.Stat(r.path("/work/main.go"))
+	require.ErrorIs(t, err, os.ErrNotExist, "expired locks are deleted")
+}
+
+func TestRegistryExitedProcess(t *testing.T) {
+	t.Parallel()
+
+	r := ForDataDir(t.TempDir())
+	r.running = func(int) bool { return false }
+	require.NoError(t, r.Acquire("/work/main.go", "session-1"))
+	_, ok := r.HeldByOther("/work/main.go", "session-2")
+	require.True(t, ok, "the locks of this instance stay")
+
+	lock := Lock{Path: "/work/main.go", SessionID: "session-1", PID: os.Getpid() + 1, Time: time.Now()}
+	data, err := json.Marshal(lock)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(r.path(lock.Path), data, 0o600))
+	_, ok = r.HeldByOther("/work/main.go", "session-2")
+	require.False(t, ok, "the locks of instances that exited are released")
+	_, err = os.Stat(r.path("/work/main.go"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestRegistryRelease(t *testing.T) {
+	t.Parallel()
+
+	r := ForDataDir(t.TempDir())
+	require.NoError(t, r.Release("session-1"), "nothing is locked yet")
+	require.NoError(t, r.Acquire("/work/main.go", "session-1"))
+	require.NoError(t, r.Acquire("/work/other.go", "session-2"))
+
+	require.NoError(t, r.Release("session-1"))
+	_, ok := r.HeldByOther("/work/main.go", "session-3")
+	require.False(t, ok)
+	_, ok = r.HeldByOther("/work/other.go", "session-3")
+	require.True(t, ok)
+
+	require.NoError(t, r.ReleaseProcess())
+	_, ok = r.HeldByOther("/work/other.go", "session-3")
+	require.False(t, ok)
+}
+
+func TestNilRegistry(t *testing.T) {
+	t.Parallel()
+
+	var r *Registry
+	require.NoError(t, r.Acquire("/work/main.go", "session-1"))
+	_, ok := r.HeldByOther("/work/main.go", "session-2")
+	require.False(t, ok)
+	require.NoError(t, r.Release("session-1"))
+	require.NoError(t, r.ReleaseProcess())
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package editlock
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processRunning reports whether the process with pid is running.
+func processRunning(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
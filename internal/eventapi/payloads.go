@@ -0,0 +1,193 @@
+package eventapi
+
+import (
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+// Session is the payload of the sessions topic.
+type Session struct {
+	ID              string   `json:"id"`
+	ParentSessionID string   `json:"parent_session_id,omitempty"`
+	Title           string   `json:"title"`
+	MessageCount    int64    `json:"message_count"`
+	Tags            []string `json:"tags,omitempty"`
+	CreatedAt       int64    `json:"created_at"`
+	UpdatedAt       int64    `json:"updated_at"`
+}
+
+// Usage is the payload of the usage topic, sent when the tokens or the cost
+// of a session change.
+type Usage struct {
+	SessionID           string  `json:"session_id"`
+	PromptTokens        int64   `json:"prompt_tokens"`
+	CompletionTokens    int64   `json:"completion_tokens"`
+	InputTokens         int64   `json:"input_tokens"`
+	CacheReadTokens     int64   `json:"cache_read_tokens"`
+	CacheCreationTokens int64   `json:"cache_creation_tokens"`
+	Cost                float64 `json:"cost"`
+}
+
+// Message is the payload of the messages topic. Messages are updated as
+// they stream, each time with all of their content so far.
+type Message struct {
+	ID           string       `json:"id"`
+	SessionID    string       `json:"session_id"`
+	Role         string       `json:"role"`
+	Text         string       `json:"text,omitempty"`
+	Reasoning    string       `json:"reasoning,omitempty"`
+	ToolCalls    []ToolCall   `json:"tool_calls,omitempty"`
+	ToolResults  []ToolResult `json:"tool_results,omitempty"`
+	FinishReason string       `json:"finish_reason,omitempty"`
+	Model        string       `json:"model,omitempty"`
+	Provider     string       `json:"provider,omitempty"`
+	CreatedAt    int64        `json:"created_at"`
+	UpdatedAt    int64        `json:"updated_at"`
+}
+
+// ToolCall is a tool call of a message.
+type ToolCall struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Input    string `json:"input"`
+	Finished bool   `json:"finished"`
+}
+
+// ToolResult is the result of a tool call. Binary data is left out.
+type ToolResult struct {
+	ToolCallID string `json:"tool_call_id"`
+	Name       string `json:"name"`
+	Content    string `json:"content"`
+	IsError    bool   `json:"is_error"`
+}
+
+// Permission is the payload of the permissions topic for a request.
+type Permission struct {
+	ID          string `json:"id"`
+	SessionID   string `json:"session_id"`
+	ToolCallID  string `json:"tool_call_id"`
+	ToolName    string `json:"tool_name"`
+	Description string `json:"description"`
+	Action      string `json:"action"`
+	Path        string `json:"path"`
+}
+
+// PermissionResult is the payload of the permissions topic when a request
+// is answered.
+type PermissionResult struct {
+	ToolCallID string `json:"tool_call_id"`
+	Granted    bool   `json:"granted"`
+	Denied     bool   `json:"denied"`
+	// TimedOut tells that the request went unanswered for WaitedSeconds.
+	TimedOut      bool `json:"timed_out,omitempty"`
+	WaitedSeconds int  `json:"waited_seconds,omitempty"`
+}
+
+// ServerState is the payload of the mcp and lsp topics.
+type ServerState struct {
+	Name        string `json:"name"`
+	State       string `json:"state"`
+	Error       string `json:"error,omitempty"`
+	Tools       int    `json:"tools,omitempty"`
+	Prompts     int    `json:"prompts,omitempty"`
+	Diagnostics int    `json:"diagnostics,omitempty"`
+}
+
+// FromSession returns the payloads of the sessions and usage topics for s.
+func FromSession(s session.Session) (Session, Usage) {
+	sess := Session{
+		ID:              s.ID,
+		ParentSessionID: s.ParentSessionID,
+		Title:           s.Title,
+		MessageCount:    s.MessageCount,
+		Tags:            s.Tags,
+		CreatedAt:       s.CreatedAt,
+		UpdatedAt:       s.UpdatedAt,
+	}
+	usage := Usage{
+		SessionID:           s.ID,
+		PromptTokens:        s.PromptTokens,
+		CompletionTokens:    s.CompletionTokens,
+		InputTokens:         s.InputTokens,
+		CacheReadTokens:     s.CacheReadTokens,
+		CacheCreationTokens: s.CacheCreationTokens,
+		Cost:                s.Cost,
+	}
+	return sess, usage
+}
+
+// FromMessage returns the payload of the messages topic for msg.
+func FromMessage(msg message.Message) Message {
+	m := Message{
+		ID:        msg.ID,
+		SessionID: msg.SessionID,
+		Role:      string(msg.Role),
+		Text:      msg.Content().Text,
+		Reasoning: msg.ReasoningContent().Thinking,
+		Model:     msg.Model,
+		Provider:  msg.Provider,
+		CreatedAt: msg.CreatedAt,
+		UpdatedAt: msg.UpdatedAt,
+	}
+	for _, call := range msg.ToolCalls() {
+		m.ToolCalls = append(m.ToolCalls, ToolCall{
+			ID:       call.ID,
+			Name:     call.Name,
+			Input:    call.Input,
+			Finished: call.Finished,
+		})
+	}
+	for _, result := range msg.ToolResults() {
+		m.ToolResults = append(m.ToolResults, ToolResult{
+			ToolCallID: result.ToolCallID,
+			Name:       result.Name,
+			Content:    result.Content,
+			IsError:    result.IsError,
+		})
+	}
+	if finish := msg.FinishPart(); finish != nil {
+		m.FinishReason = string(finish.Reason)
+	}
+	return m
+}
+
+// FromPermission returns the payload of the permissions topic for req.
+func FromPermission(req permission.PermissionRequest) Permission {
+	return Permission{
+		ID:          req.ID,
+		SessionID:   req.SessionID,
+		ToolCallID:  req.ToolCallID,
+		ToolName:    req.ToolName,
+		Description: req.Description,
+		Action:      req.Action,
+		Path:        req.Path,
+	}
+}
+
+// FromPermissionNotification returns the payload of the permissions topic
+// for n.
+func FromPermissionNotification(n permission.PermissionNotification) PermissionResult {
+	return PermissionResult{
+		ToolCallID:    n.ToolCallID,
+		Granted:       n.Granted,
+		Denied:        n.Denied,
+		TimedOut:      n.TimedOut,
+		WaitedSeconds: int(n.Waited.Seconds()),
+	}
+}
+
+// FromMCPEvent returns the payload of the mcp topic for e.
+func FromMCPEvent(e mcp.Event) ServerState {
+	s := ServerState{
+		Name:    e.Name,
+		State:   e.State.String(),
+		Tools:   e.Counts.Tools,
+		Prompts: e.Counts.Prompts,
+	}
+	if e.Error != nil {
+		s.Error = e.Error.Error()
+	}
+	return s
+}
@@ -0,0 +1,226 @@
+// Package eventapi serves the events of a running Crush instance over a
+// unix socket, so that external tools like status bars and editor plugins
+// can observe it.
+//
+// The protocol is newline-delimited JSON. Each line the server writes is an
+// [Envelope], starting with a "hello" one. Clients can write a [Request]
+// line to only receive some topics; nothing else they write is read, so the
+// API can't change the state of Crush.
+package eventapi
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/netext"
+)
+
+// Version is the version of the protocol, sent in the hello envelope.
+const Version = 1
+
+// The topics of the events.
+const (
+	TopicHello       = "hello"
+	TopicSessions    = "sessions"
+	TopicUsage       = "usage"
+	TopicMessages    = "messages"
+	TopicPermissions = "permissions"
+	TopicMCP         = "mcp"
+	TopicLSP         = "lsp"
+)
+
+// clientBuffer is how many envelopes a client can fall behind by before
+// the next ones are dropped for it.
+const clientBuffer = 256
+
+// Envelope is a line the server writes.
+type Envelope struct {
+	Topic   string `json:"topic"`
+	Type    string `json:"type"`
+	Time    int64  `json:"time"`
+	Payload any    `json:"payload,omitempty"`
+}
+
+// Request is a line a client writes to only receive the events of Topics,
+// or all of them again when it is empty.
+type Request struct {
+	Subscribe []string `json:"subscribe"`
+}
+
+// Hello is the payload of the first envelope sent to each client.
+type Hello struct {
+	Version    int    `json:"version"`
+	PID        int    `json:"pid"`
+	WorkingDir string `json:"working_dir"`
+}
+
+// Server sends the events it is given to the clients connected to its
+// socket.
+type Server struct {
+	listener net.Listener
+	path     string
+	hello    Hello
+
+	mu      sync.Mutex
+	clients map[*client]struct{}
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+type client struct {
+	conn net.Conn
+	out  chan []byte
+
+	mu     sync.Mutex
+	topics []string
+}
+
+// Listen serves events at the unix socket path. A socket left behind by an
+// instance that exited is replaced, but not one that is still served.
+func Listen(path, workingDir string) (*Server, error) {
+	listener, err := netext.ListenPrivate(path)
+	if errors.Is(err, netext.ErrSocketInUse) {
+		return nil, fmt.Errorf("another instance serves events at %s", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		listener: listener,
+		path:     path,
+		hello:    Hello{Version: Version, PID: os.Getpid(), WorkingDir: workingDir},
+		clients:  make(map[*client]struct{}),
+	}
+	s.wg.Go(s.accept)
+	return s, nil
+}
+
+// Path returns the path of the socket.
+func (s *Server) Path() string {
+	return s.path
+}
+
+func (s *Server) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				slog.Warn("Failed to accept an event API client", "error", err)
+			}
+			return
+		}
+		c := &client{conn: conn, out: make(chan []byte, clientBuffer)}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		c.out <- encode(TopicHello, TopicHello, s.hello)
+		s.clients[c] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Go(func() { s.write(c) })
+		s.wg.Go(func() { s.read(c) })
+	}
+}
+
+// write sends the envelopes of c until it disconnects.
+func (s *Server) write(c *client) {
+	for line := range c.out {
+		if _, err := c.conn.Write(line); err != nil {
+			s.remove(c)
+			return
+		}
+	}
+}
+
+// read handles the requests of c until it disconnects.
+func (s *Server) read(c *client) {
+	scanner := bufio.NewScanner(c.conn)
+	for scanner.Scan() {
+		var req Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			continue
+		}
+		c.mu.Lock()
+		c.topics = req.Subscribe
+		c.mu.Unlock()
+	}
+	s.remove(c)
+}
+
+func (s *Server) remove(c *client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.clients[c]; !ok {
+		return
+	}
+	delete(s.clients, c)
+	close(c.out)
+	c.conn.Close()
+}
+
+// Publish sends an event of topic to the clients subscribed to it. Clients
+// that fall too far behind miss it.
+func (s *Server) Publish(topic, typ string, payload any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.clients) == 0 {
+		return
+	}
+	line := encode(topic, typ, payload)
+	for c := range s.clients {
+		if !c.subscribed(topic) {
+			continue
+		}
+		select {
+		case c.out <- line:
+		default:
+			slog.Debug("Dropped an event for a slow event API client", "topic", topic)
+		}
+	}
+}
+
+func (c *client) subscribed(topic string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.topics) == 0 || slices.Contains(c.topics, topic)
+}
+
+func encode(topic, typ string, payload any) []byte {
+	data, err := json.Marshal(Envelope{
+		Topic:   topic,
+		Type:    typ,
+		Time:    time.Now().UnixMilli(),
+		Payload: payload,
+	})
+	if err != nil {
+		slog.Error("Failed to encode an event", "topic", topic, "error", err)
+		data, _ = json.Marshal(Envelope{Topic: topic, Type: typ, Time: time.Now().UnixMilli()})
+	}
+	return append(data, '\n')
+}
+
+// Close disconnects the clients and removes the socket.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	err := s.listener.Close()
+	for c := range s.clients {
+		delete(s.clients, c)
+		close(c.out)
+		c.conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
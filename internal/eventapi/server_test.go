@@ -0,0 +1,106 @@
+package eventapi
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// socketPath returns a path short enough for a unix socket, as temporary
+// directories of tests can be too long on macOS.
+func socketPath(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "crush")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "events.sock")
+}
+
+func readEnvelope(t *testing.T, r *bufio.Reader) Envelope {
+	t.Helper()
+	line, err := r.ReadBytes('\n')
+	require.NoError(t, err)
+	var e Envelope
+	require.NoError(t, json.Unmarshal(line, &e))
+	return e
+}
+
+// connect connects to s and waits for its hello.
+func connect(t *testing.T, s *Server) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	conn, err := net.Dial("unix", s.Path())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	r := bufio.NewReader(conn)
+	hello := readEnvelope(t, r)
+	require.Equal(t, TopicHello, hello.Topic)
+	require.Equal(t, map[string]any{
+		"version":     float64(Version),
+		"pid":         float64(os.Getpid()),
+		"working_dir": "/project",
+	}, hello.Payload)
+	return conn, r
+}
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	s, err := Listen(socketPath(t), "/project")
+	require.NoError(t, err)
+
+	_, err = Listen(s.Path(), "/project")
+	require.ErrorContains(t, err, "another instance")
+
+	_, allReader := connect(t, s)
+	usage, usageReader := connect(t, s)
+	_, err = usage.Write([]byte(`{"subscribe":["usage"]}` + "\n"))
+	require.NoError(t, err)
+
+	// The subscription is read concurrently, so publish until it applies.
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for c := range s.clients {
+			if !c.subscribed(TopicMessages) {
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, 10*time.Millisecond)
+
+	s.Publish(TopicMessages, "created", Message{ID: "m1", Role: "user", Text: "hi"})
+	s.Publish(TopicUsage, "updated", Usage{SessionID: "s1", PromptTokens: 10})
+
+	e := readEnvelope(t, allReader)
+	require.Equal(t, TopicMessages, e.Topic)
+	require.Equal(t, "created", e.Type)
+	require.Equal(t, "hi", e.Payload.(map[string]any)["text"])
+	require.Equal(t, TopicUsage, readEnvelope(t, allReader).Topic)
+
+	e = readEnvelope(t, usageReader)
+	require.Equal(t, TopicUsage, e.Topic, "other topics are filtered out")
+	require.Equal(t, float64(10), e.Payload.(map[string]any)["prompt_tokens"])
+
+	require.NoError(t, s.Close())
+	_, err = allReader.ReadBytes('\n')
+	require.Error(t, err, "clients are disconnected")
+	_, err = os.Stat(s.Path())
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestListenReplacesStaleSocket(t *testing.T) {
+	t.Parallel()
+
+	path := socketPath(t)
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+	s, err := Listen(path, "/project")
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+}
@@ -7,8 +7,9 @@ import (
 )
 
 // SmartJoin joins two paths, treating the second path as absolute if it is an
-// absolute path.
+// absolute path. The second path is normalized first, see [Normalize].
 func SmartJoin(one, two string) string {
+	two = Normalize(two)
 	if SmartIsAbs(two) {
 		return two
 	}
@@ -18,6 +19,7 @@ func SmartJoin(one, two string) string {
 // SmartIsAbs checks if a path is absolute, considering both OS-specific and
 // Unix-style paths.
 func SmartIsAbs(path string) bool {
+	path = Normalize(path)
 	switch runtime.GOOS {
 	case "windows":
 		return filepath.IsAbs(path) || strings.HasPrefix(filepath.ToSlash(path), "/")
@@ -0,0 +1,103 @@
+package filepathext
+
+import (
+	"cmp"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// IsWSL reports whether Crush runs in the Windows Subsystem for Linux.
+var IsWSL = sync.OnceValue(func() bool {
+	if runtime.GOOS != "linux" {
+		return false
+	}
+	if os.Getenv("WSL_DISTRO_NAME") != "" {
+		return true
+	}
+	version, err := os.ReadFile("/proc/version")
+	return err == nil && strings.Contains(strings.ToLower(string(version)), "microsoft")
+})
+
+// Normalize returns path the way the operating system takes it, for paths
+// written for the other side of Windows and WSL, as models often do.
+//
+// On Windows, separators become backslashes, WSL paths like /mnt/c/src
+// become C:\src and drive letters are upper cased. In WSL, Windows paths
+// like C:\src become /mnt/c/src, and \\wsl$\<distro>\home paths become
+// /home. UNC paths are kept, and other paths are returned as they are.
+func Normalize(path string) string {
+	return normalize(path, runtime.GOOS, IsWSL())
+}
+
+// SamePath reports whether a and b are the same path once normalized and
+// cleaned, ignoring case on Windows.
+func SamePath(a, b string) bool {
+	a, b = filepath.Clean(Normalize(a)), filepath.Clean(Normalize(b))
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+func normalize(path, goos string, wsl bool) string {
+	switch {
+	case goos == "windows":
+		return normalizeWindows(path)
+	case wsl:
+		return normalizeWSL(path)
+	}
+	return path
+}
+
+func normalizeWindows(path string) string {
+	path = strings.ReplaceAll(path, "/", `\`)
+	// \C:\src, as in the path of the file:///C:/src URL.
+	if len(path) >= 3 && path[0] == '\\' && isDrive(path[1:]) {
+		path = path[1:]
+	}
+	// \mnt\c\src, from WSL.
+	if rest, ok := strings.CutPrefix(path, `\mnt\`); ok && len(rest) >= 1 && isLetter(rest[0]) && (len(rest) == 1 || rest[1] == '\\') {
+		path = rest[:1] + ":" + cmp.Or(rest[1:], `\`)
+	}
+	if isDrive(path) {
+		path = strings.ToUpper(path[:1]) + path[1:]
+	}
+	return path
+}
+
+func normalizeWSL(path string) string {
+	if isDrive(path) {
+		rest := strings.ReplaceAll(path[2:], `\`, "/")
+		return "/mnt/" + strings.ToLower(path[:1]) + "/" + strings.TrimLeft(rest, "/")
+	}
+	// \\wsl$\Ubuntu\home and \\wsl.localhost\Ubuntu\home are the files of
+	// the distributions.
+	slashed := strings.ReplaceAll(path, `\`, "/")
+	for _, prefix := range []string{"//wsl$/", "//wsl.localhost/"} {
+		if rest, ok := cutPrefixFold(slashed, prefix); ok {
+			_, rest, _ = strings.Cut(rest, "/")
+			return "/" + rest
+		}
+	}
+	return path
+}
+
+// isDrive reports whether path starts with a drive letter and a colon.
+func isDrive(path string) bool {
+	return len(path) >= 2 && isLetter(path[0]) && path[1] == ':' &&
+		(len(path) == 2 || path[2] == '\\' || path[2] == '/')
+}
+
+func isLetter(b byte) bool {
+	return ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+}
+
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
@@ -0,0 +1,50 @@
+package filepathext
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		path string
+		goos string
+		wsl  bool
+		want string
+	}{
+		{name: "windows mixed separators", path: `C:/src\crush/main.go`, goos: "windows", want: `C:\src\crush\main.go`},
+		{name: "windows lower case drive", path: `c:\src`, goos: "windows", want: `C:\src`},
+		{name: "windows wsl path", path: "/mnt/c/Users/me/main.go", goos: "windows", want: `C:\Users\me\main.go`},
+		{name: "windows wsl drive", path: "/mnt/d", goos: "windows", want: `D:\`},
+		{name: "windows url path", path: "/C:/src/main.go", goos: "windows", want: `C:\src\main.go`},
+		{name: "windows unc", path: "//server/share/main.go", goos: "windows", want: `\\server\share\main.go`},
+		{name: "windows relative", path: "internal/app.go", goos: "windows", want: `internal\app.go`},
+		{name: "windows not a drive", path: "/mnt/cdrom/a", goos: "windows", want: `\mnt\cdrom\a`},
+		{name: "wsl windows path", path: `C:\Users\me\main.go`, goos: "linux", wsl: true, want: "/mnt/c/Users/me/main.go"},
+		{name: "wsl windows slashes", path: "D:/src", goos: "linux", wsl: true, want: "/mnt/d/src"},
+		{name: "wsl drive", path: "C:", goos: "linux", wsl: true, want: "/mnt/c/"},
+		{name: "wsl distribution path", path: `\\wsl$\Ubuntu\home\me`, goos: "linux", wsl: true, want: "/home/me"},
+		{name: "wsl localhost path", path: `\\WSL.localhost\Ubuntu\home\me`, goos: "linux", wsl: true, want: "/home/me"},
+		{name: "wsl posix path", path: "/home/me", goos: "linux", wsl: true, want: "/home/me"},
+		{name: "linux windows path", path: `C:\src`, goos: "linux", want: `C:\src`},
+		{name: "empty", path: "", goos: "windows", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, normalize(tt.path, tt.goos, tt.wsl))
+		})
+	}
+}
+
+func TestSamePath(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, SamePath("/home/me/", "/home/me"))
+	require.True(t, SamePath("/home/me/src/..", "/home/me"))
+	require.False(t, SamePath("/home/me", "/home/you"))
+}
@@ -0,0 +1,192 @@
+// Package focus lets editor plugins add the file or the selection they are
+// on to the prompt of a running Crush instance, through a unix socket in
+// the data directory of the project.
+//
+// A client writes a [Request] as a JSON line and reads a [Response] line
+// back. The text is only added to the prompt; nothing is sent until the
+// user does.
+package focus
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/charmbracelet/crush/internal/netext"
+)
+
+// timeout is how long a request and its response can take.
+const timeout = 5 * time.Second
+
+// ErrNotRunning is returned by [Send] when no instance serves the socket.
+var ErrNotRunning = errors.New("no Crush instance is running in the project")
+
+// Request is a file, or a selection of it, to add to the prompt.
+type Request struct {
+	// File is an absolute path.
+	File string `json:"file"`
+	// Line and EndLine are the 1-based lines of the selection, or the line
+	// the cursor is on when EndLine is 0.
+	Line      int    `json:"line,omitempty"`
+	EndLine   int    `json:"end_line,omitempty"`
+	Selection string `json:"selection,omitempty"`
+}
+
+// Response is the answer to a request.
+type Response struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// SocketPath returns the path of the socket of the instance with dataDir.
+func SocketPath(dataDir string) string {
+	return filepath.Join(dataDir, "focus.sock")
+}
+
+// Validate reports whether r can be added to the prompt.
+func (r Request) Validate() error {
+	switch {
+	case r.File == "":
+		return errors.New("the file is missing")
+	case !filepath.IsAbs(r.File):
+		return fmt.Errorf("the file %s isn't an absolute path", r.File)
+	case r.Line < 0 || r.EndLine < 0:
+		return errors.New("lines start at 1")
+	case r.EndLine != 0 && r.EndLine < r.Line:
+		return errors.New("the selection ends before it starts")
+	}
+	return nil
+}
+
+// Prompt returns the text r adds to the prompt: the file relative to
+// workingDir with its lines, and the selection in a code block.
+func (r Request) Prompt(workingDir string) string {
+	path := r.File
+	if rel, err := filepath.Rel(workingDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		path = filepath.ToSlash(rel)
+	}
+	switch {
+	case r.Line > 0 && r.EndLine > r.Line:
+		path += ":" + strconv.Itoa(r.Line) + "-" + strconv.Itoa(r.EndLine)
+	case r.Line > 0:
+		path += ":" + strconv.Itoa(r.Line)
+	}
+	if strings.ContainsFunc(path, unicode.IsSpace) {
+		path = "`" + path + "`"
+	}
+	if r.Selection == "" {
+		return path
+	}
+	fence := "```"
+	for strings.Contains(r.Selection, fence) {
+		fence += "`"
+	}
+	lang := strings.TrimPrefix(filepath.Ext(r.File), ".")
+	return path + "\n" + fence + lang + "\n" + strings.TrimRight(r.Selection, "\n") + "\n" + fence
+}
+
+// Server handles the requests sent to its socket.
+type Server struct {
+	listener net.Listener
+	wg       sync.WaitGroup
+}
+
+// Listen handles the requests sent to the unix socket path with handle. A
+// socket left behind by an instance that exited is replaced, but not one
+// that is still served.
+func Listen(path string, handle func(Request)) (*Server, error) {
+	// Requests put text in the prompt, so only the user can send them.
+	listener, err := netext.ListenPrivate(path)
+	if errors.Is(err, netext.ErrSocketInUse) {
+		return nil, fmt.Errorf("another instance is running at %s", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{listener: listener}
+	s.wg.Go(func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				if !errors.Is(err, net.ErrClosed) {
+					slog.Warn("Failed to accept a focus request", "error", err)
+				}
+				return
+			}
+			s.wg.Go(func() { serve(conn, handle) })
+		}
+	})
+	return s, nil
+}
+
+func serve(conn net.Conn, handle func(Request)) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+	var req Request
+	var resp Response
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	switch {
+	case err != nil:
+		resp.Error = err.Error()
+	default:
+		if err := json.Unmarshal(line, &req); err != nil {
+			resp.Error = "invalid request: " + err.Error()
+		} else if err := req.Validate(); err != nil {
+			resp.Error = err.Error()
+		} else {
+			handle(req)
+			resp.OK = true
+		}
+	}
+	data, _ := json.Marshal(resp)
+	_, _ = conn.Write(append(data, '\n'))
+}
+
+// Close stops handling requests and removes the socket.
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+// Send sends req to the instance serving the socket at path.
+func Send(path string, req Request) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	conn, err := net.DialTimeout("unix", path, timeout)
+	if err != nil {
+		return ErrNotRunning
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return fmt.Errorf("no response from Crush: %w", err)
+	}
+	var resp Response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return fmt.Errorf("invalid response from Crush: %w", err)
+	}
+	if !resp.OK {
+		return errors.New(resp.Error)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package focus
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestPrompt(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "internal/app/app.go", Request{File: "/project/internal/app/app.go"}.Prompt("/project"))
+	require.Equal(t, "main.go:42", Request{File: "/project/main.go", Line: 42}.Prompt("/project"))
+	require.Equal(t, "/elsewhere/main.go:3-5", Request{File: "/elsewhere/main.go", Line: 3, EndLine: 5}.Prompt("/project"))
+	require.Equal(t, "`my notes.md`", Request{File: "/project/my notes.md"}.Prompt("/project"))
+	require.Equal(t,
+		"main.go:10-11\n```go\nfunc main() {\n}\n```",
+		Request{File: "/project/main.go", Line: 10, EndLine: 11, Selection: "func main() {\n}\n"}.Prompt("/project"),
+	)
+	require.Equal(t,
+		"README.md\n````md\n```sh\ncrush\n```\n````",
+		Request{File: "/project/README.md", Selection: "```sh\ncrush\n```"}.Prompt("/project"),
+		"the fence is longer than the ones of the selection",
+	)
+}
+
+func TestRequestValidate(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, Request{File: "/project/main.go", Line: 1}.Validate())
+	require.ErrorContains(t, Request{}.Validate(), "missing")
+	require.ErrorContains(t, Request{File: "main.go"}.Validate(), "absolute")
+	require.ErrorContains(t, Request{File: "/project/main.go", Line: 5, EndLine: 2}.Validate(), "ends before")
+}
+
+func TestSend(t *testing.T) {
+	t.Parallel()
+
+	// Temporary directories of tests can be too long for a socket on macOS.
+	dir, err := os.MkdirTemp("", "crush")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := SocketPath(dir)
+
+	req := Request{File: "/project/main.go", Line: 42}
+	require.ErrorIs(t, Send(path, req), ErrNotRunning)
+
+	received := make(chan Request, 1)
+	s, err := Listen(path, func(r Request) { received <- r })
+	require.NoError(t, err)
+
+	require.NoError(t, Send(path, req))
+	require.Equal(t, req, <-received)
+	require.ErrorContains(t, Send(path, Request{File: "main.go"}), "absolute")
+
+	require.NoError(t, s.Close())
+	_, err = os.Stat(filepath.Join(dir, "focus.sock"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -60,13 +60,14 @@ func SkipHidden(path string) bool {
 // FastGlobWalker provides gitignore-aware file walking with fastwalk
 // It uses hierarchical ignore checking like git does, checking .gitignore/.crushignore
 // files in each directory from the root to the target path.
+// With noIgnore, only version control directories are skipped.
 type FastGlobWalker struct {
 	directoryLister *directoryLister
 }
 
-func NewFastGlobWalker(searchPath string) *FastGlobWalker {
+func NewFastGlobWalker(searchPath string, noIgnore bool) *FastGlobWalker {
 	return &FastGlobWalker{
-		directoryLister: NewDirectoryLister(searchPath),
+		directoryLister: newDirectoryLister(searchPath, noIgnore),
 	}
 }
 
@@ -76,12 +77,12 @@ func (w *FastGlobWalker) ShouldSkip(path string) bool {
 	return w.directoryLister.shouldIgnore(path, nil)
 }
 
-func GlobWithDoubleStar(pattern, searchPath string, limit int) ([]string, bool, error) {
+func GlobWithDoubleStar(pattern, searchPath string, limit int, noIgnore bool) ([]string, bool, error) {
 	// Normalize pattern to forward slashes on Windows so their config can use
 	// backslashes
 	pattern = filepath.ToSlash(pattern)
 
-	walker := NewFastGlobWalker(searchPath)
+	walker := NewFastGlobWalker(searchPath, noIgnore)
 	found := csync.NewSlice[FileInfo]()
 	conf := fastwalk.Config{
 		Follow:  true,
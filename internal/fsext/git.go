@@ -0,0 +1,71 @@
+package fsext
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// GitChangedFiles returns the files git status lists in the repository dir
+// is in: the changed, deleted and untracked ones, as absolute paths. Renamed
+// files are listed with their original path too.
+func GitChangedFiles(ctx context.Context, dir string) ([]string, error) {
+	out, err := exec.CommandContext(ctx, "git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return nil, err
+	}
+	root := strings.TrimSpace(string(out))
+	status, err := exec.CommandContext(ctx, "git", "-C", root, "status", "--porcelain", "-z", "--untracked-files=all").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	entries := bytes.Split(status, []byte{0})
+	for i := 0; i < len(entries); i++ {
+		// Entries are "XY path", followed by the original path for renames
+		// and copies.
+		entry := string(entries[i])
+		if len(entry) < 4 {
+			continue
+		}
+		files = append(files, filepath.Join(root, entry[3:]))
+		if (entry[0] == 'R' || entry[0] == 'C') && i+1 < len(entries) {
+			i++
+			files = append(files, filepath.Join(root, string(entries[i])))
+		}
+	}
+	return files, nil
+}
+
+// maxGitContextLines is how many lines of git status and diff stat
+// GitWorkingTree returns.
+const maxGitContextLines = 20
+
+// GitWorkingTree returns the short git status and the diff stat of the
+// uncommitted changes of the repository dir is in, each cut to a few lines.
+// Both are empty when dir isn't in a repository.
+func GitWorkingTree(ctx context.Context, dir string) (status, diffStat string) {
+	out, err := exec.CommandContext(ctx, "git", "-C", dir, "status", "--short", "--branch").Output()
+	if err != nil {
+		return "", ""
+	}
+	status = truncateLines(string(out), maxGitContextLines)
+	if out, err := exec.CommandContext(ctx, "git", "-C", dir, "diff", "HEAD", "--stat").Output(); err == nil {
+		diffStat = truncateLines(string(out), maxGitContextLines)
+	}
+	return status, diffStat
+}
+
+// truncateLines returns the first n lines of s, noting how many were left
+// out.
+func truncateLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) <= n {
+		return strings.Join(lines, "\n")
+	}
+	return strings.Join(lines[:n], "\n") + fmt.Sprintf("\n… %d more lines", len(lines)-n)
+}
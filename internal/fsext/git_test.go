@@ -0,0 +1,84 @@
+package fsext
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitChangedFiles(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		out, err := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	write := func(name string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644))
+	}
+
+	_, err := GitChangedFiles(t.Context(), dir)
+	require.Error(t, err, "not a repository")
+
+	git("init", "-q")
+	write("kept.txt")
+	write("old.txt")
+	git("add", ".")
+	git("commit", "-q", "-m", "first")
+
+	write("sub/new file.txt")
+	git("mv", "old.txt", "renamed.txt")
+
+	files, err := GitChangedFiles(t.Context(), filepath.Join(dir, "sub"))
+	require.NoError(t, err)
+	root, err := filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{
+		filepath.Join(root, "renamed.txt"),
+		filepath.Join(root, "old.txt"),
+		filepath.Join(root, "sub", "new file.txt"),
+	}, files)
+}
+
+func TestGitWorkingTree(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	status, diffStat := GitWorkingTree(t.Context(), dir)
+	require.Empty(t, status, "not a repository")
+	require.Empty(t, diffStat)
+
+	git := func(args ...string) {
+		out, err := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git("init", "-q")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0o644))
+	git("add", ".")
+	git("commit", "-q", "-m", "first")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\nb\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.txt"), []byte("b\n"), 0o644))
+
+	status, diffStat = GitWorkingTree(t.Context(), dir)
+	require.Contains(t, status, " M a.txt")
+	require.Contains(t, status, "?? b.txt")
+	require.Contains(t, diffStat, "a.txt | 1 +")
+}
+
+func TestTruncateLines(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "a\nb", truncateLines("a\nb\n", 2))
+	require.Equal(t, "a\nb\n… 2 more lines", truncateLines("a\nb\nc\nd\n", 2))
+}
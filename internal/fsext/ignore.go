@@ -0,0 +1,246 @@
+package fsext
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/home"
+	ignore "github.com/sabhiram/go-gitignore"
+)
+
+// commonIgnorePatterns contains commonly ignored files and directories
+var commonIgnorePatterns = sync.OnceValue(func() ignore.IgnoreParser {
+	return ignore.CompileIgnoreLines(
+		// Version control
+		".git",
+		".svn",
+		".hg",
+		".bzr",
+
+		// IDE and editor files
+		".vscode",
+		".idea",
+		"*.swp",
+		"*.swo",
+		"*~",
+		".DS_Store",
+		"Thumbs.db",
+
+		// Build artifacts and dependencies
+		"node_modules",
+		"target",
+		"build",
+		"dist",
+		"out",
+		"bin",
+		"obj",
+		"*.o",
+		"*.so",
+		"*.dylib",
+		"*.dll",
+		"*.exe",
+
+		// Logs and temporary files
+		"*.log",
+		"*.tmp",
+		"*.temp",
+		".cache",
+		".tmp",
+
+		// Language-specific
+		"__pycache__",
+		"*.pyc",
+		"*.pyo",
+		".pytest_cache",
+		"vendor",
+		"Cargo.lock",
+		"package-lock.json",
+		"yarn.lock",
+		"pnpm-lock.yaml",
+
+		// OS generated files
+		".Trash",
+		".Spotlight-V100",
+		".fseventsd",
+
+		// Crush
+		".crush",
+
+		// macOS stuff
+		"OrbStack",
+		".local",
+		".share",
+	)
+})
+
+var homeIgnore = sync.OnceValue(func() ignore.IgnoreParser {
+	home := home.Dir()
+	var lines []string
+	for _, name := range []string{
+		filepath.Join(home, ".gitignore"),
+		filepath.Join(home, ".config", "git", "ignore"),
+		filepath.Join(home, ".config", "crush", "ignore"),
+	} {
+		if bts, err := os.ReadFile(name); err == nil {
+			lines = append(lines, strings.Split(string(bts), "\n")...)
+		}
+	}
+	return ignore.CompileIgnoreLines(lines...)
+})
+
+// configIgnore holds the ignore_patterns option.
+var configIgnore atomic.Pointer[ignore.GitIgnore]
+
+// SetIgnorePatterns sets the patterns, in .gitignore syntax, skipped on top
+// of the ignore files. They come from the ignore_patterns option.
+func SetIgnorePatterns(patterns []string) {
+	configIgnore.Store(ignore.CompileIgnoreLines(patterns...))
+}
+
+// directoryLister is the ignore engine shared by the ls, glob and grep
+// tools and the file completions.
+type directoryLister struct {
+	ignores  *csync.Map[string, ignore.IgnoreParser]
+	rootPath string
+	// noIgnore skips none of the ignore rules, for when the agent asks for
+	// the ignored files too. Version control directories are still skipped.
+	noIgnore bool
+}
+
+func NewDirectoryLister(rootPath string) *directoryLister {
+	return newDirectoryLister(rootPath, false)
+}
+
+func newDirectoryLister(rootPath string, noIgnore bool) *directoryLister {
+	dl := &directoryLister{
+		rootPath: rootPath,
+		ignores:  csync.NewMap[string, ignore.IgnoreParser](),
+		noIgnore: noIgnore,
+	}
+	if !noIgnore {
+		dl.getIgnore(rootPath)
+	}
+	return dl
+}
+
+// git checks, in order:
+// - ./.gitignore, ../.gitignore, etc, until repo root
+// ~/.config/git/ignore
+// ~/.gitignore
+//
+// This will do the following:
+// - the given ignorePatterns
+// - [commonIgnorePatterns]
+// - the ignore_patterns option
+// - ./.gitignore, ../.gitignore, etc, until dl.rootPath
+// - ./.crushignore, ../.crushignore, etc, until dl.rootPath
+// ~/.config/git/ignore
+// ~/.gitignore
+// ~/.config/crush/ignore
+func (dl *directoryLister) shouldIgnore(path string, ignorePatterns []string) bool {
+	if len(ignorePatterns) > 0 {
+		base := filepath.Base(path)
+		for _, pattern := range ignorePatterns {
+			if matched, err := filepath.Match(pattern, base); err == nil && matched {
+				return true
+			}
+		}
+	}
+
+	// Don't apply gitignore rules to the root directory itself
+	// In gitignore semantics, patterns don't apply to the repo root
+	if path == dl.rootPath {
+		return false
+	}
+
+	if dl.noIgnore {
+		return isVersionControlDir(filepath.Base(path))
+	}
+
+	relPath, err := filepath.Rel(dl.rootPath, path)
+	if err != nil {
+		relPath = path
+	}
+
+	if commonIgnorePatterns().MatchesPath(relPath) {
+		slog.Debug("ignoring common pattern", "path", relPath)
+		return true
+	}
+
+	if patterns := configIgnore.Load(); patterns != nil &&
+		(patterns.MatchesPath(relPath) || patterns.MatchesPath(relPath+"/")) {
+		slog.Debug("ignoring configured pattern", "path", relPath)
+		return true
+	}
+
+	parentDir := filepath.Dir(path)
+	ignoreParser := dl.getIgnore(parentDir)
+	if ignoreParser.MatchesPath(relPath) {
+		slog.Debug("ignoring dir pattern", "path", relPath, "dir", parentDir)
+		return true
+	}
+
+	// For directories, also check with trailing slash (gitignore convention)
+	if ignoreParser.MatchesPath(relPath + "/") {
+		slog.Debug("ignoring dir pattern with slash", "path", relPath+"/", "dir", parentDir)
+		return true
+	}
+
+	if dl.checkParentIgnores(relPath) {
+		return true
+	}
+
+	if homeIgnore().MatchesPath(relPath) {
+		slog.Debug("ignoring home dir pattern", "path", relPath)
+		return true
+	}
+
+	return false
+}
+
+func (dl *directoryLister) checkParentIgnores(path string) bool {
+	parent := filepath.Dir(filepath.Dir(path))
+	for parent != "." && path != "." {
+		if dl.getIgnore(parent).MatchesPath(path) {
+			slog.Debug("ingoring parent dir pattern", "path", path, "dir", parent)
+			return true
+		}
+		if parent == dl.rootPath || parent == filepath.Dir(parent) {
+			break
+		}
+		parent = filepath.Dir(parent)
+	}
+	return false
+}
+
+func (dl *directoryLister) getIgnore(path string) ignore.IgnoreParser {
+	return dl.ignores.GetOrSet(path, func() ignore.IgnoreParser {
+		var lines []string
+		for _, ign := range []string{".crushignore", ".gitignore"} {
+			name := filepath.Join(path, ign)
+			if content, err := os.ReadFile(name); err == nil {
+				lines = append(lines, strings.Split(string(content), "\n")...)
+			}
+		}
+		if len(lines) == 0 {
+			// Return a no-op parser to avoid nil checks
+			return ignore.CompileIgnoreLines()
+		}
+		return ignore.CompileIgnoreLines(lines...)
+	})
+}
+
+// isVersionControlDir reports whether name is the metadata directory of a
+// version control system.
+func isVersionControlDir(name string) bool {
+	switch name {
+	case ".git", ".svn", ".hg", ".bzr":
+		return true
+	}
+	return false
+}
@@ -120,3 +120,29 @@ func TestShouldExcludeFileCommonPatterns(t *testing.T) {
 		require.True(t, ShouldExcludeFile(tempDir, dir), "Expected %s to be ignored by common patterns", filepath.Base(dir))
 	}
 }
+
+func TestIgnorePatterns(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"api.generated.go", "main.go", "testdata/golden.txt", ".git/HEAD"} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(tempDir, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, name), []byte("test"), 0o644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, ".gitignore"), []byte("main.go\n"), 0o644))
+
+	SetIgnorePatterns([]string{"*.generated.go", "testdata/"})
+	t.Cleanup(func() { SetIgnorePatterns(nil) })
+
+	files, _, err := ListDirectory(tempDir, nil, -1, -1, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{".gitignore"}, relPaths(t, files, tempDir))
+
+	files, _, err = ListDirectory(tempDir, nil, -1, -1, true)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{
+		".gitignore",
+		"api.generated.go",
+		"main.go",
+		"testdata",
+		"testdata/golden.txt",
+	}, relPaths(t, files, tempDir), "only version control directories are skipped")
+}
@@ -28,7 +28,7 @@ func TestListDirectory(t *testing.T) {
 	}
 
 	t.Run("no limit", func(t *testing.T) {
-		files, truncated, err := ListDirectory(tmp, nil, -1, -1)
+		files, truncated, err := ListDirectory(tmp, nil, -1, -1, false)
 		require.NoError(t, err)
 		require.False(t, truncated)
 		require.Len(t, files, 4)
@@ -40,7 +40,7 @@ func TestListDirectory(t *testing.T) {
 		}, relPaths(t, files, tmp))
 	})
 	t.Run("limit", func(t *testing.T) {
-		files, truncated, err := ListDirectory(tmp, nil, -1, 2)
+		files, truncated, err := ListDirectory(tmp, nil, -1, 2, false)
 		require.NoError(t, err)
 		require.True(t, truncated)
 		require.Len(t, files, 2)
@@ -57,3 +57,29 @@ func relPaths(tb testing.TB, in []string, base string) []string {
 	}
 	return out
 }
+
+func TestReadDir(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	for name, content := range map[string]string{
+		".gitignore":     "*.log\n",
+		"build.log":      "build output",
+		"main.go":        "package main",
+		"b/file.go":      "package b",
+		"a/file.go":      "package a",
+		"node_modules/x": "ignored by default",
+	} {
+		fp := filepath.Join(tmp, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fp), 0o755))
+		require.NoError(t, os.WriteFile(fp, []byte(content), 0o644))
+	}
+
+	entries, err := ReadDir(tmp, tmp)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	require.Equal(t, []string{"a", "b", ".gitignore", "main.go"}, names)
+}
@@ -0,0 +1,53 @@
+package fsext
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/filepathext"
+)
+
+// ResolveScope returns the absolute path of dir, a directory relative to
+// workingDir or absolute, checking that it is workingDir or inside it.
+func ResolveScope(workingDir, dir string) (string, error) {
+	scope := filepath.Clean(filepathext.SmartJoin(workingDir, dir))
+	if !InScope(scope, workingDir) {
+		return "", fmt.Errorf("scope %s is outside the working directory %s", dir, workingDir)
+	}
+	info, err := os.Stat(scope)
+	if err != nil {
+		return "", fmt.Errorf("invalid scope %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("invalid scope %s: not a directory", dir)
+	}
+	return scope, nil
+}
+
+// InScope reports whether path, an absolute one, is scope or inside it. The
+// symbolic links are followed, so that a link inside scope to a file out of
+// it isn't.
+func InScope(path, scope string) bool {
+	rel, err := filepath.Rel(evalSymlinks(scope), evalSymlinks(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// evalSymlinks returns path with the symbolic links followed, up to the
+// deepest of its directories that exists, for the files about to be created.
+func evalSymlinks(path string) string {
+	path = filepath.Clean(path)
+	var missing []string
+	for {
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...)
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return filepath.Join(append([]string{path}, missing...)...)
+		}
+		missing = append([]string{filepath.Base(path)}, missing...)
+		path = parent
+	}
+}
@@ -0,0 +1,50 @@
+package fsext
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveScope(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "packages", "api"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), nil, 0o644))
+
+	scope, err := ResolveScope(dir, "packages/api")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "packages", "api"), scope)
+
+	scope, err = ResolveScope(dir, "")
+	require.NoError(t, err)
+	require.Equal(t, dir, scope)
+
+	_, err = ResolveScope(dir, "..")
+	require.ErrorContains(t, err, "outside the working directory")
+	_, err = ResolveScope(dir, "packages/web")
+	require.Error(t, err)
+	_, err = ResolveScope(dir, "go.mod")
+	require.ErrorContains(t, err, "not a directory")
+}
+
+func TestInScopeSymlinks(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	outside := t.TempDir()
+	scope := filepath.Join(dir, "packages", "api")
+	require.NoError(t, os.MkdirAll(scope, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "secret"), nil, 0o644))
+	if err := os.Symlink(outside, filepath.Join(scope, "link")); err != nil {
+		t.Skipf("symbolic links aren't supported: %v", err)
+	}
+
+	require.True(t, InScope(filepath.Join(scope, "main.go"), scope))
+	require.True(t, InScope(filepath.Join(scope, "new", "file.go"), scope), "files to be created are in scope")
+	require.False(t, InScope(filepath.Join(scope, "link", "secret"), scope))
+	require.False(t, InScope(filepath.Join(scope, "link", "new.go"), scope))
+}
@@ -2,9 +2,6 @@ package history
 
 import (
 	"context"
-	"database/sql"
-	"fmt"
-	"strings"
 
 	"github.com/charmbracelet/crush/internal/db"
 	"github.com/charmbracelet/crush/internal/pubsub"
@@ -39,15 +36,13 @@ type Service interface {
 
 type service struct {
 	*pubsub.Broker[File]
-	db *sql.DB
-	q  *db.Queries
+	q db.Querier
 }
 
-func NewService(q *db.Queries, db *sql.DB) Service {
+func NewService(q db.Querier) Service {
 	return &service{
 		Broker: pubsub.NewBroker[File](),
 		q:      q,
-		db:     db,
 	}
 }
 
@@ -75,48 +70,27 @@ func (s *service) CreateVersion(ctx context.Context, sessionID, path, content st
 }
 
 func (s *service) createWithVersion(ctx context.Context, sessionID, path, content string, version int64) (File, error) {
-	// Maximum number of retries for transaction conflicts
+	// Maximum number of retries for version conflicts
 	const maxRetries = 3
 	var file File
 	var err error
 
-	// Retry loop for transaction conflicts
 	for attempt := range maxRetries {
-		// Start a transaction
-		tx, txErr := s.db.BeginTx(ctx, nil)
-		if txErr != nil {
-			return File{}, fmt.Errorf("failed to begin transaction: %w", txErr)
-		}
-
-		// Create a new queries instance with the transaction
-		qtx := s.q.WithTx(tx)
-
-		// Try to create the file within the transaction
-		dbFile, txErr := qtx.CreateFile(ctx, db.CreateFileParams{
+		dbFile, createErr := s.q.CreateFile(ctx, db.CreateFileParams{
 			ID:        uuid.New().String(),
 			SessionID: sessionID,
 			Path:      path,
 			Content:   content,
 			Version:   version,
 		})
-		if txErr != nil {
-			// Rollback the transaction
-			tx.Rollback()
-
-			// Check if this is a uniqueness constraint violation
-			if strings.Contains(txErr.Error(), "UNIQUE constraint failed") {
-				if attempt < maxRetries-1 {
-					// If we have retries left, increment version and try again
-					version++
-					continue
-				}
+		if createErr != nil {
+			// Another version of this file was created concurrently.
+			if db.IsUniqueViolation(createErr) && attempt < maxRetries-1 {
+				// If we have retries left, increment version and try again
+				version++
+				continue
 			}
-			return File{}, txErr
-		}
-
-		// Commit the transaction
-		if txErr = tx.Commit(); txErr != nil {
-			return File{}, fmt.Errorf("failed to commit transaction: %w", txErr)
+			return File{}, createErr
 		}
 
 		file = s.fromDBItem(dbFile)
@@ -0,0 +1,188 @@
+// Package hooks runs user configured shell commands on lifecycle events.
+//
+// Each command gets the event as JSON on stdin. A command that exits with
+// code 2 blocks the event, with its stderr as the reason. Any other failure
+// is logged and ignored, so a broken hook never stops the agent.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/shell"
+)
+
+// Event is a lifecycle event hooks run on.
+type Event string
+
+const (
+	PreToolUse   Event = "pre_tool_use"
+	PostToolUse  Event = "post_tool_use"
+	OnSessionEnd Event = "on_session_end"
+	OnFileEdit   Event = "on_file_edit"
+)
+
+const (
+	defaultTimeout = 60 * time.Second
+	// blockExitCode is the exit code hooks use to block an event.
+	blockExitCode = 2
+)
+
+// Payload is the JSON sent to hooks on stdin.
+type Payload struct {
+	Event      Event           `json:"event"`
+	SessionID  string          `json:"session_id,omitempty"`
+	WorkingDir string          `json:"working_dir"`
+	ToolName   string          `json:"tool_name,omitempty"`
+	ToolInput  json.RawMessage `json:"tool_input,omitempty"`
+	ToolOutput string          `json:"tool_output,omitempty"`
+	ToolError  bool            `json:"tool_error,omitempty"`
+	FilePath   string          `json:"file_path,omitempty"`
+}
+
+// Result is the combined outcome of the hooks run for an event.
+type Result struct {
+	// Blocked is set when a hook exited with code 2.
+	Blocked bool
+	// Reason is the stderr of the hook that blocked the event.
+	Reason string
+	// ToolInput is the rewritten tool input, if a pre_tool_use hook printed
+	// one.
+	ToolInput json.RawMessage
+}
+
+// Runner runs the configured hooks.
+type Runner struct {
+	mu         sync.RWMutex
+	hooks      config.Hooks
+	workingDir string
+}
+
+// New returns a runner for hooks, run in workingDir.
+func New(hooks config.Hooks, workingDir string) *Runner {
+	return &Runner{hooks: hooks, workingDir: workingDir}
+}
+
+// Set replaces the hooks run, like when the working directory becomes
+// trusted and the ones of the project apply.
+func (r *Runner) Set(hooks config.Hooks) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = hooks
+}
+
+func (r *Runner) forEvent(event Event) []config.Hook {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	switch event {
+	case PreToolUse:
+		return r.hooks.PreToolUse
+	case PostToolUse:
+		return r.hooks.PostToolUse
+	case OnSessionEnd:
+		return r.hooks.OnSessionEnd
+	case OnFileEdit:
+		return r.hooks.OnFileEdit
+	}
+	return nil
+}
+
+// Has reports whether any hooks are configured for event.
+func (r *Runner) Has(event Event) bool {
+	return r != nil && len(r.forEvent(event)) > 0
+}
+
+// Run runs the hooks of the payload's event that match subject, in order.
+// It stops at the first hook that blocks. A tool input rewritten by a
+// pre_tool_use hook is passed on to the hooks after it.
+func (r *Runner) Run(ctx context.Context, subject string, payload Payload) Result {
+	var result Result
+	if r == nil {
+		return result
+	}
+	payload.WorkingDir = r.workingDir
+	for _, hook := range r.forEvent(payload.Event) {
+		if !matches(hook.Matcher, subject) {
+			continue
+		}
+		blocked, reason, stdout := r.run(ctx, hook, payload)
+		if blocked {
+			return Result{Blocked: true, Reason: reason}
+		}
+		if payload.Event == PreToolUse {
+			if input := rewrittenInput(stdout); input != nil {
+				payload.ToolInput = input
+				result.ToolInput = input
+			}
+		}
+	}
+	return result
+}
+
+func (r *Runner) run(ctx context.Context, hook config.Hook, payload Payload) (blocked bool, reason, stdout string) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		slog.Error("Failed to encode hook payload", "event", payload.Event, "error", err)
+		return false, "", ""
+	}
+
+	timeout := defaultTimeout
+	if hook.Timeout > 0 {
+		timeout = time.Duration(hook.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	sh := shell.NewShell(&shell.Options{WorkingDir: r.workingDir})
+	stdout, stderr, err := sh.ExecInput(ctx, hook.Command, bytes.NewReader(data))
+	switch code := shell.ExitCode(err); {
+	case err == nil:
+		return false, "", stdout
+	case code == blockExitCode:
+		reason = strings.TrimSpace(stderr)
+		if reason == "" {
+			reason = fmt.Sprintf("blocked by %s hook %q", payload.Event, hook.Command)
+		}
+		slog.Info("Hook blocked event", "event", payload.Event, "command", hook.Command, "reason", reason)
+		return true, reason, stdout
+	default:
+		slog.Warn("Hook failed", "event", payload.Event, "command", hook.Command, "error", err, "stderr", stderr)
+		return false, "", ""
+	}
+}
+
+func matches(matcher, subject string) bool {
+	if matcher == "" {
+		return true
+	}
+	re, err := regexp.Compile(matcher)
+	if err != nil {
+		slog.Warn("Invalid hook matcher", "matcher", matcher, "error", err)
+		return false
+	}
+	return re.MatchString(subject)
+}
+
+// rewrittenInput returns the tool input a hook printed as
+// {"tool_input": {...}}, if any.
+func rewrittenInput(stdout string) json.RawMessage {
+	stdout = strings.TrimSpace(stdout)
+	if !strings.HasPrefix(stdout, "{") {
+		return nil
+	}
+	var out struct {
+		ToolInput json.RawMessage `json:"tool_input"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &out); err != nil || len(out.ToolInput) == 0 {
+		return nil
+	}
+	return out.ToolInput
+}
@@ -0,0 +1,90 @@
+package hooks
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	t.Run("passes the payload on stdin", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		r := New(config.Hooks{
+			OnFileEdit: []config.Hook{{Command: "cat > payload.json"}},
+		}, dir)
+		result := r.Run(t.Context(), "main.go", Payload{Event: OnFileEdit, SessionID: "s1", FilePath: "main.go"})
+		require.False(t, result.Blocked)
+
+		data, err := os.ReadFile(filepath.Join(dir, "payload.json"))
+		require.NoError(t, err)
+		var payload Payload
+		require.NoError(t, json.Unmarshal(data, &payload))
+		require.Equal(t, Payload{Event: OnFileEdit, SessionID: "s1", WorkingDir: dir, FilePath: "main.go"}, payload)
+	})
+
+	t.Run("exit code 2 blocks", func(t *testing.T) {
+		t.Parallel()
+
+		r := New(config.Hooks{
+			PreToolUse: []config.Hook{
+				{Command: "echo 'no rm allowed' >&2; exit 2", Matcher: "^bash$"},
+				{Command: "exit 2"},
+			},
+		}, t.TempDir())
+		result := r.Run(t.Context(), "bash", Payload{Event: PreToolUse, ToolName: "bash"})
+		require.True(t, result.Blocked)
+		require.Equal(t, "no rm allowed", result.Reason)
+	})
+
+	t.Run("matcher skips other subjects", func(t *testing.T) {
+		t.Parallel()
+
+		r := New(config.Hooks{
+			PreToolUse: []config.Hook{{Command: "exit 2", Matcher: "^bash$"}},
+		}, t.TempDir())
+		require.False(t, r.Run(t.Context(), "view", Payload{Event: PreToolUse, ToolName: "view"}).Blocked)
+	})
+
+	t.Run("failing hooks are ignored", func(t *testing.T) {
+		t.Parallel()
+
+		r := New(config.Hooks{
+			PostToolUse: []config.Hook{{Command: "exit 1"}},
+		}, t.TempDir())
+		require.Equal(t, Result{}, r.Run(t.Context(), "view", Payload{Event: PostToolUse, ToolName: "view"}))
+	})
+
+	t.Run("rewrites tool input", func(t *testing.T) {
+		t.Parallel()
+
+		r := New(config.Hooks{
+			PreToolUse: []config.Hook{
+				{Command: `echo '{"tool_input": {"command": "ls -la"}}'`},
+				{Command: "echo 'not json'"},
+			},
+		}, t.TempDir())
+		result := r.Run(t.Context(), "bash", Payload{
+			Event:     PreToolUse,
+			ToolName:  "bash",
+			ToolInput: json.RawMessage(`{"command": "ls"}`),
+		})
+		require.False(t, result.Blocked)
+		require.JSONEq(t, `{"command": "ls -la"}`, string(result.ToolInput))
+	})
+
+	t.Run("nil runner", func(t *testing.T) {
+		t.Parallel()
+
+		var r *Runner
+		require.False(t, r.Has(PreToolUse))
+		require.Equal(t, Result{}, r.Run(t.Context(), "bash", Payload{Event: PreToolUse}))
+	})
+}
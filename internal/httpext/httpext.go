@@ -0,0 +1,152 @@
+// Package httpext builds the HTTP clients of Crush, so that the requests to
+// the providers, Catwalk, the web tools and the MCP servers all go through
+// the configured proxy and trust the configured CA certificates, like the
+// ones of corporate TLS interception.
+package httpext
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Options are the network settings of the configuration.
+type Options struct {
+	// Proxy is the URL of the proxy of the requests. HTTPS_PROXY and
+	// HTTP_PROXY are used when it's empty.
+	Proxy string
+	// NoProxy lists the hosts reached without the proxy, in the syntax of
+	// NO_PROXY, which is used when it's empty.
+	NoProxy []string
+	// CABundle is the path of a PEM file of root CA certificates trusted on
+	// top of the ones of the system.
+	CABundle string
+}
+
+// IsZero reports whether the options leave the network as it is.
+func (o Options) IsZero() bool {
+	return o.Proxy == "" && len(o.NoProxy) == 0 && o.CABundle == ""
+}
+
+var (
+	// systemTransport is the default transport of the process before
+	// Configure replaces it.
+	systemTransport = http.DefaultTransport
+	configured      atomic.Pointer[http.Transport]
+)
+
+// Configure sets up the transport of the clients from opts, and makes it the
+// default transport of the process for the libraries that don't take a
+// client. Zero options restore the default transport.
+func Configure(opts Options) error {
+	if opts.IsZero() {
+		configured.Store(nil)
+		http.DefaultTransport = systemTransport
+		return nil
+	}
+	base, ok := systemTransport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("unexpected default transport %T", systemTransport)
+	}
+	t := base.Clone()
+
+	proxy := httpproxy.FromEnvironment()
+	if opts.Proxy != "" {
+		u, err := url.Parse(opts.Proxy)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("invalid proxy URL %q", opts.Proxy)
+		}
+		proxy.HTTPProxy, proxy.HTTPSProxy = opts.Proxy, opts.Proxy
+	}
+	if len(opts.NoProxy) > 0 {
+		proxy.NoProxy = strings.Join(opts.NoProxy, ",")
+	}
+	proxyURL := proxy.ProxyFunc()
+	t.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxyURL(req.URL)
+	}
+
+	if opts.CABundle != "" {
+		pool, err := certPool(opts.CABundle)
+		if err != nil {
+			return err
+		}
+		t.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	configured.Store(t)
+	http.DefaultTransport = t
+	return nil
+}
+
+// certPool returns the system certificates and the ones in the PEM file at
+// path.
+func certPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", path)
+	}
+	return pool, nil
+}
+
+// Configured reports whether Configure was given options that change the
+// network.
+func Configured() bool {
+	return configured.Load() != nil
+}
+
+// DefaultTransport returns the transport shared by the clients that don't
+// need their own.
+func DefaultTransport() http.RoundTripper {
+	if t := configured.Load(); t != nil {
+		return t
+	}
+	return systemTransport
+}
+
+// NewTransport returns a transport of its own, with the proxy and the CA
+// certificates of DefaultTransport, for the clients that tune their
+// connections.
+func NewTransport() *http.Transport {
+	if t := configured.Load(); t != nil {
+		return t.Clone()
+	}
+	if t, ok := systemTransport.(*http.Transport); ok {
+		return t.Clone()
+	}
+	return &http.Transport{Proxy: http.ProxyFromEnvironment}
+}
+
+// NewClient returns a client on DefaultTransport, with timeout if it isn't
+// zero.
+func NewClient(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: DefaultTransport(), Timeout: timeout}
+}
+
+// maxIdleConnsPerHost is the idle connections a pooled client keeps to each
+// host, up from the 2 of the default transport.
+const maxIdleConnsPerHost = 10
+
+// NewPooledClient returns a client with a transport of its own that keeps
+// more connections to each host, for the tools that make many requests to
+// the same hosts, with timeout if it isn't zero.
+func NewPooledClient(timeout time.Duration) *http.Client {
+	t := NewTransport()
+	t.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	return &http.Client{Transport: t, Timeout: timeout}
+}
@@ -0,0 +1,106 @@
+package httpext
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The tests change the default transport of the process, so they don't run
+// in parallel.
+
+func configure(t *testing.T, opts Options) {
+	t.Helper()
+	require.NoError(t, Configure(opts))
+	t.Cleanup(func() { _ = Configure(Options{}) })
+}
+
+func TestConfigureProxy(t *testing.T) {
+	configure(t, Options{
+		Proxy:   "http://proxy.example.com:3128",
+		NoProxy: []string{".internal.example.com"},
+	})
+	require.True(t, Configured())
+	require.Same(t, http.DefaultTransport, DefaultTransport())
+
+	proxyOf := func(rawURL string) string {
+		req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+		require.NoError(t, err)
+		u, err := NewTransport().Proxy(req)
+		require.NoError(t, err)
+		if u == nil {
+			return ""
+		}
+		return u.String()
+	}
+	require.Equal(t, "http://proxy.example.com:3128", proxyOf("https://api.anthropic.com/v1/messages"))
+	require.Equal(t, "http://proxy.example.com:3128", proxyOf("http://example.com"))
+	require.Empty(t, proxyOf("https://git.internal.example.com"))
+}
+
+func TestConfigureInvalidProxy(t *testing.T) {
+	t.Cleanup(func() { _ = Configure(Options{}) })
+	require.Error(t, Configure(Options{Proxy: "proxy.example.com:3128"}))
+	require.False(t, Configured())
+}
+
+func TestConfigureCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	_, err := NewClient(0).Get(server.URL)
+	require.Error(t, err, "the certificate of the test server isn't trusted by default")
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(bundle, cert, 0o644))
+	configure(t, Options{CABundle: bundle})
+
+	resp, err := NewClient(0).Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
+
+func TestConfigureInvalidCABundle(t *testing.T) {
+	t.Cleanup(func() { _ = Configure(Options{}) })
+	dir := t.TempDir()
+	require.Error(t, Configure(Options{CABundle: filepath.Join(dir, "missing.pem")}))
+
+	bundle := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(bundle, []byte("not a certificate"), 0o644))
+	require.ErrorContains(t, Configure(Options{CABundle: bundle}), "no certificates found")
+}
+
+func TestConfigureZeroOptions(t *testing.T) {
+	configure(t, Options{Proxy: "http://proxy.example.com:3128"})
+	require.NoError(t, Configure(Options{}))
+	require.False(t, Configured())
+	require.Same(t, systemTransport, http.DefaultTransport)
+	require.Same(t, systemTransport, DefaultTransport())
+}
+
+func TestNewPooledClient(t *testing.T) {
+	configure(t, Options{Proxy: "http://proxy.example.com:3128"})
+
+	client := NewPooledClient(time.Minute)
+	require.Equal(t, time.Minute, client.Timeout)
+	transport, ok := client.Transport.(*http.Transport)
+	require.True(t, ok)
+	require.NotSame(t, DefaultTransport(), transport, "the transport is its own")
+	require.Equal(t, maxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.com", nil)
+	require.NoError(t, err)
+	u, err := transport.Proxy(req)
+	require.NoError(t, err)
+	require.Equal(t, "http://proxy.example.com:3128", u.String(), "it goes through the proxy")
+}
@@ -0,0 +1,39 @@
+// Package keychain stores secrets in the operating system's credential
+// store: the macOS Keychain, the Windows Credential Manager, or the Secret
+// Service on Linux and BSD.
+package keychain
+
+import (
+	"errors"
+
+	"github.com/zalando/go-keyring"
+)
+
+// service is the name Crush's entries are stored under.
+const service = "crush"
+
+// ErrNotFound is returned when there's no entry for a key.
+var ErrNotFound = errors.New("keychain entry not found")
+
+// Get returns the secret stored under key.
+func Get(key string) (string, error) {
+	secret, err := keyring.Get(service, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrNotFound
+	}
+	return secret, err
+}
+
+// Set stores secret under key, replacing any existing entry.
+func Set(key, secret string) error {
+	return keyring.Set(service, key, secret)
+}
+
+// Delete removes the entry for key.
+func Delete(key string) error {
+	err := keyring.Delete(service, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -8,13 +8,15 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/charmbracelet/crush/internal/httpext"
 )
 
 // NewHTTPClient creates an HTTP client with debug logging enabled when debug mode is on.
 func NewHTTPClient() *http.Client {
 	return &http.Client{
 		Transport: &HTTPRoundTripLogger{
-			Transport: http.DefaultTransport,
+			Transport: httpext.DefaultTransport(),
 		},
 	}
 }
@@ -68,7 +70,7 @@ func (h *HTTPRoundTripLogger) RoundTrip(req *http.Request) (*http.Response, erro
 			"HTTP Response",
 			"status_code", resp.StatusCode,
 			"status", resp.Status,
-			"headers", formatHeaders(resp.Header),
+			"headers", RedactHeaders(resp.Header),
 			"body", bodyToString(save),
 			"content_length", resp.ContentLength,
 			"duration_ms", duration.Milliseconds(),
@@ -95,8 +97,8 @@ func bodyToString(body io.ReadCloser) string {
 	return b.String()
 }
 
-// formatHeaders formats HTTP headers for logging, filtering out sensitive information.
-func formatHeaders(headers http.Header) map[string][]string {
+// RedactHeaders formats HTTP headers for logging, filtering out sensitive information.
+func RedactHeaders(headers http.Header) map[string][]string {
 	filtered := make(map[string][]string)
 	for key, values := range headers {
 		lowerKey := strings.ToLower(key)
@@ -45,7 +45,7 @@ func TestHTTPRoundTripLogger(t *testing.T) {
 	}
 }
 
-func TestFormatHeaders(t *testing.T) {
+func TestRedactHeaders(t *testing.T) {
 	headers := http.Header{
 		"Content-Type":  []string{"application/json"},
 		"Authorization": []string{"Bearer secret-token"},
@@ -53,7 +53,7 @@ func TestFormatHeaders(t *testing.T) {
 		"User-Agent":    []string{"test-agent"},
 	}
 
-	formatted := formatHeaders(headers)
+	formatted := RedactHeaders(headers)
 
 	// Check that sensitive headers are redacted
 	if formatted["Authorization"][0] != "[REDACTED]" {
@@ -16,6 +16,8 @@ import (
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/lsp/util"
+	"github.com/charmbracelet/crush/internal/shell"
 	powernap "github.com/charmbracelet/x/powernap/pkg/lsp"
 	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
 	"github.com/charmbracelet/x/powernap/pkg/transport"
@@ -44,25 +46,29 @@ type Client struct {
 	serverState atomic.Value
 }
 
-// New creates a new LSP client using the powernap implementation.
-func New(ctx context.Context, name string, config config.LSPConfig, resolver config.VariableResolver) (*Client, error) {
-	// Convert working directory to file URI
-	workDir, err := os.Getwd()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get working directory: %w", err)
-	}
-
-	rootURI := string(protocol.URIFromPath(workDir))
+// New creates a new LSP client using the powernap implementation, with
+// workDir as the root of the workspace.
+func New(ctx context.Context, name, workDir string, config config.LSPConfig, resolver config.VariableResolver) (*Client, error) {
+	rootURI := string(util.URIFromPath(workDir))
 
 	command, err := resolver.ResolveValue(config.Command)
 	if err != nil {
 		return nil, fmt.Errorf("invalid lsp command: %w", err)
 	}
 
+	command, args := home.Long(command), config.Args
+	// Run the server in the devcontainer when the project has the same path
+	// there, as the paths in the messages aren't translated. For the same
+	// reason, the servers of a remote project run locally on its mount.
+	if r := shell.CurrentRemote(); r != nil && r.Container != "" && r.Dir == filepath.ToSlash(r.MountDir) {
+		argv := r.ContainerCommand(workDir, config.Env, append([]string{command}, args...)...)
+		command, args = argv[0], argv[1:]
+	}
+
 	// Create powernap client config
 	clientConfig := powernap.ClientConfig{
-		Command: home.Long(command),
-		Args:    config.Args,
+		Command: command,
+		Args:    args,
 		RootURI: rootURI,
 		Environment: func() map[string]string {
 			env := make(map[string]string)
@@ -163,6 +169,21 @@ const (
 	StateDisabled
 )
 
+func (s ServerState) String() string {
+	switch s {
+	case StateStarting:
+		return "starting"
+	case StateReady:
+		return "ready"
+	case StateError:
+		return "error"
+	case StateDisabled:
+		return "disabled"
+	default:
+		return "unknown"
+	}
+}
+
 // GetServerState returns the current state of the LSP server
 func (c *Client) GetServerState() ServerState {
 	if val := c.serverState.Load(); val != nil {
@@ -181,6 +202,12 @@ func (c *Client) GetName() string {
 	return c.name
 }
 
+// AutoDiagnostics reports whether the diagnostics of the client are attached
+// to the results of the tools that change files.
+func (c *Client) AutoDiagnostics() bool {
+	return c.config.AutoDiagnosticsEnabled()
+}
+
 // SetDiagnosticsCallback sets the callback function for diagnostic changes
 func (c *Client) SetDiagnosticsCallback(callback func(name string, count int)) {
 	c.onDiagnosticsChanged = callback
@@ -265,7 +292,7 @@ func (c *Client) OpenFile(ctx context.Context, filepath string) error {
 		return nil
 	}
 
-	uri := string(protocol.URIFromPath(filepath))
+	uri := string(util.URIFromPath(filepath))
 
 	if _, exists := c.openFiles.Get(uri); exists {
 		return nil // Already open
@@ -292,7 +319,7 @@ func (c *Client) OpenFile(ctx context.Context, filepath string) error {
 
 // NotifyChange notifies the server about a file change.
 func (c *Client) NotifyChange(ctx context.Context, filepath string) error {
-	uri := string(protocol.URIFromPath(filepath))
+	uri := string(util.URIFromPath(filepath))
 
 	content, err := os.ReadFile(filepath)
 	if err != nil {
@@ -321,7 +348,7 @@ func (c *Client) NotifyChange(ctx context.Context, filepath string) error {
 
 // IsFileOpen checks if a file is currently open.
 func (c *Client) IsFileOpen(filepath string) bool {
-	uri := string(protocol.URIFromPath(filepath))
+	uri := string(util.URIFromPath(filepath))
 	_, exists := c.openFiles.Get(uri)
 	return exists
 }
@@ -366,7 +393,7 @@ func (c *Client) OpenFileOnDemand(ctx context.Context, filepath string) error {
 
 // GetDiagnosticsForFile ensures a file is open and returns its diagnostics.
 func (c *Client) GetDiagnosticsForFile(ctx context.Context, filepath string) ([]protocol.Diagnostic, error) {
-	documentURI := protocol.URIFromPath(filepath)
+	documentURI := util.URIFromPath(filepath)
 
 	// Make sure the file is open
 	if !c.IsFileOpen(filepath) {
@@ -445,6 +472,32 @@ func (c *Client) WaitForDiagnostics(ctx context.Context, d time.Duration) {
 	}
 }
 
+// WaitForDiagnosticsToSettle waits for the diagnostics to change, then for
+// them to stay the same for quiet, for up to d in all. Servers often publish
+// diagnostics in several rounds after a change.
+func (c *Client) WaitForDiagnosticsToSettle(ctx context.Context, quiet, d time.Duration) {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(d)
+	pv := c.diagnostics.Version()
+	var changed time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timeout:
+			return
+		case now := <-ticker.C:
+			if v := c.diagnostics.Version(); v != pv {
+				pv = v
+				changed = now
+			} else if !changed.IsZero() && now.Sub(changed) >= quiet {
+				return
+			}
+		}
+	}
+}
+
 // FindReferences finds all references to the symbol at the given position.
 func (c *Client) FindReferences(ctx context.Context, filepath string, line, character int, includeDeclaration bool) ([]protocol.Location, error) {
 	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
@@ -463,7 +516,7 @@ func HasRootMarkers(dir string, rootMarkers []string) bool {
 	}
 	for _, pattern := range rootMarkers {
 		// Use fsext.GlobWithDoubleStar to find matches
-		matches, _, err := fsext.GlobWithDoubleStar(pattern, dir, 1)
+		matches, _, err := fsext.GlobWithDoubleStar(pattern, dir, 1, false)
 		if err == nil && len(matches) > 0 {
 			return true
 		}
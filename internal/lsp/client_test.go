@@ -3,9 +3,12 @@ package lsp
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/env"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
 )
 
 func TestClient(t *testing.T) {
@@ -21,7 +24,7 @@ func TestClient(t *testing.T) {
 
 	// Test creating a powernap client - this will likely fail with echo
 	// but we can still test the basic structure
-	client, err := New(ctx, "test", cfg, config.NewEnvironmentVariableResolver(env.NewFromMap(map[string]string{
+	client, err := New(ctx, "test", t.TempDir(), cfg, config.NewEnvironmentVariableResolver(env.NewFromMap(map[string]string{
 		"THE_CMD": "echo",
 	})))
 	if err != nil {
@@ -55,3 +58,21 @@ func TestClient(t *testing.T) {
 		t.Logf("Close failed as expected with dummy command: %v", err)
 	}
 }
+
+func TestWaitForDiagnosticsToSettle(t *testing.T) {
+	t.Parallel()
+
+	c := &Client{diagnostics: csync.NewVersionedMap[protocol.DocumentURI, []protocol.Diagnostic]()}
+	go func() {
+		for range 3 {
+			time.Sleep(100 * time.Millisecond)
+			c.diagnostics.Set("file:///main.go", nil)
+		}
+	}()
+	start := time.Now()
+	c.WaitForDiagnosticsToSettle(t.Context(), 200*time.Millisecond, 5*time.Second)
+	elapsed := time.Since(start)
+	if elapsed < 500*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("expected to wait for the last round and the quiet period, waited %s", elapsed)
+	}
+}
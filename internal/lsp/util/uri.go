@@ -0,0 +1,37 @@
+package util
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+// URIFromPath returns the URI of the file at path, normalized first, see
+// [filepathext.Normalize]. Files on network shares get URIs with the server
+// as their host, as file://server/share/main.go.
+func URIFromPath(path string) protocol.DocumentURI {
+	path = filepathext.Normalize(path)
+	slashed := strings.ReplaceAll(path, `\`, "/")
+	if rest, ok := strings.CutPrefix(slashed, "//"); ok {
+		host, share, _ := strings.Cut(rest, "/")
+		u := url.URL{Scheme: "file", Host: host, Path: "/" + share}
+		return protocol.DocumentURI(u.String())
+	}
+	return protocol.URIFromPath(path)
+}
+
+// PathFromURI returns the path of the file at uri the way the operating
+// system takes it, for the URIs of files on network shares and of Windows
+// drives too.
+func PathFromURI(uri protocol.DocumentURI) (string, error) {
+	if u, err := url.Parse(string(uri)); err == nil && u.Host != "" && u.Host != "localhost" {
+		return filepathext.Normalize("//" + u.Host + u.Path), nil
+	}
+	path, err := uri.Path()
+	if err != nil {
+		return "", err
+	}
+	return filepathext.Normalize(path), nil
+}
@@ -0,0 +1,23 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURIFromPath(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, protocol.DocumentURI("file://server/share/main.go"), URIFromPath("//server/share/main.go"))
+
+	path, err := PathFromURI("file://server/share/main.go")
+	require.NoError(t, err)
+	require.Equal(t, "//server/share/main.go", path)
+
+	dir := t.TempDir()
+	path, err = PathFromURI(URIFromPath(dir))
+	require.NoError(t, err)
+	require.Equal(t, dir, path)
+}
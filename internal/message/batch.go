@@ -0,0 +1,345 @@
+package message
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/db"
+)
+
+// flushInterval is how often buffered message updates are written to the
+// database while a message is streaming.
+const flushInterval = 250 * time.Millisecond
+
+// writeBatcher buffers message updates in memory and writes them to the
+// database in batches. Every buffered update is also appended to a
+// write-ahead log so that no content is lost if Crush crashes before the
+// next flush. The log is synced to the disk on each flush tick rather than on
+// each update, for streaming not to wait on the disk for every delta: a
+// crash of Crush loses no update, but one of the system can lose the ones
+// of the last flushInterval. Each process has a log of its own, locked for as long as it
+// runs, so that instances on the same project don't replay or truncate the
+// updates of each other.
+type writeBatcher struct {
+	q db.Querier
+
+	mu      sync.Mutex
+	pending map[string]Message
+	wal     *os.File
+	// unsynced tells that the log has updates that may not be on the disk
+	// yet.
+	unsynced bool
+}
+
+func newWriteBatcher(ctx context.Context, q db.Querier, walDir string) (*writeBatcher, error) {
+	if err := os.MkdirAll(walDir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create write-ahead log directory: %w", err)
+	}
+	b := &writeBatcher{
+		q:       q,
+		pending: make(map[string]Message),
+	}
+	if err := b.recover(ctx, walDir); err != nil {
+		return nil, fmt.Errorf("failed to recover messages from write-ahead log: %w", err)
+	}
+	wal, err := createLog(walDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open write-ahead log: %w", err)
+	}
+	b.wal = wal
+	go b.loop(ctx)
+	return b, nil
+}
+
+// createLog creates the write-ahead log of the process in dir, locked until
+// it's closed.
+func createLog(dir string) (*os.File, error) {
+	for {
+		f, err := os.CreateTemp(dir, "messages-*.wal")
+		if err != nil {
+			return nil, err
+		}
+		locked, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		// Another process recovering the logs may have taken or removed it
+		// before it was locked.
+		if locked && isFile(f) {
+			return f, nil
+		}
+		f.Close()
+	}
+}
+
+// isFile reports whether f is still the file at its path.
+func isFile(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	current, err := os.Stat(f.Name())
+	return err == nil && os.SameFile(info, current)
+}
+
+// recover replays the updates left in the write-ahead logs of previous runs
+// that didn't shut down cleanly. The logs of the running processes are
+// locked, and left alone.
+func (b *writeBatcher) recover(ctx context.Context, walDir string) error {
+	paths, err := filepath.Glob(filepath.Join(walDir, "*.wal"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if err := b.recoverLog(ctx, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recoverLog replays the updates in the log at path and removes it, unless
+// its process is still running.
+func (b *writeBatcher) recoverLog(ctx context.Context, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	locked, err := tryLock(f)
+	if err != nil || !locked {
+		f.Close()
+		return err
+	}
+	err = b.replay(ctx, f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		slog.Warn("Failed to remove recovered write-ahead log", "path", path, "error", err)
+	}
+	return nil
+}
+
+// replay writes the latest update of each message in the log r to the
+// database.
+func (b *writeBatcher) replay(ctx context.Context, r io.Reader) error {
+	var order []string
+	latest := make(map[string]db.UpdateMessageParams)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var params db.UpdateMessageParams
+		if err := json.Unmarshal(scanner.Bytes(), &params); err != nil {
+			// The last line may be partially written if we crashed mid-write.
+			slog.Warn("Skipping corrupt write-ahead log entry", "error", err)
+			continue
+		}
+		if _, ok := latest[params.ID]; !ok {
+			order = append(order, params.ID)
+		}
+		latest[params.ID] = params
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range order {
+		if err := b.q.UpdateMessage(ctx, latest[id]); err != nil {
+			return err
+		}
+	}
+	if len(order) > 0 {
+		slog.Info("Recovered unsaved message updates", "count", len(order))
+	}
+	return nil
+}
+
+func (b *writeBatcher) loop(ctx context.Context) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := b.flush(ctx); err != nil {
+				slog.Error("Failed to flush message updates", "error", err)
+			}
+			if err := b.sync(); err != nil {
+				slog.Error("Failed to sync write-ahead log", "error", err)
+			}
+		}
+	}
+}
+
+// add buffers an update, persisting it to the write-ahead log first.
+func (b *writeBatcher) add(message Message, params db.UpdateMessageParams) error {
+	data, err := b.encode(params)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.append(data); err != nil {
+		return err
+	}
+	b.pending[message.ID] = message
+	return nil
+}
+
+// encode returns the line of the write-ahead log for an update.
+func (b *writeBatcher) encode(params db.UpdateMessageParams) ([]byte, error) {
+	// Keep the log encrypted too if the store encrypts messages.
+	if sealer, ok := b.q.(db.Sealer); ok {
+		parts, err := sealer.Seal(params.Parts)
+		if err != nil {
+			return nil, err
+		}
+		params.Parts = parts
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// append writes data to the write-ahead log. It reaches the disk with the
+// next sync.
+func (b *writeBatcher) append(data []byte) error {
+	if _, err := b.wal.Write(data); err != nil {
+		return fmt.Errorf("failed to write to write-ahead log: %w", err)
+	}
+	b.unsynced = true
+	return nil
+}
+
+// sync waits for the updates appended to the write-ahead log since the last
+// sync to reach the disk, for the log to survive a crash of the system too.
+func (b *writeBatcher) sync() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.unsynced {
+		return nil
+	}
+	if err := b.wal.Sync(); err != nil {
+		return fmt.Errorf("failed to sync write-ahead log: %w", err)
+	}
+	b.unsynced = false
+	return nil
+}
+
+// get returns the buffered version of a message, if any.
+func (b *writeBatcher) get(id string) (Message, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	msg, ok := b.pending[id]
+	return msg, ok
+}
+
+// discard drops any buffered update for a message, e.g. because it was
+// deleted, from the write-ahead log too, so that it isn't replayed.
+func (b *writeBatcher) discard(id string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.pending, id)
+	if info, err := b.wal.Stat(); err == nil && info.Size() == 0 {
+		return
+	}
+	if err := b.rewrite(); err != nil {
+		slog.Error("Failed to rewrite write-ahead log", "error", err)
+	}
+}
+
+// rewrite replaces the content of the write-ahead log with the buffered
+// updates.
+func (b *writeBatcher) rewrite() error {
+	var data []byte
+	for _, msg := range b.pending {
+		params, err := updateParams(msg)
+		if err != nil {
+			return err
+		}
+		line, err := b.encode(params)
+		if err != nil {
+			return err
+		}
+		data = append(data, line...)
+	}
+	if err := b.truncate(); err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return b.append(data)
+}
+
+// flush writes all buffered updates to the database and resets the
+// write-ahead log.
+func (b *writeBatcher) flush(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.pending) == 0 {
+		return nil
+	}
+
+	var errs []error
+	for id, msg := range b.pending {
+		params, err := updateParams(msg)
+		if err == nil {
+			err = b.q.UpdateMessage(ctx, params)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		delete(b.pending, id)
+	}
+	if len(errs) > 0 {
+		// Keep the log around, the failed updates are still in it.
+		return errors.Join(errs...)
+	}
+	return b.truncate()
+}
+
+// truncate empties the write-ahead log, and moves back to its start, for the
+// next updates not to be written after a run of zeros that replay skips.
+func (b *writeBatcher) truncate() error {
+	if err := b.wal.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate write-ahead log: %w", err)
+	}
+	if _, err := b.wal.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind write-ahead log: %w", err)
+	}
+	b.unsynced = false
+	return nil
+}
+
+// close flushes the buffered updates, and removes the write-ahead log unless
+// some are left in it.
+func (b *writeBatcher) close(ctx context.Context) error {
+	err := b.flush(ctx)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	closeErr := b.wal.Close()
+	if err == nil && closeErr == nil {
+		closeErr = os.Remove(b.wal.Name())
+	}
+	return errors.Join(err, closeErr)
+}
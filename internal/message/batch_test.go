@@ -0,0 +1,280 @@
+package message
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func setupBatchTest(t *testing.T) (db.Querier, string) {
+	t.Helper()
+
+	dataDir := t.TempDir()
+	conn, err := db.Connect(t.Context(), dataDir)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	q := db.New(conn)
+	_, err = q.CreateSession(t.Context(), db.CreateSessionParams{ID: "session", Title: "test"})
+	require.NoError(t, err)
+	return q, filepath.Join(dataDir, "wal")
+}
+
+// crash stops svc as if its process died: without flushing, and releasing
+// its write-ahead log.
+func crash(t *testing.T, svc Service, cancel context.CancelFunc) {
+	t.Helper()
+	cancel()
+	require.NoError(t, svc.(*service).batcher.wal.Close())
+}
+
+func storedText(t *testing.T, q db.Querier, id string) string {
+	t.Helper()
+	dbMsg, err := q.GetMessage(t.Context(), id)
+	require.NoError(t, err)
+	parts, err := unmarshallParts([]byte(dbMsg.Parts))
+	require.NoError(t, err)
+	msg := Message{Parts: parts}
+	return msg.Content().Text
+}
+
+func TestBatchedServiceBuffersUpdates(t *testing.T) {
+	t.Parallel()
+
+	q, walPath := setupBatchTest(t)
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	svc, err := NewBatchedService(ctx, q, walPath)
+	require.NoError(t, err)
+
+	msg, err := svc.Create(ctx, "session", CreateMessageParams{Role: Assistant})
+	require.NoError(t, err)
+
+	msg.AppendContent("hello")
+	require.NoError(t, svc.Update(ctx, msg))
+
+	// Reads see the buffered content even before it's flushed.
+	got, err := svc.Get(ctx, msg.ID)
+	require.NoError(t, err)
+	require.Equal(t, "hello", got.Content().Text)
+
+	list, err := svc.List(ctx, "session")
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, "hello", list[0].Content().Text)
+
+	require.NoError(t, svc.Flush(ctx))
+	require.Equal(t, "hello", storedText(t, q, msg.ID))
+}
+
+func TestBatchedServiceSyncsOnTicks(t *testing.T) {
+	t.Parallel()
+
+	q, walPath := setupBatchTest(t)
+	ctx, cancel := context.WithCancel(t.Context())
+	svc, err := NewBatchedService(ctx, q, walPath)
+	require.NoError(t, err)
+	// Stop the ticks, for the log to be synced by the test alone.
+	cancel()
+	b := svc.(*service).batcher
+
+	msg, err := svc.Create(t.Context(), "session", CreateMessageParams{Role: Assistant})
+	require.NoError(t, err)
+	for _, delta := range []string{"hel", "lo"} {
+		msg.AppendContent(delta)
+		require.NoError(t, svc.Update(t.Context(), msg))
+	}
+	b.mu.Lock()
+	require.True(t, b.unsynced, "updates don't wait on the disk")
+	b.mu.Unlock()
+
+	require.NoError(t, b.sync())
+	b.mu.Lock()
+	require.False(t, b.unsynced)
+	b.mu.Unlock()
+	require.NoError(t, b.wal.Close())
+}
+
+func TestBatchedServiceFlushesOnFinish(t *testing.T) {
+	t.Parallel()
+
+	q, walPath := setupBatchTest(t)
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	svc, err := NewBatchedService(ctx, q, walPath)
+	require.NoError(t, err)
+
+	msg, err := svc.Create(ctx, "session", CreateMessageParams{Role: Assistant})
+	require.NoError(t, err)
+
+	msg.AppendContent("done")
+	msg.AddFinish(FinishReasonEndTurn, "", "")
+	require.NoError(t, svc.Update(ctx, msg))
+
+	require.Equal(t, "done", storedText(t, q, msg.ID))
+}
+
+func TestBatchedServiceRecoversFromWAL(t *testing.T) {
+	t.Parallel()
+
+	q, walPath := setupBatchTest(t)
+	ctx, cancel := context.WithCancel(t.Context())
+
+	svc, err := NewBatchedService(ctx, q, walPath)
+	require.NoError(t, err)
+
+	msg, err := svc.Create(ctx, "session", CreateMessageParams{Role: Assistant})
+	require.NoError(t, err)
+
+	msg.AppendContent("partial")
+	require.NoError(t, svc.Update(ctx, msg))
+	crash(t, svc, cancel)
+
+	_, err = NewBatchedService(t.Context(), q, walPath)
+	require.NoError(t, err)
+	require.Equal(t, "partial", storedText(t, q, msg.ID))
+	logs, err := filepath.Glob(filepath.Join(walPath, "*.wal"))
+	require.NoError(t, err)
+	require.Len(t, logs, 1, "the recovered log is removed")
+}
+
+func TestBatchedServiceRecoversAfterFlush(t *testing.T) {
+	t.Parallel()
+
+	q, walPath := setupBatchTest(t)
+	ctx, cancel := context.WithCancel(t.Context())
+
+	svc, err := NewBatchedService(ctx, q, walPath)
+	require.NoError(t, err)
+	msg, err := svc.Create(ctx, "session", CreateMessageParams{Role: Assistant})
+	require.NoError(t, err)
+	msg.AppendContent("flushed")
+	require.NoError(t, svc.Update(ctx, msg))
+	require.NoError(t, svc.(*service).batcher.flush(ctx))
+
+	msg.AppendContent(" and updated")
+	require.NoError(t, svc.Update(ctx, msg))
+	crash(t, svc, cancel)
+
+	_, err = NewBatchedService(t.Context(), q, walPath)
+	require.NoError(t, err)
+	require.Equal(t, "flushed and updated", storedText(t, q, msg.ID), "the first update after a flush is replayed")
+}
+
+func TestBatchedServiceLeavesRunningInstancesAlone(t *testing.T) {
+	t.Parallel()
+
+	q, walPath := setupBatchTest(t)
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	running, err := NewBatchedService(ctx, q, walPath)
+	require.NoError(t, err)
+	msg, err := running.Create(ctx, "session", CreateMessageParams{Role: Assistant})
+	require.NoError(t, err)
+	msg.AppendContent("streaming")
+	require.NoError(t, running.Update(ctx, msg))
+
+	other, err := NewBatchedService(ctx, q, walPath)
+	require.NoError(t, err)
+	require.Empty(t, storedText(t, q, msg.ID), "the updates of a running instance aren't replayed")
+
+	require.NoError(t, other.Close(ctx))
+	got, err := running.Get(ctx, msg.ID)
+	require.NoError(t, err)
+	require.Equal(t, "streaming", got.Content().Text, "nor are they truncated")
+	require.NoError(t, running.Close(ctx))
+	require.Equal(t, "streaming", storedText(t, q, msg.ID))
+}
+
+func TestBatchedServiceDeletesFromWAL(t *testing.T) {
+	t.Parallel()
+
+	q, walPath := setupBatchTest(t)
+	ctx, cancel := context.WithCancel(t.Context())
+
+	svc, err := NewBatchedService(ctx, q, walPath)
+	require.NoError(t, err)
+	msg, err := svc.Create(ctx, "session", CreateMessageParams{Role: Assistant})
+	require.NoError(t, err)
+	msg.AppendContent("deleted")
+	require.NoError(t, svc.Update(ctx, msg))
+	require.NoError(t, svc.DeleteSessionMessages(ctx, "session"))
+
+	wal, err := os.ReadFile(svc.(*service).batcher.wal.Name())
+	require.NoError(t, err)
+	require.Empty(t, wal)
+	crash(t, svc, cancel)
+}
+
+func TestBatchedServiceEncryptsWAL(t *testing.T) {
+	t.Parallel()
+
+	dataDir := t.TempDir()
+	plain, err := db.Open(t.Context(), db.StoreOptions{DataDir: dataDir})
+	require.NoError(t, err)
+	t.Cleanup(func() { plain.Close() })
+	_, err = plain.CreateSession(t.Context(), db.CreateSessionParams{ID: "session", Title: "test"})
+	require.NoError(t, err)
+
+	salt, err := db.EncryptionSalt(t.Context(), plain)
+	require.NoError(t, err)
+	c, err := db.NewPassphraseCipher("secret", salt)
+	require.NoError(t, err)
+	q := db.NewEncryptedStore(plain, c)
+	walPath := filepath.Join(dataDir, "wal")
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	svc, err := NewBatchedService(ctx, q, walPath)
+	require.NoError(t, err)
+
+	msg, err := svc.Create(ctx, "session", CreateMessageParams{Role: Assistant})
+	require.NoError(t, err)
+	msg.AppendContent("top secret")
+	require.NoError(t, svc.Update(ctx, msg))
+
+	wal, err := os.ReadFile(svc.(*service).batcher.wal.Name())
+	require.NoError(t, err)
+	require.Contains(t, string(wal), msg.ID)
+	require.NotContains(t, string(wal), "top secret")
+
+	require.NoError(t, svc.Flush(ctx))
+	require.Equal(t, "top secret", storedText(t, q, msg.ID))
+}
+
+func TestListLastN(t *testing.T) {
+	t.Parallel()
+
+	q, walPath := setupBatchTest(t)
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	svc, err := NewBatchedService(ctx, q, walPath)
+	require.NoError(t, err)
+
+	var ids []string
+	for range 5 {
+		msg, err := svc.Create(ctx, "session", CreateMessageParams{Role: User})
+		require.NoError(t, err)
+		ids = append(ids, msg.ID)
+	}
+
+	last, err := svc.ListLastN(ctx, "session", 3)
+	require.NoError(t, err)
+	require.Len(t, last, 3)
+	for i, msg := range last {
+		require.Equal(t, ids[2+i], msg.ID)
+	}
+
+	all, err := svc.ListLastN(ctx, "session", 10)
+	require.NoError(t, err)
+	require.Len(t, all, 5)
+	require.Equal(t, ids[0], all[0].ID)
+}
@@ -32,6 +32,9 @@ const (
 	FinishReasonCanceled         FinishReason = "canceled"
 	FinishReasonError            FinishReason = "error"
 	FinishReasonPermissionDenied FinishReason = "permission_denied"
+	// FinishReasonInterrupted marks a message whose stream was cut off by a
+	// dropped connection. Its content is partial but kept.
+	FinishReasonInterrupted FinishReason = "interrupted"
 
 	// Should never happen
 	FinishReasonUnknown FinishReason = "unknown"
@@ -124,6 +127,31 @@ type Finish struct {
 
 func (Finish) isPart() {}
 
+// Usage is what the step that produced an assistant message took.
+type Usage struct {
+	InputTokens         int64   `json:"input_tokens"`
+	OutputTokens        int64   `json:"output_tokens"`
+	ReasoningTokens     int64   `json:"reasoning_tokens,omitempty"`
+	CacheReadTokens     int64   `json:"cache_read_tokens,omitempty"`
+	CacheCreationTokens int64   `json:"cache_creation_tokens,omitempty"`
+	Cost                float64 `json:"cost"`
+	// FirstTokenMS is how long the response took to start, and DurationMS
+	// how long it took in all, in milliseconds.
+	FirstTokenMS int64 `json:"first_token_ms,omitempty"`
+	DurationMS   int64 `json:"duration_ms"`
+}
+
+func (Usage) isPart() {}
+
+// Retained marks a summary that leaves the latest messages of what it
+// summarizes out, from the one with MessageID on. They are sent along with
+// the summary as they are.
+type Retained struct {
+	MessageID string `json:"message_id"`
+}
+
+func (Retained) isPart() {}
+
 type Message struct {
 	ID               string
 	Role             MessageRole
@@ -212,6 +240,39 @@ func (m *Message) FinishPart() *Finish {
 	return nil
 }
 
+// Usage returns the usage of the step that produced the message, if it was
+// recorded.
+func (m *Message) Usage() *Usage {
+	for _, part := range m.Parts {
+		if c, ok := part.(Usage); ok {
+			return &c
+		}
+	}
+	return nil
+}
+
+// SetUsage records the usage of the step that produced the message.
+func (m *Message) SetUsage(usage Usage) {
+	for i, part := range m.Parts {
+		if _, ok := part.(Usage); ok {
+			m.Parts[i] = usage
+			return
+		}
+	}
+	m.Parts = append(m.Parts, usage)
+}
+
+// RetainedFrom returns the ID of the first message a summary leaves out, if
+// any.
+func (m *Message) RetainedFrom() string {
+	for _, part := range m.Parts {
+		if c, ok := part.(Retained); ok {
+			return c.MessageID
+		}
+	}
+	return ""
+}
+
 func (m *Message) FinishReason() FinishReason {
 	for _, part := range m.Parts {
 		if c, ok := part.(Finish); ok {
@@ -241,6 +302,17 @@ func (m *Message) AppendContent(delta string) {
 	}
 }
 
+// SetContent replaces the text of the message.
+func (m *Message) SetContent(text string) {
+	for i, part := range m.Parts {
+		if _, ok := part.(TextContent); ok {
+			m.Parts[i] = TextContent{Text: text}
+			return
+		}
+	}
+	m.Parts = append(m.Parts, TextContent{Text: text})
+}
+
 func (m *Message) AppendReasoningContent(delta string) {
 	found := false
 	for i, part := range m.Parts {
@@ -483,6 +555,10 @@ func (m *Message) ToAIMessage() []fantasy.Message {
 			Role:    fantasy.MessageRoleAssistant,
 			Content: parts,
 		})
+	case System:
+		if text := strings.TrimSpace(m.Content().Text); text != "" {
+			messages = append(messages, fantasy.NewSystemMessage(text))
+		}
 	case Tool:
 		var parts []fantasy.MessagePart
 		for _, result := range m.ToolResults() {
@@ -0,0 +1,42 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsagePart(t *testing.T) {
+	t.Parallel()
+
+	msg := Message{Role: Assistant, Parts: []ContentPart{TextContent{Text: "hi"}}}
+	require.Nil(t, msg.Usage())
+
+	msg.SetUsage(Usage{InputTokens: 10, OutputTokens: 2})
+	msg.AddFinish(FinishReasonEndTurn, "", "")
+	msg.SetUsage(Usage{InputTokens: 12, OutputTokens: 3, Cost: 0.01, FirstTokenMS: 200, DurationMS: 900})
+	require.Len(t, msg.Parts, 3)
+
+	data, err := marshallParts(msg.Parts)
+	require.NoError(t, err)
+	parts, err := unmarshallParts(data)
+	require.NoError(t, err)
+	msg.Parts = parts
+	require.Equal(t, &Usage{InputTokens: 12, OutputTokens: 3, Cost: 0.01, FirstTokenMS: 200, DurationMS: 900}, msg.Usage())
+	require.Equal(t, FinishReasonEndTurn, msg.FinishReason())
+}
+
+func TestRetainedPart(t *testing.T) {
+	t.Parallel()
+
+	msg := Message{Role: Assistant, IsSummaryMessage: true}
+	require.Empty(t, msg.RetainedFrom())
+
+	msg.Parts = []ContentPart{Retained{MessageID: "msg-1"}, TextContent{Text: "summary"}}
+	data, err := marshallParts(msg.Parts)
+	require.NoError(t, err)
+	msg.Parts, err = unmarshallParts(data)
+	require.NoError(t, err)
+	require.Equal(t, "msg-1", msg.RetainedFrom())
+	require.Equal(t, "summary", msg.Content().Text)
+}
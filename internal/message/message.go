@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/crush/internal/db"
@@ -26,15 +28,28 @@ type Service interface {
 	Update(ctx context.Context, message Message) error
 	Get(ctx context.Context, id string) (Message, error)
 	List(ctx context.Context, sessionID string) ([]Message, error)
+	// ListLastN returns the last n messages of a session, oldest first.
+	ListLastN(ctx context.Context, sessionID string, n int) ([]Message, error)
+	// Search returns the assistant messages of all sessions whose text
+	// contains every word of query, ignoring case, newest first. At most
+	// limit messages are returned, or all of them when limit is 0.
+	Search(ctx context.Context, query string, limit int) ([]Message, error)
 	Delete(ctx context.Context, id string) error
 	DeleteSessionMessages(ctx context.Context, sessionID string) error
+	// Flush writes any buffered updates to the database.
+	Flush(ctx context.Context) error
+	// Close flushes buffered updates and releases the service's resources.
+	Close(ctx context.Context) error
 }
 
 type service struct {
 	*pubsub.Broker[Message]
-	q db.Querier
+	q       db.Querier
+	batcher *writeBatcher
 }
 
+// NewService creates a message service that writes every update straight to
+// the database.
 func NewService(q db.Querier) Service {
 	return &service{
 		Broker: pubsub.NewBroker[Message](),
@@ -42,6 +57,23 @@ func NewService(q db.Querier) Service {
 	}
 }
 
+// NewBatchedService creates a message service that buffers updates and
+// writes them to the database in batches, flushing periodically and whenever
+// a message finishes. Buffered updates are kept in a write-ahead log in
+// walDir, one per process, which is replayed on startup if Crush didn't shut
+// down cleanly.
+func NewBatchedService(ctx context.Context, q db.Querier, walDir string) (Service, error) {
+	batcher, err := newWriteBatcher(ctx, q, walDir)
+	if err != nil {
+		return nil, err
+	}
+	return &service{
+		Broker:  pubsub.NewBroker[Message](),
+		q:       q,
+		batcher: batcher,
+	}, nil
+}
+
 func (s *service) Delete(ctx context.Context, id string) error {
 	message, err := s.Get(ctx, id)
 	if err != nil {
@@ -51,6 +83,9 @@ func (s *service) Delete(ctx context.Context, id string) error {
 	if err != nil {
 		return err
 	}
+	if s.batcher != nil {
+		s.batcher.discard(message.ID)
+	}
 	s.Publish(pubsub.DeletedEvent, message)
 	return nil
 }
@@ -106,29 +141,66 @@ func (s *service) DeleteSessionMessages(ctx context.Context, sessionID string) e
 }
 
 func (s *service) Update(ctx context.Context, message Message) error {
-	parts, err := marshallParts(message.Parts)
+	params, err := updateParams(message)
 	if err != nil {
 		return err
 	}
+	message.UpdatedAt = time.Now().Unix()
+	switch {
+	case s.batcher == nil:
+		err = s.q.UpdateMessage(ctx, params)
+	case params.FinishedAt.Valid:
+		// The message is done streaming, persist it right away.
+		if err = s.batcher.add(message, params); err == nil {
+			err = s.batcher.flush(ctx)
+		}
+	default:
+		err = s.batcher.add(message, params)
+	}
+	if err != nil {
+		return err
+	}
+	s.Publish(pubsub.UpdatedEvent, message)
+	return nil
+}
+
+func (s *service) Flush(ctx context.Context) error {
+	if s.batcher == nil {
+		return nil
+	}
+	return s.batcher.flush(ctx)
+}
+
+func (s *service) Close(ctx context.Context) error {
+	if s.batcher == nil {
+		return nil
+	}
+	return s.batcher.close(ctx)
+}
+
+func updateParams(message Message) (db.UpdateMessageParams, error) {
+	parts, err := marshallParts(message.Parts)
+	if err != nil {
+		return db.UpdateMessageParams{}, err
+	}
 	finishedAt := sql.NullInt64{}
 	if f := message.FinishPart(); f != nil {
 		finishedAt.Int64 = f.Time
 		finishedAt.Valid = true
 	}
-	err = s.q.UpdateMessage(ctx, db.UpdateMessageParams{
+	return db.UpdateMessageParams{
 		ID:         message.ID,
 		Parts:      string(parts),
 		FinishedAt: finishedAt,
-	})
-	if err != nil {
-		return err
-	}
-	message.UpdatedAt = time.Now().Unix()
-	s.Publish(pubsub.UpdatedEvent, message)
-	return nil
+	}, nil
 }
 
 func (s *service) Get(ctx context.Context, id string) (Message, error) {
+	if s.batcher != nil {
+		if msg, ok := s.batcher.get(id); ok {
+			return msg, nil
+		}
+	}
 	dbMessage, err := s.q.GetMessage(ctx, id)
 	if err != nil {
 		return Message{}, err
@@ -143,6 +215,43 @@ func (s *service) List(ctx context.Context, sessionID string) ([]Message, error)
 	}
 	messages := make([]Message, len(dbMessages))
 	for i, dbMessage := range dbMessages {
+		if s.batcher != nil {
+			if msg, ok := s.batcher.get(dbMessage.ID); ok {
+				messages[i] = msg
+				continue
+			}
+		}
+		messages[i], err = s.fromDBItem(dbMessage)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return messages, nil
+}
+
+func (s *service) ListLastN(ctx context.Context, sessionID string, n int) ([]Message, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	// The query returns every message as new as the n-th newest one, which
+	// can be more than n when they were created in the same second.
+	dbMessages, err := s.q.ListLastMessagesBySession(ctx, db.ListLastMessagesBySessionParams{
+		SessionID:   sessionID,
+		SessionID_2: sessionID,
+		Offset:      int64(n - 1),
+	})
+	if err != nil {
+		return nil, err
+	}
+	dbMessages = dbMessages[max(0, len(dbMessages)-n):]
+	messages := make([]Message, len(dbMessages))
+	for i, dbMessage := range dbMessages {
+		if s.batcher != nil {
+			if msg, ok := s.batcher.get(dbMessage.ID); ok {
+				messages[i] = msg
+				continue
+			}
+		}
 		messages[i], err = s.fromDBItem(dbMessage)
 		if err != nil {
 			return nil, err
@@ -151,6 +260,51 @@ func (s *service) List(ctx context.Context, sessionID string) ([]Message, error)
 	return messages, nil
 }
 
+func (s *service) Search(ctx context.Context, query string, limit int) ([]Message, error) {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	dbMessages, err := s.q.ListAllMessages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var found []Message
+	// Walk backwards so the newest messages come first.
+	for _, dbMessage := range slices.Backward(dbMessages) {
+		if MessageRole(dbMessage.Role) != Assistant {
+			continue
+		}
+		msg, ok := Message{}, false
+		if s.batcher != nil {
+			msg, ok = s.batcher.get(dbMessage.ID)
+		}
+		if !ok {
+			msg, err = s.fromDBItem(dbMessage)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if !containsAll(strings.ToLower(msg.Content().Text), terms) {
+			continue
+		}
+		found = append(found, msg)
+		if limit > 0 && len(found) == limit {
+			break
+		}
+	}
+	return found, nil
+}
+
+func containsAll(text string, terms []string) bool {
+	for _, term := range terms {
+		if !strings.Contains(text, term) {
+			return false
+		}
+	}
+	return true
+}
+
 func (s *service) fromDBItem(item db.Message) (Message, error) {
 	parts, err := unmarshallParts([]byte(item.Parts))
 	if err != nil {
@@ -179,6 +333,8 @@ const (
 	toolCallType   partType = "tool_call"
 	toolResultType partType = "tool_result"
 	finishType     partType = "finish"
+	usageType      partType = "usage"
+	retainedType   partType = "retained"
 )
 
 type partWrapper struct {
@@ -207,6 +363,10 @@ func marshallParts(parts []ContentPart) ([]byte, error) {
 			typ = toolResultType
 		case Finish:
 			typ = finishType
+		case Usage:
+			typ = usageType
+		case Retained:
+			typ = retainedType
 		default:
 			return nil, fmt.Errorf("unknown part type: %T", part)
 		}
@@ -281,6 +441,18 @@ func unmarshallParts(data []byte) ([]ContentPart, error) {
 				return nil, err
 			}
 			parts = append(parts, part)
+		case usageType:
+			part := Usage{}
+			if err := json.Unmarshal(wrapper.Data, &part); err != nil {
+				return nil, err
+			}
+			parts = append(parts, part)
+		case retainedType:
+			part := Retained{}
+			if err := json.Unmarshal(wrapper.Data, &part); err != nil {
+				return nil, err
+			}
+			parts = append(parts, part)
 		default:
 			return nil, fmt.Errorf("unknown part type: %s", wrapper.Type)
 		}
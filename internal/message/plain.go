@@ -0,0 +1,84 @@
+package message
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// plainResultLines is how many lines of a tool result are kept in plain
+// text.
+const plainResultLines = 20
+
+// PlainText renders the message as plain linear text, with a marker at the
+// start of each part saying what it is, for screen readers and plain text
+// streams. Reasoning and the tool calls of assistant messages, rendered
+// with PlainToolCall, are left out.
+func (m *Message) PlainText() string {
+	var parts []string
+	switch m.Role {
+	case User:
+		parts = append(parts, "You: "+strings.TrimSpace(m.Content().Text))
+		for _, b := range m.BinaryContent() {
+			parts = append(parts, "Attachment: "+filepath.Base(b.Path))
+		}
+	case Assistant:
+		if text := strings.TrimSpace(m.Content().Text); text != "" {
+			label := "Assistant: "
+			if m.IsSummaryMessage {
+				label = "Summary: "
+			}
+			parts = append(parts, label+text)
+		}
+		if finish := m.FinishPart(); finish != nil {
+			switch finish.Reason {
+			case FinishReasonCanceled:
+				parts = append(parts, "Canceled.")
+			case FinishReasonError:
+				parts = append(parts, "Error: "+strings.TrimSpace(finish.Message+" "+finish.Details))
+			case FinishReasonPermissionDenied:
+				parts = append(parts, "Permission denied.")
+			case FinishReasonInterrupted:
+				parts = append(parts, "Interrupted.")
+			}
+		}
+	case Tool:
+		for _, result := range m.ToolResults() {
+			parts = append(parts, plainToolResult(result))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// PlainToolCall renders a tool call, and its result when there is one, as
+// plain text.
+func PlainToolCall(call ToolCall, result *ToolResult, cancelled bool) string {
+	text := "Tool " + call.Name
+	if input := strings.TrimSpace(call.Input); input != "" && input != "{}" {
+		text += ": " + input
+	}
+	switch {
+	case result != nil && result.ToolCallID != "":
+		text += "\n" + plainToolResult(*result)
+	case cancelled:
+		text += "\nCanceled."
+	}
+	return text
+}
+
+func plainToolResult(result ToolResult) string {
+	label := "Result of " + result.Name + ": "
+	if result.IsError {
+		label = "Error from " + result.Name + ": "
+	}
+	content := strings.TrimSpace(result.Content)
+	if content == "" && result.Data != "" {
+		return label + result.MIMEType + " data"
+	}
+	lines := strings.Split(content, "\n")
+	if len(lines) > plainResultLines {
+		more := len(lines) - plainResultLines
+		lines = append(lines[:plainResultLines], fmt.Sprintf("(%d more lines)", more))
+	}
+	return label + strings.Join(lines, "\n")
+}
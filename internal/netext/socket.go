@@ -0,0 +1,70 @@
+// Package netext has the helpers of the servers Crush runs for other
+// processes of the user, like editor plugins and status bars.
+package netext
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrSocketInUse is the error of listening at a socket another process
+// still serves.
+var ErrSocketInUse = errors.New("socket in use")
+
+// ListenPrivate listens at the unix socket path, which only the user can
+// connect to, as the servers share the conversation or take text for the
+// prompt. A socket left behind by a process that exited is replaced, but
+// not one that is still served. The socket is created in a directory only
+// the user can enter, and moved to path once its permissions are set, for
+// no one else to connect to it in between. Closing the listener removes the
+// socket.
+func ListenPrivate(path string) (net.Listener, error) {
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, ErrSocketInUse
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".sock-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	tmpPath := filepath.Join(dir, filepath.Base(path))
+	listener, err := net.Listen("unix", tmpPath)
+	if err != nil {
+		return nil, err
+	}
+	// The socket is removed from path, not from where it was created.
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	if err := os.Chmod(tmpPath, 0o600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return &privateListener{Listener: listener, path: path}, nil
+}
+
+// privateListener removes its socket when it's closed.
+type privateListener struct {
+	net.Listener
+	path string
+}
+
+func (l *privateListener) Close() error {
+	err := l.Listener.Close()
+	if rmErr := os.Remove(l.path); rmErr != nil && !errors.Is(rmErr, os.ErrNotExist) {
+		return errors.Join(err, rmErr)
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package netext
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// socketPath returns a path short enough for a unix socket, as temporary
+// directories of tests can be too long on macOS.
+func socketPath(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "crush")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "test.sock")
+}
+
+func TestListenPrivate(t *testing.T) {
+	t.Parallel()
+
+	path := socketPath(t)
+	listener, err := ListenPrivate(path)
+	require.NoError(t, err)
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "the directory the socket was created in is removed")
+
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	conn.Close()
+
+	_, err = ListenPrivate(path)
+	require.ErrorIs(t, err, ErrSocketInUse)
+
+	require.NoError(t, listener.Close())
+	_, err = os.Stat(path)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestListenPrivateReplacesStaleSocket(t *testing.T) {
+	t.Parallel()
+
+	path := socketPath(t)
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+	listener, err := ListenPrivate(path)
+	require.NoError(t, err)
+	require.NoError(t, listener.Close())
+}
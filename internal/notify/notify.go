@@ -0,0 +1,86 @@
+// Package notify tells the user the agent needs their attention, through
+// the terminal bell, desktop notification escape sequences, or a custom
+// command.
+package notify
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const commandTimeout = 10 * time.Second
+
+// Notifier sends notifications as configured.
+type Notifier struct {
+	cfg        config.Notifications
+	workingDir string
+}
+
+// New returns a notifier for cfg, or nil when no notifications are
+// configured.
+func New(cfg *config.Notifications, workingDir string) *Notifier {
+	if cfg == nil || (!cfg.Bell && cfg.Desktop == "" && cfg.Command == "") {
+		return nil
+	}
+	return &Notifier{cfg: *cfg, workingDir: workingDir}
+}
+
+// Sequence returns the escape sequences to write to the terminal for a
+// notification.
+func (n *Notifier) Sequence(title, body string) string {
+	if n == nil {
+		return ""
+	}
+	var sb strings.Builder
+	if n.cfg.Bell {
+		sb.WriteByte(ansi.BEL)
+	}
+	switch n.cfg.Desktop {
+	case config.DesktopNotificationOSC777:
+		sb.WriteString("\x1b]777;notify;" + sanitize(title) + ";" + sanitize(body) + "\x07")
+	case config.DesktopNotificationOSC9:
+		sb.WriteString(ansi.Notify(sanitize(title + ": " + body)))
+	}
+	return sb.String()
+}
+
+// Run runs the notification command, if any.
+func (n *Notifier) Run(ctx context.Context, title, body string) {
+	if n == nil || n.cfg.Command == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	sh := shell.NewShell(&shell.Options{
+		WorkingDir: n.workingDir,
+		Env: append(
+			os.Environ(),
+			"CRUSH_NOTIFICATION_TITLE="+title,
+			"CRUSH_NOTIFICATION_BODY="+body,
+		),
+	})
+	if _, stderr, err := sh.Exec(ctx, n.cfg.Command); err != nil {
+		slog.Warn("Notification command failed", "command", n.cfg.Command, "error", err, "stderr", stderr)
+	}
+}
+
+// sanitize strips the characters that would end or split an OSC sequence.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == ';':
+			return ','
+		case r < 0x20 || r == 0x7f:
+			return ' '
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	require.Nil(t, New(nil, ""))
+	require.Nil(t, New(&config.Notifications{}, ""))
+	require.NotNil(t, New(&config.Notifications{Bell: true}, ""))
+}
+
+func TestSequence(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		cfg  config.Notifications
+		want string
+	}{
+		{
+			name: "bell",
+			cfg:  config.Notifications{Bell: true},
+			want: "\a",
+		},
+		{
+			name: "osc777",
+			cfg:  config.Notifications{Desktop: config.DesktopNotificationOSC777},
+			want: "\x1b]777;notify;Crush;Done, ok\a",
+		},
+		{
+			name: "osc9 and bell",
+			cfg:  config.Notifications{Bell: true, Desktop: config.DesktopNotificationOSC9},
+			want: "\a\x1b]9;Crush: Done, ok\a",
+		},
+		{
+			name: "command only",
+			cfg:  config.Notifications{Command: "true"},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, New(&tt.cfg, "").Sequence("Crush", "Done; ok"))
+		})
+	}
+
+	var n *Notifier
+	require.Empty(t, n.Sequence("Crush", "Done"))
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	n := New(&config.Notifications{
+		Command: `echo "$CRUSH_NOTIFICATION_TITLE|$CRUSH_NOTIFICATION_BODY" > out.txt`,
+	}, dir)
+	n.Run(t.Context(), "Crush", "Agent finished")
+
+	data, err := os.ReadFile(filepath.Join(dir, "out.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "Crush|Agent finished\n", string(data))
+}
@@ -1,4 +1,4 @@
-package claude
+package oauth
 
 import (
 	"crypto/rand"
@@ -0,0 +1,209 @@
+// Package chatgpt implements the OAuth login used by ChatGPT Plus, Pro, and
+// Team subscriptions to access OpenAI models through the Codex endpoint.
+package chatgpt
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/httpext"
+	"github.com/charmbracelet/crush/internal/oauth"
+)
+
+const (
+	clientID    = "app_EMoamEEZ73f0CkXaXp7hrann"
+	tokenURL    = "https://auth.openai.com/oauth/token"
+	callbackURL = "http://localhost:1455/auth/callback"
+
+	// BaseURL is the Responses API endpoint for subscription accounts.
+	BaseURL = "https://chatgpt.com/backend-api/codex"
+)
+
+// AuthorizeURL returns the ChatGPT OAuth2 authorization URL.
+func AuthorizeURL(challenge, state string) (string, error) {
+	u, err := url.Parse("https://auth.openai.com/oauth/authorize")
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", clientID)
+	q.Set("redirect_uri", callbackURL)
+	q.Set("scope", "openid profile email offline_access")
+	q.Set("code_challenge", challenge)
+	q.Set("code_challenge_method", "S256")
+	q.Set("id_token_add_organizations", "true")
+	q.Set("codex_cli_simplified_flow", "true")
+	q.Set("state", state)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// WaitForCode listens for the authorization redirect on the local callback
+// address and returns the authorization code once the user has logged in.
+func WaitForCode(ctx context.Context, state string) (string, error) {
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return "", err
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:"+u.Port())
+	if err != nil {
+		return "", fmt.Errorf("chatgpt: failed to listen for the login callback: %w", err)
+	}
+
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc(u.Path, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var res result
+		switch {
+		case q.Get("error") != "":
+			res.err = fmt.Errorf("chatgpt: login failed: %s", cmp.Or(q.Get("error_description"), q.Get("error")))
+		case q.Get("state") != state:
+			res.err = errors.New("chatgpt: login failed: state mismatch")
+		case q.Get("code") == "":
+			res.err = errors.New("chatgpt: login failed: no authorization code")
+		default:
+			res.code = q.Get("code")
+		}
+		if res.err != nil {
+			http.Error(w, res.err.Error(), http.StatusBadRequest)
+		} else {
+			_, _ = io.WriteString(w, "Logged in to Crush. You can close this window.")
+		}
+		select {
+		case results <- res:
+		default:
+		}
+	})
+
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = server.Serve(listener) }()
+	defer server.Close()
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case res := <-results:
+		return res.code, res.err
+	}
+}
+
+// ExchangeToken exchanges the authorization code for an OAuth2 token.
+func ExchangeToken(ctx context.Context, code, verifier string) (*oauth.Token, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {strings.TrimSpace(code)},
+		"redirect_uri":  {callbackURL},
+		"client_id":     {clientID},
+		"code_verifier": {verifier},
+	}
+	return requestToken(ctx, form, "exchange")
+}
+
+// RefreshToken refreshes the OAuth2 token using the provided refresh token.
+// The returned token keeps refreshToken if the server didn't rotate it.
+func RefreshToken(ctx context.Context, refreshToken string) (*oauth.Token, error) {
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+		"client_id":     {clientID},
+		"scope":         {"openid profile email"},
+	}
+	token, err := requestToken(ctx, form, "refresh")
+	if err != nil {
+		return nil, err
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	return token, nil
+}
+
+func requestToken(ctx context.Context, form url.Values, action string) (*oauth.Token, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := httpext.NewClient(30 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("chatgpt: failed to %s token: status %d body %q", action, resp.StatusCode, string(body))
+	}
+
+	var tr tokenResponse
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return nil, err
+	}
+	return tr.token(), nil
+}
+
+type tokenResponse struct {
+	IDToken      string `json:"id_token"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+func (r tokenResponse) token() *oauth.Token {
+	token := &oauth.Token{
+		AccessToken:  r.AccessToken,
+		RefreshToken: r.RefreshToken,
+		ExpiresIn:    r.ExpiresIn,
+	}
+	claims := jwtClaims(r.IDToken)
+	if token.ExpiresIn == 0 {
+		// Fall back to the access token's own expiry.
+		if exp, ok := jwtClaims(r.AccessToken)["exp"].(float64); ok {
+			token.ExpiresIn = int(time.Until(time.Unix(int64(exp), 0)).Seconds())
+		}
+	}
+	if auth, ok := claims["https://api.openai.com/auth"].(map[string]any); ok {
+		token.AccountID, _ = auth["chatgpt_account_id"].(string)
+	}
+	token.SetExpiresAt()
+	return token
+}
+
+// jwtClaims returns the claims of a JWT without verifying it; they're only
+// used for metadata about tokens we got straight from the issuer.
+func jwtClaims(token string) map[string]any {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil
+	}
+	var claims map[string]any
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil
+	}
+	return claims
+}
@@ -0,0 +1,47 @@
+package chatgpt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func fakeJWT(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	return "e30." + base64.RawURLEncoding.EncodeToString(payload) + ".sig"
+}
+
+func TestTokenResponse(t *testing.T) {
+	t.Parallel()
+
+	exp := time.Now().Add(time.Hour).Unix()
+	tr := tokenResponse{
+		IDToken: fakeJWT(t, map[string]any{
+			"https://api.openai.com/auth": map[string]any{"chatgpt_account_id": "acct_123"},
+		}),
+		AccessToken:  fakeJWT(t, map[string]any{"exp": exp}),
+		RefreshToken: "refresh",
+	}
+
+	token := tr.token()
+	require.Equal(t, tr.AccessToken, token.AccessToken)
+	require.Equal(t, "refresh", token.RefreshToken)
+	require.Equal(t, "acct_123", token.AccountID)
+	require.InDelta(t, exp, token.ExpiresAt, 5)
+	require.False(t, token.IsExpired())
+}
+
+func TestAuthorizeURL(t *testing.T) {
+	t.Parallel()
+
+	u, err := AuthorizeURL("challenge", "state")
+	require.NoError(t, err)
+	require.Contains(t, u, "code_challenge=challenge")
+	require.Contains(t, u, "state=state")
+	require.Contains(t, u, "redirect_uri=http%3A%2F%2Flocalhost%3A1455%2Fauth%2Fcallback")
+}
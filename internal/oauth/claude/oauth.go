@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/crush/internal/httpext"
 	"github.com/charmbracelet/crush/internal/oauth"
 )
 
@@ -121,6 +122,6 @@ func request(ctx context.Context, method, url string, body any) (*http.Response,
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "anthropic")
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := httpext.NewClient(30 * time.Second)
 	return client.Do(req)
 }
@@ -0,0 +1,73 @@
+package copilot
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		body      string
+		initiator string
+		vision    bool
+	}{
+		{
+			name:      "user message",
+			body:      `{"messages":[{"role":"system","content":"hi"},{"role":"user","content":"hello"}]}`,
+			initiator: "user",
+		},
+		{
+			name:      "tool result",
+			body:      `{"messages":[{"role":"user","content":"hello"},{"role":"assistant","content":""},{"role":"tool","content":"ok"}]}`,
+			initiator: "agent",
+		},
+		{
+			name:      "image",
+			body:      `{"messages":[{"role":"user","content":[{"type":"text","text":"look"},{"type":"image_url","image_url":{"url":"data:"}}]}]}`,
+			initiator: "user",
+			vision:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got *http.Request
+			var body []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r
+				body, _ = io.ReadAll(r.Body)
+			}))
+			defer server.Close()
+
+			client := &http.Client{Transport: &Transport{}}
+			resp, err := client.Post(server.URL, "application/json", strings.NewReader(tt.body))
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.Equal(t, tt.body, string(body))
+			require.Equal(t, tt.initiator, got.Header.Get("X-Initiator"))
+			require.Equal(t, tt.vision, got.Header.Get("Copilot-Vision-Request") == "true")
+		})
+	}
+}
+
+func TestTokenResponse(t *testing.T) {
+	t.Parallel()
+
+	expiresAt := time.Now().Add(30 * time.Minute).Unix()
+	token := tokenResponse{Token: "copilot", ExpiresAt: expiresAt}.token("github")
+	require.Equal(t, "copilot", token.AccessToken)
+	require.Equal(t, "github", token.RefreshToken)
+	require.Equal(t, expiresAt, token.ExpiresAt)
+	require.False(t, token.IsExpired())
+}
@@ -0,0 +1,140 @@
+package copilot
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/httpext"
+)
+
+type modelsResponse struct {
+	Data []struct {
+		ID                 string `json:"id"`
+		Name               string `json:"name"`
+		ModelPickerEnabled bool   `json:"model_picker_enabled"`
+		Capabilities       struct {
+			Type   string `json:"type"`
+			Limits struct {
+				MaxContextWindowTokens int64 `json:"max_context_window_tokens"`
+				MaxOutputTokens        int64 `json:"max_output_tokens"`
+			} `json:"limits"`
+			Supports struct {
+				ToolCalls bool `json:"tool_calls"`
+				Vision    bool `json:"vision"`
+			} `json:"supports"`
+		} `json:"capabilities"`
+	} `json:"data"`
+}
+
+// Models lists the chat models available to the Copilot token. Models that
+// can't call tools or aren't offered in the model picker are left out.
+// Copilot is billed by subscription, so models have no cost.
+func Models(ctx context.Context, baseURL, token string) ([]catwalk.Model, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(baseURL, "/")+"/models", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	for k, v := range Headers {
+		req.Header.Set(k, v)
+	}
+
+	body, err := do(req)
+	if err != nil {
+		return nil, fmt.Errorf("copilot: failed to list models: %w", err)
+	}
+	var mr modelsResponse
+	if err := json.Unmarshal(body, &mr); err != nil {
+		return nil, err
+	}
+
+	var models []catwalk.Model
+	for _, m := range mr.Data {
+		if m.Capabilities.Type != "chat" || !m.ModelPickerEnabled || !m.Capabilities.Supports.ToolCalls {
+			continue
+		}
+		models = append(models, catwalk.Model{
+			ID:               m.ID,
+			Name:             cmp.Or(m.Name, m.ID),
+			ContextWindow:    m.Capabilities.Limits.MaxContextWindowTokens,
+			DefaultMaxTokens: m.Capabilities.Limits.MaxOutputTokens,
+			SupportsImages:   m.Capabilities.Supports.Vision,
+		})
+	}
+	return models, nil
+}
+
+// Transport sets the X-Initiator header Copilot uses for usage accounting:
+// only requests that answer a user message count against the premium
+// request quota, while follow-up requests after tool calls are marked as
+// initiated by the agent.
+type Transport struct {
+	Base http.RoundTripper
+}
+
+// RoundTrip implements [http.RoundTripper].
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := cmp.Or(t.Base, httpext.DefaultTransport())
+	if req.Body == nil || req.Method != http.MethodPost {
+		return base.RoundTrip(req)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	var r chatRequest
+	_ = json.Unmarshal(body, &r)
+	req.Header.Set("X-Initiator", r.initiator())
+	if r.hasImages() {
+		req.Header.Set("Copilot-Vision-Request", "true")
+	}
+	return base.RoundTrip(req)
+}
+
+type chatRequest struct {
+	Messages []struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	} `json:"messages"`
+}
+
+// initiator returns "user" when the last message of the request was sent by
+// the user and "agent" otherwise.
+func (r chatRequest) initiator() string {
+	if len(r.Messages) == 0 || r.Messages[len(r.Messages)-1].Role == "user" {
+		return "user"
+	}
+	return "agent"
+}
+
+func (r chatRequest) hasImages() bool {
+	for _, m := range r.Messages {
+		var parts []struct {
+			Type string `json:"type"`
+		}
+		if json.Unmarshal(m.Content, &parts) != nil {
+			continue
+		}
+		for _, p := range parts {
+			if p.Type == "image_url" {
+				return true
+			}
+		}
+	}
+	return false
+}
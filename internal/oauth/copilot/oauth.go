@@ -0,0 +1,194 @@
+// Package copilot implements the GitHub device flow login and the token
+// exchange used to access GitHub Copilot chat models.
+package copilot
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/httpext"
+	"github.com/charmbracelet/crush/internal/oauth"
+)
+
+const (
+	// Name is the provider type of GitHub Copilot providers.
+	Name = "copilot"
+
+	// BaseURL is the default Copilot API endpoint, used when the token
+	// exchange doesn't return one.
+	BaseURL = "https://api.githubcopilot.com"
+
+	clientID       = "Iv1.b507a08c87ecfe98"
+	deviceCodeURL  = "https://github.com/login/device/code"
+	accessTokenURL = "https://github.com/login/oauth/access_token"
+	copilotToken   = "https://api.github.com/copilot_internal/v2/token"
+)
+
+// Headers are the editor headers Copilot requires on every request.
+var Headers = map[string]string{
+	"Editor-Version":         "vscode/1.99.3",
+	"Editor-Plugin-Version":  "copilot-chat/0.26.7",
+	"Copilot-Integration-Id": "vscode-chat",
+	"User-Agent":             "GitHubCopilotChat/0.26.7",
+	"Openai-Intent":          "conversation-edits",
+}
+
+// ErrDeviceCodeExpired is returned when the user didn't enter the device
+// code in time.
+var ErrDeviceCodeExpired = errors.New("copilot: device code expired")
+
+// DeviceCode is a pending device flow login.
+type DeviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// RequestDeviceCode starts the GitHub device flow. The user must enter the
+// returned UserCode at VerificationURI.
+func RequestDeviceCode(ctx context.Context) (*DeviceCode, error) {
+	form := url.Values{
+		"client_id": {clientID},
+		"scope":     {"read:user"},
+	}
+	var dc DeviceCode
+	if err := postForm(ctx, deviceCodeURL, form, &dc); err != nil {
+		return nil, fmt.Errorf("copilot: failed to request device code: %w", err)
+	}
+	return &dc, nil
+}
+
+// PollAccessToken waits for the user to authorize the device code and
+// returns the resulting GitHub access token.
+func PollAccessToken(ctx context.Context, dc *DeviceCode) (string, error) {
+	interval := time.Duration(max(dc.Interval, 1)) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(dc.ExpiresIn)*time.Second)
+	defer cancel()
+
+	form := url.Values{
+		"client_id":   {clientID},
+		"device_code": {dc.DeviceCode},
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return "", ErrDeviceCodeExpired
+			}
+			return "", ctx.Err()
+		case <-time.After(interval):
+		}
+
+		var resp struct {
+			AccessToken string `json:"access_token"`
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		if err := postForm(ctx, accessTokenURL, form, &resp); err != nil {
+			return "", fmt.Errorf("copilot: failed to poll access token: %w", err)
+		}
+		switch resp.Error {
+		case "":
+			return resp.AccessToken, nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		case "expired_token":
+			return "", ErrDeviceCodeExpired
+		default:
+			return "", fmt.Errorf("copilot: login failed: %s", cmp.Or(resp.Description, resp.Error))
+		}
+	}
+}
+
+// RefreshToken exchanges the GitHub access token for a short-lived Copilot
+// token. The GitHub token is kept as the refresh token, so Copilot tokens
+// refresh like any other OAuth token.
+func RefreshToken(ctx context.Context, githubToken string) (*oauth.Token, error) {
+	token, _, err := ExchangeToken(ctx, githubToken)
+	return token, err
+}
+
+// ExchangeToken is like [RefreshToken] but also returns the API endpoint of
+// the user's Copilot plan.
+func ExchangeToken(ctx context.Context, githubToken string) (*oauth.Token, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, copilotToken, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Authorization", "token "+githubToken)
+	req.Header.Set("Accept", "application/json")
+	for k, v := range Headers {
+		req.Header.Set(k, v)
+	}
+
+	body, err := do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("copilot: failed to exchange token: %w", err)
+	}
+	var tr tokenResponse
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return nil, "", err
+	}
+	return tr.token(githubToken), cmp.Or(tr.Endpoints.API, BaseURL), nil
+}
+
+type tokenResponse struct {
+	Token     string `json:"token"`
+	ExpiresAt int64  `json:"expires_at"`
+	Endpoints struct {
+		API string `json:"api"`
+	} `json:"endpoints"`
+}
+
+func (r tokenResponse) token(githubToken string) *oauth.Token {
+	return &oauth.Token{
+		AccessToken:  r.Token,
+		RefreshToken: githubToken,
+		ExpiresIn:    int(time.Until(time.Unix(r.ExpiresAt, 0)).Seconds()),
+		ExpiresAt:    r.ExpiresAt,
+	}
+}
+
+func postForm(ctx context.Context, u string, form url.Values, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	body, err := do(req)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+func do(req *http.Request) ([]byte, error) {
+	client := httpext.NewClient(30 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d body %q", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
@@ -4,12 +4,15 @@ import (
 	"time"
 )
 
-// Token represents an OAuth2 token from Claude Code Max.
+// Token represents an OAuth2 token from Claude Code Max or ChatGPT.
 type Token struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 	ExpiresIn    int    `json:"expires_in"`
 	ExpiresAt    int64  `json:"expires_at"`
+	// AccountID is the account the token is scoped to, for providers that
+	// need it sent along with requests.
+	AccountID string `json:"account_id,omitempty"`
 }
 
 // SetExpiresAt calculates and sets the ExpiresAt field based on the current time and ExpiresIn.
@@ -0,0 +1,112 @@
+package oauth
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/charmbracelet/crush/internal/httpext"
+)
+
+// RefreshFunc returns a new token for the given refresh token.
+type RefreshFunc func(ctx context.Context, refreshToken string) (*Token, error)
+
+// RefreshTransport is an [http.RoundTripper] that keeps an OAuth token
+// fresh. It refreshes the token before a request when it expired, and once
+// more when the server rejects it anyway, retrying the request with the new
+// token.
+//
+// The token is read through Token on every request, so a token refreshed or
+// replaced elsewhere is picked up right away.
+type RefreshTransport struct {
+	Base http.RoundTripper
+	// Token returns the current token.
+	Token func() *Token
+	// Refresh exchanges a refresh token for a new token.
+	Refresh RefreshFunc
+	// Save persists a refreshed token.
+	Save func(*Token) error
+	// Lock serializes the refreshes, shared by the transports of the same
+	// token for them not to refresh it concurrently. A nil Lock serializes
+	// the refreshes of this transport only.
+	Lock sync.Locker
+
+	mu sync.Mutex
+}
+
+// RoundTrip implements [http.RoundTripper].
+func (t *RefreshTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := cmp.Or(t.Base, httpext.DefaultTransport())
+	token := t.Token()
+	if token == nil {
+		return base.RoundTrip(req)
+	}
+
+	refreshed := false
+	if token.IsExpired() {
+		if newToken, err := t.refresh(req.Context(), token); err == nil {
+			token = newToken
+			refreshed = true
+		}
+	}
+
+	resp, err := base.RoundTrip(authorize(req, token))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || refreshed {
+		return resp, err
+	}
+	if req.Body != nil && req.GetBody == nil {
+		// The body was consumed and can't be sent again.
+		return resp, nil
+	}
+
+	newToken, err := t.refresh(req.Context(), token)
+	if err != nil {
+		return resp, nil
+	}
+	retry := authorize(req, newToken)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		retry.Body = body
+	}
+	_ = resp.Body.Close()
+	slog.Debug("Retrying request with refreshed OAuth token", "url", req.URL.String())
+	return base.RoundTrip(retry)
+}
+
+// refresh refreshes stale unless another request already replaced it.
+func (t *RefreshTransport) refresh(ctx context.Context, stale *Token) (*Token, error) {
+	lock := t.Lock
+	if lock == nil {
+		lock = &t.mu
+	}
+	lock.Lock()
+	defer lock.Unlock()
+
+	if current := t.Token(); current != nil && current.AccessToken != stale.AccessToken {
+		return current, nil
+	}
+	token, err := t.Refresh(ctx, stale.RefreshToken)
+	if err != nil {
+		slog.Error("Failed to refresh OAuth token", "error", err)
+		return nil, err
+	}
+	token.AccountID = cmp.Or(token.AccountID, stale.AccountID)
+	if t.Save != nil {
+		if err := t.Save(token); err != nil {
+			slog.Error("Failed to save refreshed OAuth token", "error", err)
+		}
+	}
+	slog.Info("Successfully refreshed OAuth token")
+	return token, nil
+}
+
+func authorize(req *http.Request, token *Token) *http.Request {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	return req
+}
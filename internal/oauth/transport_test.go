@@ -0,0 +1,115 @@
+package oauth
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshTransport(t *testing.T) {
+	t.Parallel()
+
+	newTransport := func(token *Token, refreshes *atomic.Int32) *RefreshTransport {
+		return &RefreshTransport{
+			Token: func() *Token { return token },
+			Refresh: func(_ context.Context, refreshToken string) (*Token, error) {
+				require.Equal(t, "refresh", refreshToken)
+				refreshes.Add(1)
+				return &Token{AccessToken: "new", RefreshToken: "refresh", ExpiresIn: 3600}, nil
+			},
+			Save: func(newToken *Token) error {
+				token = newToken
+				return nil
+			},
+		}
+	}
+
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if r.Header.Get("Authorization") != "Bearer new" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	t.Run("refreshes expired token before the request", func(t *testing.T) {
+		bodies = nil
+		var refreshes atomic.Int32
+		token := &Token{AccessToken: "old", RefreshToken: "refresh", ExpiresAt: time.Now().Add(-time.Minute).Unix()}
+		client := &http.Client{Transport: newTransport(token, &refreshes)}
+
+		resp, err := client.Post(server.URL, "text/plain", strings.NewReader("hello"))
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, int32(1), refreshes.Load())
+		require.Equal(t, []string{"hello"}, bodies)
+	})
+
+	t.Run("retries once when the token is rejected", func(t *testing.T) {
+		bodies = nil
+		var refreshes atomic.Int32
+		token := &Token{AccessToken: "revoked", RefreshToken: "refresh", ExpiresIn: 3600}
+		token.SetExpiresAt()
+		transport := newTransport(token, &refreshes)
+		client := &http.Client{Transport: transport}
+
+		resp, err := client.Post(server.URL, "text/plain", strings.NewReader("hello"))
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, int32(1), refreshes.Load())
+		require.Equal(t, []string{"hello", "hello"}, bodies)
+		require.Equal(t, "new", transport.Token().AccessToken)
+	})
+
+	t.Run("gives up after one retry", func(t *testing.T) {
+		bodies = nil
+		var refreshes atomic.Int32
+		token := &Token{AccessToken: "revoked", RefreshToken: "refresh", ExpiresIn: 3600}
+		token.SetExpiresAt()
+		transport := newTransport(token, &refreshes)
+		transport.Refresh = func(context.Context, string) (*Token, error) {
+			refreshes.Add(1)
+			return &Token{AccessToken: "also-revoked", ExpiresIn: 3600}, nil
+		}
+		client := &http.Client{Transport: transport}
+
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		require.Equal(t, int32(1), refreshes.Load())
+		require.Len(t, bodies, 2)
+	})
+
+	t.Run("transports sharing a lock refresh the token once", func(t *testing.T) {
+		var refreshes atomic.Int32
+		token := &Token{AccessToken: "old", RefreshToken: "refresh", ExpiresAt: time.Now().Add(-time.Minute).Unix()}
+		var lock sync.Mutex
+		first, second := newTransport(token, &refreshes), newTransport(token, &refreshes)
+		first.Lock, second.Lock = &lock, &lock
+		second.Token, second.Save = first.Token, first.Save
+
+		stale := first.Token()
+		var wg sync.WaitGroup
+		for _, transport := range []*RefreshTransport{first, second} {
+			wg.Go(func() {
+				_, err := transport.refresh(t.Context(), stale)
+				require.NoError(t, err)
+			})
+		}
+		wg.Wait()
+		require.Equal(t, int32(1), refreshes.Load(), "the second refresh sees the token of the first")
+	})
+}
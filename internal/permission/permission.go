@@ -7,14 +7,34 @@ import (
 	"path/filepath"
 	"slices"
 	"sync"
+	"time"
 
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/trust"
 	"github.com/google/uuid"
 )
 
 var ErrorPermissionDenied = errors.New("user denied permission")
 
+// TimeoutAction is what is done with a request no one answered before the
+// timeout.
+type TimeoutAction string
+
+const (
+	// TimeoutKeepWaiting keeps waiting for an answer, notifying again each
+	// time the timeout passes.
+	TimeoutKeepWaiting TimeoutAction = "keep-waiting"
+	// TimeoutDeny denies the request.
+	TimeoutDeny TimeoutAction = "deny"
+	// TimeoutAllowReadOnly grants the requests that only read and denies
+	// the rest.
+	TimeoutAllowReadOnly TimeoutAction = "allow-readonly"
+)
+
+// readOnlyActions are the actions of the tools that only read.
+var readOnlyActions = []string{"read", "list"}
+
 type CreatePermissionRequest struct {
 	SessionID   string `json:"session_id"`
 	ToolCallID  string `json:"tool_call_id"`
@@ -23,12 +43,27 @@ type CreatePermissionRequest struct {
 	Action      string `json:"action"`
 	Params      any    `json:"params"`
 	Path        string `json:"path"`
+	// ReadOnly requests are granted without asking unless the working
+	// directory is untrusted.
+	ReadOnly bool `json:"read_only,omitempty"`
+	// Confirmation is the text the user has to type to grant a destructive
+	// request. Such requests are asked even when the tool is allowed, can't
+	// be granted for the session, and are denied in auto-approved sessions.
+	Confirmation string `json:"confirmation,omitempty"`
+	// Warning tells why the request needs its confirmation, when it isn't
+	// for being destructive.
+	Warning string `json:"warning,omitempty"`
 }
 
 type PermissionNotification struct {
 	ToolCallID string `json:"tool_call_id"`
 	Granted    bool   `json:"granted"`
 	Denied     bool   `json:"denied"`
+	// TimedOut tells that the request waited for Waited without an answer.
+	// It was granted or denied when one of them is set, and is still
+	// waiting otherwise.
+	TimedOut bool          `json:"timed_out,omitempty"`
+	Waited   time.Duration `json:"waited,omitempty"`
 }
 
 type PermissionRequest struct {
@@ -40,6 +75,9 @@ type PermissionRequest struct {
 	Action      string `json:"action"`
 	Params      any    `json:"params"`
 	Path        string `json:"path"`
+	// Confirmation is the text the user has to type to grant the request.
+	Confirmation string `json:"confirmation,omitempty"`
+	Warning      string `json:"warning,omitempty"`
 }
 
 type Service interface {
@@ -51,6 +89,14 @@ type Service interface {
 	AutoApproveSession(sessionID string)
 	SetSkipRequests(skip bool)
 	SkipRequests() bool
+	SetAllowedTools(tools []string)
+	SetTrust(level trust.Level)
+	// SetTimeout sets how long requests wait for an answer before action is
+	// taken. A timeout of 0 waits forever.
+	SetTimeout(timeout time.Duration, action TimeoutAction)
+	// SetApprovalExpiry sets how long the permissions granted for the session
+	// last. An expiry of 0 keeps them until the end.
+	SetApprovalExpiry(expiry time.Duration)
 	SubscribeNotifications(ctx context.Context) <-chan pubsub.Event[PermissionNotification]
 }
 
@@ -59,17 +105,31 @@ type permissionService struct {
 
 	notificationBroker    *pubsub.Broker[PermissionNotification]
 	workingDir            string
-	sessionPermissions    []PermissionRequest
+	sessionPermissions    []sessionPermission
 	sessionPermissionsMu  sync.RWMutex
+	approvalExpiry        time.Duration
 	pendingRequests       *csync.Map[string, chan bool]
 	autoApproveSessions   map[string]bool
 	autoApproveSessionsMu sync.RWMutex
 	skip                  bool
 	allowedTools          []string
+	trust                 trust.Level
+	timeout               time.Duration
+	timeoutAction         TimeoutAction
+	timeoutMu             sync.RWMutex
 
 	// used to make sure we only process one request at a time
-	requestMu     sync.Mutex
-	activeRequest *PermissionRequest
+	requestMu sync.Mutex
+	// activeRequest is answered while Request holds requestMu, so it has a
+	// lock of its own.
+	activeRequest   *PermissionRequest
+	activeRequestMu sync.Mutex
+}
+
+// sessionPermission is a permission granted for the session, and when.
+type sessionPermission struct {
+	PermissionRequest
+	granted time.Time
 }
 
 func (s *permissionService) GrantPersistent(permission PermissionRequest) {
@@ -82,13 +142,14 @@ func (s *permissionService) GrantPersistent(permission PermissionRequest) {
 		respCh <- true
 	}
 
-	s.sessionPermissionsMu.Lock()
-	s.sessionPermissions = append(s.sessionPermissions, permission)
-	s.sessionPermissionsMu.Unlock()
-
-	if s.activeRequest != nil && s.activeRequest.ID == permission.ID {
-		s.activeRequest = nil
+	// Destructive requests are confirmed one at a time.
+	if permission.Confirmation == "" {
+		s.sessionPermissionsMu.Lock()
+		s.sessionPermissions = append(s.sessionPermissions, sessionPermission{permission, time.Now()})
+		s.sessionPermissionsMu.Unlock()
 	}
+
+	s.clearActiveRequest(permission.ID)
 }
 
 func (s *permissionService) Grant(permission PermissionRequest) {
@@ -101,9 +162,7 @@ func (s *permissionService) Grant(permission PermissionRequest) {
 		respCh <- true
 	}
 
-	if s.activeRequest != nil && s.activeRequest.ID == permission.ID {
-		s.activeRequest = nil
-	}
+	s.clearActiveRequest(permission.ID)
 }
 
 func (s *permissionService) Deny(permission PermissionRequest) {
@@ -117,15 +176,18 @@ func (s *permissionService) Deny(permission PermissionRequest) {
 		respCh <- false
 	}
 
-	if s.activeRequest != nil && s.activeRequest.ID == permission.ID {
-		s.activeRequest = nil
-	}
+	s.clearActiveRequest(permission.ID)
 }
 
 func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 	if s.skip {
 		return true
 	}
+	untrusted := s.trust == trust.Untrusted
+	confirm := opts.Confirmation != ""
+	if opts.ReadOnly && !untrusted && !confirm {
+		return true
+	}
 
 	// tell the UI that a permission was requested
 	s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
@@ -134,9 +196,10 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 	s.requestMu.Lock()
 	defer s.requestMu.Unlock()
 
-	// Check if the tool/action combination is in the allowlist
+	// Check if the tool/action combination is in the allowlist. Untrusted
+	// directories can't allow tools through their configuration.
 	commandKey := opts.ToolName + ":" + opts.Action
-	if slices.Contains(s.allowedTools, commandKey) || slices.Contains(s.allowedTools, opts.ToolName) {
+	if !untrusted && !confirm && (slices.Contains(s.allowedTools, commandKey) || slices.Contains(s.allowedTools, opts.ToolName)) {
 		return true
 	}
 
@@ -144,8 +207,10 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 	autoApprove := s.autoApproveSessions[opts.SessionID]
 	s.autoApproveSessionsMu.RUnlock()
 
+	// Auto-approved sessions have no one to type the confirmation of
+	// destructive requests, so these are denied.
 	if autoApprove {
-		return true
+		return !confirm
 	}
 
 	fileInfo, err := os.Stat(opts.Path)
@@ -170,27 +235,18 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 		Description: opts.Description,
 		Action:      opts.Action,
 		Params:      opts.Params,
-	}
 
-	s.sessionPermissionsMu.RLock()
-	for _, p := range s.sessionPermissions {
-		if p.ToolName == permission.ToolName && p.Action == permission.Action && p.SessionID == permission.SessionID && p.Path == permission.Path {
-			s.sessionPermissionsMu.RUnlock()
-			return true
-		}
+		Confirmation: opts.Confirmation,
+		Warning:      opts.Warning,
 	}
-	s.sessionPermissionsMu.RUnlock()
 
-	s.sessionPermissionsMu.RLock()
-	for _, p := range s.sessionPermissions {
-		if p.ToolName == permission.ToolName && p.Action == permission.Action && p.SessionID == permission.SessionID && p.Path == permission.Path {
-			s.sessionPermissionsMu.RUnlock()
-			return true
-		}
+	if !confirm && s.grantedForSession(permission) {
+		return true
 	}
-	s.sessionPermissionsMu.RUnlock()
 
+	s.activeRequestMu.Lock()
 	s.activeRequest = &permission
+	s.activeRequestMu.Unlock()
 
 	respCh := make(chan bool, 1)
 	s.pendingRequests.Set(permission.ID, respCh)
@@ -199,7 +255,77 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 	// Publish the request
 	s.Publish(pubsub.CreatedEvent, permission)
 
-	return <-respCh
+	return s.wait(permission, respCh, opts.ReadOnly || slices.Contains(readOnlyActions, opts.Action))
+}
+
+// grantedForSession reports whether permission was granted for the session,
+// dropping the grants that expired.
+func (s *permissionService) grantedForSession(permission PermissionRequest) bool {
+	s.sessionPermissionsMu.Lock()
+	defer s.sessionPermissionsMu.Unlock()
+	if s.approvalExpiry > 0 {
+		s.sessionPermissions = slices.DeleteFunc(s.sessionPermissions, func(p sessionPermission) bool {
+			return time.Since(p.granted) >= s.approvalExpiry
+		})
+	}
+	return slices.ContainsFunc(s.sessionPermissions, func(p sessionPermission) bool {
+		return p.ToolName == permission.ToolName && p.Action == permission.Action && p.SessionID == permission.SessionID && p.Path == permission.Path
+	})
+}
+
+// wait returns the answer to a request, or what the timeout action makes of
+// it when no answer comes in time.
+func (s *permissionService) wait(permission PermissionRequest, respCh chan bool, readOnly bool) bool {
+	s.timeoutMu.RLock()
+	timeout, action := s.timeout, s.timeoutAction
+	s.timeoutMu.RUnlock()
+	if timeout <= 0 {
+		return <-respCh
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	var waited time.Duration
+	for {
+		select {
+		case granted := <-respCh:
+			return granted
+		case <-timer.C:
+		}
+		waited += timeout
+		granted := readOnly && permission.Confirmation == ""
+		switch action {
+		case TimeoutDeny:
+			granted = false
+		case TimeoutAllowReadOnly:
+		default:
+			s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
+				ToolCallID: permission.ToolCallID,
+				TimedOut:   true,
+				Waited:     waited,
+			})
+			timer.Reset(timeout)
+			continue
+		}
+		s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
+			ToolCallID: permission.ToolCallID,
+			Granted:    granted,
+			Denied:     !granted,
+			TimedOut:   true,
+			Waited:     waited,
+		})
+		s.clearActiveRequest(permission.ID)
+		return granted
+	}
+}
+
+// clearActiveRequest forgets the active request once the one with id was
+// answered.
+func (s *permissionService) clearActiveRequest(id string) {
+	s.activeRequestMu.Lock()
+	defer s.activeRequestMu.Unlock()
+	if s.activeRequest != nil && s.activeRequest.ID == id {
+		s.activeRequest = nil
+	}
 }
 
 func (s *permissionService) AutoApproveSession(sessionID string) {
@@ -220,12 +346,35 @@ func (s *permissionService) SkipRequests() bool {
 	return s.skip
 }
 
+// SetAllowedTools replaces the tools that don't require permission.
+func (s *permissionService) SetAllowedTools(tools []string) {
+	s.allowedTools = tools
+}
+
+// SetTrust sets the trust level of the working directory.
+func (s *permissionService) SetTrust(level trust.Level) {
+	s.trust = level
+}
+
+func (s *permissionService) SetTimeout(timeout time.Duration, action TimeoutAction) {
+	s.timeoutMu.Lock()
+	defer s.timeoutMu.Unlock()
+	s.timeout = timeout
+	s.timeoutAction = action
+}
+
+func (s *permissionService) SetApprovalExpiry(expiry time.Duration) {
+	s.sessionPermissionsMu.Lock()
+	defer s.sessionPermissionsMu.Unlock()
+	s.approvalExpiry = expiry
+}
+
 func NewPermissionService(workingDir string, skip bool, allowedTools []string) Service {
 	return &permissionService{
 		Broker:              pubsub.NewBroker[PermissionRequest](),
 		notificationBroker:  pubsub.NewBroker[PermissionNotification](),
 		workingDir:          workingDir,
-		sessionPermissions:  make([]PermissionRequest, 0),
+		sessionPermissions:  make([]sessionPermission, 0),
 		autoApproveSessions: make(map[string]bool),
 		skip:                skip,
 		allowedTools:        allowedTools,
@@ -3,7 +3,9 @@ package permission
 import (
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/charmbracelet/crush/internal/trust"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -94,6 +96,161 @@ func TestPermissionService_SkipMode(t *testing.T) {
 	}
 }
 
+func TestPermissionService_Trust(t *testing.T) {
+	readOnly := CreatePermissionRequest{
+		SessionID: "session",
+		ToolName:  "bash",
+		Action:    "execute",
+		Path:      "/tmp",
+		ReadOnly:  true,
+	}
+
+	t.Run("read-only requests are granted unless untrusted", func(t *testing.T) {
+		service := NewPermissionService("/tmp", false, []string{})
+		assert.True(t, service.Request(readOnly))
+		service.SetTrust(trust.Trusted)
+		assert.True(t, service.Request(readOnly))
+	})
+
+	t.Run("untrusted directories ask for everything", func(t *testing.T) {
+		service := NewPermissionService("/tmp", false, []string{"bash"})
+		service.SetTrust(trust.Untrusted)
+		events := service.Subscribe(t.Context())
+
+		var granted bool
+		var wg sync.WaitGroup
+		wg.Go(func() {
+			granted = service.Request(readOnly)
+		})
+		event := <-events
+		assert.Equal(t, "bash", event.Payload.ToolName, "neither read-only nor allowed tools skip the request")
+		service.Deny(event.Payload)
+		wg.Wait()
+		assert.False(t, granted)
+	})
+}
+
+func TestPermissionService_Confirmation(t *testing.T) {
+	service := NewPermissionService("/tmp", false, []string{"bash"})
+	events := service.Subscribe(t.Context())
+
+	destructive := CreatePermissionRequest{
+		SessionID:    "session",
+		ToolName:     "bash",
+		Action:       "execute",
+		Path:         "/tmp",
+		Confirmation: "yes",
+	}
+	var granted bool
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		granted = service.Request(destructive)
+	})
+	event := <-events
+	assert.Equal(t, "yes", event.Payload.Confirmation, "allowed tools still ask")
+	service.GrantPersistent(event.Payload)
+	wg.Wait()
+	assert.True(t, granted)
+
+	wg.Go(func() {
+		granted = service.Request(destructive)
+	})
+	event = <-events
+	assert.Equal(t, "bash", event.Payload.ToolName, "destructive requests are never granted for the session")
+	service.Deny(event.Payload)
+	wg.Wait()
+	assert.False(t, granted)
+
+	service.AutoApproveSession("session")
+	assert.False(t, service.Request(destructive), "no one confirms in auto-approved sessions")
+	destructive.Confirmation = ""
+	assert.True(t, service.Request(destructive))
+}
+
+func TestPermissionService_Timeout(t *testing.T) {
+	write := CreatePermissionRequest{
+		SessionID:  "session",
+		ToolCallID: "call",
+		ToolName:   "edit",
+		Action:     "write",
+		Path:       "/tmp",
+	}
+	read := write
+	read.ToolName, read.Action = "view", "read"
+
+	t.Run("deny", func(t *testing.T) {
+		service := NewPermissionService("/tmp", false, []string{})
+		service.SetTimeout(10*time.Millisecond, TimeoutDeny)
+		notifications := service.SubscribeNotifications(t.Context())
+		assert.False(t, service.Request(read))
+		<-notifications // The request was shown.
+		n := (<-notifications).Payload
+		assert.Equal(t, PermissionNotification{ToolCallID: "call", Denied: true, TimedOut: true, Waited: 10 * time.Millisecond}, n)
+	})
+
+	t.Run("allow-readonly", func(t *testing.T) {
+		service := NewPermissionService("/tmp", false, []string{})
+		service.SetTimeout(10*time.Millisecond, TimeoutAllowReadOnly)
+		assert.True(t, service.Request(read))
+		assert.False(t, service.Request(write))
+		read.Confirmation = "yes"
+		assert.False(t, service.Request(read), "requests to confirm are never granted by a timeout")
+		read.Confirmation = ""
+	})
+
+	t.Run("keep-waiting", func(t *testing.T) {
+		service := NewPermissionService("/tmp", false, []string{})
+		service.SetTimeout(10*time.Millisecond, TimeoutKeepWaiting)
+		events := service.Subscribe(t.Context())
+		notifications := service.SubscribeNotifications(t.Context())
+
+		var granted bool
+		var wg sync.WaitGroup
+		wg.Go(func() {
+			granted = service.Request(write)
+		})
+		event := <-events
+		<-notifications
+		assert.Equal(t, PermissionNotification{ToolCallID: "call", TimedOut: true, Waited: 10 * time.Millisecond}, (<-notifications).Payload)
+		assert.Equal(t, 20*time.Millisecond, (<-notifications).Payload.Waited, "it notifies again each timeout")
+		service.Grant(event.Payload)
+		wg.Wait()
+		assert.True(t, granted)
+	})
+}
+
+func TestPermissionService_ApprovalExpiry(t *testing.T) {
+	service := NewPermissionService("/tmp", false, []string{})
+	service.SetApprovalExpiry(50 * time.Millisecond)
+	events := service.Subscribe(t.Context())
+
+	write := CreatePermissionRequest{
+		SessionID: "session",
+		ToolName:  "edit",
+		Action:    "write",
+		Path:      "/tmp",
+	}
+	var granted bool
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		granted = service.Request(write)
+	})
+	service.GrantPersistent((<-events).Payload)
+	wg.Wait()
+	assert.True(t, granted)
+	assert.True(t, service.Request(write), "granted for the session")
+
+	time.Sleep(60 * time.Millisecond)
+	wg.Go(func() {
+		granted = service.Request(write)
+	})
+	event := <-events
+	assert.Equal(t, "edit", event.Payload.ToolName, "the grant expired")
+	service.Deny(event.Payload)
+	wg.Wait()
+	assert.False(t, granted)
+}
+
 func TestPermissionService_SequentialProperties(t *testing.T) {
 	t.Run("Sequential permission requests with persistent grants", func(t *testing.T) {
 		service := NewPermissionService("/tmp", false, []string{})
@@ -185,7 +342,7 @@ func TestPermissionService_SequentialProperties(t *testing.T) {
 		events := service.Subscribe(t.Context())
 
 		var wg sync.WaitGroup
-		results := make([]bool, 0)
+		results := make([]bool, 3)
 
 		requests := []CreatePermissionRequest{
 			{
@@ -215,7 +372,7 @@ func TestPermissionService_SequentialProperties(t *testing.T) {
 			wg.Add(1)
 			go func(index int, request CreatePermissionRequest) {
 				defer wg.Done()
-				results = append(results, service.Request(request))
+				results[index] = service.Request(request)
 			}(i, req)
 		}
 
@@ -0,0 +1,170 @@
+// Package prompthistory keeps the prompts submitted in a project, so that
+// they can be recalled in the editor. The history is kept apart from the
+// sessions and is never sent to the model.
+package prompthistory
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/fsext"
+)
+
+// maxEntries is how many prompts the history keeps.
+const maxEntries = 1000
+
+// History is the prompt history of a project, stored as JSON lines in a
+// file.
+type History struct {
+	path    string
+	sealer  db.Sealer
+	mu      sync.Mutex
+	entries []string
+	loaded  bool
+}
+
+// New returns the history stored in the file at path. The prompts are
+// encrypted with sealer, if not nil, like the messages of an encrypted
+// store.
+func New(path string, sealer db.Sealer) *History {
+	return &History{path: path, sealer: sealer}
+}
+
+// ForDataDir returns the history stored in the data directory of a project.
+func ForDataDir(dataDir string, sealer db.Sealer) *History {
+	return New(filepath.Join(dataDir, "prompt_history.jsonl"), sealer)
+}
+
+// Entries returns the prompts, oldest first.
+func (h *History) Entries() ([]string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := h.load(); err != nil {
+		return nil, err
+	}
+	return append([]string(nil), h.entries...), nil
+}
+
+// Add adds prompt to the history, unless it is empty or the same as the last
+// one.
+func (h *History) Add(prompt string) error {
+	prompt = strings.TrimSpace(prompt)
+	if prompt == "" {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := h.load(); err != nil {
+		return err
+	}
+	if n := len(h.entries); n > 0 && h.entries[n-1] == prompt {
+		return nil
+	}
+	h.entries = append(h.entries, prompt)
+	if len(h.entries) > maxEntries {
+		h.entries = h.entries[len(h.entries)-maxEntries:]
+		return h.save()
+	}
+	return h.append(prompt)
+}
+
+func (h *History) load() error {
+	if h.loaded {
+		return nil
+	}
+	data, err := os.ReadFile(h.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read prompt history: %w", err)
+	}
+	var unsealed bool
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		var prompt string
+		// Skip lines a crash may have cut short.
+		if json.Unmarshal(scanner.Bytes(), &prompt) != nil || prompt == "" {
+			continue
+		}
+		switch {
+		case h.sealer != nil:
+			unsealed = unsealed || !db.IsSealed(prompt)
+			if prompt, err = h.sealer.Open(prompt); err != nil {
+				return fmt.Errorf("failed to read prompt history: %w", err)
+			}
+		case db.IsSealed(prompt):
+			// Prompts encrypted before encryption was turned off can't be
+			// read anymore.
+			continue
+		}
+		h.entries = append(h.entries, prompt)
+	}
+	if len(h.entries) > maxEntries {
+		h.entries = h.entries[len(h.entries)-maxEntries:]
+	}
+	h.loaded = true
+	// Encrypt the prompts kept before encryption was turned on.
+	if unsealed {
+		return h.save()
+	}
+	return nil
+}
+
+// encode returns the line of the history file for prompt.
+func (h *History) encode(prompt string) ([]byte, error) {
+	if h.sealer != nil {
+		sealed, err := h.sealer.Seal(prompt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save prompt history: %w", err)
+		}
+		prompt = sealed
+	}
+	return json.Marshal(prompt)
+}
+
+func (h *History) append(prompt string) error {
+	line, err := h.encode(prompt)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o700); err != nil {
+		return fmt.Errorf("failed to save prompt history: %w", err)
+	}
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to save prompt history: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to save prompt history: %w", err)
+	}
+	return f.Close()
+}
+
+// save writes the whole history, to drop the oldest prompts.
+func (h *History) save() error {
+	var buf bytes.Buffer
+	for _, prompt := range h.entries {
+		line, err := h.encode(prompt)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o700); err != nil {
+		return fmt.Errorf("failed to save prompt history: %w", err)
+	}
+	if err := fsext.WriteFileAtomic(h.path, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to save prompt history: %w", err)
+	}
+	return nil
+}
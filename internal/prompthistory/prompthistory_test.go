@@ -0,0 +1,87 @@
+package prompthistory
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	h := ForDataDir(t.TempDir(), nil)
+	entries, err := h.Entries()
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	require.NoError(t, h.Add("  fix the tests\n"))
+	require.NoError(t, h.Add("fix the tests"))
+	require.NoError(t, h.Add(" "))
+	require.NoError(t, h.Add("explain\nthis"))
+	require.NoError(t, h.Add("fix the tests"))
+
+	want := []string{"fix the tests", "explain\nthis", "fix the tests"}
+	entries, err = h.Entries()
+	require.NoError(t, err)
+	require.Equal(t, want, entries)
+
+	entries, err = New(h.path, nil).Entries()
+	require.NoError(t, err)
+	require.Equal(t, want, entries)
+}
+
+func TestHistorySkipsBadLines(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "prompt_history.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("\"one\"\n{\"two\n\"three\"\n"), 0o600))
+
+	entries, err := New(path, nil).Entries()
+	require.NoError(t, err)
+	require.Equal(t, []string{"one", "three"}, entries)
+}
+
+func TestHistoryTrim(t *testing.T) {
+	t.Parallel()
+
+	h := ForDataDir(t.TempDir(), nil)
+	for i := range maxEntries + 5 {
+		require.NoError(t, h.Add(strconv.Itoa(i)))
+	}
+
+	entries, err := New(h.path, nil).Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, maxEntries)
+	require.Equal(t, "5", entries[0])
+	require.Equal(t, strconv.Itoa(maxEntries+4), entries[len(entries)-1])
+}
+
+func TestHistoryEncrypted(t *testing.T) {
+	t.Parallel()
+
+	c, err := db.NewKeyCipher(make([]byte, db.KeySize), make([]byte, 16))
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "prompt_history.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("\"kept before\"\n"), 0o600))
+
+	h := New(path, c)
+	require.NoError(t, h.Add("the secret plan"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "the secret plan")
+	require.NotContains(t, string(data), "kept before")
+
+	entries, err := New(path, c).Entries()
+	require.NoError(t, err)
+	require.Equal(t, []string{"kept before", "the secret plan"}, entries)
+
+	entries, err = New(path, nil).Entries()
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
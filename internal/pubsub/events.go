@@ -33,3 +33,10 @@ type UpdateAvailableMsg struct {
 	LatestVersion  string
 	IsDevelopment  bool
 }
+
+// ContextDriftMsg is sent when the project changed since its context file
+// was last written.
+type ContextDriftMsg struct {
+	File    string
+	Changes []string
+}
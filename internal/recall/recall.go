@@ -0,0 +1,107 @@
+// Package recall searches the answers the assistant gave in previous
+// sessions, so they can be reused as context in a new prompt.
+package recall
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// snippetContext is how many characters before the first match a snippet
+// shows.
+const snippetContext = 24
+
+// Result is a past assistant answer matching a search.
+type Result struct {
+	Message      message.Message
+	SessionTitle string
+}
+
+// Text returns the text of the answer.
+func (r Result) Text() string {
+	return r.Message.Content().Text
+}
+
+// Search returns the past assistant answers of all sessions containing every
+// word of query, newest first. At most limit answers are returned, or all of
+// them when limit is 0.
+func Search(ctx context.Context, messages message.Service, sessions session.Service, query string, limit int) ([]Result, error) {
+	msgs, err := messages.Search(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+	titles := map[string]string{}
+	results := make([]Result, 0, len(msgs))
+	for _, msg := range msgs {
+		title, ok := titles[msg.SessionID]
+		if !ok {
+			// The session may be gone while its messages are not; show
+			// the answer anyway.
+			if sess, err := sessions.Get(ctx, msg.SessionID); err == nil {
+				title = sess.Title
+			}
+			titles[msg.SessionID] = title
+		}
+		results = append(results, Result{Message: msg, SessionTitle: title})
+	}
+	return results, nil
+}
+
+// Snippet returns the text of an answer on a single line of at most width
+// cells, starting a bit before the first word of query it contains.
+func Snippet(text, query string, width int) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	start := 0
+	if terms := strings.Fields(query); len(terms) > 0 {
+		if i := indexFold(runes, []rune(terms[0])); i > snippetContext {
+			start = i - snippetContext
+		}
+	}
+	snippet := string(runes[start:])
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	return ansi.Truncate(snippet, width, "…")
+}
+
+// indexFold returns the index of the first occurrence of sub in s, ignoring
+// case, or -1.
+func indexFold(s, sub []rune) int {
+	for i := 0; i+len(sub) <= len(s); i++ {
+		match := true
+		for j, r := range sub {
+			if unicode.ToLower(s[i+j]) != unicode.ToLower(r) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
+
+// Quote formats an answer to be added to a prompt as context.
+func Quote(r Result) string {
+	var sb strings.Builder
+	if r.SessionTitle != "" {
+		fmt.Fprintf(&sb, "From an earlier answer, in the session %q:\n\n", r.SessionTitle)
+	} else {
+		sb.WriteString("From an earlier answer:\n\n")
+	}
+	for line := range strings.SplitSeq(strings.TrimSpace(r.Text()), "\n") {
+		if line == "" {
+			sb.WriteString(">\n")
+			continue
+		}
+		sb.WriteString("> " + line + "\n")
+	}
+	return sb.String()
+}
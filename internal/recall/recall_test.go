@@ -0,0 +1,74 @@
+package recall
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+	sessions := session.NewService(q)
+	messages := message.NewService(q)
+
+	create := func(sessionID string, role message.MessageRole, text string) {
+		t.Helper()
+		_, err := messages.Create(t.Context(), sessionID, message.CreateMessageParams{
+			Role:  role,
+			Parts: []message.ContentPart{message.TextContent{Text: text}},
+		})
+		require.NoError(t, err)
+	}
+	first, err := sessions.Create(t.Context(), "Database setup")
+	require.NoError(t, err)
+	create(first.ID, message.User, "How do I run the migrations?")
+	create(first.ID, message.Assistant, "Run the Goose migrations with `task migrate`.")
+	second, err := sessions.Create(t.Context(), "Release")
+	require.NoError(t, err)
+	create(second.ID, message.Assistant, "Tag the release, then the migrations run on deploy.")
+	create(second.ID, message.Assistant, "Nothing to see here.")
+
+	results, err := Search(t.Context(), messages, sessions, "MIGRATIONS run", 0)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, "Release", results[0].SessionTitle)
+	require.Equal(t, "Database setup", results[1].SessionTitle)
+
+	results, err = Search(t.Context(), messages, sessions, "goose migrate", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "Run the Goose migrations with `task migrate`.", results[0].Text())
+
+	// User messages are not answers.
+	results, err = Search(t.Context(), messages, sessions, "how do i", 0)
+	require.NoError(t, err)
+	require.Empty(t, results)
+}
+
+func TestSnippet(t *testing.T) {
+	t.Parallel()
+
+	text := "First, install the dependencies.\n\nThen open the configuration file and set the port to 8080."
+	require.Equal(t, "First, install the …", Snippet(text, "install", 20))
+	require.Equal(t, "…ration file and set the port…", Snippet(text, "PORT", 30))
+	require.Equal(t, "First, install the …", Snippet(text, "missing", 20))
+}
+
+func TestQuote(t *testing.T) {
+	t.Parallel()
+
+	msg := message.Message{Parts: []message.ContentPart{message.TextContent{Text: "Use a map.\n\nIt's faster.\n"}}}
+	require.Equal(t,
+		"From an earlier answer, in the session \"Lookups\":\n\n> Use a map.\n>\n> It's faster.\n",
+		Quote(Result{Message: msg, SessionTitle: "Lookups"}),
+	)
+	require.Equal(t, "From an earlier answer:\n\n> Use a map.\n>\n> It's faster.\n", Quote(Result{Message: msg}))
+}
@@ -0,0 +1,124 @@
+// Package remote runs Crush against a project on another host over SSH. The
+// project directory is mounted locally with sshfs, so that the file tools
+// and the LSP clients work on it as on a local one, and the commands of the
+// bash tool run on the host over ssh.
+//
+// The LSP servers run locally, not on the host: the project is mounted at
+// another path than it has there, and the paths in the messages of the LSP
+// protocol aren't translated.
+package remote
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// unmountTimeout is how long unmounting the project can take.
+const unmountTimeout = 5 * time.Second
+
+// Target is a directory on a host reachable over ssh.
+type Target struct {
+	// Host is the destination given to ssh, like user@host or an alias of
+	// the ssh configuration.
+	Host string
+	// Dir is the directory on the host. A relative one is relative to the
+	// home directory of the user.
+	Dir string
+}
+
+// Parse parses a [user@]host:path target. The path defaults to the home
+// directory of the user.
+func Parse(spec string) (Target, error) {
+	host, dir, _ := strings.Cut(spec, ":")
+	if host == "" || strings.HasPrefix(host, "-") || strings.ContainsAny(host, " \t/") {
+		return Target{}, fmt.Errorf("invalid remote %q: use [user@]host:path", spec)
+	}
+	if user, name, ok := strings.Cut(host, "@"); ok && (user == "" || name == "") {
+		return Target{}, fmt.Errorf("invalid remote %q: use [user@]host:path", spec)
+	}
+	if dir == "" {
+		dir = "."
+	}
+	return Target{Host: host, Dir: dir}, nil
+}
+
+// String returns the target as host:path.
+func (t Target) String() string {
+	return t.Host + ":" + t.Dir
+}
+
+// ID returns a name for the target that is safe to use in file names.
+func (t Target) ID() string {
+	sum := sha256.Sum256([]byte(t.String()))
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' {
+			return r
+		}
+		return '_'
+	}, t.Host)
+	return name + "-" + hex.EncodeToString(sum[:4])
+}
+
+// Mount is the local mount of a target.
+type Mount struct {
+	Target Target
+	// Dir is the local directory the target is mounted on.
+	Dir string
+}
+
+// NewMount mounts target on a new temporary directory with sshfs.
+func NewMount(ctx context.Context, target Target) (*Mount, error) {
+	if _, err := exec.LookPath("sshfs"); err != nil {
+		return nil, errors.New("remote mode needs sshfs: install it (with macFUSE on macOS) and try again")
+	}
+	dir, err := os.MkdirTemp("", "crush-remote-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mount point: %w", err)
+	}
+	cmd := exec.CommandContext(ctx, "sshfs", target.String(), dir,
+		"-o", "reconnect,ServerAliveInterval=15,ServerAliveCountMax=3",
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		_ = os.Remove(dir)
+		return nil, fmt.Errorf("failed to mount %s: %w: %s", target, err, strings.TrimSpace(string(out)))
+	}
+	return &Mount{Target: target, Dir: dir}, nil
+}
+
+// Close unmounts the target and removes the mount point.
+func (m *Mount) Close() error {
+	// The mount can't be unmounted while it is the working directory.
+	if wd, err := os.Getwd(); err == nil && (wd == m.Dir || strings.HasPrefix(wd, m.Dir+string(filepath.Separator))) {
+		_ = os.Chdir(os.TempDir())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), unmountTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	switch {
+	case runtime.GOOS == "darwin":
+		cmd = exec.CommandContext(ctx, "umount", m.Dir)
+	case hasCommand("fusermount3"):
+		cmd = exec.CommandContext(ctx, "fusermount3", "-u", m.Dir)
+	default:
+		cmd = exec.CommandContext(ctx, "fusermount", "-u", m.Dir)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to unmount %s: %w: %s", m.Target, err, strings.TrimSpace(string(out)))
+	}
+	return os.Remove(m.Dir)
+}
+
+func hasCommand(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
@@ -0,0 +1,49 @@
+package remote
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		spec   string
+		target Target
+		err    bool
+	}{
+		{spec: "me@devbox:/srv/project", target: Target{Host: "me@devbox", Dir: "/srv/project"}},
+		{spec: "devbox:src/app", target: Target{Host: "devbox", Dir: "src/app"}},
+		{spec: "devbox", target: Target{Host: "devbox", Dir: "."}},
+		{spec: "devbox:", target: Target{Host: "devbox", Dir: "."}},
+		{spec: ":/srv", err: true},
+		{spec: "@devbox:/srv", err: true},
+		{spec: "me@:/srv", err: true},
+		{spec: "-oProxyCommand=x:/srv", err: true},
+		{spec: "dev box:/srv", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			t.Parallel()
+			target, err := Parse(tt.spec)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.target, target)
+		})
+	}
+}
+
+func TestTargetID(t *testing.T) {
+	t.Parallel()
+
+	a := Target{Host: "me@dev.box", Dir: "/srv/a"}
+	b := Target{Host: "me@dev.box", Dir: "/srv/b"}
+	require.Regexp(t, `^me_dev\.box-[0-9a-f]{8}$`, a.ID())
+	require.NotEqual(t, a.ID(), b.ID())
+	require.Equal(t, a.ID(), Target{Host: "me@dev.box", Dir: "/srv/a"}.ID())
+}
@@ -0,0 +1,238 @@
+// Package replay records provider responses to disk and replays them, so
+// that integration tests and demos can run offline and deterministically.
+//
+// Set CRUSH_REPLAY to "record" to save the response of every provider
+// request, or to "replay" to answer requests from the saved responses
+// without reaching the provider. The responses are saved in
+// CRUSH_REPLAY_DIR, or in the replays directory of the data directory.
+package replay
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/crush/internal/httpext"
+)
+
+// Mode is whether responses are recorded or replayed.
+type Mode string
+
+const (
+	// Off sends requests to the provider as usual.
+	Off Mode = ""
+	// Record sends requests to the provider and saves their responses.
+	Record Mode = "record"
+	// Replay answers requests from the saved responses.
+	Replay Mode = "replay"
+)
+
+// FromEnv returns the mode and directory set in the environment, using the
+// replays directory of dataDir when CRUSH_REPLAY_DIR isn't set.
+func FromEnv(dataDir string) (Mode, string, error) {
+	mode := Mode(strings.ToLower(strings.TrimSpace(os.Getenv("CRUSH_REPLAY"))))
+	switch mode {
+	case Off, Record, Replay:
+	default:
+		return Off, "", fmt.Errorf("invalid CRUSH_REPLAY %q, must be record or replay", mode)
+	}
+	dir := os.Getenv("CRUSH_REPLAY_DIR")
+	if dir == "" {
+		dir = filepath.Join(dataDir, "replays")
+	}
+	return mode, dir, nil
+}
+
+// Fixture is a saved request and its response.
+type Fixture struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is the part of a request its response is looked up by.
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+// Response is a saved response.
+type Response struct {
+	StatusCode int                 `json:"status_code"`
+	Headers    map[string][]string `json:"headers,omitempty"`
+	Body       string              `json:"body,omitempty"`
+}
+
+// Transport is an http.RoundTripper that records or replays the responses to
+// its requests. Requests are looked up by a hash of their method, URL and
+// body, with their secrets scrubbed; the same request sent again gets the
+// response recorded for it the same time, or the last one.
+type Transport struct {
+	// Base sends the requests to record, httpext.DefaultTransport if nil.
+	Base     http.RoundTripper
+	Mode     Mode
+	Dir      string
+	Scrubber *Scrubber
+}
+
+// seen counts how many times each request was sent, across the transports
+// of every provider, by mode and fixture path.
+var (
+	seenMu sync.Mutex
+	seen   = make(map[string]int)
+)
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	key := Request{
+		Method: req.Method,
+		URL:    t.Scrubber.Scrub(req.URL.String()),
+		Body:   t.Scrubber.Scrub(string(canonicalJSON(body))),
+	}
+	path := t.path(key)
+
+	if t.Mode == Replay {
+		return t.replay(req, key, path)
+	}
+	return t.record(req, key, path)
+}
+
+// path returns the file of the response to key, counting how many times it
+// was asked for.
+func (t *Transport) path(key Request) string {
+	sum := sha256.Sum256([]byte(key.Method + "\n" + key.URL + "\n" + key.Body))
+	hash := hex.EncodeToString(sum[:8])
+
+	seenMu.Lock()
+	defer seenMu.Unlock()
+	id := string(t.Mode) + ":" + filepath.Join(t.Dir, hash)
+	seen[id]++
+	n := seen[id]
+	if t.Mode == Replay {
+		// Fall back to the last recorded response.
+		for n > 1 && !exists(fixturePath(t.Dir, hash, n)) {
+			n--
+		}
+	}
+	return fixturePath(t.Dir, hash, n)
+}
+
+func (t *Transport) record(req *http.Request, key Request, path string) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = httpext.DefaultTransport()
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	// Streamed responses are read whole, to be saved before they're used.
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	headers := make(map[string][]string, len(resp.Header))
+	for name, values := range resp.Header {
+		scrubbed := make([]string, len(values))
+		for i, value := range values {
+			scrubbed[i] = t.Scrubber.ScrubHeader(name, value)
+		}
+		headers[name] = scrubbed
+	}
+	fixture := Fixture{
+		Request: key,
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Headers:    headers,
+			Body:       t.Scrubber.Scrub(string(body)),
+		},
+	}
+	if err := save(path, fixture); err != nil {
+		return nil, fmt.Errorf("failed to record response: %w", err)
+	}
+	return resp, nil
+}
+
+func (t *Transport) replay(req *http.Request, key Request, path string) (*http.Response, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no recorded response for %s %s in %s", key.Method, key.URL, t.Dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to read recorded response %s: %w", path, err)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fixture.Response.StatusCode, http.StatusText(fixture.Response.StatusCode)),
+		StatusCode:    fixture.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header(fixture.Response.Headers),
+		Body:          io.NopCloser(strings.NewReader(fixture.Response.Body)),
+		ContentLength: int64(len(fixture.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// canonicalJSON returns body with its object keys sorted and without
+// whitespace, so that the same request hashes the same however it is
+// encoded. Bodies that aren't JSON are returned as they are.
+func canonicalJSON(body []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if dec.Decode(&v) != nil || dec.More() {
+		return body
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return data
+}
+
+func fixturePath(dir, hash string, n int) string {
+	return filepath.Join(dir, fmt.Sprintf("%s-%d.json", hash, n))
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func save(path string, fixture Fixture) error {
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
@@ -0,0 +1,96 @@
+package replay
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func send(t *testing.T, transport http.RoundTripper, body string) string {
+	t.Helper()
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, "https://api.example.com/v1/chat?key=sk-test-0123456789abcdef", strings.NewReader(body))
+	require.NoError(t, err)
+	resp, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestRecordReplay(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	calls := 0
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"text/event-stream"}, "Set-Cookie": {"session=abc"}},
+			Body:       io.NopCloser(strings.NewReader("data: answer " + strings.Repeat("I", calls) + " for hunter2-password\n\n")),
+		}, nil
+	})
+	scrubber := &Scrubber{secrets: []string{"hunter2-password"}}
+
+	recorder := &Transport{Base: base, Mode: Record, Dir: dir, Scrubber: scrubber}
+	require.Equal(t, "data: answer I for hunter2-password\n\n", send(t, recorder, `{"b": 1, "a": [1, 2]}`))
+	require.Equal(t, "data: answer II for hunter2-password\n\n", send(t, recorder, `{"a":[1,2],"b":1}`))
+	require.Equal(t, 2, calls)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		require.NotContains(t, string(data), "hunter2-password")
+		require.NotContains(t, string(data), "sk-test-0123456789abcdef")
+		require.NotContains(t, string(data), "session=abc")
+	}
+
+	replayer := &Transport{Mode: Replay, Dir: dir, Scrubber: scrubber}
+	require.Equal(t, "data: answer I for [REDACTED]\n\n", send(t, replayer, `{"a":[1,2],"b":1}`))
+	require.Equal(t, "data: answer II for [REDACTED]\n\n", send(t, replayer, `{"a":[1,2],"b":1}`))
+	// Requests sent more times than recorded get the last response.
+	require.Equal(t, "data: answer II for [REDACTED]\n\n", send(t, replayer, `{"a":[1,2],"b":1}`))
+	require.Equal(t, 2, calls)
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, "https://api.example.com/v1/chat", strings.NewReader("{}"))
+	require.NoError(t, err)
+	_, err = replayer.RoundTrip(req)
+	require.ErrorContains(t, err, "no recorded response for POST https://api.example.com/v1/chat")
+}
+
+func TestScrubber(t *testing.T) {
+	t.Parallel()
+
+	s := &Scrubber{secrets: []string{"my-own-secret-value"}}
+	require.Equal(t,
+		`{"key":"[REDACTED]","auth":"[REDACTED]","url":"https://x.dev/?alt=sse&key=[REDACTED]"}`,
+		s.Scrub(`{"key":"my-own-secret-value","auth":"Bearer abc.def.ghijkl","url":"https://x.dev/?alt=sse&key=AIzaSomething"}`),
+	)
+	require.Equal(t, "[REDACTED]", s.Scrub("sk-ant-REDACTED"))
+	require.Equal(t, "[REDACTED]", s.ScrubHeader("X-Api-Key", "anything"))
+	require.Equal(t, "application/json", s.ScrubHeader("Content-Type", "application/json"))
+	require.Equal(t, "plain text", (*Scrubber)(nil).Scrub("plain text"))
+}
+
+func TestNewScrubber(t *testing.T) {
+	t.Setenv("CRUSH_TEST_API_KEY", "a-long-enough-key")
+	t.Setenv("CRUSH_TEST_SHORT_TOKEN", "1")
+
+	s := NewScrubber("given-secret-value", "short")
+	require.Equal(t, "[REDACTED] [REDACTED] 1 short", s.Scrub("a-long-enough-key given-secret-value 1 short"))
+}
@@ -0,0 +1,92 @@
+package replay
+
+import (
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// redacted replaces the secrets a Scrubber finds.
+const redacted = "[REDACTED]"
+
+// minSecretLen is how long the value of an environment variable has to be
+// to be scrubbed, so that short values like "1" don't scrub everything.
+const minSecretLen = 8
+
+// secretPatterns match secrets by their shape, for those not given to the
+// Scrubber.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{16,}`),
+	regexp.MustCompile(`\bAIza[A-Za-z0-9_-]{30,}`),
+	regexp.MustCompile(`\b(?:gh[pousr]|github_pat)_[A-Za-z0-9_]{20,}`),
+	regexp.MustCompile(`(?i)\bBearer\s+[A-Za-z0-9._~+/=-]{8,}`),
+	regexp.MustCompile(`(?i)([?&](?:key|api_key|access_token|token)=)[^&"\s]+`),
+}
+
+// Scrubber removes secrets from what is saved in fixtures: the values it is
+// given, and anything shaped like an API key or a token.
+type Scrubber struct {
+	secrets []string
+}
+
+// NewScrubber returns a scrubber for secrets, and for the values of the
+// environment variables whose names tell they hold one.
+func NewScrubber(secrets ...string) *Scrubber {
+	for _, env := range os.Environ() {
+		name, value, _ := strings.Cut(env, "=")
+		if isSecretName(name) {
+			secrets = append(secrets, value)
+		}
+	}
+	secrets = slices.DeleteFunc(secrets, func(s string) bool {
+		return len(s) < minSecretLen
+	})
+	// Scrub longer secrets first, for those containing others.
+	slices.SortFunc(secrets, func(a, b string) int {
+		return len(b) - len(a)
+	})
+	return &Scrubber{secrets: slices.Compact(secrets)}
+}
+
+// Scrub replaces the secrets in s.
+func (s *Scrubber) Scrub(text string) string {
+	if s == nil {
+		return text
+	}
+	for _, secret := range s.secrets {
+		text = strings.ReplaceAll(text, secret, redacted)
+	}
+	for _, pattern := range secretPatterns {
+		text = pattern.ReplaceAllStringFunc(text, func(match string) string {
+			// Keep the name of query parameters.
+			if i := strings.IndexByte(match, '='); i >= 0 && (match[0] == '?' || match[0] == '&') {
+				return match[:i+1] + redacted
+			}
+			return redacted
+		})
+	}
+	return text
+}
+
+// ScrubHeader returns the value of a header, redacted whole if the header
+// holds credentials.
+func (s *Scrubber) ScrubHeader(name, value string) string {
+	lower := strings.ToLower(name)
+	if strings.Contains(lower, "authorization") || strings.Contains(lower, "cookie") || isSecretName(name) {
+		return redacted
+	}
+	return s.Scrub(value)
+}
+
+// isSecretName reports whether a variable or header named name holds a
+// secret.
+func isSecretName(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, word := range []string{"KEY", "TOKEN", "SECRET", "PASSWORD"} {
+		if strings.Contains(upper, word) {
+			return true
+		}
+	}
+	return false
+}
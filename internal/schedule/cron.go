@@ -0,0 +1,138 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// shorthands are the named schedules accepted in place of the five fields.
+var shorthands = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Spec is a parsed cron expression: minute, hour, day of month, month and
+// day of week, matched in the local time zone.
+type Spec struct {
+	minute, hour, dom, month, dow uint64
+
+	// As in cron, when both days are restricted either one matching is
+	// enough.
+	domAny, dowAny bool
+}
+
+// Parse parses a five field cron expression. Fields take `*`, numbers,
+// ranges (`1-5`), steps (`*/15`, `0-30/10`) and comma separated lists of
+// those; Sunday is 0 or 7. The @hourly, @daily, @weekly, @monthly and
+// @yearly shorthands are accepted too.
+func Parse(expr string) (Spec, error) {
+	expr = strings.TrimSpace(expr)
+	if s, ok := shorthands[expr]; ok {
+		expr = s
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return Spec{}, fmt.Errorf("invalid schedule %q: expected 5 fields, got %d", expr, len(fields))
+	}
+
+	var spec Spec
+	var err error
+	if spec.minute, err = parseField(fields[0], 0, 59); err != nil {
+		return Spec{}, fmt.Errorf("invalid minute in %q: %w", expr, err)
+	}
+	if spec.hour, err = parseField(fields[1], 0, 23); err != nil {
+		return Spec{}, fmt.Errorf("invalid hour in %q: %w", expr, err)
+	}
+	if spec.dom, err = parseField(fields[2], 1, 31); err != nil {
+		return Spec{}, fmt.Errorf("invalid day of month in %q: %w", expr, err)
+	}
+	if spec.month, err = parseField(fields[3], 1, 12); err != nil {
+		return Spec{}, fmt.Errorf("invalid month in %q: %w", expr, err)
+	}
+	if spec.dow, err = parseField(fields[4], 0, 7); err != nil {
+		return Spec{}, fmt.Errorf("invalid day of week in %q: %w", expr, err)
+	}
+	if spec.dow&(1<<7) != 0 {
+		spec.dow |= 1
+	}
+	spec.domAny = fields[2] == "*"
+	spec.dowAny = fields[4] == "*"
+	return spec, nil
+}
+
+func parseField(field string, lo, hi int) (uint64, error) {
+	var bits uint64
+	for part := range strings.SplitSeq(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepStr)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepStr)
+			}
+		}
+
+		start, end := lo, hi
+		if rng != "*" {
+			from, to, isRange := strings.Cut(rng, "-")
+			var err error
+			if start, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("invalid value %q", from)
+			}
+			end = start
+			if isRange {
+				if end, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("invalid value %q", to)
+				}
+			} else if hasStep {
+				end = hi
+			}
+		}
+		if start < lo || end > hi || start > end {
+			return 0, fmt.Errorf("%q is out of range %d-%d", rng, lo, hi)
+		}
+		for v := start; v <= end; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// Next returns the first time after t the schedule matches, or the zero
+// time if it never does within five years.
+func (s Spec) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (s Spec) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
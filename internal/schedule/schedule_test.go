@@ -0,0 +1,102 @@
+package schedule
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	for _, expr := range []string{"* * * * *", "0 9 * * 1", "*/15 8-18 * * 1-5", "0 0 1,15 * *", "@daily", "0 0 * * 7"} {
+		_, err := Parse(expr)
+		require.NoError(t, err, expr)
+	}
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "*/0 * * * *", "5-1 * * * *", "@often"} {
+		_, err := Parse(expr)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestNext(t *testing.T) {
+	t.Parallel()
+
+	// A Wednesday.
+	from := time.Date(2026, 10, 14, 10, 30, 15, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 10, 14, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 10, 14, 10, 45, 0, 0, time.UTC)},
+		{"0 9 * * 1", time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 29 2 *", time.Date(2028, 2, 29, 12, 0, 0, 0, time.UTC)},
+		// Either day matches when both are restricted.
+		{"0 0 20 * 5", time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		spec, err := Parse(tt.expr)
+		require.NoError(t, err)
+		require.Equal(t, tt.want, spec.Next(from), tt.expr)
+	}
+
+	spec, err := Parse("0 0 31 2 *")
+	require.NoError(t, err)
+	require.True(t, spec.Next(from).IsZero())
+}
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	store := NewStore(filepath.Join(t.TempDir(), "data", "schedules.json"))
+	tasks, err := store.List()
+	require.NoError(t, err)
+	require.Empty(t, tasks)
+
+	_, err = store.Add("not a schedule", "hi")
+	require.Error(t, err)
+	_, err = store.Add("@daily", " ")
+	require.EqualError(t, err, "no prompt provided")
+
+	first, err := store.Add("@daily", "summarize the changes")
+	require.NoError(t, err)
+	second, err := store.Add("0 9 * * 1", "review the TODOs")
+	require.NoError(t, err)
+
+	at := time.Now().Truncate(time.Second)
+	require.NoError(t, store.recordRun(first.ID, at, "session", nil))
+	tasks, err = store.List()
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	require.True(t, at.Equal(tasks[0].LastRun))
+	require.Equal(t, "session", tasks[0].LastSessionID)
+
+	require.NoError(t, store.Remove(first.ID))
+	require.Error(t, store.Remove(first.ID))
+	tasks, err = store.List()
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	require.Equal(t, second.ID, tasks[0].ID)
+}
+
+func TestDue(t *testing.T) {
+	t.Parallel()
+
+	since := time.Date(2026, 10, 14, 8, 59, 30, 0, time.Local)
+	tasks := []Task{
+		{ID: "nine", Cron: "0 9 * * *"},
+		{ID: "ten", Cron: "0 10 * * *"},
+		{ID: "ran", Cron: "0 9 * * *", LastRun: since.Add(time.Minute)},
+		{ID: "broken", Cron: "nope"},
+	}
+	require.Equal(t, time.Date(2026, 10, 14, 9, 0, 0, 0, time.Local), nextRun(tasks, since))
+
+	got := due(tasks, since, since.Add(time.Minute))
+	require.Len(t, got, 1)
+	require.Equal(t, "nine", got[0].ID)
+}
@@ -0,0 +1,99 @@
+package schedule
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// recheckInterval bounds how long Serve waits before reloading the tasks,
+// so tasks added or removed meanwhile are picked up.
+const recheckInterval = time.Minute
+
+// RunFunc runs a task and returns the ID of the session it ran in.
+type RunFunc func(ctx context.Context, task Task) (sessionID string, err error)
+
+// Serve runs the tasks in store as they come due, one at a time, until ctx
+// is done. Runs that were due while nothing was serving are skipped.
+func Serve(ctx context.Context, store *Store, run RunFunc) error {
+	since := time.Now()
+	for {
+		tasks, err := store.List()
+		if err != nil {
+			return err
+		}
+
+		wait := recheckInterval
+		if next := nextRun(tasks, since); !next.IsZero() {
+			wait = min(wait, time.Until(next))
+		}
+		timer := time.NewTimer(max(0, wait))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		now := time.Now()
+		tasks, err = store.List()
+		if err != nil {
+			return err
+		}
+		for _, task := range due(tasks, since, now) {
+			slog.Info("Running scheduled task", "id", task.ID, "cron", task.Cron)
+			sessionID, runErr := run(ctx, task)
+			if ctx.Err() != nil {
+				return nil
+			}
+			if runErr != nil {
+				slog.Error("Scheduled task failed", "id", task.ID, "error", runErr)
+			}
+			if err := store.recordRun(task.ID, now, sessionID, runErr); err != nil {
+				return err
+			}
+		}
+		since = now
+	}
+}
+
+// lastChecked is the time after which the next run of task is looked for.
+func lastChecked(task Task, since time.Time) time.Time {
+	if task.LastRun.After(since) {
+		return task.LastRun
+	}
+	return since
+}
+
+// nextRun returns the earliest time after since one of the tasks is due, or
+// the zero time if none is.
+func nextRun(tasks []Task, since time.Time) time.Time {
+	var next time.Time
+	for _, task := range tasks {
+		spec, err := Parse(task.Cron)
+		if err != nil {
+			continue
+		}
+		t := spec.Next(lastChecked(task, since))
+		if !t.IsZero() && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+	return next
+}
+
+// due returns the tasks that came due after since and no later than now.
+func due(tasks []Task, since, now time.Time) []Task {
+	var result []Task
+	for _, task := range tasks {
+		spec, err := Parse(task.Cron)
+		if err != nil {
+			slog.Warn("Skipping scheduled task with an invalid schedule", "id", task.ID, "error", err)
+			continue
+		}
+		if t := spec.Next(lastChecked(task, since)); !t.IsZero() && !t.After(now) {
+			result = append(result, task)
+		}
+	}
+	return result
+}
@@ -0,0 +1,150 @@
+// Package schedule runs agent tasks on cron-like schedules. Tasks are kept
+// per project, and run by `crush schedule serve` as they come due.
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/google/uuid"
+)
+
+// Task is a prompt run on a schedule.
+type Task struct {
+	ID        string    `json:"id"`
+	Cron      string    `json:"cron"`
+	Prompt    string    `json:"prompt"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// LastRun is when the task last ran, and LastSessionID the session it
+	// ran in.
+	LastRun       time.Time `json:"last_run,omitzero"`
+	LastSessionID string    `json:"last_session_id,omitempty"`
+	LastError     string    `json:"last_error,omitempty"`
+}
+
+// Store keeps tasks in a JSON file.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+type storeFile struct {
+	Tasks []Task `json:"tasks"`
+}
+
+// NewStore returns a store kept in the file at path.
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// ProjectStore returns the store in the project data directory.
+func ProjectStore(dataDir string) *Store {
+	return NewStore(filepath.Join(dataDir, "schedules.json"))
+}
+
+// List returns the tasks, oldest first.
+func (s *Store) List() ([]Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Add adds a task running prompt on the cron schedule.
+func (s *Store) Add(cron, prompt string) (Task, error) {
+	if _, err := Parse(cron); err != nil {
+		return Task{}, err
+	}
+	prompt = strings.TrimSpace(prompt)
+	if prompt == "" {
+		return Task{}, errors.New("no prompt provided")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tasks, err := s.load()
+	if err != nil {
+		return Task{}, err
+	}
+	task := Task{
+		ID:        uuid.NewString()[:8],
+		Cron:      strings.TrimSpace(cron),
+		Prompt:    prompt,
+		CreatedAt: time.Now(),
+	}
+	return task, s.save(append(tasks, task))
+}
+
+// Remove removes the task with the given ID.
+func (s *Store) Remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tasks, err := s.load()
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(tasks, func(t Task) bool { return t.ID == id })
+	if i < 0 {
+		return fmt.Errorf("no scheduled task %q", id)
+	}
+	return s.save(slices.Delete(tasks, i, i+1))
+}
+
+// recordRun stores the outcome of a run of the task with the given ID.
+func (s *Store) recordRun(id string, at time.Time, sessionID string, runErr error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tasks, err := s.load()
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(tasks, func(t Task) bool { return t.ID == id })
+	if i < 0 {
+		// Removed while running.
+		return nil
+	}
+	tasks[i].LastRun = at
+	tasks[i].LastSessionID = sessionID
+	tasks[i].LastError = ""
+	if runErr != nil {
+		tasks[i].LastError = runErr.Error()
+	}
+	return s.save(tasks)
+}
+
+func (s *Store) load() ([]Task, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scheduled tasks: %w", err)
+	}
+	var file storeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", s.path, err)
+	}
+	return file.Tasks, nil
+}
+
+func (s *Store) save(tasks []Task) error {
+	data, err := json.MarshalIndent(storeFile{Tasks: tasks}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to save scheduled tasks: %w", err)
+	}
+	if err := fsext.WriteFileAtomic(s.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save scheduled tasks: %w", err)
+	}
+	return nil
+}
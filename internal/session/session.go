@@ -3,8 +3,12 @@ package session
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"log/slog"
+	"slices"
 	"strings"
+	"unicode"
 
 	"github.com/charmbracelet/crush/internal/db"
 	"github.com/charmbracelet/crush/internal/event"
@@ -23,6 +27,29 @@ type Session struct {
 	Cost             float64
 	CreatedAt        int64
 	UpdatedAt        int64
+	Todos            []Todo
+	Tags             []string
+
+	// InputTokens are all the input tokens sent during the session, of which
+	// CacheReadTokens were read from the provider's prompt cache and
+	// CacheCreationTokens were written to it.
+	InputTokens         int64
+	CacheReadTokens     int64
+	CacheCreationTokens int64
+}
+
+type TodoStatus string
+
+const (
+	TodoStatusPending    TodoStatus = "pending"
+	TodoStatusInProgress TodoStatus = "in_progress"
+	TodoStatusCompleted  TodoStatus = "completed"
+)
+
+// Todo is an item of the task list the agent keeps for a session.
+type Todo struct {
+	Content string     `json:"content"`
+	Status  TodoStatus `json:"status"`
 }
 
 type Service interface {
@@ -33,6 +60,8 @@ type Service interface {
 	Get(ctx context.Context, id string) (Session, error)
 	List(ctx context.Context) ([]Session, error)
 	Save(ctx context.Context, session Session) (Session, error)
+	SetTodos(ctx context.Context, id string, todos []Todo) (Session, error)
+	SetTags(ctx context.Context, id string, tags []string) (Session, error)
 	Delete(ctx context.Context, id string) error
 
 	// Agent tool session management
@@ -120,7 +149,10 @@ func (s *service) Save(ctx context.Context, session Session) (Session, error) {
 			String: session.SummaryMessageID,
 			Valid:  session.SummaryMessageID != "",
 		},
-		Cost: session.Cost,
+		Cost:                session.Cost,
+		InputTokens:         session.InputTokens,
+		CacheReadTokens:     session.CacheReadTokens,
+		CacheCreationTokens: session.CacheCreationTokens,
 	})
 	if err != nil {
 		return Session{}, err
@@ -130,6 +162,75 @@ func (s *service) Save(ctx context.Context, session Session) (Session, error) {
 	return session, nil
 }
 
+// SetTodos replaces the task list of a session. It is kept apart from
+// [Service.Save] so updating the usage of a session doesn't overwrite it.
+func (s *service) SetTodos(ctx context.Context, id string, todos []Todo) (Session, error) {
+	var data sql.NullString
+	if len(todos) > 0 {
+		encoded, err := json.Marshal(todos)
+		if err != nil {
+			return Session{}, err
+		}
+		data = sql.NullString{String: string(encoded), Valid: true}
+	}
+	dbSession, err := s.q.UpdateSessionTodos(ctx, db.UpdateSessionTodosParams{
+		ID:    id,
+		Todos: data,
+	})
+	if err != nil {
+		return Session{}, err
+	}
+	session := s.fromDBItem(dbSession)
+	s.Publish(pubsub.UpdatedEvent, session)
+	return session, nil
+}
+
+// SetTags replaces the tags of a session with the normalized tags, as
+// [ParseTags] returns them.
+func (s *service) SetTags(ctx context.Context, id string, tags []string) (Session, error) {
+	tags = ParseTags(strings.Join(tags, " "))
+	var data sql.NullString
+	if len(tags) > 0 {
+		encoded, err := json.Marshal(tags)
+		if err != nil {
+			return Session{}, err
+		}
+		data = sql.NullString{String: string(encoded), Valid: true}
+	}
+	dbSession, err := s.q.UpdateSessionTags(ctx, db.UpdateSessionTagsParams{
+		ID:   id,
+		Tags: data,
+	})
+	if err != nil {
+		return Session{}, err
+	}
+	session := s.fromDBItem(dbSession)
+	s.Publish(pubsub.UpdatedEvent, session)
+	return session, nil
+}
+
+// ParseTags splits text into tags at commas and spaces. Tags are lowercased
+// without a leading #, and listed once, in the order they first appear.
+func ParseTags(text string) []string {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	var tags []string
+	for _, field := range fields {
+		tag := strings.ToLower(strings.TrimLeft(field, "#"))
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// HasTag reports whether the session is tagged with tag, in any case and
+// with or without a leading #.
+func (s Session) HasTag(tag string) bool {
+	return slices.Contains(s.Tags, strings.ToLower(strings.TrimLeft(tag, "#")))
+}
+
 func (s *service) List(ctx context.Context) ([]Session, error) {
 	dbSessions, err := s.q.ListSessions(ctx)
 	if err != nil {
@@ -143,17 +244,34 @@ func (s *service) List(ctx context.Context) ([]Session, error) {
 }
 
 func (s service) fromDBItem(item db.Session) Session {
+	var todos []Todo
+	if item.Todos.Valid {
+		if err := json.Unmarshal([]byte(item.Todos.String), &todos); err != nil {
+			slog.Error("Failed to decode session todos", "session_id", item.ID, "error", err)
+		}
+	}
+	var tags []string
+	if item.Tags.Valid {
+		if err := json.Unmarshal([]byte(item.Tags.String), &tags); err != nil {
+			slog.Error("Failed to decode session tags", "session_id", item.ID, "error", err)
+		}
+	}
 	return Session{
-		ID:               item.ID,
-		ParentSessionID:  item.ParentSessionID.String,
-		Title:            item.Title,
-		MessageCount:     item.MessageCount,
-		PromptTokens:     item.PromptTokens,
-		CompletionTokens: item.CompletionTokens,
-		SummaryMessageID: item.SummaryMessageID.String,
-		Cost:             item.Cost,
-		CreatedAt:        item.CreatedAt,
-		UpdatedAt:        item.UpdatedAt,
+		ID:                  item.ID,
+		ParentSessionID:     item.ParentSessionID.String,
+		Title:               item.Title,
+		MessageCount:        item.MessageCount,
+		PromptTokens:        item.PromptTokens,
+		CompletionTokens:    item.CompletionTokens,
+		SummaryMessageID:    item.SummaryMessageID.String,
+		Cost:                item.Cost,
+		CreatedAt:           item.CreatedAt,
+		UpdatedAt:           item.UpdatedAt,
+		Todos:               todos,
+		Tags:                tags,
+		InputTokens:         item.InputTokens,
+		CacheReadTokens:     item.CacheReadTokens,
+		CacheCreationTokens: item.CacheCreationTokens,
 	}
 }
 
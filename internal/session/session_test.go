@@ -0,0 +1,45 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTags(t *testing.T) {
+	t.Parallel()
+
+	require.Nil(t, ParseTags(" , "))
+	require.Equal(t, []string{"refactor", "db", "bug-fix"}, ParseTags("#Refactor, db  bug-fix,refactor\tDB"))
+}
+
+func TestSetTags(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	sessions := NewService(db.New(conn))
+
+	s, err := sessions.Create(t.Context(), "test")
+	require.NoError(t, err)
+	require.Empty(t, s.Tags)
+
+	_, err = sessions.SetTags(t.Context(), s.ID, []string{"Refactor", "#db"})
+	require.NoError(t, err)
+	s, err = sessions.Get(t.Context(), s.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"refactor", "db"}, s.Tags)
+	require.True(t, s.HasTag("#DB"))
+	require.False(t, s.HasTag("bug"))
+
+	s.Title = "renamed"
+	s, err = sessions.Save(t.Context(), s)
+	require.NoError(t, err)
+	require.Equal(t, []string{"refactor", "db"}, s.Tags, "saving the session keeps its tags")
+
+	s, err = sessions.SetTags(t.Context(), s.ID, nil)
+	require.NoError(t, err)
+	require.Empty(t, s.Tags)
+}
@@ -0,0 +1,251 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf16"
+
+	"mvdan.cc/sh/v3/interp"
+)
+
+// ParseShellType returns the shell type of a name from the configuration:
+// "posix" or empty for the built-in POSIX interpreter, "powershell" or
+// "cmd".
+func ParseShellType(name string) (ShellType, error) {
+	switch strings.ToLower(name) {
+	case "", "posix":
+		return ShellTypePOSIX, nil
+	case "powershell", "pwsh":
+		return ShellTypePowerShell, nil
+	case "cmd":
+		return ShellTypeCmd, nil
+	}
+	return ShellTypePOSIX, fmt.Errorf("unknown shell %q: use posix, powershell or cmd", name)
+}
+
+// String returns the name of the shell type.
+func (t ShellType) String() string {
+	switch t {
+	case ShellTypePowerShell:
+		return "powershell"
+	case ShellTypeCmd:
+		return "cmd"
+	case ShellTypeSSH:
+		return "ssh"
+	case ShellTypeContainer:
+		return "container"
+	}
+	return "posix"
+}
+
+// nativeState holds the files a native shell writes its state to once the
+// command finishes.
+type nativeState struct {
+	cwd, env, status string
+}
+
+// execNative runs command in the PowerShell or cmd.exe of the system. Like
+// with the POSIX interpreter, the working directory and environment the
+// command leaves are kept for the next command of the shell.
+func (s *Shell) execNative(ctx context.Context, command string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if blocked := s.blockedCommand(command); blocked != "" {
+		return fmt.Errorf("command is not allowed for security reasons: %s", blocked)
+	}
+
+	dir, err := os.MkdirTemp("", "crush-shell-*")
+	if err != nil {
+		return fmt.Errorf("could not run command: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	state := nativeState{
+		cwd:    filepath.Join(dir, "cwd"),
+		env:    filepath.Join(dir, "env"),
+		status: filepath.Join(dir, "status"),
+	}
+
+	var cmd *exec.Cmd
+	switch s.shellType {
+	case ShellTypePowerShell:
+		cmd = exec.CommandContext(ctx, powerShellPath(),
+			"-NoLogo", "-NoProfile", "-NonInteractive",
+			"-EncodedCommand", encodePowerShell(powerShellScript(command, state)),
+		)
+	case ShellTypeCmd:
+		cmd = cmdCommand(ctx, cmdLine(command, state))
+	}
+	cmd.Dir = s.cwd
+	cmd.Env = s.env
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// Don't wait for background processes holding on to the output.
+	cmd.WaitDelay = time.Second
+
+	err = cmd.Run()
+	s.loadNativeState(state)
+	s.logger.InfoPersist("command finished", "command", command, "shell", s.shellType, "err", err)
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return interp.ExitStatus(exitErr.ExitCode())
+	}
+	if err != nil {
+		return fmt.Errorf("could not run command: %w", err)
+	}
+	if data, readErr := os.ReadFile(state.status); readErr == nil {
+		if status, _ := strconv.Atoi(strings.TrimSpace(string(data))); status != 0 {
+			return interp.ExitStatus(status)
+		}
+	}
+	return nil
+}
+
+// loadNativeState reads the working directory and environment a native
+// shell left. Missing files, like after an exit in the command, keep the
+// state as it was.
+func (s *Shell) loadNativeState(state nativeState) {
+	if data, err := os.ReadFile(state.cwd); err == nil {
+		if cwd := strings.TrimSpace(trimBOM(string(data))); cwd != "" {
+			s.cwd = cwd
+		}
+	}
+	data, err := os.ReadFile(state.env)
+	if err != nil {
+		return
+	}
+	sep := "\n"
+	if s.shellType == ShellTypePowerShell {
+		sep = "\x00"
+	}
+	var env []string
+	for entry := range strings.SplitSeq(trimBOM(string(data)), sep) {
+		entry = strings.TrimSuffix(entry, "\r")
+		// cmd lists hidden variables like =C: that can't be set.
+		if entry == "" || entry[0] == '=' || strings.HasPrefix(entry, "__CRUSH_") {
+			continue
+		}
+		env = append(env, entry)
+	}
+	if len(env) > 0 {
+		s.env = env
+	}
+}
+
+// blockedCommand returns the first command of a native shell command line
+// that a block function rejects. Native shells can't be hooked like the
+// POSIX interpreter, so commands are split on the usual separators.
+func (s *Shell) blockedCommand(command string) string {
+	if len(s.blockFuncs) == 0 {
+		return ""
+	}
+	segments := strings.FieldsFunc(command, func(r rune) bool {
+		return strings.ContainsRune(";|&\n(){}", r)
+	})
+	for _, segment := range segments {
+		args := strings.Fields(segment)
+		for i, arg := range args {
+			args[i] = strings.Trim(arg, `"'`)
+		}
+		if len(args) > 0 {
+			// Match "git.exe" like "git".
+			args[0] = strings.TrimSuffix(strings.ToLower(args[0]), ".exe")
+		}
+		for _, blockFunc := range s.blockFuncs {
+			if blockFunc(args) {
+				return strings.TrimSpace(segment)
+			}
+		}
+	}
+	return ""
+}
+
+// powerShellPath prefers PowerShell 7 to Windows PowerShell.
+func powerShellPath() string {
+	if path, err := exec.LookPath("pwsh"); err == nil {
+		return path
+	}
+	return "powershell"
+}
+
+// powerShellScript wraps command to use UTF-8 output and save the working
+// directory and environment once it finishes, even when it exits early.
+// The exit code is the one of the last native command, or 1 when the last
+// statement failed.
+func powerShellScript(command string, state nativeState) string {
+	var b strings.Builder
+	b.WriteString("[Console]::OutputEncoding = [Text.Encoding]::UTF8\n")
+	b.WriteString("$OutputEncoding = [Text.Encoding]::UTF8\n")
+	b.WriteString("$global:LASTEXITCODE = 0\n")
+	b.WriteString("$__crushOk = $true\n")
+	b.WriteString("try {\n")
+	b.WriteString(command)
+	b.WriteString("\n$__crushOk = $?\n")
+	b.WriteString("} finally {\n")
+	fmt.Fprintf(&b, "[IO.File]::WriteAllText(%s, (Get-Location).ProviderPath)\n", quotePowerShell(state.cwd))
+	fmt.Fprintf(&b, "[IO.File]::WriteAllText(%s, ((Get-ChildItem Env:) | ForEach-Object { $_.Name + '=' + $_.Value }) -join \"`0\")\n", quotePowerShell(state.env))
+	b.WriteString("}\n")
+	b.WriteString("if ($LASTEXITCODE) { exit $LASTEXITCODE }\n")
+	b.WriteString("if (-not $__crushOk) { exit 1 }\n")
+	return b.String()
+}
+
+// Quote quotes s as a word of the commands of the shells of type t, when it
+// needs quoting.
+func Quote(t ShellType, s string) string {
+	if s != "" && !strings.ContainsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("_-./:=@%+,", r)
+	}) {
+		return s
+	}
+	switch t {
+	case ShellTypePowerShell:
+		return quotePowerShell(s)
+	case ShellTypeCmd:
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	default:
+		return quotePOSIX(s)
+	}
+}
+
+// quotePowerShell quotes s as a literal PowerShell string.
+func quotePowerShell(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// encodePowerShell encodes a script for -EncodedCommand, which takes it
+// as is, without any quoting.
+func encodePowerShell(script string) string {
+	var buf bytes.Buffer
+	for _, r := range utf16.Encode([]rune(script)) {
+		buf.WriteByte(byte(r))
+		buf.WriteByte(byte(r >> 8))
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+// cmdLine appends to command the saving of its exit code, working directory
+// and environment. CALL expands %ERRORLEVEL% after command runs rather than
+// when the line is read.
+func cmdLine(command string, state nativeState) string {
+	return fmt.Sprintf(`chcp 65001 >nul & %s & (call echo %%^ERRORLEVEL%%)>"%s" & cd>"%s" & set>"%s"`,
+		command, state.status, state.cwd, state.env)
+}
+
+// trimBOM removes the byte order mark Windows PowerShell writes.
+func trimBOM(s string) string {
+	return strings.TrimPrefix(s, "\ufeff")
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package shell
+
+import (
+	"context"
+	"os/exec"
+)
+
+// cmdCommand runs line with cmd.exe, which is only found on Windows.
+func cmdCommand(ctx context.Context, line string) *exec.Cmd {
+	return exec.CommandContext(ctx, "cmd.exe", "/d", "/s", "/c", line)
+}
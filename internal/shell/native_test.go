@@ -0,0 +1,103 @@
+package shell
+
+import (
+	"encoding/base64"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseShellType(t *testing.T) {
+	t.Parallel()
+
+	for name, want := range map[string]ShellType{
+		"":           ShellTypePOSIX,
+		"posix":      ShellTypePOSIX,
+		"PowerShell": ShellTypePowerShell,
+		"pwsh":       ShellTypePowerShell,
+		"cmd":        ShellTypeCmd,
+	} {
+		got, err := ParseShellType(name)
+		require.NoError(t, err, name)
+		require.Equal(t, want, got, name)
+	}
+	_, err := ParseShellType("zsh")
+	require.Error(t, err)
+}
+
+func TestNativeBlockedCommand(t *testing.T) {
+	t.Parallel()
+
+	sh := NewShell(&Options{
+		Type: ShellTypePowerShell,
+		BlockFuncs: []BlockFunc{
+			CommandsBlocker([]string{"curl"}),
+			ArgumentsBlocker("npm", []string{"install"}, []string{"-g"}),
+		},
+	})
+	require.Empty(t, sh.blockedCommand("Get-ChildItem | Select-String foo"))
+	require.Equal(t, "curl.exe https://example.com", sh.blockedCommand("cd src; curl.exe https://example.com"))
+	require.Equal(t, "npm install -g left-pad", sh.blockedCommand("echo hi && npm install -g left-pad"))
+
+	_, _, err := sh.Exec(t.Context(), "curl example.com")
+	require.ErrorContains(t, err, "not allowed for security reasons")
+}
+
+func TestPowerShellScript(t *testing.T) {
+	t.Parallel()
+
+	state := nativeState{cwd: `C:\Temp\it's\cwd`, env: `C:\Temp\env`}
+	script := powerShellScript(`Write-Output "a 'quoted' $value"`, state)
+	require.Contains(t, script, "try {\nWrite-Output \"a 'quoted' $value\"\n")
+	require.Contains(t, script, `'C:\Temp\it''s\cwd'`)
+
+	data, err := base64.StdEncoding.DecodeString(encodePowerShell("é ok"))
+	require.NoError(t, err)
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = uint16(data[2*i]) | uint16(data[2*i+1])<<8
+	}
+	require.Equal(t, "é ok", string(utf16.Decode(units)))
+}
+
+func TestLoadNativeState(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	state := nativeState{cwd: filepath.Join(dir, "cwd"), env: filepath.Join(dir, "env")}
+	require.NoError(t, os.WriteFile(state.cwd, []byte("\ufeffC:\\project\r\n"), 0o644))
+	require.NoError(t, os.WriteFile(state.env, []byte("=C:=C:\\project\r\nPATH=C:\\bin\r\n__CRUSH_EXIT=0\r\nFOO=a=b\r\n"), 0o644))
+
+	sh := NewShell(&Options{Type: ShellTypeCmd, Env: []string{"OLD=1"}})
+	sh.loadNativeState(state)
+	require.Equal(t, `C:\project`, sh.GetWorkingDir())
+	require.Equal(t, []string{`PATH=C:\bin`, "FOO=a=b"}, sh.GetEnv())
+
+	// Nothing written, like after an exit in the command.
+	sh.loadNativeState(nativeState{cwd: filepath.Join(dir, "missing"), env: filepath.Join(dir, "missing")})
+	require.Equal(t, `C:\project`, sh.GetWorkingDir())
+}
+
+func TestPowerShellExec(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("pwsh"); err != nil {
+		t.Skip("PowerShell isn't installed")
+	}
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub dir"), 0o755))
+
+	sh := NewShell(&Options{WorkingDir: dir, Type: ShellTypePowerShell})
+	stdout, _, err := sh.Exec(t.Context(), `Set-Location 'sub dir'; $env:CRUSH_TEST = "it's set"; Write-Output 'done'`)
+	require.NoError(t, err)
+	require.Equal(t, "done\n", stdout)
+	require.Equal(t, filepath.Join(dir, "sub dir"), sh.GetWorkingDir())
+
+	stdout, _, err = sh.Exec(t.Context(), `Write-Output $env:CRUSH_TEST; exit 3`)
+	require.Equal(t, "it's set\n", stdout)
+	require.Equal(t, 3, ExitCode(err))
+}
@@ -0,0 +1,20 @@
+//go:build windows
+
+package shell
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+)
+
+// cmdCommand runs line with cmd.exe. The command line is passed as is:
+// quoting it like the arguments of other programs would break the quotes
+// inside it.
+func cmdCommand(ctx context.Context, line string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "cmd.exe")
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CmdLine: `cmd.exe /d /s /c "` + line + `"`,
+	}
+	return cmd
+}
@@ -0,0 +1,203 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+const (
+	// sshFailureStatus is the exit status of ssh when it fails itself,
+	// rather than the remote command.
+	sshFailureStatus = 255
+	// dockerFailureStatus is the exit status of docker exec when it fails
+	// itself.
+	dockerFailureStatus = 125
+)
+
+// Remote is the host or container the commands of ShellTypeSSH and
+// ShellTypeContainer shells run on. Dir, the project directory there, is
+// mounted on the local MountDir, so that a local working directory under
+// MountDir is the same directory of Dir.
+type Remote struct {
+	// Host is the destination given to ssh, like user@host.
+	Host string
+	// Container is the ID of the container commands run in with docker
+	// exec, instead of over ssh.
+	Container string
+	// User is the user commands run as in the container.
+	User     string
+	Dir      string
+	MountDir string
+}
+
+var currentRemote atomic.Pointer[Remote]
+
+// SetRemote makes the shells of ShellTypeSSH run their commands on r.
+func SetRemote(r *Remote) {
+	currentRemote.Store(r)
+}
+
+// CurrentRemote returns the host set with SetRemote, or nil when commands
+// run locally.
+func CurrentRemote() *Remote {
+	return currentRemote.Load()
+}
+
+// RemotePath returns the directory on the host of the local path, which is
+// Dir for paths out of MountDir.
+func (r *Remote) RemotePath(local string) string {
+	rel, err := filepath.Rel(r.MountDir, local)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return r.Dir
+	}
+	return path.Join(r.Dir, filepath.ToSlash(rel))
+}
+
+// ContainerCommand returns the docker command line that runs argv in the
+// container, in the directory of the local dir, with env added to its
+// environment.
+func (r *Remote) ContainerCommand(dir string, env map[string]string, argv ...string) []string {
+	args := []string{"docker", "exec", "-i", "-w", r.RemotePath(dir)}
+	if r.User != "" {
+		args = append(args, "-u", r.User)
+	}
+	for _, name := range slices.Sorted(maps.Keys(env)) {
+		args = append(args, "-e", name+"="+env[name])
+	}
+	args = append(args, r.Container)
+	return append(args, argv...)
+}
+
+// execRemote runs command with sh on the remote host or in the container,
+// in the directory of the working directory of the shell. The environment
+// of the shell is the one there, and the working directory the command
+// leaves isn't kept.
+func (s *Shell) execRemote(ctx context.Context, command string, stdin io.Reader, stdout, stderr io.Writer) error {
+	r := CurrentRemote()
+	if r == nil {
+		return errors.New("could not run command: no remote host or container")
+	}
+	if blocked, err := s.blockedRemoteCommand(command); err != nil {
+		return fmt.Errorf("could not parse command: %w", err)
+	} else if blocked != "" {
+		return fmt.Errorf("command is not allowed for security reasons: %s", blocked)
+	}
+
+	name, failureStatus := r.Host, sshFailureStatus
+	var cmd *exec.Cmd
+	if s.shellType == ShellTypeContainer {
+		name, failureStatus = r.Container, dockerFailureStatus
+		args := r.ContainerCommand(s.cwd, nil, "sh", "-c", command)
+		cmd = exec.CommandContext(ctx, args[0], args[1:]...)
+	} else {
+		cmd = exec.CommandContext(ctx, "ssh", "-T", "-o", "BatchMode=yes", r.Host,
+			remoteCommandLine(r.RemotePath(s.cwd), command),
+		)
+	}
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// Don't wait for background processes holding on to the output.
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	s.logger.InfoPersist("command finished", "command", command, "shell", s.shellType, "host", name, "err", err)
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if exitErr.ExitCode() == failureStatus {
+			return fmt.Errorf("could not run command on %s: %s failed", name, cmd.Args[0])
+		}
+		return interp.ExitStatus(exitErr.ExitCode())
+	}
+	if err != nil {
+		return fmt.Errorf("could not run command: %w", err)
+	}
+	return nil
+}
+
+// remoteCommandLine returns the command line ssh runs on the host: command
+// run by sh in dir. ssh hands it to the login shell of the user, so command
+// is quoted as a single argument of sh.
+func remoteCommandLine(dir, command string) string {
+	script := "cd " + quotePOSIX(dir) + " || exit 1\n" + command
+	return "sh -c " + quotePOSIX(script)
+}
+
+// quotePOSIX quotes s as a literal POSIX shell word.
+func quotePOSIX(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// blockedRemoteCommand returns the first simple command of a POSIX command
+// line that a block function rejects. Words that need expanding are matched
+// as written.
+func (s *Shell) blockedRemoteCommand(command string) (string, error) {
+	if len(s.blockFuncs) == 0 {
+		return "", nil
+	}
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil {
+		return "", err
+	}
+	var blocked string
+	syntax.Walk(file, func(node syntax.Node) bool {
+		call, ok := node.(*syntax.CallExpr)
+		if !ok || blocked != "" || len(call.Args) == 0 {
+			return blocked == ""
+		}
+		args := make([]string, len(call.Args))
+		for i, word := range call.Args {
+			args[i] = wordString(word)
+		}
+		for _, blockFunc := range s.blockFuncs {
+			if blockFunc(args) {
+				blocked = strings.Join(args, " ")
+				return false
+			}
+		}
+		return true
+	})
+	return blocked, nil
+}
+
+// wordString returns the literal value of word, without its quotes when it
+// has no expansions.
+func wordString(word *syntax.Word) string {
+	var b strings.Builder
+	for _, part := range word.Parts {
+		switch part := part.(type) {
+		case *syntax.Lit:
+			b.WriteString(part.Value)
+		case *syntax.SglQuoted:
+			b.WriteString(part.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range part.Parts {
+				if lit, ok := inner.(*syntax.Lit); ok {
+					b.WriteString(lit.Value)
+				} else {
+					syntax.NewPrinter().Print(&b, inner)
+				}
+			}
+		default:
+			syntax.NewPrinter().Print(&b, part)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,80 @@
+package shell
+
+import (
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemotePath(t *testing.T) {
+	t.Parallel()
+
+	mount := filepath.Join(t.TempDir(), "mount")
+	r := &Remote{Host: "devbox", Dir: "/srv/project", MountDir: mount}
+	require.Equal(t, "/srv/project", r.RemotePath(mount))
+	require.Equal(t, "/srv/project/cmd/app", r.RemotePath(filepath.Join(mount, "cmd", "app")))
+	require.Equal(t, "/srv/project", r.RemotePath(filepath.Join(mount+"-other", "cmd")))
+	require.Equal(t, "/srv/project", r.RemotePath(filepath.Dir(mount)))
+}
+
+func TestRemoteCommandLine(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+
+	dir := t.TempDir()
+	line := remoteCommandLine(dir, `pwd; echo "it's $((1 + 2))"`)
+	// ssh hands the line to the login shell of the user on the host.
+	out, err := exec.Command("sh", "-c", line).Output()
+	require.NoError(t, err)
+	wd, err := filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+	require.Equal(t, wd+"\nit's 3\n", string(out))
+
+	err = exec.Command("sh", "-c", remoteCommandLine(filepath.Join(dir, "missing"), "echo never")).Run()
+	require.Error(t, err)
+}
+
+func TestBlockedRemoteCommand(t *testing.T) {
+	t.Parallel()
+
+	sh := NewShell(&Options{
+		Type: ShellTypeSSH,
+		BlockFuncs: []BlockFunc{
+			CommandsBlocker([]string{"curl"}),
+			ArgumentsBlocker("npm", []string{"install"}, []string{"-g"}),
+		},
+	})
+	for command, want := range map[string]string{
+		"ls -la && git status":           "",
+		"cd src; 'curl' example.com":     "curl example.com",
+		`(echo hi | npm install -g "x")`: "npm install -g x",
+		"echo curl":                      "",
+	} {
+		blocked, err := sh.blockedRemoteCommand(command)
+		require.NoError(t, err, command)
+		require.Equal(t, want, blocked, command)
+	}
+	_, err := sh.blockedRemoteCommand("echo (")
+	require.Error(t, err)
+}
+
+func TestContainerCommand(t *testing.T) {
+	t.Parallel()
+
+	project := filepath.Join(t.TempDir(), "app")
+	r := &Remote{Container: "abc123", User: "vscode", Dir: "/workspaces/app", MountDir: project}
+	require.Equal(t, []string{
+		"docker", "exec", "-i", "-w", "/workspaces/app/pkg", "-u", "vscode",
+		"-e", "A=1", "-e", "B=2",
+		"abc123", "gopls", "serve",
+	}, r.ContainerCommand(filepath.Join(project, "pkg"), map[string]string{"B": "2", "A": "1"}, "gopls", "serve"))
+
+	r.User = ""
+	require.Equal(t, []string{"docker", "exec", "-i", "-w", "/workspaces/app", "abc123", "sh", "-c", "ls"},
+		r.ContainerCommand(project, nil, "sh", "-c", "ls"))
+}
@@ -34,6 +34,11 @@ const (
 	ShellTypePOSIX ShellType = iota
 	ShellTypeCmd
 	ShellTypePowerShell
+	// ShellTypeSSH runs commands on the host set with SetRemote.
+	ShellTypeSSH
+	// ShellTypeContainer runs commands in the container set with
+	// SetRemote.
+	ShellTypeContainer
 )
 
 // Logger interface for optional logging
@@ -56,6 +61,7 @@ type Shell struct {
 	mu         sync.Mutex
 	logger     Logger
 	blockFuncs []BlockFunc
+	shellType  ShellType
 }
 
 // Options for creating a new shell
@@ -64,6 +70,9 @@ type Options struct {
 	Env        []string
 	Logger     Logger
 	BlockFuncs []BlockFunc
+	// Type is the shell commands run in. PowerShell and cmd run the ones
+	// of the system instead of the built-in POSIX interpreter.
+	Type ShellType
 }
 
 // NewShell creates a new shell instance with the given options
@@ -92,6 +101,7 @@ func NewShell(opts *Options) *Shell {
 		env:        env,
 		logger:     logger,
 		blockFuncs: opts.BlockFuncs,
+		shellType:  opts.Type,
 	}
 }
 
@@ -103,6 +113,16 @@ func (s *Shell) Exec(ctx context.Context, command string) (string, string, error
 	return s.exec(ctx, command)
 }
 
+// ExecInput executes a command in the shell with stdin read from input
+func (s *Shell) ExecInput(ctx context.Context, command string, input io.Reader) (string, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var stdout, stderr bytes.Buffer
+	err := s.execCommon(ctx, command, input, &stdout, &stderr)
+	return stdout.String(), stderr.String(), err
+}
+
 // ExecStream executes a command in the shell with streaming output to provided writers
 func (s *Shell) ExecStream(ctx context.Context, command string, stdout, stderr io.Writer) error {
 	s.mu.Lock()
@@ -237,9 +257,9 @@ func (s *Shell) blockHandler() func(next interp.ExecHandlerFunc) interp.ExecHand
 }
 
 // newInterp creates a new interpreter with the current shell state
-func (s *Shell) newInterp(stdout, stderr io.Writer) (*interp.Runner, error) {
+func (s *Shell) newInterp(stdin io.Reader, stdout, stderr io.Writer) (*interp.Runner, error) {
 	return interp.New(
-		interp.StdIO(nil, stdout, stderr),
+		interp.StdIO(stdin, stdout, stderr),
 		interp.Interactive(false),
 		interp.Env(expand.ListEnviron(s.env...)),
 		interp.Dir(s.cwd),
@@ -257,13 +277,20 @@ func (s *Shell) updateShellFromRunner(runner *interp.Runner) {
 }
 
 // execCommon is the shared implementation for executing commands
-func (s *Shell) execCommon(ctx context.Context, command string, stdout, stderr io.Writer) error {
+func (s *Shell) execCommon(ctx context.Context, command string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if s.shellType == ShellTypeSSH || s.shellType == ShellTypeContainer {
+		return s.execRemote(ctx, command, stdin, stdout, stderr)
+	}
+	if s.shellType != ShellTypePOSIX {
+		return s.execNative(ctx, command, stdin, stdout, stderr)
+	}
+
 	line, err := syntax.NewParser().Parse(strings.NewReader(command), "")
 	if err != nil {
 		return fmt.Errorf("could not parse command: %w", err)
 	}
 
-	runner, err := s.newInterp(stdout, stderr)
+	runner, err := s.newInterp(stdin, stdout, stderr)
 	if err != nil {
 		return fmt.Errorf("could not run command: %w", err)
 	}
@@ -277,13 +304,13 @@ func (s *Shell) execCommon(ctx context.Context, command string, stdout, stderr i
 // exec executes commands using a cross-platform shell interpreter.
 func (s *Shell) exec(ctx context.Context, command string) (string, string, error) {
 	var stdout, stderr bytes.Buffer
-	err := s.execCommon(ctx, command, &stdout, &stderr)
+	err := s.execCommon(ctx, command, nil, &stdout, &stderr)
 	return stdout.String(), stderr.String(), err
 }
 
 // execStream executes commands using POSIX shell emulation with streaming output
 func (s *Shell) execStream(ctx context.Context, command string, stdout, stderr io.Writer) error {
-	return s.execCommon(ctx, command, stdout, stderr)
+	return s.execCommon(ctx, command, nil, stdout, stderr)
 }
 
 func (s *Shell) execHandlers() []func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
@@ -0,0 +1,281 @@
+// Package telemetry exports traces and metrics of agent runs, provider calls
+// and tool calls to an OpenTelemetry collector over OTLP/HTTP.
+package telemetry
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/httpext"
+	"github.com/charmbracelet/crush/internal/version"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const (
+	scope = "github.com/charmbracelet/crush"
+	// exportTimeout is the timeout of the exports, the default of the
+	// exporters.
+	exportTimeout = 10 * time.Second
+)
+
+var (
+	tracer  trace.Tracer = noop.NewTracerProvider().Tracer(scope)
+	metrics *instruments
+)
+
+type instruments struct {
+	runDuration      metric.Float64Histogram
+	providerDuration metric.Float64Histogram
+	toolDuration     metric.Float64Histogram
+	tokens           metric.Int64Counter
+	cost             metric.Float64Counter
+}
+
+// Init starts exporting to the collector of cfg. It does nothing when no
+// endpoint is configured. The returned function flushes and stops the
+// export.
+func Init(ctx context.Context, cfg *config.Telemetry) (shutdown func(context.Context) error, err error) {
+	if cfg == nil || cfg.OTLPEndpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	endpoint := strings.TrimSuffix(cfg.OTLPEndpoint, "/")
+	headers := cfg.ResolvedHeaders()
+
+	traceOpts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpointURL(endpoint + "/v1/traces"),
+		otlptracehttp.WithHeaders(headers),
+	}
+	metricOpts := []otlpmetrichttp.Option{
+		otlpmetrichttp.WithEndpointURL(endpoint + "/v1/metrics"),
+		otlpmetrichttp.WithHeaders(headers),
+	}
+	if httpext.Configured() {
+		// Through the proxy and trusting the CA bundle of the configuration
+		// instead of the OTEL_EXPORTER_OTLP_* ones.
+		client := httpext.NewClient(exportTimeout)
+		traceOpts = append(traceOpts, otlptracehttp.WithHTTPClient(client))
+		metricOpts = append(metricOpts, otlpmetrichttp.WithHTTPClient(client))
+	}
+
+	traceExporter, err := otlptracehttp.New(ctx, traceOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+	metricExporter, err := otlpmetrichttp.New(ctx, metricOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metric exporter: %w", err)
+	}
+
+	res := resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cmp.Or(cfg.ServiceName, "crush")),
+		semconv.ServiceVersion(version.Version),
+	)
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(traceExporter),
+		sdktrace.WithResource(res),
+	)
+	meterProvider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)),
+		sdkmetric.WithResource(res),
+	)
+	if err := setup(tracerProvider, meterProvider); err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) error {
+		return errors.Join(tracerProvider.Shutdown(ctx), meterProvider.Shutdown(ctx))
+	}, nil
+}
+
+// Enabled reports whether telemetry is exported.
+func Enabled() bool {
+	return metrics != nil
+}
+
+func setup(tracerProvider trace.TracerProvider, meterProvider metric.MeterProvider) error {
+	meter := meterProvider.Meter(scope)
+	var m instruments
+	var errs [5]error
+	m.runDuration, errs[0] = meter.Float64Histogram("crush.agent.run.duration",
+		metric.WithDescription("Duration of agent runs, from the prompt to the final answer"),
+		metric.WithUnit("s"),
+	)
+	m.providerDuration, errs[1] = meter.Float64Histogram("crush.provider.call.duration",
+		metric.WithDescription("Duration of provider calls, one per agent step"),
+		metric.WithUnit("s"),
+	)
+	m.toolDuration, errs[2] = meter.Float64Histogram("crush.tool.call.duration",
+		metric.WithDescription("Duration of tool calls"),
+		metric.WithUnit("s"),
+	)
+	m.tokens, errs[3] = meter.Int64Counter("crush.tokens",
+		metric.WithDescription("Tokens used by provider calls, by token type"),
+		metric.WithUnit("{token}"),
+	)
+	m.cost, errs[4] = meter.Float64Counter("crush.cost",
+		metric.WithDescription("Estimated cost of provider calls"),
+		metric.WithUnit("USD"),
+	)
+	if err := errors.Join(errs[:]...); err != nil {
+		return fmt.Errorf("failed to create metrics: %w", err)
+	}
+	tracer = tracerProvider.Tracer(scope, trace.WithInstrumentationVersion(version.Version))
+	metrics = &m
+	return nil
+}
+
+// Model identifies the model of a run or provider call.
+type Model struct {
+	Provider string
+	Model    string
+}
+
+func (m Model) attributes() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("gen_ai.system", m.Provider),
+		attribute.String("gen_ai.request.model", m.Model),
+	}
+}
+
+// Run is an agent run being measured.
+type Run struct {
+	span  trace.Span
+	start time.Time
+	attrs []attribute.KeyValue
+}
+
+// StartRun starts measuring an agent run. The provider and tool calls of the
+// run must use the returned context.
+func StartRun(ctx context.Context, sessionID string, model Model) (context.Context, *Run) {
+	attrs := model.attributes()
+	ctx, span := tracer.Start(ctx, "invoke_agent",
+		trace.WithAttributes(attrs...),
+		trace.WithAttributes(attribute.String("crush.session.id", sessionID)),
+	)
+	return ctx, &Run{span: span, start: time.Now(), attrs: attrs}
+}
+
+// End ends the run, which failed when err isn't nil.
+func (r *Run) End(err error) {
+	end(r.span, err)
+	if metrics != nil {
+		metrics.runDuration.Record(context.Background(), time.Since(r.start).Seconds(),
+			metric.WithAttributes(withError(r.attrs, err)...))
+	}
+}
+
+// ProviderCall is a provider call being measured.
+type ProviderCall struct {
+	span  trace.Span
+	start time.Time
+	attrs []attribute.KeyValue
+}
+
+// StartProviderCall starts measuring a provider call of the run in ctx.
+func StartProviderCall(ctx context.Context, model Model) *ProviderCall {
+	attrs := model.attributes()
+	_, span := tracer.Start(ctx, "chat "+model.Model,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	return &ProviderCall{span: span, start: time.Now(), attrs: attrs}
+}
+
+// Context returns a copy of ctx carrying the provider call, for the tool
+// calls it requests. Agent steps pass their context on to the next step, so
+// the call isn't taken from the context it's started with.
+func (c *ProviderCall) Context(ctx context.Context) context.Context {
+	return trace.ContextWithSpan(ctx, c.span)
+}
+
+// End ends the provider call with the tokens it used and their cost, or with
+// the error it failed with.
+func (c *ProviderCall) End(usage fantasy.Usage, cost float64, err error) {
+	c.span.SetAttributes(
+		attribute.Int64("gen_ai.usage.input_tokens", usage.InputTokens),
+		attribute.Int64("gen_ai.usage.output_tokens", usage.OutputTokens),
+		attribute.Int64("gen_ai.usage.cache_read_tokens", usage.CacheReadTokens),
+		attribute.Int64("gen_ai.usage.cache_creation_tokens", usage.CacheCreationTokens),
+		attribute.Float64("crush.cost", cost),
+	)
+	end(c.span, err)
+	if metrics == nil {
+		return
+	}
+	ctx := context.Background()
+	metrics.providerDuration.Record(ctx, time.Since(c.start).Seconds(),
+		metric.WithAttributes(withError(c.attrs, err)...))
+	for tokenType, count := range map[string]int64{
+		"input":          usage.InputTokens,
+		"output":         usage.OutputTokens,
+		"cache_read":     usage.CacheReadTokens,
+		"cache_creation": usage.CacheCreationTokens,
+	} {
+		if count > 0 {
+			metrics.tokens.Add(ctx, count, metric.WithAttributes(
+				append(c.attrs[:len(c.attrs):len(c.attrs)], attribute.String("gen_ai.token.type", tokenType))...))
+		}
+	}
+	if cost > 0 {
+		metrics.cost.Add(ctx, cost, metric.WithAttributes(c.attrs...))
+	}
+}
+
+// ToolCall is a tool call being measured.
+type ToolCall struct {
+	span  trace.Span
+	start time.Time
+	attrs []attribute.KeyValue
+}
+
+// StartToolCall starts measuring a call of the named tool.
+func StartToolCall(ctx context.Context, name, callID string) (context.Context, *ToolCall) {
+	attrs := []attribute.KeyValue{attribute.String("gen_ai.tool.name", name)}
+	ctx, span := tracer.Start(ctx, "execute_tool "+name,
+		trace.WithAttributes(attrs...),
+		trace.WithAttributes(attribute.String("gen_ai.tool.call.id", callID)),
+	)
+	return ctx, &ToolCall{span: span, start: time.Now(), attrs: attrs}
+}
+
+// End ends the tool call. Both err and error responses count as failures.
+func (c *ToolCall) End(isError bool, err error) {
+	if err == nil && isError {
+		err = errToolResponse
+	}
+	end(c.span, err)
+	if metrics != nil {
+		metrics.toolDuration.Record(context.Background(), time.Since(c.start).Seconds(),
+			metric.WithAttributes(withError(c.attrs, err)...))
+	}
+}
+
+var errToolResponse = errors.New("the tool returned an error")
+
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func withError(attrs []attribute.KeyValue, err error) []attribute.KeyValue {
+	return append(attrs[:len(attrs):len(attrs)], attribute.Bool("error", err != nil))
+}
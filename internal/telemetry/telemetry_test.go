@@ -0,0 +1,70 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTelemetry(t *testing.T) {
+	// Not parallel: setup replaces the package's tracer and metrics.
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	require.NoError(t, setup(
+		sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)),
+		sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	))
+	require.True(t, Enabled())
+
+	model := Model{Provider: "anthropic", Model: "claude"}
+	ctx, run := StartRun(t.Context(), "session", model)
+	call := StartProviderCall(ctx, model)
+	_, tool := StartToolCall(call.Context(context.Background()), "bash", "call-1")
+	tool.End(true, nil)
+	call.End(fantasy.Usage{InputTokens: 10, OutputTokens: 5}, 0.25, nil)
+	run.End(errors.New("provider error"))
+
+	ended := spans.Ended()
+	require.Len(t, ended, 3)
+	toolSpan, callSpan, runSpan := ended[0], ended[1], ended[2]
+	require.Equal(t, "execute_tool bash", toolSpan.Name())
+	require.Equal(t, "chat claude", callSpan.Name())
+	require.Equal(t, "invoke_agent", runSpan.Name())
+	require.Equal(t, callSpan.SpanContext().SpanID(), toolSpan.Parent().SpanID())
+	require.Equal(t, runSpan.SpanContext().SpanID(), callSpan.Parent().SpanID())
+	require.Equal(t, codes.Error, toolSpan.Status().Code, "error responses fail the tool call")
+	require.Equal(t, codes.Unset, callSpan.Status().Code)
+	require.Equal(t, codes.Error, runSpan.Status().Code)
+	require.Contains(t, callSpan.Attributes(), attribute.Int64("gen_ai.usage.input_tokens", 10))
+
+	var data metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(t.Context(), &data))
+	require.Len(t, data.ScopeMetrics, 1)
+	got := map[string]metricdata.Aggregation{}
+	for _, m := range data.ScopeMetrics[0].Metrics {
+		got[m.Name] = m.Data
+	}
+	require.Len(t, got, 5)
+
+	tokens := map[string]int64{}
+	for _, point := range got["crush.tokens"].(metricdata.Sum[int64]).DataPoints {
+		tokenType, _ := point.Attributes.Value("gen_ai.token.type")
+		tokens[tokenType.AsString()] = point.Value
+	}
+	require.Equal(t, map[string]int64{"input": 10, "output": 5}, tokens)
+	require.Equal(t, 0.25, got["crush.cost"].(metricdata.Sum[float64]).DataPoints[0].Value)
+
+	runs := got["crush.agent.run.duration"].(metricdata.Histogram[float64]).DataPoints
+	require.Len(t, runs, 1)
+	failed, _ := runs[0].Attributes.Value("error")
+	require.True(t, failed.AsBool())
+}
@@ -0,0 +1,297 @@
+// Package transcript records provider request/response pairs to disk so
+// provider bugs can be reproduced without enabling full HTTP debug logs.
+package transcript
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/httpext"
+	"github.com/charmbracelet/crush/internal/log"
+)
+
+const (
+	dirName          = "transcripts"
+	unknownSessionID = "unknown"
+)
+
+// Entry is a single recorded request/response pair.
+type Entry struct {
+	Time       time.Time `json:"time"`
+	SessionID  string    `json:"session_id"`
+	Provider   string    `json:"provider,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	Request    Request   `json:"request"`
+	Response   *Response `json:"response,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Request is the recorded, redacted HTTP request.
+type Request struct {
+	Method  string              `json:"method"`
+	URL     string              `json:"url"`
+	Headers map[string][]string `json:"headers,omitempty"`
+	Body    json.RawMessage     `json:"body,omitempty"`
+}
+
+// Response is the recorded, redacted HTTP response.
+type Response struct {
+	StatusCode int                 `json:"status_code"`
+	Status     string              `json:"status"`
+	Headers    map[string][]string `json:"headers,omitempty"`
+	Body       json.RawMessage     `json:"body,omitempty"`
+}
+
+// Dir returns the directory transcripts are stored in for the given data
+// directory.
+func Dir(dataDir string) string {
+	return filepath.Join(dataDir, dirName)
+}
+
+// Recorder is an http.RoundTripper that saves every request/response pair as
+// a JSON file under its directory, grouped by session.
+type Recorder struct {
+	Transport http.RoundTripper
+	Provider  string
+
+	dir string
+	seq atomic.Uint64
+}
+
+// NewRecorder creates a new recorder writing to the transcripts directory
+// inside dataDir. If transport is nil, httpext.DefaultTransport is used.
+func NewRecorder(dataDir, provider string, transport http.RoundTripper) *Recorder {
+	if transport == nil {
+		transport = httpext.DefaultTransport()
+	}
+	return &Recorder{
+		Transport: transport,
+		Provider:  provider,
+		dir:       Dir(dataDir),
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := Entry{
+		Time:      time.Now(),
+		SessionID: tools.GetSessionFromContext(req.Context()),
+		Provider:  r.Provider,
+		Request: Request{
+			Method:  req.Method,
+			URL:     redactURL(req.URL),
+			Headers: log.RedactHeaders(req.Header),
+			Body:    toRawJSON(reqBody),
+		},
+	}
+	seq := r.seq.Add(1)
+
+	resp, err := r.Transport.RoundTrip(req)
+	if err != nil {
+		entry.DurationMS = time.Since(entry.Time).Milliseconds()
+		entry.Error = err.Error()
+		r.save(entry, seq)
+		return resp, err
+	}
+
+	entry.Response = &Response{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Headers:    log.RedactHeaders(resp.Header),
+	}
+
+	// Streamed responses are recorded as they are consumed, so wrap the body
+	// instead of reading it upfront.
+	resp.Body = &recordingBody{
+		ReadCloser: resp.Body,
+		done: func(body []byte, readErr error) {
+			entry.DurationMS = time.Since(entry.Time).Milliseconds()
+			entry.Response.Body = toRawJSON(body)
+			if readErr != nil {
+				entry.Error = readErr.Error()
+			}
+			r.save(entry, seq)
+		},
+	}
+	return resp, nil
+}
+
+func (r *Recorder) save(entry Entry, seq uint64) {
+	sessionDir := filepath.Join(r.dir, sessionDirName(entry.SessionID))
+	if err := os.MkdirAll(sessionDir, 0o700); err != nil {
+		slog.Error("Failed to create transcript directory", "error", err)
+		return
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal transcript entry", "error", err)
+		return
+	}
+	name := fmt.Sprintf("%s-%04d.json", entry.Time.Format("20060102-150405.000"), seq)
+	if err := os.WriteFile(filepath.Join(sessionDir, name), data, 0o600); err != nil {
+		slog.Error("Failed to write transcript entry", "error", err)
+	}
+}
+
+// Sessions returns the IDs of all sessions that have recorded transcripts.
+func Sessions(dataDir string) ([]string, error) {
+	entries, err := os.ReadDir(Dir(dataDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var sessions []string
+	for _, e := range entries {
+		if e.IsDir() {
+			sessions = append(sessions, e.Name())
+		}
+	}
+	return sessions, nil
+}
+
+// Load returns the recorded entries for the given session, oldest first.
+func Load(dataDir, sessionID string) ([]Entry, error) {
+	if !validSessionID(sessionID) {
+		return nil, fmt.Errorf("invalid session ID %q", sessionID)
+	}
+	sessionDir := filepath.Join(Dir(dataDir), sessionDirName(sessionID))
+	files, err := os.ReadDir(sessionDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no transcript found for session %q", sessionID)
+		}
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(sessionDir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var entry Entry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse transcript entry %s: %w", f.Name(), err)
+		}
+		entries = append(entries, entry)
+	}
+	slices.SortStableFunc(entries, func(a, b Entry) int {
+		return a.Time.Compare(b.Time)
+	})
+	return entries, nil
+}
+
+type recordingBody struct {
+	io.ReadCloser
+	buf  bytes.Buffer
+	once sync.Once
+	done func(body []byte, err error)
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	if err == io.EOF {
+		b.finish(nil)
+	} else if err != nil {
+		b.finish(err)
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.finish(nil)
+	return err
+}
+
+func (b *recordingBody) finish(err error) {
+	b.once.Do(func() {
+		b.done(b.buf.Bytes(), err)
+	})
+}
+
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(*body)
+	if err != nil {
+		return nil, err
+	}
+	if err := (*body).Close(); err != nil {
+		return nil, err
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// toRawJSON keeps JSON bodies as-is and encodes anything else (e.g.
+// server-sent events) as a JSON string.
+func toRawJSON(body []byte) json.RawMessage {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil
+	}
+	if json.Valid(body) {
+		return body
+	}
+	data, _ := json.Marshal(string(body))
+	return data
+}
+
+func redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	redactedURL := *u
+	query := redactedURL.Query()
+	for key := range query {
+		lowerKey := strings.ToLower(key)
+		if lowerKey == "key" ||
+			strings.Contains(lowerKey, "token") ||
+			strings.Contains(lowerKey, "secret") {
+			query.Set(key, "REDACTED")
+		}
+	}
+	redactedURL.RawQuery = query.Encode()
+	redactedURL.User = nil
+	return redactedURL.String()
+}
+
+func sessionDirName(sessionID string) string {
+	if sessionID == "" {
+		return unknownSessionID
+	}
+	// Agent tool sessions contain "$$", keep them filesystem friendly.
+	return strings.NewReplacer("/", "_", "\\", "_", "$$", "__").Replace(sessionID)
+}
+
+// validSessionID reports whether sessionID names a directory of Dir, and not
+// Dir itself, one above it or one in another directory.
+func validSessionID(sessionID string) bool {
+	return sessionID != "." && sessionID != ".." && !strings.ContainsAny(sessionID, `/\`)
+}
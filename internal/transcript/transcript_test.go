@@ -0,0 +1,101 @@
+package transcript
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": "resp_1", "output": "hello"}`))
+	}))
+	defer server.Close()
+
+	dataDir := t.TempDir()
+	client := &http.Client{Transport: NewRecorder(dataDir, "openai", nil)}
+
+	ctx := context.WithValue(t.Context(), tools.SessionIDContextKey, "session-1")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"?key=secret", strings.NewReader(`{"model": "gpt"}`))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret-token")
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.JSONEq(t, `{"id": "resp_1", "output": "hello"}`, string(body))
+
+	sessions, err := Sessions(dataDir)
+	require.NoError(t, err)
+	require.Equal(t, []string{"session-1"}, sessions)
+
+	entries, err := Load(dataDir, "session-1")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	entry := entries[0]
+	require.Equal(t, "openai", entry.Provider)
+	require.Equal(t, http.MethodPost, entry.Request.Method)
+	require.NotContains(t, entry.Request.URL, "secret")
+	require.Equal(t, []string{"[REDACTED]"}, entry.Request.Headers["Authorization"])
+	require.JSONEq(t, `{"model": "gpt"}`, string(entry.Request.Body))
+	require.NotNil(t, entry.Response)
+	require.Equal(t, http.StatusOK, entry.Response.StatusCode)
+	require.JSONEq(t, `{"id": "resp_1", "output": "hello"}`, string(entry.Response.Body))
+}
+
+func TestRecorderStreamedBody(t *testing.T) {
+	t.Parallel()
+
+	const stream = "data: {\"delta\": \"hel\"}\n\ndata: {\"delta\": \"lo\"}\n\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(stream))
+	}))
+	defer server.Close()
+
+	dataDir := t.TempDir()
+	client := &http.Client{Transport: NewRecorder(dataDir, "anthropic", nil)}
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	_, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	entries, err := Load(dataDir, "")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	var body string
+	require.NoError(t, json.Unmarshal(entries[0].Response.Body, &body))
+	require.Equal(t, strings.TrimSpace(stream), body)
+}
+
+func TestLoadMissingSession(t *testing.T) {
+	t.Parallel()
+
+	_, err := Load(t.TempDir(), "nope")
+	require.Error(t, err)
+}
+
+func TestLoadInvalidSession(t *testing.T) {
+	t.Parallel()
+
+	for _, id := range []string{".", "..", "../sessions", "a/b", `a\b`} {
+		_, err := Load(t.TempDir(), id)
+		require.ErrorContains(t, err, "invalid session ID", id)
+	}
+}
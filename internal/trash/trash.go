@@ -0,0 +1,233 @@
+// Package trash keeps the contents files had before the tools of a session
+// replaced or deleted them, in the data directory of the project, so that
+// they can be restored by hand with crush restore. It complements the file
+// history, which only keeps the versions of the files in the database.
+package trash
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRetention is how long the contents are kept by default.
+	DefaultRetention = 7 * 24 * time.Hour
+	// MaxSize is the size of the largest file kept. Larger files are
+	// replaced without being kept.
+	MaxSize = 10 * 1024 * 1024
+	// RestoreSessionID is the session the contents replaced by Restore are
+	// kept for, so that a restore can be undone too.
+	RestoreSessionID = "restore"
+)
+
+// Entry is the content a file had before a session replaced it.
+type Entry struct {
+	Path      string    `json:"path"`
+	SessionID string    `json:"session_id"`
+	Time      time.Time `json:"time"`
+	Size      int64     `json:"size"`
+
+	// name is the name of the files of the entry without their extension.
+	name string
+}
+
+// Store keeps the contents in a directory, with a directory per session
+// holding a metadata file and a content file per entry. A nil store keeps
+// nothing.
+type Store struct {
+	dir       string
+	retention time.Duration
+	now       func() time.Time
+}
+
+// New returns the store of the contents in dir, which are kept for
+// retention.
+func New(dir string, retention time.Duration) *Store {
+	return &Store{dir: dir, retention: retention, now: time.Now}
+}
+
+// ForDataDir returns the store of the contents in the data directory of a
+// project.
+func ForDataDir(dataDir string, retention time.Duration) *Store {
+	return New(filepath.Join(dataDir, "trash"), retention)
+}
+
+// Save keeps the content of the file at path for the session with
+// sessionID, which is about to replace or delete it. Files that don't exist
+// or are larger than MaxSize aren't kept.
+func (s *Store) Save(sessionID, path string) error {
+	if s == nil {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && (!info.Mode().IsRegular() || info.Size() > MaxSize)) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to keep %s: %w", path, err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to keep %s: %w", path, err)
+	}
+
+	now := s.now()
+	sum := sha256.Sum256([]byte(path))
+	e := Entry{
+		Path:      path,
+		SessionID: sessionID,
+		Time:      now,
+		Size:      int64(len(content)),
+		name:      strconv.FormatInt(now.UnixNano(), 10) + "-" + hex.EncodeToString(sum[:8]),
+	}
+	meta, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.sessionDir(sessionID), 0o700); err != nil {
+		return fmt.Errorf("failed to keep %s: %w", path, err)
+	}
+	// The content goes first so that listed entries always have it.
+	if err := os.WriteFile(s.contentPath(e), content, 0o600); err != nil {
+		return fmt.Errorf("failed to keep %s: %w", path, err)
+	}
+	if err := os.WriteFile(s.metaPath(e), meta, 0o600); err != nil {
+		_ = os.Remove(s.contentPath(e))
+		return fmt.Errorf("failed to keep %s: %w", path, err)
+	}
+	return nil
+}
+
+// List returns the entries of the file at path, or of every file when path
+// is empty, newest first.
+func (s *Store) List(path string) ([]Entry, error) {
+	if s == nil {
+		return nil, nil
+	}
+	metas, err := filepath.Glob(filepath.Join(s.dir, "*", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, meta := range metas {
+		e, err := readEntry(meta)
+		if err != nil || (path != "" && e.Path != path) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	slices.SortFunc(entries, func(a, b Entry) int {
+		return cmp.Or(b.Time.Compare(a.Time), strings.Compare(a.Path, b.Path))
+	})
+	return entries, nil
+}
+
+// Content returns the content kept by an entry.
+func (s *Store) Content(e Entry) ([]byte, error) {
+	content, err := os.ReadFile(s.contentPath(e))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the kept content of %s: %w", e.Path, err)
+	}
+	return content, nil
+}
+
+// Restore writes the content kept by an entry back to its file, first
+// keeping the content the file has for RestoreSessionID.
+func (s *Store) Restore(e Entry) error {
+	content, err := s.Content(e)
+	if err != nil {
+		return err
+	}
+	if err := s.Save(RestoreSessionID, e.Path); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(e.Path), 0o755); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", e.Path, err)
+	}
+	if err := os.WriteFile(e.Path, content, 0o644); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", e.Path, err)
+	}
+	return nil
+}
+
+// Prune deletes the entries older than the retention of the store, and the
+// directories of the sessions left without any.
+func (s *Store) Prune() error {
+	if s == nil {
+		return nil
+	}
+	sessions, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	cutoff := s.now().Add(-s.retention)
+	for _, session := range sessions {
+		if !session.IsDir() {
+			continue
+		}
+		dir := filepath.Join(s.dir, session.Name())
+		metas, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil {
+			return err
+		}
+		for _, meta := range metas {
+			e, err := readEntry(meta)
+			if err == nil && !e.Time.Before(cutoff) {
+				continue
+			}
+			name := strings.TrimSuffix(meta, ".json")
+			if err := errors.Join(os.Remove(meta), os.Remove(name+".orig")); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+		// Fails when entries are left, which is fine.
+		_ = os.Remove(dir)
+	}
+	return nil
+}
+
+func readEntry(meta string) (Entry, error) {
+	var e Entry
+	data, err := os.ReadFile(meta)
+	if err != nil {
+		return e, err
+	}
+	if err := json.Unmarshal(data, &e); err != nil {
+		return e, err
+	}
+	e.name = strings.TrimSuffix(filepath.Base(meta), ".json")
+	return e, nil
+}
+
+// sessionDir returns the directory of the entries of a session, named after
+// its hash when the ID doesn't fit in a file name.
+func (s *Store) sessionDir(sessionID string) string {
+	name := sessionID
+	if name == "" || !filepath.IsLocal(name) || strings.ContainsAny(name, `/\:`) {
+		sum := sha256.Sum256([]byte(sessionID))
+		name = hex.EncodeToString(sum[:8])
+	}
+	return filepath.Join(s.dir, name)
+}
+
+func (s *Store) metaPath(e Entry) string {
+	return filepath.Join(s.sessionDir(e.SessionID), e.name+".json")
+}
+
+func (s *Store) contentPath(e Entry) string {
+	return filepath.Join(s.sessionDir(e.SessionID), e.name+".orig")
+}
@@ -0,0 +1,105 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	s := ForDataDir(t.TempDir(), DefaultRetention)
+	s.now = func() time.Time { return now }
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.go")
+	other := filepath.Join(dir, "other.go")
+
+	require.NoError(t, s.Save("session-1", file), "files that don't exist aren't kept")
+	entries, err := s.List("")
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	require.NoError(t, os.WriteFile(file, []byte("one"), 0o644))
+	require.NoError(t, s.Save("session-1", file))
+	now = now.Add(time.Minute)
+	require.NoError(t, os.WriteFile(file, []byte("two"), 0o644))
+	require.NoError(t, s.Save("session-2", file))
+	require.NoError(t, os.WriteFile(other, []byte("other"), 0o644))
+	require.NoError(t, s.Save("session-1", other))
+	require.NoError(t, os.WriteFile(file, []byte("three"), 0o644))
+
+	entries, err = s.List(file)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, "session-2", entries[0].SessionID, "newest first")
+	require.Equal(t, int64(3), entries[0].Size)
+	require.Equal(t, "session-1", entries[1].SessionID)
+	entries, err = s.List("")
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	entries, err = s.List(file)
+	require.NoError(t, err)
+	content, err := s.Content(entries[1])
+	require.NoError(t, err)
+	require.Equal(t, "one", string(content))
+
+	require.NoError(t, s.Restore(entries[1]))
+	content, err = os.ReadFile(file)
+	require.NoError(t, err)
+	require.Equal(t, "one", string(content))
+	entries, err = s.List(file)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	require.Equal(t, RestoreSessionID, entries[0].SessionID, "the restored content is kept too")
+	content, err = s.Content(entries[0])
+	require.NoError(t, err)
+	require.Equal(t, "three", string(content))
+
+	now = now.Add(DefaultRetention)
+	require.NoError(t, s.Prune())
+	entries, err = s.List("")
+	require.NoError(t, err)
+	require.Len(t, entries, 3, "only the entry older than the retention is deleted")
+	for _, e := range entries {
+		require.False(t, e.Time.Before(now.Add(-DefaultRetention)))
+	}
+
+	now = now.Add(time.Minute)
+	require.NoError(t, s.Prune())
+	entries, err = s.List("")
+	require.NoError(t, err)
+	require.Empty(t, entries)
+	sessions, err := os.ReadDir(s.dir)
+	require.NoError(t, err)
+	require.Empty(t, sessions, "empty session directories are deleted")
+}
+
+func TestStoreSessionDir(t *testing.T) {
+	t.Parallel()
+
+	s := New(t.TempDir(), DefaultRetention)
+	require.Equal(t, filepath.Join(s.dir, "session-1"), s.sessionDir("session-1"))
+	for _, id := range []string{"", "..", "../escape", `a\b`} {
+		require.Equal(t, s.dir, filepath.Dir(s.sessionDir(id)), id)
+		require.NotEqual(t, "..", filepath.Base(s.sessionDir(id)), id)
+	}
+}
+
+func TestNilStore(t *testing.T) {
+	t.Parallel()
+
+	var s *Store
+	file := filepath.Join(t.TempDir(), "main.go")
+	require.NoError(t, os.WriteFile(file, []byte("one"), 0o644))
+	require.NoError(t, s.Save("session-1", file))
+	entries, err := s.List(file)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+	require.NoError(t, s.Prune())
+}
@@ -0,0 +1,141 @@
+// Package trust stores which directories the user trusts. The trust level of
+// the working directory decides how permissions are asked for: read-only
+// commands run without asking in trusted directories, while everything asks
+// in untrusted ones.
+package trust
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/fsext"
+)
+
+// Level is how much a directory is trusted.
+type Level string
+
+const (
+	// Unknown directories haven't been marked yet, and get the default
+	// permissions behavior.
+	Unknown   Level = ""
+	Trusted   Level = "trusted"
+	Untrusted Level = "untrusted"
+)
+
+// Store keeps the trust levels of directories in a JSON file. The level of
+// a directory applies to the directories inside it that have none.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+type storeFile struct {
+	Directories map[string]Level `json:"directories"`
+}
+
+// NewStore returns a store kept in the file at path.
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// DefaultStore returns the store in the global data directory.
+func DefaultStore() *Store {
+	return NewStore(filepath.Join(filepath.Dir(config.GlobalConfigData()), "trust.json"))
+}
+
+// Lookup returns the trust level of dir, and the directory it was marked
+// on, dir itself or one of its parents.
+func (s *Store) Lookup(dir string) (Level, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dirs, err := s.load()
+	if err != nil {
+		return Unknown, "", err
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return Unknown, "", err
+	}
+	for {
+		if level, ok := dirs[dir]; ok {
+			return level, dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return Unknown, "", nil
+		}
+		dir = parent
+	}
+}
+
+// Set marks dir with level, or forgets it for Unknown.
+func (s *Store) Set(dir string, level Level) error {
+	switch level {
+	case Unknown, Trusted, Untrusted:
+	default:
+		return fmt.Errorf("unknown trust level %q", level)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dirs, err := s.load()
+	if err != nil {
+		return err
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	if level == Unknown {
+		delete(dirs, dir)
+	} else {
+		dirs[dir] = level
+	}
+	return s.save(dirs)
+}
+
+// List returns the levels of all the marked directories.
+func (s *Store) List() (map[string]Level, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+func (s *Store) load() (map[string]Level, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]Level{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trusted directories: %w", err)
+	}
+	var file storeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", s.path, err)
+	}
+	if file.Directories == nil {
+		file.Directories = map[string]Level{}
+	}
+	return file.Directories, nil
+}
+
+func (s *Store) save(dirs map[string]Level) error {
+	data, err := json.MarshalIndent(storeFile{Directories: dirs}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to save trusted directories: %w", err)
+	}
+	if err := fsext.WriteFileAtomic(s.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save trusted directories: %w", err)
+	}
+	return nil
+}
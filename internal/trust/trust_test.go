@@ -0,0 +1,39 @@
+package trust
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store := NewStore(filepath.Join(dir, "data", "trust.json"))
+	project := filepath.Join(dir, "src", "project")
+
+	level, _, err := store.Lookup(project)
+	require.NoError(t, err)
+	require.Equal(t, Unknown, level, "nothing is stored yet")
+
+	require.NoError(t, store.Set(filepath.Join(dir, "src"), Trusted))
+	level, from, err := store.Lookup(project)
+	require.NoError(t, err)
+	require.Equal(t, Trusted, level, "subdirectories inherit the level")
+	require.Equal(t, filepath.Join(dir, "src"), from)
+
+	require.NoError(t, store.Set(project, Untrusted))
+	level, from, err = store.Lookup(filepath.Join(project, "pkg"))
+	require.NoError(t, err)
+	require.Equal(t, Untrusted, level, "the closest directory wins")
+	require.Equal(t, project, from)
+
+	require.NoError(t, store.Set(project, Unknown))
+	dirs, err := NewStore(filepath.Join(dir, "data", "trust.json")).List()
+	require.NoError(t, err)
+	require.Equal(t, map[string]Level{filepath.Join(dir, "src"): Trusted}, dirs)
+
+	require.Error(t, store.Set(project, "maybe"))
+}
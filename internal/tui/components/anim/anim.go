@@ -2,9 +2,11 @@
 package anim
 
 import (
+	"cmp"
 	"fmt"
 	"image/color"
 	"math/rand/v2"
+	"slices"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -64,6 +66,15 @@ func nextID() int {
 	return int(atomic.AddInt64(&lastID, 1))
 }
 
+// static makes spinners show their label instead of animating.
+var static atomic.Bool
+
+// SetStatic makes all spinners show a static label instead of animating,
+// for screen readers.
+func SetStatic(v bool) {
+	static.Store(v)
+}
+
 // Cache for expensive animation calculations
 type animCache struct {
 	initialFrames  [][]string
@@ -349,6 +360,10 @@ func (a *Anim) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 
 // View renders the current state of the animation.
 func (a *Anim) View() string {
+	if static.Load() {
+		label := strings.Join(slices.Collect(a.label.Seq()), "")
+		return cmp.Or(label, "Working") + "..."
+	}
 	var b strings.Builder
 	step := int(a.step.Load())
 	for i := range a.width {
@@ -383,6 +398,9 @@ func (a *Anim) View() string {
 
 // Step is a command that triggers the next step in the animation.
 func (a *Anim) Step() tea.Cmd {
+	if static.Load() {
+		return nil
+	}
 	return tea.Tick(time.Second/time.Duration(fps), func(t time.Time) tea.Msg {
 		return StepMsg{id: a.id}
 	})
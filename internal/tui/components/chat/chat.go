@@ -27,6 +27,12 @@ type SendMsg struct {
 	Attachments []message.Attachment
 }
 
+// RunShellMsg runs Command in the shell without the agent and adds its output
+// to the session.
+type RunShellMsg struct {
+	Command string
+}
+
 type SessionSelectedMsg = session.Session
 
 type SessionClearedMsg struct{}
@@ -53,6 +59,8 @@ type MessageListCmp interface {
 	GoToBottom() tea.Cmd
 	GetSelectedText() string
 	CopySelectedText(bool) tea.Cmd
+	// Summarizing reports whether a summary of the session is streaming.
+	Summarizing() bool
 }
 
 // messageListCmp implements MessageListCmp, providing a virtualized list
@@ -74,8 +82,18 @@ type messageListCmp struct {
 	lastClickY    int
 	clickCount    int
 	promptQueue   int
+
+	// ticking tells whether a ToolTickMsg is on its way.
+	ticking bool
 }
 
+// ToolTickMsg re-renders the running tool calls, for the time they have
+// been running to stay current.
+type ToolTickMsg struct{}
+
+// toolTickInterval is how often running tool calls are re-rendered.
+const toolTickInterval = time.Second
+
 // New creates a new message list component with custom keybindings
 // and reverse ordering (newest messages at bottom).
 func New(app *app.App) MessageListCmp {
@@ -204,7 +222,30 @@ func (m *messageListCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		return m, tea.Batch(cmds...)
 
 	case pubsub.Event[message.Message]:
-		cmds = append(cmds, m.handleMessageEvent(msg))
+		cmds = append(cmds, m.handleMessageEvent(msg), m.tickRunningTools())
+		return m, tea.Batch(cmds...)
+
+	case pubsub.Event[agent.ToolProgress]:
+		m.handleToolProgress(msg.Payload)
+		cmds = append(cmds, m.tickRunningTools())
+		return m, tea.Batch(cmds...)
+
+	case ToolTickMsg:
+		m.ticking = false
+		for _, item := range m.listCmp.Items() {
+			if toolCall, ok := item.(messages.ToolCallCmp); ok && toolCall.Running() {
+				m.listCmp.UpdateItem(toolCall.ID(), toolCall)
+			}
+		}
+		cmds = append(cmds, m.tickRunningTools())
+		return m, tea.Batch(cmds...)
+
+	case pubsub.Event[agent.SubAgentProgress]:
+		m.handleSubAgentProgress(msg.Payload)
+		return m, tea.Batch(cmds...)
+
+	case pubsub.Event[tools.DownloadProgress]:
+		m.handleDownloadProgress(msg.Payload)
 		return m, tea.Batch(cmds...)
 
 	case tea.MouseWheelMsg:
@@ -366,22 +407,39 @@ func (m *messageListCmp) messageExists(messageID string) bool {
 	return false
 }
 
-// handleDeleteMessage removes a message from the list.
+// handleDeleteMessage removes a message from the list, with the tool calls
+// of an assistant message.
 func (m *messageListCmp) handleDeleteMessage(msg message.Message) tea.Cmd {
 	items := m.listCmp.Items()
 	for i := len(items) - 1; i >= 0; i-- {
-		if msgCmp, ok := items[i].(messages.MessageCmp); ok && msgCmp.GetMessage().ID == msg.ID {
-			m.listCmp.DeleteItem(items[i].ID())
-			return nil
+		switch item := items[i].(type) {
+		case messages.MessageCmp:
+			if item.GetMessage().ID == msg.ID {
+				m.listCmp.DeleteItem(item.ID())
+			}
+		case messages.ToolCallCmp:
+			if item.ParentMessageID() == msg.ID {
+				m.listCmp.DeleteItem(item.ID())
+			}
 		}
 	}
 	return nil
 }
 
+func (m *messageListCmp) Summarizing() bool {
+	items := m.listCmp.Items()
+	for i := len(items) - 1; i >= 0; i-- {
+		if msg, ok := items[i].(messages.MessageCmp); ok && msg.Summarizing() {
+			return true
+		}
+	}
+	return false
+}
+
 // handleNewMessage routes new messages to appropriate handlers based on role.
 func (m *messageListCmp) handleNewMessage(msg message.Message) tea.Cmd {
 	switch msg.Role {
-	case message.User:
+	case message.User, message.System:
 		return m.handleNewUserMessage(msg)
 	case message.Assistant:
 		return m.handleNewAssistantMessage(msg)
@@ -410,6 +468,52 @@ func (m *messageListCmp) handleToolMessage(msg message.Message) tea.Cmd {
 	return nil
 }
 
+// handleSubAgentProgress updates the status line of the tool call that
+// started a sub-agent.
+func (m *messageListCmp) handleSubAgentProgress(progress agent.SubAgentProgress) {
+	items := m.listCmp.Items()
+	if toolCallIndex := m.findToolCallByID(items, progress.ToolCallID); toolCallIndex != NotFound {
+		toolCall := items[toolCallIndex].(messages.ToolCallCmp)
+		toolCall.SetSubAgentProgress(progress)
+		m.listCmp.UpdateItem(toolCall.ID(), toolCall)
+	}
+}
+
+// handleDownloadProgress updates the progress line of a download tool call.
+func (m *messageListCmp) handleDownloadProgress(progress tools.DownloadProgress) {
+	items := m.listCmp.Items()
+	if toolCallIndex := m.findToolCallByID(items, progress.ToolCallID); toolCallIndex != NotFound {
+		toolCall := items[toolCallIndex].(messages.ToolCallCmp)
+		toolCall.SetDownloadProgress(progress)
+		m.listCmp.UpdateItem(toolCall.ID(), toolCall)
+	}
+}
+
+// handleToolProgress updates the timeouts of a tool call.
+func (m *messageListCmp) handleToolProgress(progress agent.ToolProgress) {
+	items := m.listCmp.Items()
+	if toolCallIndex := m.findToolCallByID(items, progress.ToolCallID); toolCallIndex != NotFound {
+		toolCall := items[toolCallIndex].(messages.ToolCallCmp)
+		toolCall.SetToolProgress(progress)
+		m.listCmp.UpdateItem(toolCall.ID(), toolCall)
+	}
+}
+
+// tickRunningTools schedules a ToolTickMsg while tool calls are running,
+// unless one is on its way already.
+func (m *messageListCmp) tickRunningTools() tea.Cmd {
+	if m.ticking {
+		return nil
+	}
+	for _, item := range m.listCmp.Items() {
+		if toolCall, ok := item.(messages.ToolCallCmp); ok && toolCall.Running() {
+			m.ticking = true
+			return tea.Tick(toolTickInterval, func(time.Time) tea.Msg { return ToolTickMsg{} })
+		}
+	}
+	return nil
+}
+
 // findToolCallByID searches for a tool call with the specified ID.
 // Returns the index if found, NotFound otherwise.
 func (m *messageListCmp) findToolCallByID(items []list.Item, toolCallID string) int {
@@ -604,7 +708,7 @@ func (m *messageListCmp) convertMessagesToUI(sessionMessages []message.Message,
 
 	for _, msg := range sessionMessages {
 		switch msg.Role {
-		case message.User:
+		case message.User, message.System:
 			m.lastUserMessageTime = msg.CreatedAt
 			uiMessages = append(uiMessages, messages.NewMessageCmp(msg))
 		case message.Assistant:
@@ -0,0 +1,43 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/attachment"
+)
+
+// attachmentType returns the short type of an attachment, e.g. PNG.
+func attachmentType(a message.Attachment) string {
+	kind, sub, _ := strings.Cut(a.MimeType, "/")
+	sub, _, _ = strings.Cut(sub, ";")
+	switch {
+	case kind == "text":
+		return "TEXT"
+	case sub == "" || sub == "octet-stream":
+		return "FILE"
+	}
+	return strings.ToUpper(sub)
+}
+
+// attachmentWarning returns why the attachments can't be sent as they are to
+// provider with the given limits, or an empty string when they can.
+func attachmentWarning(attachments []message.Attachment, supportsImages bool, provider string, limits agent.AttachmentLimits) string {
+	var total int64
+	for _, a := range attachments {
+		if !supportsImages && strings.HasPrefix(a.MimeType, "image/") {
+			return "the model doesn't take images, they won't be sent"
+		}
+		size := int64(len(a.Content))
+		if limits.File > 0 && size > limits.File {
+			return fmt.Sprintf("%s is over the %s %s takes per file", a.FileName, attachment.FormatSize(limits.File), provider)
+		}
+		total += size
+	}
+	if limits.Total > 0 && total > limits.Total {
+		return fmt.Sprintf("%s in total is over the %s %s takes", attachment.FormatSize(total), attachment.FormatSize(limits.Total), provider)
+	}
+	return ""
+}
@@ -0,0 +1,43 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachmentType(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "PNG", attachmentType(message.Attachment{MimeType: "image/png"}))
+	require.Equal(t, "TEXT", attachmentType(message.Attachment{MimeType: "text/plain; charset=utf-8"}))
+	require.Equal(t, "FILE", attachmentType(message.Attachment{MimeType: "application/octet-stream"}))
+	require.Equal(t, "FILE", attachmentType(message.Attachment{}))
+}
+
+func TestAttachmentWarning(t *testing.T) {
+	t.Parallel()
+
+	img := func(name string, size int) message.Attachment {
+		return message.Attachment{FileName: name, MimeType: "image/png", Content: make([]byte, size)}
+	}
+	limits := agent.AttachmentLimits{File: 3 << 20, Total: 5 << 20}
+	small := []message.Attachment{img("a.png", 1<<20), img("b.png", 2<<20)}
+
+	require.Empty(t, attachmentWarning(small, true, "Anthropic", limits))
+	require.Empty(t, attachmentWarning(small, true, "Local", agent.AttachmentLimits{}))
+	require.Equal(t,
+		"the model doesn't take images, they won't be sent",
+		attachmentWarning(small, false, "Anthropic", limits),
+	)
+	require.Equal(t,
+		"big.png is over the 3.0 MB Anthropic takes per file",
+		attachmentWarning([]message.Attachment{img("big.png", 4<<20)}, true, "Anthropic", limits),
+	)
+	require.Equal(t,
+		"6.0 MB in total is over the 5.0 MB Anthropic takes",
+		attachmentWarning(append(small, img("c.png", 3<<20)), true, "Anthropic", limits),
+	)
+}
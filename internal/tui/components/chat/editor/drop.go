@@ -0,0 +1,154 @@
+package editor
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/filepicker"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+// droppedPaths returns the files of a paste made by dropping them on the
+// terminal, or false when the paste isn't one. Terminals paste the absolute
+// paths of dropped files separated by spaces or new lines, quoting them or
+// escaping spaces with backslashes when escapes is set, and some paste
+// file:// URLs instead.
+func droppedPaths(content string, escapes bool) ([]string, bool) {
+	var (
+		paths   []string
+		current strings.Builder
+		quote   rune
+		inPath  bool
+		escaped bool
+	)
+	flush := func() {
+		if inPath {
+			paths = append(paths, current.String())
+		}
+		current.Reset()
+		inPath = false
+	}
+	for _, r := range strings.TrimSpace(content) {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\\' && escapes:
+			escaped, inPath = true, true
+		case r == '\'' || r == '"':
+			quote, inPath = r, true
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			current.WriteRune(r)
+			inPath = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, false
+	}
+	flush()
+	if len(paths) == 0 {
+		return nil, false
+	}
+
+	for i, path := range paths {
+		if u, err := url.Parse(path); err == nil && u.Scheme == "file" {
+			path = u.Path
+			if u.Host != "" && u.Host != "localhost" {
+				path = "//" + u.Host + path
+			}
+			path = filepath.FromSlash(path)
+		}
+		path = filepathext.Normalize(path)
+		paths[i] = path
+		if !filepath.IsAbs(path) {
+			return nil, false
+		}
+		if _, err := os.Stat(path); err != nil {
+			return nil, false
+		}
+	}
+	return paths, true
+}
+
+// isImage reports whether the file at path is an image that can be attached.
+func isImage(path string) bool {
+	return slices.Contains(filepicker.AllowedTypes, strings.ToLower(filepath.Ext(path)))
+}
+
+// readImage reads the image at path to attach it.
+func readImage(path string) (message.Attachment, error) {
+	tooBig, err := filepicker.IsFileTooBig(path, filepicker.MaxAttachmentSize)
+	if err != nil {
+		return message.Attachment{}, err
+	}
+	if tooBig {
+		return message.Attachment{}, fmt.Errorf("%s is too large, max 5MB", filepath.Base(path))
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return message.Attachment{}, err
+	}
+	mimeBufferSize := min(512, len(content))
+	mimeType := http.DetectContentType(content[:mimeBufferSize])
+	return message.Attachment{FilePath: path, FileName: filepath.Base(path), MimeType: mimeType, Content: content}, nil
+}
+
+// mentionPath returns how a file is mentioned in the prompt: relative to the
+// working directory cwd when it's in it, and quoted with backticks when it
+// has spaces.
+func mentionPath(path, cwd string) string {
+	if rel, err := filepath.Rel(cwd, path); err == nil && !strings.HasPrefix(rel, "..") {
+		path = filepath.ToSlash(rel)
+	}
+	if strings.ContainsFunc(path, unicode.IsSpace) {
+		return "`" + path + "`"
+	}
+	return path
+}
+
+// dropFiles attaches the images dropped on the window and mentions the other
+// files, or mentions all of them with the file_drop option set to mention.
+func (m *editorCmp) dropFiles(paths []string) tea.Cmd {
+	mentionAll := m.app.Config().Options.TUI.FileDrop == config.FileDropMention
+	cwd, _ := os.Getwd()
+	var mentions []string
+	var cmds []tea.Cmd
+	for _, path := range paths {
+		if mentionAll || !isImage(path) {
+			mentions = append(mentions, mentionPath(path, cwd))
+			continue
+		}
+		a, err := readImage(path)
+		if err != nil {
+			cmds = append(cmds, util.ReportError(err))
+			continue
+		}
+		cmds = append(cmds, util.CmdHandler(filepicker.FilePickedMsg{Attachment: a}))
+	}
+	if len(mentions) > 0 {
+		text := strings.Join(mentions, " ") + " "
+		if value := m.textarea.Value(); value != "" && !unicode.IsSpace(rune(value[len(value)-1])) {
+			text = " " + text
+		}
+		m.textarea.InsertString(text)
+	}
+	return tea.Batch(cmds...)
+}
@@ -0,0 +1,53 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDroppedPaths(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "notes.md")
+	spaced := filepath.Join(dir, "my shot.png")
+	for _, path := range []string{plain, spaced} {
+		require.NoError(t, os.WriteFile(path, nil, 0o600))
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		paths   []string
+	}{
+		{name: "single path", content: plain, paths: []string{plain}},
+		{name: "escaped spaces", content: filepath.Join(dir, `my\ shot.png`) + " " + plain, paths: []string{spaced, plain}},
+		{name: "quoted", content: "'" + spaced + "'\n\"" + plain + "\"\n", paths: []string{spaced, plain}},
+		{name: "file url", content: "file://" + filepath.ToSlash(filepath.Join(dir, "notes.md")), paths: []string{plain}},
+		{name: "relative path", content: "notes.md"},
+		{name: "missing file", content: plain + " " + filepath.Join(dir, "gone.md")},
+		{name: "text", content: "fix the tests"},
+		{name: "unclosed quote", content: "'" + plain},
+		{name: "empty", content: "  "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			paths, ok := droppedPaths(tt.content, true)
+			require.Equal(t, tt.paths != nil, ok)
+			require.Equal(t, tt.paths, paths)
+		})
+	}
+}
+
+func TestMentionPath(t *testing.T) {
+	t.Parallel()
+
+	cwd := filepath.FromSlash("/work/project")
+	require.Equal(t, "internal/main.go", mentionPath(filepath.FromSlash("/work/project/internal/main.go"), cwd))
+	require.Equal(t, "`docs/my notes.md`", mentionPath(filepath.FromSlash("/work/project/docs/my notes.md"), cwd))
+	require.Equal(t, filepath.FromSlash("/tmp/log.txt"), mentionPath(filepath.FromSlash("/tmp/log.txt"), cwd))
+}
@@ -1,23 +1,28 @@
 package editor
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"log/slog"
 	"math/rand"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"slices"
 	"strings"
+	"time"
 	"unicode"
 
 	"charm.land/bubbles/v2/key"
 	"charm.land/bubbles/v2/textarea"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/agent"
 	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/drafts"
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/session"
@@ -25,6 +30,7 @@ import (
 	"github.com/charmbracelet/crush/internal/tui/components/completions"
 	"github.com/charmbracelet/crush/internal/tui/components/core/layout"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/attachment"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/commands"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/filepicker"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/quit"
@@ -57,42 +63,72 @@ type editorCmp struct {
 	session            session.Session
 	textarea           textarea.Model
 	attachments        []message.Attachment
-	deleteMode         bool
+	attachmentMode     bool
+	selectedAttachment int
 	readyPlaceholder   string
 	workingPlaceholder string
 
+	// estimate is the footer estimating the cost of the prompt, computed
+	// for estimateKey.
+	estimateKey string
+	estimate    string
+
 	keyMap EditorKeyMap
 
+	// Prompt history, while going through it with up and down: the prompts,
+	// the position of the one shown and what was being written before.
+	historyEntries []string
+	historyIndex   int
+	historyDraft   string
+
+	// Pasted text collapsed into placeholders, the n-th one standing for
+	// pastes[n-1].
+	pastes []string
+
+	// Draft autosave: what the editor had when a save was last scheduled and
+	// the number of that save, as only the latest one is done.
+	draftText        string
+	draftAttachments int
+	draftSeq         int
+
 	// File path completions
 	currentQuery          string
 	completionsStartIndex int
 	isCompletionsOpen     bool
 }
 
-var DeleteKeyMaps = DeleteAttachmentKeyMaps{
-	AttachmentDeleteMode: key.NewBinding(
-		key.WithKeys("ctrl+r"),
-		key.WithHelp("ctrl+r+{i}", "delete attachment at index i"),
-	),
-	Escape: key.NewBinding(
-		key.WithKeys("esc", "alt+esc"),
-		key.WithHelp("esc", "cancel delete mode"),
-	),
-	DeleteAllAttachments: key.NewBinding(
-		key.WithKeys("r"),
-		key.WithHelp("ctrl+r+r", "delete all attachments"),
-	),
-}
-
 const (
 	maxAttachments = 5
 	maxFileResults = 25
+
+	// draftSaveDelay is how long the editor waits for typing to pause before
+	// saving the draft.
+	draftSaveDelay = 500 * time.Millisecond
 )
 
 type OpenEditorMsg struct {
 	Text string
 }
 
+// SaveDraftMsg saves the draft of the editor, unless it changed again since
+// the save was scheduled.
+type SaveDraftMsg struct {
+	seq int
+}
+
+// AttachFilesMsg adds files to the prompt being written, as if they were
+// dropped on the window: images are attached and other files mentioned.
+type AttachFilesMsg struct {
+	Paths []string
+}
+
+// InsertTextMsg adds text to the prompt being written, after what is already
+// there, or before it when Prepend is set.
+type InsertTextMsg struct {
+	Text    string
+	Prepend bool
+}
+
 func (m *editorCmp) openEditor(value string) tea.Cmd {
 	editor := os.Getenv("EDITOR")
 	if editor == "" {
@@ -135,12 +171,13 @@ func (m *editorCmp) openEditor(value string) tea.Cmd {
 }
 
 func (m *editorCmp) Init() tea.Cmd {
-	return nil
+	return m.restoreDraft()
 }
 
 func (m *editorCmp) send() tea.Cmd {
-	value := m.textarea.Value()
+	value := m.value()
 	value = strings.TrimSpace(value)
+	m.pastes = nil
 
 	switch value {
 	case "exit", "quit":
@@ -148,6 +185,21 @@ func (m *editorCmp) send() tea.Cmd {
 		return util.CmdHandler(dialogs.OpenDialogMsg{Model: quit.NewQuitDialog()})
 	}
 
+	m.historyEntries = nil
+	if err := m.app.Prompts.Add(value); err != nil {
+		slog.Warn("Failed to save prompt history", "error", err)
+	}
+
+	// Prompts can start with directives like !model, everything else
+	// starting with ! is a shell command.
+	if command, ok := strings.CutPrefix(value, "!"); ok && !agent.HasPromptOverrides(value) {
+		m.textarea.Reset()
+		if command = strings.TrimSpace(command); command == "" {
+			return nil
+		}
+		return util.CmdHandler(chat.RunShellMsg{Command: command})
+	}
+
 	m.textarea.Reset()
 	attachments := m.attachments
 
@@ -173,6 +225,11 @@ func (m *editorCmp) repositionCompletions() tea.Msg {
 }
 
 func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	u, cmd := m.update(msg)
+	return u, tea.Batch(cmd, m.scheduleDraftSave())
+}
+
+func (m *editorCmp) update(msg tea.Msg) (util.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	var cmds []tea.Cmd
 	switch msg := msg.(type) {
@@ -215,11 +272,49 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		if m.app.AgentCoordinator.IsSessionBusy(m.session.ID) {
 			return m, util.ReportWarn("Agent is working, please wait...")
 		}
-		return m, m.openEditor(m.textarea.Value())
+		return m, m.openEditor(m.value())
 	case OpenEditorMsg:
+		m.pastes = nil
 		m.textarea.SetValue(msg.Text)
 		m.textarea.MoveToEnd()
+	case SaveDraftMsg:
+		if msg.seq == m.draftSeq {
+			m.saveDraft()
+		}
+		return m, nil
+	case commands.DiscardDraftMsg:
+		m.textarea.Reset()
+		m.pastes = nil
+		m.attachments = nil
+		m.attachmentMode = false
+		if err := m.app.Drafts.Delete(m.session.ID); err != nil {
+			return m, util.ReportError(err)
+		}
+		return m, util.ReportInfo("Draft discarded")
+	case AttachFilesMsg:
+		return m, m.dropFiles(msg.Paths)
+	case InsertTextMsg:
+		if msg.Prepend {
+			m.textarea.SetValue(msg.Text + strings.TrimLeft(m.textarea.Value(), " \n"))
+			m.textarea.MoveToEnd()
+			return m, m.Focus()
+		}
+		value := strings.TrimRight(m.textarea.Value(), "\n")
+		if value != "" {
+			value += "\n\n"
+		}
+		m.textarea.SetValue(value + msg.Text)
+		m.textarea.MoveToEnd()
+		return m, m.Focus()
 	case tea.PasteMsg:
+		if paths, ok := droppedPaths(msg.Content, runtime.GOOS != "windows"); ok {
+			return m, m.dropFiles(paths)
+		}
+		if pasted := strings.ReplaceAll(msg.Content, "\r\n", "\n"); strings.Count(pasted, "\n") >= pasteCollapseLines {
+			m.pastes = append(m.pastes, pasted)
+			m.textarea.InsertString(pastePlaceholder(len(m.pastes), pasted))
+			return m, nil
+		}
 		path := strings.ReplaceAll(msg.Content, "\\ ", " ")
 		// try to get an image
 		path, err := filepath.Abs(strings.TrimSpace(path))
@@ -238,23 +333,13 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			m.textarea, cmd = m.textarea.Update(msg)
 			return m, cmd
 		}
-		tooBig, _ := filepicker.IsFileTooBig(path, filepicker.MaxAttachmentSize)
-		if tooBig {
-			m.textarea, cmd = m.textarea.Update(msg)
-			return m, cmd
-		}
-
-		content, err := os.ReadFile(path)
+		image, err := readImage(path)
 		if err != nil {
 			m.textarea, cmd = m.textarea.Update(msg)
 			return m, cmd
 		}
-		mimeBufferSize := min(512, len(content))
-		mimeType := http.DetectContentType(content[:mimeBufferSize])
-		fileName := filepath.Base(path)
-		attachment := message.Attachment{FilePath: path, FileName: fileName, MimeType: mimeType, Content: content}
 		return m, util.CmdHandler(filepicker.FilePickedMsg{
-			Attachment: attachment,
+			Attachment: image,
 		})
 
 	case commands.ToggleYoloModeMsg:
@@ -280,25 +365,59 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		case m.isCompletionsOpen && curIdx <= m.completionsStartIndex:
 			cmds = append(cmds, util.CmdHandler(completions.CloseCompletionsMsg{}))
 		}
-		if key.Matches(msg, DeleteKeyMaps.AttachmentDeleteMode) {
-			m.deleteMode = true
+		if key.Matches(msg, m.keyMap.SearchPrompts) {
+			return m, util.CmdHandler(commands.OpenPromptHistoryMsg{})
+		}
+		if key.Matches(msg, AttachmentsKeyMaps.AttachmentDeleteMode) {
+			if len(m.attachments) == 0 {
+				return m, nil
+			}
+			m.attachmentMode = true
+			m.selectedAttachment = len(m.attachments) - 1
+			return m, nil
+		}
+		if m.attachmentMode {
+			switch {
+			case key.Matches(msg, AttachmentsKeyMaps.NextAttachment):
+				m.selectedAttachment = min(m.selectedAttachment+1, len(m.attachments)-1)
+				return m, nil
+			case key.Matches(msg, AttachmentsKeyMaps.PreviousAttachment):
+				m.selectedAttachment = max(m.selectedAttachment-1, 0)
+				return m, nil
+			case key.Matches(msg, AttachmentsKeyMaps.RemoveAttachment):
+				m.removeAttachment(m.selectedAttachment)
+				return m, nil
+			case key.Matches(msg, AttachmentsKeyMaps.PreviewAttachment):
+				return m, util.CmdHandler(dialogs.OpenDialogMsg{
+					Model: attachment.NewPreviewDialog(m.attachments[m.selectedAttachment]),
+				})
+			}
+		}
+		if key.Matches(msg, m.keyMap.ExpandPastes) && len(m.pastes) > 0 {
+			m.expandPastes()
 			return m, nil
 		}
-		if key.Matches(msg, DeleteKeyMaps.DeleteAllAttachments) && m.deleteMode {
-			m.deleteMode = false
+		if !m.isCompletionsOpen && key.Matches(msg, m.keyMap.PreviousPrompt) && m.textarea.Line() == 0 {
+			if m.previousPrompt() {
+				return m, nil
+			}
+		}
+		if !m.isCompletionsOpen && key.Matches(msg, m.keyMap.NextPrompt) && m.textarea.Line() == m.textarea.LineCount()-1 {
+			if m.nextPrompt() {
+				return m, nil
+			}
+		}
+		if key.Matches(msg, AttachmentsKeyMaps.DeleteAllAttachments) && m.attachmentMode {
+			m.attachmentMode = false
 			m.attachments = nil
 			return m, nil
 		}
 		rune := msg.Code
-		if m.deleteMode && unicode.IsDigit(rune) {
+		if m.attachmentMode && unicode.IsDigit(rune) {
 			num := int(rune - '0')
-			m.deleteMode = false
+			m.attachmentMode = false
 			if num < 10 && len(m.attachments) > num {
-				if num == 0 {
-					m.attachments = m.attachments[num+1:]
-				} else {
-					m.attachments = slices.Delete(m.attachments, num, num+1)
-				}
+				m.removeAttachment(num)
 				return m, nil
 			}
 		}
@@ -306,10 +425,10 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			if m.app.AgentCoordinator.IsSessionBusy(m.session.ID) {
 				return m, util.ReportWarn("Agent is working, please wait...")
 			}
-			return m, m.openEditor(m.textarea.Value())
+			return m, m.openEditor(m.value())
 		}
-		if key.Matches(msg, DeleteKeyMaps.Escape) {
-			m.deleteMode = false
+		if key.Matches(msg, AttachmentsKeyMaps.Escape) {
+			m.attachmentMode = false
 			return m, nil
 		}
 		if key.Matches(msg, m.keyMap.Newline) {
@@ -370,6 +489,136 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// removeAttachment removes the i-th attachment, leaving attachment mode once
+// there are none left.
+func (m *editorCmp) removeAttachment(i int) {
+	if i < 0 || i >= len(m.attachments) {
+		return
+	}
+	m.attachments = slices.Delete(slices.Clone(m.attachments), i, i+1)
+	m.selectedAttachment = min(m.selectedAttachment, len(m.attachments)-1)
+	if len(m.attachments) == 0 {
+		m.attachmentMode = false
+	}
+}
+
+// attachmentWarning returns why the attachments can't be sent as they are
+// with the current model, or an empty string when they can.
+func (m *editorCmp) attachmentWarning() string {
+	if len(m.attachments) == 0 || m.app.AgentCoordinator == nil {
+		return ""
+	}
+	model := m.app.AgentCoordinator.Model()
+	providerCfg, ok := m.app.Config().Providers.Get(model.ModelCfg.Provider)
+	if !ok {
+		return ""
+	}
+	name := cmp.Or(providerCfg.Name, providerCfg.ID)
+	limits := agent.ProviderAttachmentLimits(providerCfg.Type)
+	return attachmentWarning(m.attachments, model.CatwalkCfg.SupportsImages, name, limits)
+}
+
+// value returns the prompt in the editor, with the pasted text it stands for
+// in place of the placeholders.
+func (m *editorCmp) value() string {
+	return expandPastes(m.textarea.Value(), m.pastes)
+}
+
+// expandPastes puts the pasted text in the editor in place of the
+// placeholders.
+func (m *editorCmp) expandPastes() {
+	m.textarea.SetValue(m.value())
+	m.textarea.MoveToEnd()
+	m.pastes = nil
+}
+
+// previousPrompt shows the prompt submitted before the one shown, keeping
+// what was being written to come back to. It reports whether there was one.
+func (m *editorCmp) previousPrompt() bool {
+	if m.historyEntries == nil {
+		entries, err := m.app.Prompts.Entries()
+		if err != nil || len(entries) == 0 {
+			return false
+		}
+		m.historyEntries = entries
+		m.historyIndex = len(entries)
+		m.historyDraft = m.textarea.Value()
+	}
+	if m.historyIndex == 0 {
+		return false
+	}
+	m.historyIndex--
+	m.textarea.SetValue(m.historyEntries[m.historyIndex])
+	// Up again goes on to the prompt before.
+	m.textarea.MoveToBegin()
+	return true
+}
+
+// nextPrompt shows the prompt submitted after the one shown, or what was being
+// written after the last one. It reports whether a prompt of the history was
+// shown.
+func (m *editorCmp) nextPrompt() bool {
+	if m.historyEntries == nil {
+		return false
+	}
+	m.historyIndex++
+	if m.historyIndex >= len(m.historyEntries) {
+		m.textarea.SetValue(m.historyDraft)
+		m.historyEntries = nil
+	} else {
+		m.textarea.SetValue(m.historyEntries[m.historyIndex])
+	}
+	m.textarea.MoveToEnd()
+	return true
+}
+
+// scheduleDraftSave saves the draft after a pause, if it changed since the
+// last save was scheduled.
+func (m *editorCmp) scheduleDraftSave() tea.Cmd {
+	if m.textarea.Value() == m.draftText && len(m.attachments) == m.draftAttachments {
+		return nil
+	}
+	m.draftText = m.textarea.Value()
+	m.draftAttachments = len(m.attachments)
+	m.draftSeq++
+	seq := m.draftSeq
+	return tea.Tick(draftSaveDelay, func(time.Time) tea.Msg {
+		return SaveDraftMsg{seq: seq}
+	})
+}
+
+// saveDraft saves the draft of the session, or deletes it if the editor is
+// empty.
+func (m *editorCmp) saveDraft() {
+	draft := drafts.Draft{Text: m.value(), Attachments: m.attachments}
+	if err := m.app.Drafts.Save(m.session.ID, draft); err != nil {
+		slog.Warn("Failed to save draft", "error", err)
+	}
+}
+
+// restoreDraft puts the saved draft of the session in the editor, or empties
+// it when there is none.
+func (m *editorCmp) restoreDraft() tea.Cmd {
+	draft, err := m.app.Drafts.Load(m.session.ID)
+	if err != nil {
+		slog.Warn("Failed to restore draft", "error", err)
+	}
+	m.pastes = nil
+	m.textarea.SetValue(draft.Text)
+	m.textarea.MoveToEnd()
+	m.attachments = draft.Attachments
+	m.attachmentMode = false
+	m.historyEntries = nil
+	// Cancel the pending save, which was for the previous session.
+	m.draftText = draft.Text
+	m.draftAttachments = len(draft.Attachments)
+	m.draftSeq++
+	if draft.IsEmpty() {
+		return nil
+	}
+	return util.ReportInfo("Restored unsent draft, use Discard Draft in the commands to drop it")
+}
+
 func (m *editorCmp) setEditorPrompt() {
 	if m.app.Permissions.SkipRequests() {
 		m.textarea.SetPromptFunc(4, yoloPromptFunc)
@@ -429,21 +678,76 @@ func (m *editorCmp) View() string {
 	if m.app.Permissions.SkipRequests() {
 		m.textarea.Placeholder = "Yolo mode!"
 	}
+	footer := t.S().Base.
+		Foreground(t.FgSubtle).
+		Width(m.width - 2).
+		AlignHorizontal(lipgloss.Right).
+		Render(m.estimateFooter())
+	if warning := m.attachmentWarning(); warning != "" {
+		estimate := t.S().Subtle.Render(m.estimateFooter())
+		warning = t.S().Warning.Render(styles.WarningIcon + " " + warning)
+		gap := max(1, m.width-2-lipgloss.Width(warning)-lipgloss.Width(estimate))
+		footer = t.S().Base.MaxWidth(m.width - 2).Render(warning + strings.Repeat(" ", gap) + estimate)
+	}
 	if len(m.attachments) == 0 {
-		content := t.S().Base.Padding(1).Render(
-			m.textarea.View(),
+		content := t.S().Base.Padding(1, 1, 0, 1).Render(
+			lipgloss.JoinVertical(lipgloss.Top,
+				m.textarea.View(),
+				footer,
+			),
 		)
 		return content
 	}
-	content := t.S().Base.Padding(0, 1, 1, 1).Render(
+	content := t.S().Base.Padding(0, 1).Render(
 		lipgloss.JoinVertical(lipgloss.Top,
 			m.attachmentsContent(),
 			m.textarea.View(),
+			footer,
 		),
 	)
 	return content
 }
 
+// estimateFooter estimates the tokens and cost of sending the prompt and
+// attachments with the current model, recomputing only when they change.
+func (m *editorCmp) estimateFooter() string {
+	value := m.textarea.Value()
+	if strings.TrimSpace(value) == "" && len(m.attachments) == 0 {
+		return ""
+	}
+	var key strings.Builder
+	for _, a := range m.attachments {
+		key.WriteString(a.FilePath + "\x00")
+	}
+	// Pastes don't change once made, so counting them is enough.
+	fmt.Fprintf(&key, "%d\x00", len(m.pastes))
+	key.WriteString(value)
+	if key.String() == m.estimateKey {
+		return m.estimate
+	}
+	m.estimateKey = key.String()
+	value = m.value()
+
+	var model catwalk.Model
+	if m.app.AgentCoordinator != nil {
+		model = m.app.AgentCoordinator.Model().CatwalkCfg
+	}
+	tokens, cost := agent.EstimatePrompt(model, value, m.attachments)
+	m.estimate = fmt.Sprintf("~%d tokens", tokens)
+	if tokens == 1 {
+		m.estimate = "~1 token"
+	}
+	switch {
+	case cost >= 0.01:
+		m.estimate += fmt.Sprintf(" · $%.2f", cost)
+	case cost >= 0.0001:
+		m.estimate += fmt.Sprintf(" · $%.4f", cost)
+	case cost > 0:
+		m.estimate += " · <$0.0001"
+	}
+	return m.estimate
+}
+
 func (m *editorCmp) SetSize(width, height int) tea.Cmd {
 	m.width = width
 	m.height = height
@@ -463,20 +767,28 @@ func (m *editorCmp) attachmentsContent() string {
 		MarginLeft(1).
 		Background(t.FgMuted).
 		Foreground(t.FgBase)
-	for i, attachment := range m.attachments {
+	selectedStyles := attachmentStyles.
+		Background(t.Primary).
+		Foreground(t.White)
+	for i, a := range m.attachments {
 		var filename string
-		if len(attachment.FileName) > 10 {
-			filename = fmt.Sprintf(" %s %s...", styles.DocumentIcon, attachment.FileName[0:7])
+		if len(a.FileName) > 10 {
+			filename = fmt.Sprintf(" %s %s...", styles.DocumentIcon, a.FileName[0:7])
 		} else {
-			filename = fmt.Sprintf(" %s %s", styles.DocumentIcon, attachment.FileName)
+			filename = fmt.Sprintf(" %s %s", styles.DocumentIcon, a.FileName)
 		}
-		if m.deleteMode {
+		filename += fmt.Sprintf(" · %s %s ", attachmentType(a), attachment.FormatSize(int64(len(a.Content))))
+		if m.attachmentMode {
 			filename = fmt.Sprintf("%d%s", i, filename)
 		}
-		styledAttachments = append(styledAttachments, attachmentStyles.Render(filename))
+		style := attachmentStyles
+		if m.attachmentMode && i == m.selectedAttachment {
+			style = selectedStyles
+		}
+		styledAttachments = append(styledAttachments, style.Render(filename))
 	}
 	content := lipgloss.JoinHorizontal(lipgloss.Left, styledAttachments...)
-	return content
+	return t.S().Base.MaxWidth(m.width - 2).Render(content)
 }
 
 func (m *editorCmp) SetPosition(x, y int) tea.Cmd {
@@ -488,16 +800,29 @@ func (m *editorCmp) SetPosition(x, y int) tea.Cmd {
 func (m *editorCmp) startCompletions() tea.Msg {
 	ls := m.app.Config().Options.TUI.Completions
 	depth, limit := ls.Limits()
-	files, _, _ := fsext.ListDirectory(".", nil, depth, limit)
+	files, _, _ := fsext.ListDirectory(".", nil, depth, limit, false)
+	known := make(map[string]bool, len(files))
+	for i, file := range files {
+		files[i] = strings.TrimPrefix(file, "./")
+		known[files[i]] = true
+	}
+
+	ctx := context.Background()
+	ranks := rankFiles(m.fileUses(ctx, known), changedFiles(ctx), time.Now())
+	// The list is shown bottom up, so the highest ranked files go last.
 	slices.Sort(files)
+	slices.SortStableFunc(files, func(a, b string) int {
+		return ranks[a] - ranks[b]
+	})
+
 	completionItems := make([]completions.Completion, 0, len(files))
 	for _, file := range files {
-		file = strings.TrimPrefix(file, "./")
 		completionItems = append(completionItems, completions.Completion{
 			Title: file,
 			Value: FileCompletionItem{
 				Path: file,
 			},
+			Rank: ranks[file],
 		})
 	}
 
@@ -534,8 +859,14 @@ func (c *editorCmp) Bindings() []key.Binding {
 // TODO: most likely we do not need to have the session here
 // we need to move some functionality to the page level
 func (c *editorCmp) SetSession(session session.Session) tea.Cmd {
+	if session.ID == c.session.ID {
+		c.session = session
+		return nil
+	}
+	// Each session has its own draft.
+	c.saveDraft()
 	c.session = session
-	return nil
+	return c.restoreDraft()
 }
 
 func (c *editorCmp) IsCompletionsOpen() bool {
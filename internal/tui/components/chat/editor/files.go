@@ -0,0 +1,183 @@
+package editor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// changedFileRank is the rank of files with uncommitted changes.
+	changedFileRank = 10
+	// maxFileRank keeps the rank from burying better matches; fuzzy match
+	// scores are in the same range.
+	maxFileRank = 30
+	gitTimeout  = 2 * time.Second
+)
+
+// fileUse is a file that was mentioned or edited in the session at a given
+// time.
+type fileUse struct {
+	path string
+	at   time.Time
+}
+
+// rankFiles returns the rank of files by frecency: each use counts more
+// the more recent it is, and files with uncommitted changes get a flat
+// bonus.
+func rankFiles(uses []fileUse, changed []string, now time.Time) map[string]int {
+	ranks := make(map[string]int)
+	for _, path := range changed {
+		ranks[path] += changedFileRank
+	}
+	for _, use := range uses {
+		switch age := now.Sub(use.at); {
+		case age < time.Hour:
+			ranks[use.path] += 8
+		case age < 24*time.Hour:
+			ranks[use.path] += 4
+		case age < 7*24*time.Hour:
+			ranks[use.path] += 2
+		default:
+			ranks[use.path]++
+		}
+	}
+	for path, rank := range ranks {
+		ranks[path] = min(rank, maxFileRank)
+	}
+	return ranks
+}
+
+// fileUses returns the files mentioned in the user's messages and edited by
+// the agent in the current session, relative to the working directory.
+func (m *editorCmp) fileUses(ctx context.Context, files map[string]bool) []fileUse {
+	if m.session.ID == "" {
+		return nil
+	}
+	cwd, _ := os.Getwd()
+	var uses []fileUse
+
+	msgs, _ := m.app.Messages.List(ctx, m.session.ID)
+	for _, msg := range msgs {
+		for word := range strings.FieldsSeq(msg.Content().Text) {
+			word = strings.TrimPrefix(strings.Trim(word, "`'\",.:;()[]"), "@")
+			if files[word] {
+				uses = append(uses, fileUse{path: word, at: time.Unix(msg.CreatedAt, 0)})
+			}
+		}
+	}
+
+	edited, _ := m.app.History.ListLatestSessionFiles(ctx, m.session.ID)
+	for _, file := range edited {
+		path := file.Path
+		if filepath.IsAbs(path) {
+			rel, err := filepath.Rel(cwd, path)
+			if err != nil {
+				continue
+			}
+			path = filepath.ToSlash(rel)
+		}
+		if files[path] {
+			uses = append(uses, fileUse{path: path, at: time.Unix(file.UpdatedAt, 0)})
+		}
+	}
+	return uses
+}
+
+// changedFiles returns the files with uncommitted changes, relative to the
+// working directory, or nil outside a git repository.
+func changedFiles(ctx context.Context) []string {
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+
+	top, err := exec.CommandContext(ctx, "git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return nil
+	}
+	status, err := exec.CommandContext(ctx, "git", "status", "--porcelain", "-z", "--untracked-files=all").Output()
+	if err != nil {
+		return nil
+	}
+	cwd, _ := os.Getwd()
+	return parseGitStatus(status, strings.TrimSpace(string(top)), cwd)
+}
+
+// parseGitStatus parses `git status --porcelain -z`, whose paths are
+// relative to the repository root, into paths relative to cwd.
+func parseGitStatus(status []byte, top, cwd string) []string {
+	var paths []string
+	entries := bytes.Split(status, []byte{0})
+	for i := 0; i < len(entries); i++ {
+		entry := string(entries[i])
+		if len(entry) < 4 {
+			continue
+		}
+		if entry[0] == 'R' || entry[0] == 'C' {
+			// Renames and copies are followed by the original path.
+			i++
+		}
+		rel, err := filepath.Rel(cwd, filepath.Join(top, entry[3:]))
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+	return paths
+}
+
+// Preview implements [completions.Previewer]. It shows the first lines of a
+// file, or the entries of a directory.
+func (f FileCompletionItem) Preview(width, height int) string {
+	lines := make([]string, 0, height)
+	if strings.HasSuffix(f.Path, "/") {
+		entries, err := os.ReadDir(f.Path)
+		if err != nil {
+			return ""
+		}
+		walker := fsext.NewFastGlobWalker(".", false)
+		for _, entry := range entries {
+			if len(lines) == height {
+				break
+			}
+			if walker.ShouldSkip(filepath.Join(f.Path, entry.Name())) {
+				continue
+			}
+			name := entry.Name()
+			if entry.IsDir() {
+				name += "/"
+			}
+			lines = append(lines, ansi.Truncate(name, width, "…"))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for len(lines) < height && scanner.Scan() {
+		line := scanner.Text()
+		if strings.ContainsRune(line, 0) {
+			return "Binary file"
+		}
+		line = strings.ReplaceAll(line, "\t", "    ")
+		line = strings.Map(func(r rune) rune {
+			if r < 0x20 || r == 0x7f {
+				return ' '
+			}
+			return r
+		}, line)
+		lines = append(lines, ansi.Truncate(line, width, "…"))
+	}
+	return strings.Join(lines, "\n")
+}
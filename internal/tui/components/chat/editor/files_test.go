@@ -0,0 +1,60 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRankFiles(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	ranks := rankFiles([]fileUse{
+		{path: "main.go", at: now.Add(-time.Minute)},
+		{path: "main.go", at: now.Add(-2 * time.Hour)},
+		{path: "old.go", at: now.Add(-30 * 24 * time.Hour)},
+		{path: "hot.go", at: now},
+		{path: "hot.go", at: now},
+		{path: "hot.go", at: now},
+		{path: "hot.go", at: now},
+	}, []string{"main.go", "new.go"}, now)
+
+	require.Equal(t, map[string]int{
+		"main.go": 22,
+		"new.go":  10,
+		"old.go":  1,
+		"hot.go":  30,
+	}, ranks)
+}
+
+func TestParseGitStatus(t *testing.T) {
+	t.Parallel()
+
+	status := []byte(" M internal/app/app.go\x00?? internal/new.go\x00R  internal/b.go\x00internal/a.go\x00 M README.md\x00")
+	require.Equal(t,
+		[]string{"app/app.go", "new.go", "b.go"},
+		parseGitStatus(status, "/repo", "/repo/internal"),
+	)
+}
+
+func TestFilePreview(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n\nfunc main() {\n\tprintln(\"a very long line\")\n}\n"), 0o644))
+
+	require.Equal(t,
+		"package main\n\nfunc main() {\n    println(\"a …",
+		FileCompletionItem{Path: path}.Preview(16, 4),
+	)
+	require.Equal(t, "main.go", FileCompletionItem{Path: dir + "/"}.Preview(16, 4))
+
+	bin := filepath.Join(dir, "bin")
+	require.NoError(t, os.WriteFile(bin, []byte{0x7f, 'E', 'L', 'F', 0}, 0o644))
+	require.Equal(t, "Binary file", FileCompletionItem{Path: bin}.Preview(16, 4))
+}
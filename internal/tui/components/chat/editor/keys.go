@@ -5,10 +5,14 @@ import (
 )
 
 type EditorKeyMap struct {
-	AddFile     key.Binding
-	SendMessage key.Binding
-	OpenEditor  key.Binding
-	Newline     key.Binding
+	AddFile        key.Binding
+	SendMessage    key.Binding
+	OpenEditor     key.Binding
+	Newline        key.Binding
+	PreviousPrompt key.Binding
+	NextPrompt     key.Binding
+	SearchPrompts  key.Binding
+	ExpandPastes   key.Binding
 }
 
 func DefaultEditorKeyMap() EditorKeyMap {
@@ -32,6 +36,22 @@ func DefaultEditorKeyMap() EditorKeyMap {
 			// to reflect that.
 			key.WithHelp("ctrl+j", "newline"),
 		),
+		PreviousPrompt: key.NewBinding(
+			key.WithKeys("up"),
+			key.WithHelp("↑", "previous prompt"),
+		),
+		NextPrompt: key.NewBinding(
+			key.WithKeys("down"),
+			key.WithHelp("↓", "next prompt"),
+		),
+		SearchPrompts: key.NewBinding(
+			key.WithKeys("alt+r"),
+			key.WithHelp("alt+r", "search prompts"),
+		),
+		ExpandPastes: key.NewBinding(
+			key.WithKeys("alt+e"),
+			key.WithHelp("alt+e", "expand pasted text"),
+		),
 	}
 }
 
@@ -42,7 +62,13 @@ func (k EditorKeyMap) KeyBindings() []key.Binding {
 		k.SendMessage,
 		k.OpenEditor,
 		k.Newline,
+		k.PreviousPrompt,
+		k.SearchPrompts,
+		k.ExpandPastes,
 		AttachmentsKeyMaps.AttachmentDeleteMode,
+		AttachmentsKeyMaps.NextAttachment,
+		AttachmentsKeyMaps.PreviewAttachment,
+		AttachmentsKeyMaps.RemoveAttachment,
 		AttachmentsKeyMaps.DeleteAllAttachments,
 		AttachmentsKeyMaps.Escape,
 	}
@@ -52,20 +78,40 @@ type DeleteAttachmentKeyMaps struct {
 	AttachmentDeleteMode key.Binding
 	Escape               key.Binding
 	DeleteAllAttachments key.Binding
+	NextAttachment       key.Binding
+	PreviousAttachment   key.Binding
+	RemoveAttachment     key.Binding
+	PreviewAttachment    key.Binding
 }
 
 // TODO: update this to use the new keymap concepts
 var AttachmentsKeyMaps = DeleteAttachmentKeyMaps{
 	AttachmentDeleteMode: key.NewBinding(
 		key.WithKeys("ctrl+r"),
-		key.WithHelp("ctrl+r+{i}", "delete attachment at index i"),
+		key.WithHelp("ctrl+r", "manage attachments"),
 	),
 	Escape: key.NewBinding(
 		key.WithKeys("esc", "alt+esc"),
-		key.WithHelp("esc", "cancel delete mode"),
+		key.WithHelp("esc", "done with attachments"),
 	),
 	DeleteAllAttachments: key.NewBinding(
 		key.WithKeys("r"),
 		key.WithHelp("ctrl+r+r", "delete all attachments"),
 	),
+	NextAttachment: key.NewBinding(
+		key.WithKeys("right", "l"),
+		key.WithHelp("←/→", "choose attachment"),
+	),
+	PreviousAttachment: key.NewBinding(
+		key.WithKeys("left", "h"),
+		key.WithHelp("←/→", "choose attachment"),
+	),
+	RemoveAttachment: key.NewBinding(
+		key.WithKeys("d", "backspace", "delete"),
+		key.WithHelp("d", "remove attachment"),
+	),
+	PreviewAttachment: key.NewBinding(
+		key.WithKeys("enter", "p"),
+		key.WithHelp("enter", "preview attachment"),
+	),
 }
@@ -0,0 +1,31 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// pasteCollapseLines is how many lines a paste can have before it is
+// collapsed into a placeholder, as the editor gets slow with long prompts.
+const pasteCollapseLines = 10
+
+// pastePlaceholder returns what stands in the editor for the n-th paste,
+// counting from one.
+func pastePlaceholder(n int, content string) string {
+	return fmt.Sprintf("[Pasted #%d · %d lines]", n, strings.Count(content, "\n")+1)
+}
+
+// expandPastes replaces the placeholders in value with what was pasted.
+// Placeholders that were deleted stay deleted.
+func expandPastes(value string, pastes []string) string {
+	if len(pastes) == 0 {
+		return value
+	}
+	pairs := make([]string, 0, 2*len(pastes))
+	for i, content := range pastes {
+		pairs = append(pairs, pastePlaceholder(i+1, content), content)
+	}
+	// A single pass, so that pasted text that looks like a placeholder is
+	// left alone.
+	return strings.NewReplacer(pairs...).Replace(value)
+}
@@ -0,0 +1,22 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandPastes(t *testing.T) {
+	t.Parallel()
+
+	first := "a\nb\nc"
+	second := "literal [Pasted #1 · 3 lines]\nx"
+	pastes := []string{first, second}
+	require.Equal(t, "[Pasted #1 · 3 lines]", pastePlaceholder(1, first))
+
+	value := "look at " + pastePlaceholder(1, first) + " and " + pastePlaceholder(2, second)
+	require.Equal(t, "look at a\nb\nc and "+second, expandPastes(value, pastes))
+
+	require.Equal(t, "only "+second, expandPastes("only "+pastePlaceholder(2, second), pastes))
+	require.Equal(t, "nothing pasted", expandPastes("nothing pasted", nil))
+}
@@ -0,0 +1,515 @@
+package filetree
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/tui/components/core/layout"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// FileTree shows the working directory as a tree next to the chat, marking
+// the files the agent created or modified in the session.
+type FileTree interface {
+	util.Model
+	layout.Sizeable
+	layout.Focusable
+	layout.Help
+	SetSession(session session.Session) tea.Cmd
+}
+
+// OpenFileMsg shows the file at Path in the file view.
+type OpenFileMsg struct {
+	Path string
+}
+
+// AttachFileMsg adds the file at Path to the prompt being written.
+type AttachFileMsg struct {
+	Path string
+}
+
+// change is what the agent did to a file in the session.
+type change int
+
+const (
+	unchanged change = iota
+	modified
+	created
+)
+
+// dirLoadedMsg carries the entries of a directory read from disk.
+type dirLoadedMsg struct {
+	dir     string
+	entries []entry
+	err     error
+}
+
+// changesLoadedMsg carries the files the agent changed in a session.
+type changesLoadedMsg struct {
+	sessionID string
+	changes   map[string]change
+}
+
+type entry struct {
+	path  string
+	name  string
+	isDir bool
+}
+
+// row is an entry shown in the tree, at depth levels below the root.
+type row struct {
+	entry
+	depth int
+}
+
+type KeyMap struct {
+	Up       key.Binding
+	Down     key.Binding
+	Expand   key.Binding
+	Collapse key.Binding
+	Open     key.Binding
+	Attach   key.Binding
+	Refresh  key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Up: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "up"),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "down"),
+		),
+		Expand: key.NewBinding(
+			key.WithKeys("right", "l"),
+			key.WithHelp("→/l", "expand"),
+		),
+		Collapse: key.NewBinding(
+			key.WithKeys("left", "h"),
+			key.WithHelp("←/h", "collapse"),
+		),
+		Open: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "view"),
+		),
+		Attach: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "attach"),
+		),
+		Refresh: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "refresh"),
+		),
+	}
+}
+
+type fileTreeCmp struct {
+	width, height int
+	root          string
+	history       history.Service
+	session       session.Session
+	focused       bool
+	keyMap        KeyMap
+
+	// children are the entries of the directories read so far, and
+	// expanded the directories whose entries are shown.
+	children map[string][]entry
+	expanded map[string]bool
+	errs     map[string]error
+	rows     []row
+	cursor   int
+	offset   int
+
+	changes map[string]change
+}
+
+func New(history history.Service, root string) FileTree {
+	return &fileTreeCmp{
+		root:     root,
+		history:  history,
+		keyMap:   DefaultKeyMap(),
+		children: make(map[string][]entry),
+		expanded: map[string]bool{root: true},
+		errs:     make(map[string]error),
+		changes:  make(map[string]change),
+	}
+}
+
+func (m *fileTreeCmp) Init() tea.Cmd {
+	return loadDir(m.root, m.root)
+}
+
+func (m *fileTreeCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case dirLoadedMsg:
+		m.children[msg.dir] = msg.entries
+		m.errs[msg.dir] = msg.err
+		m.rebuild()
+		return m, nil
+	case changesLoadedMsg:
+		if msg.sessionID != m.session.ID {
+			return m, nil
+		}
+		m.changes = msg.changes
+		return m, nil
+	case pubsub.Event[history.File]:
+		file := msg.Payload
+		if m.session.ID == "" || file.SessionID != m.session.ID {
+			return m, nil
+		}
+		if _, ok := m.changes[file.Path]; !ok {
+			m.changes[file.Path] = changeOf(file)
+		}
+		// The file may be new, so read its directory again.
+		if dir := filepath.Dir(file.Path); m.children[dir] != nil {
+			return m, loadDir(m.root, dir)
+		}
+		return m, nil
+	case tea.MouseWheelMsg:
+		switch msg.Button {
+		case tea.MouseWheelUp:
+			m.offset = max(0, m.offset-1)
+		case tea.MouseWheelDown:
+			m.offset = max(0, min(m.offset+1, len(m.rows)-m.listHeight()))
+		}
+		return m, nil
+	case tea.MouseClickMsg:
+		// The click is relative to the tree.
+		index := m.offset + msg.Y - headerHeight
+		if msg.Y < headerHeight || index >= len(m.rows) {
+			return m, nil
+		}
+		m.cursor = index
+		if m.rows[index].isDir {
+			return m, m.toggle(m.rows[index].path)
+		}
+		return m, nil
+	case tea.KeyPressMsg:
+		if !m.focused {
+			return m, nil
+		}
+		return m, m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m *fileTreeCmp) handleKey(msg tea.KeyPressMsg) tea.Cmd {
+	if len(m.rows) == 0 {
+		if key.Matches(msg, m.keyMap.Refresh) {
+			return m.refresh()
+		}
+		return nil
+	}
+	current := m.rows[m.cursor]
+	switch {
+	case key.Matches(msg, m.keyMap.Up):
+		m.moveCursor(-1)
+	case key.Matches(msg, m.keyMap.Down):
+		m.moveCursor(1)
+	case key.Matches(msg, m.keyMap.Expand):
+		if current.isDir && !m.expanded[current.path] {
+			return m.toggle(current.path)
+		}
+	case key.Matches(msg, m.keyMap.Collapse):
+		if current.isDir && m.expanded[current.path] {
+			return m.toggle(current.path)
+		}
+		m.selectParent(current)
+	case key.Matches(msg, m.keyMap.Open):
+		if current.isDir {
+			return m.toggle(current.path)
+		}
+		return util.CmdHandler(OpenFileMsg{Path: current.path})
+	case key.Matches(msg, m.keyMap.Attach):
+		if current.isDir {
+			return util.ReportWarn("Only files can be attached")
+		}
+		return util.CmdHandler(AttachFileMsg{Path: current.path})
+	case key.Matches(msg, m.keyMap.Refresh):
+		return m.refresh()
+	}
+	return nil
+}
+
+func (m *fileTreeCmp) View() string {
+	t := styles.CurrentTheme()
+	style := t.S().Base.
+		Width(m.width).
+		Height(m.height).
+		PaddingLeft(1).
+		BorderLeft(true).
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(t.Border)
+	if m.focused {
+		style = style.BorderForeground(t.BorderFocus)
+	}
+
+	width := m.contentWidth()
+	header := t.S().Subtle.Render(ansi.Truncate(filepath.Base(m.root), width, "…"))
+	lines := []string{header, ""}
+	if err := m.errs[m.root]; err != nil {
+		lines = append(lines, t.S().Error.Render(ansi.Truncate(err.Error(), width, "…")))
+		return style.Render(strings.Join(lines, "\n"))
+	}
+
+	end := min(len(m.rows), m.offset+m.listHeight())
+	for i := m.offset; i < end; i++ {
+		lines = append(lines, m.renderRow(m.rows[i], i == m.cursor, width))
+	}
+	return style.Render(strings.Join(lines, "\n"))
+}
+
+// renderRow renders an entry indented by its depth, with the marker of what
+// the agent did to it.
+func (m *fileTreeCmp) renderRow(r row, selected bool, width int) string {
+	t := styles.CurrentTheme()
+	icon := "  "
+	if r.isDir {
+		icon = "▸ "
+		if m.expanded[r.path] {
+			icon = "▾ "
+		}
+	}
+
+	nameStyle := t.S().Text
+	marker := " "
+	switch {
+	case r.isDir && m.containsChanges(r.path):
+		nameStyle = t.S().Base.Foreground(t.Warning)
+		marker = "•"
+	case m.changes[r.path] == created:
+		nameStyle = t.S().Success
+		marker = "+"
+	case m.changes[r.path] == modified:
+		nameStyle = t.S().Base.Foreground(t.Warning)
+		marker = "~"
+	case r.isDir:
+		nameStyle = t.S().Base.Foreground(t.FgHalfMuted)
+	}
+
+	prefix := strings.Repeat("  ", r.depth) + icon
+	name := r.name
+	if r.isDir {
+		name += string(filepath.Separator)
+	}
+	name = ansi.Truncate(name, max(0, width-lipgloss.Width(prefix)-2), "…")
+	text := prefix + name
+	gap := max(1, width-lipgloss.Width(text)-1)
+	if selected && m.focused {
+		return t.S().TextSelected.Width(width).Render(text + strings.Repeat(" ", gap) + marker)
+	}
+	return t.S().Muted.Render(prefix) + nameStyle.Render(name) + strings.Repeat(" ", gap) + nameStyle.Render(marker)
+}
+
+func (m *fileTreeCmp) SetSize(width, height int) tea.Cmd {
+	m.width = width
+	m.height = height
+	m.scrollToCursor()
+	return nil
+}
+
+func (m *fileTreeCmp) GetSize() (int, int) {
+	return m.width, m.height
+}
+
+func (m *fileTreeCmp) Focus() tea.Cmd {
+	m.focused = true
+	return nil
+}
+
+func (m *fileTreeCmp) Blur() tea.Cmd {
+	m.focused = false
+	return nil
+}
+
+func (m *fileTreeCmp) IsFocused() bool {
+	return m.focused
+}
+
+func (m *fileTreeCmp) Bindings() []key.Binding {
+	return []key.Binding{
+		m.keyMap.Up,
+		m.keyMap.Down,
+		m.keyMap.Expand,
+		m.keyMap.Collapse,
+		m.keyMap.Open,
+		m.keyMap.Attach,
+		m.keyMap.Refresh,
+	}
+}
+
+func (m *fileTreeCmp) SetSession(session session.Session) tea.Cmd {
+	if m.session.ID == session.ID {
+		return nil
+	}
+	m.session = session
+	m.changes = make(map[string]change)
+	if session.ID == "" {
+		return nil
+	}
+	return m.loadChanges
+}
+
+// headerHeight is the height of the name of the root and the blank line
+// under it.
+const headerHeight = 2
+
+func (m *fileTreeCmp) listHeight() int {
+	return max(0, m.height-headerHeight)
+}
+
+func (m *fileTreeCmp) contentWidth() int {
+	return max(0, m.width-2) // border and padding
+}
+
+// toggle expands or collapses the directory at path, reading it the first
+// time it's expanded.
+func (m *fileTreeCmp) toggle(path string) tea.Cmd {
+	m.expanded[path] = !m.expanded[path]
+	m.rebuild()
+	if m.expanded[path] && m.children[path] == nil {
+		return loadDir(m.root, path)
+	}
+	return nil
+}
+
+// refresh reads the expanded directories again.
+func (m *fileTreeCmp) refresh() tea.Cmd {
+	var cmds []tea.Cmd
+	for dir, expanded := range m.expanded {
+		if expanded {
+			cmds = append(cmds, loadDir(m.root, dir))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m *fileTreeCmp) moveCursor(delta int) {
+	m.cursor = max(0, min(m.cursor+delta, len(m.rows)-1))
+	m.scrollToCursor()
+}
+
+// selectParent moves the cursor to the directory r is in.
+func (m *fileTreeCmp) selectParent(r row) {
+	parent := filepath.Dir(r.path)
+	for i := m.cursor - 1; i >= 0; i-- {
+		if m.rows[i].path == parent {
+			m.cursor = i
+			m.scrollToCursor()
+			return
+		}
+	}
+}
+
+func (m *fileTreeCmp) scrollToCursor() {
+	height := m.listHeight()
+	if height == 0 {
+		return
+	}
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if m.cursor >= m.offset+height {
+		m.offset = m.cursor - height + 1
+	}
+	m.offset = max(0, min(m.offset, len(m.rows)-height))
+}
+
+// rebuild lays out the rows of the expanded directories, keeping the cursor
+// on the same entry.
+func (m *fileTreeCmp) rebuild() {
+	var selected string
+	if m.cursor < len(m.rows) {
+		selected = m.rows[m.cursor].path
+	}
+
+	m.rows = m.rows[:0]
+	var walk func(dir string, depth int)
+	walk = func(dir string, depth int) {
+		for _, e := range m.children[dir] {
+			m.rows = append(m.rows, row{entry: e, depth: depth})
+			if e.isDir && m.expanded[e.path] {
+				walk(e.path, depth+1)
+			}
+		}
+	}
+	walk(m.root, 0)
+
+	m.cursor = min(m.cursor, max(0, len(m.rows)-1))
+	for i, r := range m.rows {
+		if r.path == selected {
+			m.cursor = i
+			break
+		}
+	}
+	m.scrollToCursor()
+}
+
+// containsChanges reports whether the agent changed files in dir.
+func (m *fileTreeCmp) containsChanges(dir string) bool {
+	prefix := dir + string(filepath.Separator)
+	for path, c := range m.changes {
+		if c != unchanged && strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadChanges reads the files the agent changed in the session from its
+// history.
+func (m *fileTreeCmp) loadChanges() tea.Msg {
+	files, err := m.history.ListBySession(context.Background(), m.session.ID)
+	if err != nil {
+		return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+	}
+	changes := make(map[string]change)
+	first := make(map[string]history.File)
+	for _, f := range files {
+		if prev, ok := first[f.Path]; !ok || f.Version < prev.Version {
+			first[f.Path] = f
+		}
+	}
+	for path, f := range first {
+		changes[path] = changeOf(f)
+	}
+	return changesLoadedMsg{sessionID: m.session.ID, changes: changes}
+}
+
+// changeOf returns what the agent did to a file from its first version in
+// the session, which is empty for the files it created.
+func changeOf(first history.File) change {
+	if first.Content == "" {
+		return created
+	}
+	return modified
+}
+
+func loadDir(root, dir string) tea.Cmd {
+	return func() tea.Msg {
+		dirEntries, err := fsext.ReadDir(root, dir)
+		entries := make([]entry, 0, len(dirEntries))
+		for _, e := range dirEntries {
+			isDir := e.IsDir()
+			if e.Type()&os.ModeSymlink != 0 {
+				if info, err := os.Stat(filepath.Join(dir, e.Name())); err == nil {
+					isDir = info.IsDir()
+				}
+			}
+			entries = append(entries, entry{path: filepath.Join(dir, e.Name()), name: e.Name(), isDir: isDir})
+		}
+		return dirLoadedMsg{dir: dir, entries: entries, err: err}
+	}
+}
@@ -0,0 +1,93 @@
+package filetree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileTree(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"README.md":   "# Readme\n",
+		"cmd/main.go": "package main\n",
+		".gitignore":  "*.log\n",
+		"build.log":   "output\n",
+	} {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	m := New(nil, root).(*fileTreeCmp)
+	m.session = session.Session{ID: "session"}
+	m.SetSize(30, 20)
+	m.Focus()
+	run := func(cmd tea.Cmd) tea.Msg {
+		t.Helper()
+		if cmd == nil {
+			return nil
+		}
+		msg := cmd()
+		u, _ := m.Update(msg)
+		m = u.(*fileTreeCmp)
+		return msg
+	}
+	press := func(k string) tea.Cmd {
+		_, cmd := m.Update(tea.KeyPressMsg{Code: rune(k[0]), Text: k})
+		return cmd
+	}
+	names := func() []string {
+		var names []string
+		for _, r := range m.rows {
+			names = append(names, r.name)
+		}
+		return names
+	}
+
+	run(m.Init())
+	require.Equal(t, []string{"cmd", ".gitignore", "README.md"}, names(), "directories come first and ignored files are left out")
+
+	run(press("l"))
+	require.Equal(t, []string{"cmd", "main.go", ".gitignore", "README.md"}, names())
+
+	run(press("j"))
+	require.Equal(t, AttachFileMsg{Path: filepath.Join(root, "cmd", "main.go")}, press("a")())
+
+	// The agent creates a file next to main.go and edits the readme.
+	newFile := filepath.Join(root, "cmd", "util.go")
+	require.NoError(t, os.WriteFile(newFile, []byte("package main\n"), 0o644))
+	edit := func(sessionID, path, before string) tea.Cmd {
+		_, cmd := m.Update(pubsub.Event[history.File]{
+			Type:    pubsub.CreatedEvent,
+			Payload: history.File{SessionID: sessionID, Path: path, Content: before},
+		})
+		return cmd
+	}
+	require.Nil(t, edit("other", newFile, ""), "edits in other sessions are ignored")
+	run(edit("session", newFile, ""))
+	run(edit("session", filepath.Join(root, "README.md"), "# Readme\n"))
+	require.Equal(t, []string{"cmd", "main.go", "util.go", ".gitignore", "README.md"}, names())
+	require.Equal(t, "main.go", m.rows[m.cursor].name, "the cursor stays on the selected file")
+	require.Equal(t, created, m.changes[newFile])
+	require.Equal(t, modified, m.changes[filepath.Join(root, "README.md")])
+	require.True(t, m.containsChanges(filepath.Join(root, "cmd")))
+
+	run(press("h"))
+	require.Equal(t, 0, m.cursor, "collapsing a file selects its directory")
+	run(press("h"))
+	require.Equal(t, []string{"cmd", ".gitignore", "README.md"}, names())
+
+	run(press("j"))
+	run(press("j"))
+	_, cmd := m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	require.Equal(t, OpenFileMsg{Path: filepath.Join(root, "README.md")}, cmd())
+}
@@ -0,0 +1,297 @@
+package fileview
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"charm.land/bubbles/v2/viewport"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/tui/components/core/layout"
+	"github.com/charmbracelet/crush/internal/tui/highlight"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// maxFileSize is the size above which files aren't shown.
+const maxFileSize = 1024 * 1024
+
+// FileView shows a file next to the chat: the one the agent touched last,
+// or one pinned by the user. It reloads whenever a new version of the file
+// lands in the session's history.
+type FileView interface {
+	util.Model
+	layout.Sizeable
+	SetSession(session session.Session) tea.Cmd
+	// Pin shows the file at path until unpinned with an empty path, which
+	// goes back to following the agent.
+	Pin(path string) tea.Cmd
+	Pinned() bool
+}
+
+// followMsg switches to the file at path unless one is pinned.
+type followMsg struct {
+	path string
+}
+
+// fileLoadedMsg carries the highlighted lines of a file read from disk.
+type fileLoadedMsg struct {
+	path  string
+	lines []string
+	err   error
+}
+
+type fileViewCmp struct {
+	width, height int
+	history       history.Service
+	session       session.Session
+
+	path     string
+	pinned   bool
+	lines    []string
+	err      error
+	viewport viewport.Model
+}
+
+func New(history history.Service) FileView {
+	vp := viewport.New()
+	vp.KeyMap = viewport.KeyMap{}
+	return &fileViewCmp{
+		history:  history,
+		viewport: vp,
+	}
+}
+
+func (m *fileViewCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (m *fileViewCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case pubsub.Event[history.File]:
+		file := msg.Payload
+		if m.session.ID == "" || file.SessionID != m.session.ID {
+			return m, nil
+		}
+		return m, m.follow(file.Path)
+	case followMsg:
+		return m, m.follow(msg.path)
+	case fileLoadedMsg:
+		if msg.path != m.path {
+			return m, nil
+		}
+		changed := firstChange(m.lines, msg.lines)
+		m.lines, m.err = msg.lines, msg.err
+		m.render()
+		// Bring the first changed line into view, leaving some context
+		// above it.
+		if changed >= 0 && (changed < m.viewport.YOffset() || changed >= m.viewport.YOffset()+m.viewport.Height()) {
+			m.viewport.SetYOffset(changed - m.viewport.Height()/4)
+		}
+		return m, nil
+	case tea.MouseWheelMsg:
+		vp, cmd := m.viewport.Update(msg)
+		m.viewport = vp
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m *fileViewCmp) View() string {
+	t := styles.CurrentTheme()
+	style := t.S().Base.
+		Width(m.width).
+		Height(m.height).
+		PaddingLeft(1).
+		BorderLeft(true).
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(t.Border)
+
+	if m.path == "" {
+		hint := "Files the agent edits show up here. Pin one from the command palette to keep it in view."
+		return style.Render(t.S().Muted.Width(m.contentWidth()).Render(hint))
+	}
+
+	label := "Following"
+	if m.pinned {
+		label = "Pinned"
+	}
+	path := fsext.PrettyPath(m.path)
+	if rel, err := filepath.Rel(config.Get().WorkingDir(), m.path); err == nil && !strings.HasPrefix(rel, "..") {
+		path = rel
+	}
+	header := t.S().Subtle.Render(label+" ") + t.S().Base.Foreground(t.FgBase).Render(
+		ansi.Truncate(path, m.contentWidth()-lipgloss.Width(label)-1, "…"),
+	)
+
+	body := m.viewport.View()
+	if m.err != nil {
+		body = t.S().Error.Render(ansi.Truncate(m.err.Error(), m.contentWidth(), "…"))
+	}
+	return style.Render(lipgloss.JoinVertical(lipgloss.Left, header, "", body))
+}
+
+func (m *fileViewCmp) SetSize(width, height int) tea.Cmd {
+	m.width = width
+	m.height = height
+	m.viewport.SetWidth(m.contentWidth())
+	m.viewport.SetHeight(max(0, height-2))
+	m.render()
+	return nil
+}
+
+func (m *fileViewCmp) GetSize() (int, int) {
+	return m.width, m.height
+}
+
+func (m *fileViewCmp) SetSession(session session.Session) tea.Cmd {
+	if m.session.ID == session.ID {
+		return nil
+	}
+	m.session = session
+	if m.pinned || session.ID == "" {
+		return nil
+	}
+	m.path, m.lines, m.err = "", nil, nil
+	m.render()
+	return m.loadLatest
+}
+
+func (m *fileViewCmp) Pin(path string) tea.Cmd {
+	if path == "" {
+		m.pinned = false
+		return m.loadLatest
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(config.Get().WorkingDir(), path)
+	}
+	m.pinned = false
+	cmd := m.follow(path)
+	m.pinned = true
+	return cmd
+}
+
+// follow shows the file at path, reloading it if it's already shown.
+func (m *fileViewCmp) follow(path string) tea.Cmd {
+	if m.pinned && !samePath(path, m.path) {
+		return nil
+	}
+	if !samePath(path, m.path) {
+		m.lines, m.err = nil, nil
+		m.viewport.GotoTop()
+	}
+	m.path = path
+	return loadFile(path)
+}
+
+func (m *fileViewCmp) Pinned() bool {
+	return m.pinned
+}
+
+func (m *fileViewCmp) contentWidth() int {
+	return max(0, m.width-2) // border and padding
+}
+
+// render lays out the lines with line numbers for the current width.
+func (m *fileViewCmp) render() {
+	t := styles.CurrentTheme()
+	digits := len(fmt.Sprint(len(m.lines)))
+	codeWidth := max(0, m.contentWidth()-digits-1)
+	var b strings.Builder
+	for i, ln := range m.lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		num := t.S().Base.Foreground(t.FgMuted).Render(fmt.Sprintf("%*d ", digits, i+1))
+		b.WriteString(num + ansi.Truncate(ln, codeWidth, "…"))
+	}
+	m.viewport.SetContent(b.String())
+}
+
+// loadLatest follows the file the agent touched last in the session.
+func (m *fileViewCmp) loadLatest() tea.Msg {
+	files, err := m.history.ListBySession(context.Background(), m.session.ID)
+	if err != nil || len(files) == 0 {
+		return nil
+	}
+	latest := files[0]
+	for _, f := range files[1:] {
+		if f.CreatedAt > latest.CreatedAt || (f.CreatedAt == latest.CreatedAt && f.Version > latest.Version) {
+			latest = f
+		}
+	}
+	return followMsg{path: latest.Path}
+}
+
+func loadFile(path string) tea.Cmd {
+	return func() tea.Msg {
+		lines, err := readHighlighted(path)
+		return fileLoadedMsg{path: path, lines: lines, err: err}
+	}
+}
+
+func readHighlighted(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", filepath.Base(path))
+	}
+	if info.Size() > maxFileSize {
+		return nil, fmt.Errorf("file too large to show (%d bytes)", info.Size())
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.IndexByte(content, 0) >= 0 {
+		return nil, fmt.Errorf("binary file")
+	}
+
+	source, _ := fsext.ToUnixLineEndings(string(content))
+	source = strings.ReplaceAll(strings.TrimSuffix(source, "\n"), "\t", "    ")
+	source = ansi.Strip(source)
+	highlighted, err := highlight.SyntaxHighlight(source, path, styles.CurrentTheme().BgBase)
+	if err != nil {
+		highlighted = source
+	}
+	return strings.Split(highlighted, "\n"), nil
+}
+
+// firstChange returns the index of the first line that differs between the
+// two versions, or -1 when they are the same.
+func firstChange(before, after []string) int {
+	for i := range min(len(before), len(after)) {
+		if before[i] != after[i] {
+			return i
+		}
+	}
+	if len(before) != len(after) {
+		return min(len(before), len(after))
+	}
+	return -1
+}
+
+func samePath(a, b string) bool {
+	if a == "" || b == "" {
+		return a == b
+	}
+	if !filepath.IsAbs(a) {
+		a = filepath.Join(config.Get().WorkingDir(), a)
+	}
+	if !filepath.IsAbs(b) {
+		b = filepath.Join(config.Get().WorkingDir(), b)
+	}
+	return filepath.Clean(a) == filepath.Clean(b)
+}
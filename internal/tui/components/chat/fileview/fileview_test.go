@@ -0,0 +1,66 @@
+package fileview
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileViewFollowsAndPins(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	edited := filepath.Join(dir, "main.go")
+	pinned := filepath.Join(dir, "README.md")
+	require.NoError(t, os.WriteFile(edited, []byte("package main\n\nfunc main() {}\n"), 0o644))
+	require.NoError(t, os.WriteFile(pinned, []byte("# Readme\n"), 0o644))
+
+	m := &fileViewCmp{session: session.Session{ID: "session"}}
+	m.SetSize(60, 20)
+	run := func(cmd tea.Cmd) {
+		t.Helper()
+		if cmd == nil {
+			return
+		}
+		u, _ := m.Update(cmd())
+		m = u.(*fileViewCmp)
+	}
+	edit := func(sessionID, path string) tea.Cmd {
+		_, cmd := m.Update(pubsub.Event[history.File]{
+			Type:    pubsub.CreatedEvent,
+			Payload: history.File{SessionID: sessionID, Path: path},
+		})
+		return cmd
+	}
+
+	require.Nil(t, edit("other", edited), "edits in other sessions are ignored")
+
+	run(edit("session", edited))
+	require.Equal(t, edited, m.path)
+	require.Len(t, m.lines, 3)
+
+	run(m.Pin(pinned))
+	require.True(t, m.Pinned())
+	require.Equal(t, pinned, m.path)
+	require.Len(t, m.lines, 1)
+	require.Nil(t, edit("session", edited), "pinned files stay in view")
+
+	require.NoError(t, os.WriteFile(pinned, []byte("# Readme\n\nMore.\n"), 0o644))
+	run(edit("session", pinned))
+	require.Len(t, m.lines, 3, "pinned files reload when edited")
+}
+
+func TestFirstChange(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, -1, firstChange([]string{"a", "b"}, []string{"a", "b"}))
+	require.Equal(t, 1, firstChange([]string{"a", "b"}, []string{"a", "c"}))
+	require.Equal(t, 2, firstChange([]string{"a", "b"}, []string{"a", "b", "c"}))
+	require.Equal(t, 0, firstChange(nil, []string{"a"}))
+}
@@ -0,0 +1,170 @@
+package messages
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/glamour/v2"
+
+	"github.com/charmbracelet/crush/internal/tui/styles"
+)
+
+// markdownBlock is a completed top-level block of a message with its
+// rendered output.
+type markdownBlock struct {
+	source   string
+	rendered string
+}
+
+// streamingMarkdown renders markdown that grows at the end, as the content of
+// a message does while it streams. Only the trailing block, which may still
+// change, is rendered again on each call; the output of the blocks before it
+// is reused.
+type streamingMarkdown struct {
+	width    int
+	theme    *styles.Theme
+	renderer *glamour.TermRenderer
+	blocks   []markdownBlock
+}
+
+// Render renders content at the given width, the same as rendering it whole.
+func (s *streamingMarkdown) Render(content string, width int) string {
+	if t := styles.CurrentTheme(); width != s.width || t != s.theme {
+		s.width = width
+		s.theme = t
+		s.renderer = styles.GetMarkdownRenderer(width)
+		s.blocks = nil
+	}
+
+	sources := splitMarkdownBlocks(content)
+	if len(sources) == 0 {
+		return ""
+	}
+	completed, last := sources[:len(sources)-1], sources[len(sources)-1]
+
+	kept := 0
+	for kept < len(completed) && kept < len(s.blocks) && s.blocks[kept].source == completed[kept] {
+		kept++
+	}
+	s.blocks = s.blocks[:kept]
+	for i, source := range completed[kept:] {
+		s.blocks = append(s.blocks, markdownBlock{
+			source:   source,
+			rendered: s.render(source, kept+i == 0),
+		})
+	}
+
+	var sb strings.Builder
+	for _, b := range s.blocks {
+		sb.WriteString(b.rendered)
+	}
+	sb.WriteString(s.render(last, len(s.blocks) == 0))
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// render renders a single block. The renderer spaces blocks differently at
+// the start of a document, so a block that isn't first is rendered after a
+// placeholder paragraph, which is then cut off.
+func (s *streamingMarkdown) render(source string, first bool) string {
+	if first {
+		rendered, _ := s.renderer.Render(source)
+		return rendered
+	}
+	rendered, _ := s.renderer.Render("x\n\n" + source)
+	_, rendered, _ = strings.Cut(rendered, "\n")
+	return rendered
+}
+
+// linkReference matches a link reference definition, which can be used by
+// blocks anywhere in the document.
+var linkReference = regexp.MustCompile(`(?m)^ {0,3}\[[^\]]+\]:`)
+
+// splitMarkdownBlocks splits content into top-level blocks that render the
+// same on their own as they do in the whole document. It splits only at blank
+// lines outside of code fences, and never before a line that could continue
+// the block above: an indented line, a list item or a block quote. Content
+// with link reference definitions isn't split.
+func splitMarkdownBlocks(content string) []string {
+	if strings.TrimSpace(content) == "" {
+		return nil
+	}
+	if linkReference.MatchString(content) {
+		return []string{content}
+	}
+	var (
+		blocks  []string
+		current []string
+		fence   string
+		blank   bool
+	)
+	for line := range strings.SplitSeq(content, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) && strings.TrimRight(trimmed, fence[:1]+" ") == "" {
+				fence = ""
+			}
+			current = append(current, line)
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			blank = len(current) > 0
+			if blank {
+				current = append(current, line)
+			}
+			continue
+		}
+		if blank && startsBlock(line) {
+			blocks = append(blocks, strings.TrimRight(strings.Join(current, "\n"), " \t\n"))
+			current = nil
+		}
+		blank = false
+		if f := codeFence(trimmed); f != "" && len(line)-len(trimmed) < 4 {
+			fence = f
+		}
+		current = append(current, line)
+	}
+	if len(current) > 0 {
+		// Trailing blank lines may still be part of an open code fence.
+		blocks = append(blocks, strings.Join(current, "\n"))
+	}
+	return blocks
+}
+
+// startsBlock reports whether line, following a blank line, starts a new
+// top-level block rather than continuing the one above.
+func startsBlock(line string) bool {
+	switch {
+	case line[0] == ' ' || line[0] == '\t':
+		return false
+	case line[0] == '>':
+		return false
+	case isListItem(line):
+		return false
+	}
+	return true
+}
+
+// isListItem reports whether line starts with a bullet or an ordered list
+// marker.
+func isListItem(line string) bool {
+	if len(line) > 1 && strings.ContainsRune("-*+", rune(line[0])) && line[1] == ' ' {
+		return true
+	}
+	digits := len(line) - len(strings.TrimLeft(line, "0123456789"))
+	if digits == 0 || digits > 9 || len(line) < digits+2 {
+		return false
+	}
+	return (line[digits] == '.' || line[digits] == ')') && line[digits+1] == ' '
+}
+
+// codeFence returns the opening fence of a fenced code block starting at line,
+// or "" if it doesn't start one.
+func codeFence(line string) string {
+	for _, c := range []string{"`", "~"} {
+		n := len(line) - len(strings.TrimLeft(line, c))
+		if n >= 3 {
+			return strings.Repeat(c, n)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,84 @@
+package messages
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/require"
+)
+
+const streamedMarkdown = "# Title\n\n" +
+	"A paragraph that is long enough to wrap around the width of the renderer at least once.\n\n" +
+	"- one\n- two\n\n  more of two\n\n" +
+	"1. first\n2. second\n\n" +
+	"```go\nfunc main() {\n\n\tprintln(\"hi\")\n}\n```\n\n" +
+	"> a quote\n\n" +
+	"| a | b |\n|---|---|\n| 1 | 2 |\n\n" +
+	"---\n\n" +
+	"## Section\n\n" +
+	"The end."
+
+func renderWhole(content string, width int) string {
+	rendered, _ := styles.GetMarkdownRenderer(width).Render(content)
+	return strings.TrimSuffix(rendered, "\n")
+}
+
+func TestStreamingMarkdown(t *testing.T) {
+	t.Parallel()
+
+	var s streamingMarkdown
+	for i := range len(streamedMarkdown) + 1 {
+		content := streamedMarkdown[:i]
+		require.Equal(t, ansi.Strip(renderWhole(content, 60)), ansi.Strip(s.Render(content, 60)), "after %d bytes", i)
+	}
+	require.Len(t, s.blocks, len(splitMarkdownBlocks(streamedMarkdown))-1)
+	require.Greater(t, len(s.blocks), 5)
+
+	require.Equal(t, ansi.Strip(renderWhole(streamedMarkdown, 40)), ansi.Strip(s.Render(streamedMarkdown, 40)))
+}
+
+func TestSplitMarkdownBlocks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"empty", "\n\n", nil},
+		{"paragraphs", "one\n\n\ntwo\n", []string{"one", "two\n"}},
+		{"list", "- a\n\n- b\n\n  c\n\nd", []string{"- a\n\n- b\n\n  c", "d"}},
+		{"paragraph and list", "a\n\n1. b", []string{"a\n\n1. b"}},
+		{"fence", "```\na\n\nb\n```\n\nc", []string{"```\na\n\nb\n```", "c"}},
+		{"open fence", "a\n\n~~~\nb\n\nc", []string{"a", "~~~\nb\n\nc"}},
+		{"quote", "> a\n\n> b", []string{"> a\n\n> b"}},
+		{"link reference", "[a]\n\n[a]: https://example.com", []string{"[a]\n\n[a]: https://example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, splitMarkdownBlocks(tt.content))
+		})
+	}
+}
+
+func BenchmarkStreamingMarkdown(b *testing.B) {
+	content := strings.Repeat(streamedMarkdown+"\n\n", 4)
+	b.Run("whole", func(b *testing.B) {
+		for b.Loop() {
+			for i := 0; i < len(content); i += 64 {
+				renderWhole(content[:i], 80)
+			}
+		}
+	})
+	b.Run("streaming", func(b *testing.B) {
+		for b.Loop() {
+			var s streamingMarkdown
+			for i := 0; i < len(content); i += 64 {
+				s.Render(content[:i], 80)
+			}
+		}
+	})
+}
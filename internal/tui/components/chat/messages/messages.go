@@ -29,6 +29,49 @@ import (
 // CopyKey is the key binding for copying message content to the clipboard.
 var CopyKey = key.NewBinding(key.WithKeys("c", "y", "C", "Y"), key.WithHelp("c/y", "copy"))
 
+// ContinueKey is the key binding for continuing a response that was cut off
+// by a dropped connection.
+var ContinueKey = key.NewBinding(key.WithKeys("ctrl+t"), key.WithHelp("ctrl+t", "continue"))
+
+// SkipToolKey is the key binding for skipping the selected tool call while it
+// runs, or cancelling the sub-agent of an agent or agentic fetch tool call.
+var SkipToolKey = key.NewBinding(key.WithKeys("x"), key.WithHelp("x", "skip tool"))
+
+// ExtendToolKey is the key binding for starting the timeouts of the selected
+// tool call over while it runs.
+var ExtendToolKey = key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "extend tool"))
+
+// OpenMatchesKey is the key binding for listing the matches of the selected
+// grep tool call, to open one in the editor.
+var OpenMatchesKey = key.NewBinding(key.WithKeys("o"), key.WithHelp("o", "open match"))
+
+// UsageKey is the key binding for showing the usage of the step that produced
+// the selected assistant message or tool call.
+var UsageKey = key.NewBinding(key.WithKeys("i"), key.WithHelp("i", "usage"))
+
+// OpenUsageMsg asks to show the usage of the step that produced the message
+// with MessageID.
+type OpenUsageMsg struct {
+	MessageID string
+}
+
+// EditKey is the key binding for editing the selected user message and
+// resending it.
+var EditKey = key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "edit & resend"))
+
+// EditMessageMsg asks to edit the user message with MessageID and resend it.
+type EditMessageMsg struct {
+	MessageID string
+}
+
+// CancelSummaryKey is the key binding for cancelling a summary while it
+// streams, which drops what was summarized so far.
+var CancelSummaryKey = key.NewBinding(key.WithKeys("esc", "alt+esc"), key.WithHelp("esc", "cancel summary"))
+
+// summaryPreviewLines is how many of the latest lines of a summary show while
+// it streams.
+const summaryPreviewLines = 6
+
 // ClearSelectionKey is the key binding for clearing the current selection in the chat interface.
 var ClearSelectionKey = key.NewBinding(key.WithKeys("esc", "alt+esc"), key.WithHelp("esc", "clear selection"))
 
@@ -41,6 +84,7 @@ type MessageCmp interface {
 	GetMessage() message.Message    // Access to underlying message data
 	SetMessage(msg message.Message) // Update the message content
 	Spinning() bool                 // Animation state for loading messages
+	Summarizing() bool              // Whether it is a summary that is still streaming
 	ID() string
 }
 
@@ -58,6 +102,9 @@ type messageCmp struct {
 
 	// Thinking viewport for displaying reasoning content
 	thinkingViewport viewport.Model
+
+	// Rendered content, kept across deltas while the message streams
+	markdown streamingMarkdown
 }
 
 var focusedMessageBorder = lipgloss.Border{
@@ -114,6 +161,12 @@ func (m *messageCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 				util.ReportInfo("Message copied to clipboard"),
 			)
 		}
+		if key.Matches(msg, UsageKey) && m.message.Role == message.Assistant {
+			return m, util.CmdHandler(OpenUsageMsg{MessageID: m.message.ID})
+		}
+		if key.Matches(msg, EditKey) && m.message.Role == message.User {
+			return m, util.CmdHandler(EditMessageMsg{MessageID: m.message.ID})
+		}
 	}
 	return m, nil
 }
@@ -121,10 +174,13 @@ func (m *messageCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 // View renders the message component based on its current state.
 // Returns different views for spinning, user, and assistant messages.
 func (m *messageCmp) View() string {
+	if accessible() {
+		return m.plainView()
+	}
+	if m.Summarizing() {
+		return m.renderSummaryProgress()
+	}
 	if m.spinning && m.message.ReasoningContent().Thinking == "" {
-		if m.message.IsSummaryMessage {
-			m.anim.SetLabel("Summarizing")
-		}
 		return m.style().PaddingLeft(1).Render(m.anim.View())
 	}
 	if m.message.ID != "" {
@@ -139,6 +195,27 @@ func (m *messageCmp) View() string {
 	return m.style().Render("No message content")
 }
 
+// accessible reports whether messages are shown as plain text for screen
+// readers.
+func accessible() bool {
+	cfg := config.Get()
+	return cfg != nil && cfg.Options.TUI.Accessible
+}
+
+// plainView renders the message as plain text, with a marker for each part
+// and no borders.
+func (m *messageCmp) plainView() string {
+	t := styles.CurrentTheme()
+	text := m.message.PlainText()
+	if m.spinning && text == "" {
+		text = m.anim.View()
+	}
+	if text == "" {
+		return ""
+	}
+	return t.S().Text.Width(m.width).Render(text)
+}
+
 // GetMessage returns the underlying message data
 func (m *messageCmp) GetMessage() message.Message {
 	return m.message
@@ -211,13 +288,43 @@ func (m *messageCmp) renderAssistantMessage() string {
 		if thinkingContent != "" {
 			parts = append(parts, "")
 		}
-		parts = append(parts, m.toMarkdown(content))
+		parts = append(parts, m.markdown.Render(content, m.textWidth()))
+	}
+
+	if finished && finishedData.Reason == message.FinishReasonInterrupted {
+		tag := t.S().Base.Padding(0, 1).Background(t.Warning).Foreground(t.White).Render("INTERRUPTED")
+		hint := fmt.Sprintf("%s. Press %s to continue from here.", finishedData.Message, ContinueKey.Help().Key)
+		hint = ansi.Truncate(hint, m.textWidth()-2-lipgloss.Width(tag), "...")
+		parts = append(parts, "", fmt.Sprintf("%s %s", tag, t.S().Base.Foreground(t.FgHalfMuted).Render(hint)))
 	}
 
 	joined := lipgloss.JoinVertical(lipgloss.Left, parts...)
 	return m.style().Render(joined)
 }
 
+// renderSummaryProgress renders a summary while it streams, with a spinner,
+// how long it is so far, its latest lines and how to cancel it.
+func (m *messageCmp) renderSummaryProgress() string {
+	t := styles.CurrentTheme()
+	m.anim.SetLabel("Summarizing")
+	header := m.anim.View()
+	content := strings.TrimSpace(m.message.Content().Text)
+	if content != "" {
+		words := len(strings.Fields(content))
+		header += t.S().Subtle.Render(fmt.Sprintf(" %d words", words))
+	}
+	parts := []string{header}
+	if content != "" {
+		wrapped := ansi.Wrap(content, m.textWidth()-2, "")
+		lines := strings.Split(wrapped, "\n")
+		lines = lines[max(0, len(lines)-summaryPreviewLines):]
+		parts = append(parts, "", t.S().Muted.Render(strings.Join(lines, "\n")))
+	}
+	help := CancelSummaryKey.Help()
+	parts = append(parts, "", t.S().Subtle.Render(fmt.Sprintf("Press %s to cancel", help.Key)))
+	return m.style().PaddingLeft(1).Render(lipgloss.JoinVertical(lipgloss.Left, parts...))
+}
+
 // renderUserMessage renders user messages with file attachments. It displays
 // message content and any attached files with appropriate icons.
 func (m *messageCmp) renderUserMessage() string {
@@ -323,6 +430,10 @@ func (m *messageCmp) shouldSpin() bool {
 	if m.message.IsFinished() {
 		return false
 	}
+	// A summary spins until it is done, for its progress to show.
+	if m.message.IsSummaryMessage {
+		return true
+	}
 
 	if strings.TrimSpace(m.message.Content().Text) != "" {
 		return false
@@ -369,6 +480,12 @@ func (m *messageCmp) Spinning() bool {
 	return m.spinning
 }
 
+// Summarizing reports whether the message is a summary that is still
+// streaming.
+func (m *messageCmp) Summarizing() bool {
+	return m.message.IsSummaryMessage && !m.message.IsFinished()
+}
+
 type AssistantSection interface {
 	list.Item
 	layout.Sizeable
@@ -0,0 +1,41 @@
+package messages
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummaryProgress(t *testing.T) {
+	t.Parallel()
+
+	msg := message.Message{ID: "summary", Role: message.Assistant, IsSummaryMessage: true}
+	var lines []string
+	for i := range 10 {
+		lines = append(lines, fmt.Sprintf("line %d", i+1))
+	}
+	msg.AppendContent(strings.Join(lines, "\n"))
+
+	m := NewMessageCmp(msg)
+	m.Init()
+	m.SetSize(80, 0)
+	require.True(t, m.Summarizing())
+	require.True(t, m.Spinning())
+
+	view := ansi.Strip(m.View())
+	require.Contains(t, view, "Summarizing")
+	require.Contains(t, view, "20 words")
+	require.Contains(t, view, "line 10")
+	require.Contains(t, view, "line 5")
+	require.NotContains(t, view, "line 4")
+	require.Contains(t, view, "Press esc to cancel")
+
+	msg.AddFinish(message.FinishReasonEndTurn, "", "")
+	m.SetMessage(msg)
+	require.False(t, m.Summarizing())
+	require.NotContains(t, ansi.Strip(m.View()), "cancel")
+}
@@ -13,7 +13,9 @@ import (
 	"github.com/charmbracelet/crush/internal/agent/tools"
 	"github.com/charmbracelet/crush/internal/ansiext"
 	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/todos"
 	"github.com/charmbracelet/crush/internal/tui/highlight"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/x/ansi"
@@ -22,6 +24,10 @@ import (
 // responseContextHeight limits the number of lines displayed in tool output
 const responseContextHeight = 10
 
+// compareContextHeight limits the number of lines of each compared answer,
+// which are what a comparison is for.
+const compareContextHeight = 40
+
 // renderer defines the interface for tool-specific rendering implementations
 type renderer interface {
 	// Render returns the complete (already styled) tool‑call view, not
@@ -166,20 +172,29 @@ func init() {
 	registry.register(tools.BashToolName, func() renderer { return bashRenderer{} })
 	registry.register(tools.JobOutputToolName, func() renderer { return bashOutputRenderer{} })
 	registry.register(tools.JobKillToolName, func() renderer { return bashKillRenderer{} })
+	registry.register(tools.RunTestsToolName, func() renderer { return runTestsRenderer{} })
+	for _, name := range []string{"build", "lint", "test", "format"} {
+		registry.register(tools.ProjectCommandToolPrefix+name, func() renderer { return projectCommandRenderer{} })
+	}
 	registry.register(tools.DownloadToolName, func() renderer { return downloadRenderer{} })
 	registry.register(tools.ViewToolName, func() renderer { return viewRenderer{} })
 	registry.register(tools.EditToolName, func() renderer { return editRenderer{} })
 	registry.register(tools.MultiEditToolName, func() renderer { return multiEditRenderer{} })
+	registry.register(tools.ApplyPatchToolName, func() renderer { return applyPatchRenderer{} })
 	registry.register(tools.WriteToolName, func() renderer { return writeRenderer{} })
 	registry.register(tools.FetchToolName, func() renderer { return simpleFetchRenderer{} })
 	registry.register(tools.AgenticFetchToolName, func() renderer { return agenticFetchRenderer{} })
 	registry.register(tools.WebFetchToolName, func() renderer { return webFetchRenderer{} })
 	registry.register(tools.GlobToolName, func() renderer { return globRenderer{} })
 	registry.register(tools.GrepToolName, func() renderer { return grepRenderer{} })
+	registry.register(tools.GitLogToolName, func() renderer { return gitLogRenderer{} })
+	registry.register(tools.GitBlameToolName, func() renderer { return gitBlameRenderer{} })
 	registry.register(tools.LSToolName, func() renderer { return lsRenderer{} })
 	registry.register(tools.SourcegraphToolName, func() renderer { return sourcegraphRenderer{} })
 	registry.register(tools.DiagnosticsToolName, func() renderer { return diagnosticsRenderer{} })
+	registry.register(tools.TodoToolName, func() renderer { return todoRenderer{} })
 	registry.register(agent.AgentToolName, func() renderer { return agentRenderer{} })
+	registry.register(agent.CompareToolName, func() renderer { return compareRenderer{} })
 }
 
 // -----------------------------------------------------------------------------
@@ -405,17 +420,32 @@ func (vr viewRenderer) Render(v *toolCallCmp) string {
 		addMain(file).
 		addKeyValue("limit", formatNonZero(params.Limit)).
 		addKeyValue("offset", formatNonZero(params.Offset)).
+		addKeyValue("lines", formatLineRange(params.StartLine, params.EndLine)).
 		build()
 
+	offset := params.Offset
+	if params.StartLine > 0 {
+		offset = params.StartLine - 1
+	}
 	return vr.renderWithParams(v, "View", args, func() string {
 		var meta tools.ViewResponseMetadata
 		if err := vr.unmarshalParams(v.result.Metadata, &meta); err != nil {
+			// Outlines of large files have no metadata.
 			return renderPlainContent(v, v.result.Content)
 		}
-		return renderCodeContent(v, meta.FilePath, meta.Content, params.Offset)
+		return renderCodeContent(v, meta.FilePath, meta.Content, offset)
 	})
 }
 
+// formatLineRange returns a range of lines like "10-20", "10-" or "-20",
+// or an empty string if neither end is set.
+func formatLineRange(start, end int) string {
+	if start == 0 && end == 0 {
+		return ""
+	}
+	return formatNonZero(start) + "-" + formatNonZero(end)
+}
+
 // formatNonZero returns string representation of non-zero integers, empty string for zero
 func formatNonZero(value int) string {
 	if value == 0 {
@@ -533,6 +563,66 @@ func (mer multiEditRenderer) Render(v *toolCallCmp) string {
 	})
 }
 
+// -----------------------------------------------------------------------------
+//  Apply Patch renderer
+// -----------------------------------------------------------------------------
+
+// applyPatchRenderer handles patches with a diff of each changed file
+type applyPatchRenderer struct {
+	baseRenderer
+}
+
+// Render displays the patched files with a formatted diff of each
+func (apr applyPatchRenderer) Render(v *toolCallCmp) string {
+	t := styles.CurrentTheme()
+	var args []string
+	var meta tools.ApplyPatchResponseMetadata
+	if v.result.Metadata != "" && apr.unmarshalParams(v.result.Metadata, &meta) == nil {
+		args = newParamBuilder().
+			addMain(fmt.Sprintf("%d file(s)", len(meta.Files))).
+			addKeyValue("changes", fmt.Sprintf("+%d -%d", meta.Additions, meta.Removals)).
+			build()
+	}
+
+	return apr.renderWithParams(v, "Patch", args, func() string {
+		if len(meta.Files) == 0 {
+			return renderPlainContent(v, v.result.Content)
+		}
+
+		var parts []string
+		for _, file := range meta.Files {
+			title := fsext.PrettyPath(file.FilePath)
+			switch {
+			case file.Created:
+				title += " (created)"
+			case file.Deleted:
+				title += " (deleted)"
+			}
+			parts = append(parts, t.S().Muted.PaddingLeft(2).Render(title))
+			formatter := core.DiffFormatter().
+				Before(fsext.PrettyPath(file.FilePath), file.OldContent).
+				After(fsext.PrettyPath(file.FilePath), file.NewContent).
+				Width(v.textWidth() - 2) // -2 for padding
+			if v.textWidth() > 120 {
+				formatter = formatter.Split()
+			}
+			parts = append(parts, formatter.String())
+		}
+		// add a message to the bottom if the content was truncated
+		formatted := strings.Join(parts, "\n")
+		if lipgloss.Height(formatted) > responseContextHeight {
+			contentLines := strings.Split(formatted, "\n")
+			truncateMessage := t.S().Muted.
+				Background(t.BgBaseLighter).
+				PaddingLeft(2).
+				Width(v.textWidth() - 2).
+				Render(fmt.Sprintf("… (%d lines)", len(contentLines)-responseContextHeight))
+			formatted = strings.Join(contentLines[:responseContextHeight], "\n") + "\n" + truncateMessage
+		}
+		return formatted
+	})
+}
+
 // -----------------------------------------------------------------------------
 //  Write renderer
 // -----------------------------------------------------------------------------
@@ -571,10 +661,16 @@ func (fr simpleFetchRenderer) Render(v *toolCallCmp) string {
 	var params tools.FetchParams
 	var args []string
 	if err := fr.unmarshalParams(v.call.Input, &params); err == nil {
+		var cached string
+		var meta tools.FetchResponseMetadata
+		if v.result.Metadata != "" && fr.unmarshalParams(v.result.Metadata, &meta) == nil && meta.Cached {
+			cached = meta.FetchedAt.Local().Format(time.DateTime)
+		}
 		args = newParamBuilder().
 			addMain(params.URL).
 			addKeyValue("format", params.Format).
 			addKeyValue("timeout", formatTimeout(params.Timeout)).
+			addKeyValue("cached", cached).
 			build()
 	}
 
@@ -651,7 +747,7 @@ func (fr agenticFetchRenderer) Render(v *toolCallCmp) string {
 
 	if v.result.ToolCallID == "" {
 		v.spinning = true
-		parts = append(parts, "", v.anim.View())
+		parts = append(parts, "", v.renderSubAgentStatus())
 	} else {
 		v.spinning = false
 	}
@@ -721,6 +817,19 @@ func (dr downloadRenderer) Render(v *toolCallCmp) string {
 			build()
 	}
 
+	if !v.isNested && v.isRunning() && v.download.Downloaded > 0 {
+		header := dr.makeHeader(v, "Download", v.textWidth(), args...)
+		v.spinning = true
+		status := formatDownloadProgress(v.download)
+		if elapsed := v.elapsed(time.Now()); elapsed >= showElapsedAfter {
+			status += " · " + formatElapsed(elapsed)
+		}
+		body := v.anim.View() + " " + styles.CurrentTheme().S().Subtle.Render(status)
+		if v.progress.Slow(time.Now()) {
+			body += "\n" + v.renderRunningStatus()
+		}
+		return joinHeaderBody(header, body)
+	}
 	return dr.renderWithParams(v, "Download", args, func() string {
 		return renderPlainContent(v, v.result.Content)
 	})
@@ -743,6 +852,7 @@ func (gr globRenderer) Render(v *toolCallCmp) string {
 		args = newParamBuilder().
 			addMain(params.Pattern).
 			addKeyValue("path", params.Path).
+			addFlag("no ignore", params.NoIgnore).
 			build()
 	}
 
@@ -751,6 +861,62 @@ func (gr globRenderer) Render(v *toolCallCmp) string {
 	})
 }
 
+// -----------------------------------------------------------------------------
+//  Git renderers
+// -----------------------------------------------------------------------------
+
+// gitLogRenderer handles listing the commits of the repository
+type gitLogRenderer struct {
+	baseRenderer
+}
+
+// Render displays the path with the line range, revision and pattern options
+func (gr gitLogRenderer) Render(v *toolCallCmp) string {
+	var params tools.GitLogParams
+	var args []string
+	if err := gr.unmarshalParams(v.call.Input, &params); err == nil {
+		path := params.Path
+		if params.StartLine > 0 {
+			path = fmt.Sprintf("%s:%d-%d", path, params.StartLine, max(params.EndLine, params.StartLine))
+		}
+		args = newParamBuilder().
+			addMain(path).
+			addKeyValue("revision", params.Revision).
+			addKeyValue("grep", params.Grep).
+			addFlag("patch", params.Patch).
+			build()
+	}
+
+	return gr.renderWithParams(v, "Git Log", args, func() string {
+		return renderPlainContent(v, v.result.Content)
+	})
+}
+
+// gitBlameRenderer handles annotating the lines of a file with their commits
+type gitBlameRenderer struct {
+	baseRenderer
+}
+
+// Render displays the file with the line range and revision options
+func (gr gitBlameRenderer) Render(v *toolCallCmp) string {
+	var params tools.GitBlameParams
+	var args []string
+	if err := gr.unmarshalParams(v.call.Input, &params); err == nil {
+		path := fsext.PrettyPath(params.Path)
+		if params.StartLine > 0 || params.EndLine > 0 {
+			path = fmt.Sprintf("%s:%d-%d", path, max(params.StartLine, 1), params.EndLine)
+		}
+		args = newParamBuilder().
+			addMain(path).
+			addKeyValue("revision", params.Revision).
+			build()
+	}
+
+	return gr.renderWithParams(v, "Git Blame", args, func() string {
+		return renderPlainContent(v, v.result.Content)
+	})
+}
+
 // -----------------------------------------------------------------------------
 //  Grep renderer
 // -----------------------------------------------------------------------------
@@ -770,6 +936,8 @@ func (gr grepRenderer) Render(v *toolCallCmp) string {
 			addKeyValue("path", params.Path).
 			addKeyValue("include", params.Include).
 			addFlag("literal", params.LiteralText).
+			addFlag("multiline", params.Multiline).
+			addFlag("no ignore", params.NoIgnore).
 			build()
 	}
 
@@ -798,7 +966,10 @@ func (lr lsRenderer) Render(v *toolCallCmp) string {
 		}
 		path = fsext.PrettyPath(path)
 
-		args = newParamBuilder().addMain(path).build()
+		args = newParamBuilder().
+			addMain(path).
+			addFlag("no ignore", params.NoIgnore).
+			build()
 	}
 
 	return lr.renderWithParams(v, "List", args, func() string {
@@ -810,19 +981,21 @@ func (lr lsRenderer) Render(v *toolCallCmp) string {
 //  Sourcegraph renderer
 // -----------------------------------------------------------------------------
 
-// sourcegraphRenderer handles code search with count and context options
+// sourcegraphRenderer handles code search with pattern type, count, page and context options
 type sourcegraphRenderer struct {
 	baseRenderer
 }
 
-// Render displays the search query with optional count and context window parameters
+// Render displays the search query with optional pattern type, count, page and context window parameters
 func (sr sourcegraphRenderer) Render(v *toolCallCmp) string {
 	var params tools.SourcegraphParams
 	var args []string
 	if err := sr.unmarshalParams(v.call.Input, &params); err == nil {
 		args = newParamBuilder().
 			addMain(params.Query).
+			addKeyValue("pattern", params.PatternType).
 			addKeyValue("count", formatNonZero(params.Count)).
+			addKeyValue("page", formatNonZero(params.Page)).
 			addKeyValue("context", formatNonZero(params.ContextWindow)).
 			build()
 	}
@@ -850,6 +1023,185 @@ func (dr diagnosticsRenderer) Render(v *toolCallCmp) string {
 	})
 }
 
+// -----------------------------------------------------------------------------
+//  Run tests renderer
+// -----------------------------------------------------------------------------
+
+// runTestsRenderer handles test runs
+type runTestsRenderer struct {
+	baseRenderer
+}
+
+// Render displays the counts of the tests with the output of the failures
+func (rr runTestsRenderer) Render(v *toolCallCmp) string {
+	var params tools.RunTestsParams
+	var args []string
+	if err := rr.unmarshalParams(v.call.Input, &params); err == nil {
+		main := "changed files"
+		switch {
+		case params.All:
+			main = "all"
+		case len(params.Files) > 0:
+			main = strings.Join(params.Files, " ")
+		}
+		args = newParamBuilder().
+			addMain(main).
+			addKeyValue("filter", params.Filter).
+			build()
+	}
+
+	return rr.renderWithParams(v, "Run Tests", args, func() string {
+		var meta tools.RunTestsResponseMetadata
+		if err := rr.unmarshalParams(v.result.Metadata, &meta); err != nil || len(meta.Runs) == 0 {
+			return renderPlainContent(v, v.result.Content)
+		}
+		frameworks := make([]string, len(meta.Runs))
+		for i, run := range meta.Runs {
+			frameworks[i] = run.Framework
+		}
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%s: %d passed, %d failed, %d skipped", strings.Join(frameworks, ", "), meta.Passed, meta.Failed, meta.Skipped)
+		for _, f := range meta.Failures {
+			name := f.Name
+			if f.Location != "" {
+				name = f.Location + " " + name
+			}
+			fmt.Fprintf(&sb, "\n\nFAIL %s\n%s", name, f.Output)
+		}
+		if len(meta.Coverage) > 0 {
+			sb.WriteString("\n\nCoverage:")
+			for _, fc := range meta.Coverage {
+				fmt.Fprintf(&sb, "\n  %s %d%%", fc.File, fc.Percent())
+			}
+		}
+		return renderPlainContent(v, sb.String())
+	})
+}
+
+// -----------------------------------------------------------------------------
+//  Project command renderer
+// -----------------------------------------------------------------------------
+
+// projectCommandRenderer handles the commands the project declares
+type projectCommandRenderer struct {
+	baseRenderer
+}
+
+// Render displays the declared command with its output
+func (pr projectCommandRenderer) Render(v *toolCallCmp) string {
+	var meta tools.ProjectCommandResponseMetadata
+	var args []string
+	if v.call.Finished {
+		if err := pr.unmarshalParams(v.result.Metadata, &meta); err == nil {
+			args = newParamBuilder().addMain(strings.ReplaceAll(meta.Command, "\n", " ")).build()
+		}
+	}
+
+	return pr.renderWithParams(v, prettifyToolName(v.call.Name), args, func() string {
+		if meta.Command == "" {
+			return renderPlainContent(v, v.result.Content)
+		}
+		if meta.Output == "" {
+			return ""
+		}
+		return renderPlainContent(v, meta.Output)
+	})
+}
+
+// -----------------------------------------------------------------------------
+//  Todo renderer
+// -----------------------------------------------------------------------------
+
+// todoRenderer handles task list updates
+type todoRenderer struct {
+	baseRenderer
+}
+
+// Render displays the task list as a checklist
+func (tr todoRenderer) Render(v *toolCallCmp) string {
+	var params tools.TodoParams
+	var args []string
+	if err := tr.unmarshalParams(v.call.Input, &params); err == nil {
+		var done int
+		for _, todo := range params.Todos {
+			if todo.Status == string(session.TodoStatusCompleted) {
+				done++
+			}
+		}
+		args = newParamBuilder().
+			addMain(fmt.Sprintf("%d/%d done", done, len(params.Todos))).
+			build()
+	}
+
+	return tr.renderWithParams(v, "Todo", args, func() string {
+		var meta tools.TodoResponseMetadata
+		if err := tr.unmarshalParams(v.result.Metadata, &meta); err != nil || len(meta.Todos) == 0 {
+			return renderPlainContent(v, v.result.Content)
+		}
+		return todos.RenderTodoBlock(meta.Todos, todos.RenderOptions{
+			MaxWidth: v.textWidth() - 2,
+			MaxItems: responseContextHeight,
+		})
+	})
+}
+
+// -----------------------------------------------------------------------------
+//  Compare renderer
+// -----------------------------------------------------------------------------
+
+// compareRenderer handles answers of the same prompt by several models
+type compareRenderer struct {
+	baseRenderer
+}
+
+// Render displays the answers side by side with what each of them took
+func (cr compareRenderer) Render(v *toolCallCmp) string {
+	var params agent.CompareParams
+	_ = cr.unmarshalParams(v.call.Input, &params)
+	args := newParamBuilder().addMain(strings.Join(params.Models, " vs ")).build()
+
+	return cr.renderWithParams(v, "Compare", args, func() string {
+		var meta agent.CompareMetadata
+		if err := cr.unmarshalParams(v.result.Metadata, &meta); err != nil || len(meta.Results) == 0 {
+			return renderMarkdownContent(v, v.result.Content)
+		}
+		const gap = 2
+		width := (v.textWidth() - 2 - gap*(len(meta.Results)-1)) / len(meta.Results)
+		columns := make([]string, 0, len(meta.Results)*2)
+		for i, r := range meta.Results {
+			if i > 0 {
+				columns = append(columns, strings.Repeat(" ", gap))
+			}
+			columns = append(columns, renderCompareResult(r, width))
+		}
+		return lipgloss.JoinHorizontal(lipgloss.Top, columns...)
+	})
+}
+
+// renderCompareResult renders one model's answer in a column of width.
+func renderCompareResult(r agent.CompareResult, width int) string {
+	t := styles.CurrentTheme()
+	name := t.S().Base.Foreground(t.Blue).Bold(true).Render(ansi.Truncate(cmp.Or(r.Name, r.Model), width, "…"))
+	duration := (time.Duration(r.DurationMS) * time.Millisecond).Round(100 * time.Millisecond)
+	stats := t.S().Subtle.Render(ansi.Truncate(fmt.Sprintf("%s in · %s out · $%.4f · %s",
+		formatTokens(r.InputTokens), formatTokens(r.OutputTokens), r.Cost, duration), width, "…"))
+
+	var body string
+	if r.Error != "" {
+		body = t.S().Base.Foreground(t.Error).Width(width).Render(r.Error)
+	} else {
+		body = strings.TrimSpace(r.Response)
+		if rendered, err := styles.GetPlainMarkdownRenderer(width).Render(body); err == nil {
+			body = strings.TrimSpace(rendered)
+		}
+		if lines := strings.Split(body, "\n"); len(lines) > compareContextHeight {
+			body = strings.Join(lines[:compareContextHeight], "\n") + "\n" +
+				t.S().Muted.Render(fmt.Sprintf("… (%d lines)", len(lines)-compareContextHeight))
+		}
+	}
+	return lipgloss.NewStyle().Width(width).Render(lipgloss.JoinVertical(lipgloss.Left, name, stats, "", body))
+}
+
 // -----------------------------------------------------------------------------
 //  Task renderer
 // -----------------------------------------------------------------------------
@@ -916,7 +1268,7 @@ func (tr agentRenderer) Render(v *toolCallCmp) string {
 
 	if v.result.ToolCallID == "" {
 		v.spinning = true
-		parts = append(parts, "", v.anim.View())
+		parts = append(parts, "", v.renderSubAgentStatus())
 	} else {
 		v.spinning = false
 	}
@@ -991,7 +1343,7 @@ func earlyState(header string, v *toolCallCmp) (string, bool) {
 		if v.permissionRequested && !v.permissionGranted {
 			message = t.S().Base.Foreground(t.FgSubtle).Render("Requesting permission...")
 		} else {
-			message = t.S().Base.Foreground(t.FgSubtle).Render("Waiting for tool response...")
+			message = v.renderRunningStatus()
 		}
 	default:
 		return "", false
@@ -1163,18 +1515,32 @@ func prettifyToolName(name string) string {
 	switch name {
 	case agent.AgentToolName:
 		return "Agent"
+	case agent.CompareToolName:
+		return "Compare"
 	case tools.BashToolName:
 		return "Bash"
 	case tools.JobOutputToolName:
 		return "Job: Output"
 	case tools.JobKillToolName:
 		return "Job: Kill"
+	case tools.RunTestsToolName:
+		return "Run Tests"
+	case tools.ProjectCommandToolPrefix + "build":
+		return "Project: Build"
+	case tools.ProjectCommandToolPrefix + "lint":
+		return "Project: Lint"
+	case tools.ProjectCommandToolPrefix + "test":
+		return "Project: Test"
+	case tools.ProjectCommandToolPrefix + "format":
+		return "Project: Format"
 	case tools.DownloadToolName:
 		return "Download"
 	case tools.EditToolName:
 		return "Edit"
 	case tools.MultiEditToolName:
 		return "Multi-Edit"
+	case tools.ApplyPatchToolName:
+		return "Patch"
 	case tools.FetchToolName:
 		return "Fetch"
 	case tools.AgenticFetchToolName:
@@ -1185,6 +1551,10 @@ func prettifyToolName(name string) string {
 		return "Glob"
 	case tools.GrepToolName:
 		return "Grep"
+	case tools.GitLogToolName:
+		return "Git Log"
+	case tools.GitBlameToolName:
+		return "Git Blame"
 	case tools.LSToolName:
 		return "List"
 	case tools.SourcegraphToolName:
@@ -1193,6 +1563,8 @@ func prettifyToolName(name string) string {
 		return "View"
 	case tools.WriteToolName:
 		return "Write"
+	case tools.TodoToolName:
+		return "Todo"
 	default:
 		return name
 	}
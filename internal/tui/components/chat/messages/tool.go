@@ -43,6 +43,35 @@ type ToolCallCmp interface {
 	ID() string
 	SetPermissionRequested() // Mark permission request
 	SetPermissionGranted()   // Mark permission granted
+	// SetSubAgentProgress updates the status line of agent and agentic fetch
+	// tool calls.
+	SetSubAgentProgress(agent.SubAgentProgress)
+	// SetDownloadProgress updates the progress line of download tool calls.
+	SetDownloadProgress(tools.DownloadProgress)
+	// SetToolProgress updates the timeouts of tool calls that have any.
+	SetToolProgress(agent.ToolProgress)
+	// Running reports whether the tool call is being run.
+	Running() bool
+}
+
+// CancelSubAgentMsg asks to cancel the sub-agent started by a tool call.
+type CancelSubAgentMsg struct {
+	ToolCallID string
+}
+
+// SkipToolMsg asks to skip a running tool call.
+type SkipToolMsg struct {
+	ToolCallID string
+}
+
+// ExtendToolMsg asks to start the timeouts of a running tool call over.
+type ExtendToolMsg struct {
+	ToolCallID string
+}
+
+// OpenMatchesMsg asks to list the matches of a grep tool call.
+type OpenMatchesMsg struct {
+	Matches []tools.GrepMatch
 }
 
 // toolCallCmp implements the ToolCallCmp interface for displaying tool calls.
@@ -65,6 +94,12 @@ type toolCallCmp struct {
 	anim     util.Model // Animation component for pending states
 
 	nestedToolCalls []ToolCallCmp // Nested tool calls for hierarchical display
+
+	subAgent agent.SubAgentProgress // Progress of the sub-agent started by the tool call
+	download tools.DownloadProgress // Progress of the file received by a download tool call
+	progress agent.ToolProgress     // Timeouts of the tool call, if it has any
+
+	runningSince time.Time // When the tool call was seen to start running
 }
 
 // ToolCallOption provides functional options for configuring tool call components
@@ -168,6 +203,25 @@ func (m *toolCallCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		if key.Matches(msg, CopyKey) {
 			return m, m.copyTool()
 		}
+		if key.Matches(msg, SkipToolKey) {
+			switch {
+			case m.runsSubAgent():
+				return m, util.CmdHandler(CancelSubAgentMsg{ToolCallID: m.call.ID})
+			case m.isRunning():
+				return m, util.CmdHandler(SkipToolMsg{ToolCallID: m.call.ID})
+			}
+		}
+		if key.Matches(msg, ExtendToolKey) && m.isRunning() && m.extendable() {
+			return m, util.CmdHandler(ExtendToolMsg{ToolCallID: m.call.ID})
+		}
+		if key.Matches(msg, UsageKey) {
+			return m, util.CmdHandler(OpenUsageMsg{MessageID: m.parentMessageID})
+		}
+		if key.Matches(msg, OpenMatchesKey) {
+			if matches := m.grepMatches(); len(matches) > 0 {
+				return m, util.CmdHandler(OpenMatchesMsg{Matches: matches})
+			}
+		}
 	}
 	return m, nil
 }
@@ -175,6 +229,9 @@ func (m *toolCallCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 // View renders the tool call component based on its current state.
 // Shows either a pending animation or the tool-specific rendered result.
 func (m *toolCallCmp) View() string {
+	if accessible() {
+		return m.plainView()
+	}
 	box := m.style()
 
 	if !m.call.Finished && !m.cancelled {
@@ -189,6 +246,20 @@ func (m *toolCallCmp) View() string {
 	return box.Render(r.Render(m))
 }
 
+// plainView renders the tool call and its result as plain text.
+func (m *toolCallCmp) plainView() string {
+	t := styles.CurrentTheme()
+	var result *message.ToolResult
+	if m.result.ToolCallID != "" {
+		result = &m.result
+	}
+	text := message.PlainToolCall(m.call, result, m.cancelled)
+	for _, nested := range m.nestedToolCalls {
+		text += "\n" + nested.View()
+	}
+	return t.S().Text.Width(m.width).Render(text)
+}
+
 // State management methods
 
 // SetCancelled marks the tool call as cancelled
@@ -279,6 +350,11 @@ func (m *toolCallCmp) formatParametersForCopy() string {
 			parts = append(parts, fmt.Sprintf("**Edits:** %d", len(params.Edits)))
 			return strings.Join(parts, "\n")
 		}
+	case tools.ApplyPatchToolName:
+		var params tools.ApplyPatchParams
+		if json.Unmarshal([]byte(m.call.Input), &params) == nil {
+			return fmt.Sprintf("**Patch:**\n```diff\n%s\n```", strings.TrimSuffix(params.Patch, "\n"))
+		}
 	case tools.WriteToolName:
 		var params tools.WriteParams
 		if json.Unmarshal([]byte(m.call.Input), &params) == nil {
@@ -356,6 +432,9 @@ func (m *toolCallCmp) formatParametersForCopy() string {
 			if params.Timeout > 0 {
 				parts = append(parts, fmt.Sprintf("**Timeout:** %s", (time.Duration(params.Timeout)*time.Second).String()))
 			}
+			if params.SHA256 != "" {
+				parts = append(parts, fmt.Sprintf("**SHA256:** %s", params.SHA256))
+			}
 			return strings.Join(parts, "\n")
 		}
 	case tools.SourcegraphToolName:
@@ -363,9 +442,15 @@ func (m *toolCallCmp) formatParametersForCopy() string {
 		if json.Unmarshal([]byte(m.call.Input), &params) == nil {
 			var parts []string
 			parts = append(parts, fmt.Sprintf("**Query:** %s", params.Query))
+			if params.PatternType != "" {
+				parts = append(parts, fmt.Sprintf("**Pattern Type:** %s", params.PatternType))
+			}
 			if params.Count > 0 {
 				parts = append(parts, fmt.Sprintf("**Count:** %d", params.Count))
 			}
+			if params.Page > 0 {
+				parts = append(parts, fmt.Sprintf("**Page:** %d", params.Page))
+			}
 			if params.ContextWindow > 0 {
 				parts = append(parts, fmt.Sprintf("**Context:** %d", params.ContextWindow))
 			}
@@ -406,6 +491,8 @@ func (m *toolCallCmp) formatResultForCopy() string {
 		return m.formatEditResultForCopy()
 	case tools.MultiEditToolName:
 		return m.formatMultiEditResultForCopy()
+	case tools.ApplyPatchToolName:
+		return m.formatApplyPatchResultForCopy()
 	case tools.WriteToolName:
 		return m.formatWriteResultForCopy()
 	case tools.FetchToolName:
@@ -416,7 +503,7 @@ func (m *toolCallCmp) formatResultForCopy() string {
 		return m.formatWebFetchResultForCopy()
 	case agent.AgentToolName:
 		return m.formatAgentResultForCopy()
-	case tools.DownloadToolName, tools.GrepToolName, tools.GlobToolName, tools.LSToolName, tools.SourcegraphToolName, tools.DiagnosticsToolName:
+	case tools.DownloadToolName, tools.GrepToolName, tools.GlobToolName, tools.LSToolName, tools.SourcegraphToolName, tools.DiagnosticsToolName, tools.GitLogToolName, tools.GitBlameToolName:
 		return fmt.Sprintf("```\n%s\n```", m.result.Content)
 	default:
 		return m.result.Content
@@ -561,6 +648,23 @@ func (m *toolCallCmp) formatMultiEditResultForCopy() string {
 	return result.String()
 }
 
+func (m *toolCallCmp) formatApplyPatchResultForCopy() string {
+	var meta tools.ApplyPatchResponseMetadata
+	if m.result.Metadata == "" || json.Unmarshal([]byte(m.result.Metadata), &meta) != nil {
+		return m.result.Content
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "Changes: +%d -%d\n", meta.Additions, meta.Removals)
+	result.WriteString("```diff\n")
+	for _, file := range meta.Files {
+		diffContent, _, _ := diff.GenerateDiff(file.OldContent, file.NewContent, fsext.PrettyPath(file.FilePath))
+		result.WriteString(diffContent)
+	}
+	result.WriteString("```")
+	return result.String()
+}
+
 func (m *toolCallCmp) formatWriteResultForCopy() string {
 	var params tools.WriteParams
 	if json.Unmarshal([]byte(m.call.Input), &params) != nil {
@@ -708,6 +812,9 @@ func (m *toolCallCmp) SetToolCall(call message.ToolCall) {
 	if m.call.Finished {
 		m.spinning = false
 	}
+	if m.runningSince.IsZero() && m.isRunning() {
+		m.runningSince = time.Now()
+	}
 }
 
 // ParentMessageID returns the ID of the message that initiated this tool call
@@ -850,6 +957,173 @@ func (m *toolCallCmp) Spinning() bool {
 	return m.spinning
 }
 
+// SetSubAgentProgress updates the progress of the sub-agent started by the
+// tool call.
+func (m *toolCallCmp) SetSubAgentProgress(p agent.SubAgentProgress) {
+	m.subAgent = p
+}
+
+// SetDownloadProgress updates the progress of the file received by the tool
+// call.
+func (m *toolCallCmp) SetDownloadProgress(p tools.DownloadProgress) {
+	m.download = p
+}
+
+// SetToolProgress updates the timeouts of the tool call.
+func (m *toolCallCmp) SetToolProgress(p agent.ToolProgress) {
+	m.progress = p
+}
+
+// Running reports whether the tool call is being run.
+func (m *toolCallCmp) Running() bool {
+	return m.isRunning()
+}
+
+// isRunning reports whether the tool call is being run, waiting for its
+// result.
+func (m *toolCallCmp) isRunning() bool {
+	return m.call.Finished && !m.cancelled && m.result.ToolCallID == ""
+}
+
+// extendable reports whether the tool call runs with a timeout or a soft
+// timeout that can be started over.
+func (m *toolCallCmp) extendable() bool {
+	return !m.progress.StartedAt.IsZero() && !m.progress.Done
+}
+
+// elapsed returns how long the tool call has been running at now, or 0 if
+// it isn't known.
+func (m *toolCallCmp) elapsed(now time.Time) time.Duration {
+	start := m.runningSince
+	if !m.progress.StartedAt.IsZero() {
+		start = m.progress.StartedAt
+	}
+	if start.IsZero() || (m.permissionRequested && !m.permissionGranted) {
+		return 0
+	}
+	return now.Sub(start)
+}
+
+// showElapsedAfter is how long a tool call runs before the time it has
+// been running is shown.
+const showElapsedAfter = 3 * time.Second
+
+// renderRunningStatus renders the status line of a running tool call: how
+// long it has been running, and a warning once it runs past its soft
+// timeout, e.g. "Running for 1m12s, longer than expected · stops in 48s".
+func (m *toolCallCmp) renderRunningStatus() string {
+	t := styles.CurrentTheme()
+	now := time.Now()
+	elapsed := m.elapsed(now)
+	if elapsed < showElapsedAfter {
+		return t.S().Base.Foreground(t.FgSubtle).Render("Waiting for tool response...")
+	}
+	status := "Running for " + formatElapsed(elapsed)
+	if !m.progress.Slow(now) {
+		return t.S().Base.Foreground(t.FgSubtle).Render(status + "...")
+	}
+	status += ", longer than expected"
+	if !m.progress.Deadline.IsZero() {
+		status += " · stops in " + formatElapsed(max(m.progress.Deadline.Sub(now), 0))
+	}
+	status = t.S().Warning.Render(status)
+	if m.focused {
+		status += t.S().Muted.Render(" · e to extend, x to skip")
+	}
+	return status
+}
+
+// formatElapsed formats a duration to the second, e.g. 1m12s.
+func formatElapsed(d time.Duration) string {
+	return d.Round(time.Second).String()
+}
+
+// grepMatches returns the matches found by a grep tool call.
+func (m *toolCallCmp) grepMatches() []tools.GrepMatch {
+	if m.call.Name != tools.GrepToolName || m.result.IsError || m.result.Metadata == "" {
+		return nil
+	}
+	var meta tools.GrepResponseMetadata
+	if err := json.Unmarshal([]byte(m.result.Metadata), &meta); err != nil {
+		return nil
+	}
+	return meta.Matches
+}
+
+// runsSubAgent reports whether the tool call has a sub-agent that can be
+// cancelled.
+func (m *toolCallCmp) runsSubAgent() bool {
+	if m.cancelled || m.result.ToolCallID != "" || m.subAgent.Status == agent.SubAgentDone {
+		return false
+	}
+	return m.call.Name == agent.AgentToolName || m.call.Name == tools.AgenticFetchToolName
+}
+
+// renderSubAgentStatus renders the live status line of a sub-agent, e.g.
+// "running Bash · 12.3K tokens".
+func (m *toolCallCmp) renderSubAgentStatus() string {
+	t := styles.CurrentTheme()
+	var parts []string
+	switch m.subAgent.Status {
+	case agent.SubAgentQueued:
+		parts = append(parts, "queued")
+	case agent.SubAgentRunning:
+		if m.subAgent.Tool != "" {
+			parts = append(parts, "running "+prettifyToolName(m.subAgent.Tool))
+		} else {
+			parts = append(parts, "thinking")
+		}
+	default:
+		return m.anim.View()
+	}
+	if m.subAgent.Tokens > 0 {
+		parts = append(parts, formatTokens(m.subAgent.Tokens)+" tokens")
+	}
+	status := t.S().Subtle.Render(strings.Join(parts, " · "))
+	if m.focused {
+		status += t.S().Muted.Render(" · x to cancel")
+	}
+	return m.anim.View() + " " + status
+}
+
+// formatDownloadProgress formats the progress of a download, e.g.
+// "12.3 MB of 40.0 MB · 30%".
+func formatDownloadProgress(p tools.DownloadProgress) string {
+	if p.Total <= 0 {
+		return formatBytes(p.Downloaded)
+	}
+	return fmt.Sprintf("%s of %s · %d%%", formatBytes(p.Downloaded), formatBytes(p.Total), p.Downloaded*100/p.Total)
+}
+
+// formatBytes formats a size in a human-readable way, e.g. 12.3 MB.
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+// formatTokens formats a token count in a human-readable way, e.g. 12.3K.
+func formatTokens(tokens int64) string {
+	var s string
+	switch {
+	case tokens >= 1_000_000:
+		s = fmt.Sprintf("%.1fM", float64(tokens)/1_000_000)
+	case tokens >= 1_000:
+		s = fmt.Sprintf("%.1fK", float64(tokens)/1_000)
+	default:
+		return fmt.Sprintf("%d", tokens)
+	}
+	s = strings.Replace(s, ".0K", "K", 1)
+	return strings.Replace(s, ".0M", "M", 1)
+}
+
 func (m *toolCallCmp) ID() string {
 	return m.call.ID
 }
@@ -862,4 +1136,6 @@ func (m *toolCallCmp) SetPermissionRequested() {
 // SetPermissionGranted marks that permission was granted for this tool call
 func (m *toolCallCmp) SetPermissionGranted() {
 	m.permissionGranted = true
+	// The call runs once allowed.
+	m.runningSince = time.Now()
 }
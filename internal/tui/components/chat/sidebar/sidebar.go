@@ -25,6 +25,7 @@ import (
 	"github.com/charmbracelet/crush/internal/tui/components/logo"
 	lspcomponent "github.com/charmbracelet/crush/internal/tui/components/lsp"
 	"github.com/charmbracelet/crush/internal/tui/components/mcp"
+	"github.com/charmbracelet/crush/internal/tui/components/todos"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/tui/util"
 	"github.com/charmbracelet/crush/internal/version"
@@ -44,6 +45,7 @@ const (
 	DefaultMaxFilesShown = 10
 	DefaultMaxLSPsShown  = 8
 	DefaultMaxMCPsShown  = 8
+	DefaultMaxTodosShown = 8
 	MinItemsPerSection   = 2 // Minimum items to show per section
 )
 
@@ -62,11 +64,15 @@ type Sidebar interface {
 	layout.Sizeable
 	SetSession(session session.Session) tea.Cmd
 	SetCompactMode(bool)
+	// SetReasoningLevel shows the reasoning level set for the session, if
+	// any, instead of the configured one.
+	SetReasoningLevel(level string)
 }
 
 type sidebarCmp struct {
 	width, height int
 	session       session.Session
+	reasoning     string
 	logo          string
 	cwd           string
 	lspClients    *csync.Map[string, *lsp.Client]
@@ -159,6 +165,9 @@ func (m *sidebarCmp) View() string {
 		}
 	} else {
 		// Vertical layout (default)
+		if len(m.session.Todos) > 0 {
+			parts = append(parts, "", m.todosBlock())
+		}
 		if m.session.ID != "" {
 			parts = append(parts, "", m.filesBlock())
 		}
@@ -320,6 +329,11 @@ func (m *sidebarCmp) calculateAvailableHeight() int {
 
 	usedHeight += 6 // 3 sections × 2 lines each (header + empty line)
 
+	if len(m.session.Todos) > 0 {
+		usedHeight += 3 // Header, empty line, and empty line after the block
+		usedHeight += min(len(m.session.Todos), DefaultMaxTodosShown+1)
+	}
+
 	// Base padding
 	usedHeight += 2 // Top and bottom padding
 
@@ -477,6 +491,20 @@ func (m *sidebarCmp) filesBlock() string {
 	}, true)
 }
 
+func (m *sidebarCmp) todosBlock() string {
+	todoList := m.session.Todos
+	return todos.RenderTodoBlock(todoList, todos.RenderOptions{
+		MaxWidth:    m.getMaxWidth(),
+		MaxItems:    DefaultMaxTodosShown,
+		ShowSection: true,
+		SectionName: core.SectionWithInfo(
+			"Todos",
+			m.getMaxWidth(),
+			fmt.Sprintf("%d/%d", todos.Done(todoList), len(todoList)),
+		),
+	})
+}
+
 func (m *sidebarCmp) lspBlock() string {
 	// Limit the number of LSPs shown
 	_, maxLSPs, _ := m.getDynamicLimits()
@@ -505,9 +533,9 @@ func (m *sidebarCmp) mcpBlock() string {
 	}, true)
 }
 
-func formatTokensAndCost(tokens, contextWindow int64, cost float64) string {
-	t := styles.CurrentTheme()
-	// Format tokens in human-readable format (e.g., 110K, 1.2M)
+// formatTokens formats a number of tokens in human-readable format (e.g.,
+// 110K, 1.2M).
+func formatTokens(tokens int64) string {
 	var formattedTokens string
 	switch {
 	case tokens >= 1_000_000:
@@ -525,6 +553,12 @@ func formatTokensAndCost(tokens, contextWindow int64, cost float64) string {
 	if strings.HasSuffix(formattedTokens, ".0M") {
 		formattedTokens = strings.Replace(formattedTokens, ".0M", "M", 1)
 	}
+	return formattedTokens
+}
+
+func formatTokensAndCost(tokens, contextWindow int64, cost float64) string {
+	t := styles.CurrentTheme()
+	formattedTokens := formatTokens(tokens)
 
 	percentage := (float64(tokens) / float64(contextWindow)) * 100
 
@@ -543,6 +577,22 @@ func formatTokensAndCost(tokens, contextWindow int64, cost float64) string {
 	return fmt.Sprintf("%s %s", formattedTokens, formattedCost)
 }
 
+// formatCacheUsage shows how much of the session's input was read from the
+// prompt cache, if anything was cached.
+func formatCacheUsage(session session.Session) string {
+	if session.InputTokens == 0 || session.CacheReadTokens+session.CacheCreationTokens == 0 {
+		return ""
+	}
+	t := styles.CurrentTheme()
+	hitRate := float64(session.CacheReadTokens) / float64(session.InputTokens) * 100
+	return t.S().Base.Foreground(t.FgSubtle).Render(fmt.Sprintf(
+		"Cache %d%% hits (%s read, %s written)",
+		int(hitRate),
+		formatTokens(session.CacheReadTokens),
+		formatTokens(session.CacheCreationTokens),
+	))
+}
+
 func (s *sidebarCmp) currentModelBlock() string {
 	cfg := config.Get()
 	agentCfg := cfg.Agents[config.AgentCoder]
@@ -562,8 +612,11 @@ func (s *sidebarCmp) currentModelBlock() string {
 	}
 	if model.CanReason {
 		reasoningInfoStyle := t.S().Subtle.PaddingLeft(2)
-		switch modelProvider.Type {
-		case catwalk.TypeAnthropic:
+		switch {
+		case s.reasoning != "":
+			formatter := cases.Title(language.English, cases.NoLower)
+			parts = append(parts, reasoningInfoStyle.Render(formatter.String(fmt.Sprintf("Reasoning %s (session)", s.reasoning))))
+		case modelProvider.Type == catwalk.TypeAnthropic:
 			formatter := cases.Title(language.English, cases.NoLower)
 			if selectedModel.Think {
 				parts = append(parts, reasoningInfoStyle.Render(formatter.String("Thinking on")))
@@ -589,6 +642,9 @@ func (s *sidebarCmp) currentModelBlock() string {
 			),
 		)
 	}
+	if cacheUsage := formatCacheUsage(s.session); cacheUsage != "" {
+		parts = append(parts, "  "+cacheUsage)
+	}
 	return lipgloss.JoinVertical(
 		lipgloss.Left,
 		parts...,
@@ -601,6 +657,11 @@ func (m *sidebarCmp) SetSession(session session.Session) tea.Cmd {
 	return m.loadSessionFiles
 }
 
+// SetReasoningLevel implements Sidebar.
+func (m *sidebarCmp) SetReasoningLevel(level string) {
+	m.reasoning = level
+}
+
 // SetCompactMode sets the compact mode for the sidebar.
 func (m *sidebarCmp) SetCompactMode(compact bool) {
 	m.compactMode = compact
@@ -467,7 +467,7 @@ func (s *splashCmp) initializeProject() tea.Cmd {
 
 	cmds = append(cmds, util.CmdHandler(OnboardingCompleteMsg{}))
 	if !s.selectedNo {
-		initPrompt, err := agent.InitializePrompt(*config.Get())
+		initPrompt, err := agent.InitializePrompt(*config.Get(), nil)
 		if err != nil {
 			return util.ReportError(err)
 		}
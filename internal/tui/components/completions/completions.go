@@ -1,6 +1,7 @@
 package completions
 
 import (
+	"fmt"
 	"strings"
 
 	"charm.land/bubbles/v2/key"
@@ -11,11 +12,22 @@ import (
 	"github.com/charmbracelet/crush/internal/tui/util"
 )
 
-const maxCompletionsHeight = 10
+const (
+	maxCompletionsHeight = 10
+	minPreviewWidth      = 20
+	maxPreviewWidth      = 60
+)
 
 type Completion struct {
 	Title string // The title of the completion item
 	Value any    // The value of the completion item
+	Rank  int    // Added to the match score when filtering, see [list.HasRank]
+}
+
+// Previewer is implemented by completion values that can show a preview
+// next to the completions popup while they are highlighted.
+type Previewer interface {
+	Preview(width, height int) string
 }
 
 type OpenCompletionsMsg struct {
@@ -72,6 +84,11 @@ type completionsCmp struct {
 
 	list  listModel
 	query string // The current filter query
+
+	// The preview of the highlighted item is cached, as reading it on every
+	// render would be too slow.
+	previewKey string
+	preview    string
 }
 
 func New() Completions {
@@ -181,6 +198,7 @@ func (c *completionsCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 				completion.Title,
 				completion.Value,
 				list.WithCompletionBackgroundColor(t.BgSubtle),
+				list.WithCompletionRank(completion.Rank),
 			)
 			items = append(items, item)
 		}
@@ -263,7 +281,47 @@ func (c *completionsCmp) View() string {
 		Height(c.height).
 		Background(t.BgSubtle)
 
-	return style.Render(c.list.View())
+	view := style.Render(c.list.View())
+	if preview := c.renderPreview(); preview != "" {
+		view = lipgloss.JoinHorizontal(lipgloss.Top, view, preview)
+	}
+	return view
+}
+
+// renderPreview renders the preview of the highlighted item, if it has one
+// and there is room for it to the right of the popup.
+func (c *completionsCmp) renderPreview() string {
+	s := c.list.SelectedItem()
+	if s == nil {
+		return ""
+	}
+	previewer, ok := (*s).Value().(Previewer)
+	if !ok {
+		return ""
+	}
+	width := min(maxPreviewWidth, c.wWidth-c.x-c.width-1)
+	if width < minPreviewWidth {
+		return ""
+	}
+
+	key := fmt.Sprintf("%s:%d:%d", (*s).ID(), width, c.height)
+	if key != c.previewKey {
+		// Leave room for the padding.
+		c.preview = previewer.Preview(width-2, c.height)
+		c.previewKey = key
+	}
+	if c.preview == "" {
+		return ""
+	}
+
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(width).
+		Height(c.height).
+		Padding(0, 1).
+		Background(t.BgBase).
+		Foreground(t.FgMuted).
+		Render(c.preview)
 }
 
 // listWidth returns the width of the last 10 items in the list, which is used
@@ -7,8 +7,8 @@ import (
 	"charm.land/bubbles/v2/help"
 	"charm.land/bubbles/v2/key"
 	"charm.land/lipgloss/v2"
-	"github.com/alecthomas/chroma/v2"
 	"github.com/charmbracelet/crush/internal/tui/exp/diffview"
+	"github.com/charmbracelet/crush/internal/tui/highlight"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/x/ansi"
 )
@@ -201,7 +201,10 @@ func SelectableButtonsVertical(buttons []ButtonOpts, spacing int) string {
 func DiffFormatter() *diffview.DiffView {
 	t := styles.CurrentTheme()
 	formatDiff := diffview.New()
-	style := chroma.MustNewStyle("crush", styles.GetChromaTheme())
-	diff := formatDiff.ChromaStyle(style).Style(t.S().Diff).TabWidth(4)
+	diff := formatDiff.
+		ChromaStyle(styles.GetChromaStyle()).
+		ChromaLexer(highlight.Lexer).
+		Style(t.S().Diff).
+		TabWidth(4)
 	return diff
 }
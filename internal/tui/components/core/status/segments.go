@@ -0,0 +1,112 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Built-in segments of the status bar.
+const (
+	segmentModel   = "model"
+	segmentCost    = "cost"
+	segmentContext = "context"
+	segmentLSP     = "lsp"
+)
+
+const (
+	// segmentTimeout is how long the command of a custom segment can run.
+	segmentTimeout = 5 * time.Second
+	// maxSegmentWidth is how wide the output of a custom segment can be.
+	maxSegmentWidth = 40
+)
+
+// segmentMsg carries the output of the command of a custom segment.
+type segmentMsg struct {
+	segment config.StatusSegment
+	output  string
+}
+
+// runSegments runs the commands of the custom segments of the status bar.
+func runSegments() tea.Cmd {
+	var cmds []tea.Cmd
+	for _, segment := range config.Get().Options.TUI.StatusBar.Custom {
+		cmds = append(cmds, runSegment(segment))
+	}
+	return tea.Batch(cmds...)
+}
+
+// runSegment runs the command of segment and returns its output.
+func runSegment(segment config.StatusSegment) tea.Cmd {
+	return func() tea.Msg {
+		cfg := config.Get()
+		ctx, cancel := context.WithTimeout(context.Background(), segmentTimeout)
+		defer cancel()
+		sh := shell.NewShell(&shell.Options{
+			WorkingDir: cfg.WorkingDir(),
+			Type:       cfg.Options.Tools.ShellType(),
+		})
+		stdout, _, err := sh.Exec(ctx, segment.Command)
+		if err != nil {
+			slog.Debug("Status bar segment failed", "segment", segment.Name, "error", err)
+		}
+		return segmentMsg{segment: segment, output: segmentOutput(stdout)}
+	}
+}
+
+// segmentOutput returns what a custom segment shows of the output of its
+// command: the first line that isn't empty.
+func segmentOutput(output string) string {
+	for line := range strings.SplitSeq(output, "\n") {
+		if line = strings.TrimSpace(ansi.Strip(line)); line != "" {
+			return ansi.Truncate(line, maxSegmentWidth, "…")
+		}
+	}
+	return ""
+}
+
+// segments renders the segments of the status bar, leaving out the ones with
+// nothing to show.
+func (m *statusCmp) segments() string {
+	t := styles.CurrentTheme()
+	cfg := config.Get()
+	var parts []string
+	for _, name := range cfg.Options.TUI.StatusBar.SegmentNames() {
+		var part string
+		switch name {
+		case segmentModel:
+			model := cfg.GetModelByType(cfg.Agents[config.AgentCoder].Model)
+			if model != nil {
+				part = model.Name
+			}
+		case segmentCost:
+			if m.session.ID != "" {
+				part = fmt.Sprintf("$%.2f", m.session.Cost)
+			}
+		case segmentContext:
+			model := cfg.GetModelByType(cfg.Agents[config.AgentCoder].Model)
+			if m.session.ID != "" && model != nil && model.ContextWindow > 0 {
+				tokens := m.session.CompletionTokens + m.session.PromptTokens
+				part = fmt.Sprintf("%d%% context", tokens*100/model.ContextWindow)
+			}
+		case segmentLSP:
+			if m.lspClients != nil && m.lspClients.Len() > 0 {
+				part = fmt.Sprintf("%d LSP", m.lspClients.Len())
+			}
+		default:
+			part = m.custom[name]
+		}
+		if part != "" {
+			parts = append(parts, t.S().Muted.Render(part))
+		}
+	}
+	return strings.Join(parts, t.S().Subtle.Render(" • "))
+}
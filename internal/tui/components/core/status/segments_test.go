@@ -0,0 +1,17 @@
+package status
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSegmentOutput(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "main", segmentOutput("main\n"))
+	require.Equal(t, "prod-cluster", segmentOutput("\n  \x1b[32mprod-cluster\x1b[0m  \nother\n"))
+	require.Empty(t, segmentOutput(" \n"))
+	require.Equal(t, strings.Repeat("a", maxSegmentWidth-1)+"…", segmentOutput(strings.Repeat("a", 100)))
+}
@@ -1,11 +1,16 @@
 package status
 
 import (
+	"strings"
 	"time"
 
 	"charm.land/bubbles/v2/help"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/tui/util"
 	"github.com/charmbracelet/x/ansi"
@@ -15,6 +20,7 @@ type StatusCmp interface {
 	util.Model
 	ToggleFullHelp()
 	SetKeyMap(keyMap help.KeyMap)
+	SetSession(session session.Session)
 }
 
 type statusCmp struct {
@@ -23,6 +29,11 @@ type statusCmp struct {
 	messageTTL time.Duration
 	help       help.Model
 	keyMap     help.KeyMap
+
+	session    session.Session
+	lspClients *csync.Map[string, *lsp.Client]
+	// custom is the output of the custom segments by name.
+	custom map[string]string
 }
 
 // clearMessageCmd is a command that clears status messages after a timeout
@@ -33,7 +44,7 @@ func (m *statusCmp) clearMessageCmd(ttl time.Duration) tea.Cmd {
 }
 
 func (m *statusCmp) Init() tea.Cmd {
-	return nil
+	return runSegments()
 }
 
 func (m *statusCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
@@ -53,17 +64,37 @@ func (m *statusCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		return m, m.clearMessageCmd(ttl)
 	case util.ClearStatusMsg:
 		m.info = util.InfoMsg{}
+	case segmentMsg:
+		m.custom[msg.segment.Name] = msg.output
+		return m, tea.Tick(msg.segment.IntervalDuration(), func(time.Time) tea.Msg {
+			return runSegment(msg.segment)()
+		})
+	case pubsub.Event[session.Session]:
+		if msg.Type == pubsub.UpdatedEvent && msg.Payload.ID == m.session.ID {
+			m.session = msg.Payload
+		}
 	}
 	return m, nil
 }
 
 func (m *statusCmp) View() string {
 	t := styles.CurrentTheme()
-	status := t.S().Base.Padding(0, 1, 1, 1).Render(m.help.View(m.keyMap))
 	if m.info.Msg != "" {
-		status = m.infoMsg()
+		return m.infoMsg()
+	}
+	segments := m.segments()
+	if segments == "" {
+		return t.S().Base.Padding(0, 1, 1, 1).Render(m.help.View(m.keyMap))
 	}
-	return status
+	// The segments take up to half of the line, and the help makes room for
+	// them on the right.
+	segments = ansi.Truncate(segments, max(0, m.width/2), "…")
+	segmentsWidth := lipgloss.Width(segments)
+	m.help.SetWidth(max(0, m.width-segmentsWidth-3))
+	defer m.help.SetWidth(m.width - 2)
+	help := m.help.View(m.keyMap)
+	gap := strings.Repeat(" ", max(1, m.width-2-lipgloss.Width(help)-segmentsWidth))
+	return t.S().Base.Padding(0, 1, 1, 1).Render(lipgloss.JoinHorizontal(lipgloss.Top, help, gap, segments))
 }
 
 func (m *statusCmp) infoMsg() string {
@@ -102,12 +133,19 @@ func (m *statusCmp) SetKeyMap(keyMap help.KeyMap) {
 	m.keyMap = keyMap
 }
 
-func NewStatusCmp() StatusCmp {
+// SetSession sets the session the cost and context segments are about.
+func (m *statusCmp) SetSession(session session.Session) {
+	m.session = session
+}
+
+func NewStatusCmp(lspClients *csync.Map[string, *lsp.Client]) StatusCmp {
 	t := styles.CurrentTheme()
 	help := help.New()
 	help.Styles = t.S().Help
 	return &statusCmp{
 		messageTTL: 5 * time.Second,
 		help:       help,
+		lspClients: lspClients,
+		custom:     make(map[string]string),
 	}
 }
@@ -0,0 +1,191 @@
+// Package attachment previews an attachment of the prompt before it's sent.
+package attachment
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/components/image"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const (
+	PreviewDialogID dialogs.DialogID = "attachment_preview"
+
+	defaultWidth  = 80
+	previewHeight = 20
+)
+
+// PreviewDialog shows what an attachment holds.
+type PreviewDialog interface {
+	dialogs.DialogModel
+}
+
+type KeyMap struct {
+	Close key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Close: key.NewBinding(
+			key.WithKeys("esc", "enter", "q"),
+			key.WithHelp("esc", "close"),
+		),
+	}
+}
+
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Close}
+}
+
+func (k KeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+// previewRenderedMsg carries the preview drawn for a size of the dialog.
+type previewRenderedMsg struct {
+	width, height int
+	preview       string
+	err           error
+}
+
+type previewDialogCmp struct {
+	wWidth, wHeight int
+	width, height   int
+
+	attachment message.Attachment
+	preview    string
+	err        error
+	keyMap     KeyMap
+	help       help.Model
+}
+
+// NewPreviewDialog creates a dialog previewing attachment, with the image
+// drawn in the terminal or the start of the text.
+func NewPreviewDialog(attachment message.Attachment) PreviewDialog {
+	t := styles.CurrentTheme()
+	help := help.New()
+	help.Styles = t.S().Help
+	return &previewDialogCmp{
+		attachment: attachment,
+		width:      defaultWidth,
+		keyMap:     DefaultKeyMap(),
+		help:       help,
+	}
+}
+
+func (p *previewDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (p *previewDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.wWidth = msg.Width
+		p.wHeight = msg.Height
+		p.width = min(defaultWidth, p.wWidth-8)
+		p.height = max(1, min(previewHeight, p.wHeight/2))
+		return p, p.render()
+	case previewRenderedMsg:
+		// Only keep the preview for the current size.
+		if msg.width == p.previewWidth() && msg.height == p.height {
+			p.preview, p.err = msg.preview, msg.err
+		}
+	case tea.KeyPressMsg:
+		if key.Matches(msg, p.keyMap.Close) {
+			return p, util.CmdHandler(dialogs.CloseDialogMsg{})
+		}
+	}
+	return p, nil
+}
+
+// render draws the preview in the background, as decoding large images takes
+// a while.
+func (p *previewDialogCmp) render() tea.Cmd {
+	width, height := p.previewWidth(), p.height
+	a := p.attachment
+	return func() tea.Msg {
+		msg := previewRenderedMsg{width: width, height: height}
+		switch {
+		case strings.HasPrefix(a.MimeType, "image/"):
+			msg.preview, msg.err = image.Render(uint(width), uint(height), a.Content)
+		case strings.HasPrefix(a.MimeType, "text/"):
+			lines := strings.Split(string(a.Content), "\n")
+			msg.preview = strings.Join(lines[:min(len(lines), height)], "\n")
+		default:
+			msg.err = fmt.Errorf("no preview for %s", a.MimeType)
+		}
+		return msg
+	}
+}
+
+func (p *previewDialogCmp) previewWidth() int {
+	return max(1, p.width-4)
+}
+
+func (p *previewDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	a := p.attachment
+	header := t.S().Base.Padding(0, 1, 1, 1).Render(core.Title(a.FileName, p.width-4))
+
+	details := fmt.Sprintf("%s · %s", a.MimeType, FormatSize(int64(len(a.Content))))
+	if a.FilePath != "" {
+		details += " · " + a.FilePath
+	}
+	details = t.S().Muted.Width(p.width - 4).MaxHeight(2).Render(details)
+
+	preview := p.preview
+	switch {
+	case p.err != nil:
+		preview = t.S().Error.Render(p.err.Error())
+	case preview == "":
+		preview = t.S().Muted.Render("Loading preview...")
+	}
+	preview = t.S().Base.Width(p.previewWidth()).MaxWidth(p.previewWidth()).MaxHeight(p.height).Render(preview)
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		header,
+		t.S().Base.PaddingLeft(1).Render(details),
+		"",
+		t.S().Base.PaddingLeft(1).Render(preview),
+		"",
+		t.S().Base.Width(p.width-2).PaddingLeft(1).Render(p.help.View(p.keyMap)),
+	)
+	return t.S().Base.
+		Width(p.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(content)
+}
+
+func (p *previewDialogCmp) Position() (int, int) {
+	row := max(0, (p.wHeight-p.height-8)/2)
+	col := p.wWidth/2 - p.width/2
+	return row, col
+}
+
+func (p *previewDialogCmp) ID() dialogs.DialogID {
+	return PreviewDialogID
+}
+
+// FormatSize formats a size in a human-readable way, e.g. 1.2 MB.
+func FormatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
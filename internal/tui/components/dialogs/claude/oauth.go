@@ -68,7 +68,7 @@ func NewOAuth2() *OAuth2 {
 func (o *OAuth2) Init() tea.Cmd {
 	t := styles.CurrentTheme()
 
-	verifier, challenge, err := claude.GetChallenge()
+	verifier, challenge, err := oauth.GetChallenge()
 	if err != nil {
 		o.err = err
 		return nil
@@ -16,6 +16,7 @@ import (
 	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/drift"
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/tui/components/chat"
 	"github.com/charmbracelet/crush/internal/tui/components/core"
@@ -72,7 +73,14 @@ type commandDialogCmp struct {
 }
 
 type (
-	SwitchSessionsMsg      struct{}
+	SwitchSessionsMsg   struct{}
+	OpenRecallDialogMsg struct{}
+	// OpenPromptHistoryMsg searches the prompts submitted in the project.
+	OpenPromptHistoryMsg struct{}
+	// DiscardDraftMsg empties the editor and deletes its saved draft.
+	DiscardDraftMsg        struct{}
+	OpenSettingsDialogMsg  struct{}
+	OpenMCPDialogMsg       struct{}
 	NewSessionsMsg         struct{}
 	SwitchModelMsg         struct{}
 	QuitMsg                struct{}
@@ -81,13 +89,56 @@ type (
 	ToggleCompactModeMsg   struct{}
 	ToggleThinkingMsg      struct{}
 	OpenReasoningDialogMsg struct{}
+	CycleReasoningMsg      struct{}
 	OpenExternalEditorMsg  struct{}
 	ToggleYoloModeMsg      struct{}
 	CompactMsg             struct {
 		SessionID string
 	}
+	OpenRetryDialogMsg struct {
+		SessionID string
+	}
+	// RetryMsg asks to drop the last response of a session and send its
+	// message again, with Model, a provider and model ID separated by a
+	// slash, when it isn't empty.
+	RetryMsg struct {
+		SessionID string
+		Model     string
+	}
+	OpenCompareDialogMsg struct{}
+	// OpenTagsDialogMsg edits the tags of the session with SessionID.
+	OpenTagsDialogMsg struct {
+		SessionID string
+	}
+	ToggleFileViewMsg    struct{}
+	ToggleFileTreeMsg    struct{}
+	OpenPinFileDialogMsg struct{}
+	// OpenInitializeDialogMsg picks the template to initialize the project
+	// with.
+	OpenInitializeDialogMsg struct{}
+	// PinFileMsg keeps the file at Path in the file view, or follows the
+	// files the agent edits again when Path is empty.
+	PinFileMsg struct {
+		Path string
+	}
 )
 
+// OpenScopeDialogMsg changes the directory the file tools of the session
+// with SessionID, or of new sessions when it is empty, are restricted to.
+type OpenScopeDialogMsg struct {
+	SessionID string
+}
+
+// OpenDevcontainerDialogMsg asks again whether the tools run in the
+// devcontainer of the project.
+type OpenDevcontainerDialogMsg struct{}
+
+// OpenContextPreviewDialogMsg shows what the next request of the session
+// with SessionID sends to the model.
+type OpenContextPreviewDialogMsg struct {
+	SessionID string
+}
+
 func NewCommandDialog(sessionID string) CommandsDialog {
 	keyMap := DefaultCommandsDialogKeyMap()
 	listKeyMap := list.DefaultKeyMap()
@@ -125,8 +176,12 @@ func (c *commandDialogCmp) Init() tea.Cmd {
 	if err != nil {
 		return util.ReportError(err)
 	}
-	c.userCommands = commands
+	macros, err := c.loadMacros(config.Get().Options.TUI.Macros)
+	c.userCommands = append(commands, macros...)
 	c.mcpPrompts.SetSlice(loadMCPPrompts())
+	if err != nil {
+		return tea.Batch(util.ReportError(err), c.setCommandType(c.selected))
+	}
 	return c.setCommandType(c.selected)
 }
 
@@ -332,6 +387,39 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 				return util.CmdHandler(SwitchSessionsMsg{})
 			},
 		},
+		{
+			ID:          "recall_answer",
+			Title:       "Recall Answer",
+			Description: "Search previous answers and add one to the prompt",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenRecallDialogMsg{})
+			},
+		},
+		{
+			ID:          "prompt_history",
+			Title:       "Search Prompt History",
+			Description: "Search the prompts submitted in this project and edit one again",
+			Shortcut:    "alt+r",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenPromptHistoryMsg{})
+			},
+		},
+		{
+			ID:          "discard_draft",
+			Title:       "Discard Draft",
+			Description: "Empty the editor and delete its saved draft",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(DiscardDraftMsg{})
+			},
+		},
+		{
+			ID:          "compare_models",
+			Title:       "Compare Models (Experimental)",
+			Description: "Send the next prompt to two models side by side",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenCompareDialogMsg{})
+			},
+		},
 		{
 			ID:          "switch_model",
 			Title:       "Switch Model",
@@ -354,6 +442,33 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 					SessionID: c.sessionID,
 				})
 			},
+		}, Command{
+			ID:          "tag_session",
+			Title:       "Tag Session",
+			Description: "Edit the tags of the current session",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenTagsDialogMsg{
+					SessionID: c.sessionID,
+				})
+			},
+		}, Command{
+			ID:          "retry",
+			Title:       "Retry Last Response",
+			Description: "Drop the last response and send its message again",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(RetryMsg{
+					SessionID: c.sessionID,
+				})
+			},
+		}, Command{
+			ID:          "retry_with_model",
+			Title:       "Retry with Different Model",
+			Description: "Send the last message again with another model",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenRetryDialogMsg{
+					SessionID: c.sessionID,
+				})
+			},
 		})
 	}
 
@@ -381,6 +496,16 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 				})
 			}
 
+			commands = append(commands, Command{
+				ID:          "think",
+				Title:       "Think: Cycle Reasoning Level",
+				Shortcut:    "alt+t",
+				Description: "Cycle the reasoning of this session between off, low, medium and high",
+				Handler: func(cmd Command) tea.Cmd {
+					return util.CmdHandler(CycleReasoningMsg{})
+				},
+			})
+
 			// OpenAI models: reasoning effort dialog
 			if len(model.ReasoningLevels) > 0 {
 				commands = append(commands, Command{
@@ -406,6 +531,28 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 		})
 	}
 	if c.sessionID != "" {
+		commands = append(commands, Command{
+			ID:          "toggle_file_view",
+			Title:       "Toggle File View",
+			Description: "Show the file the agent edits next to the chat",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(ToggleFileViewMsg{})
+			},
+		}, Command{
+			ID:          "toggle_file_tree",
+			Title:       "Toggle File Tree",
+			Description: "Show the working directory with the files the agent changed",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(ToggleFileTreeMsg{})
+			},
+		}, Command{
+			ID:          "pin_file",
+			Title:       "Pin File in File View",
+			Description: "Keep a file in the file view instead of following edits",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenPinFileDialogMsg{})
+			},
+		})
 		agentCfg := config.Get().Agents[config.AgentCoder]
 		model := config.Get().GetModelByType(agentCfg.Model)
 		if model.SupportsImages {
@@ -443,6 +590,46 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 				return util.CmdHandler(ToggleYoloModeMsg{})
 			},
 		},
+		{
+			ID:          "settings",
+			Title:       "Settings",
+			Description: "Change common options and model parameters",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenSettingsDialogMsg{})
+			},
+		},
+		{
+			ID:          "mcp_servers",
+			Title:       "MCP Servers",
+			Description: "Add, remove, disable or reconnect MCP servers",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenMCPDialogMsg{})
+			},
+		},
+		{
+			ID:          "context",
+			Title:       "Context",
+			Description: "Show the tokens of each part of the next request",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenContextPreviewDialogMsg{SessionID: c.sessionID})
+			},
+		},
+		{
+			ID:          "scope",
+			Title:       "Set Scope",
+			Description: "Restrict the file tools to a directory of the project",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenScopeDialogMsg{SessionID: c.sessionID})
+			},
+		},
+		{
+			ID:          "devcontainer",
+			Title:       "Devcontainer",
+			Description: "Choose whether tools run in the project's devcontainer",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenDevcontainerDialogMsg{})
+			},
+		},
 		{
 			ID:          "toggle_help",
 			Title:       "Toggle Help",
@@ -457,12 +644,24 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 			Title:       "Initialize Project",
 			Description: fmt.Sprintf("Create/Update the %s memory file", config.Get().Options.InitializeAs),
 			Handler: func(cmd Command) tea.Cmd {
-				initPrompt, err := agent.InitializePrompt(*config.Get())
-				if err != nil {
+				return util.CmdHandler(OpenInitializeDialogMsg{})
+			},
+		},
+		{
+			ID:          "update_context",
+			Title:       "Update Project Context",
+			Description: fmt.Sprintf("Review the changes to the project since the %s memory file was written", config.Get().Options.InitializeAs),
+			Handler: func(cmd Command) tea.Cmd {
+				cfg := config.Get()
+				changes, err := drift.Check(cfg.Options.DataDirectory, cfg.WorkingDir(), cfg.Options.InitializeAs)
+				switch {
+				case err != nil:
 					return util.ReportError(err)
+				case len(changes) == 0:
+					return util.ReportInfo(fmt.Sprintf("No changes to the project since %s was written", cfg.Options.InitializeAs))
 				}
 				return util.CmdHandler(chat.SendMsg{
-					Text: initPrompt,
+					Text: agent.UpdateContextPrompt(*cfg, changes),
 				})
 			},
 		},
@@ -176,16 +176,22 @@ func createCommandHandler(id, desc, content string) func(Command) tea.Cmd {
 
 func execUserPrompt(content string, args map[string]string) tea.Cmd {
 	return func() tea.Msg {
-		for name, value := range args {
-			placeholder := "$" + name
-			content = strings.ReplaceAll(content, placeholder, value)
-		}
 		return CommandRunCustomMsg{
-			Content: content,
+			Content: expandArgs(content, args),
 		}
 	}
 }
 
+// expandArgs replaces the $NAME placeholders in content with the values of
+// args.
+func expandArgs(content string, args map[string]string) string {
+	for name, value := range args {
+		placeholder := "$" + name
+		content = strings.ReplaceAll(content, placeholder, value)
+	}
+	return content
+}
+
 func extractArgNames(content string) []string {
 	matches := namedArgPattern.FindAllStringSubmatch(content, -1)
 	if len(matches) == 0 {
@@ -0,0 +1,192 @@
+package commands
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const macroCommandPrefix = "macro:"
+
+// The commands a step of a macro can run.
+const (
+	// MacroNew starts a new session.
+	MacroNew = "new"
+	// MacroCompact summarizes the current session.
+	MacroCompact = "compact"
+	// MacroModel switches the large model to the provider/model argument.
+	MacroModel = "model"
+	// MacroSmallModel switches the small model to the provider/model
+	// argument.
+	MacroSmallModel = "small_model"
+	// MacroSend sends the argument as a prompt.
+	MacroSend = "send"
+	// MacroCommand runs the command of the commands dialog with the ID in
+	// the argument.
+	MacroCommand = "command"
+)
+
+// macroCommands tells whether each command a step can run takes an argument.
+var macroCommands = map[string]bool{
+	MacroNew:        false,
+	MacroCompact:    false,
+	MacroModel:      true,
+	MacroSmallModel: true,
+	MacroSend:       true,
+	MacroCommand:    true,
+}
+
+// MacroStep is a step of a macro: one of the macro commands and its argument.
+type MacroStep struct {
+	Command string
+	Arg     string
+	// Cmd runs the command of the commands dialog a MacroCommand step refers
+	// to.
+	Cmd tea.Cmd
+}
+
+// RunMacroMsg runs the steps of a macro one after the other.
+type RunMacroMsg struct {
+	Name  string
+	Steps []MacroStep
+}
+
+// ParseMacro parses the steps of a macro. Steps are separated by semicolons or
+// new lines, and start with the command they run followed by its argument, if
+// any. A semicolon in an argument is written as \;.
+func ParseMacro(run string) ([]MacroStep, error) {
+	var (
+		steps   []MacroStep
+		current strings.Builder
+	)
+	flush := func() error {
+		step := strings.TrimSpace(current.String())
+		current.Reset()
+		if step == "" {
+			return nil
+		}
+		command, arg, _ := strings.Cut(step, " ")
+		arg = strings.TrimSpace(arg)
+		takesArg, ok := macroCommands[command]
+		switch {
+		case !ok:
+			return fmt.Errorf("unknown command %q", command)
+		case takesArg && arg == "":
+			return fmt.Errorf("%s needs an argument", command)
+		case !takesArg && arg != "":
+			return fmt.Errorf("%s takes no argument", command)
+		}
+		steps = append(steps, MacroStep{Command: command, Arg: arg})
+		return nil
+	}
+
+	for i := 0; i < len(run); i++ {
+		switch {
+		case run[i] == '\\' && i+1 < len(run) && run[i+1] == ';':
+			current.WriteByte(';')
+			i++
+		case run[i] == ';' || run[i] == '\n':
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		default:
+			current.WriteByte(run[i])
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	if len(steps) == 0 {
+		return nil, errors.New("no steps")
+	}
+	return steps, nil
+}
+
+// loadMacros returns the commands running the macros of the configuration.
+// Macros that don't parse are left out and reported.
+func (c *commandDialogCmp) loadMacros(macros []config.Macro) ([]Command, error) {
+	var (
+		commands []Command
+		errs     []error
+	)
+	for _, macro := range macros {
+		if _, err := ParseMacro(macro.Run); err != nil {
+			errs = append(errs, fmt.Errorf("macro %q: %w", macro.Name, err))
+			continue
+		}
+		commands = append(commands, Command{
+			ID:          macroCommandPrefix + macro.Name,
+			Title:       macro.Name,
+			Description: cmp.Or(macro.Description, macro.Run),
+			Handler:     c.createMacroHandler(macro),
+		})
+	}
+	return commands, errors.Join(errs...)
+}
+
+// createMacroHandler asks for the arguments of the macro, if it has any, and
+// runs it.
+func (c *commandDialogCmp) createMacroHandler(macro config.Macro) func(Command) tea.Cmd {
+	return func(cmd Command) tea.Cmd {
+		args := extractArgNames(macro.Run)
+		if len(args) == 0 {
+			return c.runMacro(macro.Name, macro.Run)
+		}
+		return util.CmdHandler(ShowArgumentsDialogMsg{
+			CommandID:   cmd.ID,
+			Description: cmd.Description,
+			ArgNames:    args,
+			OnSubmit: func(args map[string]string) tea.Cmd {
+				// Arguments can't add steps.
+				escaped := make(map[string]string, len(args))
+				for name, value := range args {
+					escaped[name] = strings.ReplaceAll(value, ";", `\;`)
+				}
+				return c.runMacro(macro.Name, expandArgs(macro.Run, escaped))
+			},
+		})
+	}
+}
+
+// runMacro parses the steps of a macro and looks up the commands of the
+// commands dialog they run.
+func (c *commandDialogCmp) runMacro(name, run string) tea.Cmd {
+	steps, err := ParseMacro(run)
+	if err != nil {
+		return util.ReportError(fmt.Errorf("macro %q: %w", name, err))
+	}
+	for i, step := range steps {
+		if step.Command != MacroCommand {
+			continue
+		}
+		command, ok := c.findCommand(step.Arg)
+		if !ok {
+			return util.ReportError(fmt.Errorf("macro %q: command %q not found", name, step.Arg))
+		}
+		steps[i].Cmd = command.Handler(command)
+	}
+	return util.CmdHandler(RunMacroMsg{Name: name, Steps: steps})
+}
+
+// findCommand returns the command of the commands dialog with the given ID.
+// Macros can't run other macros.
+func (c *commandDialogCmp) findCommand(id string) (Command, bool) {
+	if strings.HasPrefix(id, macroCommandPrefix) {
+		return Command{}, false
+	}
+	all := append(c.defaultCommands(), c.userCommands...)
+	for command := range c.mcpPrompts.Seq() {
+		all = append(all, command)
+	}
+	for _, command := range all {
+		if strings.EqualFold(command.ID, id) {
+			return command, true
+		}
+	}
+	return Command{}, false
+}
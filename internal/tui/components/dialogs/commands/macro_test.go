@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMacro(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		run   string
+		steps []MacroStep
+		err   string
+	}{
+		{
+			name: "chained steps",
+			run:  "compact; model openai/gpt-4o;send Review $FILE",
+			steps: []MacroStep{
+				{Command: MacroCompact},
+				{Command: MacroModel, Arg: "openai/gpt-4o"},
+				{Command: MacroSend, Arg: "Review $FILE"},
+			},
+		},
+		{
+			name: "new lines and empty steps",
+			run:  "new\n\ncommand toggle_yolo;",
+			steps: []MacroStep{
+				{Command: MacroNew},
+				{Command: MacroCommand, Arg: "toggle_yolo"},
+			},
+		},
+		{
+			name: "escaped semicolon",
+			run:  `send a\; b; new`,
+			steps: []MacroStep{
+				{Command: MacroSend, Arg: "a; b"},
+				{Command: MacroNew},
+			},
+		},
+		{name: "unknown command", run: "compact; deploy", err: `unknown command "deploy"`},
+		{name: "missing argument", run: "send", err: "send needs an argument"},
+		{name: "extra argument", run: "new session", err: "new takes no argument"},
+		{name: "no steps", run: " ; ", err: "no steps"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			steps, err := ParseMacro(tt.run)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.steps, steps)
+		})
+	}
+}
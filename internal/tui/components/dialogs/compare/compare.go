@@ -0,0 +1,239 @@
+package compare
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/tui/components/chat/editor"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/exp/list"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const (
+	CompareDialogID dialogs.DialogID = "compare"
+
+	defaultWidth int = 60
+)
+
+type listModel = list.FilterableList[list.CompletionItem[string]]
+
+// CompareDialog picks the two models to send the next prompt to side by side.
+type CompareDialog interface {
+	dialogs.DialogModel
+}
+
+type compareDialogCmp struct {
+	width   int
+	wWidth  int // Width of the terminal window
+	wHeight int // Height of the terminal window
+
+	first     string // The model picked first, once there is one
+	modelList listModel
+	keyMap    KeyMap
+	help      help.Model
+}
+
+type KeyMap struct {
+	Next     key.Binding
+	Previous key.Binding
+	Select   key.Binding
+	Close    key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Next: key.NewBinding(
+			key.WithKeys("down", "ctrl+n"),
+			key.WithHelp("↓/ctrl+n", "next"),
+		),
+		Previous: key.NewBinding(
+			key.WithKeys("up", "ctrl+p"),
+			key.WithHelp("↑/ctrl+p", "previous"),
+		),
+		Select: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "pick"),
+		),
+		Close: key.NewBinding(
+			key.WithKeys("esc", "ctrl+c"),
+			key.WithHelp("esc/ctrl+c", "close"),
+		),
+	}
+}
+
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Select, k.Close}
+}
+
+func (k KeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Next, k.Previous},
+		{k.Select, k.Close},
+	}
+}
+
+// NewCompareDialog creates a dialog that picks two of the configured models
+// and starts the prompt being written with the directive comparing them.
+func NewCompareDialog() CompareDialog {
+	keyMap := DefaultKeyMap()
+	listKeyMap := list.DefaultKeyMap()
+	listKeyMap.Down.SetEnabled(false)
+	listKeyMap.Up.SetEnabled(false)
+	listKeyMap.DownOneItem = keyMap.Next
+	listKeyMap.UpOneItem = keyMap.Previous
+
+	t := styles.CurrentTheme()
+	inputStyle := t.S().Base.PaddingLeft(1).PaddingBottom(1)
+	modelList := list.NewFilterableList(
+		[]list.CompletionItem[string]{},
+		list.WithFilterInputStyle(inputStyle),
+		list.WithFilterListOptions(
+			list.WithKeyMap(listKeyMap),
+			list.WithWrapNavigation(),
+			list.WithResizeByList(),
+		),
+	)
+	help := help.New()
+	help.Styles = t.S().Help
+
+	return &compareDialogCmp{
+		modelList: modelList,
+		width:     defaultWidth,
+		keyMap:    keyMap,
+		help:      help,
+	}
+}
+
+func (r *compareDialogCmp) Init() tea.Cmd {
+	cfg := config.Get()
+	current := cfg.Models[config.SelectedModelTypeLarge]
+	providers := cfg.EnabledProviders()
+	slices.SortFunc(providers, func(a, b config.ProviderConfig) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	var items []list.CompletionItem[string]
+	for _, provider := range providers {
+		for _, model := range provider.Models {
+			id := provider.ID + "/" + model.ID
+			shortcut := provider.Name
+			if provider.ID == current.Provider && model.ID == current.Model {
+				shortcut = "current"
+			}
+			items = append(items, list.NewCompletionItem(
+				cmp.Or(model.Name, model.ID),
+				id,
+				list.WithCompletionID(id),
+				list.WithCompletionShortcut(shortcut),
+			))
+		}
+	}
+	return r.modelList.SetItems(items)
+}
+
+func (r *compareDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		r.wWidth = msg.Width
+		r.wHeight = msg.Height
+		return r, r.modelList.SetSize(r.listWidth(), r.listHeight())
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, r.keyMap.Select):
+			selectedItem := r.modelList.SelectedItem()
+			if selectedItem == nil {
+				return r, nil
+			}
+			model := (*selectedItem).Value()
+			switch {
+			case r.first == "":
+				r.first = model
+				return r, nil
+			case model == r.first:
+				return r, util.ReportWarn("Pick another model to compare with")
+			}
+			return r, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(editor.InsertTextMsg{
+					Text:    fmt.Sprintf("!compare %s %s ", r.first, model),
+					Prepend: true,
+				}),
+			)
+		case key.Matches(msg, r.keyMap.Close):
+			return r, util.CmdHandler(dialogs.CloseDialogMsg{})
+		default:
+			u, cmd := r.modelList.Update(msg)
+			r.modelList = u.(listModel)
+			return r, cmd
+		}
+	}
+	return r, nil
+}
+
+func (r *compareDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	title := "Compare: First Model"
+	if r.first != "" {
+		title = "Compare " + r.first + " With"
+	}
+	header := t.S().Base.Padding(0, 1, 1, 1).Render(core.Title(title, r.width-4))
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		header,
+		r.modelList.View(),
+		"",
+		t.S().Base.Width(r.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(r.help.View(r.keyMap)),
+	)
+	return r.style().Render(content)
+}
+
+func (r *compareDialogCmp) Cursor() *tea.Cursor {
+	if cursor, ok := r.modelList.(util.Cursor); ok {
+		cursor := cursor.Cursor()
+		if cursor != nil {
+			row, col := r.Position()
+			cursor.Y += row + 3
+			cursor.X += col + 2
+		}
+		return cursor
+	}
+	return nil
+}
+
+func (r *compareDialogCmp) listWidth() int {
+	return r.width - 2
+}
+
+func (r *compareDialogCmp) listHeight() int {
+	listHeight := len(r.modelList.Items()) + 2 + 4 // height based on items + 2 for the input + 4 for the sections
+	return min(listHeight, r.wHeight/2)
+}
+
+func (r *compareDialogCmp) style() lipgloss.Style {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(r.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus)
+}
+
+func (r *compareDialogCmp) Position() (int, int) {
+	row := r.wHeight/4 - 2 // just a bit above the center
+	col := r.wWidth / 2
+	col -= r.width / 2
+	return row, col
+}
+
+func (r *compareDialogCmp) ID() dialogs.DialogID {
+	return CompareDialogID
+}
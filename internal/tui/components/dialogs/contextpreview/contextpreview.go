@@ -0,0 +1,164 @@
+package contextpreview
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/viewport"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const (
+	ContextPreviewDialogID dialogs.DialogID = "context_preview"
+
+	defaultWidth int = 64
+)
+
+// ContextPreviewDialog shows what the next request of a session sends to
+// the model, part by part.
+type ContextPreviewDialog interface {
+	dialogs.DialogModel
+}
+
+// row is a part of the request, or a file or tool of a part when sub is
+// set.
+type row struct {
+	label  string
+	tokens int
+	sub    bool
+}
+
+type contextPreviewDialogCmp struct {
+	wWidth  int
+	wHeight int
+
+	preview  agent.ContextPreview
+	rows     []row
+	viewport viewport.Model
+	close    key.Binding
+}
+
+// NewContextPreviewDialog creates a dialog with the estimated tokens of the
+// parts of preview.
+func NewContextPreviewDialog(preview agent.ContextPreview) ContextPreviewDialog {
+	vp := viewport.New()
+	vp.SetWidth(defaultWidth - 4)
+	return &contextPreviewDialogCmp{
+		preview:  preview,
+		rows:     previewRows(preview),
+		viewport: vp,
+		close: key.NewBinding(
+			key.WithKeys("esc", "alt+esc", "enter", "q"),
+			key.WithHelp("esc", "close"),
+		),
+	}
+}
+
+// previewRows lists the parts of preview, with the context files under the
+// system prompt and the tools under their total.
+func previewRows(preview agent.ContextPreview) []row {
+	rows := []row{{label: "System prompt", tokens: preview.SystemPrompt}}
+	for _, file := range preview.ContextFiles {
+		rows = append(rows, row{label: file.Name, tokens: file.Tokens, sub: true})
+	}
+	if preview.Summary > 0 {
+		rows = append(rows, row{label: "Summary", tokens: preview.Summary})
+	}
+	rows = append(rows, row{label: fmt.Sprintf("History (%d messages)", preview.Messages), tokens: preview.History})
+	rows = append(rows, row{label: fmt.Sprintf("Tools (%d)", len(preview.Tools)), tokens: preview.ToolTokens()})
+	for _, tool := range preview.Tools {
+		rows = append(rows, row{label: tool.Name, tokens: tool.Tokens, sub: true})
+	}
+	return rows
+}
+
+// formatCount formats n with thousands separators.
+func formatCount(n int) string {
+	s := fmt.Sprintf("%d", n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+func (c *contextPreviewDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (c *contextPreviewDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		c.wWidth = msg.Width
+		c.wHeight = msg.Height
+		c.viewport.SetHeight(min(len(c.rows), max(5, c.wHeight-16)))
+	case tea.KeyPressMsg:
+		if key.Matches(msg, c.close) {
+			return c, util.CmdHandler(dialogs.CloseDialogMsg{})
+		}
+		var cmd tea.Cmd
+		c.viewport, cmd = c.viewport.Update(msg)
+		return c, cmd
+	}
+	return c, nil
+}
+
+func (c *contextPreviewDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	width := defaultWidth - 4
+	line := func(label, value string, labelStyle, valueStyle lipgloss.Style) string {
+		value = valueStyle.Render(value)
+		return labelStyle.Width(width-lipgloss.Width(value)).Render(label) + value
+	}
+
+	lines := make([]string, 0, len(c.rows))
+	for _, r := range c.rows {
+		if r.sub {
+			lines = append(lines, line("  "+r.label, formatCount(r.tokens), t.S().Subtle, t.S().Subtle))
+			continue
+		}
+		lines = append(lines, line(r.label, formatCount(r.tokens), t.S().Muted, t.S().Text))
+	}
+	c.viewport.SetContent(strings.Join(lines, "\n"))
+
+	total := c.preview.Total()
+	totalValue := formatCount(total)
+	if c.preview.ContextWindow > 0 {
+		totalValue = fmt.Sprintf("%s (%d%% of %s)", totalValue,
+			int64(total)*100/c.preview.ContextWindow, formatCount(int(c.preview.ContextWindow)))
+	}
+	footer := []string{
+		t.S().Subtle.Render(strings.Repeat("─", width)),
+		line("Total (estimated)", totalValue, t.S().Text, t.S().Text.Bold(true)),
+	}
+	if c.preview.ReportedTokens > 0 {
+		footer = append(footer, line("Reported after the last response", formatCount(int(c.preview.ReportedTokens)), t.S().Muted, t.S().Text))
+	}
+
+	header := t.S().Base.PaddingBottom(1).Render(core.Title("Context", width))
+	model := t.S().Muted.PaddingBottom(1).Render("Next request to " + c.preview.Model + ", before your prompt")
+	help := t.S().Subtle.PaddingTop(1).Render("↑/↓ scroll · esc close")
+	content := lipgloss.JoinVertical(lipgloss.Left, header, model, c.viewport.View(), strings.Join(footer, "\n"), help)
+	return t.S().Base.
+		Width(defaultWidth).
+		Padding(0, 1).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(content)
+}
+
+func (c *contextPreviewDialogCmp) Position() (int, int) {
+	row := c.wHeight/2 - (c.viewport.Height()+10)/2
+	col := c.wWidth/2 - defaultWidth/2
+	return row, col
+}
+
+func (c *contextPreviewDialogCmp) ID() dialogs.DialogID {
+	return ContextPreviewDialogID
+}
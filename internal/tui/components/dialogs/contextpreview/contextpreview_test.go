@@ -0,0 +1,40 @@
+package contextpreview
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewRows(t *testing.T) {
+	t.Parallel()
+
+	preview := agent.ContextPreview{
+		SystemPrompt: 4000,
+		ContextFiles: []agent.ContextPart{{Name: "AGENTS.md", Tokens: 1500}},
+		History:      12000,
+		Messages:     8,
+		Tools:        []agent.ContextPart{{Name: "bash", Tokens: 900}, {Name: "view", Tokens: 300}},
+	}
+	require.Equal(t, []row{
+		{label: "System prompt", tokens: 4000},
+		{label: "AGENTS.md", tokens: 1500, sub: true},
+		{label: "History (8 messages)", tokens: 12000},
+		{label: "Tools (2)", tokens: 1200},
+		{label: "bash", tokens: 900, sub: true},
+		{label: "view", tokens: 300, sub: true},
+	}, previewRows(preview))
+
+	preview.Summary = 700
+	require.Equal(t, row{label: "Summary", tokens: 700}, previewRows(preview)[2])
+}
+
+func TestFormatCount(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "0", formatCount(0))
+	require.Equal(t, "999", formatCount(999))
+	require.Equal(t, "1,000", formatCount(1000))
+	require.Equal(t, "1,234,567", formatCount(1234567))
+}
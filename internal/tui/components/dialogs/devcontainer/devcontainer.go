@@ -0,0 +1,165 @@
+package devcontainer
+
+import (
+	"context"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/devcontainer"
+	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const (
+	question                              = "Run tools in the devcontainer?"
+	DevcontainerDialogID dialogs.DialogID = "devcontainer"
+	width                                 = 60
+)
+
+// DevcontainerDialog asks whether the tools run in the devcontainer of the
+// project, the first time Crush finds one.
+type DevcontainerDialog interface {
+	dialogs.DialogModel
+}
+
+type devcontainerDialogCmp struct {
+	wWidth  int
+	wHeight int
+
+	app        *app.App
+	selectedNo bool // true if "No" button is selected
+	keymap     KeyMap
+}
+
+// NewDevcontainerDialog creates a new devcontainer dialog for the project
+// of the app.
+func NewDevcontainerDialog(app *app.App) DevcontainerDialog {
+	_, choice := app.Devcontainer()
+	return &devcontainerDialogCmp{
+		app:        app,
+		selectedNo: choice == devcontainer.Ignore,
+		keymap:     DefaultKeymap(),
+	}
+}
+
+func (d *devcontainerDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles keyboard input for the devcontainer dialog.
+func (d *devcontainerDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		d.wWidth = msg.Width
+		d.wHeight = msg.Height
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, d.keymap.LeftRight, d.keymap.Tab):
+			d.selectedNo = !d.selectedNo
+			return d, nil
+		case key.Matches(msg, d.keymap.EnterSpace):
+			if d.selectedNo {
+				return d, d.setChoice(devcontainer.Ignore)
+			}
+			return d, d.setChoice(devcontainer.Use)
+		case key.Matches(msg, d.keymap.Yes):
+			return d, d.setChoice(devcontainer.Use)
+		case key.Matches(msg, d.keymap.No):
+			return d, d.setChoice(devcontainer.Ignore)
+		case key.Matches(msg, d.keymap.Close):
+			return d, util.CmdHandler(dialogs.CloseDialogMsg{})
+		}
+	}
+	return d, nil
+}
+
+// setChoice saves choice in the background, as finding the container runs
+// docker.
+func (d *devcontainerDialogCmp) setChoice(choice devcontainer.Choice) tea.Cmd {
+	return tea.Batch(
+		util.CmdHandler(dialogs.CloseDialogMsg{}),
+		func() tea.Msg {
+			if err := d.app.SetDevcontainerChoice(context.Background(), choice); err != nil {
+				if choice == devcontainer.Use {
+					return util.ReportWarn("Tools run on the host: " + err.Error())()
+				}
+				return util.ReportError(err)()
+			}
+			if choice == devcontainer.Use {
+				return util.ReportInfo("Tools run in the devcontainer")()
+			}
+			return util.ReportInfo("Tools run on the host")()
+		},
+	)
+}
+
+// View renders the devcontainer dialog with Yes/No buttons.
+func (d *devcontainerDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base
+	yesStyle := t.S().Text
+	noStyle := yesStyle
+
+	if d.selectedNo {
+		noStyle = noStyle.Foreground(t.White).Background(t.Secondary)
+		yesStyle = yesStyle.Background(t.BgSubtle)
+	} else {
+		yesStyle = yesStyle.Foreground(t.White).Background(t.Secondary)
+		noStyle = noStyle.Background(t.BgSubtle)
+	}
+
+	const horizontalPadding = 3
+	yesButton := yesStyle.PaddingLeft(horizontalPadding).Underline(true).Render("Y") +
+		yesStyle.PaddingRight(horizontalPadding).Render("es, use container")
+	noButton := noStyle.PaddingLeft(horizontalPadding).Underline(true).Render("N") +
+		noStyle.PaddingRight(horizontalPadding).Render("o, stay on host")
+
+	buttons := baseStyle.Width(width).Align(lipgloss.Right).Render(
+		lipgloss.JoinHorizontal(lipgloss.Center, yesButton, "  ", noButton),
+	)
+
+	explanation := t.S().Muted.Width(width).Render(
+		"This project declares a devcontainer. " +
+			"Crush can run the bash tool and the LSPs inside its running container with docker exec, " +
+			"so they use the project's toolchain. " +
+			"Change it later with Devcontainer in the commands.",
+	)
+
+	cfg, _ := d.app.Devcontainer()
+	location := ""
+	if cfg != nil {
+		location = home.Short(cfg.Path)
+	}
+	content := baseStyle.Render(
+		lipgloss.JoinVertical(
+			lipgloss.Left,
+			t.S().Title.Render(question),
+			t.S().Subtle.Render(location),
+			"",
+			explanation,
+			"",
+			buttons,
+		),
+	)
+
+	dialogStyle := baseStyle.
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus)
+
+	return dialogStyle.Render(content)
+}
+
+func (d *devcontainerDialogCmp) Position() (int, int) {
+	row := d.wHeight/2 - lipgloss.Height(d.View())/2
+	col := d.wWidth/2 - (width+6)/2
+	return row, col
+}
+
+func (d *devcontainerDialogCmp) ID() dialogs.DialogID {
+	return DevcontainerDialogID
+}
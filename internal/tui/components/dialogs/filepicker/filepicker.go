@@ -12,6 +12,7 @@ import (
 	"charm.land/bubbles/v2/key"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/filepathext"
 	"github.com/charmbracelet/crush/internal/home"
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/tui/components/core"
@@ -55,7 +56,7 @@ func NewFilePickerCmp(workingDir string) FilePicker {
 	fp.AllowedTypes = AllowedTypes
 
 	if workingDir != "" {
-		fp.CurrentDirectory = workingDir
+		fp.CurrentDirectory = filepathext.Normalize(workingDir)
 	} else {
 		// Fallback to current working directory, then home directory
 		if cwd, err := os.Getwd(); err == nil {
@@ -107,7 +108,7 @@ func (m *model) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		}
 		if key.Matches(msg, m.filePicker.KeyMap.Back) {
 			// make sure we don't go back if we are at the home directory
-			if m.filePicker.CurrentDirectory == home.Dir() {
+			if filepathext.SamePath(m.filePicker.CurrentDirectory, home.Dir()) {
 				return m, nil
 			}
 		}
@@ -0,0 +1,211 @@
+package grepmatches
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/exp/list"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const MatchesDialogID dialogs.DialogID = "grep_matches"
+
+// MatchesDialog interface for the dialog listing the matches of a grep
+// tool call
+type MatchesDialog interface {
+	dialogs.DialogModel
+}
+
+type MatchesList = list.FilterableList[list.CompletionItem[tools.GrepMatch]]
+
+type matchesDialogCmp struct {
+	wWidth      int
+	wHeight     int
+	width       int
+	keyMap      KeyMap
+	matchesList MatchesList
+	help        help.Model
+}
+
+// NewMatchesDialogCmp creates a dialog listing the matches of a grep tool
+// call, opening the chosen one in the editor.
+func NewMatchesDialogCmp(matches []tools.GrepMatch) MatchesDialog {
+	t := styles.CurrentTheme()
+	keyMap := DefaultKeyMap()
+	listKeyMap := list.DefaultKeyMap()
+	listKeyMap.Down.SetEnabled(false)
+	listKeyMap.Up.SetEnabled(false)
+	listKeyMap.DownOneItem = keyMap.Next
+	listKeyMap.UpOneItem = keyMap.Previous
+
+	items := make([]list.CompletionItem[tools.GrepMatch], len(matches))
+	for i, match := range matches {
+		location := fmt.Sprintf("%s:%d", displayPath(match.Path), match.Line)
+		items[i] = list.NewCompletionItem(
+			location+"  "+match.Preview,
+			match,
+			list.WithCompletionID(location+":"+strconv.Itoa(match.Column)),
+		)
+	}
+
+	inputStyle := t.S().Base.PaddingLeft(1).PaddingBottom(1)
+	matchesList := list.NewFilterableList(
+		items,
+		list.WithFilterPlaceholder("Filter the matches"),
+		list.WithFilterInputStyle(inputStyle),
+		list.WithFilterListOptions(
+			list.WithKeyMap(listKeyMap),
+			list.WithWrapNavigation(),
+		),
+	)
+	help := help.New()
+	help.Styles = t.S().Help
+	return &matchesDialogCmp{
+		keyMap:      keyMap,
+		matchesList: matchesList,
+		help:        help,
+	}
+}
+
+func (m *matchesDialogCmp) Init() tea.Cmd {
+	return tea.Sequence(m.matchesList.Init(), m.matchesList.Focus())
+}
+
+func (m *matchesDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.wWidth = msg.Width
+		m.wHeight = msg.Height
+		m.width = min(120, m.wWidth-8)
+		m.matchesList.SetInputWidth(m.listWidth() - 2)
+		return m, m.matchesList.SetSize(m.listWidth(), m.listHeight())
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, m.keyMap.Select):
+			selectedItem := m.matchesList.SelectedItem()
+			if selectedItem == nil {
+				return m, nil
+			}
+			return m, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				openMatch((*selectedItem).Value()),
+			)
+		case key.Matches(msg, m.keyMap.Close):
+			return m, util.CmdHandler(dialogs.CloseDialogMsg{})
+		default:
+			u, cmd := m.matchesList.Update(msg)
+			m.matchesList = u.(MatchesList)
+			return m, cmd
+		}
+	}
+	return m, nil
+}
+
+func (m *matchesDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Grep Matches", m.width-4)),
+		m.matchesList.View(),
+		"",
+		t.S().Base.Width(m.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(m.help.View(m.keyMap)),
+	)
+	return m.style().Render(content)
+}
+
+func (m *matchesDialogCmp) Cursor() *tea.Cursor {
+	if cursor, ok := m.matchesList.(util.Cursor); ok {
+		cursor := cursor.Cursor()
+		if cursor != nil {
+			row, col := m.Position()
+			cursor.Y += row + 3 // Border + title
+			cursor.X += col + 2
+		}
+		return cursor
+	}
+	return nil
+}
+
+func (m *matchesDialogCmp) style() lipgloss.Style {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(m.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus)
+}
+
+func (m *matchesDialogCmp) listHeight() int {
+	return m.wHeight/2 - 6 // 5 for the border, title and help
+}
+
+func (m *matchesDialogCmp) listWidth() int {
+	return m.width - 2 // 2 for the border
+}
+
+func (m *matchesDialogCmp) Position() (int, int) {
+	row := m.wHeight/4 - 2 // just a bit above the center
+	col := m.wWidth / 2
+	col -= m.width / 2
+	return row, col
+}
+
+// ID implements MatchesDialog.
+func (m *matchesDialogCmp) ID() dialogs.DialogID {
+	return MatchesDialogID
+}
+
+// displayPath returns the path of a match relative to the working directory
+// when it's inside it.
+func displayPath(path string) string {
+	cwd, err := os.Getwd()
+	if err != nil || !filepath.IsAbs(path) {
+		return path
+	}
+	rel, err := filepath.Rel(cwd, path)
+	if err != nil || !filepath.IsLocal(rel) {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// openMatch opens the file of a match in the editor, at the line of the
+// match for the editors taking a +line argument.
+func openMatch(match tools.GrepMatch) tea.Cmd {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		// Use platform-appropriate default editor
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		} else {
+			editor = "nvim"
+		}
+	}
+
+	args := []string{filepath.FromSlash(match.Path)}
+	if editor != "notepad" {
+		args = append([]string{fmt.Sprintf("+%d", match.Line)}, args...)
+	}
+	c := exec.CommandContext(context.TODO(), editor, args...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	return tea.ExecProcess(c, func(err error) tea.Msg {
+		if err != nil {
+			return util.ReportError(err)()
+		}
+		return nil
+	})
+}
@@ -0,0 +1,280 @@
+package initialize
+
+import (
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/tui/components/chat"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/exp/list"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const (
+	InitializeDialogID dialogs.DialogID = "initialize"
+
+	defaultWidth int = 60
+
+	// The answers to whether to add the servers a template recommends.
+	addServers  = "add"
+	skipServers = "skip"
+)
+
+type listModel = list.FilterableList[list.CompletionItem[string]]
+
+// InitializeDialog picks the template the project context file is created
+// with, and whether to add the servers it recommends.
+type InitializeDialog interface {
+	dialogs.DialogModel
+}
+
+type initializeDialogCmp struct {
+	width   int
+	wWidth  int // Width of the terminal window
+	wHeight int // Height of the terminal window
+
+	templates []config.InitTemplate
+	picked    *config.InitTemplate // The template picked, once servers are asked about
+	list      listModel
+	keyMap    KeyMap
+	help      help.Model
+}
+
+type KeyMap struct {
+	Next     key.Binding
+	Previous key.Binding
+	Select   key.Binding
+	Close    key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Next: key.NewBinding(
+			key.WithKeys("down", "ctrl+n"),
+			key.WithHelp("↓/ctrl+n", "next"),
+		),
+		Previous: key.NewBinding(
+			key.WithKeys("up", "ctrl+p"),
+			key.WithHelp("↑/ctrl+p", "previous"),
+		),
+		Select: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "choose"),
+		),
+		Close: key.NewBinding(
+			key.WithKeys("esc", "ctrl+c"),
+			key.WithHelp("esc/ctrl+c", "close"),
+		),
+	}
+}
+
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Select, k.Close}
+}
+
+func (k KeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Next, k.Previous},
+		{k.Select, k.Close},
+	}
+}
+
+// NewInitializeDialog creates a dialog that initializes the project with
+// one of the initialization templates, or without one.
+func NewInitializeDialog() InitializeDialog {
+	keyMap := DefaultKeyMap()
+	help := help.New()
+	help.Styles = styles.CurrentTheme().S().Help
+
+	return &initializeDialogCmp{
+		list:   newList(keyMap),
+		width:  defaultWidth,
+		keyMap: keyMap,
+		help:   help,
+	}
+}
+
+func newList(keyMap KeyMap) listModel {
+	listKeyMap := list.DefaultKeyMap()
+	listKeyMap.Down.SetEnabled(false)
+	listKeyMap.Up.SetEnabled(false)
+	listKeyMap.DownOneItem = keyMap.Next
+	listKeyMap.UpOneItem = keyMap.Previous
+
+	t := styles.CurrentTheme()
+	inputStyle := t.S().Base.PaddingLeft(1).PaddingBottom(1)
+	return list.NewFilterableList(
+		[]list.CompletionItem[string]{},
+		list.WithFilterInputStyle(inputStyle),
+		list.WithFilterListOptions(
+			list.WithKeyMap(listKeyMap),
+			list.WithWrapNavigation(),
+			list.WithResizeByList(),
+		),
+	)
+}
+
+func (d *initializeDialogCmp) Init() tea.Cmd {
+	templates, err := config.InitTemplates()
+	d.templates = templates
+
+	items := []list.CompletionItem[string]{
+		list.NewCompletionItem("Default", "", list.WithCompletionID("default"), list.WithCompletionShortcut("no template")),
+	}
+	for _, t := range templates {
+		items = append(items, list.NewCompletionItem(
+			t.Name,
+			t.Name,
+			list.WithCompletionID(t.Name),
+			list.WithCompletionShortcut(t.Description),
+		))
+	}
+	cmds := []tea.Cmd{d.list.SetItems(items)}
+	if err != nil {
+		cmds = append(cmds, util.ReportError(err))
+	}
+	return tea.Batch(cmds...)
+}
+
+func (d *initializeDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		d.wWidth = msg.Width
+		d.wHeight = msg.Height
+		return d, d.list.SetSize(d.listWidth(), d.listHeight())
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, d.keyMap.Select):
+			selectedItem := d.list.SelectedItem()
+			if selectedItem == nil {
+				return d, nil
+			}
+			value := (*selectedItem).Value()
+			if d.picked != nil {
+				return d, d.initialize(d.picked, value == addServers)
+			}
+			tmpl := d.template(value)
+			if tmpl == nil {
+				return d, d.initialize(nil, false)
+			}
+			missing := config.Get().MissingServers(*tmpl)
+			if len(missing) == 0 {
+				return d, d.initialize(tmpl, false)
+			}
+			// A new list, without what was typed to find the template.
+			d.picked = tmpl
+			d.list = newList(d.keyMap)
+			return d, tea.Sequence(
+				d.list.SetItems([]list.CompletionItem[string]{
+					list.NewCompletionItem("Add "+strings.Join(missing, ", "), addServers, list.WithCompletionID(addServers)),
+					list.NewCompletionItem("Don't add them", skipServers, list.WithCompletionID(skipServers)),
+				}),
+				d.list.SetSize(d.listWidth(), d.listHeight()),
+			)
+		case key.Matches(msg, d.keyMap.Close):
+			return d, util.CmdHandler(dialogs.CloseDialogMsg{})
+		default:
+			u, cmd := d.list.Update(msg)
+			d.list = u.(listModel)
+			return d, cmd
+		}
+	}
+	return d, nil
+}
+
+func (d *initializeDialogCmp) template(name string) *config.InitTemplate {
+	for i := range d.templates {
+		if d.templates[i].Name == name {
+			return &d.templates[i]
+		}
+	}
+	return nil
+}
+
+// initialize sends the initialization prompt for tmpl, after adding the
+// servers it recommends to the project configuration when asked to.
+func (d *initializeDialogCmp) initialize(tmpl *config.InitTemplate, withServers bool) tea.Cmd {
+	cfg := config.Get()
+	initPrompt, err := agent.InitializePrompt(*cfg, tmpl)
+	if err != nil {
+		return util.ReportError(err)
+	}
+	cmds := []tea.Cmd{util.CmdHandler(dialogs.CloseDialogMsg{})}
+	if withServers {
+		added, err := cfg.ApplyInitTemplate(*tmpl)
+		if err != nil {
+			return util.ReportError(err)
+		}
+		if len(added) > 0 {
+			cmds = append(cmds, util.ReportInfo("Added "+strings.Join(added, ", ")+" to the project configuration"))
+		}
+	}
+	cmds = append(cmds, util.CmdHandler(chat.SendMsg{Text: initPrompt}))
+	return tea.Sequence(cmds...)
+}
+
+func (d *initializeDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	title := "Initialize Project"
+	if d.picked != nil {
+		title = "Add Recommended Servers"
+	}
+	header := t.S().Base.Padding(0, 1, 1, 1).Render(core.Title(title, d.width-4))
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		header,
+		d.list.View(),
+		"",
+		t.S().Base.Width(d.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(d.help.View(d.keyMap)),
+	)
+	return d.style().Render(content)
+}
+
+func (d *initializeDialogCmp) Cursor() *tea.Cursor {
+	if cursor, ok := d.list.(util.Cursor); ok {
+		cursor := cursor.Cursor()
+		if cursor != nil {
+			row, col := d.Position()
+			cursor.Y += row + 3
+			cursor.X += col + 2
+		}
+		return cursor
+	}
+	return nil
+}
+
+func (d *initializeDialogCmp) listWidth() int {
+	return d.width - 2
+}
+
+func (d *initializeDialogCmp) listHeight() int {
+	listHeight := len(d.list.Items()) + 2 + 4 // height based on items + 2 for the input + 4 for the sections
+	return min(listHeight, d.wHeight/2)
+}
+
+func (d *initializeDialogCmp) style() lipgloss.Style {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(d.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus)
+}
+
+func (d *initializeDialogCmp) Position() (int, int) {
+	row := d.wHeight/4 - 2 // just a bit above the center
+	col := d.wWidth / 2
+	col -= d.width / 2
+	return row, col
+}
+
+func (d *initializeDialogCmp) ID() dialogs.DialogID {
+	return InitializeDialogID
+}
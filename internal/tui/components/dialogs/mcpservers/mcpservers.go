@@ -0,0 +1,364 @@
+package mcpservers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/exp/list"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const MCPServersDialogID dialogs.DialogID = "mcp_servers"
+
+// MCPServersDialog interface for the dialog managing MCP servers
+type MCPServersDialog interface {
+	dialogs.DialogModel
+}
+
+// serverTestedMsg is sent when connecting to a server being added is done.
+type serverTestedMsg struct {
+	name   string
+	mcp    config.MCPConfig
+	counts mcp.Counts
+	err    error
+}
+
+type ServerList = list.List[list.CompletionItem[string]]
+
+type mcpServersDialogCmp struct {
+	wWidth  int
+	wHeight int
+	width   int
+	keyMap  KeyMap
+	list    ServerList
+	input   textinput.Model
+	help    help.Model
+
+	adding bool
+	// testing is the name of the server being connected to before adding
+	// it, if any.
+	testing string
+}
+
+// NewMCPServersDialogCmp creates a dialog listing the MCP servers and their
+// state, to add, remove, disable or reconnect them. Changes are saved to the
+// global configuration and applied to the running agent.
+func NewMCPServersDialogCmp() MCPServersDialog {
+	t := styles.CurrentTheme()
+	keyMap := DefaultKeyMap()
+	listKeyMap := list.DefaultKeyMap()
+	listKeyMap.Down.SetEnabled(false)
+	listKeyMap.Up.SetEnabled(false)
+	listKeyMap.DownOneItem = keyMap.Next
+	listKeyMap.UpOneItem = keyMap.Previous
+
+	input := textinput.New()
+	input.SetVirtualCursor(false)
+	input.SetStyles(t.S().TextInput)
+	input.Placeholder = "name command [args...] or name https://..."
+
+	help := help.New()
+	help.Styles = t.S().Help
+	return &mcpServersDialogCmp{
+		keyMap: keyMap,
+		input:  input,
+		help:   help,
+		list: list.New(
+			items(),
+			list.WithKeyMap(listKeyMap),
+			list.WithWrapNavigation(),
+		),
+	}
+}
+
+func (s *mcpServersDialogCmp) Init() tea.Cmd {
+	return tea.Sequence(s.list.Init(), s.list.Focus())
+}
+
+func (s *mcpServersDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.wWidth = msg.Width
+		s.wHeight = msg.Height
+		s.width = min(80, s.wWidth-8)
+		s.input.SetWidth(s.listWidth() - 4)
+		return s, s.list.SetSize(s.listWidth(), s.listHeight())
+	case pubsub.Event[mcp.Event]:
+		if msg.Payload.Type == mcp.EventStateChanged {
+			return s, s.refresh()
+		}
+	case serverTestedMsg:
+		return s, s.added(msg)
+	case tea.KeyPressMsg:
+		if s.adding {
+			switch {
+			case key.Matches(msg, s.keyMap.Save):
+				return s, s.add()
+			case key.Matches(msg, s.keyMap.Cancel):
+				s.stopAdding()
+				return s, nil
+			default:
+				var cmd tea.Cmd
+				s.input, cmd = s.input.Update(msg)
+				return s, cmd
+			}
+		}
+		switch {
+		case key.Matches(msg, s.keyMap.Add):
+			s.adding = true
+			return s, s.input.Focus()
+		case key.Matches(msg, s.keyMap.Restart):
+			if name, ok := s.selected(); ok {
+				return s, restart(name)
+			}
+		case key.Matches(msg, s.keyMap.Toggle):
+			if name, ok := s.selected(); ok {
+				return s, s.toggle(name)
+			}
+		case key.Matches(msg, s.keyMap.Remove):
+			if name, ok := s.selected(); ok {
+				return s, s.remove(name)
+			}
+		case key.Matches(msg, s.keyMap.Close):
+			return s, util.CmdHandler(dialogs.CloseDialogMsg{})
+		case key.Matches(msg, s.keyMap.Next):
+			return s, s.list.SelectItemBelow()
+		case key.Matches(msg, s.keyMap.Previous):
+			return s, s.list.SelectItemAbove()
+		}
+	}
+	return s, nil
+}
+
+func (s *mcpServersDialogCmp) selected() (string, bool) {
+	item := s.list.SelectedItem()
+	if item == nil {
+		return "", false
+	}
+	return (*item).Value(), true
+}
+
+func (s *mcpServersDialogCmp) toggle(name string) tea.Cmd {
+	cfg := config.Get()
+	m, ok := cfg.MCP[name]
+	if !ok {
+		return nil
+	}
+	if err := cfg.SetMCPDisabled(name, !m.Disabled); err != nil {
+		return util.ReportError(err)
+	}
+	return restart(name)
+}
+
+func (s *mcpServersDialogCmp) remove(name string) tea.Cmd {
+	if err := config.Get().RemoveMCP(name); err != nil {
+		return util.ReportError(err)
+	}
+	return tea.Batch(
+		restart(name),
+		util.ReportInfo(fmt.Sprintf("Removed the %s MCP server", name)),
+	)
+}
+
+// add connects to the server being added, which is only saved if that
+// works.
+func (s *mcpServersDialogCmp) add() tea.Cmd {
+	if s.testing != "" {
+		return nil
+	}
+	name, m, err := parseServer(s.input.Value())
+	if err != nil {
+		return util.ReportError(err)
+	}
+	cfg := config.Get()
+	if _, ok := cfg.MCP[name]; ok {
+		return util.ReportError(fmt.Errorf("an MCP server named %q already exists", name))
+	}
+	s.testing = name
+	return tea.Batch(
+		util.ReportInfo(fmt.Sprintf("Connecting to %s...", name)),
+		func() tea.Msg {
+			counts, err := mcp.Test(context.Background(), name, m, cfg.Resolver())
+			return serverTestedMsg{name: name, mcp: m, counts: counts, err: err}
+		},
+	)
+}
+
+func (s *mcpServersDialogCmp) added(msg serverTestedMsg) tea.Cmd {
+	s.testing = ""
+	if msg.err != nil {
+		return util.ReportError(fmt.Errorf("failed to connect to %s: %w", msg.name, msg.err))
+	}
+	if err := config.Get().SetMCP(msg.name, msg.mcp); err != nil {
+		return util.ReportError(err)
+	}
+	s.stopAdding()
+	return tea.Batch(
+		s.refresh(),
+		restart(msg.name),
+		util.ReportInfo(fmt.Sprintf("Added the %s MCP server with %d tools", msg.name, msg.counts.Tools)),
+	)
+}
+
+func (s *mcpServersDialogCmp) stopAdding() {
+	s.adding = false
+	s.input.Blur()
+	s.input.SetValue("")
+}
+
+// restart applies the configuration of a server to the running agent,
+// through the MCP state events.
+func restart(name string) tea.Cmd {
+	return func() tea.Msg {
+		mcp.Restart(context.Background(), config.Get(), name)
+		return nil
+	}
+}
+
+// parseServer parses a server to add, given as its name followed by either
+// its URL or the command starting it.
+func parseServer(value string) (string, config.MCPConfig, error) {
+	fields := strings.Fields(value)
+	if len(fields) < 2 {
+		return "", config.MCPConfig{}, fmt.Errorf("give the name of the server and its command or URL")
+	}
+	name, target := fields[0], fields[1:]
+	if len(target) == 1 && (strings.HasPrefix(target[0], "http://") || strings.HasPrefix(target[0], "https://")) {
+		return name, config.MCPConfig{Type: config.MCPHttp, URL: target[0]}, nil
+	}
+	return name, config.MCPConfig{Type: config.MCPStdio, Command: target[0], Args: target[1:]}, nil
+}
+
+func (s *mcpServersDialogCmp) refresh() tea.Cmd {
+	selected, _ := s.selected()
+	cmds := []tea.Cmd{s.list.SetItems(items())}
+	if selected != "" {
+		cmds = append(cmds, s.list.SetSelected(selected))
+	}
+	cmds = append(cmds, s.list.SetSize(s.listWidth(), s.listHeight()))
+	return tea.Sequence(cmds...)
+}
+
+func items() []list.CompletionItem[string] {
+	states := mcp.GetStates()
+	var items []list.CompletionItem[string]
+	for _, server := range config.Get().MCP.Sorted() {
+		items = append(items, list.NewCompletionItem(
+			server.Name,
+			server.Name,
+			list.WithCompletionID(server.Name),
+			list.WithCompletionShortcut(status(server.MCP, states[server.Name])),
+		))
+	}
+	return items
+}
+
+func status(m config.MCPConfig, info mcp.ClientInfo) string {
+	if m.Disabled {
+		return "disabled"
+	}
+	switch info.State {
+	case mcp.StateStarting:
+		return "starting"
+	case mcp.StateConnected:
+		return fmt.Sprintf("%d tools, %d prompts", info.Counts.Tools, info.Counts.Prompts)
+	case mcp.StateError:
+		return "error"
+	case mcp.StateDegraded:
+		if m.Type == config.MCPStdio {
+			return "restarting"
+		}
+		return "reconnecting"
+	case mcp.StateOffline:
+		return "offline"
+	default:
+		return "stopped"
+	}
+}
+
+func (s *mcpServersDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	parts := []string{
+		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("MCP Servers", s.width-4)),
+	}
+	var helpView string
+	if s.adding {
+		parts = append(parts,
+			t.S().Base.PaddingLeft(1).Render(s.input.View()),
+			t.S().Base.Padding(0, 1, 1, 1).Render(t.S().Subtle.Render("Servers are checked before they're added")),
+		)
+		helpView = s.help.View(addKeyMap(s.keyMap))
+	} else {
+		helpView = s.help.View(s.keyMap)
+	}
+	if len(s.list.Items()) == 0 {
+		parts = append(parts, t.S().Base.PaddingLeft(1).Render(t.S().Subtle.Render("No MCP servers configured")))
+	} else {
+		parts = append(parts, s.list.View())
+		if name, ok := s.selected(); ok {
+			if info, ok := mcp.GetState(name); ok && info.Error != nil {
+				parts = append(parts, "", t.S().Base.Width(s.width-2).PaddingLeft(1).Foreground(t.Error).Render(info.Error.Error()))
+			}
+		}
+	}
+	parts = append(parts,
+		"",
+		t.S().Base.Width(s.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(helpView),
+	)
+	return s.style().Render(lipgloss.JoinVertical(lipgloss.Left, parts...))
+}
+
+func (s *mcpServersDialogCmp) Cursor() *tea.Cursor {
+	if !s.adding {
+		return nil
+	}
+	cursor := s.input.Cursor()
+	if cursor == nil {
+		return nil
+	}
+	row, col := s.Position()
+	cursor.Y += row + 3 // Border + title
+	cursor.X += col + 2
+	return cursor
+}
+
+func (s *mcpServersDialogCmp) style() lipgloss.Style {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(s.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus)
+}
+
+func (s *mcpServersDialogCmp) listHeight() int {
+	// Leave room for the border, title, input, error and help.
+	return min(len(s.list.Items()), s.wHeight/2-10)
+}
+
+func (s *mcpServersDialogCmp) listWidth() int {
+	return s.width - 2 // 2 for the border
+}
+
+func (s *mcpServersDialogCmp) Position() (int, int) {
+	row := s.wHeight/4 - 2 // just a bit above the center
+	col := s.wWidth / 2
+	col -= s.width / 2
+	return row, col
+}
+
+// ID implements MCPServersDialog.
+func (s *mcpServersDialogCmp) ID() dialogs.DialogID {
+	return MCPServersDialogID
+}
@@ -10,6 +10,8 @@ type KeyMap struct {
 	Previous,
 	Choose,
 	Tab,
+	Favorite,
+	Swap,
 	Close key.Binding
 
 	isAPIKeyHelp  bool
@@ -43,6 +45,14 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("tab"),
 			key.WithHelp("tab", "toggle type"),
 		),
+		Favorite: key.NewBinding(
+			key.WithKeys("ctrl+f"),
+			key.WithHelp("ctrl+f", "star"),
+		),
+		Swap: key.NewBinding(
+			key.WithKeys("ctrl+x"),
+			key.WithHelp("ctrl+x", "swap large/small"),
+		),
 		Close: key.NewBinding(
 			key.WithKeys("esc", "alt+esc"),
 			key.WithHelp("esc", "exit"),
@@ -57,6 +67,8 @@ func (k KeyMap) KeyBindings() []key.Binding {
 		k.Next,
 		k.Previous,
 		k.Tab,
+		k.Favorite,
+		k.Swap,
 		k.Close,
 	}
 }
@@ -145,6 +157,8 @@ func (k KeyMap) ShortHelp() []key.Binding {
 			key.WithHelp("↑↓", "choose"),
 		),
 		k.Tab,
+		k.Favorite,
+		k.Swap,
 		k.Select,
 		k.Close,
 	}
@@ -29,6 +29,15 @@ func modelKey(providerID, modelID string) string {
 	return providerID + ":" + modelID
 }
 
+// starred returns the marker shown next to a favorite model.
+func starred(providerID, modelID string) string {
+	if !config.Get().IsFavoriteModel(providerID, modelID) {
+		return ""
+	}
+	t := styles.CurrentTheme()
+	return t.S().Base.Foreground(t.Yellow).Render(styles.StarIcon)
+}
+
 func NewModelListComponent(keyMap list.KeyMap, inputPlaceholder string, shouldResize bool) *ModelListComponent {
 	t := styles.CurrentTheme()
 	inputStyle := t.S().Base.PaddingLeft(1).PaddingBottom(1)
@@ -190,6 +199,7 @@ func (m *ModelListComponent) SetModelType(modelType int) tea.Cmd {
 					model.Name,
 					modelOption,
 					list.WithCompletionID(key),
+					list.WithCompletionShortcut(starred(string(configProvider.ID), model.ID)),
 				)
 				itemsByKey[key] = item
 
@@ -263,6 +273,7 @@ func (m *ModelListComponent) SetModelType(modelType int) tea.Cmd {
 				model.Name,
 				modelOption,
 				list.WithCompletionID(key),
+				list.WithCompletionShortcut(starred(string(displayProvider.ID), model.ID)),
 			)
 			itemsByKey[key] = item
 			group.Items = append(group.Items, item)
@@ -273,34 +284,17 @@ func (m *ModelListComponent) SetModelType(modelType int) tea.Cmd {
 		groups = append(groups, group)
 	}
 
+	var quickGroups []list.Group[list.CompletionItem[ModelOption]]
+	favoriteGroup := m.quickAccessGroup("Favorites", "favorite", cfg.FavoriteModels, itemsByKey, &selectedItemID)
+	if len(favoriteGroup.Items) > 0 {
+		quickGroups = append(quickGroups, favoriteGroup)
+	}
+
 	if len(recentItems) > 0 {
-		recentSection := list.NewItemSection("Recently used")
-		recentGroup := list.Group[list.CompletionItem[ModelOption]]{
-			Section: recentSection,
-		}
 		var validRecentItems []config.SelectedModel
 		for _, recent := range recentItems {
-			key := modelKey(recent.Provider, recent.Model)
-			option, ok := itemsByKey[key]
-			if !ok {
-				continue
-			}
-			validRecentItems = append(validRecentItems, recent)
-			recentID := fmt.Sprintf("recent::%s", key)
-			modelOption := option.Value()
-			providerName := modelOption.Provider.Name
-			if providerName == "" {
-				providerName = string(modelOption.Provider.ID)
-			}
-			item := list.NewCompletionItem(
-				modelOption.Model.Name,
-				option.Value(),
-				list.WithCompletionID(recentID),
-				list.WithCompletionShortcut(providerName),
-			)
-			recentGroup.Items = append(recentGroup.Items, item)
-			if recent.Model == currentModel.Model && recent.Provider == currentModel.Provider {
-				selectedItemID = recentID
+			if _, ok := itemsByKey[modelKey(recent.Provider, recent.Model)]; ok {
+				validRecentItems = append(validRecentItems, recent)
 			}
 		}
 
@@ -310,10 +304,16 @@ func (m *ModelListComponent) SetModelType(modelType int) tea.Cmd {
 			}
 		}
 
+		// Favorites are already listed above.
+		notFavorite := slices.DeleteFunc(validRecentItems, func(recent config.SelectedModel) bool {
+			return cfg.IsFavoriteModel(recent.Provider, recent.Model)
+		})
+		recentGroup := m.quickAccessGroup("Recently used", "recent", notFavorite, itemsByKey, &selectedItemID)
 		if len(recentGroup.Items) > 0 {
-			groups = append([]list.Group[list.CompletionItem[ModelOption]]{recentGroup}, groups...)
+			quickGroups = append(quickGroups, recentGroup)
 		}
 	}
+	groups = append(quickGroups, groups...)
 
 	var cmds []tea.Cmd
 
@@ -330,6 +330,60 @@ func (m *ModelListComponent) SetModelType(modelType int) tea.Cmd {
 	return tea.Sequence(cmds...)
 }
 
+// quickAccessGroup builds a group listed above the providers, e.g. the
+// favorite models, with the provider name shown next to each model. Models
+// that aren't available are skipped.
+func (m *ModelListComponent) quickAccessGroup(
+	title, idPrefix string,
+	models []config.SelectedModel,
+	itemsByKey map[string]list.CompletionItem[ModelOption],
+	selectedItemID *string,
+) list.Group[list.CompletionItem[ModelOption]] {
+	group := list.Group[list.CompletionItem[ModelOption]]{
+		Section: list.NewItemSection(title),
+	}
+	for _, selected := range models {
+		key := modelKey(selected.Provider, selected.Model)
+		option, ok := itemsByKey[key]
+		if !ok {
+			continue
+		}
+		id := fmt.Sprintf("%s::%s", idPrefix, key)
+		modelOption := option.Value()
+		providerName := modelOption.Provider.Name
+		if providerName == "" {
+			providerName = string(modelOption.Provider.ID)
+		}
+		item := list.NewCompletionItem(
+			modelOption.Model.Name,
+			modelOption,
+			list.WithCompletionID(id),
+			list.WithCompletionShortcut(providerName),
+			list.WithCompletionFilterPrefix(providerName),
+		)
+		group.Items = append(group.Items, item)
+		// The current model is selected where it is listed first.
+		if *selectedItemID == key {
+			*selectedItemID = id
+		}
+	}
+	return group
+}
+
+// SelectedItemID returns the ID of the selected item.
+func (m *ModelListComponent) SelectedItemID() string {
+	s := m.list.SelectedItem()
+	if s == nil {
+		return ""
+	}
+	return (*s).ID()
+}
+
+// SetSelected selects the item with the given ID.
+func (m *ModelListComponent) SetSelected(id string) tea.Cmd {
+	return m.list.SetSelected(id)
+}
+
 // GetModelType returns the current model type
 func (m *ModelListComponent) GetModelType() int {
 	return m.modelType
@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"charm.land/bubbles/v2/help"
@@ -40,6 +41,13 @@ type ModelSelectedMsg struct {
 	ModelType config.SelectedModelType
 }
 
+// SwapModelsMsg is sent to make the large model the small one and the other
+// way around.
+type SwapModelsMsg struct{}
+
+// ModelsSwappedMsg is sent once the large and small models were swapped.
+type ModelsSwappedMsg struct{}
+
 // CloseModelDialogMsg is sent when a model is selected
 type CloseModelDialogMsg struct{}
 
@@ -141,6 +149,8 @@ func (m *modelDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		return m, tea.Batch(cmds...)
 	case claude.AuthenticationCompleteMsg:
 		return m, util.CmdHandler(dialogs.CloseDialogMsg{})
+	case ModelsSwappedMsg:
+		return m, m.modelList.SetModelType(m.modelList.GetModelType())
 	case tea.KeyPressMsg:
 		switch {
 		case key.Matches(msg, key.NewBinding(key.WithKeys("c", "C"))):
@@ -276,6 +286,10 @@ func (m *modelDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 				m.modelList.SetInputPlaceholder(largeModelInputPlaceholder)
 				return m, m.modelList.SetModelType(LargeModelType)
 			}
+		case key.Matches(msg, m.keyMap.Favorite) && m.isSelectingModel():
+			return m, m.toggleFavorite()
+		case key.Matches(msg, m.keyMap.Swap) && m.isSelectingModel():
+			return m, util.CmdHandler(SwapModelsMsg{})
 		case key.Matches(msg, m.keyMap.Close):
 			if m.showClaudeAuthMethodChooser {
 				m.claudeAuthMethodChooser.SetDefaults()
@@ -477,6 +491,39 @@ func (m *modelDialogCmp) modelTypeRadio() string {
 	return t.S().Base.Foreground(t.FgHalfMuted).Render(iconUnselected + " " + choices[0] + "  " + iconSelected + " " + choices[1])
 }
 
+// isSelectingModel reports whether the model list is shown, rather than one of
+// the provider authentication steps.
+func (m *modelDialogCmp) isSelectingModel() bool {
+	return !m.needsAPIKey && !m.showClaudeAuthMethodChooser && !m.showClaudeOAuth2
+}
+
+// toggleFavorite stars the selected model, or unstars it, keeping it selected.
+func (m *modelDialogCmp) toggleFavorite() tea.Cmd {
+	selected := m.modelList.SelectedModel()
+	if selected == nil {
+		return nil
+	}
+	providerID, modelID := string(selected.Provider.ID), selected.Model.ID
+	starred, err := config.Get().ToggleFavoriteModel(providerID, modelID)
+	if err != nil {
+		return util.ReportError(err)
+	}
+
+	selectedID := m.modelList.SelectedItemID()
+	if !starred && strings.HasPrefix(selectedID, "favorite::") {
+		selectedID = modelKey(providerID, modelID)
+	}
+	info := fmt.Sprintf("%s added to favorites", selected.Model.Name)
+	if !starred {
+		info = fmt.Sprintf("%s removed from favorites", selected.Model.Name)
+	}
+	return tea.Sequence(
+		m.modelList.SetModelType(m.modelList.GetModelType()),
+		m.modelList.SetSelected(selectedID),
+		util.ReportInfo(info),
+	)
+}
+
 func (m *modelDialogCmp) isProviderConfigured(providerID string) bool {
 	cfg := config.Get()
 	if _, ok := cfg.Providers.Get(providerID); ok {
@@ -17,6 +17,10 @@ type KeyMap struct {
 	ScrollUp key.Binding
 	ScrollLeft,
 	ScrollRight key.Binding
+	// PrevHunk and NextHunk step through the edits of a multiedit call.
+	// They are only enabled when it has more than one.
+	PrevHunk,
+	NextHunk key.Binding
 }
 
 func DefaultKeyMap() KeyMap {
@@ -69,6 +73,16 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("shift+right", "L"),
 			key.WithHelp("shift+→", "scroll right"),
 		),
+		PrevHunk: key.NewBinding(
+			key.WithKeys("["),
+			key.WithHelp("[", "previous edit"),
+			key.WithDisabled(),
+		),
+		NextHunk: key.NewBinding(
+			key.WithKeys("]"),
+			key.WithHelp("]", "next edit"),
+			key.WithDisabled(),
+		),
 	}
 }
 
@@ -87,6 +101,8 @@ func (k KeyMap) KeyBindings() []key.Binding {
 		k.ScrollUp,
 		k.ScrollLeft,
 		k.ScrollRight,
+		k.PrevHunk,
+		k.NextHunk,
 	}
 }
 
@@ -103,11 +119,18 @@ func (k KeyMap) FullHelp() [][]key.Binding {
 
 // ShortHelp implements help.KeyMap.
 func (k KeyMap) ShortHelp() []key.Binding {
-	return []key.Binding{
+	bindings := []key.Binding{
 		k.ToggleDiffMode,
 		key.NewBinding(
 			key.WithKeys("shift+left", "shift+down", "shift+up", "shift+right"),
 			key.WithHelp("shift+←↓↑→", "scroll"),
 		),
 	}
+	if k.NextHunk.Enabled() {
+		bindings = append(bindings, key.NewBinding(
+			key.WithKeys("[", "]"),
+			key.WithHelp("[/]", "step through edits"),
+		))
+	}
+	return bindings
 }
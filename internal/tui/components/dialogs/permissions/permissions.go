@@ -7,6 +7,7 @@ import (
 
 	"charm.land/bubbles/v2/help"
 	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
 	"charm.land/bubbles/v2/viewport"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
@@ -57,6 +58,9 @@ type permissionDialogCmp struct {
 	diffSplitMode        *bool // nil means use defaultDiffSplitMode
 	diffXOffset          int   // horizontal scroll offset
 	diffYOffset          int   // vertical scroll offset
+	// hunk is the edit of a multiedit call shown, starting at 1, or 0 to
+	// show all of them.
+	hunk int
 
 	// Caching
 	cachedContent string
@@ -67,6 +71,10 @@ type permissionDialogCmp struct {
 
 	finalDialogHeight int
 
+	// confirmInput is where the user types the confirmation of destructive
+	// requests.
+	confirmInput textinput.Model
+
 	keyMap KeyMap
 }
 
@@ -77,7 +85,7 @@ func NewPermissionDialogCmp(permission permission.PermissionRequest, opts *Optio
 
 	// Create viewport for content
 	contentViewport := viewport.New()
-	return &permissionDialogCmp{
+	p := &permissionDialogCmp{
 		contentViewPort: contentViewport,
 		selectedOption:  0, // Default to "Allow"
 		permission:      permission,
@@ -85,6 +93,58 @@ func NewPermissionDialogCmp(permission permission.PermissionRequest, opts *Optio
 		keyMap:          DefaultKeyMap(),
 		contentDirty:    true, // Mark as dirty initially
 	}
+	if len(p.multiEditHunks()) > 1 {
+		p.keyMap.PrevHunk.SetEnabled(true)
+		p.keyMap.NextHunk.SetEnabled(true)
+	}
+	if permission.Confirmation != "" {
+		t := styles.CurrentTheme()
+		p.confirmInput = textinput.New()
+		p.confirmInput.Placeholder = permission.Confirmation
+		p.confirmInput.Prompt = "> "
+		p.confirmInput.SetStyles(t.S().TextInput)
+		p.confirmInput.Focus()
+	}
+	return p
+}
+
+// needsConfirmation reports whether the request is only granted after the
+// user types its confirmation.
+func (p *permissionDialogCmp) needsConfirmation() bool {
+	return p.permission.Confirmation != ""
+}
+
+// confirmed reports whether the user typed the confirmation.
+func (p *permissionDialogCmp) confirmed() bool {
+	return strings.TrimSpace(p.confirmInput.Value()) == p.permission.Confirmation
+}
+
+// updateConfirmation handles the keys of destructive requests: they are
+// typed in the confirmation input, enter allows once it matches, and esc
+// denies.
+func (p *permissionDialogCmp) updateConfirmation(msg tea.KeyPressMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc":
+		return tea.Batch(
+			util.CmdHandler(dialogs.CloseDialogMsg{}),
+			util.CmdHandler(PermissionResponseMsg{Action: PermissionDeny, Permission: p.permission}),
+		)
+	case "enter":
+		if !p.confirmed() {
+			return nil
+		}
+		return tea.Batch(
+			util.CmdHandler(dialogs.CloseDialogMsg{}),
+			util.CmdHandler(PermissionResponseMsg{Action: PermissionAllow, Permission: p.permission}),
+		)
+	case "up", "down", "pgup", "pgdown":
+		viewPort, cmd := p.contentViewPort.Update(msg)
+		p.contentViewPort = viewPort
+		return cmd
+	}
+	var cmd tea.Cmd
+	p.confirmInput, cmd = p.confirmInput.Update(msg)
+	return cmd
 }
 
 func (p *permissionDialogCmp) Init() tea.Cmd {
@@ -92,7 +152,7 @@ func (p *permissionDialogCmp) Init() tea.Cmd {
 }
 
 func (p *permissionDialogCmp) supportsDiffView() bool {
-	return p.permission.ToolName == tools.EditToolName || p.permission.ToolName == tools.WriteToolName || p.permission.ToolName == tools.MultiEditToolName
+	return p.permission.ToolName == tools.EditToolName || p.permission.ToolName == tools.WriteToolName || p.permission.ToolName == tools.MultiEditToolName || p.permission.ToolName == tools.ApplyPatchToolName
 }
 
 func (p *permissionDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
@@ -105,7 +165,16 @@ func (p *permissionDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		p.contentDirty = true // Mark content as dirty on window resize
 		cmd := p.SetSize()
 		cmds = append(cmds, cmd)
+	case tea.PasteMsg:
+		if p.needsConfirmation() {
+			var cmd tea.Cmd
+			p.confirmInput, cmd = p.confirmInput.Update(msg)
+			return p, cmd
+		}
 	case tea.KeyPressMsg:
+		if p.needsConfirmation() {
+			return p, p.updateConfirmation(msg)
+		}
 		switch {
 		case key.Matches(msg, p.keyMap.Right) || key.Matches(msg, p.keyMap.Tab):
 			p.selectedOption = (p.selectedOption + 1) % 3
@@ -140,6 +209,12 @@ func (p *permissionDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 				p.contentDirty = true // Mark content as dirty when diff mode changes
 				return p, nil
 			}
+		case key.Matches(msg, p.keyMap.NextHunk):
+			p.stepHunk(1)
+			return p, nil
+		case key.Matches(msg, p.keyMap.PrevHunk):
+			p.stepHunk(-1)
+			return p, nil
 		case key.Matches(msg, p.keyMap.ScrollDown):
 			if p.supportsDiffView() {
 				p.scrollDown()
@@ -184,6 +259,30 @@ func (p *permissionDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	return p, tea.Batch(cmds...)
 }
 
+// multiEditHunks returns the hunks of the edits of a multiedit call, or none
+// for other tools.
+func (p *permissionDialogCmp) multiEditHunks() []tools.MultiEditHunk {
+	params, ok := p.permission.Params.(tools.MultiEditPermissionsParams)
+	if !ok {
+		return nil
+	}
+	return params.Hunks
+}
+
+// stepHunk shows the edit delta edits after the one shown, going through
+// all of them at once between the last and the first.
+func (p *permissionDialogCmp) stepHunk(delta int) {
+	hunks := p.multiEditHunks()
+	if len(hunks) < 2 {
+		return
+	}
+	n := len(hunks) + 1
+	p.hunk = ((p.hunk+delta)%n + n) % n
+	p.diffXOffset = 0
+	p.diffYOffset = 0
+	p.contentDirty = true
+}
+
 func (p *permissionDialogCmp) scrollDown() {
 	p.diffYOffset += 1
 	p.contentDirty = true
@@ -241,6 +340,10 @@ func (p *permissionDialogCmp) renderButtons() string {
 	t := styles.CurrentTheme()
 	baseStyle := t.S().Base
 
+	if p.needsConfirmation() {
+		return p.renderConfirmation()
+	}
+
 	buttons := []core.ButtonOpts{
 		{
 			Text:           "Allow",
@@ -271,6 +374,27 @@ func (p *permissionDialogCmp) renderButtons() string {
 	return baseStyle.AlignHorizontal(lipgloss.Right).Width(p.width - 4).Render(content)
 }
 
+// renderConfirmation renders the confirmation input of destructive requests
+// in place of the buttons.
+func (p *permissionDialogCmp) renderConfirmation() string {
+	t := styles.CurrentTheme()
+	p.confirmInput.SetWidth(p.width - 6)
+	warning := "This command is destructive."
+	if p.permission.Warning != "" {
+		warning = p.permission.Warning
+	}
+	prompt := t.S().Text.Width(p.width - 4).Render(
+		fmt.Sprintf("%s Type %q to allow it.", warning, p.permission.Confirmation),
+	)
+	// Enter only allows once the confirmation is typed.
+	allowStyle := t.S().Subtle
+	if p.confirmed() {
+		allowStyle = t.S().Text
+	}
+	help := allowStyle.Render("enter allow") + t.S().Subtle.Render(" · esc deny")
+	return lipgloss.JoinVertical(lipgloss.Left, prompt, p.confirmInput.View(), "", help)
+}
+
 func (p *permissionDialogCmp) renderHeader() string {
 	t := styles.CurrentTheme()
 	baseStyle := t.S().Base
@@ -300,7 +424,7 @@ func (p *permissionDialogCmp) renderHeader() string {
 
 	// Add tool-specific header information
 	switch p.permission.ToolName {
-	case tools.BashToolName:
+	case tools.BashToolName, tools.RunTestsToolName:
 		params := p.permission.Params.(tools.BashPermissionsParams)
 		descKey := t.S().Muted.Render("Desc")
 		descValue := t.S().Text.
@@ -312,6 +436,19 @@ func (p *permissionDialogCmp) renderHeader() string {
 				descKey,
 				descValue,
 			),
+		)
+		if params.Risk != "" {
+			riskKey := t.S().Muted.Render("Risk")
+			riskStyle := t.S().Warning
+			if p.needsConfirmation() {
+				riskStyle = t.S().Error
+			}
+			riskValue := riskStyle.
+				Width(p.width - lipgloss.Width(riskKey)).
+				Render(fmt.Sprintf(" %s: %s", params.Risk, params.RiskCommand))
+			headerParts = append(headerParts, lipgloss.JoinHorizontal(lipgloss.Left, riskKey, riskValue))
+		}
+		headerParts = append(headerParts,
 			baseStyle.Render(strings.Repeat(" ", p.width)),
 			t.S().Muted.Width(p.width).Render("Command"),
 		)
@@ -379,6 +516,39 @@ func (p *permissionDialogCmp) renderHeader() string {
 				fileKey,
 				filePath,
 			),
+		)
+		if len(params.Hunks) > 1 {
+			editKey := t.S().Muted.Render("Edit")
+			edit := fmt.Sprintf(" all %d, which are allowed together", len(params.Hunks))
+			if p.hunk > 0 {
+				hunk := params.Hunks[p.hunk-1]
+				edit = fmt.Sprintf(" %d of %d, from line %d", p.hunk, len(params.Hunks), hunk.StartLine)
+			}
+			headerParts = append(headerParts,
+				lipgloss.JoinHorizontal(
+					lipgloss.Left,
+					editKey,
+					t.S().Text.Width(p.width-lipgloss.Width(editKey)).Render(edit),
+				),
+			)
+		}
+		headerParts = append(headerParts, baseStyle.Render(strings.Repeat(" ", p.width)))
+	case tools.ApplyPatchToolName:
+		params := p.permission.Params.(tools.ApplyPatchPermissionsParams)
+		filesKey := t.S().Muted.Render("Files")
+		paths := make([]string, len(params.Files))
+		for i, file := range params.Files {
+			paths[i] = fsext.PrettyPath(file.FilePath)
+		}
+		filesValue := t.S().Text.
+			Width(p.width - lipgloss.Width(filesKey)).
+			Render(fmt.Sprintf(" %s", strings.Join(paths, ", ")))
+		headerParts = append(headerParts,
+			lipgloss.JoinHorizontal(
+				lipgloss.Left,
+				filesKey,
+				filesValue,
+			),
 			baseStyle.Render(strings.Repeat(" ", p.width)),
 		)
 	case tools.FetchToolName:
@@ -433,7 +603,7 @@ func (p *permissionDialogCmp) getOrGenerateContent() string {
 	// Generate new content
 	var content string
 	switch p.permission.ToolName {
-	case tools.BashToolName:
+	case tools.BashToolName, tools.RunTestsToolName:
 		content = p.generateBashContent()
 	case tools.DownloadToolName:
 		content = p.generateDownloadContent()
@@ -443,6 +613,8 @@ func (p *permissionDialogCmp) getOrGenerateContent() string {
 		content = p.generateWriteContent()
 	case tools.MultiEditToolName:
 		content = p.generateMultiEditContent()
+	case tools.ApplyPatchToolName:
+		content = p.generateApplyPatchContent()
 	case tools.FetchToolName:
 		content = p.generateFetchContent()
 	case tools.AgenticFetchToolName:
@@ -482,6 +654,25 @@ func (p *permissionDialogCmp) generateBashContent() string {
 				Render(ln))
 		}
 
+		// Show what the command would change.
+		for _, section := range []struct{ title, text string }{
+			{"git status", pr.GitStatus},
+			{"git diff --stat", pr.GitDiffStat},
+		} {
+			if section.text == "" {
+				continue
+			}
+			out = append(out, t.S().Muted.Width(width).Padding(0, 3).Background(t.BgSubtle).Render(""))
+			out = append(out, t.S().Muted.Width(width).Padding(0, 3).Background(t.BgSubtle).Render("$ "+section.title))
+			for ln := range strings.SplitSeq(section.text, "\n") {
+				out = append(out, t.S().Muted.
+					Width(width).
+					Padding(0, 3).
+					Background(t.BgSubtle).
+					Render(ln))
+			}
+		}
+
 		// Ensure minimum of 7 lines for command display
 		minLines := 7
 		for len(out) < minLines {
@@ -556,6 +747,9 @@ func (p *permissionDialogCmp) generateDownloadContent() string {
 		if pr.Timeout > 0 {
 			content += fmt.Sprintf("\nTimeout: %ds", pr.Timeout)
 		}
+		if pr.SHA256 != "" {
+			content += fmt.Sprintf("\nSHA256: %s", pr.SHA256)
+		}
 
 		finalContent := baseStyle.
 			Padding(1, 2).
@@ -568,10 +762,16 @@ func (p *permissionDialogCmp) generateDownloadContent() string {
 
 func (p *permissionDialogCmp) generateMultiEditContent() string {
 	if pr, ok := p.permission.Params.(tools.MultiEditPermissionsParams); ok {
+		before, after, lineOffset := pr.OldContent, pr.NewContent, 0
+		if p.hunk > 0 && p.hunk <= len(pr.Hunks) {
+			hunk := pr.Hunks[p.hunk-1]
+			before, after, lineOffset = hunk.OldContent, hunk.NewContent, hunk.StartLine-1
+		}
 		// Use the cache for diff rendering
 		formatter := core.DiffFormatter().
-			Before(fsext.PrettyPath(pr.FilePath), pr.OldContent).
-			After(fsext.PrettyPath(pr.FilePath), pr.NewContent).
+			Before(fsext.PrettyPath(pr.FilePath), before).
+			After(fsext.PrettyPath(pr.FilePath), after).
+			LineOffset(lineOffset).
 			Height(p.contentViewPort.Height()).
 			Width(p.contentViewPort.Width()).
 			XOffset(p.diffXOffset).
@@ -588,6 +788,40 @@ func (p *permissionDialogCmp) generateMultiEditContent() string {
 	return ""
 }
 
+func (p *permissionDialogCmp) generateApplyPatchContent() string {
+	pr, ok := p.permission.Params.(tools.ApplyPatchPermissionsParams)
+	if !ok {
+		return ""
+	}
+	t := styles.CurrentTheme()
+	var lines []string
+	for _, file := range pr.Files {
+		title := fsext.PrettyPath(file.FilePath)
+		switch {
+		case file.Created:
+			title += " (created)"
+		case file.Deleted:
+			title += " (deleted)"
+		}
+		lines = append(lines, t.S().Muted.Width(p.contentViewPort.Width()).Render(title))
+		// Render the whole diff of each file, and scroll them together.
+		formatter := core.DiffFormatter().
+			Before(fsext.PrettyPath(file.FilePath), file.OldContent).
+			After(fsext.PrettyPath(file.FilePath), file.NewContent).
+			Width(p.contentViewPort.Width()).
+			XOffset(p.diffXOffset)
+		if p.useDiffSplitMode() {
+			formatter = formatter.Split()
+		} else {
+			formatter = formatter.Unified()
+		}
+		lines = append(lines, strings.Split(formatter.String(), "\n")...)
+	}
+	height := p.contentViewPort.Height()
+	p.diffYOffset = max(0, min(p.diffYOffset, len(lines)-height))
+	return strings.Join(lines[p.diffYOffset:min(p.diffYOffset+height, len(lines))], "\n")
+}
+
 func (p *permissionDialogCmp) generateFetchContent() string {
 	t := styles.CurrentTheme()
 	baseStyle := t.S().Base.Background(t.BgSubtle)
@@ -803,9 +1037,12 @@ func (p *permissionDialogCmp) SetSize() tea.Cmd {
 	oldWidth, oldHeight := p.width, p.height
 
 	switch p.permission.ToolName {
-	case tools.BashToolName:
+	case tools.BashToolName, tools.RunTestsToolName:
 		p.width = int(float64(p.wWidth) * 0.8)
 		p.height = int(float64(p.wHeight) * 0.3)
+		if params, ok := p.permission.Params.(tools.BashPermissionsParams); ok && params.GitStatus != "" {
+			p.height = int(float64(p.wHeight) * 0.6)
+		}
 	case tools.DownloadToolName:
 		p.width = int(float64(p.wWidth) * 0.8)
 		p.height = int(float64(p.wHeight) * 0.4)
@@ -815,7 +1052,7 @@ func (p *permissionDialogCmp) SetSize() tea.Cmd {
 	case tools.WriteToolName:
 		p.width = int(float64(p.wWidth) * 0.8)
 		p.height = int(float64(p.wHeight) * 0.8)
-	case tools.MultiEditToolName:
+	case tools.MultiEditToolName, tools.ApplyPatchToolName:
 		p.width = int(float64(p.wWidth) * 0.8)
 		p.height = int(float64(p.wHeight) * 0.8)
 	case tools.FetchToolName:
@@ -0,0 +1,216 @@
+package pinfile
+
+import (
+	"slices"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/commands"
+	"github.com/charmbracelet/crush/internal/tui/exp/list"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const (
+	PinFileDialogID dialogs.DialogID = "pin_file"
+
+	defaultWidth int = 60
+)
+
+type listModel = list.FilterableList[list.CompletionItem[string]]
+
+// PinFileDialog picks the file to keep in the file view.
+type PinFileDialog interface {
+	dialogs.DialogModel
+}
+
+type pinFileDialogCmp struct {
+	width   int
+	wWidth  int // Width of the terminal window
+	wHeight int // Height of the terminal window
+
+	fileList listModel
+	keyMap   KeyMap
+	help     help.Model
+}
+
+type KeyMap struct {
+	Next     key.Binding
+	Previous key.Binding
+	Select   key.Binding
+	Close    key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Next: key.NewBinding(
+			key.WithKeys("down", "ctrl+n"),
+			key.WithHelp("↓/ctrl+n", "next"),
+		),
+		Previous: key.NewBinding(
+			key.WithKeys("up", "ctrl+p"),
+			key.WithHelp("↑/ctrl+p", "previous"),
+		),
+		Select: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "pin"),
+		),
+		Close: key.NewBinding(
+			key.WithKeys("esc", "ctrl+c"),
+			key.WithHelp("esc/ctrl+c", "close"),
+		),
+	}
+}
+
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Select, k.Close}
+}
+
+func (k KeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Next, k.Previous},
+		{k.Select, k.Close},
+	}
+}
+
+// NewPinFileDialog creates a dialog that pins a project file in the file
+// view, or goes back to following the files the agent edits.
+func NewPinFileDialog() PinFileDialog {
+	keyMap := DefaultKeyMap()
+	listKeyMap := list.DefaultKeyMap()
+	listKeyMap.Down.SetEnabled(false)
+	listKeyMap.Up.SetEnabled(false)
+	listKeyMap.DownOneItem = keyMap.Next
+	listKeyMap.UpOneItem = keyMap.Previous
+
+	t := styles.CurrentTheme()
+	inputStyle := t.S().Base.PaddingLeft(1).PaddingBottom(1)
+	fileList := list.NewFilterableList(
+		[]list.CompletionItem[string]{},
+		list.WithFilterInputStyle(inputStyle),
+		list.WithFilterListOptions(
+			list.WithKeyMap(listKeyMap),
+			list.WithWrapNavigation(),
+			list.WithResizeByList(),
+		),
+	)
+	help := help.New()
+	help.Styles = t.S().Help
+
+	return &pinFileDialogCmp{
+		fileList: fileList,
+		width:    defaultWidth,
+		keyMap:   keyMap,
+		help:     help,
+	}
+}
+
+func (r *pinFileDialogCmp) Init() tea.Cmd {
+	depth, limit := config.Get().Options.TUI.Completions.Limits()
+	files, _, _ := fsext.ListDirectory(".", nil, depth, limit, false)
+	slices.Sort(files)
+
+	items := []list.CompletionItem[string]{
+		list.NewCompletionItem("Follow agent edits", "", list.WithCompletionID("@follow")),
+	}
+	for _, file := range files {
+		file = strings.TrimPrefix(file, "./")
+		if strings.HasSuffix(file, "/") {
+			continue
+		}
+		items = append(items, list.NewCompletionItem(file, file, list.WithCompletionID(file)))
+	}
+	return r.fileList.SetItems(items)
+}
+
+func (r *pinFileDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		r.wWidth = msg.Width
+		r.wHeight = msg.Height
+		return r, r.fileList.SetSize(r.listWidth(), r.listHeight())
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, r.keyMap.Select):
+			selectedItem := r.fileList.SelectedItem()
+			if selectedItem == nil {
+				return r, nil
+			}
+			return r, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(commands.PinFileMsg{
+					Path: (*selectedItem).Value(),
+				}),
+			)
+		case key.Matches(msg, r.keyMap.Close):
+			return r, util.CmdHandler(dialogs.CloseDialogMsg{})
+		default:
+			u, cmd := r.fileList.Update(msg)
+			r.fileList = u.(listModel)
+			return r, cmd
+		}
+	}
+	return r, nil
+}
+
+func (r *pinFileDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	header := t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Pin File", r.width-4))
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		header,
+		r.fileList.View(),
+		"",
+		t.S().Base.Width(r.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(r.help.View(r.keyMap)),
+	)
+	return r.style().Render(content)
+}
+
+func (r *pinFileDialogCmp) Cursor() *tea.Cursor {
+	if cursor, ok := r.fileList.(util.Cursor); ok {
+		cursor := cursor.Cursor()
+		if cursor != nil {
+			row, col := r.Position()
+			cursor.Y += row + 3
+			cursor.X += col + 2
+		}
+		return cursor
+	}
+	return nil
+}
+
+func (r *pinFileDialogCmp) listWidth() int {
+	return r.width - 2
+}
+
+func (r *pinFileDialogCmp) listHeight() int {
+	listHeight := len(r.fileList.Items()) + 2 + 4 // height based on items + 2 for the input + 4 for the sections
+	return min(listHeight, r.wHeight/2)
+}
+
+func (r *pinFileDialogCmp) style() lipgloss.Style {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(r.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus)
+}
+
+func (r *pinFileDialogCmp) Position() (int, int) {
+	row := r.wHeight/4 - 2 // just a bit above the center
+	col := r.wWidth / 2
+	col -= r.width / 2
+	return row, col
+}
+
+func (r *pinFileDialogCmp) ID() dialogs.DialogID {
+	return PinFileDialogID
+}
@@ -14,6 +14,9 @@ const (
 	QuitDialogID dialogs.DialogID = "quit"
 )
 
+// QuitMsg asks to quit, once what the agent is doing is saved.
+type QuitMsg struct{}
+
 // QuitDialog represents a confirmation dialog for quitting the application.
 type QuitDialog interface {
 	dialogs.DialogModel
@@ -52,11 +55,11 @@ func (q *quitDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			return q, nil
 		case key.Matches(msg, q.keymap.EnterSpace):
 			if !q.selectedNo {
-				return q, tea.Quit
+				return q, util.CmdHandler(QuitMsg{})
 			}
 			return q, util.CmdHandler(dialogs.CloseDialogMsg{})
 		case key.Matches(msg, q.keymap.Yes):
-			return q, tea.Quit
+			return q, util.CmdHandler(QuitMsg{})
 		case key.Matches(msg, q.keymap.No, q.keymap.Close):
 			return q, util.CmdHandler(dialogs.CloseDialogMsg{})
 		}
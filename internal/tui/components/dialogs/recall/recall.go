@@ -0,0 +1,260 @@
+package recall
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/message"
+	recallsearch "github.com/charmbracelet/crush/internal/recall"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/tui/components/chat/editor"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/exp/list"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const RecallDialogID dialogs.DialogID = "recall"
+
+const (
+	// searchDelay is how long to wait for the user to stop typing before
+	// searching.
+	searchDelay = 200 * time.Millisecond
+	maxResults  = 50
+	// maxTitleWidth is the width the session titles are truncated to.
+	maxTitleWidth = 24
+)
+
+// RecallDialog interface for the dialog searching past answers
+type RecallDialog interface {
+	dialogs.DialogModel
+}
+
+type ResultsList = list.List[list.CompletionItem[recallsearch.Result]]
+
+// searchMsg triggers the search for the query typed in the dialog, unless
+// the user kept typing.
+type searchMsg struct {
+	seq int
+}
+
+// resultsMsg carries the answers found for a search.
+type resultsMsg struct {
+	seq     int
+	results []recallsearch.Result
+	err     error
+}
+
+type recallDialogCmp struct {
+	wWidth   int
+	wHeight  int
+	width    int
+	keyMap   KeyMap
+	input    textinput.Model
+	results  ResultsList
+	help     help.Model
+	messages message.Service
+	sessions session.Service
+
+	// seq identifies the latest query, to drop the results of stale ones.
+	seq    int
+	query  string
+	status string
+}
+
+// NewRecallDialogCmp creates a dialog searching the assistant's answers in
+// all sessions, adding the chosen one to the prompt.
+func NewRecallDialogCmp(messages message.Service, sessions session.Service) RecallDialog {
+	t := styles.CurrentTheme()
+	keyMap := DefaultKeyMap()
+	listKeyMap := list.DefaultKeyMap()
+	listKeyMap.Down.SetEnabled(false)
+	listKeyMap.Up.SetEnabled(false)
+	listKeyMap.DownOneItem = keyMap.Next
+	listKeyMap.UpOneItem = keyMap.Previous
+
+	input := textinput.New()
+	input.Placeholder = "Search previous answers"
+	input.SetVirtualCursor(false)
+	input.SetStyles(t.S().TextInput)
+	input.Focus()
+
+	help := help.New()
+	help.Styles = t.S().Help
+	return &recallDialogCmp{
+		keyMap: keyMap,
+		input:  input,
+		results: list.New(
+			[]list.CompletionItem[recallsearch.Result]{},
+			list.WithKeyMap(listKeyMap),
+			list.WithWrapNavigation(),
+		),
+		help:     help,
+		messages: messages,
+		sessions: sessions,
+		status:   "Type to search the answers of all sessions",
+	}
+}
+
+func (r *recallDialogCmp) Init() tea.Cmd {
+	return tea.Sequence(r.results.Init(), r.results.Focus())
+}
+
+func (r *recallDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		r.wWidth = msg.Width
+		r.wHeight = msg.Height
+		r.width = min(120, r.wWidth-8)
+		r.input.SetWidth(r.listWidth() - 4)
+		return r, r.results.SetSize(r.listWidth(), r.listHeight())
+	case searchMsg:
+		if msg.seq != r.seq {
+			return r, nil
+		}
+		return r, r.search(msg.seq, r.query)
+	case resultsMsg:
+		if msg.seq != r.seq {
+			return r, nil
+		}
+		if msg.err != nil {
+			r.status = "Search failed"
+			return r, tea.Batch(r.results.SetItems(nil), util.ReportError(msg.err))
+		}
+		return r, r.setResults(msg.results)
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, r.keyMap.Select):
+			selectedItem := r.results.SelectedItem()
+			if selectedItem == nil {
+				return r, nil
+			}
+			selected := (*selectedItem).Value()
+			return r, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(editor.InsertTextMsg{Text: recallsearch.Quote(selected)}),
+			)
+		case key.Matches(msg, r.keyMap.Close):
+			return r, util.CmdHandler(dialogs.CloseDialogMsg{})
+		case key.Matches(msg, r.keyMap.Next):
+			return r, r.results.SelectItemBelow()
+		case key.Matches(msg, r.keyMap.Previous):
+			return r, r.results.SelectItemAbove()
+		default:
+			var cmd tea.Cmd
+			r.input, cmd = r.input.Update(msg)
+			if r.input.Value() == r.query {
+				return r, cmd
+			}
+			r.query = r.input.Value()
+			r.seq++
+			seq := r.seq
+			return r, tea.Batch(cmd, tea.Tick(searchDelay, func(time.Time) tea.Msg {
+				return searchMsg{seq: seq}
+			}))
+		}
+	}
+	return r, nil
+}
+
+func (r *recallDialogCmp) search(seq int, query string) tea.Cmd {
+	return func() tea.Msg {
+		results, err := recallsearch.Search(context.Background(), r.messages, r.sessions, query, maxResults)
+		return resultsMsg{seq: seq, results: results, err: err}
+	}
+}
+
+func (r *recallDialogCmp) setResults(results []recallsearch.Result) tea.Cmd {
+	switch {
+	case r.query == "":
+		r.status = "Type to search the answers of all sessions"
+	case len(results) == 0:
+		r.status = "No matching answers"
+	case len(results) == maxResults:
+		r.status = fmt.Sprintf("Showing the newest %d answers", maxResults)
+	default:
+		r.status = fmt.Sprintf("%d answers", len(results))
+	}
+
+	items := make([]list.CompletionItem[recallsearch.Result], len(results))
+	for i, result := range results {
+		title := result.SessionTitle
+		if title == "" {
+			title = "Untitled session"
+		}
+		title = " " + ansi.Truncate(title, maxTitleWidth, "…")
+		// Leave room for the padding of the item and the session title.
+		snippetWidth := r.listWidth() - 3 - lipgloss.Width(title)
+		items[i] = list.NewCompletionItem(
+			recallsearch.Snippet(result.Text(), r.query, snippetWidth),
+			result,
+			list.WithCompletionID(result.Message.ID),
+			list.WithCompletionShortcut(title),
+		)
+	}
+	return r.results.SetItems(items)
+}
+
+func (r *recallDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	parts := []string{
+		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Recall Answer", r.width-4)),
+		t.S().Base.PaddingLeft(1).Render(r.input.View()),
+		t.S().Base.Padding(1, 1, 0, 1).Render(t.S().Subtle.Render(r.status)),
+	}
+	if len(r.results.Items()) > 0 {
+		parts = append(parts, "", r.results.View())
+	}
+	parts = append(parts,
+		"",
+		t.S().Base.Width(r.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(r.help.View(r.keyMap)),
+	)
+	return r.style().Render(lipgloss.JoinVertical(lipgloss.Left, parts...))
+}
+
+func (r *recallDialogCmp) Cursor() *tea.Cursor {
+	cursor := r.input.Cursor()
+	if cursor == nil {
+		return nil
+	}
+	row, col := r.Position()
+	cursor.Y += row + 3 // Border + title
+	cursor.X += col + 2
+	return cursor
+}
+
+func (r *recallDialogCmp) style() lipgloss.Style {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(r.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus)
+}
+
+func (r *recallDialogCmp) listHeight() int {
+	return r.wHeight/2 - 8 // border, title, input, status and help
+}
+
+func (r *recallDialogCmp) listWidth() int {
+	return r.width - 2 // 2 for the border
+}
+
+func (r *recallDialogCmp) Position() (int, int) {
+	row := r.wHeight/4 - 2 // just a bit above the center
+	col := r.wWidth / 2
+	col -= r.width / 2
+	return row, col
+}
+
+// ID implements RecallDialog.
+func (r *recallDialogCmp) ID() dialogs.DialogID {
+	return RecallDialogID
+}
@@ -0,0 +1,205 @@
+package resend
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textarea"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const (
+	ResendDialogID dialogs.DialogID = "resend"
+
+	defaultWidth  int = 70
+	defaultHeight int = 8
+)
+
+// ResendMsg asks to drop the user message with MessageID from the session
+// with SessionID, along with everything after it, and send Prompt instead.
+type ResendMsg struct {
+	SessionID string
+	MessageID string
+	Prompt    string
+}
+
+// ResendDialog edits an earlier user message of a session before sending it
+// again.
+type ResendDialog interface {
+	dialogs.DialogModel
+}
+
+type resendDialogCmp struct {
+	wWidth  int
+	wHeight int
+
+	sessionID  string
+	messageID  string
+	discarded  int
+	input      textarea.Model
+	confirming bool
+
+	send  key.Binding
+	close key.Binding
+	yes   key.Binding
+	no    key.Binding
+}
+
+// NewResendDialogCmp creates a dialog to edit text, the content of the user
+// message with messageID, and resend it, discarding the discarded messages
+// that come after it.
+func NewResendDialogCmp(sessionID, messageID, text string, discarded int) ResendDialog {
+	t := styles.CurrentTheme()
+	input := textarea.New()
+	input.SetStyles(t.S().TextArea)
+	input.ShowLineNumbers = false
+	input.CharLimit = -1
+	input.SetVirtualCursor(false)
+	input.SetWidth(defaultWidth - 4)
+	input.SetHeight(defaultHeight)
+	input.SetValue(text)
+	input.Focus()
+
+	return &resendDialogCmp{
+		sessionID: sessionID,
+		messageID: messageID,
+		discarded: discarded,
+		input:     input,
+		send: key.NewBinding(
+			key.WithKeys("ctrl+s", "ctrl+y"),
+			key.WithHelp("ctrl+s", "resend"),
+		),
+		close: key.NewBinding(
+			key.WithKeys("esc", "alt+esc"),
+			key.WithHelp("esc", "cancel"),
+		),
+		yes: key.NewBinding(
+			key.WithKeys("y", "Y", "enter"),
+			key.WithHelp("y", "discard and resend"),
+		),
+		no: key.NewBinding(
+			key.WithKeys("n", "N", "esc", "alt+esc"),
+			key.WithHelp("n", "back"),
+		),
+	}
+}
+
+func (d *resendDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (d *resendDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		d.wWidth = msg.Width
+		d.wHeight = msg.Height
+	case tea.KeyPressMsg:
+		if d.confirming {
+			switch {
+			case key.Matches(msg, d.yes):
+				return d, d.resend()
+			case key.Matches(msg, d.no):
+				d.confirming = false
+				return d, d.input.Focus()
+			}
+			return d, nil
+		}
+		switch {
+		case key.Matches(msg, d.close):
+			return d, util.CmdHandler(dialogs.CloseDialogMsg{})
+		case key.Matches(msg, d.send):
+			if strings.TrimSpace(d.input.Value()) == "" {
+				return d, util.ReportWarn("The message is empty")
+			}
+			if d.discarded == 0 {
+				return d, d.resend()
+			}
+			d.confirming = true
+			d.input.Blur()
+			return d, nil
+		default:
+			var cmd tea.Cmd
+			d.input, cmd = d.input.Update(msg)
+			return d, cmd
+		}
+	case tea.PasteMsg:
+		if d.confirming {
+			return d, nil
+		}
+		var cmd tea.Cmd
+		d.input, cmd = d.input.Update(msg)
+		return d, cmd
+	}
+	return d, nil
+}
+
+func (d *resendDialogCmp) resend() tea.Cmd {
+	return tea.Sequence(
+		util.CmdHandler(dialogs.CloseDialogMsg{}),
+		util.CmdHandler(ResendMsg{
+			SessionID: d.sessionID,
+			MessageID: d.messageID,
+			Prompt:    d.input.Value(),
+		}),
+	)
+}
+
+func (d *resendDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	header := t.S().Base.PaddingBottom(1).Render(core.Title("Edit and Resend", defaultWidth-4))
+	footer := t.S().Subtle.PaddingTop(1).Render("ctrl+s resend · esc cancel")
+	if d.confirming {
+		footer = lipgloss.JoinVertical(
+			lipgloss.Left,
+			t.S().Warning.PaddingTop(1).Width(defaultWidth-4).Render(d.confirmation()),
+			t.S().Subtle.PaddingTop(1).Render("y discard and resend · n back"),
+		)
+	}
+	content := lipgloss.JoinVertical(lipgloss.Left, header, d.input.View(), footer)
+	return t.S().Base.
+		Width(defaultWidth).
+		Padding(0, 1).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(content)
+}
+
+// confirmation describes what resending discards.
+func (d *resendDialogCmp) confirmation() string {
+	noun := "messages"
+	if d.discarded == 1 {
+		noun = "message"
+	}
+	return fmt.Sprintf("Resending discards the %d later %s of this session. Continue?", d.discarded, noun)
+}
+
+func (d *resendDialogCmp) Cursor() *tea.Cursor {
+	if d.confirming {
+		return nil
+	}
+	cursor := d.input.Cursor()
+	if cursor == nil {
+		return nil
+	}
+	row, col := d.Position()
+	// Border and title.
+	cursor.Y += row + 3
+	cursor.X += col + 2
+	return cursor
+}
+
+func (d *resendDialogCmp) Position() (int, int) {
+	row := d.wHeight/2 - (defaultHeight+6)/2
+	col := d.wWidth/2 - defaultWidth/2
+	return row, col
+}
+
+func (d *resendDialogCmp) ID() dialogs.DialogID {
+	return ResendDialogID
+}
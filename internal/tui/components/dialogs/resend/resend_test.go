@@ -0,0 +1,62 @@
+package resend
+
+import (
+	"reflect"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/stretchr/testify/require"
+)
+
+// collect runs cmd, and the commands of the sequences it returns.
+func collect(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	// Sequences are an unexported slice of commands.
+	if v := reflect.ValueOf(msg); v.Kind() == reflect.Slice {
+		var msgs []tea.Msg
+		for i := range v.Len() {
+			msgs = append(msgs, collect(v.Index(i).Interface().(tea.Cmd))...)
+		}
+		return msgs
+	}
+	return []tea.Msg{msg}
+}
+
+func TestResendConfirmsDiscarding(t *testing.T) {
+	t.Parallel()
+
+	d := NewResendDialogCmp("session", "message", "hello", 3).(*resendDialogCmp)
+	require.Equal(t, "hello", d.input.Value())
+
+	_, cmd := d.Update(tea.KeyPressMsg{Code: 's', Mod: tea.ModCtrl})
+	require.Nil(t, cmd)
+	require.True(t, d.confirming)
+	require.Contains(t, d.confirmation(), "3 later messages")
+
+	d.Update(tea.KeyPressMsg{Code: 'n', Text: "n"})
+	require.False(t, d.confirming)
+	require.True(t, d.input.Focused(), "back to editing")
+
+	d.Update(tea.KeyPressMsg{Code: 's', Mod: tea.ModCtrl})
+	_, cmd = d.Update(tea.KeyPressMsg{Code: 'y', Text: "y"})
+	require.Equal(t, []tea.Msg{
+		dialogs.CloseDialogMsg{},
+		ResendMsg{SessionID: "session", MessageID: "message", Prompt: "hello"},
+	}, collect(cmd))
+}
+
+func TestResendLastMessage(t *testing.T) {
+	t.Parallel()
+
+	d := NewResendDialogCmp("session", "message", "hello", 0).(*resendDialogCmp)
+	_, cmd := d.Update(tea.KeyPressMsg{Code: 's', Mod: tea.ModCtrl})
+	require.False(t, d.confirming, "nothing is discarded")
+	require.Equal(t, []tea.Msg{
+		dialogs.CloseDialogMsg{},
+		ResendMsg{SessionID: "session", MessageID: "message", Prompt: "hello"},
+	}, collect(cmd))
+}
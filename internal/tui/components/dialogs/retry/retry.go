@@ -0,0 +1,227 @@
+package retry
+
+import (
+	"cmp"
+	"slices"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/commands"
+	"github.com/charmbracelet/crush/internal/tui/exp/list"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const (
+	RetryDialogID dialogs.DialogID = "retry"
+
+	defaultWidth int = 60
+)
+
+type listModel = list.FilterableList[list.CompletionItem[string]]
+
+// RetryDialog picks the model to retry the last response of a session with.
+type RetryDialog interface {
+	dialogs.DialogModel
+}
+
+type retryDialogCmp struct {
+	width   int
+	wWidth  int // Width of the terminal window
+	wHeight int // Height of the terminal window
+
+	sessionID string
+	modelList listModel
+	keyMap    KeyMap
+	help      help.Model
+}
+
+type KeyMap struct {
+	Next     key.Binding
+	Previous key.Binding
+	Select   key.Binding
+	Close    key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Next: key.NewBinding(
+			key.WithKeys("down", "ctrl+n"),
+			key.WithHelp("↓/ctrl+n", "next"),
+		),
+		Previous: key.NewBinding(
+			key.WithKeys("up", "ctrl+p"),
+			key.WithHelp("↑/ctrl+p", "previous"),
+		),
+		Select: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "retry"),
+		),
+		Close: key.NewBinding(
+			key.WithKeys("esc", "ctrl+c"),
+			key.WithHelp("esc/ctrl+c", "close"),
+		),
+	}
+}
+
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Select, k.Close}
+}
+
+func (k KeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Next, k.Previous},
+		{k.Select, k.Close},
+	}
+}
+
+// NewRetryDialog creates a dialog that retries the last response of the
+// session with the model picked from the configured ones.
+func NewRetryDialog(sessionID string) RetryDialog {
+	keyMap := DefaultKeyMap()
+	listKeyMap := list.DefaultKeyMap()
+	listKeyMap.Down.SetEnabled(false)
+	listKeyMap.Up.SetEnabled(false)
+	listKeyMap.DownOneItem = keyMap.Next
+	listKeyMap.UpOneItem = keyMap.Previous
+
+	t := styles.CurrentTheme()
+	inputStyle := t.S().Base.PaddingLeft(1).PaddingBottom(1)
+	modelList := list.NewFilterableList(
+		[]list.CompletionItem[string]{},
+		list.WithFilterInputStyle(inputStyle),
+		list.WithFilterListOptions(
+			list.WithKeyMap(listKeyMap),
+			list.WithWrapNavigation(),
+			list.WithResizeByList(),
+		),
+	)
+	help := help.New()
+	help.Styles = t.S().Help
+
+	return &retryDialogCmp{
+		sessionID: sessionID,
+		modelList: modelList,
+		width:     defaultWidth,
+		keyMap:    keyMap,
+		help:      help,
+	}
+}
+
+func (r *retryDialogCmp) Init() tea.Cmd {
+	cfg := config.Get()
+	current := cfg.Models[config.SelectedModelTypeLarge]
+	providers := cfg.EnabledProviders()
+	slices.SortFunc(providers, func(a, b config.ProviderConfig) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	var items []list.CompletionItem[string]
+	for _, provider := range providers {
+		for _, model := range provider.Models {
+			id := provider.ID + "/" + model.ID
+			shortcut := provider.Name
+			if provider.ID == current.Provider && model.ID == current.Model {
+				shortcut = "current"
+			}
+			items = append(items, list.NewCompletionItem(
+				cmp.Or(model.Name, model.ID),
+				id,
+				list.WithCompletionID(id),
+				list.WithCompletionShortcut(shortcut),
+			))
+		}
+	}
+	return r.modelList.SetItems(items)
+}
+
+func (r *retryDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		r.wWidth = msg.Width
+		r.wHeight = msg.Height
+		return r, r.modelList.SetSize(r.listWidth(), r.listHeight())
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, r.keyMap.Select):
+			selectedItem := r.modelList.SelectedItem()
+			if selectedItem == nil {
+				return r, nil
+			}
+			return r, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(commands.RetryMsg{
+					SessionID: r.sessionID,
+					Model:     (*selectedItem).Value(),
+				}),
+			)
+		case key.Matches(msg, r.keyMap.Close):
+			return r, util.CmdHandler(dialogs.CloseDialogMsg{})
+		default:
+			u, cmd := r.modelList.Update(msg)
+			r.modelList = u.(listModel)
+			return r, cmd
+		}
+	}
+	return r, nil
+}
+
+func (r *retryDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	header := t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Retry With Model", r.width-4))
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		header,
+		r.modelList.View(),
+		"",
+		t.S().Base.Width(r.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(r.help.View(r.keyMap)),
+	)
+	return r.style().Render(content)
+}
+
+func (r *retryDialogCmp) Cursor() *tea.Cursor {
+	if cursor, ok := r.modelList.(util.Cursor); ok {
+		cursor := cursor.Cursor()
+		if cursor != nil {
+			row, col := r.Position()
+			cursor.Y += row + 3
+			cursor.X += col + 2
+		}
+		return cursor
+	}
+	return nil
+}
+
+func (r *retryDialogCmp) listWidth() int {
+	return r.width - 2
+}
+
+func (r *retryDialogCmp) listHeight() int {
+	listHeight := len(r.modelList.Items()) + 2 + 4 // height based on items + 2 for the input + 4 for the sections
+	return min(listHeight, r.wHeight/2)
+}
+
+func (r *retryDialogCmp) style() lipgloss.Style {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(r.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus)
+}
+
+func (r *retryDialogCmp) Position() (int, int) {
+	row := r.wHeight/4 - 2 // just a bit above the center
+	col := r.wWidth / 2
+	col -= r.width / 2
+	return row, col
+}
+
+func (r *retryDialogCmp) ID() dialogs.DialogID {
+	return RetryDialogID
+}
@@ -0,0 +1,158 @@
+package scope
+
+import (
+	"path/filepath"
+
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const (
+	ScopeDialogID dialogs.DialogID = "scope"
+
+	defaultWidth int = 60
+)
+
+// ScopeDialog changes the directory the file tools of a session are
+// restricted to.
+type ScopeDialog interface {
+	dialogs.DialogModel
+}
+
+type scopeDialogCmp struct {
+	wWidth  int
+	wHeight int
+
+	coordinator agent.Coordinator
+	sessionID   string
+	workingDir  string
+	input       textinput.Model
+	save        key.Binding
+	close       key.Binding
+}
+
+// NewScopeDialogCmp creates a dialog to change the scope of the session
+// with sessionID, or of new sessions when it is empty, to a directory
+// relative to workingDir.
+func NewScopeDialogCmp(coordinator agent.Coordinator, sessionID, workingDir string) ScopeDialog {
+	t := styles.CurrentTheme()
+	input := textinput.New()
+	input.Placeholder = "packages/api"
+	input.Prompt = "> "
+	input.SetVirtualCursor(false)
+	input.SetStyles(t.S().TextInput)
+	input.SetWidth(defaultWidth - 6)
+	if rel, err := filepath.Rel(workingDir, coordinator.Scope(sessionID)); err == nil && rel != "." {
+		input.SetValue(filepath.ToSlash(rel))
+	}
+	input.Focus()
+
+	return &scopeDialogCmp{
+		coordinator: coordinator,
+		sessionID:   sessionID,
+		workingDir:  workingDir,
+		input:       input,
+		save: key.NewBinding(
+			key.WithKeys("enter", "ctrl+y"),
+			key.WithHelp("enter", "save"),
+		),
+		close: key.NewBinding(
+			key.WithKeys("esc", "alt+esc"),
+			key.WithHelp("esc", "cancel"),
+		),
+	}
+}
+
+func (d *scopeDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (d *scopeDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		d.wWidth = msg.Width
+		d.wHeight = msg.Height
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, d.close):
+			return d, util.CmdHandler(dialogs.CloseDialogMsg{})
+		case key.Matches(msg, d.save):
+			return d, d.saveScope()
+		default:
+			var cmd tea.Cmd
+			d.input, cmd = d.input.Update(msg)
+			return d, cmd
+		}
+	case tea.PasteMsg:
+		var cmd tea.Cmd
+		d.input, cmd = d.input.Update(msg)
+		return d, cmd
+	}
+	return d, nil
+}
+
+// saveScope sets the scope, keeping the dialog open when the directory is
+// invalid so that it can be fixed.
+func (d *scopeDialogCmp) saveScope() tea.Cmd {
+	dir := d.input.Value()
+	if err := d.coordinator.SetScope(d.sessionID, dir); err != nil {
+		return util.ReportError(err)
+	}
+	info := "Scope set to " + filepath.ToSlash(filepath.Clean(dir))
+	if rel, _ := filepath.Rel(d.workingDir, d.coordinator.Scope(d.sessionID)); rel == "." {
+		info = "Scope cleared: the file tools can use the whole project"
+	}
+	return tea.Sequence(
+		util.CmdHandler(dialogs.CloseDialogMsg{}),
+		util.ReportInfo(info),
+	)
+}
+
+func (d *scopeDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	header := t.S().Base.PaddingBottom(1).Render(core.Title("Set Scope", defaultWidth-4))
+	help := t.S().Subtle.PaddingTop(1).Render("enter save · esc cancel")
+	content := lipgloss.JoinVertical(lipgloss.Left, header, d.explanationView(), d.input.View(), help)
+	return t.S().Base.
+		Width(defaultWidth).
+		Padding(0, 1).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(content)
+}
+
+func (d *scopeDialogCmp) explanationView() string {
+	t := styles.CurrentTheme()
+	return t.S().Muted.PaddingBottom(1).Width(defaultWidth - 4).Render(
+		"The file tools only use files in this directory of the project, and search it by default. Leave it empty for the whole project.",
+	)
+}
+
+func (d *scopeDialogCmp) Cursor() *tea.Cursor {
+	cursor := d.input.Cursor()
+	if cursor == nil {
+		return nil
+	}
+	row, col := d.Position()
+	// Border, title and explanation.
+	cursor.Y += row + 3 + lipgloss.Height(d.explanationView())
+	cursor.X += col + 2
+	return cursor
+}
+
+func (d *scopeDialogCmp) Position() (int, int) {
+	row := d.wHeight/2 - 5
+	col := d.wWidth/2 - defaultWidth/2
+	return row, col
+}
+
+func (d *scopeDialogCmp) ID() dialogs.DialogID {
+	return ScopeDialogID
+}
@@ -8,6 +8,8 @@ type KeyMap struct {
 	Select,
 	Next,
 	Previous,
+	Tag,
+	FilterTag,
 	Close key.Binding
 }
 
@@ -25,6 +27,14 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("up", "ctrl+p"),
 			key.WithHelp("↑", "previous item"),
 		),
+		Tag: key.NewBinding(
+			key.WithKeys("ctrl+t"),
+			key.WithHelp("ctrl+t", "tag"),
+		),
+		FilterTag: key.NewBinding(
+			key.WithKeys("ctrl+f"),
+			key.WithHelp("ctrl+f", "filter by tag"),
+		),
 		Close: key.NewBinding(
 			key.WithKeys("esc", "alt+esc"),
 			key.WithHelp("esc", "exit"),
@@ -38,6 +48,8 @@ func (k KeyMap) KeyBindings() []key.Binding {
 		k.Select,
 		k.Next,
 		k.Previous,
+		k.Tag,
+		k.FilterTag,
 		k.Close,
 	}
 }
@@ -62,6 +74,8 @@ func (k KeyMap) ShortHelp() []key.Binding {
 			key.WithHelp("↑↓", "choose"),
 		),
 		k.Select,
+		k.Tag,
+		k.FilterTag,
 		k.Close,
 	}
 }
@@ -1,22 +1,56 @@
 package sessions
 
 import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
 	"charm.land/bubbles/v2/help"
 	"charm.land/bubbles/v2/key"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/event"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/tui/components/chat"
 	"github.com/charmbracelet/crush/internal/tui/components/core"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/commands"
 	"github.com/charmbracelet/crush/internal/tui/exp/list"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/x/ansi"
 )
 
 const SessionsDialogID dialogs.DialogID = "sessions"
 
+const (
+	// previewMessages is how many of the last messages of the selected
+	// session are loaded for its preview.
+	previewMessages = 6
+	// maxPreviewLines is how many lines of each message the preview shows.
+	maxPreviewLines = 3
+	// minPreviewWidth is the dialog width below which the preview is hidden.
+	minPreviewWidth = 90
+)
+
+// previewMsg carries the last messages of a session, for its preview.
+type previewMsg struct {
+	sessionID string
+	messages  []message.Message
+	err       error
+}
+
+// preview is what the preview pane shows of a session.
+type preview struct {
+	messages []message.Message
+	err      error
+}
+
 // SessionDialog interface for the session switching dialog
 type SessionDialog interface {
 	dialogs.DialogModel
@@ -33,10 +67,22 @@ type sessionDialogCmp struct {
 	keyMap            KeyMap
 	sessionsList      SessionsList
 	help              help.Model
+
+	// sessions are all the sessions, of which only the ones tagged with
+	// tagFilter are listed when it isn't empty.
+	sessions  []session.Session
+	tagFilter string
+
+	// messages loads the previews, which are kept by session ID. previewID
+	// is the session the preview pane shows.
+	messages  message.Service
+	previews  map[string]preview
+	previewID string
 }
 
-// NewSessionDialogCmp creates a new session switching dialog
-func NewSessionDialogCmp(sessions []session.Session, selectedID string) SessionDialog {
+// NewSessionDialogCmp creates a new session switching dialog, previewing the
+// last messages of the selected session next to the list.
+func NewSessionDialogCmp(sessions []session.Session, selectedID string, messages message.Service) SessionDialog {
 	t := styles.CurrentTheme()
 	listKeyMap := list.DefaultKeyMap()
 	keyMap := DefaultKeyMap()
@@ -45,16 +91,9 @@ func NewSessionDialogCmp(sessions []session.Session, selectedID string) SessionD
 	listKeyMap.DownOneItem = keyMap.Next
 	listKeyMap.UpOneItem = keyMap.Previous
 
-	items := make([]list.CompletionItem[session.Session], len(sessions))
-	if len(sessions) > 0 {
-		for i, session := range sessions {
-			items[i] = list.NewCompletionItem(session.Title, session, list.WithCompletionID(session.ID))
-		}
-	}
-
 	inputStyle := t.S().Base.PaddingLeft(1).PaddingBottom(1)
 	sessionsList := list.NewFilterableList(
-		items,
+		sessionItems(sessions, ""),
 		list.WithFilterPlaceholder("Enter a session name"),
 		list.WithFilterInputStyle(inputStyle),
 		list.WithFilterListOptions(
@@ -69,6 +108,9 @@ func NewSessionDialogCmp(sessions []session.Session, selectedID string) SessionD
 		keyMap:            DefaultKeyMap(),
 		sessionsList:      sessionsList,
 		help:              help,
+		sessions:          sessions,
+		messages:          messages,
+		previews:          make(map[string]preview),
 	}
 
 	return s
@@ -82,7 +124,15 @@ func (s *sessionDialogCmp) Init() tea.Cmd {
 }
 
 func (s *sessionDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	m, cmd := s.update(msg)
+	return m, tea.Batch(cmd, s.loadPreview())
+}
+
+func (s *sessionDialogCmp) update(msg tea.Msg) (util.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case previewMsg:
+		s.previews[msg.sessionID] = preview{messages: msg.messages, err: msg.err}
+		return s, nil
 	case tea.WindowSizeMsg:
 		var cmds []tea.Cmd
 		s.wWidth = msg.Width
@@ -94,6 +144,31 @@ func (s *sessionDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			cmds = append(cmds, s.sessionsList.SetSelected(s.selectedSessionID))
 		}
 		return s, tea.Batch(cmds...)
+	case pubsub.Event[session.Session]:
+		if msg.Type != pubsub.UpdatedEvent {
+			return s, nil
+		}
+		i := slices.IndexFunc(s.sessions, func(existing session.Session) bool {
+			return existing.ID == msg.Payload.ID
+		})
+		if i == -1 {
+			return s, nil
+		}
+		s.sessions[i] = msg.Payload
+		// The session changed, so its preview may be stale.
+		delete(s.previews, msg.Payload.ID)
+		if s.previewID == msg.Payload.ID {
+			s.previewID = ""
+		}
+		var selectedID string
+		if selectedItem := s.sessionsList.SelectedItem(); selectedItem != nil {
+			selectedID = (*selectedItem).Value().ID
+		}
+		cmds := []tea.Cmd{s.sessionsList.SetItems(sessionItems(s.sessions, s.tagFilter))}
+		if selectedID != "" {
+			cmds = append(cmds, s.sessionsList.SetSelected(selectedID))
+		}
+		return s, tea.Sequence(cmds...)
 	case tea.KeyPressMsg:
 		switch {
 		case key.Matches(msg, s.keyMap.Select):
@@ -108,6 +183,16 @@ func (s *sessionDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 					),
 				)
 			}
+		case key.Matches(msg, s.keyMap.Tag):
+			selectedItem := s.sessionsList.SelectedItem()
+			if selectedItem != nil {
+				return s, util.CmdHandler(commands.OpenTagsDialogMsg{
+					SessionID: (*selectedItem).Value().ID,
+				})
+			}
+		case key.Matches(msg, s.keyMap.FilterTag):
+			s.tagFilter = nextTag(s.sessions, s.tagFilter)
+			return s, s.sessionsList.SetItems(sessionItems(s.sessions, s.tagFilter))
 		case key.Matches(msg, s.keyMap.Close):
 			return s, util.CmdHandler(dialogs.CloseDialogMsg{})
 		default:
@@ -122,9 +207,16 @@ func (s *sessionDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 func (s *sessionDialogCmp) View() string {
 	t := styles.CurrentTheme()
 	listView := s.sessionsList.View()
+	if s.showPreview() {
+		listView = lipgloss.JoinHorizontal(
+			lipgloss.Top,
+			listView,
+			s.renderPreview(s.previewWidth(), lipgloss.Height(listView)),
+		)
+	}
 	content := lipgloss.JoinVertical(
 		lipgloss.Left,
-		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Switch Session", s.width-4)),
+		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title(s.title(), s.width-4)),
 		listView,
 		"",
 		t.S().Base.Width(s.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(s.help.View(s.keyMap)),
@@ -133,6 +225,169 @@ func (s *sessionDialogCmp) View() string {
 	return s.style().Render(content)
 }
 
+// loadPreview loads the preview of the selected session, unless it's already
+// loaded.
+func (s *sessionDialogCmp) loadPreview() tea.Cmd {
+	selectedItem := s.sessionsList.SelectedItem()
+	if selectedItem == nil {
+		s.previewID = ""
+		return nil
+	}
+	id := (*selectedItem).Value().ID
+	if id == s.previewID {
+		return nil
+	}
+	s.previewID = id
+	if _, ok := s.previews[id]; ok || s.messages == nil {
+		return nil
+	}
+	messages := s.messages
+	return func() tea.Msg {
+		msgs, err := messages.ListLastN(context.Background(), id, previewMessages)
+		return previewMsg{sessionID: id, messages: msgs, err: err}
+	}
+}
+
+// renderPreview renders the preview of the selected session: its title, the
+// model it last used, its cost, when it was updated and its last messages,
+// the newest at the bottom.
+func (s *sessionDialogCmp) renderPreview(width, height int) string {
+	t := styles.CurrentTheme()
+	style := t.S().Base.
+		Width(width).
+		Height(height).
+		MaxHeight(height).
+		PaddingLeft(1).
+		BorderLeft(true).
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(t.Border)
+	textWidth := width - 2 // border and padding
+
+	i := slices.IndexFunc(s.sessions, func(sess session.Session) bool {
+		return sess.ID == s.previewID
+	})
+	if i == -1 {
+		return style.Render("")
+	}
+	sess := s.sessions[i]
+
+	title := sess.Title
+	if title == "" {
+		title = "Untitled session"
+	}
+	header := []string{t.S().Text.Bold(true).Render(ansi.Truncate(title, textWidth, "…"))}
+
+	var body []string
+	p, loaded := s.previews[sess.ID]
+	switch {
+	case !loaded:
+		body = append(body, t.S().Subtle.Render("Loading…"))
+	case p.err != nil:
+		body = append(body, t.S().Error.Render("Couldn't load the messages"))
+	default:
+		for _, msg := range p.messages {
+			if msg.Role == message.Tool {
+				continue
+			}
+			text := strings.TrimSpace(msg.PlainText())
+			if text == "" {
+				continue
+			}
+			textStyle := t.S().Muted
+			if msg.Role == message.User {
+				textStyle = t.S().Text
+			}
+			lines := strings.Split(textStyle.Width(textWidth).Render(text), "\n")
+			if len(lines) > maxPreviewLines {
+				lines = lines[:maxPreviewLines]
+				lines[maxPreviewLines-1] = textStyle.Render(ansi.Truncate(ansi.Strip(lines[maxPreviewLines-1]), textWidth-1, "") + "…")
+			}
+			body = append(body, strings.Join(lines, "\n"), "")
+		}
+		if len(body) == 0 {
+			body = append(body, t.S().Subtle.Render("No messages yet"))
+		}
+	}
+
+	info := []string{fmt.Sprintf("$%.2f", sess.Cost), time.Unix(sess.UpdatedAt, 0).Format("2006-01-02 15:04")}
+	if model := previewModel(p.messages); model != "" {
+		info = append([]string{model}, info...)
+	}
+	header = append(header,
+		t.S().Subtle.Render(ansi.Truncate(strings.Join(info, " · "), textWidth, "…")),
+		"",
+	)
+
+	// Keep the newest lines when the messages don't fit.
+	lines := strings.Split(strings.TrimSuffix(strings.Join(body, "\n"), "\n"), "\n")
+	if room := height - len(header); len(lines) > room {
+		lines = lines[len(lines)-max(room, 0):]
+	}
+	return style.Render(strings.Join(append(header, lines...), "\n"))
+}
+
+// previewModel returns the name of the model that wrote the last assistant
+// message of messages, or "" if there's none.
+func previewModel(messages []message.Message) string {
+	for _, msg := range slices.Backward(messages) {
+		if msg.Role != message.Assistant || msg.Model == "" {
+			continue
+		}
+		if cfg := config.Get(); cfg != nil {
+			if model := cfg.GetModel(msg.Provider, msg.Model); model != nil {
+				return model.Name
+			}
+		}
+		return msg.Model
+	}
+	return ""
+}
+
+func (s *sessionDialogCmp) showPreview() bool {
+	return s.width >= minPreviewWidth
+}
+
+func (s *sessionDialogCmp) title() string {
+	if s.tagFilter == "" {
+		return "Switch Session"
+	}
+	return "Switch Session #" + s.tagFilter
+}
+
+// sessionItems lists the sessions tagged with tag, or all of them when tag
+// is empty, with their tags on the side.
+func sessionItems(sessions []session.Session, tag string) []list.CompletionItem[session.Session] {
+	var items []list.CompletionItem[session.Session]
+	for _, s := range sessions {
+		if tag != "" && !s.HasTag(tag) {
+			continue
+		}
+		opts := []list.CompletionItemOption{list.WithCompletionID(s.ID)}
+		if len(s.Tags) > 0 {
+			opts = append(opts, list.WithCompletionShortcut("#"+strings.Join(s.Tags, " #")))
+		}
+		items = append(items, list.NewCompletionItem(s.Title, s, opts...))
+	}
+	return items
+}
+
+// nextTag returns the tag after current, in alphabetical order, of the tags
+// of sessions. It returns "" after the last one, and the first one after "".
+func nextTag(sessions []session.Session, current string) string {
+	var tags []string
+	for _, s := range sessions {
+		tags = append(tags, s.Tags...)
+	}
+	slices.Sort(tags)
+	tags = slices.Compact(tags)
+	for _, tag := range tags {
+		if tag > current {
+			return tag
+		}
+	}
+	return ""
+}
+
 func (s *sessionDialogCmp) Cursor() *tea.Cursor {
 	if cursor, ok := s.sessionsList.(util.Cursor); ok {
 		cursor := cursor.Cursor()
@@ -157,9 +412,16 @@ func (s *sessionDialogCmp) listHeight() int {
 }
 
 func (s *sessionDialogCmp) listWidth() int {
+	if s.showPreview() {
+		return (s.width - 2) / 2
+	}
 	return s.width - 2 // 2 for the border
 }
 
+func (s *sessionDialogCmp) previewWidth() int {
+	return s.width - 2 - s.listWidth()
+}
+
 func (s *sessionDialogCmp) Position() (int, int) {
 	row := s.wHeight/4 - 2 // just a bit above the center
 	col := s.wWidth / 2
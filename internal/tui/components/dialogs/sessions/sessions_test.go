@@ -0,0 +1,75 @@
+package sessions
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/require"
+)
+
+// runPreviewLoads runs cmd and hands the previews it loads to the dialog.
+func runPreviewLoads(t *testing.T, d SessionDialog, cmd tea.Cmd) {
+	t.Helper()
+	if cmd == nil {
+		return
+	}
+	switch msg := cmd().(type) {
+	case tea.BatchMsg:
+		for _, cmd := range msg {
+			runPreviewLoads(t, d, cmd)
+		}
+	case previewMsg:
+		require.NoError(t, msg.err)
+		d.Update(msg)
+	}
+}
+
+func TestSessionPreview(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+	sessions := session.NewService(q)
+	messages := message.NewService(q)
+
+	first, err := sessions.Create(t.Context(), "First session")
+	require.NoError(t, err)
+	second, err := sessions.Create(t.Context(), "Second session")
+	require.NoError(t, err)
+	_, err = messages.Create(t.Context(), first.ID, message.CreateMessageParams{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: "fix the parser"}},
+	})
+	require.NoError(t, err)
+	_, err = messages.Create(t.Context(), first.ID, message.CreateMessageParams{
+		Role:  message.Assistant,
+		Model: "some-model",
+		Parts: []message.ContentPart{message.TextContent{Text: "Fixed it"}},
+	})
+	require.NoError(t, err)
+
+	d := NewSessionDialogCmp([]session.Session{first, second}, first.ID, messages)
+	d.Init()
+	_, cmd := d.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	runPreviewLoads(t, d, cmd)
+
+	view := ansi.Strip(d.View())
+	require.Contains(t, view, "some-model · $0.00")
+	require.Contains(t, view, "You: fix the parser")
+	require.Contains(t, view, "Assistant: Fixed it")
+
+	_, cmd = d.Update(tea.KeyPressMsg{Code: tea.KeyDown})
+	runPreviewLoads(t, d, cmd)
+	view = ansi.Strip(d.View())
+	require.Contains(t, view, "No messages yet")
+	require.NotContains(t, view, "fix the parser")
+
+	d.Update(tea.WindowSizeMsg{Width: 80, Height: 40})
+	require.NotContains(t, ansi.Strip(d.View()), "No messages yet")
+}
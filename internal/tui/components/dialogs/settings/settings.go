@@ -0,0 +1,474 @@
+package settings
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/commands"
+	"github.com/charmbracelet/crush/internal/tui/exp/list"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const SettingsDialogID dialogs.DialogID = "settings"
+
+const (
+	settingYolo          = "yolo"
+	settingAutoSummarize = "auto_summarize"
+	settingDiffMode      = "diff_mode"
+	settingFileDrop      = "file_drop"
+	settingTrailerStyle  = "trailer_style"
+	settingGeneratedWith = "generated_with"
+
+	// Prefixes of the IDs of the model parameter and tool settings.
+	modelPrefix = "model."
+	toolPrefix  = "tool."
+)
+
+var trailerStyles = []config.TrailerStyle{
+	config.TrailerStyleAssistedBy,
+	config.TrailerStyleCoAuthoredBy,
+	config.TrailerStyleNone,
+}
+
+// SettingsDialog interface for the dialog changing common options
+type SettingsDialog interface {
+	dialogs.DialogModel
+}
+
+// SettingsChangedMsg is sent after a setting is changed and saved.
+type SettingsChangedMsg struct {
+	// ReloadAgent is set when the agent has to be rebuilt for the change to
+	// apply.
+	ReloadAgent bool
+}
+
+type SettingsList = list.List[list.CompletionItem[string]]
+
+type settingsDialogCmp struct {
+	wWidth  int
+	wHeight int
+	width   int
+	keyMap  KeyMap
+	list    SettingsList
+	input   textinput.Model
+	help    help.Model
+
+	yolo bool
+	// editing is the ID of the model parameter being edited, if any.
+	editing string
+}
+
+// NewSettingsDialogCmp creates a dialog toggling common options and editing
+// the parameters of the large model. Changes are saved to the global
+// configuration, except for yolo mode, which only lasts for the session.
+func NewSettingsDialogCmp(yolo bool) SettingsDialog {
+	t := styles.CurrentTheme()
+	keyMap := DefaultKeyMap()
+	listKeyMap := list.DefaultKeyMap()
+	listKeyMap.Down.SetEnabled(false)
+	listKeyMap.Up.SetEnabled(false)
+	listKeyMap.DownOneItem = keyMap.Next
+	listKeyMap.UpOneItem = keyMap.Previous
+
+	input := textinput.New()
+	input.SetVirtualCursor(false)
+	input.SetStyles(t.S().TextInput)
+
+	help := help.New()
+	help.Styles = t.S().Help
+	s := &settingsDialogCmp{
+		keyMap: keyMap,
+		input:  input,
+		help:   help,
+		yolo:   yolo,
+	}
+	s.list = list.New(
+		s.items(),
+		list.WithKeyMap(listKeyMap),
+		list.WithWrapNavigation(),
+	)
+	return s
+}
+
+func (s *settingsDialogCmp) Init() tea.Cmd {
+	return tea.Sequence(s.list.Init(), s.list.Focus())
+}
+
+func (s *settingsDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.wWidth = msg.Width
+		s.wHeight = msg.Height
+		s.width = min(70, s.wWidth-8)
+		s.input.SetWidth(s.listWidth() - 4)
+		return s, s.list.SetSize(s.listWidth(), s.listHeight())
+	case tea.KeyPressMsg:
+		if s.editing != "" {
+			switch {
+			case key.Matches(msg, s.keyMap.Save):
+				return s, s.saveModelParam()
+			case key.Matches(msg, s.keyMap.Cancel):
+				s.stopEditing()
+				return s, nil
+			default:
+				var cmd tea.Cmd
+				s.input, cmd = s.input.Update(msg)
+				return s, cmd
+			}
+		}
+		switch {
+		case key.Matches(msg, s.keyMap.Change):
+			selectedItem := s.list.SelectedItem()
+			if selectedItem == nil {
+				return s, nil
+			}
+			return s, s.change((*selectedItem).Value())
+		case key.Matches(msg, s.keyMap.Close):
+			return s, util.CmdHandler(dialogs.CloseDialogMsg{})
+		case key.Matches(msg, s.keyMap.Next):
+			return s, s.list.SelectItemBelow()
+		case key.Matches(msg, s.keyMap.Previous):
+			return s, s.list.SelectItemAbove()
+		}
+	}
+	return s, nil
+}
+
+// change toggles or cycles the value of a setting, or starts editing it for
+// the model parameters.
+func (s *settingsDialogCmp) change(id string) tea.Cmd {
+	cfg := config.Get()
+	var err error
+	reloadAgent := true
+	switch {
+	case id == settingYolo:
+		s.yolo = !s.yolo
+		return tea.Batch(s.refresh(id), util.CmdHandler(commands.ToggleYoloModeMsg{}))
+	case id == settingAutoSummarize:
+		err = cfg.SetDisableAutoSummarize(!cfg.Options.DisableAutoSummarize)
+	case id == settingDiffMode:
+		reloadAgent = false
+		mode := "unified"
+		if cfg.Options.TUI.DiffMode == "unified" {
+			mode = "split"
+		}
+		err = cfg.SetDiffMode(mode)
+	case id == settingFileDrop:
+		reloadAgent = false
+		mode := config.FileDropMention
+		if cfg.Options.TUI.FileDrop == config.FileDropMention {
+			mode = config.FileDropAttach
+		}
+		err = cfg.SetFileDrop(mode)
+	case id == settingTrailerStyle:
+		attribution := currentAttribution(cfg)
+		i := slices.Index(trailerStyles, attribution.TrailerStyle)
+		attribution.TrailerStyle = trailerStyles[(i+1)%len(trailerStyles)]
+		err = cfg.SetAttribution(attribution)
+	case id == settingGeneratedWith:
+		attribution := currentAttribution(cfg)
+		attribution.GeneratedWith = !attribution.GeneratedWith
+		err = cfg.SetAttribution(attribution)
+	case strings.HasPrefix(id, toolPrefix):
+		tool := strings.TrimPrefix(id, toolPrefix)
+		err = cfg.SetToolDisabled(tool, !slices.Contains(cfg.Options.DisabledTools, tool))
+	case strings.HasPrefix(id, modelPrefix):
+		param, ok := findModelParam(strings.TrimPrefix(id, modelPrefix))
+		if !ok {
+			return nil
+		}
+		s.editing = id
+		s.input.Prompt = param.title + ": "
+		s.input.Placeholder = "default"
+		s.input.SetValue(param.get(cfg.Models[config.SelectedModelTypeLarge]))
+		s.input.CursorEnd()
+		return s.input.Focus()
+	default:
+		return nil
+	}
+	if err != nil {
+		return util.ReportError(err)
+	}
+	return tea.Batch(s.refresh(id), util.CmdHandler(SettingsChangedMsg{ReloadAgent: reloadAgent}))
+}
+
+func (s *settingsDialogCmp) saveModelParam() tea.Cmd {
+	id := s.editing
+	param, ok := findModelParam(strings.TrimPrefix(id, modelPrefix))
+	if !ok {
+		s.stopEditing()
+		return nil
+	}
+	cfg := config.Get()
+	model := cfg.Models[config.SelectedModelTypeLarge]
+	if err := param.set(&model, strings.TrimSpace(s.input.Value())); err != nil {
+		return util.ReportError(fmt.Errorf("invalid %s: %w", strings.ToLower(param.title), err))
+	}
+	if err := cfg.SetModelParameters(config.SelectedModelTypeLarge, model); err != nil {
+		return util.ReportError(err)
+	}
+	s.stopEditing()
+	return tea.Batch(s.refresh(id), util.CmdHandler(SettingsChangedMsg{ReloadAgent: true}))
+}
+
+func (s *settingsDialogCmp) stopEditing() {
+	s.editing = ""
+	s.input.Blur()
+	s.input.SetValue("")
+}
+
+func (s *settingsDialogCmp) refresh(id string) tea.Cmd {
+	for _, item := range s.items() {
+		if item.ID() == id {
+			return s.list.UpdateItem(id, item)
+		}
+	}
+	return nil
+}
+
+func (s *settingsDialogCmp) items() []list.CompletionItem[string] {
+	cfg := config.Get()
+	attribution := currentAttribution(cfg)
+	diffMode := cfg.Options.TUI.DiffMode
+	if diffMode == "" {
+		diffMode = "auto"
+	}
+
+	var items []list.CompletionItem[string]
+	add := func(id, title, value string) {
+		items = append(items, list.NewCompletionItem(
+			title,
+			id,
+			list.WithCompletionID(id),
+			list.WithCompletionShortcut(value),
+		))
+	}
+	add(settingYolo, "Yolo Mode (this session)", onOff(s.yolo))
+	add(settingAutoSummarize, "Auto-Summarize", onOff(!cfg.Options.DisableAutoSummarize))
+	add(settingDiffMode, "Diff Mode", diffMode)
+	add(settingFileDrop, "Dropped Files", cmp.Or(cfg.Options.TUI.FileDrop, config.FileDropAttach))
+	add(settingTrailerStyle, "Attribution Trailer", string(attribution.TrailerStyle))
+	add(settingGeneratedWith, "Generated With Crush", onOff(attribution.GeneratedWith))
+	if model, ok := cfg.Models[config.SelectedModelTypeLarge]; ok {
+		for _, param := range modelParams {
+			value := param.get(model)
+			if value == "" {
+				value = "default"
+			}
+			add(modelPrefix+param.key, "Model: "+param.title, value)
+		}
+	}
+	for _, tool := range config.ToolNames() {
+		value := "enabled"
+		if slices.Contains(cfg.Options.DisabledTools, tool) {
+			value = "disabled"
+		}
+		add(toolPrefix+tool, "Tool: "+tool, value)
+	}
+	return items
+}
+
+func currentAttribution(cfg *config.Config) config.Attribution {
+	if cfg.Options.Attribution == nil {
+		return config.Attribution{TrailerStyle: config.TrailerStyleAssistedBy, GeneratedWith: true}
+	}
+	return *cfg.Options.Attribution
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+// modelParam is a parameter of the selected model that can be edited. An
+// empty value means the provider's default.
+type modelParam struct {
+	key   string
+	title string
+	get   func(config.SelectedModel) string
+	set   func(*config.SelectedModel, string) error
+}
+
+var modelParams = []modelParam{
+	{
+		key:   "max_tokens",
+		title: "Max Tokens",
+		get: func(m config.SelectedModel) string {
+			if m.MaxTokens == 0 {
+				return ""
+			}
+			return strconv.FormatInt(m.MaxTokens, 10)
+		},
+		set: func(m *config.SelectedModel, value string) error {
+			n, err := parseInt(value, 1, 200000)
+			if err != nil {
+				return err
+			}
+			m.MaxTokens = 0
+			if n != nil {
+				m.MaxTokens = *n
+			}
+			return nil
+		},
+	},
+	floatParam("temperature", "Temperature", func(m *config.SelectedModel) **float64 { return &m.Temperature }, 0, 1),
+	floatParam("top_p", "Top P", func(m *config.SelectedModel) **float64 { return &m.TopP }, 0, 1),
+	{
+		key:   "top_k",
+		title: "Top K",
+		get: func(m config.SelectedModel) string {
+			if m.TopK == nil {
+				return ""
+			}
+			return strconv.FormatInt(*m.TopK, 10)
+		},
+		set: func(m *config.SelectedModel, value string) error {
+			n, err := parseInt(value, 1, math.MaxInt64)
+			if err != nil {
+				return err
+			}
+			m.TopK = n
+			return nil
+		},
+	},
+	floatParam("frequency_penalty", "Frequency Penalty", func(m *config.SelectedModel) **float64 { return &m.FrequencyPenalty }, -2, 2),
+	floatParam("presence_penalty", "Presence Penalty", func(m *config.SelectedModel) **float64 { return &m.PresencePenalty }, -2, 2),
+}
+
+func floatParam(key, title string, field func(*config.SelectedModel) **float64, lo, hi float64) modelParam {
+	return modelParam{
+		key:   key,
+		title: title,
+		get: func(m config.SelectedModel) string {
+			v := *field(&m)
+			if v == nil {
+				return ""
+			}
+			return strconv.FormatFloat(*v, 'f', -1, 64)
+		},
+		set: func(m *config.SelectedModel, value string) error {
+			if value == "" {
+				*field(m) = nil
+				return nil
+			}
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("%q is not a number", value)
+			}
+			if v < lo || v > hi {
+				return fmt.Errorf("%s is not between %s and %s", value,
+					strconv.FormatFloat(lo, 'f', -1, 64), strconv.FormatFloat(hi, 'f', -1, 64))
+			}
+			*field(m) = &v
+			return nil
+		},
+	}
+}
+
+func parseInt(value string, lo, hi int64) (*int64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a whole number", value)
+	}
+	if n < lo {
+		return nil, fmt.Errorf("%d is less than %d", n, lo)
+	}
+	if n > hi {
+		return nil, fmt.Errorf("%d is more than %d", n, hi)
+	}
+	return &n, nil
+}
+
+func findModelParam(key string) (modelParam, bool) {
+	i := slices.IndexFunc(modelParams, func(p modelParam) bool {
+		return p.key == key
+	})
+	if i < 0 {
+		return modelParam{}, false
+	}
+	return modelParams[i], true
+}
+
+func (s *settingsDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	parts := []string{
+		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Settings", s.width-4)),
+	}
+	var helpView string
+	if s.editing != "" {
+		parts = append(parts,
+			t.S().Base.PaddingLeft(1).Render(s.input.View()),
+			t.S().Base.Padding(0, 1, 1, 1).Render(t.S().Subtle.Render("Leave empty to use the default")),
+		)
+		helpView = s.help.View(editKeyMap(s.keyMap))
+	} else {
+		helpView = s.help.View(s.keyMap)
+	}
+	parts = append(parts,
+		s.list.View(),
+		"",
+		t.S().Base.Width(s.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(helpView),
+	)
+	return s.style().Render(lipgloss.JoinVertical(lipgloss.Left, parts...))
+}
+
+func (s *settingsDialogCmp) Cursor() *tea.Cursor {
+	if s.editing == "" {
+		return nil
+	}
+	cursor := s.input.Cursor()
+	if cursor == nil {
+		return nil
+	}
+	row, col := s.Position()
+	cursor.Y += row + 3 // Border + title
+	cursor.X += col + 2
+	return cursor
+}
+
+func (s *settingsDialogCmp) style() lipgloss.Style {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(s.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus)
+}
+
+func (s *settingsDialogCmp) listHeight() int {
+	// Leave room for the border, title, value being edited and help.
+	return min(len(s.list.Items()), s.wHeight/2-8)
+}
+
+func (s *settingsDialogCmp) listWidth() int {
+	return s.width - 2 // 2 for the border
+}
+
+func (s *settingsDialogCmp) Position() (int, int) {
+	row := s.wHeight/4 - 2 // just a bit above the center
+	col := s.wWidth / 2
+	col -= s.width / 2
+	return row, col
+}
+
+// ID implements SettingsDialog.
+func (s *settingsDialogCmp) ID() dialogs.DialogID {
+	return SettingsDialogID
+}
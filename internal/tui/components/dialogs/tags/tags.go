@@ -0,0 +1,152 @@
+package tags
+
+import (
+	"context"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const (
+	TagsDialogID dialogs.DialogID = "tags"
+
+	defaultWidth int = 60
+)
+
+// TagsDialog edits the tags of a session.
+type TagsDialog interface {
+	dialogs.DialogModel
+}
+
+type tagsDialogCmp struct {
+	wWidth  int
+	wHeight int
+
+	sessions session.Service
+	session  session.Session
+	input    textinput.Model
+	save     key.Binding
+	close    key.Binding
+}
+
+// NewTagsDialogCmp creates a dialog to edit the tags of s, separated by
+// spaces or commas.
+func NewTagsDialogCmp(sessions session.Service, s session.Session) TagsDialog {
+	t := styles.CurrentTheme()
+	input := textinput.New()
+	input.Placeholder = "refactor bug"
+	input.Prompt = "# "
+	input.SetVirtualCursor(false)
+	input.SetStyles(t.S().TextInput)
+	input.SetWidth(defaultWidth - 6)
+	if len(s.Tags) > 0 {
+		input.SetValue(strings.Join(s.Tags, " ") + " ")
+	}
+	input.Focus()
+
+	return &tagsDialogCmp{
+		sessions: sessions,
+		session:  s,
+		input:    input,
+		save: key.NewBinding(
+			key.WithKeys("enter", "ctrl+y"),
+			key.WithHelp("enter", "save"),
+		),
+		close: key.NewBinding(
+			key.WithKeys("esc", "alt+esc"),
+			key.WithHelp("esc", "cancel"),
+		),
+	}
+}
+
+func (d *tagsDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (d *tagsDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		d.wWidth = msg.Width
+		d.wHeight = msg.Height
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, d.close):
+			return d, util.CmdHandler(dialogs.CloseDialogMsg{})
+		case key.Matches(msg, d.save):
+			return d, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				d.saveTags(session.ParseTags(d.input.Value())),
+			)
+		default:
+			var cmd tea.Cmd
+			d.input, cmd = d.input.Update(msg)
+			return d, cmd
+		}
+	case tea.PasteMsg:
+		var cmd tea.Cmd
+		d.input, cmd = d.input.Update(msg)
+		return d, cmd
+	}
+	return d, nil
+}
+
+func (d *tagsDialogCmp) saveTags(tags []string) tea.Cmd {
+	sessions, id := d.sessions, d.session.ID
+	return func() tea.Msg {
+		if _, err := sessions.SetTags(context.Background(), id, tags); err != nil {
+			return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+		}
+		if len(tags) == 0 {
+			return util.InfoMsg{Type: util.InfoTypeInfo, Msg: "Session tags cleared"}
+		}
+		return util.InfoMsg{Type: util.InfoTypeInfo, Msg: "Session tagged #" + strings.Join(tags, " #")}
+	}
+}
+
+func (d *tagsDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	title := d.session.Title
+	if title == "" {
+		title = "Untitled session"
+	}
+	header := t.S().Base.PaddingBottom(1).Render(core.Title("Tag Session", defaultWidth-4))
+	name := t.S().Muted.PaddingBottom(1).Width(defaultWidth - 4).Render(title)
+	help := t.S().Subtle.PaddingTop(1).Render("separate tags with spaces · enter save · esc cancel")
+	content := lipgloss.JoinVertical(lipgloss.Left, header, name, d.input.View(), help)
+	return t.S().Base.
+		Width(defaultWidth).
+		Padding(0, 1).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(content)
+}
+
+func (d *tagsDialogCmp) Cursor() *tea.Cursor {
+	cursor := d.input.Cursor()
+	if cursor == nil {
+		return nil
+	}
+	row, col := d.Position()
+	// Border, title and session name.
+	cursor.Y += row + 5
+	cursor.X += col + 2
+	return cursor
+}
+
+func (d *tagsDialogCmp) Position() (int, int) {
+	row := d.wHeight/2 - 5
+	col := d.wWidth/2 - defaultWidth/2
+	return row, col
+}
+
+func (d *tagsDialogCmp) ID() dialogs.DialogID {
+	return TagsDialogID
+}
@@ -0,0 +1,157 @@
+package trust
+
+import (
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/trust"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const (
+	question                       = "Do you trust the files in this folder?"
+	TrustDialogID dialogs.DialogID = "trust"
+	width                          = 60
+)
+
+// TrustChangedMsg is sent once the user marked the working directory, for
+// what runs the commands of the project to be set up again.
+type TrustChangedMsg struct {
+	Level trust.Level
+}
+
+// TrustDialog asks whether the working directory is trusted the first time
+// Crush runs in it.
+type TrustDialog interface {
+	dialogs.DialogModel
+}
+
+type trustDialogCmp struct {
+	wWidth  int
+	wHeight int
+
+	app        *app.App
+	selectedNo bool // true if "No" button is selected
+	keymap     KeyMap
+}
+
+// NewTrustDialog creates a new trust dialog for the working directory of
+// the app.
+func NewTrustDialog(app *app.App) TrustDialog {
+	return &trustDialogCmp{
+		app:    app,
+		keymap: DefaultKeymap(),
+	}
+}
+
+func (d *trustDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles keyboard input for the trust dialog.
+func (d *trustDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		d.wWidth = msg.Width
+		d.wHeight = msg.Height
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, d.keymap.LeftRight, d.keymap.Tab):
+			d.selectedNo = !d.selectedNo
+			return d, nil
+		case key.Matches(msg, d.keymap.EnterSpace):
+			if d.selectedNo {
+				return d, d.setTrust(trust.Untrusted)
+			}
+			return d, d.setTrust(trust.Trusted)
+		case key.Matches(msg, d.keymap.Yes):
+			return d, d.setTrust(trust.Trusted)
+		case key.Matches(msg, d.keymap.No):
+			return d, d.setTrust(trust.Untrusted)
+		case key.Matches(msg, d.keymap.Close):
+			return d, util.CmdHandler(dialogs.CloseDialogMsg{})
+		}
+	}
+	return d, nil
+}
+
+func (d *trustDialogCmp) setTrust(level trust.Level) tea.Cmd {
+	if err := d.app.SetTrust(level); err != nil {
+		return tea.Batch(util.CmdHandler(dialogs.CloseDialogMsg{}), util.ReportError(err))
+	}
+	info := "Folder trusted: read-only commands run without asking"
+	if level == trust.Untrusted {
+		info = "Folder not trusted: every tool call that needs permission will ask"
+	}
+	return tea.Batch(
+		util.CmdHandler(dialogs.CloseDialogMsg{}),
+		util.CmdHandler(TrustChangedMsg{Level: level}),
+		util.ReportInfo(info),
+	)
+}
+
+// View renders the trust dialog with Trust/Don't trust buttons.
+func (d *trustDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base
+	yesStyle := t.S().Text
+	noStyle := yesStyle
+
+	if d.selectedNo {
+		noStyle = noStyle.Foreground(t.White).Background(t.Secondary)
+		yesStyle = yesStyle.Background(t.BgSubtle)
+	} else {
+		yesStyle = yesStyle.Foreground(t.White).Background(t.Secondary)
+		noStyle = noStyle.Background(t.BgSubtle)
+	}
+
+	const horizontalPadding = 3
+	yesButton := yesStyle.PaddingLeft(horizontalPadding).Underline(true).Render("Y") +
+		yesStyle.PaddingRight(horizontalPadding).Render("es, trust")
+	noButton := noStyle.PaddingLeft(horizontalPadding).Underline(true).Render("N") +
+		noStyle.PaddingRight(horizontalPadding).Render("o, always ask")
+
+	buttons := baseStyle.Width(width).Align(lipgloss.Right).Render(
+		lipgloss.JoinHorizontal(lipgloss.Center, yesButton, "  ", noButton),
+	)
+
+	explanation := t.S().Muted.Width(width).Render(
+		"Crush runs read-only commands without asking in trusted folders. " +
+			"In untrusted ones it asks before every tool call that needs permission, " +
+			"ignoring the tools allowed by the configuration. " +
+			"Change it later with crush trust.",
+	)
+
+	content := baseStyle.Render(
+		lipgloss.JoinVertical(
+			lipgloss.Left,
+			t.S().Title.Render(question),
+			t.S().Subtle.Render(home.Short(d.app.Config().WorkingDir())),
+			"",
+			explanation,
+			"",
+			buttons,
+		),
+	)
+
+	trustDialogStyle := baseStyle.
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus)
+
+	return trustDialogStyle.Render(content)
+}
+
+func (d *trustDialogCmp) Position() (int, int) {
+	row := d.wHeight/2 - lipgloss.Height(d.View())/2
+	col := d.wWidth/2 - (width+6)/2
+	return row, col
+}
+
+func (d *trustDialogCmp) ID() dialogs.DialogID {
+	return TrustDialogID
+}
@@ -0,0 +1,143 @@
+package usage
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const (
+	UsageDialogID dialogs.DialogID = "usage"
+
+	defaultWidth int = 48
+)
+
+// UsageDialog shows what the step that produced an assistant message took.
+type UsageDialog interface {
+	dialogs.DialogModel
+}
+
+type usageDialogCmp struct {
+	wWidth  int
+	wHeight int
+
+	rows  [][2]string
+	close key.Binding
+}
+
+// NewUsageDialog creates a dialog with the tokens, cost, model, timing and
+// finish reason of msg.
+func NewUsageDialog(msg message.Message) UsageDialog {
+	modelName := msg.Model
+	if model := config.Get().GetModel(msg.Provider, msg.Model); model != nil {
+		modelName = cmp.Or(model.Name, msg.Model)
+	}
+	return &usageDialogCmp{
+		rows: usageRows(msg, modelName),
+		close: key.NewBinding(
+			key.WithKeys("esc", "alt+esc", "enter", "q"),
+			key.WithHelp("esc", "close"),
+		),
+	}
+}
+
+// usageRows lists the details of msg as label and value pairs.
+func usageRows(msg message.Message, modelName string) [][2]string {
+	rows := [][2]string{{"Model", modelName}}
+	if msg.Provider != "" {
+		rows = append(rows, [2]string{"Provider", msg.Provider})
+	}
+	if finish := msg.FinishPart(); finish != nil {
+		reason := string(finish.Reason)
+		if finish.Message != "" {
+			reason += ": " + finish.Message
+		}
+		rows = append(rows, [2]string{"Finish reason", reason})
+	}
+
+	usage := msg.Usage()
+	if usage == nil {
+		return append(rows, [2]string{"Usage", "not recorded"})
+	}
+	rows = append(rows,
+		[2]string{"Input tokens", fmt.Sprintf("%d", usage.InputTokens)},
+		[2]string{"Output tokens", fmt.Sprintf("%d", usage.OutputTokens)},
+	)
+	if usage.ReasoningTokens > 0 {
+		rows = append(rows, [2]string{"Reasoning tokens", fmt.Sprintf("%d", usage.ReasoningTokens)})
+	}
+	rows = append(rows,
+		[2]string{"Cache read tokens", fmt.Sprintf("%d", usage.CacheReadTokens)},
+		[2]string{"Cache write tokens", fmt.Sprintf("%d", usage.CacheCreationTokens)},
+		[2]string{"Cost", fmt.Sprintf("$%.4f", usage.Cost)},
+	)
+	if usage.FirstTokenMS > 0 {
+		rows = append(rows, [2]string{"First token", formatMS(usage.FirstTokenMS)})
+	}
+	return append(rows, [2]string{"Duration", formatMS(usage.DurationMS)})
+}
+
+func formatMS(ms int64) string {
+	return (time.Duration(ms) * time.Millisecond).Round(10 * time.Millisecond).String()
+}
+
+func (u *usageDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (u *usageDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		u.wWidth = msg.Width
+		u.wHeight = msg.Height
+	case tea.KeyPressMsg:
+		if key.Matches(msg, u.close) {
+			return u, util.CmdHandler(dialogs.CloseDialogMsg{})
+		}
+	}
+	return u, nil
+}
+
+func (u *usageDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	labelWidth := 0
+	for _, row := range u.rows {
+		labelWidth = max(labelWidth, lipgloss.Width(row[0]))
+	}
+	lines := make([]string, 0, len(u.rows))
+	for _, row := range u.rows {
+		label := t.S().Muted.Width(labelWidth + 2).Render(row[0])
+		lines = append(lines, label+t.S().Text.Render(row[1]))
+	}
+
+	header := t.S().Base.PaddingBottom(1).Render(core.Title("Message Usage", defaultWidth-4))
+	help := t.S().Subtle.PaddingTop(1).Render("esc close")
+	content := lipgloss.JoinVertical(lipgloss.Left, header, strings.Join(lines, "\n"), help)
+	return t.S().Base.
+		Width(defaultWidth).
+		Padding(0, 1).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(content)
+}
+
+func (u *usageDialogCmp) Position() (int, int) {
+	row := u.wHeight/2 - (len(u.rows)+6)/2
+	col := u.wWidth/2 - defaultWidth/2
+	return row, col
+}
+
+func (u *usageDialogCmp) ID() dialogs.DialogID {
+	return UsageDialogID
+}
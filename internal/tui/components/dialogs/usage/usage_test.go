@@ -0,0 +1,42 @@
+package usage
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageRows(t *testing.T) {
+	t.Parallel()
+
+	msg := message.Message{
+		Role:     message.Assistant,
+		Provider: "anthropic",
+		Model:    "claude-sonnet-4",
+		Parts:    []message.ContentPart{message.Finish{Reason: message.FinishReasonError, Message: "overloaded"}},
+	}
+	require.Equal(t, [][2]string{
+		{"Model", "Claude Sonnet 4"},
+		{"Provider", "anthropic"},
+		{"Finish reason", "error: overloaded"},
+		{"Usage", "not recorded"},
+	}, usageRows(msg, "Claude Sonnet 4"))
+
+	msg.Parts = []message.ContentPart{
+		message.Finish{Reason: message.FinishReasonToolUse},
+		message.Usage{InputTokens: 1200, OutputTokens: 80, CacheReadTokens: 9000, Cost: 0.0123, FirstTokenMS: 850, DurationMS: 4321},
+	}
+	require.Equal(t, [][2]string{
+		{"Model", "Claude Sonnet 4"},
+		{"Provider", "anthropic"},
+		{"Finish reason", "tool_use"},
+		{"Input tokens", "1200"},
+		{"Output tokens", "80"},
+		{"Cache read tokens", "9000"},
+		{"Cache write tokens", "0"},
+		{"Cost", "$0.0123"},
+		{"First token", "850ms"},
+		{"Duration", "4.32s"},
+	}, usageRows(msg, "Claude Sonnet 4"))
+}
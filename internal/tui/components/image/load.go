@@ -3,6 +3,7 @@
 package image
 
 import (
+	"bytes"
 	"context"
 	"image"
 	"image/png"
@@ -88,6 +89,16 @@ func imageToString(width, height uint, img image.Image) (string, error) {
 	return str.String(), nil
 }
 
+// Render draws the image in data with half blocks in at most width by height
+// cells.
+func Render(width, height uint, data []byte) (string, error) {
+	img, _, err := imageorient.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	return imageToString(width, height, img)
+}
+
 func readerToImage(width uint, height uint, url string, r io.Reader) (string, error) {
 	if strings.HasSuffix(strings.ToLower(url), ".svg") {
 		return svgToImage(width, height, r)
@@ -74,6 +74,24 @@ func RenderMCPList(opts RenderOptions) []string {
 				if count := state.Counts.Prompts; count > 0 {
 					extraContent = append(extraContent, t.S().Subtle.Render(fmt.Sprintf("%d prompts", count)))
 				}
+			case mcp.StateDegraded:
+				icon = t.ItemBusyIcon
+				verb := "reconnecting"
+				if l.MCP.Type == config.MCPStdio {
+					verb = "restarting"
+				}
+				description = t.S().Subtle.Render(verb + "...")
+				if state.Reconnects > 0 {
+					description = t.S().Subtle.Render(fmt.Sprintf("%s, attempt %d...", verb, state.Reconnects+1))
+				}
+				if count := state.Counts.Tools; count > 0 {
+					extraContent = append(extraContent, t.S().Subtle.Render(fmt.Sprintf("%d tools", count)))
+				}
+			case mcp.StateOffline:
+				description = t.S().Subtle.Render("offline")
+				if state.Error != nil {
+					description = t.S().Subtle.Render(fmt.Sprintf("offline: %s", state.Error.Error()))
+				}
 			case mcp.StateError:
 				icon = t.ItemErrorIcon
 				if state.Error != nil {
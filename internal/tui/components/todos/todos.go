@@ -0,0 +1,99 @@
+package todos
+
+import (
+	"fmt"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+)
+
+const (
+	pendingIcon    = "○"
+	inProgressIcon = "◐"
+)
+
+// RenderOptions contains options for rendering todo lists.
+type RenderOptions struct {
+	MaxWidth    int
+	MaxItems    int
+	ShowSection bool
+	SectionName string
+}
+
+// Done returns the number of completed todos.
+func Done(todos []session.Todo) int {
+	var done int
+	for _, todo := range todos {
+		if todo.Status == session.TodoStatusCompleted {
+			done++
+		}
+	}
+	return done
+}
+
+// RenderTodoList renders todos as a checklist with the given options.
+func RenderTodoList(todos []session.Todo, opts RenderOptions) []string {
+	t := styles.CurrentTheme()
+	todoList := []string{}
+
+	if opts.ShowSection {
+		sectionName := opts.SectionName
+		if sectionName == "" {
+			sectionName = "Todos"
+		}
+		todoList = append(todoList, t.S().Subtle.Render(sectionName), "")
+	}
+
+	maxItems := len(todos)
+	if opts.MaxItems > 0 {
+		maxItems = min(opts.MaxItems, len(todos))
+	}
+
+	// Start at the todo being worked on, so it stays visible in long lists.
+	start := 0
+	for i, todo := range todos {
+		if todo.Status != session.TodoStatusCompleted {
+			start = min(i, len(todos)-maxItems)
+			break
+		}
+	}
+
+	for _, todo := range todos[start : start+maxItems] {
+		var icon, content string
+		switch todo.Status {
+		case session.TodoStatusCompleted:
+			icon = t.S().Base.Foreground(t.Success).Render(styles.CheckIcon)
+			content = t.S().Subtle.Strikethrough(true).Render(ansi.Truncate(todo.Content, opts.MaxWidth-2, "…"))
+		case session.TodoStatusInProgress:
+			icon = t.S().Base.Foreground(t.Primary).Render(inProgressIcon)
+			content = t.S().Text.Render(ansi.Truncate(todo.Content, opts.MaxWidth-2, "…"))
+		default:
+			icon = t.S().Muted.Render(pendingIcon)
+			content = t.S().Muted.Render(ansi.Truncate(todo.Content, opts.MaxWidth-2, "…"))
+		}
+		todoList = append(todoList, icon+" "+content)
+	}
+	return todoList
+}
+
+// RenderTodoBlock renders a complete todo block with an indicator of the
+// todos left out.
+func RenderTodoBlock(todos []session.Todo, opts RenderOptions) string {
+	t := styles.CurrentTheme()
+	todoList := RenderTodoList(todos, opts)
+
+	if opts.MaxItems > 0 && len(todos) > opts.MaxItems {
+		todoList = append(todoList,
+			t.S().Base.Foreground(t.FgSubtle).Render(fmt.Sprintf("…and %d more", len(todos)-opts.MaxItems)),
+		)
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Left, todoList...)
+	if opts.MaxWidth > 0 {
+		return lipgloss.NewStyle().Width(opts.MaxWidth).Render(content)
+	}
+	return content
+}
@@ -38,6 +38,7 @@ type DiffView struct {
 	after           file
 	contextLines    int
 	lineNumbers     bool
+	lineOffset      int
 	height          int
 	width           int
 	xOffset         int
@@ -46,6 +47,7 @@ type DiffView struct {
 	style           Style
 	tabWidth        int
 	chromaStyle     *chroma.Style
+	chromaLexer     func(path, content string) chroma.Lexer
 
 	isComputed bool
 	err        error
@@ -123,6 +125,14 @@ func (dv *DiffView) ContextLines(contextLines int) *DiffView {
 	return dv
 }
 
+// LineOffset sets the number of lines before the ones the DiffView is given,
+// for showing the diff of a part of two files with their line numbers.
+func (dv *DiffView) LineOffset(lineOffset int) *DiffView {
+	dv.lineOffset = lineOffset
+	dv.isComputed = false
+	return dv
+}
+
 // Style sets the style for the DiffView.
 func (dv *DiffView) Style(style Style) *DiffView {
 	dv.style = style
@@ -181,6 +191,14 @@ func (dv *DiffView) ChromaStyle(style *chroma.Style) *DiffView {
 	return dv
 }
 
+// ChromaLexer sets the function that picks the lexer for syntax highlighting
+// from the path and content of the "before" file. If nil, chroma picks it.
+func (dv *DiffView) ChromaLexer(lexer func(path, content string) chroma.Lexer) *DiffView {
+	dv.chromaLexer = lexer
+	dv.clearCaches()
+	return dv
+}
+
 // clearSyntaxCache clears the syntax highlighting cache.
 func (dv *DiffView) clearSyntaxCache() {
 	if dv.syntaxCache != nil {
@@ -259,6 +277,10 @@ func (dv *DiffView) computeDiff() error {
 		dv.edits,
 		dv.contextLines,
 	)
+	for i := range dv.unified.Hunks {
+		dv.unified.Hunks[i].FromLine += dv.lineOffset
+		dv.unified.Hunks[i].ToLine += dv.lineOffset
+	}
 	return dv.err
 }
 
@@ -755,6 +777,10 @@ func (dv *DiffView) getChromaLexer() chroma.Lexer {
 	if dv.cachedLexer != nil {
 		return dv.cachedLexer
 	}
+	if dv.chromaLexer != nil {
+		dv.cachedLexer = dv.chromaLexer(dv.before.path, dv.before.content)
+		return dv.cachedLexer
+	}
 
 	l := lexers.Match(dv.before.path)
 	if l == nil {
@@ -183,6 +183,27 @@ func TestDiffViewTabs(t *testing.T) {
 	}
 }
 
+func TestDiffViewLineOffset(t *testing.T) {
+	t.Parallel()
+
+	for layoutName, layoutFunc := range LayoutFuncs {
+		t.Run(layoutName, func(t *testing.T) {
+			t.Parallel()
+
+			dv := diffview.New().
+				Before("main.go", TestDefaultBefore).
+				After("main.go", TestDefaultAfter).
+				LineOffset(120).
+				Style(diffview.DefaultLightStyle()).
+				ChromaStyle(styles.Get("catppuccin-latte"))
+			dv = layoutFunc(dv)
+
+			output := dv.String()
+			golden.RequireEqual(t, []byte(output))
+		})
+	}
+}
+
 func TestDiffViewLineBreakIssue(t *testing.T) {
 	t.Parallel()
 
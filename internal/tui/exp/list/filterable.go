@@ -36,6 +36,20 @@ type HasMatchIndexes interface {
 	MatchIndexes([]int)
 }
 
+// HasRank is implemented by items that should rank higher in filter results
+// than their match alone warrants, e.g. recently used files. The rank is
+// added to the fuzzy match score.
+type HasRank interface {
+	Rank() int
+}
+
+// HasFilterPrefix is implemented by items of a grouped list that should be
+// matched after something other than their group name, e.g. the provider of
+// an item in a "Favorites" group.
+type HasFilterPrefix interface {
+	FilterPrefix() string
+}
+
 type filterableOptions struct {
 	listOptions []ListOption
 	placeholder string
@@ -249,6 +263,7 @@ func (f *filterableList[T]) Filter(query string) tea.Cmd {
 	}
 
 	matches := fuzzy.FindFrom(query, f)
+	f.rankMatches(matches)
 
 	var matchedItems []T
 	resultSize := len(matches)
@@ -272,8 +287,30 @@ func (f *filterableList[T]) Filter(query string) tea.Cmd {
 	return tea.Batch(cmds...)
 }
 
+// rankMatches adds the rank of the matched items to their score and sorts
+// the matches again.
+func (f *filterableList[T]) rankMatches(matches fuzzy.Matches) {
+	ranked := false
+	for i, match := range matches {
+		if it, ok := any(f.items[match.Index]).(HasRank); ok && it.Rank() != 0 {
+			matches[i].Score += it.Rank()
+			ranked = true
+		}
+	}
+	if ranked {
+		slices.SortStableFunc(matches, func(a, b fuzzy.Match) int {
+			return b.Score - a.Score
+		})
+	}
+}
+
+// SetItems replaces the items, filtered by the current query if there's
+// one.
 func (f *filterableList[T]) SetItems(items []T) tea.Cmd {
 	f.items = items
+	if f.query != "" {
+		return f.Filter(f.query)
+	}
 	return f.list.SetItems(items)
 }
 
@@ -200,6 +200,15 @@ func (f *filterableGroupList[T]) getGroupName(g Group[T]) string {
 	return strings.ToLower(g.Section.ID())
 }
 
+// itemFilterPrefix returns what an item of group is matched after: its own
+// filter prefix, see [HasFilterPrefix], or the group name.
+func (f *filterableGroupList[T]) itemFilterPrefix(group Group[T], item T) string {
+	if it, ok := any(item).(HasFilterPrefix); ok && it.FilterPrefix() != "" {
+		return strings.ToLower(it.FilterPrefix()) + " "
+	}
+	return f.getGroupName(group) + " "
+}
+
 func (f *filterableGroupList[T]) setMatchIndexes(item T, indexes []int) {
 	if i, ok := any(item).(HasMatchIndexes); ok {
 		i.MatchIndexes(indexes)
@@ -217,11 +226,11 @@ func (f *filterableGroupList[T]) filterItemsInGroup(group Group[T], query string
 		return items
 	}
 
-	name := f.getGroupName(group) + " "
-
+	prefixes := make([]string, len(group.Items))
 	names := make([]string, len(group.Items))
 	for i, item := range group.Items {
-		names[i] = strings.ToLower(name + item.FilterValue())
+		prefixes[i] = f.itemFilterPrefix(group, item)
+		names[i] = strings.ToLower(prefixes[i] + item.FilterValue())
 	}
 
 	matches := fuzzy.Find(query, names)
@@ -233,13 +242,14 @@ func (f *filterableGroupList[T]) filterItemsInGroup(group Group[T], query string
 		var matchedItems []T
 		for _, match := range matches {
 			item := group.Items[match.Index]
+			prefix := prefixes[match.Index]
 			var idxs []int
 			for _, idx := range match.MatchedIndexes {
 				// adjusts removing group name highlights
-				if idx < len(name) {
+				if idx < len(prefix) {
 					continue
 				}
-				idxs = append(idxs, idx-len(name))
+				idxs = append(idxs, idx-len(prefix))
 			}
 			f.setMatchIndexes(item, idxs)
 			matchedItems = append(matchedItems, item)
@@ -279,8 +289,13 @@ func (f *filterableGroupList[T]) Filter(query string) tea.Cmd {
 	return tea.Batch(cmds...)
 }
 
+// SetGroups replaces the groups, filtered by the current query if there's
+// one.
 func (f *filterableGroupList[T]) SetGroups(groups []Group[T]) tea.Cmd {
 	f.groups = groups
+	if f.query != "" {
+		return f.Filter(f.query)
+	}
 	return f.groupedList.SetGroups(groups)
 }
 
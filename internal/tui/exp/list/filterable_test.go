@@ -66,3 +66,50 @@ func NewFilterableItem(content string) FilterableItem {
 func (f *filterableItem) FilterValue() string {
 	return f.content
 }
+
+func TestFilterRank(t *testing.T) {
+	t.Parallel()
+	items := []CompletionItem[string]{
+		NewCompletionItem("main.go", "main.go"),
+		NewCompletionItem("cmd/main.go", "cmd/main.go", WithCompletionRank(30)),
+	}
+	l := NewFilterableList(
+		items,
+		WithFilterListOptions(WithDirectionForward()),
+	).(*filterableList[CompletionItem[string]])
+
+	l.Filter("main")
+	var got []string
+	for _, item := range l.Items() {
+		got = append(got, item.Value())
+	}
+	assert.Equal(t, []string{"cmd/main.go", "main.go"}, got)
+}
+
+func TestGroupFilterPrefix(t *testing.T) {
+	t.Parallel()
+	groups := []Group[CompletionItem[string]]{
+		{
+			Section: NewItemSection("Favorites"),
+			Items: []CompletionItem[string]{
+				NewCompletionItem("gpt-4o", "favorite gpt-4o", WithCompletionFilterPrefix("OpenAI")),
+			},
+		},
+		{
+			Section: NewItemSection("OpenAI"),
+			Items: []CompletionItem[string]{
+				NewCompletionItem("gpt-4o", "gpt-4o"),
+				NewCompletionItem("o3", "o3"),
+			},
+		},
+	}
+	l := NewFilterableGroupedList(groups).(*filterableGroupList[CompletionItem[string]])
+
+	var got []string
+	for _, g := range groups {
+		for _, item := range l.filterItemsInGroup(g, "openaigpt") {
+			got = append(got, item.Value())
+		}
+	}
+	assert.Equal(t, []string{"favorite gpt-4o", "gpt-4o"}, got)
+}
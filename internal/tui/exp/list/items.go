@@ -23,6 +23,8 @@ type CompletionItem[T any] interface {
 	layout.Focusable
 	layout.Sizeable
 	HasMatchIndexes
+	HasRank
+	HasFilterPrefix
 	Value() T
 	Text() string
 }
@@ -36,6 +38,8 @@ type completionItemCmp[T any] struct {
 	matchIndexes []int
 	bgColor      color.Color
 	shortcut     string
+	rank         int
+	filterPrefix string
 }
 
 type options struct {
@@ -44,6 +48,8 @@ type options struct {
 	bgColor      color.Color
 	matchIndexes []int
 	shortcut     string
+	rank         int
+	filterPrefix string
 }
 
 type CompletionItemOption func(*options)
@@ -66,6 +72,21 @@ func WithCompletionShortcut(shortcut string) CompletionItemOption {
 	}
 }
 
+// WithCompletionRank ranks the item higher in filter results, see [HasRank].
+func WithCompletionRank(rank int) CompletionItemOption {
+	return func(cmp *options) {
+		cmp.rank = rank
+	}
+}
+
+// WithCompletionFilterPrefix matches the item in a grouped list after prefix
+// instead of its group name, see [HasFilterPrefix].
+func WithCompletionFilterPrefix(prefix string) CompletionItemOption {
+	return func(cmp *options) {
+		cmp.filterPrefix = prefix
+	}
+}
+
 func WithCompletionID(id string) CompletionItemOption {
 	return func(cmp *options) {
 		cmp.id = id
@@ -89,6 +110,8 @@ func NewCompletionItem[T any](text string, value T, opts ...CompletionItemOption
 	c.bgColor = o.bgColor
 	c.matchIndexes = o.matchIndexes
 	c.shortcut = o.shortcut
+	c.rank = o.rank
+	c.filterPrefix = o.filterPrefix
 	return c
 }
 
@@ -199,6 +222,14 @@ func (c *completionItemCmp[T]) MatchIndexes(indexes []int) {
 	c.matchIndexes = indexes
 }
 
+func (c *completionItemCmp[T]) Rank() int {
+	return c.rank
+}
+
+func (c *completionItemCmp[T]) FilterPrefix() string {
+	return c.filterPrefix
+}
+
 func (c *completionItemCmp[T]) FilterValue() string {
 	return c.text
 }
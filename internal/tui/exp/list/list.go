@@ -1,6 +1,7 @@
 package list
 
 import (
+	"slices"
 	"strings"
 	"sync"
 
@@ -74,6 +75,11 @@ type List[T Item] interface {
 	SelectParagraph(col, line int)
 	GetSelectedText(paddingLeft int) string
 	HasSelection() bool
+	// PinItem keeps the item with the given ID in view: scrolled out above
+	// the viewport, it's shown at its top, and below it, at its bottom.
+	PinItem(id string)
+	// UnpinItem lets the item with the given ID scroll out of view again.
+	UnpinItem(id string)
 }
 
 type direction int
@@ -133,6 +139,9 @@ type list[T Item] struct {
 	selectionEndLine    int
 
 	selectionActive bool
+
+	// pinned holds the IDs of the pinned items, see [List.PinItem].
+	pinned []string
 }
 
 type ListOption func(*confOptions)
@@ -514,6 +523,11 @@ func (l *list[T]) View() string {
 	}
 
 	view := l.getLines(viewStart, viewEnd)
+	if !l.hasSelection() {
+		// Selections are made on the content itself, so pinned items aren't
+		// shown over it meanwhile.
+		view = l.withPinnedItems(view, viewStart, viewEnd)
+	}
 
 	if l.resize {
 		return view
@@ -534,6 +548,73 @@ func (l *list[T]) View() string {
 	return l.selectionView(view, false)
 }
 
+// withPinnedItems shows the pinned items that are out of the view, made of
+// the rendered lines from start to end, over its top and bottom lines. At
+// least one line of the view is left.
+func (l *list[T]) withPinnedItems(view string, start, end int) string {
+	if len(l.pinned) == 0 {
+		return view
+	}
+	var above, below []string
+	for _, id := range l.pinnedInOrder() {
+		rItem, ok := l.renderedItems[id]
+		if !ok {
+			continue
+		}
+		switch {
+		case rItem.end < start:
+			above = append(above, strings.Split(rItem.view, "\n")...)
+		case rItem.start > end:
+			below = append(below, strings.Split(rItem.view, "\n")...)
+		}
+	}
+	if len(above) == 0 && len(below) == 0 {
+		return view
+	}
+
+	lines := strings.Split(view, "\n")
+	room := len(lines) - 1
+	// The closest lines of the items above are kept when they don't fit.
+	above = above[len(above)-min(len(above), room):]
+	room -= len(above)
+	below = below[:min(len(below), room)]
+	copy(lines, above)
+	copy(lines[len(lines)-len(below):], below)
+	return strings.Join(lines, "\n")
+}
+
+// pinnedInOrder returns the IDs of the pinned items that are in the list,
+// in the order of the list.
+func (l *list[T]) pinnedInOrder() []string {
+	ids := make([]string, 0, len(l.pinned))
+	for _, id := range l.pinned {
+		if _, ok := l.indexMap[id]; ok {
+			ids = append(ids, id)
+		}
+	}
+	slices.SortFunc(ids, func(a, b string) int {
+		return l.indexMap[a] - l.indexMap[b]
+	})
+	return ids
+}
+
+// PinItem implements List.
+func (l *list[T]) PinItem(id string) {
+	if slices.Contains(l.pinned, id) {
+		return
+	}
+	l.pinned = append(l.pinned, id)
+	l.cachedViewDirty = true
+}
+
+// UnpinItem implements List.
+func (l *list[T]) UnpinItem(id string) {
+	if i := slices.Index(l.pinned, id); i >= 0 {
+		l.pinned = slices.Delete(l.pinned, i, i+1)
+		l.cachedViewDirty = true
+	}
+}
+
 func (l *list[T]) viewPosition() (int, int) {
 	start, end := 0, 0
 	renderedLines := l.renderedHeight - 1
@@ -1120,6 +1201,7 @@ func (l *list[T]) DeleteItem(id string) tea.Cmd {
 	l.items = append(l.items[:inx], l.items[inx+1:]...)
 	delete(l.renderedItems, id)
 	delete(l.indexMap, id)
+	l.UnpinItem(id)
 
 	// Only update indices for items after the deleted one
 	itemsLen := len(l.items)
@@ -9,6 +9,7 @@ import (
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/crush/internal/tui/components/core/layout"
 	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/charmbracelet/x/exp/golden"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -580,6 +581,46 @@ type selectableItem struct {
 	focused bool
 }
 
+func TestListPinning(t *testing.T) {
+	t.Parallel()
+	for _, direction := range []ListOption{WithDirectionForward(), WithDirectionBackward()} {
+		items := []Item{}
+		for i := range 30 {
+			items = append(items, NewSimpleItem(fmt.Sprintf("Item %d", i)))
+		}
+		l := New(items, direction, WithSize(10, 10)).(*list[Item])
+		execCmd(l, l.Init())
+		execCmd(l, l.GoToTop())
+		lines := func() []string {
+			return strings.Split(ansi.Strip(l.View()), "\n")
+		}
+		require.Equal(t, "Item 0", strings.TrimSpace(lines()[0]))
+		require.Equal(t, "Item 9", strings.TrimSpace(lines()[9]))
+
+		// Pinned items below the view show at its bottom.
+		l.PinItem(items[25].ID())
+		l.PinItem(items[20].ID())
+		require.Equal(t, "Item 0", strings.TrimSpace(lines()[0]))
+		require.Equal(t, "Item 7", strings.TrimSpace(lines()[7]))
+		require.Equal(t, "Item 20", strings.TrimSpace(lines()[8]))
+		require.Equal(t, "Item 25", strings.TrimSpace(lines()[9]))
+
+		// Those above it at its top, and those in it where they are.
+		execCmd(l, l.GoToBottom())
+		l.UnpinItem(items[25].ID())
+		require.Equal(t, "Item 20", strings.TrimSpace(lines()[0]))
+		l.PinItem(items[3].ID())
+		require.Equal(t, "Item 3", strings.TrimSpace(lines()[0]))
+		require.Equal(t, "Item 21", strings.TrimSpace(lines()[1]))
+		require.Equal(t, "Item 29", strings.TrimSpace(lines()[9]))
+
+		// Deleted items are unpinned.
+		pinned := items[20].ID()
+		execCmd(l, l.DeleteItem(items[3].ID()))
+		require.Equal(t, []string{pinned}, l.pinned)
+	}
+}
+
 func NewSimpleItem(content string) *simpleItem {
 	return &simpleItem{
 		id:      uuid.NewString(),
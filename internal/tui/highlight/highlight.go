@@ -3,24 +3,51 @@ package highlight
 import (
 	"bytes"
 	"image/color"
+	"path/filepath"
+	"strings"
 
 	"github.com/alecthomas/chroma/v2"
 	"github.com/alecthomas/chroma/v2/formatters"
 	"github.com/alecthomas/chroma/v2/lexers"
 	chromaStyles "github.com/alecthomas/chroma/v2/styles"
+	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 )
 
-func SyntaxHighlight(source, fileName string, bg color.Color) (string, error) {
-	// Determine the language lexer to use
-	l := lexers.Match(fileName)
+// Lexer returns the lexer to highlight source from the named file with. The
+// languages set in the syntax options take precedence over the ones chroma
+// picks, and source over the size limit gets the plain text lexer.
+func Lexer(fileName, source string) chroma.Lexer {
+	var opts config.Syntax
+	if cfg := config.Get(); cfg != nil && cfg.Options != nil && cfg.Options.TUI != nil {
+		opts = cfg.Options.TUI.Syntax
+	}
+	if limit := opts.MaxSizeBytes(); limit > 0 && len(source) > limit {
+		return chroma.Coalesce(lexers.Fallback)
+	}
+
+	var l chroma.Lexer
+	base := filepath.Base(fileName)
+	for _, key := range []string{base, strings.ToLower(filepath.Ext(base))} {
+		if lang, ok := opts.Languages[key]; ok && key != "" {
+			l = lexers.Get(lang)
+			break
+		}
+	}
+	if l == nil {
+		l = lexers.Match(fileName)
+	}
 	if l == nil {
 		l = lexers.Analyse(source)
 	}
 	if l == nil {
 		l = lexers.Fallback
 	}
-	l = chroma.Coalesce(l)
+	return chroma.Coalesce(l)
+}
+
+func SyntaxHighlight(source, fileName string, bg color.Color) (string, error) {
+	l := Lexer(fileName, source)
 
 	// Get the formatter
 	f := formatters.Get("terminal16m")
@@ -28,7 +55,7 @@ func SyntaxHighlight(source, fileName string, bg color.Color) (string, error) {
 		f = formatters.Fallback
 	}
 
-	style := chroma.MustNewStyle("crush", styles.GetChromaTheme())
+	style := styles.GetChromaStyle()
 
 	// Modify the style to use the provided background
 	s, err := style.Builder().Transform(
@@ -0,0 +1,44 @@
+package highlight
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLexer(t *testing.T) {
+	cfgDir := t.TempDir()
+	dataDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", cfgDir)
+	t.Setenv("XDG_DATA_HOME", dataDir)
+
+	confPath := filepath.Join(cfgDir, "crush", "crush.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(confPath), 0o755))
+	require.NoError(t, os.WriteFile(confPath, []byte(`{
+		"options": {
+			"disable_provider_auto_update": true,
+			"tui": {
+				"syntax": {
+					"languages": {".tpl": "html", "Jenkinsfile": "groovy"},
+					"max_size": 1024
+				}
+			}
+		}
+	}`), 0o644))
+	_, err := config.Init(t.TempDir(), dataDir, false)
+	require.NoError(t, err)
+
+	name := func(fileName, source string) string {
+		return Lexer(fileName, source).Config().Name
+	}
+	require.Equal(t, "HTML", name("page.tpl", "<p>hi</p>"))
+	require.Equal(t, "HTML", name("PAGE.TPL", "<p>hi</p>"))
+	require.Equal(t, "Groovy", name("ci/Jenkinsfile", "pipeline {}"))
+	require.Equal(t, "Go", name("main.go", "package main"))
+	require.Equal(t, lexers.Fallback.Config().Name, name("main.go", "package main\n"+strings.Repeat("//\n", 1024)))
+}
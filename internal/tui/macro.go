@@ -0,0 +1,124 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/tui/components/chat"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/commands"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const (
+	// macroPollInterval is how often a macro checks whether the agent is done
+	// with the prompt it sent.
+	macroPollInterval = 200 * time.Millisecond
+	// macroStartTimeout is how long a macro waits for the agent to start
+	// working on the prompt it sent.
+	macroStartTimeout = 2 * time.Second
+)
+
+// macroStepMsg runs the remaining steps of a macro.
+type macroStepMsg struct {
+	name  string
+	steps []commands.MacroStep
+}
+
+// runMacro runs the first of the steps of a macro, and the others once it is
+// done.
+func (a *appModel) runMacro(name string, steps []commands.MacroStep) tea.Cmd {
+	if len(steps) == 0 {
+		return nil
+	}
+	step, rest := steps[0], steps[1:]
+	next := func() tea.Msg {
+		if len(rest) == 0 {
+			return nil
+		}
+		return macroStepMsg{name: name, steps: rest}
+	}
+	fail := func(err error) tea.Cmd {
+		return util.ReportError(fmt.Errorf("macro %q: %w", name, err))
+	}
+
+	switch step.Command {
+	case commands.MacroNew:
+		if a.app.AgentCoordinator.IsBusy() {
+			return fail(fmt.Errorf("agent is busy"))
+		}
+		return tea.Sequence(util.CmdHandler(commands.NewSessionsMsg{}), next)
+	case commands.MacroCompact:
+		sessionID := a.selectedSessionID
+		if sessionID == "" {
+			return fail(fmt.Errorf("no session to compact"))
+		}
+		return func() tea.Msg {
+			if err := a.app.AgentCoordinator.Summarize(context.Background(), sessionID); err != nil {
+				return fail(err)()
+			}
+			return next()
+		}
+	case commands.MacroModel, commands.MacroSmallModel:
+		modelType := config.SelectedModelTypeLarge
+		if step.Command == commands.MacroSmallModel {
+			modelType = config.SelectedModelTypeSmall
+		}
+		return func() tea.Msg {
+			if err := a.switchModel(modelType, step.Arg); err != nil {
+				return fail(err)()
+			}
+			return next()
+		}
+	case commands.MacroSend:
+		send := util.CmdHandler(chat.SendMsg{Text: step.Arg})
+		if len(rest) == 0 {
+			return send
+		}
+		return tea.Sequence(send, a.waitForAgent(next))
+	case commands.MacroCommand:
+		return tea.Sequence(step.Cmd, next)
+	}
+	return fail(fmt.Errorf("unknown command %q", step.Command))
+}
+
+// switchModel makes the model with the given provider/model spec the
+// preferred one of modelType, and waits for the agent to use it.
+func (a *appModel) switchModel(modelType config.SelectedModelType, spec string) error {
+	if a.app.AgentCoordinator.IsBusy() {
+		return fmt.Errorf("agent is busy")
+	}
+	cfg := config.Get()
+	providerID, modelID, _ := strings.Cut(spec, "/")
+	model := cfg.GetModel(providerID, modelID)
+	if model == nil {
+		return fmt.Errorf("model %q not found", spec)
+	}
+	if err := cfg.UpdatePreferredModel(modelType, config.SelectedModel{
+		Model:           modelID,
+		Provider:        providerID,
+		ReasoningEffort: model.DefaultReasoningEffort,
+		MaxTokens:       model.DefaultMaxTokens,
+	}); err != nil {
+		return err
+	}
+	return a.app.UpdateAgentModel(context.Background())
+}
+
+// waitForAgent runs next once the agent is done with the prompt that was just
+// sent.
+func (a *appModel) waitForAgent(next tea.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		deadline := time.Now().Add(macroStartTimeout)
+		for !a.app.AgentCoordinator.IsBusy() && time.Now().Before(deadline) {
+			time.Sleep(macroPollInterval)
+		}
+		for a.app.AgentCoordinator.IsBusy() {
+			time.Sleep(macroPollInterval)
+		}
+		return next()
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"charm.land/bubbles/v2/help"
@@ -11,6 +12,8 @@ import (
 	"charm.land/bubbles/v2/spinner"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/agent/tools"
 	"github.com/charmbracelet/crush/internal/app"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/history"
@@ -21,6 +24,8 @@ import (
 	"github.com/charmbracelet/crush/internal/tui/components/anim"
 	"github.com/charmbracelet/crush/internal/tui/components/chat"
 	"github.com/charmbracelet/crush/internal/tui/components/chat/editor"
+	"github.com/charmbracelet/crush/internal/tui/components/chat/filetree"
+	"github.com/charmbracelet/crush/internal/tui/components/chat/fileview"
 	"github.com/charmbracelet/crush/internal/tui/components/chat/header"
 	"github.com/charmbracelet/crush/internal/tui/components/chat/messages"
 	"github.com/charmbracelet/crush/internal/tui/components/chat/sidebar"
@@ -32,8 +37,11 @@ import (
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/claude"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/commands"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/filepicker"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/grepmatches"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/models"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/reasoning"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/resend"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/usage"
 	"github.com/charmbracelet/crush/internal/tui/page"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/tui/util"
@@ -55,15 +63,16 @@ const (
 	PanelTypeChat   PanelType = "chat"
 	PanelTypeEditor PanelType = "editor"
 	PanelTypeSplash PanelType = "splash"
+	PanelTypeFiles  PanelType = "files"
 )
 
 const (
-	CompactModeWidthBreakpoint  = 120 // Width at which the chat page switches to compact mode
-	CompactModeHeightBreakpoint = 30  // Height at which the chat page switches to compact mode
-	EditorHeight                = 5   // Height of the editor input area including padding
-	SideBarWidth                = 31  // Width of the sidebar
-	SideBarDetailsPadding       = 1   // Padding for the sidebar details section
-	HeaderHeight                = 1   // Height of the header
+	EditorHeight          = 5  // Height of the editor input area including padding
+	SideBarWidth          = 31 // Width of the sidebar
+	FileViewMinWidth      = 40 // Minimum width of the chat and of the file view next to it
+	FileTreeWidth         = 30 // Width of the file tree
+	SideBarDetailsPadding = 1  // Padding for the sidebar details section
+	HeaderHeight          = 1  // Height of the header
 
 	// Layout constants for borders and padding
 	BorderWidth        = 1 // Width of component borders
@@ -104,14 +113,21 @@ type chatPage struct {
 	keyMap  KeyMap
 
 	// Components
-	header  header.Header
-	sidebar sidebar.Sidebar
-	chat    chat.MessageListCmp
-	editor  editor.Editor
-	splash  splash.Splash
+	header   header.Header
+	sidebar  sidebar.Sidebar
+	chat     chat.MessageListCmp
+	editor   editor.Editor
+	splash   splash.Splash
+	fileView fileview.FileView
+	fileTree filetree.FileTree
+
+	// reasoning is the reasoning level set before the session is created.
+	reasoning agent.ReasoningLevel
 
 	// Simple state flags
 	showingDetails   bool
+	showFileView     bool
+	showFileTree     bool
 	isCanceling      bool
 	splashFullScreen bool
 	isOnboarding     bool
@@ -127,16 +143,21 @@ func New(app *app.App) ChatPage {
 		chat:        chat.New(app),
 		editor:      editor.New(app),
 		splash:      splash.New(),
+		fileView:    fileview.New(app.History),
+		fileTree:    filetree.New(app.History, app.Config().WorkingDir()),
 		focusedPane: PanelTypeSplash,
 	}
 }
 
 func (p *chatPage) Init() tea.Cmd {
 	cfg := config.Get()
-	compact := cfg.Options.TUI.CompactMode
+	// The sidebar is left out for screen readers.
+	compact := cfg.Options.TUI.CompactMode || cfg.Options.TUI.Accessible
 	p.compact = compact
 	p.forceCompact = compact
 	p.sidebar.SetCompactMode(p.compact)
+	p.showFileView = cfg.Options.TUI.FileView && !cfg.Options.TUI.Accessible
+	p.showFileTree = cfg.Options.TUI.FileTree && !cfg.Options.TUI.Accessible
 
 	// Set splash state based on config
 	if !config.HasInitialDataConfig() {
@@ -161,6 +182,8 @@ func (p *chatPage) Init() tea.Cmd {
 		p.chat.Init(),
 		p.editor.Init(),
 		p.splash.Init(),
+		p.fileView.Init(),
+		p.fileTree.Init(),
 	)
 }
 
@@ -179,6 +202,16 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			p.chat = u.(chat.MessageListCmp)
 			return p, cmd
 		}
+		if p.isMouseOverFileView(msg.X, msg.Y) {
+			u, cmd := p.fileView.Update(msg)
+			p.fileView = u.(fileview.FileView)
+			return p, cmd
+		}
+		if p.isMouseOverFileTree(msg.X, msg.Y) {
+			u, cmd := p.fileTree.Update(msg)
+			p.fileTree = u.(filetree.FileTree)
+			return p, cmd
+		}
 		return p, nil
 	case tea.MouseClickMsg:
 		if p.isOnboarding {
@@ -187,14 +220,18 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		if p.compact {
 			msg.Y -= 1
 		}
+		if p.isMouseOverFileTree(msg.X, msg.Y) {
+			p.focus(PanelTypeFiles)
+			msg.X -= p.mainWidth()
+			msg.Y -= p.panelsY()
+			u, cmd := p.fileTree.Update(msg)
+			p.fileTree = u.(filetree.FileTree)
+			return p, cmd
+		}
 		if p.isMouseOverChat(msg.X, msg.Y) {
-			p.focusedPane = PanelTypeChat
-			p.chat.Focus()
-			p.editor.Blur()
+			p.focus(PanelTypeChat)
 		} else {
-			p.focusedPane = PanelTypeEditor
-			p.editor.Focus()
-			p.chat.Blur()
+			p.focus(PanelTypeEditor)
 		}
 		u, cmd := p.chat.Update(msg)
 		p.chat = u.(chat.MessageListCmp)
@@ -239,6 +276,8 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		return p, cmd
 	case chat.SendMsg:
 		return p, p.sendMessage(msg.Text, msg.Attachments)
+	case chat.RunShellMsg:
+		return p, p.runShellCommand(msg.Command)
 	case chat.SessionSelectedMsg:
 		return p, p.setSession(msg)
 	case splash.SubmitAPIKeyMsg:
@@ -252,18 +291,36 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		if p.forceCompact {
 			p.setCompactMode(true)
 			cmd = p.updateCompactConfig(true)
-		} else if p.width >= CompactModeWidthBreakpoint && p.height >= CompactModeHeightBreakpoint {
+		} else if !compactFor(false, p.width, p.height, config.Get().Options.TUI.CompactLayout) {
 			p.setCompactMode(false)
 			cmd = p.updateCompactConfig(false)
 		}
 		return p, tea.Batch(p.SetSize(p.width, p.height), cmd)
+	case commands.ToggleFileViewMsg:
+		p.showFileView = !p.showFileView
+		return p, tea.Batch(p.SetSize(p.width, p.height), p.updateFileViewConfig(p.showFileView))
+	case commands.PinFileMsg:
+		return p, p.pinFile(msg.Path)
+	case commands.ToggleFileTreeMsg:
+		p.showFileTree = !p.showFileTree
+		cmd := p.SetSize(p.width, p.height)
+		if p.fileTreeVisible() {
+			p.focus(PanelTypeFiles)
+		}
+		return p, tea.Batch(cmd, p.updateFileTreeConfig(p.showFileTree))
+	case filetree.OpenFileMsg:
+		return p, p.pinFile(msg.Path)
+	case filetree.AttachFileMsg:
+		return p, util.CmdHandler(editor.AttachFilesMsg{Paths: []string{msg.Path}})
 	case commands.ToggleThinkingMsg:
 		return p, p.toggleThinking()
 	case commands.OpenReasoningDialogMsg:
 		return p, p.openReasoningDialog()
+	case commands.CycleReasoningMsg:
+		return p, p.cycleReasoning()
 	case reasoning.ReasoningEffortSelectedMsg:
 		return p, p.handleReasoningEffortSelected(msg.Effort)
-	case commands.OpenExternalEditorMsg:
+	case commands.OpenExternalEditorMsg, commands.DiscardDraftMsg, editor.SaveDraftMsg:
 		u, cmd := p.editor.Update(msg)
 		p.editor = u.(editor.Editor)
 		return p, cmd
@@ -287,8 +344,12 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		cmds = append(cmds, cmd)
 		return p, tea.Batch(cmds...)
 	case filepicker.FilePickedMsg,
+		editor.InsertTextMsg,
 		completions.CompletionsClosedMsg,
 		completions.SelectCompletionMsg:
+		if _, ok := msg.(editor.InsertTextMsg); ok && p.focusedPane == PanelTypeChat {
+			p.changeFocus()
+		}
 		u, cmd := p.editor.Update(msg)
 		p.editor = u.(editor.Editor)
 		cmds = append(cmds, cmd)
@@ -309,6 +370,9 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		}
 		return p, tea.Batch(cmds...)
 	case pubsub.Event[message.Message],
+		pubsub.Event[agent.SubAgentProgress],
+		pubsub.Event[tools.DownloadProgress],
+		pubsub.Event[agent.ToolProgress],
 		anim.StepMsg,
 		spinner.TickMsg:
 		if p.focusedPane == PanelTypeSplash {
@@ -322,12 +386,58 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		}
 
 		return p, tea.Batch(cmds...)
+	case chat.ToolTickMsg:
+		// Ticks go to the messages even while the splash shows, or they'd
+		// stop for good.
+		u, cmd := p.chat.Update(msg)
+		p.chat = u.(chat.MessageListCmp)
+		return p, cmd
+	case messages.CancelSubAgentMsg:
+		if p.app.AgentCoordinator != nil {
+			p.app.AgentCoordinator.CancelSubAgent(msg.ToolCallID)
+		}
+		return p, util.ReportInfo("Sub-agent cancelled")
+	case messages.SkipToolMsg:
+		if p.app.AgentCoordinator == nil || !p.app.AgentCoordinator.SkipToolCall(msg.ToolCallID) {
+			return p, nil
+		}
+		return p, util.ReportInfo("Tool call skipped")
+	case messages.ExtendToolMsg:
+		if p.app.AgentCoordinator == nil || !p.app.AgentCoordinator.ExtendToolCall(msg.ToolCallID) {
+			return p, nil
+		}
+		return p, util.ReportInfo("Tool call extended")
+	case messages.OpenMatchesMsg:
+		return p, util.CmdHandler(dialogs.OpenDialogMsg{
+			Model: grepmatches.NewMatchesDialogCmp(msg.Matches),
+		})
+	case messages.OpenUsageMsg:
+		stepMsg, err := p.app.Messages.Get(context.Background(), msg.MessageID)
+		if err != nil {
+			return p, util.ReportError(err)
+		}
+		return p, util.CmdHandler(dialogs.OpenDialogMsg{
+			Model: usage.NewUsageDialog(stepMsg),
+		})
+	case messages.EditMessageMsg:
+		return p, p.editMessage(msg.MessageID)
 	case commands.ToggleYoloModeMsg:
 		// update the editor style
 		u, cmd := p.editor.Update(msg)
 		p.editor = u.(editor.Editor)
 		return p, cmd
-	case pubsub.Event[history.File], sidebar.SessionFilesMsg:
+	case pubsub.Event[history.File]:
+		u, cmd := p.sidebar.Update(msg)
+		p.sidebar = u.(sidebar.Sidebar)
+		cmds = append(cmds, cmd)
+		u, cmd = p.fileView.Update(msg)
+		p.fileView = u.(fileview.FileView)
+		cmds = append(cmds, cmd)
+		u, cmd = p.fileTree.Update(msg)
+		p.fileTree = u.(filetree.FileTree)
+		cmds = append(cmds, cmd)
+		return p, tea.Batch(cmds...)
+	case sidebar.SessionFilesMsg:
 		u, cmd := p.sidebar.Update(msg)
 		p.sidebar = u.(sidebar.Sidebar)
 		cmds = append(cmds, cmd)
@@ -394,6 +504,11 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			}
 			p.changeFocus()
 			return p, nil
+		case key.Matches(msg, messages.CancelSummaryKey) && p.summarizing():
+			// Unlike a run, a summary is canceled at once, as nothing of it
+			// is kept.
+			p.app.AgentCoordinator.Cancel(p.session.ID)
+			return p, util.ReportInfo("Summary canceled")
 		case key.Matches(msg, p.keyMap.Cancel):
 			if p.session.ID != "" && p.app.AgentCoordinator.IsBusy() {
 				return p, p.cancel()
@@ -401,6 +516,12 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		case key.Matches(msg, p.keyMap.Details):
 			p.toggleDetails()
 			return p, nil
+		case key.Matches(msg, p.keyMap.Reasoning):
+			return p, p.cycleReasoning()
+		case key.Matches(msg, messages.ContinueKey):
+			if p.canContinue() {
+				return p, p.sendMessage(agent.ContinuePrompt, nil)
+			}
 		}
 
 		switch p.focusedPane {
@@ -416,6 +537,10 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			u, cmd := p.splash.Update(msg)
 			p.splash = u.(splash.Splash)
 			cmds = append(cmds, cmd)
+		case PanelTypeFiles:
+			u, cmd := p.fileTree.Update(msg)
+			p.fileTree = u.(filetree.FileTree)
+			cmds = append(cmds, cmd)
 		}
 	case tea.PasteMsg:
 		switch p.focusedPane {
@@ -435,6 +560,15 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			cmds = append(cmds, cmd)
 			return p, tea.Batch(cmds...)
 		}
+	default:
+		// The panels next to the chat load files in the background and
+		// get back what they loaded.
+		u, cmd := p.fileView.Update(msg)
+		p.fileView = u.(fileview.FileView)
+		cmds = append(cmds, cmd)
+		u, cmd = p.fileTree.Update(msg)
+		p.fileTree = u.(filetree.FileTree)
+		cmds = append(cmds, cmd)
 	}
 	return p, tea.Batch(cmds...)
 }
@@ -473,6 +607,20 @@ func (p *chatPage) View() string {
 		}
 	} else {
 		messagesView := p.chat.View()
+		if p.fileViewVisible() {
+			messagesView = lipgloss.JoinHorizontal(
+				lipgloss.Top,
+				messagesView,
+				p.fileView.View(),
+			)
+		}
+		if p.fileTreeVisible() {
+			messagesView = lipgloss.JoinHorizontal(
+				lipgloss.Top,
+				messagesView,
+				p.fileTree.View(),
+			)
+		}
 		editorView := p.editor.View()
 		if p.compact {
 			headerView := p.header.View()
@@ -564,6 +712,31 @@ func (p *chatPage) toggleThinking() tea.Cmd {
 	}
 }
 
+// cycleReasoning moves the session to the next reasoning level, without
+// changing the configuration.
+func (p *chatPage) cycleReasoning() tea.Cmd {
+	if p.app.AgentCoordinator == nil {
+		return nil
+	}
+	cfg := config.Get()
+	model := cfg.GetModelByType(cfg.Agents[config.AgentCoder].Model)
+	if model == nil || !model.CanReason {
+		return util.ReportWarn("The current model doesn't support reasoning")
+	}
+	level := p.reasoning
+	if level == "" {
+		level, _ = p.app.AgentCoordinator.ReasoningLevel(p.session.ID)
+	}
+	level = level.Next()
+	if p.session.ID == "" {
+		p.reasoning = level
+	} else {
+		p.app.AgentCoordinator.SetReasoningLevel(p.session.ID, level)
+	}
+	p.sidebar.SetReasoningLevel(string(level))
+	return util.ReportInfo("Reasoning " + string(level) + " for this session")
+}
+
 func (p *chatPage) openReasoningDialog() tea.Cmd {
 	return func() tea.Msg {
 		cfg := config.Get()
@@ -611,6 +784,41 @@ func (p *chatPage) handleReasoningEffortSelected(effort string) tea.Cmd {
 	}
 }
 
+func (p *chatPage) updateFileViewConfig(show bool) tea.Cmd {
+	return func() tea.Msg {
+		if err := config.Get().SetFileView(show); err != nil {
+			return util.InfoMsg{
+				Type: util.InfoTypeError,
+				Msg:  "Failed to update file view configuration: " + err.Error(),
+			}
+		}
+		return nil
+	}
+}
+
+func (p *chatPage) updateFileTreeConfig(show bool) tea.Cmd {
+	return func() tea.Msg {
+		if err := config.Get().SetFileTree(show); err != nil {
+			return util.InfoMsg{
+				Type: util.InfoTypeError,
+				Msg:  "Failed to update file tree configuration: " + err.Error(),
+			}
+		}
+		return nil
+	}
+}
+
+// pinFile shows the file at path in the file view, showing the file view if
+// it is hidden.
+func (p *chatPage) pinFile(path string) tea.Cmd {
+	cmd := p.fileView.Pin(path)
+	if !p.showFileView {
+		p.showFileView = true
+		cmd = tea.Batch(cmd, p.SetSize(p.width, p.height), p.updateFileViewConfig(true))
+	}
+	return cmd
+}
+
 func (p *chatPage) setCompactMode(compact bool) {
 	if p.compact == compact {
 		return
@@ -627,12 +835,7 @@ func (p *chatPage) handleCompactMode(newWidth int, newHeight int) {
 	if p.forceCompact {
 		return
 	}
-	if (newWidth < CompactModeWidthBreakpoint || newHeight < CompactModeHeightBreakpoint) && !p.compact {
-		p.setCompactMode(true)
-	}
-	if (newWidth >= CompactModeWidthBreakpoint && newHeight >= CompactModeHeightBreakpoint) && p.compact {
-		p.setCompactMode(false)
-	}
+	p.setCompactMode(compactFor(p.compact, newWidth, newHeight, config.Get().Options.TUI.CompactLayout))
 }
 
 func (p *chatPage) SetSize(width, height int) tea.Cmd {
@@ -650,14 +853,24 @@ func (p *chatPage) SetSize(width, height int) tea.Cmd {
 			cmds = append(cmds, p.editor.SetPosition(0, height-EditorHeight))
 		}
 	} else {
+		chatWidth := p.chatWidth()
+		chatHeight := height - EditorHeight - p.panelsY()
+		if p.fileViewVisible() {
+			cmds = append(cmds, p.fileView.SetSize(p.fileViewWidth(), chatHeight))
+		}
+		if p.fileTreeVisible() {
+			cmds = append(cmds, p.fileTree.SetSize(FileTreeWidth, chatHeight))
+		} else if p.focusedPane == PanelTypeFiles {
+			p.focus(PanelTypeEditor)
+		}
 		if p.compact {
-			cmds = append(cmds, p.chat.SetSize(width, height-EditorHeight-HeaderHeight))
+			cmds = append(cmds, p.chat.SetSize(chatWidth, height-EditorHeight-HeaderHeight))
 			p.detailsWidth = width - DetailsPositioning
 			cmds = append(cmds, p.sidebar.SetSize(p.detailsWidth-LeftRightBorders, p.detailsHeight-TopBottomBorders))
 			cmds = append(cmds, p.editor.SetSize(width, EditorHeight))
 			cmds = append(cmds, p.header.SetWidth(width-BorderWidth))
 		} else {
-			cmds = append(cmds, p.chat.SetSize(width-SideBarWidth, height-EditorHeight))
+			cmds = append(cmds, p.chat.SetSize(chatWidth, height-EditorHeight))
 			cmds = append(cmds, p.editor.SetSize(width, EditorHeight))
 			cmds = append(cmds, p.sidebar.SetSize(SideBarWidth, height-EditorHeight))
 		}
@@ -678,6 +891,7 @@ func (p *chatPage) newSession() tea.Cmd {
 	p.isCanceling = false
 	return tea.Batch(
 		util.CmdHandler(chat.SessionClearedMsg{}),
+		p.editor.SetSession(p.session),
 		p.SetSize(p.width, p.height),
 	)
 }
@@ -689,12 +903,22 @@ func (p *chatPage) setSession(session session.Session) tea.Cmd {
 
 	var cmds []tea.Cmd
 	p.session = session
+	p.reasoning = ""
+	var level agent.ReasoningLevel
+	if p.app.AgentCoordinator != nil {
+		if l, ok := p.app.AgentCoordinator.ReasoningLevel(session.ID); ok && session.ID != "" {
+			level = l
+		}
+	}
+	p.sidebar.SetReasoningLevel(string(level))
 
 	cmds = append(cmds, p.SetSize(p.width, p.height))
 	cmds = append(cmds, p.chat.SetSession(session))
 	cmds = append(cmds, p.sidebar.SetSession(session))
 	cmds = append(cmds, p.header.SetSession(session))
 	cmds = append(cmds, p.editor.SetSession(session))
+	cmds = append(cmds, p.fileView.SetSession(session))
+	cmds = append(cmds, p.fileTree.SetSession(session))
 
 	return tea.Sequence(cmds...)
 }
@@ -705,16 +929,39 @@ func (p *chatPage) changeFocus() {
 	}
 	switch p.focusedPane {
 	case PanelTypeChat:
-		p.focusedPane = PanelTypeEditor
-		p.editor.Focus()
-		p.chat.Blur()
+		if p.fileTreeVisible() {
+			p.focus(PanelTypeFiles)
+		} else {
+			p.focus(PanelTypeEditor)
+		}
 	case PanelTypeEditor:
-		p.focusedPane = PanelTypeChat
+		p.focus(PanelTypeChat)
+	case PanelTypeFiles:
+		p.focus(PanelTypeEditor)
+	}
+}
+
+// focus moves the focus to the editor, the chat or the file tree.
+func (p *chatPage) focus(pane PanelType) {
+	p.focusedPane = pane
+	p.editor.Blur()
+	p.chat.Blur()
+	p.fileTree.Blur()
+	switch pane {
+	case PanelTypeEditor:
+		p.editor.Focus()
+	case PanelTypeChat:
 		p.chat.Focus()
-		p.editor.Blur()
+	case PanelTypeFiles:
+		p.fileTree.Focus()
 	}
 }
 
+// summarizing reports whether a summary of the session is streaming.
+func (p *chatPage) summarizing() bool {
+	return p.session.ID != "" && p.app.AgentCoordinator != nil && p.chat.Summarizing()
+}
+
 func (p *chatPage) cancel() tea.Cmd {
 	if p.isCanceling {
 		p.isCanceling = false
@@ -747,6 +994,26 @@ func (p *chatPage) toggleDetails() {
 	p.setShowDetails(!p.showingDetails)
 }
 
+// canContinue reports whether the last response in the session was cut off by
+// a dropped connection and can be continued.
+func (p *chatPage) canContinue() bool {
+	if p.session.ID == "" || p.app.AgentCoordinator == nil || p.app.AgentCoordinator.IsSessionBusy(p.session.ID) {
+		return false
+	}
+	msgs, err := p.app.Messages.List(context.Background(), p.session.ID)
+	if err != nil {
+		return false
+	}
+	// Tool results for calls that were cut off come after the assistant
+	// message, so skip over them.
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Role != message.Tool {
+			return msgs[i].FinishReason() == message.FinishReasonInterrupted
+		}
+	}
+	return false
+}
+
 func (p *chatPage) sendMessage(text string, attachments []message.Attachment) tea.Cmd {
 	session := p.session
 	var cmds []tea.Cmd
@@ -761,6 +1028,9 @@ func (p *chatPage) sendMessage(text string, attachments []message.Attachment) te
 	if p.app.AgentCoordinator == nil {
 		return util.ReportError(fmt.Errorf("coder agent is not initialized"))
 	}
+	if p.reasoning != "" && session.ID != p.session.ID {
+		p.app.AgentCoordinator.SetReasoningLevel(session.ID, p.reasoning)
+	}
 	cmds = append(cmds, p.chat.GoToBottom())
 	cmds = append(cmds, func() tea.Msg {
 		_, err := p.app.AgentCoordinator.Run(context.Background(), session.ID, text, attachments...)
@@ -780,12 +1050,64 @@ func (p *chatPage) sendMessage(text string, attachments []message.Attachment) te
 	return tea.Batch(cmds...)
 }
 
+// editMessage opens a dialog to edit the user message with messageID and
+// resend it, counting the later messages that resending discards.
+func (p *chatPage) editMessage(messageID string) tea.Cmd {
+	if p.session.ID == "" || p.app.AgentCoordinator == nil {
+		return nil
+	}
+	if p.app.AgentCoordinator.IsSessionBusy(p.session.ID) {
+		return util.ReportWarn("Agent is working, please wait...")
+	}
+	msgs, err := p.app.Messages.List(context.Background(), p.session.ID)
+	if err != nil {
+		return util.ReportError(err)
+	}
+	index := slices.IndexFunc(msgs, func(msg message.Message) bool { return msg.ID == messageID })
+	if index < 0 {
+		return nil
+	}
+	return util.CmdHandler(dialogs.OpenDialogMsg{
+		Model: resend.NewResendDialogCmp(p.session.ID, messageID, msgs[index].Content().Text, len(msgs)-index-1),
+	})
+}
+
+// runShellCommand runs a command from the editor without the agent and adds
+// its output to the session, which is created if there's none yet.
+func (p *chatPage) runShellCommand(command string) tea.Cmd {
+	session := p.session
+	var cmds []tea.Cmd
+	if session.ID == "" {
+		newSession, err := p.app.Sessions.Create(context.Background(), "New Session")
+		if err != nil {
+			return util.ReportError(err)
+		}
+		session = newSession
+		cmds = append(cmds, util.CmdHandler(chat.SessionSelectedMsg(session)))
+	} else if p.app.AgentCoordinator != nil && p.app.AgentCoordinator.IsSessionBusy(session.ID) {
+		return util.ReportWarn("Agent is busy, please wait before running a command...")
+	}
+	cmds = append(cmds, p.chat.GoToBottom(), util.ReportInfo("Running "+command))
+	cmds = append(cmds, func() tea.Msg {
+		if err := p.app.RunShellCommand(context.Background(), session.ID, command); err != nil {
+			return util.InfoMsg{
+				Type: util.InfoTypeError,
+				Msg:  err.Error(),
+			}
+		}
+		return nil
+	})
+	return tea.Batch(cmds...)
+}
+
 func (p *chatPage) Bindings() []key.Binding {
 	bindings := []key.Binding{
 		p.keyMap.NewSession,
 		p.keyMap.AddAttachment,
 	}
-	if p.app.AgentCoordinator != nil && p.app.AgentCoordinator.IsBusy() {
+	if p.summarizing() {
+		bindings = append([]key.Binding{messages.CancelSummaryKey}, bindings...)
+	} else if p.app.AgentCoordinator != nil && p.app.AgentCoordinator.IsBusy() {
 		cancelBinding := p.keyMap.Cancel
 		if p.isCanceling {
 			cancelBinding = key.NewBinding(
@@ -798,13 +1120,25 @@ func (p *chatPage) Bindings() []key.Binding {
 
 	switch p.focusedPane {
 	case PanelTypeChat:
+		tabHelp := "focus editor"
+		if p.fileTreeVisible() {
+			tabHelp = "focus files"
+		}
 		bindings = append([]key.Binding{
 			key.NewBinding(
 				key.WithKeys("tab"),
-				key.WithHelp("tab", "focus editor"),
+				key.WithHelp("tab", tabHelp),
 			),
 		}, bindings...)
 		bindings = append(bindings, p.chat.Bindings()...)
+	case PanelTypeFiles:
+		bindings = append([]key.Binding{
+			key.NewBinding(
+				key.WithKeys("tab"),
+				key.WithHelp("tab", "focus editor"),
+			),
+		}, bindings...)
+		bindings = append(bindings, p.fileTree.Bindings()...)
 	case PanelTypeEditor:
 		bindings = append([]key.Binding{
 			key.NewBinding(
@@ -1097,6 +1431,11 @@ func (p *chatPage) Help() help.KeyMap {
 				[]key.Binding{
 					messages.CopyKey,
 					messages.ClearSelectionKey,
+					messages.SkipToolKey,
+					messages.ExtendToolKey,
+					messages.OpenMatchesKey,
+					messages.UsageKey,
+					messages.EditKey,
 				},
 			)
 		case PanelTypeEditor:
@@ -1127,24 +1466,42 @@ func (p *chatPage) Help() help.KeyMap {
 						key.WithKeys("ctrl+o"),
 						key.WithHelp("ctrl+o", "open editor"),
 					),
-				})
-
-			if p.editor.HasAttachments() {
-				fullList = append(fullList, []key.Binding{
 					key.NewBinding(
-						key.WithKeys("ctrl+r"),
-						key.WithHelp("ctrl+r+{i}", "delete attachment at index i"),
+						key.WithKeys("up"),
+						key.WithHelp("↑", "previous prompt"),
 					),
 					key.NewBinding(
-						key.WithKeys("ctrl+r", "r"),
-						key.WithHelp("ctrl+r+r", "delete all attachments"),
+						key.WithKeys("alt+r"),
+						key.WithHelp("alt+r", "search prompts"),
 					),
 					key.NewBinding(
-						key.WithKeys("esc", "alt+esc"),
-						key.WithHelp("esc", "cancel delete mode"),
+						key.WithKeys("alt+e"),
+						key.WithHelp("alt+e", "expand pasted text"),
 					),
 				})
+
+			if p.editor.HasAttachments() {
+				fullList = append(fullList, []key.Binding{
+					editor.AttachmentsKeyMaps.AttachmentDeleteMode,
+					editor.AttachmentsKeyMaps.NextAttachment,
+					editor.AttachmentsKeyMaps.PreviewAttachment,
+					editor.AttachmentsKeyMaps.RemoveAttachment,
+					editor.AttachmentsKeyMaps.DeleteAllAttachments,
+					editor.AttachmentsKeyMaps.Escape,
+				})
 			}
+		case PanelTypeFiles:
+			shortList = append(shortList,
+				key.NewBinding(
+					key.WithKeys("enter"),
+					key.WithHelp("enter", "view"),
+				),
+				key.NewBinding(
+					key.WithKeys("a"),
+					key.WithHelp("a", "attach"),
+				),
+			)
+			fullList = append(fullList, p.fileTree.Bindings())
 		}
 		shortList = append(shortList,
 			// Quit
@@ -1184,16 +1541,91 @@ func (p *chatPage) isMouseOverChat(x, y int) bool {
 		// In compact mode: chat area starts after header and spans full width
 		chatX = 0
 		chatY = HeaderHeight
-		chatWidth = p.width
+		chatWidth = p.chatWidth()
 		chatHeight = p.height - EditorHeight - HeaderHeight
 	} else {
 		// In non-compact mode: chat area spans from left edge to sidebar
 		chatX = 0
 		chatY = 0
-		chatWidth = p.width - SideBarWidth
+		chatWidth = p.chatWidth()
 		chatHeight = p.height - EditorHeight
 	}
 
 	// Check if mouse coordinates are within chat bounds
 	return x >= chatX && x < chatX+chatWidth && y >= chatY && y < chatY+chatHeight
 }
+
+// panelsWidth returns the width left to the chat and the panels next to it.
+func (p *chatPage) panelsWidth() int {
+	if p.compact {
+		return p.width
+	}
+	return p.width - SideBarWidth
+}
+
+// panelsY returns the row the chat and the panels next to it start at.
+func (p *chatPage) panelsY() int {
+	if p.compact {
+		return HeaderHeight
+	}
+	return 0
+}
+
+// mainWidth returns the width left to the chat and the file view.
+func (p *chatPage) mainWidth() int {
+	return p.panelsWidth() - p.fileTreeWidth()
+}
+
+// fileTreeVisible reports whether the file tree is shown next to the chat,
+// which needs room for both.
+func (p *chatPage) fileTreeVisible() bool {
+	return p.showFileTree && p.session.ID != "" && p.panelsWidth() >= FileTreeWidth+FileViewMinWidth
+}
+
+func (p *chatPage) fileTreeWidth() int {
+	if !p.fileTreeVisible() {
+		return 0
+	}
+	return FileTreeWidth
+}
+
+// fileViewVisible reports whether the file view is shown next to the chat,
+// which needs room for both.
+func (p *chatPage) fileViewVisible() bool {
+	return p.showFileView && p.session.ID != "" && p.mainWidth() >= 2*FileViewMinWidth
+}
+
+func (p *chatPage) fileViewWidth() int {
+	if !p.fileViewVisible() {
+		return 0
+	}
+	return p.mainWidth() / 2
+}
+
+func (p *chatPage) chatWidth() int {
+	return p.mainWidth() - p.fileViewWidth()
+}
+
+// isMouseOverFileView checks if the given mouse coordinates are within the
+// file view next to the chat.
+func (p *chatPage) isMouseOverFileView(x, y int) bool {
+	if !p.fileViewVisible() {
+		return false
+	}
+	fileViewY, fileViewHeight := 0, p.height-EditorHeight
+	if p.compact {
+		fileViewY = HeaderHeight
+		fileViewHeight -= HeaderHeight
+	}
+	return x >= p.chatWidth() && x < p.mainWidth() && y >= fileViewY && y < fileViewY+fileViewHeight
+}
+
+// isMouseOverFileTree checks if the given mouse coordinates are within the
+// file tree next to the chat.
+func (p *chatPage) isMouseOverFileTree(x, y int) bool {
+	if !p.fileTreeVisible() {
+		return false
+	}
+	treeY := p.panelsY()
+	return x >= p.mainWidth() && x < p.panelsWidth() && y >= treeY && y < p.height-EditorHeight
+}
@@ -0,0 +1,17 @@
+package chat
+
+import "github.com/charmbracelet/crush/internal/config"
+
+// compactFor returns whether the layout is compact for a window of width by
+// height, given whether it's compact now. It switches to compact below the
+// thresholds of layout, and back only once the window is past them by the
+// hysteresis, so that resizing around a threshold doesn't flicker.
+func compactFor(compact bool, width, height int, layout config.CompactLayout) bool {
+	minWidth, minHeight, hysteresis := layout.Thresholds()
+	if !compact {
+		return (minWidth > 0 && width < minWidth) || (minHeight > 0 && height < minHeight)
+	}
+	wide := minWidth <= 0 || width >= minWidth+hysteresis
+	tall := minHeight <= 0 || height >= minHeight+hysteresis
+	return !wide || !tall
+}
@@ -0,0 +1,29 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompactFor(t *testing.T) {
+	t.Parallel()
+
+	var layout config.CompactLayout
+	require.False(t, compactFor(false, 120, 30, layout))
+	require.True(t, compactFor(false, 119, 40, layout))
+	require.True(t, compactFor(false, 200, 29, layout))
+
+	// Growing back past the threshold isn't enough to leave compact mode.
+	require.True(t, compactFor(true, 120, 30, layout))
+	require.True(t, compactFor(true, 123, 40, layout))
+	require.True(t, compactFor(true, 200, 33, layout))
+	require.False(t, compactFor(true, 124, 34, layout))
+
+	width, none := 80, 0
+	layout = config.CompactLayout{Width: &width, Height: &none, Hysteresis: &none}
+	require.False(t, compactFor(false, 80, 5, layout))
+	require.True(t, compactFor(false, 79, 100, layout))
+	require.False(t, compactFor(true, 80, 5, layout))
+}
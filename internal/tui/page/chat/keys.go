@@ -10,6 +10,7 @@ type KeyMap struct {
 	Cancel        key.Binding
 	Tab           key.Binding
 	Details       key.Binding
+	Reasoning     key.Binding
 }
 
 func DefaultKeyMap() KeyMap {
@@ -34,5 +35,9 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("ctrl+d"),
 			key.WithHelp("ctrl+d", "toggle details"),
 		),
+		Reasoning: key.NewBinding(
+			key.WithKeys("alt+t"),
+			key.WithHelp("alt+t", "cycle reasoning"),
+		),
 	}
 }
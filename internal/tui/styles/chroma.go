@@ -1,7 +1,11 @@
 package styles
 
 import (
+	"strings"
+
 	"github.com/alecthomas/chroma/v2"
+	chromaStyles "github.com/alecthomas/chroma/v2/styles"
+	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/glamour/v2/ansi"
 )
 
@@ -77,3 +81,26 @@ func GetChromaTheme() chroma.StyleEntries {
 		chroma.Background:          chromaStyle(rules.Chroma.Background),
 	}
 }
+
+// GetChromaStyle returns the chroma style to highlight code with: the one set
+// in the syntax options, or one made of the colors of the current theme.
+func GetChromaStyle() *chroma.Style {
+	if name := syntaxStyle(); name != "" {
+		return chromaStyles.Registry[name]
+	}
+	return chroma.MustNewStyle("crush", GetChromaTheme())
+}
+
+// syntaxStyle returns the name of the chroma style set in the syntax options,
+// or "" if none is set or there's no style with that name.
+func syntaxStyle() string {
+	cfg := config.Get()
+	if cfg == nil || cfg.Options == nil || cfg.Options.TUI == nil {
+		return ""
+	}
+	name := strings.ToLower(cfg.Options.TUI.Syntax.Style)
+	if _, ok := chromaStyles.Registry[name]; !ok {
+		return ""
+	}
+	return name
+}
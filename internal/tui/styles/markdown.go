@@ -22,8 +22,13 @@ func uintPtr(u uint) *uint       { return &u }
 // returns a glamour TermRenderer configured with the current theme
 func GetMarkdownRenderer(width int) *glamour.TermRenderer {
 	t := CurrentTheme()
+	style := t.S().Markdown
+	if name := syntaxStyle(); name != "" {
+		style.CodeBlock.Chroma = nil
+		style.CodeBlock.Theme = name
+	}
 	r, _ := glamour.NewTermRenderer(
-		glamour.WithStyles(t.S().Markdown),
+		glamour.WithStyles(style),
 		glamour.WithWordWrap(width),
 	)
 	return r
@@ -2,6 +2,7 @@ package tui
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"slices"
@@ -11,13 +12,22 @@ import (
 	"charm.land/bubbles/v2/key"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/agent"
 	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/app"
 	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/devcontainer"
 	"github.com/charmbracelet/crush/internal/event"
+	"github.com/charmbracelet/crush/internal/focus"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/notify"
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/trust"
+	"github.com/charmbracelet/crush/internal/tui/components/anim"
 	cmpChat "github.com/charmbracelet/crush/internal/tui/components/chat"
+	"github.com/charmbracelet/crush/internal/tui/components/chat/editor"
 	"github.com/charmbracelet/crush/internal/tui/components/chat/splash"
 	"github.com/charmbracelet/crush/internal/tui/components/completions"
 	"github.com/charmbracelet/crush/internal/tui/components/core"
@@ -25,11 +35,25 @@ import (
 	"github.com/charmbracelet/crush/internal/tui/components/core/status"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/commands"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/compare"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/contextpreview"
+	devcontainerdialog "github.com/charmbracelet/crush/internal/tui/components/dialogs/devcontainer"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/filepicker"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/initialize"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/mcpservers"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/models"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/permissions"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/pinfile"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/prompts"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/quit"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/recall"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/resend"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/retry"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/scope"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/sessions"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/settings"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/tags"
+	trustdialog "github.com/charmbracelet/crush/internal/tui/components/dialogs/trust"
 	"github.com/charmbracelet/crush/internal/tui/page"
 	"github.com/charmbracelet/crush/internal/tui/page/chat"
 	"github.com/charmbracelet/crush/internal/tui/styles"
@@ -84,6 +108,28 @@ type appModel struct {
 	// QueryVersion instructs the TUI to query for the terminal version when it
 	// starts.
 	QueryVersion bool
+
+	// Session is the session the TUI opens, when it isn't nil.
+	Session *session.Session
+	// Prompt is text the prompt starts with.
+	Prompt string
+
+	// Notifications are only sent while the terminal is unfocused.
+	notifier *notify.Notifier
+	blurred  bool
+	// notifiedMessageID is the last finished message a notification was
+	// sent for, as a message can be updated after it finished.
+	notifiedMessageID string
+
+	// pendingPermission is the last permission request shown, and
+	// waitingForApproval whether the status bar says it waits for an
+	// answer past the timeout.
+	pendingPermission  permission.PermissionRequest
+	waitingForApproval bool
+
+	// saving tells that Crush is quitting once the runs it stopped are
+	// saved.
+	saving bool
 }
 
 // Init initializes the application model and returns initial commands.
@@ -100,9 +146,29 @@ func (a appModel) Init() tea.Cmd {
 
 	cmd = a.status.Init()
 	cmds = append(cmds, cmd)
+	if a.app.Trust() == trust.Unknown {
+		// First run in this directory: ask whether to trust it.
+		cmds = append(cmds, util.CmdHandler(dialogs.OpenDialogMsg{
+			Model: trustdialog.NewTrustDialog(a.app),
+		}))
+	}
+	if cfg, choice := a.app.Devcontainer(); cfg != nil && choice == devcontainer.Unasked {
+		cmds = append(cmds, util.CmdHandler(dialogs.OpenDialogMsg{
+			Model: devcontainerdialog.NewDevcontainerDialog(a.app),
+		}))
+	}
+	if err := a.app.DevcontainerError(); err != nil {
+		cmds = append(cmds, util.ReportWarn("Tools run on the host: "+err.Error()))
+	}
 	if a.QueryVersion {
 		cmds = append(cmds, tea.RequestTerminalVersion)
 	}
+	if a.Session != nil {
+		cmds = append(cmds, util.CmdHandler(cmpChat.SessionSelectedMsg(*a.Session)))
+	}
+	if a.Prompt != "" {
+		cmds = append(cmds, util.CmdHandler(editor.InsertTextMsg{Text: a.Prompt}))
+	}
 
 	return tea.Batch(cmds...)
 }
@@ -144,16 +210,38 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.wWidth, a.wHeight = msg.Width, msg.Height
 		a.completions.Update(msg)
 		return a, a.handleWindowResize(msg.Width, msg.Height)
+	case tea.FocusMsg:
+		a.blurred = false
+		return a, nil
+	case tea.BlurMsg:
+		a.blurred = true
+		return a, nil
+
+	case pubsub.Event[config.ReloadEvent]:
+		return a, reportConfigReload(msg.Payload)
+	case trustdialog.TrustChangedMsg:
+		// The notification command of the project only runs once trusted.
+		a.notifier = notify.New(a.app.Config().Options.Notifications, a.app.Config().WorkingDir())
+		return a, nil
+	case pubsub.Event[agent.RequestQueue]:
+		return a, reportRequestQueue(msg.Payload)
 
 	case pubsub.Event[mcp.Event]:
 		switch msg.Payload.Type {
 		case mcp.EventStateChanged:
-			return a, a.handleStateChanged(context.Background())
+			cmds = append(cmds, a.handleStateChanged(context.Background()))
 		case mcp.EventPromptsListChanged:
-			return a, handleMCPPromptsEvent(context.Background(), msg.Payload.Name)
+			cmds = append(cmds, handleMCPPromptsEvent(context.Background(), msg.Payload.Name))
 		case mcp.EventToolsListChanged:
-			return a, handleMCPToolsEvent(context.Background(), msg.Payload.Name)
+			cmds = append(cmds, handleMCPToolsEvent(context.Background(), msg.Payload.Name))
 		}
+		// Dialogs like the MCP servers one show the state of the servers.
+		if a.dialog.HasDialogs() {
+			u, dialogCmd := a.dialog.Update(msg)
+			a.dialog = u.(dialogs.DialogCmp)
+			cmds = append(cmds, dialogCmd)
+		}
+		return a, tea.Batch(cmds...)
 
 	// Completions messages
 	case completions.OpenCompletionsMsg, completions.FilterCompletionsMsg,
@@ -222,20 +310,82 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, statusCmd)
 		return a, tea.Batch(cmds...)
 
+	case pubsub.Event[message.Message]:
+		cmds = append(cmds, a.notifyRunFinished(msg))
 	// Session
 	case cmpChat.SessionSelectedMsg:
 		a.selectedSessionID = msg.ID
+		a.status.SetSession(msg)
 	case cmpChat.SessionClearedMsg:
 		a.selectedSessionID = ""
+		a.status.SetSession(session.Session{})
 	// Commands
 	case commands.SwitchSessionsMsg:
 		return a, func() tea.Msg {
 			allSessions, _ := a.app.Sessions.List(context.Background())
 			return dialogs.OpenDialogMsg{
-				Model: sessions.NewSessionDialogCmp(allSessions, a.selectedSessionID),
+				Model: sessions.NewSessionDialogCmp(allSessions, a.selectedSessionID, a.app.Messages),
 			}
 		}
 
+	case commands.OpenRecallDialogMsg:
+		return a, util.CmdHandler(
+			dialogs.OpenDialogMsg{
+				Model: recall.NewRecallDialogCmp(a.app.Messages, a.app.Sessions),
+			},
+		)
+	case commands.OpenPromptHistoryMsg:
+		return a, util.CmdHandler(
+			dialogs.OpenDialogMsg{
+				Model: prompts.NewPromptsDialog(a.app.Prompts),
+			},
+		)
+
+	case commands.OpenSettingsDialogMsg:
+		if a.app.AgentCoordinator != nil && a.app.AgentCoordinator.IsBusy() {
+			return a, util.ReportWarn("Agent is busy, please wait...")
+		}
+		return a, util.CmdHandler(
+			dialogs.OpenDialogMsg{
+				Model: settings.NewSettingsDialogCmp(a.app.Permissions.SkipRequests()),
+			},
+		)
+	case commands.OpenScopeDialogMsg:
+		return a, util.CmdHandler(
+			dialogs.OpenDialogMsg{
+				Model: scope.NewScopeDialogCmp(a.app.AgentCoordinator, msg.SessionID, a.app.Config().WorkingDir()),
+			},
+		)
+	case commands.OpenContextPreviewDialogMsg:
+		sessionID := msg.SessionID
+		return a, func() tea.Msg {
+			preview, err := a.app.AgentCoordinator.ContextPreview(context.Background(), sessionID)
+			if err != nil {
+				return util.ReportError(err)()
+			}
+			return dialogs.OpenDialogMsg{Model: contextpreview.NewContextPreviewDialog(preview)}
+		}
+	case commands.OpenDevcontainerDialogMsg:
+		if cfg, _ := a.app.Devcontainer(); cfg == nil {
+			return a, util.ReportWarn("No devcontainer found in this project")
+		}
+		return a, util.CmdHandler(
+			dialogs.OpenDialogMsg{
+				Model: devcontainerdialog.NewDevcontainerDialog(a.app),
+			},
+		)
+	case commands.OpenMCPDialogMsg:
+		return a, util.CmdHandler(
+			dialogs.OpenDialogMsg{
+				Model: mcpservers.NewMCPServersDialogCmp(),
+			},
+		)
+	case settings.SettingsChangedMsg:
+		if msg.ReloadAgent && a.app.AgentCoordinator != nil {
+			go a.app.UpdateAgentModel(context.TODO())
+		}
+		return a, util.ReportInfo("Settings saved")
+
 	case commands.SwitchModelMsg:
 		return a, util.CmdHandler(
 			dialogs.OpenDialogMsg{
@@ -251,10 +401,66 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return nil
 		}
+	case commands.OpenRetryDialogMsg:
+		return a, util.CmdHandler(dialogs.OpenDialogMsg{
+			Model: retry.NewRetryDialog(msg.SessionID),
+		})
+	case commands.OpenCompareDialogMsg:
+		return a, util.CmdHandler(dialogs.OpenDialogMsg{
+			Model: compare.NewCompareDialog(),
+		})
+	case commands.OpenTagsDialogMsg:
+		return a, func() tea.Msg {
+			s, err := a.app.Sessions.Get(context.Background(), msg.SessionID)
+			if err != nil {
+				return util.ReportError(err)()
+			}
+			return dialogs.OpenDialogMsg{
+				Model: tags.NewTagsDialogCmp(a.app.Sessions, s),
+			}
+		}
+	case commands.OpenInitializeDialogMsg:
+		return a, util.CmdHandler(dialogs.OpenDialogMsg{
+			Model: initialize.NewInitializeDialog(),
+		})
+	case commands.OpenPinFileDialogMsg:
+		return a, util.CmdHandler(dialogs.OpenDialogMsg{
+			Model: pinfile.NewPinFileDialog(),
+		})
+	case commands.RetryMsg:
+		if a.app.AgentCoordinator.IsSessionBusy(msg.SessionID) {
+			return a, util.ReportWarn("Agent is working, please wait...")
+		}
+		return a, func() tea.Msg {
+			_, err := a.app.AgentCoordinator.Retry(context.Background(), msg.SessionID, msg.Model)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				return util.ReportError(err)()
+			}
+			return nil
+		}
+	case resend.ResendMsg:
+		if a.app.AgentCoordinator.IsSessionBusy(msg.SessionID) {
+			return a, util.ReportWarn("Agent is working, please wait...")
+		}
+		return a, func() tea.Msg {
+			_, err := a.app.AgentCoordinator.Resend(context.Background(), msg.SessionID, msg.MessageID, msg.Prompt)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				return util.ReportError(err)()
+			}
+			return nil
+		}
+	case focus.Request:
+		return a, util.CmdHandler(editor.InsertTextMsg{Text: msg.Prompt(a.app.Config().WorkingDir())})
+	case commands.RunMacroMsg:
+		return a, a.runMacro(msg.Name, msg.Steps)
+	case macroStepMsg:
+		return a, a.runMacro(msg.name, msg.steps)
 	case commands.QuitMsg:
 		return a, util.CmdHandler(dialogs.OpenDialogMsg{
 			Model: quit.NewQuitDialog(),
 		})
+	case quit.QuitMsg:
+		return a, a.quit()
 	case commands.ToggleYoloModeMsg:
 		a.app.Permissions.SetSkipRequests(!a.app.Permissions.SkipRequests())
 	case commands.ToggleHelpMsg:
@@ -279,6 +485,26 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			modelTypeName = "small"
 		}
 		return a, util.ReportInfo(fmt.Sprintf("%s model changed to %s", modelTypeName, msg.Model.Model))
+	case models.SwapModelsMsg:
+		if a.app.AgentCoordinator.IsBusy() {
+			return a, util.ReportWarn("Agent is busy, please wait...")
+		}
+
+		cfg := config.Get()
+		if err := cfg.SwapPreferredModels(); err != nil {
+			return a, util.ReportError(err)
+		}
+
+		go a.app.UpdateAgentModel(context.TODO())
+
+		return a, tea.Batch(
+			util.CmdHandler(models.ModelsSwappedMsg{}),
+			util.ReportInfo(fmt.Sprintf(
+				"large model changed to %s, small model changed to %s",
+				cfg.Models[config.SelectedModelTypeLarge].Model,
+				cfg.Models[config.SelectedModelTypeSmall].Model,
+			)),
+		)
 
 	// File Picker
 	case commands.OpenFilePickerMsg:
@@ -293,22 +519,27 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		})
 	// Permissions
 	case pubsub.Event[permission.PermissionNotification]:
+		timeoutCmd := a.handlePermissionTimeout(msg.Payload)
 		item, ok := a.pages[a.currentPage]
 		if !ok {
-			return a, nil
+			return a, timeoutCmd
 		}
 
 		// Forward to view.
 		updated, itemCmd := item.Update(msg)
 		a.pages[a.currentPage] = updated
 
-		return a, itemCmd
+		return a, tea.Batch(timeoutCmd, itemCmd)
 	case pubsub.Event[permission.PermissionRequest]:
-		return a, util.CmdHandler(dialogs.OpenDialogMsg{
-			Model: permissions.NewPermissionDialogCmp(msg.Payload, &permissions.Options{
-				DiffMode: config.Get().Options.TUI.DiffMode,
+		a.pendingPermission = msg.Payload
+		return a, tea.Batch(
+			a.notify("Permission required", fmt.Sprintf("Crush wants to use the %s tool", msg.Payload.ToolName)),
+			util.CmdHandler(dialogs.OpenDialogMsg{
+				Model: permissions.NewPermissionDialogCmp(msg.Payload, &permissions.Options{
+					DiffMode: config.Get().Options.TUI.DiffMode,
+				}),
 			}),
-		})
+		)
 	case permissions.PermissionResponseMsg:
 		switch msg.Action {
 		case permissions.PermissionAllow:
@@ -372,6 +603,16 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cmds = append(cmds, pageCmd)
 		}
 		return a, tea.Batch(cmds...)
+	case pubsub.ContextDriftMsg:
+		changes := msg.Changes
+		if len(changes) > 3 {
+			changes = append(changes[:3:3], fmt.Sprintf("%d more", len(msg.Changes)-3))
+		}
+		return a, util.CmdHandler(util.InfoMsg{
+			Type: util.InfoTypeWarn,
+			Msg:  fmt.Sprintf("%s may be out of date (%s). Run Update Project Context to review the changes.", msg.File, strings.Join(changes, ", ")),
+			TTL:  15 * time.Second,
+		})
 	// Update Available
 	case pubsub.UpdateAvailableMsg:
 		// Show update notification in status bar
@@ -387,8 +628,9 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.status = s.(status.StatusCmp)
 		return a, statusCmd
 	}
-	s, _ := a.status.Update(msg)
+	s, statusCmd := a.status.Update(msg)
 	a.status = s.(status.StatusCmp)
+	cmds = append(cmds, statusCmd)
 
 	item, ok := a.pages[a.currentPage]
 	if !ok {
@@ -452,13 +694,19 @@ func (a *appModel) handleWindowResize(width, height int) tea.Cmd {
 func (a *appModel) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 	// Check this first as the user should be able to quit no matter what.
 	if key.Matches(msg, a.keyMap.Quit) {
-		if a.dialog.ActiveDialogID() == quit.QuitDialogID {
+		if a.saving {
 			return tea.Quit
 		}
+		if a.dialog.ActiveDialogID() == quit.QuitDialogID {
+			return a.quit()
+		}
 		return util.CmdHandler(dialogs.OpenDialogMsg{
 			Model: quit.NewQuitDialog(),
 		})
 	}
+	if a.saving {
+		return nil
+	}
 
 	if a.completions.Open() {
 		// completions
@@ -528,7 +776,7 @@ func (a *appModel) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 			func() tea.Msg {
 				allSessions, _ := a.app.Sessions.List(context.Background())
 				return dialogs.OpenDialogMsg{
-					Model: sessions.NewSessionDialogCmp(allSessions, a.selectedSessionID),
+					Model: sessions.NewSessionDialogCmp(allSessions, a.selectedSessionID, a.app.Messages),
 				}
 			},
 		)
@@ -550,6 +798,19 @@ func (a *appModel) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 	}
 }
 
+// quit quits Crush. When the agent is busy, it first stops it and waits for
+// its messages to be saved, showing that it's saving meanwhile.
+func (a *appModel) quit() tea.Cmd {
+	if a.saving || a.app.AgentCoordinator == nil || !a.app.AgentCoordinator.IsBusy() {
+		return tea.Quit
+	}
+	a.saving = true
+	return func() tea.Msg {
+		a.app.AgentCoordinator.CancelAll()
+		return tea.QuitMsg{}
+	}
+}
+
 // moveToPage handles navigation between different pages in the application.
 func (a *appModel) moveToPage(pageID page.PageID) tea.Cmd {
 	if a.app.AgentCoordinator.IsBusy() {
@@ -578,6 +839,7 @@ func (a *appModel) View() tea.View {
 	var view tea.View
 	t := styles.CurrentTheme()
 	view.AltScreen = true
+	view.ReportFocus = a.notifier != nil
 	view.MouseMode = tea.MouseModeCellMotion
 	view.BackgroundColor = t.BgBase
 	if a.wWidth < 25 || a.wHeight < 15 {
@@ -638,6 +900,19 @@ func (a *appModel) View() tea.View {
 		}
 	}
 
+	if a.saving {
+		saving := t.S().Base.
+			Padding(1, 4).
+			Foreground(t.White).
+			BorderStyle(lipgloss.RoundedBorder()).
+			BorderForeground(t.Primary).
+			Render("Saving…")
+		x := (a.wWidth - lipgloss.Width(saving)) / 2
+		y := (a.wHeight - lipgloss.Height(saving)) / 2
+		layers = append(layers, lipgloss.NewLayer(saving).X(x).Y(y))
+		cursor = nil
+	}
+
 	if a.completions.Open() && cursor != nil {
 		cmp := a.completions.View()
 		x, y := a.completions.Position()
@@ -662,6 +937,101 @@ func (a *appModel) View() tea.View {
 	return view
 }
 
+// notify sends a notification if the terminal is unfocused.
+func (a *appModel) notify(title, body string) tea.Cmd {
+	if a.notifier == nil || !a.blurred {
+		return nil
+	}
+	var cmds []tea.Cmd
+	if seq := a.notifier.Sequence(title, body); seq != "" {
+		cmds = append(cmds, tea.Raw(seq))
+	}
+	cmds = append(cmds, func() tea.Msg {
+		a.notifier.Run(context.Background(), title, body)
+		return nil
+	})
+	return tea.Batch(cmds...)
+}
+
+// handlePermissionTimeout tells in the status bar and a notification that
+// the pending permission request went unanswered past the timeout, closing
+// its dialog when the timeout answered it.
+func (a *appModel) handlePermissionTimeout(n permission.PermissionNotification) tea.Cmd {
+	forPending := n.ToolCallID == a.pendingPermission.ToolCallID
+	if !n.TimedOut {
+		if forPending && a.waitingForApproval && (n.Granted || n.Denied) {
+			a.waitingForApproval = false
+			return util.CmdHandler(util.ClearStatusMsg{})
+		}
+		return nil
+	}
+
+	tool := "a tool"
+	if forPending {
+		tool = "the " + a.pendingPermission.ToolName + " tool"
+	}
+	waited := formatWaited(n.Waited)
+	if !n.Granted && !n.Denied {
+		a.waitingForApproval = forPending
+		body := fmt.Sprintf("Crush has been waiting for approval to use %s for %s", tool, waited)
+		return tea.Batch(
+			a.notify("Waiting for approval", body),
+			util.CmdHandler(util.InfoMsg{Type: util.InfoTypeWarn, Msg: body + ".", TTL: time.Hour}),
+		)
+	}
+
+	a.waitingForApproval = false
+	verb := "Denied"
+	if n.Granted {
+		verb = "Allowed"
+	}
+	body := fmt.Sprintf("%s %s after waiting %s for approval", verb, tool, waited)
+	cmds := []tea.Cmd{
+		a.notify("Permission timed out", body),
+		util.CmdHandler(util.InfoMsg{Type: util.InfoTypeWarn, Msg: body + ".", TTL: time.Minute}),
+	}
+	if forPending && a.dialog.ActiveDialogID() == permissions.PermissionsDialogID {
+		cmds = append(cmds, util.CmdHandler(dialogs.CloseDialogMsg{}))
+	}
+	return tea.Batch(cmds...)
+}
+
+// formatWaited formats how long a request waited, e.g. 5m or 1h30m.
+func formatWaited(d time.Duration) string {
+	s := d.Round(time.Second).String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// notifyRunFinished sends a notification when the agent finished working
+// on the selected session.
+func (a *appModel) notifyRunFinished(event pubsub.Event[message.Message]) tea.Cmd {
+	msg := event.Payload
+	if event.Type != pubsub.UpdatedEvent || msg.Role != message.Assistant ||
+		msg.SessionID != a.selectedSessionID || msg.ID == a.notifiedMessageID {
+		return nil
+	}
+	finish := msg.FinishPart()
+	if finish == nil {
+		return nil
+	}
+	switch finish.Reason {
+	case message.FinishReasonToolUse, message.FinishReasonCanceled:
+		// The agent is still working, or the user stopped it.
+		return nil
+	case message.FinishReasonError:
+		a.notifiedMessageID = msg.ID
+		return a.notify("Agent failed", finish.Message)
+	}
+	a.notifiedMessageID = msg.ID
+	return a.notify("Agent finished", "Crush is waiting for your input")
+}
+
 func (a *appModel) handleStateChanged(ctx context.Context) tea.Cmd {
 	return func() tea.Msg {
 		a.app.UpdateAgentModel(ctx)
@@ -669,6 +1039,36 @@ func (a *appModel) handleStateChanged(ctx context.Context) tea.Cmd {
 	}
 }
 
+// reportConfigReload tells the user what a configuration reload applied and
+// which changes need a restart.
+func reportConfigReload(event config.ReloadEvent) tea.Cmd {
+	switch {
+	case event.Err != nil:
+		return util.ReportWarn(fmt.Sprintf("Configuration not reloaded: %v", event.Err))
+	case len(event.RestartRequired) > 0:
+		msg := "Restart Crush to apply the changes to " + strings.Join(event.RestartRequired, ", ")
+		if len(event.Reloaded) > 0 {
+			msg = "Configuration reloaded: " + strings.Join(event.Reloaded, ", ") + ". " + msg
+		}
+		return util.ReportWarn(msg)
+	default:
+		return util.ReportInfo("Configuration reloaded: " + strings.Join(event.Reloaded, ", "))
+	}
+}
+
+// reportRequestQueue tells how many requests wait for a provider with
+// max_concurrent_requests.
+func reportRequestQueue(queue agent.RequestQueue) tea.Cmd {
+	if queue.Waiting == 0 {
+		return nil
+	}
+	requests := "requests wait"
+	if queue.Waiting == 1 {
+		requests = "request waits"
+	}
+	return util.ReportInfo(fmt.Sprintf("%d %s for %s, which takes %d at once", queue.Waiting, requests, queue.ProviderID, queue.Limit))
+}
+
 func handleMCPPromptsEvent(ctx context.Context, name string) tea.Cmd {
 	return func() tea.Msg {
 		mcp.RefreshPrompts(ctx, name)
@@ -685,6 +1085,7 @@ func handleMCPToolsEvent(ctx context.Context, name string) tea.Cmd {
 
 // New creates and initializes a new TUI application model.
 func New(app *app.App) *appModel {
+	anim.SetStatic(app.Config().Options.TUI.Accessible)
 	chatPage := chat.New(app)
 	keyMap := DefaultKeyMap()
 	keyMap.pageBindings = chatPage.Bindings()
@@ -692,7 +1093,7 @@ func New(app *app.App) *appModel {
 	model := &appModel{
 		currentPage: chat.ChatPageID,
 		app:         app,
-		status:      status.NewStatusCmp(),
+		status:      status.NewStatusCmp(app.LSPClients),
 		loadedPages: make(map[page.PageID]bool),
 		keyMap:      keyMap,
 
@@ -702,6 +1103,7 @@ func New(app *app.App) *appModel {
 
 		dialog:      dialogs.NewDialogCmp(),
 		completions: completions.New(),
+		notifier:    notify.New(app.Config().Options.Notifications, app.Config().WorkingDir()),
 	}
 
 	return model